@@ -0,0 +1,1585 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: chess.proto
+
+package chesspb
+
+import (
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CreateMatchRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// in hours, from 1 to 12
+	Duration int32 `protobuf:"varint,1,opt,name=duration,proto3" json:"duration,omitempty"`
+	// rated matches cannot be joined by guests
+	Rated         bool `protobuf:"varint,2,opt,name=rated,proto3" json:"rated,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateMatchRequest) Reset() {
+	*x = CreateMatchRequest{}
+	mi := &file_chess_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateMatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateMatchRequest) ProtoMessage() {}
+
+func (x *CreateMatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chess_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateMatchRequest.ProtoReflect.Descriptor instead.
+func (*CreateMatchRequest) Descriptor() ([]byte, []int) {
+	return file_chess_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CreateMatchRequest) GetDuration() int32 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+func (x *CreateMatchRequest) GetRated() bool {
+	if x != nil {
+		return x.Rated
+	}
+	return false
+}
+
+type CreateMatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MatchId       string                 `protobuf:"bytes,1,opt,name=match_id,json=matchId,proto3" json:"match_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateMatchResponse) Reset() {
+	*x = CreateMatchResponse{}
+	mi := &file_chess_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateMatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateMatchResponse) ProtoMessage() {}
+
+func (x *CreateMatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_chess_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateMatchResponse.ProtoReflect.Descriptor instead.
+func (*CreateMatchResponse) Descriptor() ([]byte, []int) {
+	return file_chess_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreateMatchResponse) GetMatchId() string {
+	if x != nil {
+		return x.MatchId
+	}
+	return ""
+}
+
+type JoinMatchRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	MatchId string                 `protobuf:"bytes,1,opt,name=match_id,json=matchId,proto3" json:"match_id,omitempty"`
+	// play black instead of white, ignored if you are not the first one to join
+	BlackPieces   bool `protobuf:"varint,2,opt,name=black_pieces,json=blackPieces,proto3" json:"black_pieces,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JoinMatchRequest) Reset() {
+	*x = JoinMatchRequest{}
+	mi := &file_chess_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JoinMatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JoinMatchRequest) ProtoMessage() {}
+
+func (x *JoinMatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chess_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JoinMatchRequest.ProtoReflect.Descriptor instead.
+func (*JoinMatchRequest) Descriptor() ([]byte, []int) {
+	return file_chess_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *JoinMatchRequest) GetMatchId() string {
+	if x != nil {
+		return x.MatchId
+	}
+	return ""
+}
+
+func (x *JoinMatchRequest) GetBlackPieces() bool {
+	if x != nil {
+		return x.BlackPieces
+	}
+	return false
+}
+
+// MatchEvent is an event of a match you play in, see game.Event
+type MatchEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*MatchEvent_Opponent
+	//	*MatchEvent_Move
+	//	*MatchEvent_Resign
+	//	*MatchEvent_Chat
+	//	*MatchEvent_Aborted
+	//	*MatchEvent_Adjudicated
+	//	*MatchEvent_ServerRestarting
+	//	*MatchEvent_Resync
+	//	*MatchEvent_Opening
+	//	*MatchEvent_OpponentPresence
+	//	*MatchEvent_Spectators
+	Event         isMatchEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MatchEvent) Reset() {
+	*x = MatchEvent{}
+	mi := &file_chess_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MatchEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MatchEvent) ProtoMessage() {}
+
+func (x *MatchEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_chess_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MatchEvent.ProtoReflect.Descriptor instead.
+func (*MatchEvent) Descriptor() ([]byte, []int) {
+	return file_chess_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *MatchEvent) GetEvent() isMatchEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *MatchEvent) GetOpponent() *OpponentJoined {
+	if x != nil {
+		if x, ok := x.Event.(*MatchEvent_Opponent); ok {
+			return x.Opponent
+		}
+	}
+	return nil
+}
+
+func (x *MatchEvent) GetMove() *MovePlayed {
+	if x != nil {
+		if x, ok := x.Event.(*MatchEvent_Move); ok {
+			return x.Move
+		}
+	}
+	return nil
+}
+
+func (x *MatchEvent) GetResign() *Resigned {
+	if x != nil {
+		if x, ok := x.Event.(*MatchEvent_Resign); ok {
+			return x.Resign
+		}
+	}
+	return nil
+}
+
+func (x *MatchEvent) GetChat() *ChatMessage {
+	if x != nil {
+		if x, ok := x.Event.(*MatchEvent_Chat); ok {
+			return x.Chat
+		}
+	}
+	return nil
+}
+
+func (x *MatchEvent) GetAborted() *Aborted {
+	if x != nil {
+		if x, ok := x.Event.(*MatchEvent_Aborted); ok {
+			return x.Aborted
+		}
+	}
+	return nil
+}
+
+func (x *MatchEvent) GetAdjudicated() *Adjudicated {
+	if x != nil {
+		if x, ok := x.Event.(*MatchEvent_Adjudicated); ok {
+			return x.Adjudicated
+		}
+	}
+	return nil
+}
+
+func (x *MatchEvent) GetServerRestarting() *ServerRestarting {
+	if x != nil {
+		if x, ok := x.Event.(*MatchEvent_ServerRestarting); ok {
+			return x.ServerRestarting
+		}
+	}
+	return nil
+}
+
+func (x *MatchEvent) GetResync() *Resync {
+	if x != nil {
+		if x, ok := x.Event.(*MatchEvent_Resync); ok {
+			return x.Resync
+		}
+	}
+	return nil
+}
+
+func (x *MatchEvent) GetOpening() *OpeningDetected {
+	if x != nil {
+		if x, ok := x.Event.(*MatchEvent_Opening); ok {
+			return x.Opening
+		}
+	}
+	return nil
+}
+
+func (x *MatchEvent) GetOpponentPresence() *OpponentPresence {
+	if x != nil {
+		if x, ok := x.Event.(*MatchEvent_OpponentPresence); ok {
+			return x.OpponentPresence
+		}
+	}
+	return nil
+}
+
+func (x *MatchEvent) GetSpectators() *Spectators {
+	if x != nil {
+		if x, ok := x.Event.(*MatchEvent_Spectators); ok {
+			return x.Spectators
+		}
+	}
+	return nil
+}
+
+type isMatchEvent_Event interface {
+	isMatchEvent_Event()
+}
+
+type MatchEvent_Opponent struct {
+	Opponent *OpponentJoined `protobuf:"bytes,1,opt,name=opponent,proto3,oneof"`
+}
+
+type MatchEvent_Move struct {
+	Move *MovePlayed `protobuf:"bytes,2,opt,name=move,proto3,oneof"`
+}
+
+type MatchEvent_Resign struct {
+	Resign *Resigned `protobuf:"bytes,3,opt,name=resign,proto3,oneof"`
+}
+
+type MatchEvent_Chat struct {
+	Chat *ChatMessage `protobuf:"bytes,4,opt,name=chat,proto3,oneof"`
+}
+
+type MatchEvent_Aborted struct {
+	Aborted *Aborted `protobuf:"bytes,5,opt,name=aborted,proto3,oneof"`
+}
+
+type MatchEvent_Adjudicated struct {
+	Adjudicated *Adjudicated `protobuf:"bytes,6,opt,name=adjudicated,proto3,oneof"`
+}
+
+type MatchEvent_ServerRestarting struct {
+	ServerRestarting *ServerRestarting `protobuf:"bytes,7,opt,name=server_restarting,json=serverRestarting,proto3,oneof"`
+}
+
+type MatchEvent_Resync struct {
+	Resync *Resync `protobuf:"bytes,8,opt,name=resync,proto3,oneof"`
+}
+
+type MatchEvent_Opening struct {
+	Opening *OpeningDetected `protobuf:"bytes,9,opt,name=opening,proto3,oneof"`
+}
+
+type MatchEvent_OpponentPresence struct {
+	OpponentPresence *OpponentPresence `protobuf:"bytes,10,opt,name=opponent_presence,json=opponentPresence,proto3,oneof"`
+}
+
+type MatchEvent_Spectators struct {
+	Spectators *Spectators `protobuf:"bytes,11,opt,name=spectators,proto3,oneof"`
+}
+
+func (*MatchEvent_Opponent) isMatchEvent_Event() {}
+
+func (*MatchEvent_Move) isMatchEvent_Event() {}
+
+func (*MatchEvent_Resign) isMatchEvent_Event() {}
+
+func (*MatchEvent_Chat) isMatchEvent_Event() {}
+
+func (*MatchEvent_Aborted) isMatchEvent_Event() {}
+
+func (*MatchEvent_Adjudicated) isMatchEvent_Event() {}
+
+func (*MatchEvent_ServerRestarting) isMatchEvent_Event() {}
+
+func (*MatchEvent_Resync) isMatchEvent_Event() {}
+
+func (*MatchEvent_Opening) isMatchEvent_Event() {}
+
+func (*MatchEvent_OpponentPresence) isMatchEvent_Event() {}
+
+func (*MatchEvent_Spectators) isMatchEvent_Event() {}
+
+type OpponentJoined struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Username string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	// the opponent plays the black pieces
+	Black bool `protobuf:"varint,2,opt,name=black,proto3" json:"black,omitempty"`
+	// when the match was created
+	StartTime *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	// when the match is deleted if the game does not end
+	EndTime       *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OpponentJoined) Reset() {
+	*x = OpponentJoined{}
+	mi := &file_chess_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OpponentJoined) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpponentJoined) ProtoMessage() {}
+
+func (x *OpponentJoined) ProtoReflect() protoreflect.Message {
+	mi := &file_chess_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpponentJoined.ProtoReflect.Descriptor instead.
+func (*OpponentJoined) Descriptor() ([]byte, []int) {
+	return file_chess_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *OpponentJoined) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *OpponentJoined) GetBlack() bool {
+	if x != nil {
+		return x.Black
+	}
+	return false
+}
+
+func (x *OpponentJoined) GetStartTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartTime
+	}
+	return nil
+}
+
+func (x *OpponentJoined) GetEndTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EndTime
+	}
+	return nil
+}
+
+type MovePlayed struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// in UCI notation, like e2e4
+	Move string `protobuf:"bytes,1,opt,name=move,proto3" json:"move,omitempty"`
+	// 1 for white's first move. Only set on WatchMatch streams.
+	Ply int32 `protobuf:"varint,2,opt,name=ply,proto3" json:"ply,omitempty"`
+	// position after the move
+	Fen string `protobuf:"bytes,3,opt,name=fen,proto3" json:"fen,omitempty"`
+	// in standard algebraic notation, like e4. Only set on JoinMatch streams, like the flags below.
+	San           string `protobuf:"bytes,4,opt,name=san,proto3" json:"san,omitempty"`
+	Check         bool   `protobuf:"varint,5,opt,name=check,proto3" json:"check,omitempty"`
+	Checkmate     bool   `protobuf:"varint,6,opt,name=checkmate,proto3" json:"checkmate,omitempty"`
+	Capture       bool   `protobuf:"varint,7,opt,name=capture,proto3" json:"capture,omitempty"`
+	Castle        bool   `protobuf:"varint,8,opt,name=castle,proto3" json:"castle,omitempty"`
+	Promotion     bool   `protobuf:"varint,9,opt,name=promotion,proto3" json:"promotion,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MovePlayed) Reset() {
+	*x = MovePlayed{}
+	mi := &file_chess_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MovePlayed) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MovePlayed) ProtoMessage() {}
+
+func (x *MovePlayed) ProtoReflect() protoreflect.Message {
+	mi := &file_chess_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MovePlayed.ProtoReflect.Descriptor instead.
+func (*MovePlayed) Descriptor() ([]byte, []int) {
+	return file_chess_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *MovePlayed) GetMove() string {
+	if x != nil {
+		return x.Move
+	}
+	return ""
+}
+
+func (x *MovePlayed) GetPly() int32 {
+	if x != nil {
+		return x.Ply
+	}
+	return 0
+}
+
+func (x *MovePlayed) GetFen() string {
+	if x != nil {
+		return x.Fen
+	}
+	return ""
+}
+
+func (x *MovePlayed) GetSan() string {
+	if x != nil {
+		return x.San
+	}
+	return ""
+}
+
+func (x *MovePlayed) GetCheck() bool {
+	if x != nil {
+		return x.Check
+	}
+	return false
+}
+
+func (x *MovePlayed) GetCheckmate() bool {
+	if x != nil {
+		return x.Checkmate
+	}
+	return false
+}
+
+func (x *MovePlayed) GetCapture() bool {
+	if x != nil {
+		return x.Capture
+	}
+	return false
+}
+
+func (x *MovePlayed) GetCastle() bool {
+	if x != nil {
+		return x.Castle
+	}
+	return false
+}
+
+func (x *MovePlayed) GetPromotion() bool {
+	if x != nil {
+		return x.Promotion
+	}
+	return false
+}
+
+// the opponent resigned or left
+type Resigned struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Resigned) Reset() {
+	*x = Resigned{}
+	mi := &file_chess_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Resigned) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Resigned) ProtoMessage() {}
+
+func (x *Resigned) ProtoReflect() protoreflect.Message {
+	mi := &file_chess_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Resigned.ProtoReflect.Descriptor instead.
+func (*Resigned) Descriptor() ([]byte, []int) {
+	return file_chess_proto_rawDescGZIP(), []int{6}
+}
+
+type ChatMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatMessage) Reset() {
+	*x = ChatMessage{}
+	mi := &file_chess_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatMessage) ProtoMessage() {}
+
+func (x *ChatMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_chess_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatMessage.ProtoReflect.Descriptor instead.
+func (*ChatMessage) Descriptor() ([]byte, []int) {
+	return file_chess_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ChatMessage) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// an admin deleted the match
+type Aborted struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Aborted) Reset() {
+	*x = Aborted{}
+	mi := &file_chess_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Aborted) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Aborted) ProtoMessage() {}
+
+func (x *Aborted) ProtoReflect() protoreflect.Message {
+	mi := &file_chess_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Aborted.ProtoReflect.Descriptor instead.
+func (*Aborted) Descriptor() ([]byte, []int) {
+	return file_chess_proto_rawDescGZIP(), []int{8}
+}
+
+// an admin decided the result of the match
+type Adjudicated struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// like 1-0
+	Result        string `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Adjudicated) Reset() {
+	*x = Adjudicated{}
+	mi := &file_chess_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Adjudicated) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Adjudicated) ProtoMessage() {}
+
+func (x *Adjudicated) ProtoReflect() protoreflect.Message {
+	mi := &file_chess_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Adjudicated.ProtoReflect.Descriptor instead.
+func (*Adjudicated) Descriptor() ([]byte, []int) {
+	return file_chess_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Adjudicated) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+// the server is shutting down, join the match again once it is back
+type ServerRestarting struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServerRestarting) Reset() {
+	*x = ServerRestarting{}
+	mi := &file_chess_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerRestarting) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerRestarting) ProtoMessage() {}
+
+func (x *ServerRestarting) ProtoReflect() protoreflect.Message {
+	mi := &file_chess_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerRestarting.ProtoReflect.Descriptor instead.
+func (*ServerRestarting) Descriptor() ([]byte, []int) {
+	return file_chess_proto_rawDescGZIP(), []int{10}
+}
+
+// you did not read events fast enough and missed some. The stream ends,
+// continue from the position in fen and join the match again.
+type Resync struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Fen           string                 `protobuf:"bytes,1,opt,name=fen,proto3" json:"fen,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Resync) Reset() {
+	*x = Resync{}
+	mi := &file_chess_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Resync) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Resync) ProtoMessage() {}
+
+func (x *Resync) ProtoReflect() protoreflect.Message {
+	mi := &file_chess_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Resync.ProtoReflect.Descriptor instead.
+func (*Resync) Descriptor() ([]byte, []int) {
+	return file_chess_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Resync) GetFen() string {
+	if x != nil {
+		return x.Fen
+	}
+	return ""
+}
+
+// the game left the opening book
+type OpeningDetected struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Eco           string                 `protobuf:"bytes,1,opt,name=eco,proto3" json:"eco,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OpeningDetected) Reset() {
+	*x = OpeningDetected{}
+	mi := &file_chess_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OpeningDetected) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpeningDetected) ProtoMessage() {}
+
+func (x *OpeningDetected) ProtoReflect() protoreflect.Message {
+	mi := &file_chess_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpeningDetected.ProtoReflect.Descriptor instead.
+func (*OpeningDetected) Descriptor() ([]byte, []int) {
+	return file_chess_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *OpeningDetected) GetEco() string {
+	if x != nil {
+		return x.Eco
+	}
+	return ""
+}
+
+func (x *OpeningDetected) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// the opponent's stream ended without resigning, or they joined the match again
+type OpponentPresence struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Connected     bool                   `protobuf:"varint,1,opt,name=connected,proto3" json:"connected,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OpponentPresence) Reset() {
+	*x = OpponentPresence{}
+	mi := &file_chess_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OpponentPresence) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpponentPresence) ProtoMessage() {}
+
+func (x *OpponentPresence) ProtoReflect() protoreflect.Message {
+	mi := &file_chess_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpponentPresence.ProtoReflect.Descriptor instead.
+func (*OpponentPresence) Descriptor() ([]byte, []int) {
+	return file_chess_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *OpponentPresence) GetConnected() bool {
+	if x != nil {
+		return x.Connected
+	}
+	return false
+}
+
+// someone started or stopped watching the match
+type Spectators struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Joined bool                   `protobuf:"varint,1,opt,name=joined,proto3" json:"joined,omitempty"`
+	// how many people watch the match now
+	Count         int32 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Spectators) Reset() {
+	*x = Spectators{}
+	mi := &file_chess_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Spectators) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Spectators) ProtoMessage() {}
+
+func (x *Spectators) ProtoReflect() protoreflect.Message {
+	mi := &file_chess_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Spectators.ProtoReflect.Descriptor instead.
+func (*Spectators) Descriptor() ([]byte, []int) {
+	return file_chess_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Spectators) GetJoined() bool {
+	if x != nil {
+		return x.Joined
+	}
+	return false
+}
+
+func (x *Spectators) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type SubmitMoveRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	MatchId string                 `protobuf:"bytes,1,opt,name=match_id,json=matchId,proto3" json:"match_id,omitempty"`
+	// in UCI notation, like e2e4
+	Move          string `protobuf:"bytes,2,opt,name=move,proto3" json:"move,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitMoveRequest) Reset() {
+	*x = SubmitMoveRequest{}
+	mi := &file_chess_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitMoveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitMoveRequest) ProtoMessage() {}
+
+func (x *SubmitMoveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chess_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitMoveRequest.ProtoReflect.Descriptor instead.
+func (*SubmitMoveRequest) Descriptor() ([]byte, []int) {
+	return file_chess_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *SubmitMoveRequest) GetMatchId() string {
+	if x != nil {
+		return x.MatchId
+	}
+	return ""
+}
+
+func (x *SubmitMoveRequest) GetMove() string {
+	if x != nil {
+		return x.Move
+	}
+	return ""
+}
+
+type SubmitMoveResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitMoveResponse) Reset() {
+	*x = SubmitMoveResponse{}
+	mi := &file_chess_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitMoveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitMoveResponse) ProtoMessage() {}
+
+func (x *SubmitMoveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_chess_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitMoveResponse.ProtoReflect.Descriptor instead.
+func (*SubmitMoveResponse) Descriptor() ([]byte, []int) {
+	return file_chess_proto_rawDescGZIP(), []int{16}
+}
+
+type WatchMatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MatchId       string                 `protobuf:"bytes,1,opt,name=match_id,json=matchId,proto3" json:"match_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchMatchRequest) Reset() {
+	*x = WatchMatchRequest{}
+	mi := &file_chess_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchMatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchMatchRequest) ProtoMessage() {}
+
+func (x *WatchMatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chess_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchMatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchMatchRequest) Descriptor() ([]byte, []int) {
+	return file_chess_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *WatchMatchRequest) GetMatchId() string {
+	if x != nil {
+		return x.MatchId
+	}
+	return ""
+}
+
+// WatchEvent is an event of a match you watch
+type WatchEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*WatchEvent_State
+	//	*WatchEvent_Move
+	//	*WatchEvent_GameOver
+	Event         isWatchEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEvent) Reset() {
+	*x = WatchEvent{}
+	mi := &file_chess_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEvent) ProtoMessage() {}
+
+func (x *WatchEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_chess_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEvent.ProtoReflect.Descriptor instead.
+func (*WatchEvent) Descriptor() ([]byte, []int) {
+	return file_chess_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *WatchEvent) GetEvent() isWatchEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *WatchEvent) GetState() *MatchState {
+	if x != nil {
+		if x, ok := x.Event.(*WatchEvent_State); ok {
+			return x.State
+		}
+	}
+	return nil
+}
+
+func (x *WatchEvent) GetMove() *MovePlayed {
+	if x != nil {
+		if x, ok := x.Event.(*WatchEvent_Move); ok {
+			return x.Move
+		}
+	}
+	return nil
+}
+
+func (x *WatchEvent) GetGameOver() *GameOver {
+	if x != nil {
+		if x, ok := x.Event.(*WatchEvent_GameOver); ok {
+			return x.GameOver
+		}
+	}
+	return nil
+}
+
+type isWatchEvent_Event interface {
+	isWatchEvent_Event()
+}
+
+type WatchEvent_State struct {
+	State *MatchState `protobuf:"bytes,1,opt,name=state,proto3,oneof"`
+}
+
+type WatchEvent_Move struct {
+	Move *MovePlayed `protobuf:"bytes,2,opt,name=move,proto3,oneof"`
+}
+
+type WatchEvent_GameOver struct {
+	GameOver *GameOver `protobuf:"bytes,3,opt,name=game_over,json=gameOver,proto3,oneof"`
+}
+
+func (*WatchEvent_State) isWatchEvent_Event() {}
+
+func (*WatchEvent_Move) isWatchEvent_Event() {}
+
+func (*WatchEvent_GameOver) isWatchEvent_Event() {}
+
+// MatchState is the match when you started watching it
+type MatchState struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	MatchId string                 `protobuf:"bytes,1,opt,name=match_id,json=matchId,proto3" json:"match_id,omitempty"`
+	// empty until the player joined
+	White string `protobuf:"bytes,2,opt,name=white,proto3" json:"white,omitempty"`
+	Black string `protobuf:"bytes,3,opt,name=black,proto3" json:"black,omitempty"`
+	Rated bool   `protobuf:"varint,4,opt,name=rated,proto3" json:"rated,omitempty"`
+	// moves so far in UCI notation
+	Moves         []string               `protobuf:"bytes,5,rep,name=moves,proto3" json:"moves,omitempty"`
+	Fen           string                 `protobuf:"bytes,6,opt,name=fen,proto3" json:"fen,omitempty"`
+	StartTime     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	EndTime       *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MatchState) Reset() {
+	*x = MatchState{}
+	mi := &file_chess_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MatchState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MatchState) ProtoMessage() {}
+
+func (x *MatchState) ProtoReflect() protoreflect.Message {
+	mi := &file_chess_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MatchState.ProtoReflect.Descriptor instead.
+func (*MatchState) Descriptor() ([]byte, []int) {
+	return file_chess_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *MatchState) GetMatchId() string {
+	if x != nil {
+		return x.MatchId
+	}
+	return ""
+}
+
+func (x *MatchState) GetWhite() string {
+	if x != nil {
+		return x.White
+	}
+	return ""
+}
+
+func (x *MatchState) GetBlack() string {
+	if x != nil {
+		return x.Black
+	}
+	return ""
+}
+
+func (x *MatchState) GetRated() bool {
+	if x != nil {
+		return x.Rated
+	}
+	return false
+}
+
+func (x *MatchState) GetMoves() []string {
+	if x != nil {
+		return x.Moves
+	}
+	return nil
+}
+
+func (x *MatchState) GetFen() string {
+	if x != nil {
+		return x.Fen
+	}
+	return ""
+}
+
+func (x *MatchState) GetStartTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartTime
+	}
+	return nil
+}
+
+func (x *MatchState) GetEndTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EndTime
+	}
+	return nil
+}
+
+type GameOver struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// like 1-0, 0-1 or 1/2-1/2
+	Result        string `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GameOver) Reset() {
+	*x = GameOver{}
+	mi := &file_chess_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GameOver) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GameOver) ProtoMessage() {}
+
+func (x *GameOver) ProtoReflect() protoreflect.Message {
+	mi := &file_chess_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GameOver.ProtoReflect.Descriptor instead.
+func (*GameOver) Descriptor() ([]byte, []int) {
+	return file_chess_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GameOver) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+var File_chess_proto protoreflect.FileDescriptor
+
+const file_chess_proto_rawDesc = "" +
+	"\n" +
+	"\vchess.proto\x12\x05chess\x1a\x1fgoogle/protobuf/timestamp.proto\"F\n" +
+	"\x12CreateMatchRequest\x12\x1a\n" +
+	"\bduration\x18\x01 \x01(\x05R\bduration\x12\x14\n" +
+	"\x05rated\x18\x02 \x01(\bR\x05rated\"0\n" +
+	"\x13CreateMatchResponse\x12\x19\n" +
+	"\bmatch_id\x18\x01 \x01(\tR\amatchId\"P\n" +
+	"\x10JoinMatchRequest\x12\x19\n" +
+	"\bmatch_id\x18\x01 \x01(\tR\amatchId\x12!\n" +
+	"\fblack_pieces\x18\x02 \x01(\bR\vblackPieces\"\xce\x04\n" +
+	"\n" +
+	"MatchEvent\x123\n" +
+	"\bopponent\x18\x01 \x01(\v2\x15.chess.OpponentJoinedH\x00R\bopponent\x12'\n" +
+	"\x04move\x18\x02 \x01(\v2\x11.chess.MovePlayedH\x00R\x04move\x12)\n" +
+	"\x06resign\x18\x03 \x01(\v2\x0f.chess.ResignedH\x00R\x06resign\x12(\n" +
+	"\x04chat\x18\x04 \x01(\v2\x12.chess.ChatMessageH\x00R\x04chat\x12*\n" +
+	"\aaborted\x18\x05 \x01(\v2\x0e.chess.AbortedH\x00R\aaborted\x126\n" +
+	"\vadjudicated\x18\x06 \x01(\v2\x12.chess.AdjudicatedH\x00R\vadjudicated\x12F\n" +
+	"\x11server_restarting\x18\a \x01(\v2\x17.chess.ServerRestartingH\x00R\x10serverRestarting\x12'\n" +
+	"\x06resync\x18\b \x01(\v2\r.chess.ResyncH\x00R\x06resync\x122\n" +
+	"\aopening\x18\t \x01(\v2\x16.chess.OpeningDetectedH\x00R\aopening\x12F\n" +
+	"\x11opponent_presence\x18\n" +
+	" \x01(\v2\x17.chess.OpponentPresenceH\x00R\x10opponentPresence\x123\n" +
+	"\n" +
+	"spectators\x18\v \x01(\v2\x11.chess.SpectatorsH\x00R\n" +
+	"spectatorsB\a\n" +
+	"\x05event\"\xb4\x01\n" +
+	"\x0eOpponentJoined\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x14\n" +
+	"\x05black\x18\x02 \x01(\bR\x05black\x129\n" +
+	"\n" +
+	"start_time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tstartTime\x125\n" +
+	"\bend_time\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\aendTime\"\xda\x01\n" +
+	"\n" +
+	"MovePlayed\x12\x12\n" +
+	"\x04move\x18\x01 \x01(\tR\x04move\x12\x10\n" +
+	"\x03ply\x18\x02 \x01(\x05R\x03ply\x12\x10\n" +
+	"\x03fen\x18\x03 \x01(\tR\x03fen\x12\x10\n" +
+	"\x03san\x18\x04 \x01(\tR\x03san\x12\x14\n" +
+	"\x05check\x18\x05 \x01(\bR\x05check\x12\x1c\n" +
+	"\tcheckmate\x18\x06 \x01(\bR\tcheckmate\x12\x18\n" +
+	"\acapture\x18\a \x01(\bR\acapture\x12\x16\n" +
+	"\x06castle\x18\b \x01(\bR\x06castle\x12\x1c\n" +
+	"\tpromotion\x18\t \x01(\bR\tpromotion\"\n" +
+	"\n" +
+	"\bResigned\";\n" +
+	"\vChatMessage\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\t\n" +
+	"\aAborted\"%\n" +
+	"\vAdjudicated\x12\x16\n" +
+	"\x06result\x18\x01 \x01(\tR\x06result\"\x12\n" +
+	"\x10ServerRestarting\"\x1a\n" +
+	"\x06Resync\x12\x10\n" +
+	"\x03fen\x18\x01 \x01(\tR\x03fen\"7\n" +
+	"\x0fOpeningDetected\x12\x10\n" +
+	"\x03eco\x18\x01 \x01(\tR\x03eco\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"0\n" +
+	"\x10OpponentPresence\x12\x1c\n" +
+	"\tconnected\x18\x01 \x01(\bR\tconnected\":\n" +
+	"\n" +
+	"Spectators\x12\x16\n" +
+	"\x06joined\x18\x01 \x01(\bR\x06joined\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\"B\n" +
+	"\x11SubmitMoveRequest\x12\x19\n" +
+	"\bmatch_id\x18\x01 \x01(\tR\amatchId\x12\x12\n" +
+	"\x04move\x18\x02 \x01(\tR\x04move\"\x14\n" +
+	"\x12SubmitMoveResponse\".\n" +
+	"\x11WatchMatchRequest\x12\x19\n" +
+	"\bmatch_id\x18\x01 \x01(\tR\amatchId\"\x99\x01\n" +
+	"\n" +
+	"WatchEvent\x12)\n" +
+	"\x05state\x18\x01 \x01(\v2\x11.chess.MatchStateH\x00R\x05state\x12'\n" +
+	"\x04move\x18\x02 \x01(\v2\x11.chess.MovePlayedH\x00R\x04move\x12.\n" +
+	"\tgame_over\x18\x03 \x01(\v2\x0f.chess.GameOverH\x00R\bgameOverB\a\n" +
+	"\x05event\"\x83\x02\n" +
+	"\n" +
+	"MatchState\x12\x19\n" +
+	"\bmatch_id\x18\x01 \x01(\tR\amatchId\x12\x14\n" +
+	"\x05white\x18\x02 \x01(\tR\x05white\x12\x14\n" +
+	"\x05black\x18\x03 \x01(\tR\x05black\x12\x14\n" +
+	"\x05rated\x18\x04 \x01(\bR\x05rated\x12\x14\n" +
+	"\x05moves\x18\x05 \x03(\tR\x05moves\x12\x10\n" +
+	"\x03fen\x18\x06 \x01(\tR\x03fen\x129\n" +
+	"\n" +
+	"start_time\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tstartTime\x125\n" +
+	"\bend_time\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\aendTime\"\"\n" +
+	"\bGameOver\x12\x16\n" +
+	"\x06result\x18\x01 \x01(\tR\x06result2\x88\x02\n" +
+	"\x05Chess\x12D\n" +
+	"\vCreateMatch\x12\x19.chess.CreateMatchRequest\x1a\x1a.chess.CreateMatchResponse\x129\n" +
+	"\tJoinMatch\x12\x17.chess.JoinMatchRequest\x1a\x11.chess.MatchEvent0\x01\x12A\n" +
+	"\n" +
+	"SubmitMove\x12\x18.chess.SubmitMoveRequest\x1a\x19.chess.SubmitMoveResponse\x12;\n" +
+	"\n" +
+	"WatchMatch\x12\x18.chess.WatchMatchRequest\x1a\x11.chess.WatchEvent0\x01B\rZ\vapi/chesspbb\x06proto3"
+
+var (
+	file_chess_proto_rawDescOnce sync.Once
+	file_chess_proto_rawDescData []byte
+)
+
+func file_chess_proto_rawDescGZIP() []byte {
+	file_chess_proto_rawDescOnce.Do(func() {
+		file_chess_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_chess_proto_rawDesc), len(file_chess_proto_rawDesc)))
+	})
+	return file_chess_proto_rawDescData
+}
+
+var file_chess_proto_msgTypes = make([]protoimpl.MessageInfo, 21)
+var file_chess_proto_goTypes = []any{
+	(*CreateMatchRequest)(nil),    // 0: chess.CreateMatchRequest
+	(*CreateMatchResponse)(nil),   // 1: chess.CreateMatchResponse
+	(*JoinMatchRequest)(nil),      // 2: chess.JoinMatchRequest
+	(*MatchEvent)(nil),            // 3: chess.MatchEvent
+	(*OpponentJoined)(nil),        // 4: chess.OpponentJoined
+	(*MovePlayed)(nil),            // 5: chess.MovePlayed
+	(*Resigned)(nil),              // 6: chess.Resigned
+	(*ChatMessage)(nil),           // 7: chess.ChatMessage
+	(*Aborted)(nil),               // 8: chess.Aborted
+	(*Adjudicated)(nil),           // 9: chess.Adjudicated
+	(*ServerRestarting)(nil),      // 10: chess.ServerRestarting
+	(*Resync)(nil),                // 11: chess.Resync
+	(*OpeningDetected)(nil),       // 12: chess.OpeningDetected
+	(*OpponentPresence)(nil),      // 13: chess.OpponentPresence
+	(*Spectators)(nil),            // 14: chess.Spectators
+	(*SubmitMoveRequest)(nil),     // 15: chess.SubmitMoveRequest
+	(*SubmitMoveResponse)(nil),    // 16: chess.SubmitMoveResponse
+	(*WatchMatchRequest)(nil),     // 17: chess.WatchMatchRequest
+	(*WatchEvent)(nil),            // 18: chess.WatchEvent
+	(*MatchState)(nil),            // 19: chess.MatchState
+	(*GameOver)(nil),              // 20: chess.GameOver
+	(*timestamppb.Timestamp)(nil), // 21: google.protobuf.Timestamp
+}
+var file_chess_proto_depIdxs = []int32{
+	4,  // 0: chess.MatchEvent.opponent:type_name -> chess.OpponentJoined
+	5,  // 1: chess.MatchEvent.move:type_name -> chess.MovePlayed
+	6,  // 2: chess.MatchEvent.resign:type_name -> chess.Resigned
+	7,  // 3: chess.MatchEvent.chat:type_name -> chess.ChatMessage
+	8,  // 4: chess.MatchEvent.aborted:type_name -> chess.Aborted
+	9,  // 5: chess.MatchEvent.adjudicated:type_name -> chess.Adjudicated
+	10, // 6: chess.MatchEvent.server_restarting:type_name -> chess.ServerRestarting
+	11, // 7: chess.MatchEvent.resync:type_name -> chess.Resync
+	12, // 8: chess.MatchEvent.opening:type_name -> chess.OpeningDetected
+	13, // 9: chess.MatchEvent.opponent_presence:type_name -> chess.OpponentPresence
+	14, // 10: chess.MatchEvent.spectators:type_name -> chess.Spectators
+	21, // 11: chess.OpponentJoined.start_time:type_name -> google.protobuf.Timestamp
+	21, // 12: chess.OpponentJoined.end_time:type_name -> google.protobuf.Timestamp
+	19, // 13: chess.WatchEvent.state:type_name -> chess.MatchState
+	5,  // 14: chess.WatchEvent.move:type_name -> chess.MovePlayed
+	20, // 15: chess.WatchEvent.game_over:type_name -> chess.GameOver
+	21, // 16: chess.MatchState.start_time:type_name -> google.protobuf.Timestamp
+	21, // 17: chess.MatchState.end_time:type_name -> google.protobuf.Timestamp
+	0,  // 18: chess.Chess.CreateMatch:input_type -> chess.CreateMatchRequest
+	2,  // 19: chess.Chess.JoinMatch:input_type -> chess.JoinMatchRequest
+	15, // 20: chess.Chess.SubmitMove:input_type -> chess.SubmitMoveRequest
+	17, // 21: chess.Chess.WatchMatch:input_type -> chess.WatchMatchRequest
+	1,  // 22: chess.Chess.CreateMatch:output_type -> chess.CreateMatchResponse
+	3,  // 23: chess.Chess.JoinMatch:output_type -> chess.MatchEvent
+	16, // 24: chess.Chess.SubmitMove:output_type -> chess.SubmitMoveResponse
+	18, // 25: chess.Chess.WatchMatch:output_type -> chess.WatchEvent
+	22, // [22:26] is the sub-list for method output_type
+	18, // [18:22] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
+}
+
+func init() { file_chess_proto_init() }
+func file_chess_proto_init() {
+	if File_chess_proto != nil {
+		return
+	}
+	file_chess_proto_msgTypes[3].OneofWrappers = []any{
+		(*MatchEvent_Opponent)(nil),
+		(*MatchEvent_Move)(nil),
+		(*MatchEvent_Resign)(nil),
+		(*MatchEvent_Chat)(nil),
+		(*MatchEvent_Aborted)(nil),
+		(*MatchEvent_Adjudicated)(nil),
+		(*MatchEvent_ServerRestarting)(nil),
+		(*MatchEvent_Resync)(nil),
+		(*MatchEvent_Opening)(nil),
+		(*MatchEvent_OpponentPresence)(nil),
+		(*MatchEvent_Spectators)(nil),
+	}
+	file_chess_proto_msgTypes[18].OneofWrappers = []any{
+		(*WatchEvent_State)(nil),
+		(*WatchEvent_Move)(nil),
+		(*WatchEvent_GameOver)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_chess_proto_rawDesc), len(file_chess_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   21,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_chess_proto_goTypes,
+		DependencyIndexes: file_chess_proto_depIdxs,
+		MessageInfos:      file_chess_proto_msgTypes,
+	}.Build()
+	File_chess_proto = out.File
+	file_chess_proto_goTypes = nil
+	file_chess_proto_depIdxs = nil
+}
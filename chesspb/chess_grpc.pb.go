@@ -0,0 +1,267 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: chess.proto
+
+package chesspb
+
+import (
+	context "context"
+
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Chess_CreateMatch_FullMethodName = "/chess.Chess/CreateMatch"
+	Chess_JoinMatch_FullMethodName   = "/chess.Chess/JoinMatch"
+	Chess_SubmitMove_FullMethodName  = "/chess.Chess/SubmitMove"
+	Chess_WatchMatch_FullMethodName  = "/chess.Chess/WatchMatch"
+)
+
+// ChessClient is the client API for Chess service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Chess plays matches like the HTTP api.
+// Calls that need an account send the api key in an "authorization" metadata entry
+// in the format "Bearer apiKey", like the Authorization header of HTTP requests.
+// Errors have a status code like HTTP errors, and an ErrorInfo detail whose reason
+// is the error code of the HTTP api, like MATCH_NOT_FOUND.
+type ChessClient interface {
+	// CreateMatch creates a match that other users can join with its id, like POST /matches.
+	CreateMatch(ctx context.Context, in *CreateMatchRequest, opts ...grpc.CallOption) (*CreateMatchResponse, error)
+	// JoinMatch joins a match and streams its events until the game ends for you, like GET /matches/{id}/play.
+	// Cancelling the call resigns unless you join again within a minute, or the server is restarting, or you fell behind.
+	JoinMatch(ctx context.Context, in *JoinMatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MatchEvent], error)
+	// SubmitMove plays a move in a match you joined, like PUT /matches/{id}.
+	SubmitMove(ctx context.Context, in *SubmitMoveRequest, opts ...grpc.CallOption) (*SubmitMoveResponse, error)
+	// WatchMatch streams the moves of a match without joining it. No api key is needed.
+	// The first event is the state of the match, the stream ends after the game is over.
+	WatchMatch(ctx context.Context, in *WatchMatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchEvent], error)
+}
+
+type chessClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewChessClient(cc grpc.ClientConnInterface) ChessClient {
+	return &chessClient{cc}
+}
+
+func (c *chessClient) CreateMatch(ctx context.Context, in *CreateMatchRequest, opts ...grpc.CallOption) (*CreateMatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateMatchResponse)
+	err := c.cc.Invoke(ctx, Chess_CreateMatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chessClient) JoinMatch(ctx context.Context, in *JoinMatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MatchEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Chess_ServiceDesc.Streams[0], Chess_JoinMatch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[JoinMatchRequest, MatchEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Chess_JoinMatchClient = grpc.ServerStreamingClient[MatchEvent]
+
+func (c *chessClient) SubmitMove(ctx context.Context, in *SubmitMoveRequest, opts ...grpc.CallOption) (*SubmitMoveResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubmitMoveResponse)
+	err := c.cc.Invoke(ctx, Chess_SubmitMove_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chessClient) WatchMatch(ctx context.Context, in *WatchMatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Chess_ServiceDesc.Streams[1], Chess_WatchMatch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchMatchRequest, WatchEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Chess_WatchMatchClient = grpc.ServerStreamingClient[WatchEvent]
+
+// ChessServer is the server API for Chess service.
+// All implementations must embed UnimplementedChessServer
+// for forward compatibility.
+//
+// Chess plays matches like the HTTP api.
+// Calls that need an account send the api key in an "authorization" metadata entry
+// in the format "Bearer apiKey", like the Authorization header of HTTP requests.
+// Errors have a status code like HTTP errors, and an ErrorInfo detail whose reason
+// is the error code of the HTTP api, like MATCH_NOT_FOUND.
+type ChessServer interface {
+	// CreateMatch creates a match that other users can join with its id, like POST /matches.
+	CreateMatch(context.Context, *CreateMatchRequest) (*CreateMatchResponse, error)
+	// JoinMatch joins a match and streams its events until the game ends for you, like GET /matches/{id}/play.
+	// Cancelling the call resigns unless you join again within a minute, or the server is restarting, or you fell behind.
+	JoinMatch(*JoinMatchRequest, grpc.ServerStreamingServer[MatchEvent]) error
+	// SubmitMove plays a move in a match you joined, like PUT /matches/{id}.
+	SubmitMove(context.Context, *SubmitMoveRequest) (*SubmitMoveResponse, error)
+	// WatchMatch streams the moves of a match without joining it. No api key is needed.
+	// The first event is the state of the match, the stream ends after the game is over.
+	WatchMatch(*WatchMatchRequest, grpc.ServerStreamingServer[WatchEvent]) error
+	mustEmbedUnimplementedChessServer()
+}
+
+// UnimplementedChessServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedChessServer struct{}
+
+func (UnimplementedChessServer) CreateMatch(context.Context, *CreateMatchRequest) (*CreateMatchResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateMatch not implemented")
+}
+func (UnimplementedChessServer) JoinMatch(*JoinMatchRequest, grpc.ServerStreamingServer[MatchEvent]) error {
+	return status.Error(codes.Unimplemented, "method JoinMatch not implemented")
+}
+func (UnimplementedChessServer) SubmitMove(context.Context, *SubmitMoveRequest) (*SubmitMoveResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SubmitMove not implemented")
+}
+func (UnimplementedChessServer) WatchMatch(*WatchMatchRequest, grpc.ServerStreamingServer[WatchEvent]) error {
+	return status.Error(codes.Unimplemented, "method WatchMatch not implemented")
+}
+func (UnimplementedChessServer) mustEmbedUnimplementedChessServer() {}
+func (UnimplementedChessServer) testEmbeddedByValue()               {}
+
+// UnsafeChessServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ChessServer will
+// result in compilation errors.
+type UnsafeChessServer interface {
+	mustEmbedUnimplementedChessServer()
+}
+
+func RegisterChessServer(s grpc.ServiceRegistrar, srv ChessServer) {
+	// If the following call panics, it indicates UnimplementedChessServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Chess_ServiceDesc, srv)
+}
+
+func _Chess_CreateMatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateMatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChessServer).CreateMatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Chess_CreateMatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChessServer).CreateMatch(ctx, req.(*CreateMatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chess_JoinMatch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(JoinMatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChessServer).JoinMatch(m, &grpc.GenericServerStream[JoinMatchRequest, MatchEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Chess_JoinMatchServer = grpc.ServerStreamingServer[MatchEvent]
+
+func _Chess_SubmitMove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitMoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChessServer).SubmitMove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Chess_SubmitMove_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChessServer).SubmitMove(ctx, req.(*SubmitMoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chess_WatchMatch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchMatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChessServer).WatchMatch(m, &grpc.GenericServerStream[WatchMatchRequest, WatchEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Chess_WatchMatchServer = grpc.ServerStreamingServer[WatchEvent]
+
+// Chess_ServiceDesc is the grpc.ServiceDesc for Chess service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Chess_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chess.Chess",
+	HandlerType: (*ChessServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateMatch",
+			Handler:    _Chess_CreateMatch_Handler,
+		},
+		{
+			MethodName: "SubmitMove",
+			Handler:    _Chess_SubmitMove_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "JoinMatch",
+			Handler:       _Chess_JoinMatch_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchMatch",
+			Handler:       _Chess_WatchMatch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "chess.proto",
+}
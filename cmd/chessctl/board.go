@@ -0,0 +1,83 @@
+package main
+
+import (
+	"api/server"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
+// positionOf parses the FEN of state
+func positionOf(state server.BoardState) (*chess.Position, error) {
+	fen, err := chess.FEN(state.FEN)
+	if err != nil {
+		return nil, fmt.Errorf("server sent an invalid FEN %q: %w", state.FEN, err)
+	}
+	return chess.NewGame(fen).Position(), nil
+}
+
+// printBoard draws the board of state with its opening, black at the bottom if black is true
+func printBoard(w io.Writer, state server.BoardState, black bool) error {
+	pos, err := positionOf(state)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(w, drawBoard(pos, black))
+	if state.Opening.ECO != "" {
+		fmt.Fprintf(w, "%s %s\n", state.Opening.ECO, state.Opening.Name)
+	}
+	fmt.Fprintf(w, "%s to move\n", colorName(pos.Turn()))
+	return nil
+}
+
+// drawBoard draws pos in ASCII, white pieces in upper case and black pieces in lower case
+//
+//	8 r n b q k b n r
+//	7 p p p p p p p p
+//	6 . . . . . . . .
+//	...
+//	  a b c d e f g h
+func drawBoard(pos *chess.Position, black bool) string {
+	var sb strings.Builder
+	board := pos.Board()
+	for i := range 8 {
+		rank := chess.Rank(7 - i)
+		if black {
+			rank = chess.Rank(i)
+		}
+		sb.WriteString(rank.String())
+		for j := range 8 {
+			file := chess.File(j)
+			if black {
+				file = chess.File(7 - j)
+			}
+			sb.WriteByte(' ')
+			piece := board.Piece(chess.NewSquare(file, rank))
+			if piece == chess.NoPiece {
+				sb.WriteByte('.')
+				continue
+			}
+			letter := piece.Type().String()
+			if piece.Color() == chess.White {
+				letter = strings.ToUpper(letter)
+			}
+			sb.WriteString(letter)
+		}
+		sb.WriteByte('\n')
+	}
+	if black {
+		sb.WriteString("  h g f e d c b a\n")
+	} else {
+		sb.WriteString("  a b c d e f g h\n")
+	}
+	return sb.String()
+}
+
+func colorName(c chess.Color) string {
+	if c == chess.Black {
+		return "black"
+	}
+	return "white"
+}
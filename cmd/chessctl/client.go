@@ -0,0 +1,164 @@
+package main
+
+import (
+	"api/server"
+	"api/server/game"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/bits"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type client struct {
+	base string
+	// empty before logging in
+	key  string
+	http *http.Client
+}
+
+// request sends body as JSON, errors have the code and reason of the response
+func (c client) request(ctx context.Context, method, path string, header http.Header, body any) (*http.Response, error) {
+	var reqBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.key != "" {
+		req.Header.Set("Authorization", "Bearer "+c.key)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var reason server.ErrorReason
+		json.NewDecoder(resp.Body).Decode(&reason)
+		if reason.Reason == "" {
+			return nil, fmt.Errorf("%s %s: %s", method, path, resp.Status)
+		}
+		for _, f := range reason.Fields {
+			reason.Reason += fmt.Sprintf(", %s %s", f.Field, f.Reason)
+		}
+		return nil, fmt.Errorf("%s (%s)", reason.Reason, reason.Code)
+	}
+	return resp, nil
+}
+
+// do sends a request and decodes the response into out, unless out is nil
+func (c client) do(ctx context.Context, method, path string, body, out any) error {
+	resp, err := c.request(ctx, method, path, nil, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// signup creates an account, solving a signup challenge first if the server asks for one
+func (c client) signup(ctx context.Context, path string, body, out any) error {
+	var challenge server.SignupChallengeResponse
+	if err := c.do(ctx, http.MethodGet, "/auth/challenge", nil, &challenge); err != nil {
+		return err
+	}
+	header := http.Header{}
+	if challenge.Difficulty > 0 {
+		header.Set("X-Challenge", challenge.Challenge)
+		header.Set("X-Challenge-Nonce", solveChallenge(challenge.Challenge, challenge.Difficulty))
+	}
+	resp, err := c.request(ctx, http.MethodPost, path, header, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// solveChallenge finds a nonce so that sha256(challenge + nonce) starts with difficulty zero bits
+func solveChallenge(challenge string, difficulty int) string {
+	for n := 0; ; n++ {
+		nonce := strconv.Itoa(n)
+		sum := sha256.Sum256([]byte(challenge + nonce))
+		zeros := 0
+		for _, b := range sum {
+			zeros += bits.LeadingZeros8(b)
+			if b != 0 {
+				break
+			}
+		}
+		if zeros >= difficulty {
+			return nonce
+		}
+	}
+}
+
+// board gets the board at path, a match or an imported game
+func (c client) board(ctx context.Context, path string) (server.BoardState, error) {
+	var state server.BoardState
+	resp, err := c.request(ctx, http.MethodGet, path, http.Header{"Accept": {"application/json"}}, nil)
+	if err != nil {
+		return state, err
+	}
+	defer resp.Body.Close()
+	return state, json.NewDecoder(resp.Body).Decode(&state)
+}
+
+// join opens the event stream of a match. Closing it leaves the match, which resigns an unfinished game.
+func (c client) join(ctx context.Context, matchID string, black bool) (*eventStream, error) {
+	resp, err := c.request(ctx, http.MethodGet, "/matches/"+matchID+"/play", nil, server.JoinMatchRequest{BlackPieces: black})
+	if err != nil {
+		return nil, err
+	}
+	s := &eventStream{body: resp.Body, events: make(chan game.Event, game.EVENT_BUFFER_SIZE)}
+	go s.read()
+	return s, nil
+}
+
+// eventStream is a player's connection to a match
+type eventStream struct {
+	body io.ReadCloser
+	// closed when the stream ends
+	events chan game.Event
+}
+
+func (s *eventStream) read() {
+	defer close(s.events)
+	scanner := bufio.NewScanner(s.body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var e game.Event
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			continue
+		}
+		s.events <- e
+	}
+}
+
+func (s *eventStream) close() {
+	s.body.Close()
+}
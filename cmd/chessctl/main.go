@@ -0,0 +1,258 @@
+// Command chessctl plays chess on a server from the terminal, and doubles as an end to end check of the api.
+//
+//	go run ./cmd/chessctl register -user JohnDoe
+//	go run ./cmd/chessctl create -hours 1 -play
+//	go run ./cmd/chessctl play AB2C21
+//
+// The api key of the last register, login or guest command is saved in the user's config directory,
+// the other commands use it. -key and -url override the saved ones, so do the CHESS_API_KEY and CHESS_API_URL variables.
+package main
+
+import (
+	"api/server"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+)
+
+const DEFAULT_URL = "http://localhost:8080"
+
+// config is what chessctl remembers between commands
+type config struct {
+	URL      string `json:"url"`
+	Username string `json:"username"`
+	ApiKey   string `json:"apiKey"`
+}
+
+type command struct {
+	args, about string
+	run         func(ctx context.Context, c client, cfg config, args []string) error
+}
+
+var commands = map[string]command{
+	"register": {"-user NAME [-password PASSWORD]", "create an account and log into it", register},
+	"login":    {"-user NAME [-password PASSWORD]", "log into an account", login},
+	"guest":    {"", "play as a guest, the account expires", guest},
+	"create":   {"[-hours N] [-rated] [-play [-black]]", "create a match and print its id", create},
+	"play":     {"[-black] MATCH_ID", "join a match and play it", play},
+	"board":    {"[-black] [-ply N] MATCH_ID", "print the board of a match or an imported game", board},
+}
+
+func main() {
+	var url, key string
+	flag.StringVar(&url, "url", os.Getenv("CHESS_API_URL"), "server to use, the saved one if empty")
+	flag.StringVar(&key, "key", os.Getenv("CHESS_API_KEY"), "api key to use, the saved one if empty")
+	flag.Usage = usage
+	flag.Parse()
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "chessctl:", err)
+		os.Exit(1)
+	}
+	if url != "" {
+		cfg.URL = url
+	}
+	if cfg.URL == "" {
+		cfg.URL = DEFAULT_URL
+	}
+	if key != "" {
+		cfg.ApiKey, cfg.Username = key, ""
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	c := client{base: strings.TrimSuffix(cfg.URL, "/"), key: cfg.ApiKey, http: &http.Client{}}
+	if err := cmd.run(ctx, c, cfg, flag.Args()[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "chessctl:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: chessctl [-url URL] [-key API_KEY] COMMAND [ARGS]\n\ncommands:")
+	for _, name := range []string{"register", "login", "guest", "create", "play", "board"} {
+		cmd := commands[name]
+		fmt.Fprintf(os.Stderr, "  %-8s %-36s %s\n", name, cmd.args, cmd.about)
+	}
+	fmt.Fprintln(os.Stderr, "\nflags:")
+	flag.PrintDefaults()
+}
+
+// configPath is where the config is saved, in the user's config directory
+func configPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "chessctl", "config.json"), nil
+}
+
+// loadConfig reads the saved config, it is empty if none was saved
+func loadConfig() (config, error) {
+	var cfg config
+	path, err := configPath()
+	if err != nil {
+		return cfg, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid config in %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// saveConfig saves cfg. Only the user can read it, it has their api key.
+func saveConfig(cfg config) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// credentials reads -user and -password. The password is asked for if it is not given.
+func credentials(name string, args []string) (server.UserCredentials, error) {
+	var creds server.UserCredentials
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.StringVar(&creds.Username, "user", "", "username")
+	fs.StringVar(&creds.Password, "password", os.Getenv("CHESS_API_PASSWORD"), "password, asked for if empty")
+	fs.Parse(args)
+	if creds.Username == "" {
+		return creds, errors.New(name + " needs -user")
+	}
+	if creds.Password == "" {
+		fmt.Fprint(os.Stderr, "password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return creds, fmt.Errorf("no password: %w", err)
+		}
+		creds.Password = strings.TrimSpace(line)
+	}
+	return creds, nil
+}
+
+// loggedIn saves the api key of username and tells the user
+func loggedIn(cfg config, username, apiKey string) error {
+	cfg.Username, cfg.ApiKey = username, apiKey
+	if err := saveConfig(cfg); err != nil {
+		return fmt.Errorf("logged in, but the api key could not be saved: %w", err)
+	}
+	fmt.Printf("logged in as %s on %s\n", username, cfg.URL)
+	return nil
+}
+
+func register(ctx context.Context, c client, cfg config, args []string) error {
+	creds, err := credentials("register", args)
+	if err != nil {
+		return err
+	}
+	var resp server.ApiKeyResponse
+	if err := c.signup(ctx, "/users", creds, &resp); err != nil {
+		return err
+	}
+	return loggedIn(cfg, creds.Username, resp.ApiKey)
+}
+
+func login(ctx context.Context, c client, cfg config, args []string) error {
+	creds, err := credentials("login", args)
+	if err != nil {
+		return err
+	}
+	var resp server.ApiKeyResponse
+	if err := c.do(ctx, http.MethodPost, "/auth/login", creds, &resp); err != nil {
+		return err
+	}
+	return loggedIn(cfg, creds.Username, resp.ApiKey)
+}
+
+func guest(ctx context.Context, c client, cfg config, args []string) error {
+	var resp server.GuestResponse
+	if err := c.signup(ctx, "/auth/guest", nil, &resp); err != nil {
+		return err
+	}
+	if err := loggedIn(cfg, resp.Username, resp.ApiKey); err != nil {
+		return err
+	}
+	fmt.Printf("the guest account expires at %s\n", resp.ExpiresAt.Local().Format("2006-01-02 15:04"))
+	return nil
+}
+
+func create(ctx context.Context, c client, cfg config, args []string) error {
+	var req server.CreateMatchRequest
+	var playIt, black bool
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	fs.IntVar(&req.Duration, "hours", 1, "how long the match lasts, 1 to 12")
+	fs.BoolVar(&req.Rated, "rated", false, "guests cannot join rated matches")
+	fs.BoolVar(&playIt, "play", false, "join the match and play it")
+	fs.BoolVar(&black, "black", false, "play as black, with -play")
+	fs.Parse(args)
+
+	var created server.MatchCreatedResponse
+	if err := c.do(ctx, http.MethodPost, "/matches", req, &created); err != nil {
+		return err
+	}
+	fmt.Println(created.ID)
+	if !playIt {
+		return nil
+	}
+	return playMatch(ctx, c, created.ID, black)
+}
+
+func play(ctx context.Context, c client, cfg config, args []string) error {
+	var black bool
+	fs := flag.NewFlagSet("play", flag.ExitOnError)
+	fs.BoolVar(&black, "black", false, "play as black, if you are the first one to join")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("play needs a match id")
+	}
+	return playMatch(ctx, c, fs.Arg(0), black)
+}
+
+func board(ctx context.Context, c client, cfg config, args []string) error {
+	var black bool
+	var ply string
+	fs := flag.NewFlagSet("board", flag.ExitOnError)
+	fs.BoolVar(&black, "black", false, "show the board from black's side")
+	fs.StringVar(&ply, "ply", "", "half moves into an imported game, its final position if empty")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("board needs a match id")
+	}
+	path := "/matches/" + fs.Arg(0)
+	if ply != "" {
+		path += "?ply=" + ply
+	}
+	state, err := c.board(ctx, path)
+	if err != nil {
+		return err
+	}
+	return printBoard(os.Stdout, state, black)
+}
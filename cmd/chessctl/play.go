@@ -0,0 +1,199 @@
+package main
+
+import (
+	"api/server"
+	"api/server/game"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
+const PLAY_HELP = `type a move like e2e4 or Nf3, or one of:
+  board       show the board again
+  say TEXT    send a chat message to your opponent
+  resign      resign and leave the match
+  help        show this help`
+
+// player is the state of an interactive game, see playMatch
+type player struct {
+	c       client
+	matchID string
+	// the side the board is drawn from, known for sure once the opponent joined
+	black bool
+	// last position fetched from the server
+	pos *chess.Position
+}
+
+// playMatch joins the match and plays it from the terminal until the game ends or the user leaves.
+// Leaving an unfinished game resigns it.
+func playMatch(ctx context.Context, c client, matchID string, black bool) error {
+	stream, err := c.join(ctx, matchID, black)
+	if err != nil {
+		return err
+	}
+	defer func() { stream.close() }()
+	p := &player{c: c, matchID: matchID, black: black}
+	fmt.Printf("joined %s, the game starts once your opponent joins it too\n", matchID)
+	fmt.Println(PLAY_HELP)
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines <- strings.TrimSpace(scanner.Text())
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println("left the match")
+			return nil
+
+		case line, ok := <-lines:
+			if !ok {
+				// keep following the game without input
+				lines = nil
+				continue
+			}
+			done, err := p.command(ctx, line)
+			if err != nil {
+				fmt.Println(err)
+			}
+			if done {
+				return nil
+			}
+
+		case e, ok := <-stream.events:
+			if !ok {
+				return errors.New("the server closed the match stream")
+			}
+			if e.Type == game.Resync {
+				// the stream fell behind and ended, joining again picks up from the current position
+				stream.close()
+				if stream, err = c.join(ctx, matchID, black); err != nil {
+					return err
+				}
+				fmt.Println("reconnected")
+				p.show(ctx)
+				continue
+			}
+			if p.event(ctx, e) {
+				return nil
+			}
+		}
+	}
+}
+
+// event shows e, done is true if the game is over for the player
+func (p *player) event(ctx context.Context, e game.Event) (done bool) {
+	switch e.Type {
+	case game.OpponentInfo:
+		p.black = !e.OpponentBlack
+		fmt.Printf("playing %s, you have the %s pieces\n", e.OponentUsername, colorName(p.color()))
+		return p.show(ctx)
+	case game.Move:
+		fmt.Printf("opponent played %s\n", e.Move)
+		return p.show(ctx)
+	case game.Chat:
+		fmt.Printf("%s: %s\n", e.From, e.Message)
+	case game.OpeningDetected:
+		fmt.Printf("opening: %s %s\n", e.ECO, e.OpeningName)
+	case game.Resign:
+		fmt.Println("your opponent resigned, you won")
+		return true
+	case game.Aborted:
+		fmt.Println("the match was deleted")
+		return true
+	case game.Adjudicated:
+		fmt.Printf("an admin ended the game: %s\n", e.Result)
+		return true
+	case game.ServerRestarting:
+		fmt.Println("the server is restarting, play the match again in a moment")
+		return true
+	}
+	return false
+}
+
+// command runs a line the user typed, done is true if the game is over for the player
+func (p *player) command(ctx context.Context, line string) (done bool, err error) {
+	name, arg, _ := strings.Cut(line, " ")
+	switch name {
+	case "":
+		return false, nil
+	case "help":
+		fmt.Println(PLAY_HELP)
+		return false, nil
+	case "board":
+		return p.show(ctx), nil
+	case "say":
+		return false, p.c.do(ctx, http.MethodPost, "/matches/"+p.matchID+"/chat", server.ChatRequest{Message: arg}, nil)
+	case "resign":
+		// leaving the match resigns
+		fmt.Println("you resigned")
+		return true, nil
+	}
+	move := p.uci(line)
+	if err := p.c.do(ctx, http.MethodPut, "/matches/"+p.matchID, server.PutMoveRequest{Move: move}, nil); err != nil {
+		return false, err
+	}
+	return p.show(ctx), nil
+}
+
+// uci is move in UCI notation, moves in algebraic notation are converted with the last position.
+// Anything else is sent as it is, the server says what is wrong with it.
+func (p *player) uci(move string) string {
+	if p.pos != nil {
+		if m, err := (chess.AlgebraicNotation{}).Decode(p.pos, move); err == nil {
+			return chess.UCINotation{}.Encode(p.pos, m)
+		}
+	}
+	return strings.ToLower(move)
+}
+
+func (p *player) color() chess.Color {
+	if p.black {
+		return chess.Black
+	}
+	return chess.White
+}
+
+// show fetches and draws the board, done is true if the game ended on the board
+func (p *player) show(ctx context.Context) (done bool) {
+	state, err := p.c.board(ctx, "/matches/"+p.matchID)
+	if err != nil {
+		fmt.Println("could not get the board:", err)
+		return false
+	}
+	pos, err := positionOf(state)
+	if err != nil {
+		fmt.Println(err)
+		return false
+	}
+	p.pos = pos
+	fmt.Println()
+	printBoard(os.Stdout, state, p.black)
+	switch pos.Status() {
+	case chess.Checkmate:
+		if pos.Turn() == p.color() {
+			fmt.Println("checkmate, you lost")
+		} else {
+			fmt.Println("checkmate, you won")
+		}
+		return true
+	case chess.Stalemate:
+		fmt.Println("stalemate, it is a draw")
+		return true
+	}
+	if pos.Turn() == p.color() {
+		fmt.Println("your move")
+	}
+	return false
+}
@@ -0,0 +1,372 @@
+// Command simulate load tests a running server. Pairs of bots play games against each other
+// through the HTTP api and event streams, like real clients, and it reports throughput and latency.
+//
+// Bots sign up as guests and play quickly, so start the server with the rate limits off:
+//
+//	go run . -rate-limit-signup off -signups-per-ip off -rate-limit-move off -rate-limit-authenticated off
+//	go run ./cmd/simulate -pairs 50 -games 4
+//
+// It exits with status 1 if any game failed.
+package main
+
+import (
+	"api/server"
+	"api/server/game"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+// how long a bot waits for its opponent's move to arrive on its event stream
+const MOVE_TIMEOUT = 10 * time.Second
+
+type options struct {
+	url   string
+	pairs int
+	games int
+	// games without a result are resigned after this many moves
+	maxPlies int
+	// wait between receiving a move and playing one
+	think time.Duration
+	seed  uint64
+	// games to replay, random moves if empty
+	script [][]string
+}
+
+func main() {
+	var o options
+	var scriptFile string
+	flag.StringVar(&o.url, "url", "http://localhost:8080", "server to test")
+	flag.IntVar(&o.pairs, "pairs", 10, "bot pairs playing at the same time")
+	flag.IntVar(&o.games, "games", 1, "games each pair plays, one after the other")
+	flag.IntVar(&o.maxPlies, "max-plies", 100, "moves after which a game is resigned")
+	flag.DurationVar(&o.think, "think", 0, "how long bots think before moving")
+	flag.Uint64Var(&o.seed, "seed", 0, "seed for random moves, 0 picks one")
+	flag.StringVar(&scriptFile, "script", "", "PGN file with games to replay instead of random moves, pairs take turns through them")
+	flag.Parse()
+
+	if o.seed == 0 {
+		o.seed = rand.Uint64()
+	}
+	if scriptFile != "" {
+		script, err := readScript(scriptFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		o.script = script
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	slog.Info("starting simulation", "url", o.url, "pairs", o.pairs, "games", o.games, "seed", o.seed)
+	r := simulate(ctx, o)
+	r.print(os.Stdout)
+	if r.failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// readScript reads the games of a PGN file as UCI moves
+func readScript(path string) ([][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	games, err := chess.GamesFromPGN(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PGN in %s: %w", path, err)
+	}
+	if len(games) == 0 {
+		return nil, fmt.Errorf("no games in %s", path)
+	}
+	script := make([][]string, len(games))
+	for i, g := range games {
+		script[i] = game.ScriptedMoves(g)
+	}
+	return script, nil
+}
+
+// simulate runs the bot pairs until they played their games or ctx is done
+func simulate(ctx context.Context, o options) *results {
+	c := client{base: strings.TrimSuffix(o.url, "/"), http: &http.Client{}}
+	r := &results{}
+	start := time.Now()
+	var wg sync.WaitGroup
+	for pair := range o.pairs {
+		wg.Go(func() {
+			rng := rand.New(rand.NewPCG(o.seed, uint64(pair)))
+			var keys [2]string
+			for i := range keys {
+				guest, err := c.guest(ctx)
+				if err != nil {
+					slog.Error("bot could not sign up", "pair", pair, "error", err)
+					r.fail(o.games)
+					return
+				}
+				keys[i] = guest.ApiKey
+			}
+			for g := range o.games {
+				if ctx.Err() != nil {
+					return
+				}
+				var script []string
+				if len(o.script) > 0 {
+					script = o.script[(pair*o.games+g)%len(o.script)]
+				}
+				if err := playGame(ctx, c, keys, script, rng, o, r); err != nil {
+					slog.Error("game failed", "pair", pair, "game", g, "error", err)
+					r.fail(1)
+				}
+			}
+		})
+	}
+	wg.Wait()
+	r.elapsed = time.Since(start)
+	return r
+}
+
+// playGame plays one game between the bots with keys, white first.
+// The game is over when the board says so, the script ends, or after maxPlies moves.
+func playGame(ctx context.Context, c client, keys [2]string, script []string, rng *rand.Rand, o options, r *results) error {
+	var created server.MatchCreatedResponse
+	err := c.do(ctx, http.MethodPost, "/matches", keys[0], server.CreateMatchRequest{Duration: 1}, &created)
+	if err != nil {
+		return fmt.Errorf("create match: %w", err)
+	}
+	var streams [2]*eventStream
+	for i, key := range keys {
+		s, err := c.join(ctx, created.ID, key, i == 1)
+		if err != nil {
+			return fmt.Errorf("join match %s: %w", created.ID, err)
+		}
+		// leaving the match resigns an unfinished game
+		defer s.close()
+		streams[i] = s
+	}
+
+	board := chess.NewGame()
+	for ply := 0; board.Outcome() == chess.NoOutcome && ply < o.maxPlies; ply++ {
+		var move string
+		if script != nil {
+			if ply >= len(script) {
+				break
+			}
+			move = script[ply]
+		} else {
+			move, _ = game.RandomMove(board.Position(), rng)
+		}
+		if o.think > 0 {
+			time.Sleep(o.think)
+		}
+
+		sent := time.Now()
+		err := c.do(ctx, http.MethodPut, "/matches/"+created.ID, keys[ply%2], server.PutMoveRequest{Move: move}, nil)
+		answered := time.Since(sent)
+		if err != nil {
+			return fmt.Errorf("move %s in match %s: %w", move, created.ID, err)
+		}
+		if err := streams[(ply+1)%2].waitForMove(ctx, move); err != nil {
+			return fmt.Errorf("move %s in match %s: %w", move, created.ID, err)
+		}
+		r.move(answered, time.Since(sent))
+
+		decoded, err := chess.UCINotation{}.Decode(board.Position(), move)
+		if err == nil {
+			err = board.Move(decoded)
+		}
+		if err != nil {
+			return fmt.Errorf("server accepted %s, the bot cannot play it: %w", move, err)
+		}
+	}
+	r.gameOver()
+	return nil
+}
+
+type client struct {
+	base string
+	http *http.Client
+}
+
+func (c client) request(ctx context.Context, method, path, key string, body any) (*http.Response, error) {
+	var reqBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var reason server.ErrorReason
+		json.NewDecoder(resp.Body).Decode(&reason)
+		return nil, fmt.Errorf("%s %s: %d %s %s", method, path, resp.StatusCode, reason.Code, reason.Reason)
+	}
+	return resp, nil
+}
+
+// do sends a request and decodes the response into out, unless out is nil
+func (c client) do(ctx context.Context, method, path, key string, body, out any) error {
+	resp, err := c.request(ctx, method, path, key, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c client) guest(ctx context.Context) (server.GuestResponse, error) {
+	var guest server.GuestResponse
+	err := c.do(ctx, http.MethodPost, "/auth/guest", "", nil, &guest)
+	return guest, err
+}
+
+// eventStream is a player's connection to a match
+type eventStream struct {
+	body   io.ReadCloser
+	events chan game.Event
+}
+
+func (c client) join(ctx context.Context, matchID, key string, black bool) (*eventStream, error) {
+	resp, err := c.request(ctx, http.MethodGet, "/matches/"+matchID+"/play", key, server.JoinMatchRequest{BlackPieces: black})
+	if err != nil {
+		return nil, err
+	}
+	s := &eventStream{body: resp.Body, events: make(chan game.Event, game.EVENT_BUFFER_SIZE)}
+	go s.read()
+	return s, nil
+}
+
+func (s *eventStream) read() {
+	defer close(s.events)
+	scanner := bufio.NewScanner(s.body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var e game.Event
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			slog.Warn("invalid event", "data", data, "error", err)
+			continue
+		}
+		s.events <- e
+	}
+}
+
+// waitForMove reads events until the opponent's move arrives
+func (s *eventStream) waitForMove(ctx context.Context, move string) error {
+	timeout := time.After(MOVE_TIMEOUT)
+	for {
+		select {
+		case e, ok := <-s.events:
+			if !ok {
+				return errors.New("event stream ended")
+			}
+			switch e.Type {
+			case game.Move:
+				if e.Move != move {
+					return fmt.Errorf("expected move %s, got %s", move, e.Move)
+				}
+				return nil
+			case game.OpponentInfo, game.Chat, game.OpeningDetected:
+			default:
+				return fmt.Errorf("unexpected %s event", e.Type)
+			}
+		case <-timeout:
+			return errors.New("timed out waiting for the move event")
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *eventStream) close() {
+	s.body.Close()
+}
+
+type results struct {
+	mu sync.Mutex
+	// how long PUT /matches/{id} took
+	moveLatencies []time.Duration
+	// from sending a move until the opponent received it
+	deliveryLatencies []time.Duration
+	games, failed     int
+	elapsed           time.Duration
+}
+
+func (r *results) move(answered, delivered time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.moveLatencies = append(r.moveLatencies, answered)
+	r.deliveryLatencies = append(r.deliveryLatencies, delivered)
+}
+
+func (r *results) gameOver() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.games++
+}
+
+func (r *results) fail(games int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failed += games
+}
+
+func (r *results) print(w io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	seconds := r.elapsed.Seconds()
+	fmt.Fprintf(w, "games:    %d played, %d failed in %s\n", r.games, r.failed, r.elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "moves:    %d, %.1f/s\n", len(r.moveLatencies), float64(len(r.moveLatencies))/seconds)
+	fmt.Fprintf(w, "move:     %s\n", latencySummary(r.moveLatencies))
+	fmt.Fprintf(w, "delivery: %s\n", latencySummary(r.deliveryLatencies))
+}
+
+func latencySummary(latencies []time.Duration) string {
+	if len(latencies) == 0 {
+		return "-"
+	}
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+	percentile := func(p float64) time.Duration {
+		return sorted[int(math.Ceil(p*float64(len(sorted))))-1].Round(time.Microsecond)
+	}
+	return fmt.Sprintf("p50 %s  p95 %s  p99 %s  max %s", percentile(0.5), percentile(0.95), percentile(0.99), sorted[len(sorted)-1].Round(time.Microsecond))
+}
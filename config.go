@@ -0,0 +1,408 @@
+// configuration from flags and environment variables
+package main
+
+import (
+	"api/server"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Config is read from flags. Every flag defaults to an environment variable, which defaults to a built in value.
+type Config struct {
+	// address to listen on, like ":8080"
+	Addr string
+	// address the gRPC api listens on, empty turns it off
+	GRPCAddr string
+	// sqlite data source name, usually a file path
+	Database string
+	// how long connections wait for a lock
+	DBBusyTimeout time.Duration
+	// connections in the pool
+	DBMaxOpenConns int
+	// file containing the secret api keys are signed with. It is created if it doesn't exist.
+	JWTSecretFile string
+	// the secret itself, takes precedence over JWTSecretFile.
+	// Only read from the environment so it doesn't show up in the process list.
+	JWTSecret string
+	// file of words that are not allowed in usernames and chat, one per line
+	BannedWordsFile string
+	// usernames that are made admins on startup
+	Admins []string
+	// how long to wait for connections to close when shutting down
+	ShutdownTimeout time.Duration
+	// serve HTTPS with this certificate and key
+	TLSCertFile, TLSKeyFile string
+	// serve HTTPS with certificates from Let's Encrypt for these domains
+	AutocertDomains []string
+	// where certificates from Let's Encrypt are stored
+	AutocertCacheDir string
+	// contact address given to Let's Encrypt, optional
+	AutocertEmail string
+	// address of the HTTP server that answers ACME challenges and redirects to HTTPS
+	AutocertHTTPAddr string
+	// share matches with other replicas through this Redis server, optional
+	RedisURL string
+	// directory backups made through the admin api are written to
+	BackupDir string
+	// backup that replaces the database before the server starts, optional
+	RestoreFrom string
+	// fill the database with demo data on startup
+	Seed bool
+	// UCI engine the computer plays with, empty turns matches against the computer off
+	Engine string
+	// bot account the computer plays as
+	EngineUsername string
+	// engine processes that run at the same time
+	EngineProcesses int
+	// analyze finished games for puzzles with the engine
+	MinePuzzles bool
+	// analyze rated games for engine use with the engine
+	FairPlay bool
+	// let webhooks reach loopback and private addresses
+	WebhooksAllowPrivate bool
+	// SMTP server emails are sent through, empty turns emails off
+	SMTPAddr, SMTPUsername string
+	// only read from the environment, like JWTSecret
+	SMTPPassword string
+	// sender address of emails
+	SMTPFrom string
+	// URL the server is reached at, for links in emails
+	PublicURL string
+	// reverse proxies whose X-Forwarded-For header is trusted. Without any, the client IP is the address of the connection.
+	TrustedProxies []*net.IPNet
+	// leading zero bits of signup proof-of-work challenges, 0 turns them off
+	SignupChallengeDifficulty int
+	// accounts that can be created from one IP
+	SignupsPerIP server.RateLimit
+	// 0 means no limit
+	MaxMatchesPerUser, MaxStreamsPerUser int
+	// in the order of rateLimitOptions
+	RateLimits []server.RateLimit
+	Passwords  server.PasswordHasher
+}
+
+// minimum length of JWT_SECRET
+const MIN_JWT_SECRET_LENGTH = 16
+
+// rate limit flags and the environment variables they default to
+var rateLimitOptions = []struct {
+	flag, env string
+	value     server.RateLimit
+	limiter   func(server.RateLimiters) *server.RateLimiter
+}{
+	{"rate-limit-authenticated", "RATE_LIMIT_AUTHENTICATED", server.DEFAULT_AUTHENTICATED_RATE_LIMIT,
+		func(l server.RateLimiters) *server.RateLimiter { return l.Authenticated }},
+	{"rate-limit-public", "RATE_LIMIT_PUBLIC", server.DEFAULT_PUBLIC_RATE_LIMIT,
+		func(l server.RateLimiters) *server.RateLimiter { return l.Public }},
+	{"rate-limit-login", "RATE_LIMIT_LOGIN", server.DEFAULT_LOGIN_RATE_LIMIT,
+		func(l server.RateLimiters) *server.RateLimiter { return l.Login }},
+	{"rate-limit-signup", "RATE_LIMIT_SIGNUP", server.DEFAULT_SIGNUP_RATE_LIMIT,
+		func(l server.RateLimiters) *server.RateLimiter { return l.Signup }},
+	{"rate-limit-move", "RATE_LIMIT_MOVE", server.DEFAULT_MOVE_RATE_LIMIT,
+		func(l server.RateLimiters) *server.RateLimiter { return l.Move }},
+}
+
+func envOr(name, fallback string) string {
+	if env := os.Getenv(name); env != "" {
+		return env
+	}
+	return fallback
+}
+
+// loadConfig parses args (without the program name) and validates the result.
+func loadConfig(args []string) (Config, error) {
+	var c Config
+	fs := flag.NewFlagSet("api", flag.ContinueOnError)
+	fs.StringVar(&c.Addr, "addr", envOr("ADDR", ":8080"), "address to listen on (ADDR)")
+	fs.StringVar(&c.GRPCAddr, "grpc-addr", os.Getenv("GRPC_ADDR"),
+		"address the gRPC api listens on, like :9090. It uses the certificates of the HTTP server. Empty turns it off (GRPC_ADDR)")
+	fs.StringVar(&c.Database, "db", envOr("DATABASE", "sqlite.db"), "sqlite database (DATABASE)")
+	dbBusyTimeout := fs.String("db-busy-timeout", envOr("DB_BUSY_TIMEOUT", DEFAULT_DB_BUSY_TIMEOUT.String()),
+		"how long to wait for a locked database before failing (DB_BUSY_TIMEOUT)")
+	dbMaxOpenConns := fs.String("db-max-open-conns", envOr("DB_MAX_OPEN_CONNS", strconv.Itoa(DEFAULT_DB_MAX_OPEN_CONNS)),
+		"database connections in the pool (DB_MAX_OPEN_CONNS)")
+	fs.StringVar(&c.JWTSecretFile, "jwt-secret-file", envOr("JWT_SECRET_FILE", "JWT_SECRET"),
+		"file with the api key signing secret, created if missing (JWT_SECRET_FILE). The JWT_SECRET environment variable takes precedence")
+	fs.StringVar(&c.BannedWordsFile, "banned-words", envOr("BANNED_WORDS_FILE", "BANNED_WORDS"),
+		"file of banned words, one per line (BANNED_WORDS_FILE)")
+	admins := fs.String("admins", os.Getenv("ADMINS"), "comma separated usernames that are made admins (ADMINS)")
+	shutdownTimeout := fs.String("shutdown-timeout", envOr("SHUTDOWN_TIMEOUT", server.SHUTDOWN_TIMEOUT.String()),
+		"how long to wait for connections to close when shutting down (SHUTDOWN_TIMEOUT)")
+	rateLimits := make([]*string, len(rateLimitOptions))
+	for i, o := range rateLimitOptions {
+		rateLimits[i] = fs.String(o.flag, envOr(o.env, formatRateLimit(o.value)),
+			fmt.Sprintf(`requests per window like "10/1m", or "off" (%s)`, o.env))
+	}
+	passwordHash := fs.String("password-hash", envOr("PASSWORD_HASH", server.DEFAULT_PASSWORD_HASHER.Algorithm),
+		"bcrypt or argon2id. Existing passwords are rehashed when their owner logs in (PASSWORD_HASH)")
+	bcryptCost := fs.String("bcrypt-cost", envOr("BCRYPT_COST", strconv.Itoa(server.DEFAULT_PASSWORD_HASHER.BcryptCost)), "(BCRYPT_COST)")
+	argon2Memory := fs.String("argon2-memory", envOr("ARGON2_MEMORY", fmt.Sprint(server.DEFAULT_PASSWORD_HASHER.Argon2.Memory)), "KiB (ARGON2_MEMORY)")
+	argon2Time := fs.String("argon2-time", envOr("ARGON2_TIME", fmt.Sprint(server.DEFAULT_PASSWORD_HASHER.Argon2.Time)), "(ARGON2_TIME)")
+	argon2Threads := fs.String("argon2-threads", envOr("ARGON2_THREADS", fmt.Sprint(server.DEFAULT_PASSWORD_HASHER.Argon2.Threads)), "(ARGON2_THREADS)")
+	fs.StringVar(&c.TLSCertFile, "tls-cert", os.Getenv("TLS_CERT_FILE"), "serve HTTPS with this certificate file (TLS_CERT_FILE)")
+	fs.StringVar(&c.TLSKeyFile, "tls-key", os.Getenv("TLS_KEY_FILE"), "private key of -tls-cert (TLS_KEY_FILE)")
+	autocertDomains := fs.String("autocert-domains", os.Getenv("AUTOCERT_DOMAINS"),
+		"comma separated domains to get certificates for from Let's Encrypt, use with -addr :443 (AUTOCERT_DOMAINS)")
+	fs.StringVar(&c.AutocertCacheDir, "autocert-cache", envOr("AUTOCERT_CACHE_DIR", "certs"),
+		"directory where certificates from Let's Encrypt are stored (AUTOCERT_CACHE_DIR)")
+	fs.StringVar(&c.AutocertEmail, "autocert-email", os.Getenv("AUTOCERT_EMAIL"),
+		"contact address for Let's Encrypt (AUTOCERT_EMAIL)")
+	fs.StringVar(&c.AutocertHTTPAddr, "autocert-http-addr", envOr("AUTOCERT_HTTP_ADDR", ":80"),
+		"address that answers Let's Encrypt challenges and redirects to HTTPS, must be reachable on port 80 (AUTOCERT_HTTP_ADDR)")
+	signupDifficulty := fs.String("signup-challenge-difficulty", envOr("SIGNUP_CHALLENGE_DIFFICULTY", "0"),
+		"require a proof-of-work with this many leading zero bits to sign up, 0 turns it off. 20 takes about a second (SIGNUP_CHALLENGE_DIFFICULTY)")
+	signupsPerIP := fs.String("signups-per-ip", envOr("SIGNUPS_PER_IP", formatRateLimit(server.DEFAULT_SIGNUPS_PER_IP)),
+		`accounts that can be created from one IP, like "10/24h", or "off" (SIGNUPS_PER_IP)`)
+	maxMatches := fs.String("max-matches-per-user", envOr("MAX_MATCHES_PER_USER", strconv.Itoa(server.DEFAULT_MAX_MATCHES_PER_USER)),
+		"unfinished matches a user can have created, 0 for no limit (MAX_MATCHES_PER_USER)")
+	maxStreams := fs.String("max-streams-per-user", envOr("MAX_STREAMS_PER_USER", strconv.Itoa(server.DEFAULT_MAX_STREAMS_PER_USER)),
+		"open match and notification streams per user, 0 for no limit (MAX_STREAMS_PER_USER)")
+	fs.StringVar(&c.RedisURL, "redis-url", os.Getenv("REDIS_URL"),
+		"share matches with other replicas through Redis, like redis://localhost:6379/0 (REDIS_URL)")
+	fs.StringVar(&c.BackupDir, "backup-dir", envOr("BACKUP_DIR", server.DEFAULT_BACKUP_DIR),
+		"directory that POST /admin/backup writes backups to (BACKUP_DIR)")
+	fs.StringVar(&c.RestoreFrom, "restore", os.Getenv("RESTORE_FROM"),
+		"replace the database with this backup before starting, the old one is kept with a .before-restore suffix. -db must be a file path (RESTORE_FROM)")
+	fs.StringVar(&c.Engine, "engine", os.Getenv("ENGINE"),
+		"UCI engine executable the computer plays with, like stockfish. Matches against the computer are off without one (ENGINE)")
+	fs.StringVar(&c.EngineUsername, "engine-username", envOr("ENGINE_USERNAME", server.DEFAULT_COMPUTER_USERNAME),
+		"bot account the computer plays as, created if missing (ENGINE_USERNAME)")
+	engineProcesses := fs.String("engine-processes", envOr("ENGINE_PROCESSES", strconv.Itoa(server.DEFAULT_ENGINE_PROCESSES)),
+		"engine processes that run at the same time (ENGINE_PROCESSES)")
+	fs.BoolVar(&c.MinePuzzles, "mine-puzzles", os.Getenv("MINE_PUZZLES") == "true",
+		"analyze finished games with the engine and turn positions with one winning move into puzzles (MINE_PUZZLES=true)")
+	fs.BoolVar(&c.FairPlay, "fair-play", os.Getenv("FAIR_PLAY") == "true",
+		"analyze rated games with the engine and flag players who seem to use one, see GET /admin/fair-play (FAIR_PLAY=true)")
+	fs.BoolVar(&c.WebhooksAllowPrivate, "webhooks-allow-private", os.Getenv("WEBHOOKS_ALLOW_PRIVATE") == "true",
+		"let webhooks reach localhost and private networks, for development (WEBHOOKS_ALLOW_PRIVATE)")
+	fs.StringVar(&c.SMTPAddr, "smtp-addr", os.Getenv("SMTP_ADDR"),
+		"SMTP server like smtp.example.com:587 that emails players about their matches while they are offline, empty turns emails off (SMTP_ADDR)")
+	fs.StringVar(&c.SMTPUsername, "smtp-username", os.Getenv("SMTP_USERNAME"),
+		"user to log in to the SMTP server as, the password is read from SMTP_PASSWORD (SMTP_USERNAME)")
+	fs.StringVar(&c.SMTPFrom, "smtp-from", os.Getenv("SMTP_FROM"), "sender address of emails (SMTP_FROM)")
+	fs.StringVar(&c.PublicURL, "public-url", os.Getenv("PUBLIC_URL"),
+		"URL the server is reached at, like https://chess.example.com, for links in emails (PUBLIC_URL)")
+	trustedProxies := fs.String("trusted-proxies", os.Getenv("TRUSTED_PROXIES"),
+		"comma separated IPs or CIDR ranges of reverse proxies, the client IP is taken from their X-Forwarded-For header. "+
+			"Empty uses the address of the connection (TRUSTED_PROXIES)")
+	fs.BoolVar(&c.Seed, "seed", os.Getenv("SEED") == "true",
+		"create demo users and games, and start a few matches, for local development (SEED=true)")
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+	c.JWTSecret = os.Getenv("JWT_SECRET")
+	c.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+
+	if _, _, err := net.SplitHostPort(c.Addr); err != nil {
+		return Config{}, fmt.Errorf("invalid listen address %q: %w", c.Addr, err)
+	}
+	if c.GRPCAddr != "" {
+		if _, _, err := net.SplitHostPort(c.GRPCAddr); err != nil {
+			return Config{}, fmt.Errorf("invalid grpc-addr %q: %w", c.GRPCAddr, err)
+		}
+	}
+	if c.Database == "" {
+		return Config{}, errors.New("database must not be empty")
+	}
+	if c.JWTSecret != "" && len(c.JWTSecret) < MIN_JWT_SECRET_LENGTH {
+		return Config{}, fmt.Errorf("JWT_SECRET must be at least %d characters", MIN_JWT_SECRET_LENGTH)
+	}
+	if c.JWTSecret == "" && c.JWTSecretFile == "" {
+		return Config{}, errors.New("either JWT_SECRET or a jwt secret file is required")
+	}
+	if c.BackupDir == "" {
+		return Config{}, errors.New("backup-dir must not be empty")
+	}
+	c.Admins = splitList(*admins)
+	for _, proxy := range splitList(*trustedProxies) {
+		_, ipNet, err := net.ParseCIDR(proxy)
+		// a single address is a range of one
+		if ip := net.ParseIP(proxy); ip != nil {
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+			}
+			ipNet, err = &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)}, nil
+		}
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid trusted-proxies %q: must be IPs or CIDR ranges", proxy)
+		}
+		c.TrustedProxies = append(c.TrustedProxies, ipNet)
+	}
+	c.AutocertDomains = splitList(*autocertDomains)
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return Config{}, errors.New("tls-cert and tls-key must be used together")
+	}
+	if c.TLSCertFile != "" && len(c.AutocertDomains) > 0 {
+		return Config{}, errors.New("use either tls-cert or autocert-domains, not both")
+	}
+	if len(c.AutocertDomains) > 0 {
+		if c.AutocertCacheDir == "" {
+			return Config{}, errors.New("autocert-cache must not be empty")
+		}
+		if _, _, err := net.SplitHostPort(c.AutocertHTTPAddr); err != nil {
+			return Config{}, fmt.Errorf("invalid autocert-http-addr %q: %w", c.AutocertHTTPAddr, err)
+		}
+	}
+	var err error
+	if c.ShutdownTimeout, err = time.ParseDuration(*shutdownTimeout); err != nil || c.ShutdownTimeout <= 0 {
+		return Config{}, fmt.Errorf("invalid shutdown timeout %q", *shutdownTimeout)
+	}
+	if c.DBBusyTimeout, err = time.ParseDuration(*dbBusyTimeout); err != nil || c.DBBusyTimeout < 0 {
+		return Config{}, fmt.Errorf("invalid db-busy-timeout %q", *dbBusyTimeout)
+	}
+	if c.DBMaxOpenConns, err = strconv.Atoi(*dbMaxOpenConns); err != nil || c.DBMaxOpenConns < 1 {
+		return Config{}, fmt.Errorf("db-max-open-conns must be a positive number: %q", *dbMaxOpenConns)
+	}
+	if c.SignupChallengeDifficulty, err = strconv.Atoi(*signupDifficulty); err != nil ||
+		c.SignupChallengeDifficulty < 0 || c.SignupChallengeDifficulty > server.MAX_SIGNUP_CHALLENGE_DIFFICULTY {
+		return Config{}, fmt.Errorf("signup-challenge-difficulty must be between 0 and %d", server.MAX_SIGNUP_CHALLENGE_DIFFICULTY)
+	}
+	if c.MaxMatchesPerUser, err = strconv.Atoi(*maxMatches); err != nil || c.MaxMatchesPerUser < 0 {
+		return Config{}, fmt.Errorf("max-matches-per-user must be a number, 0 or more: %q", *maxMatches)
+	}
+	if c.MaxStreamsPerUser, err = strconv.Atoi(*maxStreams); err != nil || c.MaxStreamsPerUser < 0 {
+		return Config{}, fmt.Errorf("max-streams-per-user must be a number, 0 or more: %q", *maxStreams)
+	}
+	if c.EngineProcesses, err = strconv.Atoi(*engineProcesses); err != nil || c.EngineProcesses < 1 {
+		return Config{}, fmt.Errorf("engine-processes must be a positive number: %q", *engineProcesses)
+	}
+	if c.MinePuzzles && c.Engine == "" {
+		return Config{}, errors.New("mine-puzzles needs an engine")
+	}
+	if c.FairPlay && c.Engine == "" {
+		return Config{}, errors.New("fair-play needs an engine")
+	}
+	if c.Engine != "" && c.EngineUsername == "" {
+		return Config{}, errors.New("engine-username must not be empty")
+	}
+	if c.SMTPAddr != "" {
+		if _, _, err := net.SplitHostPort(c.SMTPAddr); err != nil {
+			return Config{}, fmt.Errorf("invalid smtp-addr %q: %w", c.SMTPAddr, err)
+		}
+		if c.SMTPFrom == "" || c.PublicURL == "" {
+			return Config{}, errors.New("emails need smtp-from and public-url")
+		}
+	}
+	if c.SignupsPerIP, err = server.ParseRateLimit(*signupsPerIP); err != nil {
+		return Config{}, fmt.Errorf("invalid signups-per-ip: %w", err)
+	}
+	for i, o := range rateLimitOptions {
+		limit, err := server.ParseRateLimit(*rateLimits[i])
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid %s: %w", o.flag, err)
+		}
+		c.RateLimits = append(c.RateLimits, limit)
+	}
+
+	h := server.DEFAULT_PASSWORD_HASHER
+	h.Algorithm = *passwordHash
+	number := func(name, value string, bits int) (uint64, error) {
+		n, err := strconv.ParseUint(value, 10, bits)
+		if err != nil {
+			return 0, fmt.Errorf("%s must be a positive number that fits in %d bits: %q", name, bits, value)
+		}
+		return n, nil
+	}
+	cost, err := number("bcrypt-cost", *bcryptCost, 8)
+	if err != nil {
+		return Config{}, err
+	}
+	memory, err := number("argon2-memory", *argon2Memory, 32)
+	if err != nil {
+		return Config{}, err
+	}
+	iterations, err := number("argon2-time", *argon2Time, 32)
+	if err != nil {
+		return Config{}, err
+	}
+	threads, err := number("argon2-threads", *argon2Threads, 8)
+	if err != nil {
+		return Config{}, err
+	}
+	h.BcryptCost = int(cost)
+	h.Argon2 = server.Argon2Params{Memory: uint32(memory), Time: uint32(iterations), Threads: uint8(threads)}
+	if err := h.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid password hashing config: %w", err)
+	}
+	c.Passwords = h
+	return c, nil
+}
+
+// applyRateLimits sets the configured limits on the server's limiters
+func (c Config) applyRateLimits(limiters server.RateLimiters) {
+	for i, o := range rateLimitOptions {
+		o.limiter(limiters).SetLimit(c.RateLimits[i])
+	}
+}
+
+// ipExtractor is how the client IP that rate limits, login throttling and the audit log use is found.
+// Clients can send any X-Forwarded-For header, so it is only read from requests of the trusted proxies.
+func (c Config) ipExtractor() echo.IPExtractor {
+	if len(c.TrustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range c.TrustedProxies {
+		options = append(options, echo.TrustIPRange(proxy))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// splitList splits a comma separated list and drops empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func formatRateLimit(l server.RateLimit) string {
+	if l.Requests == 0 {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, l.Window)
+}
+
+// jwtSecret returns JWT_SECRET, or the contents of the secret file.
+// A random secret is written to the file if it doesn't exist.
+func (c Config) jwtSecret() ([]byte, error) {
+	if c.JWTSecret != "" {
+		return []byte(c.JWTSecret), nil
+	}
+	secret, err := os.ReadFile(c.JWTSecretFile)
+	if errors.Is(err, os.ErrNotExist) {
+		secret = []byte(rand.Text())
+		if err := os.WriteFile(c.JWTSecretFile, secret, 0o600); err != nil {
+			return nil, fmt.Errorf("failed to write jwt secret: %w", err)
+		}
+		return secret, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jwt secret: %w", err)
+	}
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("jwt secret file %s is empty", c.JWTSecretFile)
+	}
+	return secret, nil
+}
+
+// bannedWords reads the banned words file. It is nil if the file doesn't exist.
+func (c Config) bannedWords() []string {
+	words, err := os.ReadFile(c.BannedWordsFile)
+	if err != nil {
+		return nil
+	}
+	return strings.Split(string(words), "\n")
+}
@@ -0,0 +1,51 @@
+// opening the sqlite database
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// how long a connection waits for another one to finish writing, before failing with "database is locked"
+	DEFAULT_DB_BUSY_TIMEOUT = 5 * time.Second
+	// WAL lets readers work while one connection writes, more connections mostly wait on each other
+	DEFAULT_DB_MAX_OPEN_CONNS = 8
+)
+
+// per connection pragmas. journal_mode = WAL is set by the schema and stays set in the file.
+func sqlitePragmas(busyTimeout time.Duration) []string {
+	return []string{
+		fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds()),
+		"foreign_keys(1)",
+		// safe with WAL, only the last transactions can be lost on power failure
+		"synchronous(NORMAL)",
+	}
+}
+
+// sqliteDSN adds the connection pragmas to the database from the config, which can be a file path or a DSN.
+func sqliteDSN(database string, busyTimeout time.Duration) string {
+	params := make([]string, 0, 3)
+	for _, pragma := range sqlitePragmas(busyTimeout) {
+		params = append(params, "_pragma="+url.QueryEscape(pragma))
+	}
+	separator := "?"
+	if strings.Contains(database, "?") {
+		separator = "&"
+	}
+	return database + separator + strings.Join(params, "&")
+}
+
+// openDatabase opens the database with the configured pragmas and connection limits.
+func (c Config) openDatabase() (*sql.DB, error) {
+	conn, err := sql.Open("sqlite", sqliteDSN(c.Database, c.DBBusyTimeout))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetMaxOpenConns(c.DBMaxOpenConns)
+	conn.SetMaxIdleConns(c.DBMaxOpenConns)
+	return conn, nil
+}
@@ -5,9 +5,66 @@
 package db
 
 import (
+	"database/sql"
 	"time"
 )
 
+type AuditLog struct {
+	ID        int64
+	Action    string
+	Actor     string
+	Target    string
+	Ip        string
+	Details   string
+	CreatedAt time.Time
+}
+
+type Block struct {
+	BlockerUid int64
+	BlockedUid int64
+	CreatedAt  time.Time
+}
+
+type ChatMessage struct {
+	ID       int64
+	MatchID  string
+	Username string
+	Message  string
+	SentAt   time.Time
+}
+
+type ExplorerMove struct {
+	Position  string
+	Move      string
+	WhiteWins int64
+	Draws     int64
+	BlackWins int64
+}
+
+type FairPlayAnalysis struct {
+	GameID            int64
+	Uid               int64
+	Moves             int64
+	EngineMatches     int64
+	AverageMoveTime   int64
+	MoveTimeDeviation int64
+	Suspicion         int64
+	CreatedAt         time.Time
+}
+
+type FairPlayQueue struct {
+	GameID    int64
+	MoveTimes string
+	CreatedAt time.Time
+}
+
+type Friendship struct {
+	RequesterUid int64
+	AddresseeUid int64
+	Accepted     bool
+	CreatedAt    time.Time
+}
+
 type Game struct {
 	ID         int64
 	WhiteUid   int64
@@ -15,6 +72,145 @@ type Game struct {
 	Result     string
 	Moves      string
 	FinishedAt time.Time
+	Eco        string
+	Opening    string
+	MatchID    string
+	Method     string
+	FinalFen   string
+}
+
+type ImportedGame struct {
+	ID         string
+	Uid        int64
+	White      string
+	Black      string
+	Result     string
+	Pgn        string
+	Eco        string
+	Opening    string
+	ImportedAt time.Time
+}
+
+type MatchMove struct {
+	MatchID  string
+	Ply      int64
+	Move     string
+	PlayedAt time.Time
+}
+
+type Notification struct {
+	ID           int64
+	Uid          int64
+	Type         string
+	FromUsername string
+	MatchID      string
+	Read         bool
+	CreatedAt    time.Time
+}
+
+type Puzzle struct {
+	ID        int64
+	GameID    int64
+	Ply       int64
+	Fen       string
+	Solution  string
+	CreatedAt time.Time
+}
+
+type PuzzleScan struct {
+	GameID    int64
+	Puzzles   int64
+	ScannedAt time.Time
+}
+
+type Report struct {
+	ID               int64
+	ReporterUid      sql.NullInt64
+	ReportedUsername string
+	MatchID          string
+	Category         string
+	Details          string
+	Resolved         bool
+	CreatedAt        time.Time
+}
+
+type Study struct {
+	ID        string
+	OwnerUid  int64
+	Name      string
+	Tree      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type StudyMember struct {
+	StudyID string
+	Uid     int64
+}
+
+type SuspendedMatch struct {
+	ID            string
+	Rated         bool
+	WhiteUsername string
+	BlackUsername string
+	Moves         string
+	StartTime     time.Time
+	EndTime       time.Time
+	ComputerLevel int64
+}
+
+type Team struct {
+	ID          string
+	OwnerUid    int64
+	Name        string
+	Description string
+	CreatedAt   time.Time
+}
+
+type TeamMatch struct {
+	MatchID   string
+	TeamID    string
+	CreatedAt time.Time
+}
+
+type TeamMember struct {
+	TeamID   string
+	Uid      int64
+	Role     string
+	JoinedAt time.Time
+}
+
+type TeamTournament struct {
+	TournamentID string
+	TeamID       string
+}
+
+type Tournament struct {
+	ID           string
+	OwnerUid     int64
+	Name         string
+	Rounds       int64
+	Duration     int64
+	Rated        bool
+	Status       string
+	CurrentRound int64
+	CreatedAt    time.Time
+}
+
+type TournamentPairing struct {
+	TournamentID string
+	Round        int64
+	WhiteUid     int64
+	BlackUid     int64
+	MatchID      string
+	Result       string
+}
+
+type TournamentPlayer struct {
+	TournamentID string
+	Uid          int64
+	Withdrawn    bool
+	JoinedAt     time.Time
 }
 
 type User struct {
@@ -22,5 +218,33 @@ type User struct {
 	Username     string
 	PasswordHash string
 	ApiKey       string
+	IsGuest      bool
+	Preferences  string
+	Email        string
+	IsAdmin      bool
+	IsBot        bool
+	Banned       bool
 	CreatedAt    time.Time
+	DeletedAt    sql.NullTime
+}
+
+type Webhook struct {
+	ID        int64
+	Uid       int64
+	Url       string
+	Secret    string
+	MatchID   string
+	CreatedAt time.Time
+}
+
+type WebhookDelivery struct {
+	ID         int64
+	WebhookID  int64
+	DeliveryID string
+	Event      string
+	Attempt    int64
+	StatusCode int64
+	Error      string
+	DurationMs int64
+	CreatedAt  time.Time
 }
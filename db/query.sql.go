@@ -7,243 +7,3148 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"time"
 )
 
-const createUser = `-- name: CreateUser :one
-INSERT INTO users (username, password_hash, api_key)
+const acceptFriendRequest = `-- name: AcceptFriendRequest :execrows
+UPDATE friendships
+SET accepted = TRUE
+WHERE requester_uid = ? AND addressee_uid = ? AND NOT accepted
+`
+
+type AcceptFriendRequestParams struct {
+	RequesterUid int64
+	AddresseeUid int64
+}
+
+func (q *Queries) AcceptFriendRequest(ctx context.Context, arg AcceptFriendRequestParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, acceptFriendRequest, arg.RequesterUid, arg.AddresseeUid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const addExplorerMove = `-- name: AddExplorerMove :exec
+INSERT INTO explorer_moves (position, move, white_wins, draws, black_wins)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (position, move) DO UPDATE
+SET white_wins = white_wins + excluded.white_wins,
+    draws = draws + excluded.draws,
+    black_wins = black_wins + excluded.black_wins
+`
+
+type AddExplorerMoveParams struct {
+	Position  string
+	Move      string
+	WhiteWins int64
+	Draws     int64
+	BlackWins int64
+}
+
+func (q *Queries) AddExplorerMove(ctx context.Context, arg AddExplorerMoveParams) error {
+	_, err := q.db.ExecContext(ctx, addExplorerMove,
+		arg.Position,
+		arg.Move,
+		arg.WhiteWins,
+		arg.Draws,
+		arg.BlackWins,
+	)
+	return err
+}
+
+const addStudyMember = `-- name: AddStudyMember :execrows
+INSERT OR IGNORE INTO study_members (study_id, uid)
+VALUES (?, ?)
+`
+
+type AddStudyMemberParams struct {
+	StudyID string
+	Uid     int64
+}
+
+func (q *Queries) AddStudyMember(ctx context.Context, arg AddStudyMemberParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, addStudyMember, arg.StudyID, arg.Uid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const addTeamMember = `-- name: AddTeamMember :execrows
+INSERT OR IGNORE INTO team_members (team_id, uid, role)
 VALUES (?, ?, ?)
-RETURNING uid, username, password_hash, api_key, created_at
 `
 
-type CreateUserParams struct {
-	Username     string
-	PasswordHash string
-	ApiKey       string
+type AddTeamMemberParams struct {
+	TeamID string
+	Uid    int64
+	Role   string
 }
 
-func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Username, arg.PasswordHash, arg.ApiKey)
+func (q *Queries) AddTeamMember(ctx context.Context, arg AddTeamMemberParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, addTeamMember, arg.TeamID, arg.Uid, arg.Role)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const addTournamentPlayer = `-- name: AddTournamentPlayer :execrows
+INSERT OR IGNORE INTO tournament_players (tournament_id, uid)
+VALUES (?, ?)
+`
+
+type AddTournamentPlayerParams struct {
+	TournamentID string
+	Uid          int64
+}
+
+func (q *Queries) AddTournamentPlayer(ctx context.Context, arg AddTournamentPlayerParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, addTournamentPlayer, arg.TournamentID, arg.Uid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const anonymizeGamesOfPlayer = `-- name: AnonymizeGamesOfPlayer :exec
+UPDATE games
+SET white_uid = CASE WHEN white_uid = ?1 THEN 0 ELSE white_uid END,
+    black_uid = CASE WHEN black_uid = ?1 THEN 0 ELSE black_uid END
+WHERE white_uid = ?1 OR black_uid = ?1
+`
+
+func (q *Queries) AnonymizeGamesOfPlayer(ctx context.Context, uid int64) error {
+	_, err := q.db.ExecContext(ctx, anonymizeGamesOfPlayer, uid)
+	return err
+}
+
+const countSignupsFromIP = `-- name: CountSignupsFromIP :one
+SELECT COUNT(*) FROM audit_log
+WHERE ip = ?1 AND action = 'account.created' AND created_at >= ?2
+`
+
+type CountSignupsFromIPParams struct {
+	Ip    string
+	Since time.Time
+}
+
+func (q *Queries) CountSignupsFromIP(ctx context.Context, arg CountSignupsFromIPParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSignupsFromIP, arg.Ip, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUnfinishedTournamentPairings = `-- name: CountUnfinishedTournamentPairings :one
+SELECT COUNT(*) FROM tournament_pairings
+WHERE tournament_id = ? AND result = ''
+`
+
+func (q *Queries) CountUnfinishedTournamentPairings(ctx context.Context, tournamentID string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUnfinishedTournamentPairings, tournamentID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countWebhooksOfUser = `-- name: CountWebhooksOfUser :one
+SELECT COUNT(*) FROM webhooks
+WHERE uid = ?
+`
+
+func (q *Queries) CountWebhooksOfUser(ctx context.Context, uid int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countWebhooksOfUser, uid)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAuditEvent = `-- name: CreateAuditEvent :exec
+INSERT INTO audit_log (action, actor, target, ip, details)
+VALUES (?, ?, ?, ?, ?)
+`
+
+type CreateAuditEventParams struct {
+	Action  string
+	Actor   string
+	Target  string
+	Ip      string
+	Details string
+}
+
+func (q *Queries) CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) error {
+	_, err := q.db.ExecContext(ctx, createAuditEvent,
+		arg.Action,
+		arg.Actor,
+		arg.Target,
+		arg.Ip,
+		arg.Details,
+	)
+	return err
+}
+
+const createBlock = `-- name: CreateBlock :exec
+INSERT OR IGNORE INTO blocks (blocker_uid, blocked_uid)
+VALUES (?, ?)
+`
+
+type CreateBlockParams struct {
+	BlockerUid int64
+	BlockedUid int64
+}
+
+func (q *Queries) CreateBlock(ctx context.Context, arg CreateBlockParams) error {
+	_, err := q.db.ExecContext(ctx, createBlock, arg.BlockerUid, arg.BlockedUid)
+	return err
+}
+
+const createChatMessage = `-- name: CreateChatMessage :one
+INSERT INTO chat_messages (match_id, username, message, sent_at)
+VALUES (?, ?, ?, ?)
+RETURNING id, match_id, username, message, sent_at
+`
+
+type CreateChatMessageParams struct {
+	MatchID  string
+	Username string
+	Message  string
+	SentAt   time.Time
+}
+
+func (q *Queries) CreateChatMessage(ctx context.Context, arg CreateChatMessageParams) (ChatMessage, error) {
+	row := q.db.QueryRowContext(ctx, createChatMessage,
+		arg.MatchID,
+		arg.Username,
+		arg.Message,
+		arg.SentAt,
+	)
+	var i ChatMessage
+	err := row.Scan(
+		&i.ID,
+		&i.MatchID,
+		&i.Username,
+		&i.Message,
+		&i.SentAt,
+	)
+	return i, err
+}
+
+const createFriendRequest = `-- name: CreateFriendRequest :exec
+INSERT INTO friendships (requester_uid, addressee_uid)
+VALUES (?, ?)
+`
+
+type CreateFriendRequestParams struct {
+	RequesterUid int64
+	AddresseeUid int64
+}
+
+func (q *Queries) CreateFriendRequest(ctx context.Context, arg CreateFriendRequestParams) error {
+	_, err := q.db.ExecContext(ctx, createFriendRequest, arg.RequesterUid, arg.AddresseeUid)
+	return err
+}
+
+const createGuestUser = `-- name: CreateGuestUser :one
+INSERT INTO users (username, password_hash, api_key, is_guest)
+VALUES (?, '', ?, TRUE)
+RETURNING uid, username, password_hash, api_key, is_guest, preferences, email, is_admin, is_bot, banned, created_at, deleted_at
+`
+
+type CreateGuestUserParams struct {
+	Username string
+	ApiKey   string
+}
+
+func (q *Queries) CreateGuestUser(ctx context.Context, arg CreateGuestUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, createGuestUser, arg.Username, arg.ApiKey)
 	var i User
 	err := row.Scan(
 		&i.Uid,
 		&i.Username,
 		&i.PasswordHash,
 		&i.ApiKey,
+		&i.IsGuest,
+		&i.Preferences,
+		&i.Email,
+		&i.IsAdmin,
+		&i.IsBot,
+		&i.Banned,
 		&i.CreatedAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
-const deleteGame = `-- name: DeleteGame :exec
-DELETE FROM games
-WHERE id = ?
+const createImportedGame = `-- name: CreateImportedGame :one
+INSERT INTO imported_games (id, uid, white, black, result, pgn, eco, opening)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, uid, white, black, result, pgn, eco, opening, imported_at
 `
 
-func (q *Queries) DeleteGame(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteGame, id)
+type CreateImportedGameParams struct {
+	ID      string
+	Uid     int64
+	White   string
+	Black   string
+	Result  string
+	Pgn     string
+	Eco     string
+	Opening string
+}
+
+func (q *Queries) CreateImportedGame(ctx context.Context, arg CreateImportedGameParams) (ImportedGame, error) {
+	row := q.db.QueryRowContext(ctx, createImportedGame,
+		arg.ID,
+		arg.Uid,
+		arg.White,
+		arg.Black,
+		arg.Result,
+		arg.Pgn,
+		arg.Eco,
+		arg.Opening,
+	)
+	var i ImportedGame
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.White,
+		&i.Black,
+		&i.Result,
+		&i.Pgn,
+		&i.Eco,
+		&i.Opening,
+		&i.ImportedAt,
+	)
+	return i, err
+}
+
+const createNotification = `-- name: CreateNotification :one
+INSERT INTO notifications (uid, type, from_username, match_id, created_at)
+VALUES (?, ?, ?, ?, ?)
+RETURNING id, uid, type, from_username, match_id, read, created_at
+`
+
+type CreateNotificationParams struct {
+	Uid          int64
+	Type         string
+	FromUsername string
+	MatchID      string
+	CreatedAt    time.Time
+}
+
+func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error) {
+	row := q.db.QueryRowContext(ctx, createNotification,
+		arg.Uid,
+		arg.Type,
+		arg.FromUsername,
+		arg.MatchID,
+		arg.CreatedAt,
+	)
+	var i Notification
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.Type,
+		&i.FromUsername,
+		&i.MatchID,
+		&i.Read,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createPuzzle = `-- name: CreatePuzzle :exec
+INSERT INTO puzzles (game_id, ply, fen, solution)
+VALUES (?, ?, ?, ?)
+`
+
+type CreatePuzzleParams struct {
+	GameID   int64
+	Ply      int64
+	Fen      string
+	Solution string
+}
+
+func (q *Queries) CreatePuzzle(ctx context.Context, arg CreatePuzzleParams) error {
+	_, err := q.db.ExecContext(ctx, createPuzzle,
+		arg.GameID,
+		arg.Ply,
+		arg.Fen,
+		arg.Solution,
+	)
 	return err
 }
 
-const deleteUser = `-- name: DeleteUser :exec
-DELETE FROM users
-WHERE uid = ?
+const createReport = `-- name: CreateReport :one
+INSERT INTO reports (reporter_uid, reported_username, match_id, category, details)
+VALUES (?, ?, ?, ?, ?)
+RETURNING id, reporter_uid, reported_username, match_id, category, details, resolved, created_at
 `
 
-func (q *Queries) DeleteUser(ctx context.Context, uid int64) error {
-	_, err := q.db.ExecContext(ctx, deleteUser, uid)
+type CreateReportParams struct {
+	ReporterUid      sql.NullInt64
+	ReportedUsername string
+	MatchID          string
+	Category         string
+	Details          string
+}
+
+func (q *Queries) CreateReport(ctx context.Context, arg CreateReportParams) (Report, error) {
+	row := q.db.QueryRowContext(ctx, createReport,
+		arg.ReporterUid,
+		arg.ReportedUsername,
+		arg.MatchID,
+		arg.Category,
+		arg.Details,
+	)
+	var i Report
+	err := row.Scan(
+		&i.ID,
+		&i.ReporterUid,
+		&i.ReportedUsername,
+		&i.MatchID,
+		&i.Category,
+		&i.Details,
+		&i.Resolved,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createStudy = `-- name: CreateStudy :one
+INSERT INTO studies (id, owner_uid, name, tree)
+VALUES (?, ?, ?, ?)
+RETURNING id, owner_uid, name, tree, created_at, updated_at
+`
+
+type CreateStudyParams struct {
+	ID       string
+	OwnerUid int64
+	Name     string
+	Tree     string
+}
+
+func (q *Queries) CreateStudy(ctx context.Context, arg CreateStudyParams) (Study, error) {
+	row := q.db.QueryRowContext(ctx, createStudy,
+		arg.ID,
+		arg.OwnerUid,
+		arg.Name,
+		arg.Tree,
+	)
+	var i Study
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerUid,
+		&i.Name,
+		&i.Tree,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createTeam = `-- name: CreateTeam :one
+INSERT INTO teams (id, owner_uid, name, description)
+VALUES (?, ?, ?, ?)
+RETURNING id, owner_uid, name, description, created_at
+`
+
+type CreateTeamParams struct {
+	ID          string
+	OwnerUid    int64
+	Name        string
+	Description string
+}
+
+func (q *Queries) CreateTeam(ctx context.Context, arg CreateTeamParams) (Team, error) {
+	row := q.db.QueryRowContext(ctx, createTeam,
+		arg.ID,
+		arg.OwnerUid,
+		arg.Name,
+		arg.Description,
+	)
+	var i Team
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerUid,
+		&i.Name,
+		&i.Description,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createTeamMatch = `-- name: CreateTeamMatch :exec
+INSERT OR REPLACE INTO team_matches (match_id, team_id)
+VALUES (?, ?)
+`
+
+type CreateTeamMatchParams struct {
+	MatchID string
+	TeamID  string
+}
+
+func (q *Queries) CreateTeamMatch(ctx context.Context, arg CreateTeamMatchParams) error {
+	_, err := q.db.ExecContext(ctx, createTeamMatch, arg.MatchID, arg.TeamID)
 	return err
 }
 
-const getGameById = `-- name: GetGameById :one
-SELECT id, white_uid, black_uid, result, moves, finished_at FROM games
-WHERE Id = ?
+const createTeamTournament = `-- name: CreateTeamTournament :exec
+INSERT INTO team_tournaments (tournament_id, team_id)
+VALUES (?, ?)
 `
 
-func (q *Queries) GetGameById(ctx context.Context, id int64) (Game, error) {
-	row := q.db.QueryRowContext(ctx, getGameById, id)
-	var i Game
+type CreateTeamTournamentParams struct {
+	TournamentID string
+	TeamID       string
+}
+
+func (q *Queries) CreateTeamTournament(ctx context.Context, arg CreateTeamTournamentParams) error {
+	_, err := q.db.ExecContext(ctx, createTeamTournament, arg.TournamentID, arg.TeamID)
+	return err
+}
+
+const createTournament = `-- name: CreateTournament :one
+INSERT INTO tournaments (id, owner_uid, name, rounds, duration, rated)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id, owner_uid, name, rounds, duration, rated, status, current_round, created_at
+`
+
+type CreateTournamentParams struct {
+	ID       string
+	OwnerUid int64
+	Name     string
+	Rounds   int64
+	Duration int64
+	Rated    bool
+}
+
+func (q *Queries) CreateTournament(ctx context.Context, arg CreateTournamentParams) (Tournament, error) {
+	row := q.db.QueryRowContext(ctx, createTournament,
+		arg.ID,
+		arg.OwnerUid,
+		arg.Name,
+		arg.Rounds,
+		arg.Duration,
+		arg.Rated,
+	)
+	var i Tournament
 	err := row.Scan(
 		&i.ID,
-		&i.WhiteUid,
-		&i.BlackUid,
-		&i.Result,
-		&i.Moves,
-		&i.FinishedAt,
+		&i.OwnerUid,
+		&i.Name,
+		&i.Rounds,
+		&i.Duration,
+		&i.Rated,
+		&i.Status,
+		&i.CurrentRound,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
-const getUserById = `-- name: GetUserById :one
-SELECT uid, username, password_hash, api_key, created_at FROM users
-WHERE uid = ?
+const createTournamentPairing = `-- name: CreateTournamentPairing :exec
+INSERT INTO tournament_pairings (tournament_id, round, white_uid, black_uid, match_id, result)
+VALUES (?, ?, ?, ?, ?, ?)
 `
 
-func (q *Queries) GetUserById(ctx context.Context, uid int64) (User, error) {
-	row := q.db.QueryRowContext(ctx, getUserById, uid)
+type CreateTournamentPairingParams struct {
+	TournamentID string
+	Round        int64
+	WhiteUid     int64
+	BlackUid     int64
+	MatchID      string
+	Result       string
+}
+
+func (q *Queries) CreateTournamentPairing(ctx context.Context, arg CreateTournamentPairingParams) error {
+	_, err := q.db.ExecContext(ctx, createTournamentPairing,
+		arg.TournamentID,
+		arg.Round,
+		arg.WhiteUid,
+		arg.BlackUid,
+		arg.MatchID,
+		arg.Result,
+	)
+	return err
+}
+
+const createUser = `-- name: CreateUser :one
+INSERT INTO users (username, password_hash, api_key)
+VALUES (?, ?, ?)
+RETURNING uid, username, password_hash, api_key, is_guest, preferences, email, is_admin, is_bot, banned, created_at, deleted_at
+`
+
+type CreateUserParams struct {
+	Username     string
+	PasswordHash string
+	ApiKey       string
+}
+
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, createUser, arg.Username, arg.PasswordHash, arg.ApiKey)
 	var i User
 	err := row.Scan(
 		&i.Uid,
 		&i.Username,
 		&i.PasswordHash,
 		&i.ApiKey,
+		&i.IsGuest,
+		&i.Preferences,
+		&i.Email,
+		&i.IsAdmin,
+		&i.IsBot,
+		&i.Banned,
+		&i.CreatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const createWebhook = `-- name: CreateWebhook :one
+INSERT INTO webhooks (uid, url, secret, match_id)
+VALUES (?, ?, ?, ?)
+RETURNING id, uid, url, secret, match_id, created_at
+`
+
+type CreateWebhookParams struct {
+	Uid     int64
+	Url     string
+	Secret  string
+	MatchID string
+}
+
+func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
+	row := q.db.QueryRowContext(ctx, createWebhook,
+		arg.Uid,
+		arg.Url,
+		arg.Secret,
+		arg.MatchID,
+	)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.Url,
+		&i.Secret,
+		&i.MatchID,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
-const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT uid, username, password_hash, api_key, created_at FROM users
-WHERE username = ?
+const createWebhookDelivery = `-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (webhook_id, delivery_id, event, attempt, status_code, error, duration_ms)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateWebhookDeliveryParams struct {
+	WebhookID  int64
+	DeliveryID string
+	Event      string
+	Attempt    int64
+	StatusCode int64
+	Error      string
+	DurationMs int64
+}
+
+func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) error {
+	_, err := q.db.ExecContext(ctx, createWebhookDelivery,
+		arg.WebhookID,
+		arg.DeliveryID,
+		arg.Event,
+		arg.Attempt,
+		arg.StatusCode,
+		arg.Error,
+		arg.DurationMs,
+	)
+	return err
+}
+
+const deleteBlock = `-- name: DeleteBlock :execrows
+DELETE FROM blocks
+WHERE blocker_uid = ? AND blocked_uid = ?
+`
+
+type DeleteBlockParams struct {
+	BlockerUid int64
+	BlockedUid int64
+}
+
+func (q *Queries) DeleteBlock(ctx context.Context, arg DeleteBlockParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteBlock, arg.BlockerUid, arg.BlockedUid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteBlocksOfUser = `-- name: DeleteBlocksOfUser :exec
+DELETE FROM blocks
+WHERE blocker_uid = ?1 OR blocked_uid = ?1
+`
+
+func (q *Queries) DeleteBlocksOfUser(ctx context.Context, uid int64) error {
+	_, err := q.db.ExecContext(ctx, deleteBlocksOfUser, uid)
+	return err
+}
+
+const deleteExpiredGuests = `-- name: DeleteExpiredGuests :execrows
+DELETE FROM users
+WHERE is_guest AND created_at < ?
+`
+
+func (q *Queries) DeleteExpiredGuests(ctx context.Context, createdAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredGuests, createdAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteFairPlayGame = `-- name: DeleteFairPlayGame :exec
+DELETE FROM fair_play_queue
+WHERE game_id = ?
+`
+
+func (q *Queries) DeleteFairPlayGame(ctx context.Context, gameID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteFairPlayGame, gameID)
+	return err
+}
+
+const deleteFriendship = `-- name: DeleteFriendship :execrows
+DELETE FROM friendships
+WHERE (requester_uid = ?1 AND addressee_uid = ?2)
+   OR (requester_uid = ?2 AND addressee_uid = ?1)
+`
+
+type DeleteFriendshipParams struct {
+	Uid      int64
+	OtherUid int64
+}
+
+func (q *Queries) DeleteFriendship(ctx context.Context, arg DeleteFriendshipParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFriendship, arg.Uid, arg.OtherUid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteFriendshipsOfUser = `-- name: DeleteFriendshipsOfUser :exec
+DELETE FROM friendships
+WHERE requester_uid = ?1 OR addressee_uid = ?1
+`
+
+func (q *Queries) DeleteFriendshipsOfUser(ctx context.Context, uid int64) error {
+	_, err := q.db.ExecContext(ctx, deleteFriendshipsOfUser, uid)
+	return err
+}
+
+const deleteGame = `-- name: DeleteGame :exec
+DELETE FROM games
+WHERE id = ?
+`
+
+func (q *Queries) DeleteGame(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteGame, id)
+	return err
+}
+
+const deleteNotificationsOfUser = `-- name: DeleteNotificationsOfUser :exec
+DELETE FROM notifications
+WHERE uid = ?
+`
+
+func (q *Queries) DeleteNotificationsOfUser(ctx context.Context, uid int64) error {
+	_, err := q.db.ExecContext(ctx, deleteNotificationsOfUser, uid)
+	return err
+}
+
+const deleteOldFairPlayGames = `-- name: DeleteOldFairPlayGames :execrows
+DELETE FROM fair_play_queue
+WHERE created_at < ?
+`
+
+// games nobody analyzed, when the server runs without the fair-play analysis
+func (q *Queries) DeleteOldFairPlayGames(ctx context.Context, createdAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOldFairPlayGames, createdAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteOldMatchMoves = `-- name: DeleteOldMatchMoves :execrows
+DELETE FROM match_moves
+WHERE played_at < ?
+`
+
+func (q *Queries) DeleteOldMatchMoves(ctx context.Context, playedAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOldMatchMoves, playedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteOldWebhookDeliveries = `-- name: DeleteOldWebhookDeliveries :execrows
+DELETE FROM webhook_deliveries
+WHERE created_at < ?
+`
+
+func (q *Queries) DeleteOldWebhookDeliveries(ctx context.Context, createdAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOldWebhookDeliveries, createdAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteStudy = `-- name: DeleteStudy :execrows
+DELETE FROM studies
+WHERE id = ? AND owner_uid = ?
+`
+
+type DeleteStudyParams struct {
+	ID       string
+	OwnerUid int64
+}
+
+func (q *Queries) DeleteStudy(ctx context.Context, arg DeleteStudyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteStudy, arg.ID, arg.OwnerUid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteSuspendedMatch = `-- name: DeleteSuspendedMatch :exec
+DELETE FROM suspended_matches
+WHERE id = ?
+`
+
+func (q *Queries) DeleteSuspendedMatch(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deleteSuspendedMatch, id)
+	return err
+}
+
+const deleteTeam = `-- name: DeleteTeam :execrows
+DELETE FROM teams
+WHERE id = ? AND owner_uid = ?
+`
+
+type DeleteTeamParams struct {
+	ID       string
+	OwnerUid int64
+}
+
+func (q *Queries) DeleteTeam(ctx context.Context, arg DeleteTeamParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteTeam, arg.ID, arg.OwnerUid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteTeamMatch = `-- name: DeleteTeamMatch :exec
+DELETE FROM team_matches
+WHERE match_id = ?
+`
+
+func (q *Queries) DeleteTeamMatch(ctx context.Context, matchID string) error {
+	_, err := q.db.ExecContext(ctx, deleteTeamMatch, matchID)
+	return err
+}
+
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users
+WHERE uid = ?
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, uid int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, uid)
+	return err
+}
+
+const deleteWebhook = `-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE id = ? AND uid = ?
+`
+
+type DeleteWebhookParams struct {
+	ID  int64
+	Uid int64
+}
+
+func (q *Queries) DeleteWebhook(ctx context.Context, arg DeleteWebhookParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteWebhook, arg.ID, arg.Uid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFriendship = `-- name: GetFriendship :one
+SELECT requester_uid, addressee_uid, accepted, created_at FROM friendships
+WHERE (requester_uid = ?1 AND addressee_uid = ?2)
+   OR (requester_uid = ?2 AND addressee_uid = ?1)
+`
+
+type GetFriendshipParams struct {
+	Uid      int64
+	OtherUid int64
+}
+
+func (q *Queries) GetFriendship(ctx context.Context, arg GetFriendshipParams) (Friendship, error) {
+	row := q.db.QueryRowContext(ctx, getFriendship, arg.Uid, arg.OtherUid)
+	var i Friendship
+	err := row.Scan(
+		&i.RequesterUid,
+		&i.AddresseeUid,
+		&i.Accepted,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getGameById = `-- name: GetGameById :one
+SELECT id, white_uid, black_uid, result, moves, finished_at, eco, opening, match_id, method, final_fen FROM games
+WHERE Id = ?
+`
+
+func (q *Queries) GetGameById(ctx context.Context, id int64) (Game, error) {
+	row := q.db.QueryRowContext(ctx, getGameById, id)
+	var i Game
+	err := row.Scan(
+		&i.ID,
+		&i.WhiteUid,
+		&i.BlackUid,
+		&i.Result,
+		&i.Moves,
+		&i.FinishedAt,
+		&i.Eco,
+		&i.Opening,
+		&i.MatchID,
+		&i.Method,
+		&i.FinalFen,
+	)
+	return i, err
+}
+
+const getImportedGame = `-- name: GetImportedGame :one
+SELECT id, uid, white, black, result, pgn, eco, opening, imported_at FROM imported_games
+WHERE id = ?
+`
+
+func (q *Queries) GetImportedGame(ctx context.Context, id string) (ImportedGame, error) {
+	row := q.db.QueryRowContext(ctx, getImportedGame, id)
+	var i ImportedGame
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.White,
+		&i.Black,
+		&i.Result,
+		&i.Pgn,
+		&i.Eco,
+		&i.Opening,
+		&i.ImportedAt,
+	)
+	return i, err
+}
+
+const getLatestGameByMatchId = `-- name: GetLatestGameByMatchId :one
+SELECT id, white_uid, black_uid, result, moves, finished_at, eco, opening, match_id, method, final_fen FROM games
+WHERE match_id = ?
+ORDER BY id DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestGameByMatchId(ctx context.Context, matchID string) (Game, error) {
+	row := q.db.QueryRowContext(ctx, getLatestGameByMatchId, matchID)
+	var i Game
+	err := row.Scan(
+		&i.ID,
+		&i.WhiteUid,
+		&i.BlackUid,
+		&i.Result,
+		&i.Moves,
+		&i.FinishedAt,
+		&i.Eco,
+		&i.Opening,
+		&i.MatchID,
+		&i.Method,
+		&i.FinalFen,
+	)
+	return i, err
+}
+
+const getPuzzle = `-- name: GetPuzzle :one
+SELECT puzzles.id, puzzles.game_id, puzzles.ply, puzzles.fen, puzzles.solution, puzzles.created_at, games.match_id,
+       COALESCE(white.username, '') AS white, COALESCE(black.username, '') AS black
+FROM puzzles
+JOIN games ON games.id = puzzles.game_id
+LEFT JOIN users AS white ON white.uid = games.white_uid
+LEFT JOIN users AS black ON black.uid = games.black_uid
+WHERE puzzles.id = ?
+`
+
+type GetPuzzleRow struct {
+	ID        int64
+	GameID    int64
+	Ply       int64
+	Fen       string
+	Solution  string
+	CreatedAt time.Time
+	MatchID   string
+	White     string
+	Black     string
+}
+
+// the players are empty if they deleted their account
+func (q *Queries) GetPuzzle(ctx context.Context, id int64) (GetPuzzleRow, error) {
+	row := q.db.QueryRowContext(ctx, getPuzzle, id)
+	var i GetPuzzleRow
+	err := row.Scan(
+		&i.ID,
+		&i.GameID,
+		&i.Ply,
+		&i.Fen,
+		&i.Solution,
+		&i.CreatedAt,
+		&i.MatchID,
+		&i.White,
+		&i.Black,
+	)
+	return i, err
+}
+
+const getRandomPuzzleId = `-- name: GetRandomPuzzleId :one
+SELECT id FROM puzzles
+ORDER BY RANDOM()
+LIMIT 1
+`
+
+func (q *Queries) GetRandomPuzzleId(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getRandomPuzzleId)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getStudy = `-- name: GetStudy :one
+SELECT studies.id, studies.name, studies.tree, studies.owner_uid, users.username AS owner, studies.created_at, studies.updated_at
+FROM studies
+JOIN users ON users.uid = studies.owner_uid
+WHERE studies.id = ?
+`
+
+type GetStudyRow struct {
+	ID        string
+	Name      string
+	Tree      string
+	OwnerUid  int64
+	Owner     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (q *Queries) GetStudy(ctx context.Context, id string) (GetStudyRow, error) {
+	row := q.db.QueryRowContext(ctx, getStudy, id)
+	var i GetStudyRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Tree,
+		&i.OwnerUid,
+		&i.Owner,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getTeam = `-- name: GetTeam :one
+SELECT teams.id, teams.owner_uid, teams.name, teams.description, teams.created_at, users.username AS owner,
+       (SELECT COUNT(*) FROM team_members WHERE team_members.team_id = teams.id) AS members
+FROM teams
+JOIN users ON users.uid = teams.owner_uid
+WHERE teams.id = ?
+`
+
+type GetTeamRow struct {
+	ID          string
+	OwnerUid    int64
+	Name        string
+	Description string
+	CreatedAt   time.Time
+	Owner       string
+	Members     int64
+}
+
+func (q *Queries) GetTeam(ctx context.Context, id string) (GetTeamRow, error) {
+	row := q.db.QueryRowContext(ctx, getTeam, id)
+	var i GetTeamRow
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerUid,
+		&i.Name,
+		&i.Description,
+		&i.CreatedAt,
+		&i.Owner,
+		&i.Members,
+	)
+	return i, err
+}
+
+const getTeamMemberRole = `-- name: GetTeamMemberRole :one
+SELECT role FROM team_members
+WHERE team_id = ? AND uid = ?
+`
+
+type GetTeamMemberRoleParams struct {
+	TeamID string
+	Uid    int64
+}
+
+func (q *Queries) GetTeamMemberRole(ctx context.Context, arg GetTeamMemberRoleParams) (string, error) {
+	row := q.db.QueryRowContext(ctx, getTeamMemberRole, arg.TeamID, arg.Uid)
+	var role string
+	err := row.Scan(&role)
+	return role, err
+}
+
+const getTeamOfMatch = `-- name: GetTeamOfMatch :one
+SELECT team_id FROM team_matches
+WHERE match_id = ?
+`
+
+func (q *Queries) GetTeamOfMatch(ctx context.Context, matchID string) (string, error) {
+	row := q.db.QueryRowContext(ctx, getTeamOfMatch, matchID)
+	var team_id string
+	err := row.Scan(&team_id)
+	return team_id, err
+}
+
+const getTournament = `-- name: GetTournament :one
+SELECT tournaments.id, tournaments.owner_uid, tournaments.name, tournaments.rounds, tournaments.duration, tournaments.rated, tournaments.status, tournaments.current_round, tournaments.created_at, users.username AS owner, COALESCE(team_tournaments.team_id, '') AS team
+FROM tournaments
+JOIN users ON users.uid = tournaments.owner_uid
+LEFT JOIN team_tournaments ON team_tournaments.tournament_id = tournaments.id
+WHERE tournaments.id = ?
+`
+
+type GetTournamentRow struct {
+	ID           string
+	OwnerUid     int64
+	Name         string
+	Rounds       int64
+	Duration     int64
+	Rated        bool
+	Status       string
+	CurrentRound int64
+	CreatedAt    time.Time
+	Owner        string
+	Team         string
+}
+
+// team is empty unless only the members of a team can join
+func (q *Queries) GetTournament(ctx context.Context, id string) (GetTournamentRow, error) {
+	row := q.db.QueryRowContext(ctx, getTournament, id)
+	var i GetTournamentRow
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerUid,
+		&i.Name,
+		&i.Rounds,
+		&i.Duration,
+		&i.Rated,
+		&i.Status,
+		&i.CurrentRound,
+		&i.CreatedAt,
+		&i.Owner,
+		&i.Team,
+	)
+	return i, err
+}
+
+const getUnfinishedTournamentPairingByMatch = `-- name: GetUnfinishedTournamentPairingByMatch :one
+SELECT tournament_pairings.tournament_id, tournament_pairings.round, tournament_pairings.white_uid, tournament_pairings.black_uid, tournament_pairings.match_id, tournament_pairings.result, COALESCE(white.username, '') AS white, COALESCE(black.username, '') AS black
+FROM tournament_pairings
+LEFT JOIN users AS white ON white.uid = tournament_pairings.white_uid
+LEFT JOIN users AS black ON black.uid = tournament_pairings.black_uid
+WHERE tournament_pairings.match_id = ? AND tournament_pairings.result = ''
+`
+
+type GetUnfinishedTournamentPairingByMatchRow struct {
+	TournamentID string
+	Round        int64
+	WhiteUid     int64
+	BlackUid     int64
+	MatchID      string
+	Result       string
+	White        string
+	Black        string
+}
+
+func (q *Queries) GetUnfinishedTournamentPairingByMatch(ctx context.Context, matchID string) (GetUnfinishedTournamentPairingByMatchRow, error) {
+	row := q.db.QueryRowContext(ctx, getUnfinishedTournamentPairingByMatch, matchID)
+	var i GetUnfinishedTournamentPairingByMatchRow
+	err := row.Scan(
+		&i.TournamentID,
+		&i.Round,
+		&i.WhiteUid,
+		&i.BlackUid,
+		&i.MatchID,
+		&i.Result,
+		&i.White,
+		&i.Black,
+	)
+	return i, err
+}
+
+const getUserById = `-- name: GetUserById :one
+SELECT uid, username, password_hash, api_key, is_guest, preferences, email, is_admin, is_bot, banned, created_at, deleted_at FROM users
+WHERE uid = ?
+`
+
+func (q *Queries) GetUserById(ctx context.Context, uid int64) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserById, uid)
+	var i User
+	err := row.Scan(
+		&i.Uid,
+		&i.Username,
+		&i.PasswordHash,
+		&i.ApiKey,
+		&i.IsGuest,
+		&i.Preferences,
+		&i.Email,
+		&i.IsAdmin,
+		&i.IsBot,
+		&i.Banned,
+		&i.CreatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getUserByUsername = `-- name: GetUserByUsername :one
+SELECT uid, username, password_hash, api_key, is_guest, preferences, email, is_admin, is_bot, banned, created_at, deleted_at FROM users
+WHERE username = ?
+`
+
+func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByUsername, username)
+	var i User
+	err := row.Scan(
+		&i.Uid,
+		&i.Username,
+		&i.PasswordHash,
+		&i.ApiKey,
+		&i.IsGuest,
+		&i.Preferences,
+		&i.Email,
+		&i.IsAdmin,
+		&i.IsBot,
+		&i.Banned,
+		&i.CreatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getWebhookOfUser = `-- name: GetWebhookOfUser :one
+SELECT id, uid, url, secret, match_id, created_at FROM webhooks
+WHERE id = ? AND uid = ?
+`
+
+type GetWebhookOfUserParams struct {
+	ID  int64
+	Uid int64
+}
+
+func (q *Queries) GetWebhookOfUser(ctx context.Context, arg GetWebhookOfUserParams) (Webhook, error) {
+	row := q.db.QueryRowContext(ctx, getWebhookOfUser, arg.ID, arg.Uid)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.Url,
+		&i.Secret,
+		&i.MatchID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const isBlockedBetween = `-- name: IsBlockedBetween :one
+SELECT EXISTS (
+    SELECT 1 FROM blocks
+    JOIN users blocker ON blocker.uid = blocks.blocker_uid
+    JOIN users blocked ON blocked.uid = blocks.blocked_uid
+    WHERE (blocker.username = ?1 AND blocked.username = ?2)
+       OR (blocker.username = ?2 AND blocked.username = ?1)
+)
+`
+
+type IsBlockedBetweenParams struct {
+	Username      string
+	OtherUsername string
+}
+
+// true if either user blocked the other
+func (q *Queries) IsBlockedBetween(ctx context.Context, arg IsBlockedBetweenParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, isBlockedBetween, arg.Username, arg.OtherUsername)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const listAccountsSharingIPs = `-- name: ListAccountsSharingIPs :many
+SELECT other.target AS username, other.ip, COUNT(*) AS events
+FROM audit_log other
+WHERE other.action IN ('account.created', 'login') AND other.target != ?1
+  AND other.ip IN (
+    SELECT mine.ip FROM audit_log mine
+    WHERE mine.action IN ('account.created', 'login') AND mine.target = ?1 AND mine.ip != ''
+  )
+GROUP BY other.target, other.ip
+ORDER BY other.target, other.ip
+`
+
+type ListAccountsSharingIPsRow struct {
+	Username string
+	Ip       string
+	Events   int64
+}
+
+// other accounts that signed up or logged in from an IP the user used
+func (q *Queries) ListAccountsSharingIPs(ctx context.Context, username string) ([]ListAccountsSharingIPsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsSharingIPs, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAccountsSharingIPsRow
+	for rows.Next() {
+		var i ListAccountsSharingIPsRow
+		if err := rows.Scan(&i.Username, &i.Ip, &i.Events); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAllGamesByPlayer = `-- name: ListAllGamesByPlayer :many
+SELECT id, white_uid, black_uid, result, moves, finished_at, eco, opening, match_id, method, final_fen FROM games
+WHERE white_uid = ?1 OR black_uid = ?1
+ORDER BY finished_at ASC
+`
+
+func (q *Queries) ListAllGamesByPlayer(ctx context.Context, uid int64) ([]Game, error) {
+	rows, err := q.db.QueryContext(ctx, listAllGamesByPlayer, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Game
+	for rows.Next() {
+		var i Game
+		if err := rows.Scan(
+			&i.ID,
+			&i.WhiteUid,
+			&i.BlackUid,
+			&i.Result,
+			&i.Moves,
+			&i.FinishedAt,
+			&i.Eco,
+			&i.Opening,
+			&i.MatchID,
+			&i.Method,
+			&i.FinalFen,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAllTeamMatches = `-- name: ListAllTeamMatches :many
+SELECT match_id FROM team_matches
+`
+
+func (q *Queries) ListAllTeamMatches(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listAllTeamMatches)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var match_id string
+		if err := rows.Scan(&match_id); err != nil {
+			return nil, err
+		}
+		items = append(items, match_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditEvents = `-- name: ListAuditEvents :many
+SELECT id, "action", actor, target, ip, details, created_at FROM audit_log
+WHERE (CAST(?1 AS TEXT) = '' OR action = ?1)
+  AND (CAST(?2 AS TEXT) = '' OR actor = ?2)
+  AND (CAST(?3 AS TEXT) = '' OR target = ?3)
+  AND created_at >= ?4
+  AND created_at < ?5
+ORDER BY id DESC
+LIMIT ?7 OFFSET ?6
+`
+
+type ListAuditEventsParams struct {
+	Action string
+	Actor  string
+	Target string
+	Since  time.Time
+	Until  time.Time
+	Offset int64
+	Limit  int64
+}
+
+func (q *Queries) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditEvents,
+		arg.Action,
+		arg.Actor,
+		arg.Target,
+		arg.Since,
+		arg.Until,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Action,
+			&i.Actor,
+			&i.Target,
+			&i.Ip,
+			&i.Details,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBlockedUsernames = `-- name: ListBlockedUsernames :many
+SELECT users.username FROM blocks
+JOIN users ON users.uid = blocks.blocked_uid
+WHERE blocks.blocker_uid = ?
+ORDER BY users.username
+`
+
+func (q *Queries) ListBlockedUsernames(ctx context.Context, blockerUid int64) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listBlockedUsernames, blockerUid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var username string
+		if err := rows.Scan(&username); err != nil {
+			return nil, err
+		}
+		items = append(items, username)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChatMessages = `-- name: ListChatMessages :many
+SELECT id, match_id, username, message, sent_at FROM chat_messages
+WHERE match_id = ?
+ORDER BY id ASC
+`
+
+func (q *Queries) ListChatMessages(ctx context.Context, matchID string) ([]ChatMessage, error) {
+	rows, err := q.db.QueryContext(ctx, listChatMessages, matchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChatMessage
+	for rows.Next() {
+		var i ChatMessage
+		if err := rows.Scan(
+			&i.ID,
+			&i.MatchID,
+			&i.Username,
+			&i.Message,
+			&i.SentAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExplorerMoves = `-- name: ListExplorerMoves :many
+SELECT move, white_wins, draws, black_wins FROM explorer_moves
+WHERE position = ?
+ORDER BY white_wins + draws + black_wins DESC, move
+`
+
+type ListExplorerMovesRow struct {
+	Move      string
+	WhiteWins int64
+	Draws     int64
+	BlackWins int64
+}
+
+// most played first
+func (q *Queries) ListExplorerMoves(ctx context.Context, position string) ([]ListExplorerMovesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listExplorerMoves, position)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListExplorerMovesRow
+	for rows.Next() {
+		var i ListExplorerMovesRow
+		if err := rows.Scan(
+			&i.Move,
+			&i.WhiteWins,
+			&i.Draws,
+			&i.BlackWins,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFairPlayAnalysesOfUser = `-- name: ListFairPlayAnalysesOfUser :many
+SELECT fair_play_analyses.game_id, fair_play_analyses.uid, fair_play_analyses.moves, fair_play_analyses.engine_matches, fair_play_analyses.average_move_time, fair_play_analyses.move_time_deviation, fair_play_analyses.suspicion, fair_play_analyses.created_at, games.match_id, games.finished_at
+FROM fair_play_analyses
+JOIN games ON games.id = fair_play_analyses.game_id
+WHERE fair_play_analyses.uid = ?
+ORDER BY fair_play_analyses.game_id DESC
+LIMIT ? OFFSET ?
+`
+
+type ListFairPlayAnalysesOfUserParams struct {
+	Uid    int64
+	Limit  int64
+	Offset int64
+}
+
+type ListFairPlayAnalysesOfUserRow struct {
+	GameID            int64
+	Uid               int64
+	Moves             int64
+	EngineMatches     int64
+	AverageMoveTime   int64
+	MoveTimeDeviation int64
+	Suspicion         int64
+	CreatedAt         time.Time
+	MatchID           string
+	FinishedAt        time.Time
+}
+
+func (q *Queries) ListFairPlayAnalysesOfUser(ctx context.Context, arg ListFairPlayAnalysesOfUserParams) ([]ListFairPlayAnalysesOfUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFairPlayAnalysesOfUser, arg.Uid, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFairPlayAnalysesOfUserRow
+	for rows.Next() {
+		var i ListFairPlayAnalysesOfUserRow
+		if err := rows.Scan(
+			&i.GameID,
+			&i.Uid,
+			&i.Moves,
+			&i.EngineMatches,
+			&i.AverageMoveTime,
+			&i.MoveTimeDeviation,
+			&i.Suspicion,
+			&i.CreatedAt,
+			&i.MatchID,
+			&i.FinishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFlaggedPlayers = `-- name: ListFlaggedPlayers :many
+SELECT users.username,
+       COUNT(*) AS games,
+       CAST(AVG(fair_play_analyses.suspicion) AS INTEGER) AS suspicion,
+       CAST(SUM(fair_play_analyses.moves) AS INTEGER) AS moves,
+       CAST(SUM(fair_play_analyses.engine_matches) AS INTEGER) AS engine_matches,
+       (SELECT COUNT(*) FROM reports
+        WHERE reports.reported_username = users.username AND reports.category = 'cheating' AND NOT reports.resolved) AS open_reports
+FROM fair_play_analyses
+JOIN users ON users.uid = fair_play_analyses.uid
+WHERE fair_play_analyses.created_at > ?1
+GROUP BY users.uid
+HAVING COUNT(*) >= CAST(?2 AS INTEGER) AND AVG(fair_play_analyses.suspicion) >= CAST(?3 AS INTEGER)
+ORDER BY suspicion DESC, users.uid
+LIMIT ?5 OFFSET ?4
+`
+
+type ListFlaggedPlayersParams struct {
+	Since        time.Time
+	MinGames     int64
+	MinSuspicion int64
+	Offset       int64
+	Limit        int64
+}
+
+type ListFlaggedPlayersRow struct {
+	Username      string
+	Games         int64
+	Suspicion     int64
+	Moves         int64
+	EngineMatches int64
+	OpenReports   int64
+}
+
+// players whose games analyzed after since look like engine play on average, most suspicious first
+func (q *Queries) ListFlaggedPlayers(ctx context.Context, arg ListFlaggedPlayersParams) ([]ListFlaggedPlayersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFlaggedPlayers,
+		arg.Since,
+		arg.MinGames,
+		arg.MinSuspicion,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFlaggedPlayersRow
+	for rows.Next() {
+		var i ListFlaggedPlayersRow
+		if err := rows.Scan(
+			&i.Username,
+			&i.Games,
+			&i.Suspicion,
+			&i.Moves,
+			&i.EngineMatches,
+			&i.OpenReports,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFriendUsernames = `-- name: ListFriendUsernames :many
+SELECT users.username FROM friendships
+JOIN users ON users.uid = CASE
+    WHEN friendships.requester_uid = ?1 THEN friendships.addressee_uid
+    ELSE friendships.requester_uid
+END
+WHERE friendships.accepted
+  AND (friendships.requester_uid = ?1 OR friendships.addressee_uid = ?1)
+ORDER BY users.username
+`
+
+func (q *Queries) ListFriendUsernames(ctx context.Context, uid int64) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listFriendUsernames, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var username string
+		if err := rows.Scan(&username); err != nil {
+			return nil, err
+		}
+		items = append(items, username)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGames = `-- name: ListGames :many
+SELECT id, white_uid, black_uid, result, moves, finished_at, eco, opening, match_id, method, final_fen FROM games
+ORDER BY finished_at DESC
+LIMIT ? OFFSET ?
+`
+
+type ListGamesParams struct {
+	Limit  int64
+	Offset int64
+}
+
+func (q *Queries) ListGames(ctx context.Context, arg ListGamesParams) ([]Game, error) {
+	rows, err := q.db.QueryContext(ctx, listGames, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Game
+	for rows.Next() {
+		var i Game
+		if err := rows.Scan(
+			&i.ID,
+			&i.WhiteUid,
+			&i.BlackUid,
+			&i.Result,
+			&i.Moves,
+			&i.FinishedAt,
+			&i.Eco,
+			&i.Opening,
+			&i.MatchID,
+			&i.Method,
+			&i.FinalFen,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGamesByPlayer = `-- name: ListGamesByPlayer :many
+SELECT id, white_uid, black_uid, result, moves, finished_at, eco, opening, match_id, method, final_fen FROM games
+WHERE white_uid = ? OR black_uid = ?
+ORDER BY finished_at DESC
+LIMIT ? OFFSET ?
+`
+
+type ListGamesByPlayerParams struct {
+	WhiteUid int64
+	BlackUid int64
+	Limit    int64
+	Offset   int64
+}
+
+func (q *Queries) ListGamesByPlayer(ctx context.Context, arg ListGamesByPlayerParams) ([]Game, error) {
+	rows, err := q.db.QueryContext(ctx, listGamesByPlayer,
+		arg.WhiteUid,
+		arg.BlackUid,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Game
+	for rows.Next() {
+		var i Game
+		if err := rows.Scan(
+			&i.ID,
+			&i.WhiteUid,
+			&i.BlackUid,
+			&i.Result,
+			&i.Moves,
+			&i.FinishedAt,
+			&i.Eco,
+			&i.Opening,
+			&i.MatchID,
+			&i.Method,
+			&i.FinalFen,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGamesOfPlayerAfter = `-- name: ListGamesOfPlayerAfter :many
+SELECT id, white_uid, black_uid, result, moves, finished_at, eco, opening, match_id, method, final_fen FROM games
+WHERE (white_uid = ?1 OR black_uid = ?1)
+  AND id > ?2
+  AND finished_at >= ?3
+  AND finished_at < ?4
+  AND (CAST(?5 AS TEXT) = ''
+    OR (?5 = 'white' AND white_uid = ?1)
+    OR (?5 = 'black' AND black_uid = ?1))
+  AND (CAST(?6 AS TEXT) = ''
+    OR (?6 = 'draw' AND result = 'draw')
+    OR (?6 = 'win' AND ((result = 'white' AND white_uid = ?1) OR (result = 'black' AND black_uid = ?1)))
+    OR (?6 = 'loss' AND ((result = 'white' AND black_uid = ?1) OR (result = 'black' AND white_uid = ?1))))
+ORDER BY id
+LIMIT ?7
+`
+
+type ListGamesOfPlayerAfterParams struct {
+	Uid     int64
+	AfterID int64
+	Since   time.Time
+	Until   time.Time
+	Color   string
+	Outcome string
+	Limit   int64
+}
+
+// games of a player in the order they were stored, starting after the game with id after_id.
+// color is white or black for the games the player played with that color, empty for all of them.
+// outcome is win, loss or draw from the player's side, empty for all of them.
+func (q *Queries) ListGamesOfPlayerAfter(ctx context.Context, arg ListGamesOfPlayerAfterParams) ([]Game, error) {
+	rows, err := q.db.QueryContext(ctx, listGamesOfPlayerAfter,
+		arg.Uid,
+		arg.AfterID,
+		arg.Since,
+		arg.Until,
+		arg.Color,
+		arg.Outcome,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Game
+	for rows.Next() {
+		var i Game
+		if err := rows.Scan(
+			&i.ID,
+			&i.WhiteUid,
+			&i.BlackUid,
+			&i.Result,
+			&i.Moves,
+			&i.FinishedAt,
+			&i.Eco,
+			&i.Opening,
+			&i.MatchID,
+			&i.Method,
+			&i.FinalFen,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listIncomingFriendRequests = `-- name: ListIncomingFriendRequests :many
+SELECT users.username, friendships.created_at FROM friendships
+JOIN users ON users.uid = friendships.requester_uid
+WHERE friendships.addressee_uid = ? AND NOT friendships.accepted
+ORDER BY friendships.created_at DESC
+`
+
+type ListIncomingFriendRequestsRow struct {
+	Username  string
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListIncomingFriendRequests(ctx context.Context, addresseeUid int64) ([]ListIncomingFriendRequestsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listIncomingFriendRequests, addresseeUid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListIncomingFriendRequestsRow
+	for rows.Next() {
+		var i ListIncomingFriendRequestsRow
+		if err := rows.Scan(&i.Username, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNotifications = `-- name: ListNotifications :many
+SELECT id, uid, type, from_username, match_id, read, created_at FROM notifications
+WHERE uid = ?1 AND (CAST(?2 AS BOOLEAN) OR NOT read)
+ORDER BY id DESC
+LIMIT ?3
+`
+
+type ListNotificationsParams struct {
+	Uid         int64
+	IncludeRead bool
+	Limit       int64
+}
+
+func (q *Queries) ListNotifications(ctx context.Context, arg ListNotificationsParams) ([]Notification, error) {
+	rows, err := q.db.QueryContext(ctx, listNotifications, arg.Uid, arg.IncludeRead, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Notification
+	for rows.Next() {
+		var i Notification
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.Type,
+			&i.FromUsername,
+			&i.MatchID,
+			&i.Read,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReports = `-- name: ListReports :many
+SELECT id, reporter_uid, reported_username, match_id, category, details, resolved, created_at FROM reports
+WHERE (CAST(?1 AS BOOLEAN) OR NOT resolved)
+ORDER BY id DESC
+LIMIT ?3 OFFSET ?2
+`
+
+type ListReportsParams struct {
+	IncludeResolved bool
+	Offset          int64
+	Limit           int64
+}
+
+func (q *Queries) ListReports(ctx context.Context, arg ListReportsParams) ([]Report, error) {
+	rows, err := q.db.QueryContext(ctx, listReports, arg.IncludeResolved, arg.Offset, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Report
+	for rows.Next() {
+		var i Report
+		if err := rows.Scan(
+			&i.ID,
+			&i.ReporterUid,
+			&i.ReportedUsername,
+			&i.MatchID,
+			&i.Category,
+			&i.Details,
+			&i.Resolved,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSharedIPs = `-- name: ListSharedIPs :many
+SELECT ip,
+    COUNT(DISTINCT target) AS accounts,
+    CAST(SUM(action = 'account.created') AS INTEGER) AS signups,
+    CAST(SUM(action = 'login') AS INTEGER) AS logins,
+    CAST(group_concat(DISTINCT target) AS TEXT) AS usernames
+FROM audit_log
+WHERE action IN ('account.created', 'login') AND ip != '' AND created_at >= ?1
+GROUP BY ip
+HAVING COUNT(DISTINCT target) >= CAST(?2 AS INTEGER)
+ORDER BY accounts DESC, ip
+LIMIT ?4 OFFSET ?3
+`
+
+type ListSharedIPsParams struct {
+	Since       time.Time
+	MinAccounts int64
+	Offset      int64
+	Limit       int64
+}
+
+type ListSharedIPsRow struct {
+	Ip        string
+	Accounts  int64
+	Signups   int64
+	Logins    int64
+	Usernames string
+}
+
+// IPs that several accounts signed up or logged in from
+func (q *Queries) ListSharedIPs(ctx context.Context, arg ListSharedIPsParams) ([]ListSharedIPsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSharedIPs,
+		arg.Since,
+		arg.MinAccounts,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSharedIPsRow
+	for rows.Next() {
+		var i ListSharedIPsRow
+		if err := rows.Scan(
+			&i.Ip,
+			&i.Accounts,
+			&i.Signups,
+			&i.Logins,
+			&i.Usernames,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStudiesOfUser = `-- name: ListStudiesOfUser :many
+SELECT studies.id, studies.name, users.username AS owner, studies.created_at, studies.updated_at
+FROM studies
+JOIN users ON users.uid = studies.owner_uid
+WHERE studies.owner_uid = ?1
+   OR studies.id IN (SELECT study_id FROM study_members WHERE study_members.uid = ?1)
+ORDER BY studies.updated_at DESC
+`
+
+type ListStudiesOfUserRow struct {
+	ID        string
+	Name      string
+	Owner     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// studies the user owns or was invited to, recently changed first
+func (q *Queries) ListStudiesOfUser(ctx context.Context, uid int64) ([]ListStudiesOfUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, listStudiesOfUser, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListStudiesOfUserRow
+	for rows.Next() {
+		var i ListStudiesOfUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Owner,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStudyMembers = `-- name: ListStudyMembers :many
+SELECT users.username FROM study_members
+JOIN users ON users.uid = study_members.uid
+WHERE study_members.study_id = ?
+ORDER BY users.username
+`
+
+func (q *Queries) ListStudyMembers(ctx context.Context, studyID string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listStudyMembers, studyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var username string
+		if err := rows.Scan(&username); err != nil {
+			return nil, err
+		}
+		items = append(items, username)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSuspendedMatches = `-- name: ListSuspendedMatches :many
+SELECT id, rated, white_username, black_username, moves, start_time, end_time, computer_level FROM suspended_matches
+`
+
+func (q *Queries) ListSuspendedMatches(ctx context.Context) ([]SuspendedMatch, error) {
+	rows, err := q.db.QueryContext(ctx, listSuspendedMatches)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SuspendedMatch
+	for rows.Next() {
+		var i SuspendedMatch
+		if err := rows.Scan(
+			&i.ID,
+			&i.Rated,
+			&i.WhiteUsername,
+			&i.BlackUsername,
+			&i.Moves,
+			&i.StartTime,
+			&i.EndTime,
+			&i.ComputerLevel,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTeamMatches = `-- name: ListTeamMatches :many
+SELECT match_id FROM team_matches
+WHERE team_id = ?
+ORDER BY created_at DESC, rowid DESC
+`
+
+// newest first
+func (q *Queries) ListTeamMatches(ctx context.Context, teamID string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listTeamMatches, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var match_id string
+		if err := rows.Scan(&match_id); err != nil {
+			return nil, err
+		}
+		items = append(items, match_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTeamMembers = `-- name: ListTeamMembers :many
+SELECT users.username, team_members.role, team_members.joined_at
+FROM team_members
+JOIN users ON users.uid = team_members.uid
+WHERE team_members.team_id = ?
+ORDER BY CASE team_members.role WHEN 'owner' THEN 0 WHEN 'admin' THEN 1 ELSE 2 END, team_members.joined_at, team_members.rowid
+`
+
+type ListTeamMembersRow struct {
+	Username string
+	Role     string
+	JoinedAt time.Time
+}
+
+// the owner first, then admins, then members in the order they joined
+func (q *Queries) ListTeamMembers(ctx context.Context, teamID string) ([]ListTeamMembersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTeamMembers, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTeamMembersRow
+	for rows.Next() {
+		var i ListTeamMembersRow
+		if err := rows.Scan(&i.Username, &i.Role, &i.JoinedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTeams = `-- name: ListTeams :many
+SELECT teams.id, teams.owner_uid, teams.name, teams.description, teams.created_at, users.username AS owner,
+       (SELECT COUNT(*) FROM team_members WHERE team_members.team_id = teams.id) AS members
+FROM teams
+JOIN users ON users.uid = teams.owner_uid
+WHERE instr(lower(teams.name), lower(CAST(?1 AS TEXT))) > 0
+ORDER BY members DESC, teams.created_at, teams.id
+LIMIT ?2
+`
+
+type ListTeamsParams struct {
+	Search string
+	Limit  int64
+}
+
+type ListTeamsRow struct {
+	ID          string
+	OwnerUid    int64
+	Name        string
+	Description string
+	CreatedAt   time.Time
+	Owner       string
+	Members     int64
+}
+
+// biggest first. search matches part of the name, empty for every team.
+func (q *Queries) ListTeams(ctx context.Context, arg ListTeamsParams) ([]ListTeamsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTeams, arg.Search, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTeamsRow
+	for rows.Next() {
+		var i ListTeamsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OwnerUid,
+			&i.Name,
+			&i.Description,
+			&i.CreatedAt,
+			&i.Owner,
+			&i.Members,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTeamsOfUser = `-- name: ListTeamsOfUser :many
+SELECT teams.id, teams.name, team_members.role
+FROM team_members
+JOIN teams ON teams.id = team_members.team_id
+WHERE team_members.uid = ?
+ORDER BY team_members.joined_at
+`
+
+type ListTeamsOfUserRow struct {
+	ID   string
+	Name string
+	Role string
+}
+
+func (q *Queries) ListTeamsOfUser(ctx context.Context, uid int64) ([]ListTeamsOfUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTeamsOfUser, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTeamsOfUserRow
+	for rows.Next() {
+		var i ListTeamsOfUserRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.Role); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTournamentPairings = `-- name: ListTournamentPairings :many
+SELECT tournament_pairings.tournament_id, tournament_pairings.round, tournament_pairings.white_uid, tournament_pairings.black_uid, tournament_pairings.match_id, tournament_pairings.result, COALESCE(white.username, '') AS white, COALESCE(black.username, '') AS black
+FROM tournament_pairings
+LEFT JOIN users AS white ON white.uid = tournament_pairings.white_uid
+LEFT JOIN users AS black ON black.uid = tournament_pairings.black_uid
+WHERE tournament_pairings.tournament_id = ?
+ORDER BY tournament_pairings.round, tournament_pairings.rowid
+`
+
+type ListTournamentPairingsRow struct {
+	TournamentID string
+	Round        int64
+	WhiteUid     int64
+	BlackUid     int64
+	MatchID      string
+	Result       string
+	White        string
+	Black        string
+}
+
+// players are empty if they deleted their account, black is empty for a bye
+func (q *Queries) ListTournamentPairings(ctx context.Context, tournamentID string) ([]ListTournamentPairingsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTournamentPairings, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTournamentPairingsRow
+	for rows.Next() {
+		var i ListTournamentPairingsRow
+		if err := rows.Scan(
+			&i.TournamentID,
+			&i.Round,
+			&i.WhiteUid,
+			&i.BlackUid,
+			&i.MatchID,
+			&i.Result,
+			&i.White,
+			&i.Black,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTournamentPlayers = `-- name: ListTournamentPlayers :many
+SELECT tournament_players.uid, users.username, tournament_players.withdrawn
+FROM tournament_players
+JOIN users ON users.uid = tournament_players.uid
+WHERE tournament_players.tournament_id = ?
+ORDER BY tournament_players.joined_at, tournament_players.rowid
+`
+
+type ListTournamentPlayersRow struct {
+	Uid       int64
+	Username  string
+	Withdrawn bool
+}
+
+// in the order they joined, which is their seed
+func (q *Queries) ListTournamentPlayers(ctx context.Context, tournamentID string) ([]ListTournamentPlayersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTournamentPlayers, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTournamentPlayersRow
+	for rows.Next() {
+		var i ListTournamentPlayersRow
+		if err := rows.Scan(&i.Uid, &i.Username, &i.Withdrawn); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTournaments = `-- name: ListTournaments :many
+SELECT tournaments.id, tournaments.owner_uid, tournaments.name, tournaments.rounds, tournaments.duration, tournaments.rated, tournaments.status, tournaments.current_round, tournaments.created_at, users.username AS owner, COALESCE(team_tournaments.team_id, '') AS team,
+       (SELECT COUNT(*) FROM tournament_players WHERE tournament_players.tournament_id = tournaments.id) AS players
+FROM tournaments
+JOIN users ON users.uid = tournaments.owner_uid
+LEFT JOIN team_tournaments ON team_tournaments.tournament_id = tournaments.id
+WHERE (CAST(?1 AS TEXT) = '' OR tournaments.status = ?1)
+  AND (CAST(?2 AS TEXT) = '' OR team_tournaments.team_id = ?2)
+ORDER BY tournaments.created_at DESC, tournaments.id
+LIMIT ?3
+`
+
+type ListTournamentsParams struct {
+	Status string
+	Team   string
+	Limit  int64
+}
+
+type ListTournamentsRow struct {
+	ID           string
+	OwnerUid     int64
+	Name         string
+	Rounds       int64
+	Duration     int64
+	Rated        bool
+	Status       string
+	CurrentRound int64
+	CreatedAt    time.Time
+	Owner        string
+	Team         string
+	Players      int64
+}
+
+// newest first. status filters by status and team by team, empty for every tournament.
+func (q *Queries) ListTournaments(ctx context.Context, arg ListTournamentsParams) ([]ListTournamentsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTournaments, arg.Status, arg.Team, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTournamentsRow
+	for rows.Next() {
+		var i ListTournamentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OwnerUid,
+			&i.Name,
+			&i.Rounds,
+			&i.Duration,
+			&i.Rated,
+			&i.Status,
+			&i.CurrentRound,
+			&i.CreatedAt,
+			&i.Owner,
+			&i.Team,
+			&i.Players,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnfinishedTournamentPairings = `-- name: ListUnfinishedTournamentPairings :many
+SELECT tournament_pairings.tournament_id, tournament_pairings.round, tournament_pairings.white_uid, tournament_pairings.black_uid, tournament_pairings.match_id, tournament_pairings.result, COALESCE(white.username, '') AS white, COALESCE(black.username, '') AS black
+FROM tournament_pairings
+LEFT JOIN users AS white ON white.uid = tournament_pairings.white_uid
+LEFT JOIN users AS black ON black.uid = tournament_pairings.black_uid
+WHERE tournament_pairings.result = ''
+`
+
+type ListUnfinishedTournamentPairingsRow struct {
+	TournamentID string
+	Round        int64
+	WhiteUid     int64
+	BlackUid     int64
+	MatchID      string
+	Result       string
+	White        string
+	Black        string
+}
+
+// games of every tournament that did not end yet
+func (q *Queries) ListUnfinishedTournamentPairings(ctx context.Context) ([]ListUnfinishedTournamentPairingsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUnfinishedTournamentPairings)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUnfinishedTournamentPairingsRow
+	for rows.Next() {
+		var i ListUnfinishedTournamentPairingsRow
+		if err := rows.Scan(
+			&i.TournamentID,
+			&i.Round,
+			&i.WhiteUid,
+			&i.BlackUid,
+			&i.MatchID,
+			&i.Result,
+			&i.White,
+			&i.Black,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT uid, username, password_hash, api_key, is_guest, preferences, email, is_admin, is_bot, banned, created_at, deleted_at FROM users
+ORDER BY created_at DESC
+LIMIT ? OFFSET ?
+`
+
+type ListUsersParams struct {
+	Limit  int64
+	Offset int64
+}
+
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.Uid,
+			&i.Username,
+			&i.PasswordHash,
+			&i.ApiKey,
+			&i.IsGuest,
+			&i.Preferences,
+			&i.Email,
+			&i.IsAdmin,
+			&i.IsBot,
+			&i.Banned,
+			&i.CreatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersDeletedBefore = `-- name: ListUsersDeletedBefore :many
+SELECT uid, username, password_hash, api_key, is_guest, preferences, email, is_admin, is_bot, banned, created_at, deleted_at FROM users
+WHERE deleted_at IS NOT NULL AND deleted_at < ?
+`
+
+func (q *Queries) ListUsersDeletedBefore(ctx context.Context, deletedAt sql.NullTime) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersDeletedBefore, deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.Uid,
+			&i.Username,
+			&i.PasswordHash,
+			&i.ApiKey,
+			&i.IsGuest,
+			&i.Preferences,
+			&i.Email,
+			&i.IsAdmin,
+			&i.IsBot,
+			&i.Banned,
+			&i.CreatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT id, webhook_id, delivery_id, event, attempt, status_code, error, duration_ms, created_at FROM webhook_deliveries
+WHERE webhook_id = ?
+ORDER BY id DESC
+LIMIT ? OFFSET ?
+`
+
+type ListWebhookDeliveriesParams struct {
+	WebhookID int64
+	Limit     int64
+	Offset    int64
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhookDeliveries, arg.WebhookID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookDelivery
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.DeliveryID,
+			&i.Event,
+			&i.Attempt,
+			&i.StatusCode,
+			&i.Error,
+			&i.DurationMs,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhooksForMatch = `-- name: ListWebhooksForMatch :many
+SELECT webhooks.id, webhooks.uid, webhooks.url, webhooks.secret, webhooks.match_id, webhooks.created_at FROM webhooks
+JOIN users ON users.uid = webhooks.uid
+WHERE users.username IN (?1, ?2)
+  AND webhooks.match_id IN ('', ?3)
+  AND users.banned = FALSE AND users.deleted_at IS NULL
+`
+
+type ListWebhooksForMatchParams struct {
+	White   string
+	Black   string
+	MatchID string
+}
+
+func (q *Queries) ListWebhooksForMatch(ctx context.Context, arg ListWebhooksForMatchParams) ([]Webhook, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhooksForMatch, arg.White, arg.Black, arg.MatchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Webhook
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.Url,
+			&i.Secret,
+			&i.MatchID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhooksOfUser = `-- name: ListWebhooksOfUser :many
+SELECT id, uid, url, secret, match_id, created_at FROM webhooks
+WHERE uid = ?
+ORDER BY id
+`
+
+func (q *Queries) ListWebhooksOfUser(ctx context.Context, uid int64) ([]Webhook, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhooksOfUser, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Webhook
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.Url,
+			&i.Secret,
+			&i.MatchID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markNotificationsRead = `-- name: MarkNotificationsRead :execrows
+UPDATE notifications
+SET read = TRUE
+WHERE uid = ? AND id <= ? AND NOT read
+`
+
+type MarkNotificationsReadParams struct {
+	Uid int64
+	ID  int64
+}
+
+func (q *Queries) MarkNotificationsRead(ctx context.Context, arg MarkNotificationsReadParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markNotificationsRead, arg.Uid, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const nextFairPlayGame = `-- name: NextFairPlayGame :one
+SELECT fair_play_queue.game_id, fair_play_queue.move_times, games.white_uid, games.black_uid, games.moves, games.match_id
+FROM fair_play_queue
+JOIN games ON games.id = fair_play_queue.game_id
+ORDER BY fair_play_queue.game_id
+LIMIT 1
+`
+
+type NextFairPlayGameRow struct {
+	GameID    int64
+	MoveTimes string
+	WhiteUid  int64
+	BlackUid  int64
+	Moves     string
+	MatchID   string
+}
+
+// the oldest rated game waiting for the fair-play analysis
+func (q *Queries) NextFairPlayGame(ctx context.Context) (NextFairPlayGameRow, error) {
+	row := q.db.QueryRowContext(ctx, nextFairPlayGame)
+	var i NextFairPlayGameRow
+	err := row.Scan(
+		&i.GameID,
+		&i.MoveTimes,
+		&i.WhiteUid,
+		&i.BlackUid,
+		&i.Moves,
+		&i.MatchID,
+	)
+	return i, err
+}
+
+const nextGameToScanForPuzzles = `-- name: NextGameToScanForPuzzles :one
+SELECT id, white_uid, black_uid, result, moves, finished_at, eco, opening, match_id, method, final_fen FROM games
+WHERE id > (SELECT COALESCE(MAX(game_id), 0) FROM puzzle_scans)
+ORDER BY id
+LIMIT 1
+`
+
+// the first finished game after the ones the puzzle miner analyzed
+func (q *Queries) NextGameToScanForPuzzles(ctx context.Context) (Game, error) {
+	row := q.db.QueryRowContext(ctx, nextGameToScanForPuzzles)
+	var i Game
+	err := row.Scan(
+		&i.ID,
+		&i.WhiteUid,
+		&i.BlackUid,
+		&i.Result,
+		&i.Moves,
+		&i.FinishedAt,
+		&i.Eco,
+		&i.Opening,
+		&i.MatchID,
+		&i.Method,
+		&i.FinalFen,
+	)
+	return i, err
+}
+
+const queueFairPlayGame = `-- name: QueueFairPlayGame :exec
+INSERT INTO fair_play_queue (game_id, move_times)
+VALUES (?, ?)
+`
+
+type QueueFairPlayGameParams struct {
+	GameID    int64
+	MoveTimes string
+}
+
+func (q *Queries) QueueFairPlayGame(ctx context.Context, arg QueueFairPlayGameParams) error {
+	_, err := q.db.ExecContext(ctx, queueFairPlayGame, arg.GameID, arg.MoveTimes)
+	return err
+}
+
+const removeStudyMember = `-- name: RemoveStudyMember :execrows
+DELETE FROM study_members
+WHERE study_id = ? AND uid = ?
+`
+
+type RemoveStudyMemberParams struct {
+	StudyID string
+	Uid     int64
+}
+
+func (q *Queries) RemoveStudyMember(ctx context.Context, arg RemoveStudyMemberParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeStudyMember, arg.StudyID, arg.Uid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const removeTeamMember = `-- name: RemoveTeamMember :execrows
+DELETE FROM team_members
+WHERE team_id = ? AND uid = ? AND role != 'owner'
+`
+
+type RemoveTeamMemberParams struct {
+	TeamID string
+	Uid    int64
+}
+
+// the owner cannot be removed, they delete the team instead
+func (q *Queries) RemoveTeamMember(ctx context.Context, arg RemoveTeamMemberParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeTeamMember, arg.TeamID, arg.Uid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const removeTournamentPlayer = `-- name: RemoveTournamentPlayer :execrows
+DELETE FROM tournament_players
+WHERE tournament_id = ? AND uid = ?
+`
+
+type RemoveTournamentPlayerParams struct {
+	TournamentID string
+	Uid          int64
+}
+
+func (q *Queries) RemoveTournamentPlayer(ctx context.Context, arg RemoveTournamentPlayerParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeTournamentPlayer, arg.TournamentID, arg.Uid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const resolveReport = `-- name: ResolveReport :execrows
+UPDATE reports
+SET resolved = TRUE
+WHERE id = ?
+`
+
+func (q *Queries) ResolveReport(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, resolveReport, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreUser = `-- name: RestoreUser :exec
+UPDATE users
+SET deleted_at = NULL
+WHERE uid = ?
 `
 
-func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User, error) {
-	row := q.db.QueryRowContext(ctx, getUserByUsername, username)
-	var i User
-	err := row.Scan(
-		&i.Uid,
-		&i.Username,
-		&i.PasswordHash,
-		&i.ApiKey,
-		&i.CreatedAt,
-	)
-	return i, err
+func (q *Queries) RestoreUser(ctx context.Context, uid int64) error {
+	_, err := q.db.ExecContext(ctx, restoreUser, uid)
+	return err
 }
 
-const listGames = `-- name: ListGames :many
-SELECT id, white_uid, black_uid, result, moves, finished_at FROM games
-ORDER BY finished_at DESC
-LIMIT ? OFFSET ?
+const setTeamMemberRole = `-- name: SetTeamMemberRole :execrows
+UPDATE team_members SET role = ?
+WHERE team_id = ? AND uid = ? AND role != 'owner'
 `
 
-type ListGamesParams struct {
-	Limit  int64
-	Offset int64
+type SetTeamMemberRoleParams struct {
+	Role   string
+	TeamID string
+	Uid    int64
 }
 
-func (q *Queries) ListGames(ctx context.Context, arg ListGamesParams) ([]Game, error) {
-	rows, err := q.db.QueryContext(ctx, listGames, arg.Limit, arg.Offset)
+// the owner's role cannot change
+func (q *Queries) SetTeamMemberRole(ctx context.Context, arg SetTeamMemberRoleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setTeamMemberRole, arg.Role, arg.TeamID, arg.Uid)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Game
-	for rows.Next() {
-		var i Game
-		if err := rows.Scan(
-			&i.ID,
-			&i.WhiteUid,
-			&i.BlackUid,
-			&i.Result,
-			&i.Moves,
-			&i.FinishedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const listGamesByPlayer = `-- name: ListGamesByPlayer :many
-SELECT id, white_uid, black_uid, result, moves, finished_at FROM games
-WHERE white_uid = ? OR black_uid = ?
-ORDER BY finished_at DESC
-LIMIT ? OFFSET ?
+const setTournamentPairingResult = `-- name: SetTournamentPairingResult :execrows
+UPDATE tournament_pairings SET result = ?
+WHERE tournament_id = ? AND round = ? AND white_uid = ? AND result = ''
 `
 
-type ListGamesByPlayerParams struct {
-	WhiteUid int64
-	BlackUid int64
-	Limit    int64
-	Offset   int64
+type SetTournamentPairingResultParams struct {
+	Result       string
+	TournamentID string
+	Round        int64
+	WhiteUid     int64
 }
 
-func (q *Queries) ListGamesByPlayer(ctx context.Context, arg ListGamesByPlayerParams) ([]Game, error) {
-	rows, err := q.db.QueryContext(ctx, listGamesByPlayer,
+func (q *Queries) SetTournamentPairingResult(ctx context.Context, arg SetTournamentPairingResultParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setTournamentPairingResult,
+		arg.Result,
+		arg.TournamentID,
+		arg.Round,
 		arg.WhiteUid,
-		arg.BlackUid,
-		arg.Limit,
-		arg.Offset,
 	)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Game
-	for rows.Next() {
-		var i Game
-		if err := rows.Scan(
-			&i.ID,
-			&i.WhiteUid,
-			&i.BlackUid,
-			&i.Result,
-			&i.Moves,
-			&i.FinishedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const listUsers = `-- name: ListUsers :many
-SELECT uid, username, password_hash, api_key, created_at FROM users
-ORDER BY created_at DESC
-LIMIT ? OFFSET ?
+const setTournamentRound = `-- name: SetTournamentRound :exec
+UPDATE tournaments SET current_round = ?, status = ?
+WHERE id = ?
 `
 
-type ListUsersParams struct {
-	Limit  int64
-	Offset int64
+type SetTournamentRoundParams struct {
+	CurrentRound int64
+	Status       string
+	ID           string
 }
 
-func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
-	rows, err := q.db.QueryContext(ctx, listUsers, arg.Limit, arg.Offset)
+func (q *Queries) SetTournamentRound(ctx context.Context, arg SetTournamentRoundParams) error {
+	_, err := q.db.ExecContext(ctx, setTournamentRound, arg.CurrentRound, arg.Status, arg.ID)
+	return err
+}
+
+const setUserAdmin = `-- name: SetUserAdmin :execrows
+UPDATE users
+SET is_admin = ?
+WHERE username = ?
+`
+
+type SetUserAdminParams struct {
+	IsAdmin  bool
+	Username string
+}
+
+func (q *Queries) SetUserAdmin(ctx context.Context, arg SetUserAdminParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserAdmin, arg.IsAdmin, arg.Username)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []User
-	for rows.Next() {
-		var i User
-		if err := rows.Scan(
-			&i.Uid,
-			&i.Username,
-			&i.PasswordHash,
-			&i.ApiKey,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
+		return 0, err
 	}
-	if err := rows.Close(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const setUserBanned = `-- name: SetUserBanned :execrows
+UPDATE users
+SET banned = ?
+WHERE uid = ?
+`
+
+type SetUserBannedParams struct {
+	Banned bool
+	Uid    int64
+}
+
+func (q *Queries) SetUserBanned(ctx context.Context, arg SetUserBannedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserBanned, arg.Banned, arg.Uid)
+	if err != nil {
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const setUserBot = `-- name: SetUserBot :execrows
+UPDATE users
+SET is_bot = TRUE
+WHERE uid = ? AND is_guest = FALSE
+`
+
+func (q *Queries) SetUserBot(ctx context.Context, uid int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserBot, uid)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
+}
+
+const softDeleteUser = `-- name: SoftDeleteUser :exec
+UPDATE users
+SET deleted_at = ?
+WHERE uid = ?
+`
+
+type SoftDeleteUserParams struct {
+	DeletedAt sql.NullTime
+	Uid       int64
+}
+
+func (q *Queries) SoftDeleteUser(ctx context.Context, arg SoftDeleteUserParams) error {
+	_, err := q.db.ExecContext(ctx, softDeleteUser, arg.DeletedAt, arg.Uid)
+	return err
+}
+
+const storeFairPlayAnalysis = `-- name: StoreFairPlayAnalysis :exec
+INSERT OR REPLACE INTO fair_play_analyses (game_id, uid, moves, engine_matches, average_move_time, move_time_deviation, suspicion)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`
+
+type StoreFairPlayAnalysisParams struct {
+	GameID            int64
+	Uid               int64
+	Moves             int64
+	EngineMatches     int64
+	AverageMoveTime   int64
+	MoveTimeDeviation int64
+	Suspicion         int64
+}
+
+func (q *Queries) StoreFairPlayAnalysis(ctx context.Context, arg StoreFairPlayAnalysisParams) error {
+	_, err := q.db.ExecContext(ctx, storeFairPlayAnalysis,
+		arg.GameID,
+		arg.Uid,
+		arg.Moves,
+		arg.EngineMatches,
+		arg.AverageMoveTime,
+		arg.MoveTimeDeviation,
+		arg.Suspicion,
+	)
+	return err
 }
 
 const storeGame = `-- name: StoreGame :one
-INSERT INTO games (white_uid, black_uid, result, moves, finished_at)
-VALUES (?, ?, ?, ?, ?)
-RETURNING id, white_uid, black_uid, result, moves, finished_at
+INSERT INTO games (white_uid, black_uid, result, moves, finished_at, eco, opening, match_id, method, final_fen)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, white_uid, black_uid, result, moves, finished_at, eco, opening, match_id, method, final_fen
 `
 
 type StoreGameParams struct {
@@ -252,6 +3157,11 @@ type StoreGameParams struct {
 	Result     string
 	Moves      string
 	FinishedAt time.Time
+	Eco        string
+	Opening    string
+	MatchID    string
+	Method     string
+	FinalFen   string
 }
 
 func (q *Queries) StoreGame(ctx context.Context, arg StoreGameParams) (Game, error) {
@@ -261,6 +3171,11 @@ func (q *Queries) StoreGame(ctx context.Context, arg StoreGameParams) (Game, err
 		arg.Result,
 		arg.Moves,
 		arg.FinishedAt,
+		arg.Eco,
+		arg.Opening,
+		arg.MatchID,
+		arg.Method,
+		arg.FinalFen,
 	)
 	var i Game
 	err := row.Scan(
@@ -270,10 +3185,124 @@ func (q *Queries) StoreGame(ctx context.Context, arg StoreGameParams) (Game, err
 		&i.Result,
 		&i.Moves,
 		&i.FinishedAt,
+		&i.Eco,
+		&i.Opening,
+		&i.MatchID,
+		&i.Method,
+		&i.FinalFen,
 	)
 	return i, err
 }
 
+const storeMatchMove = `-- name: StoreMatchMove :exec
+INSERT OR IGNORE INTO match_moves (match_id, ply, move, played_at)
+VALUES (?, ?, ?, ?)
+`
+
+type StoreMatchMoveParams struct {
+	MatchID  string
+	Ply      int64
+	Move     string
+	PlayedAt time.Time
+}
+
+func (q *Queries) StoreMatchMove(ctx context.Context, arg StoreMatchMoveParams) error {
+	_, err := q.db.ExecContext(ctx, storeMatchMove,
+		arg.MatchID,
+		arg.Ply,
+		arg.Move,
+		arg.PlayedAt,
+	)
+	return err
+}
+
+const storePuzzleScan = `-- name: StorePuzzleScan :exec
+INSERT INTO puzzle_scans (game_id, puzzles)
+VALUES (?, ?)
+`
+
+type StorePuzzleScanParams struct {
+	GameID  int64
+	Puzzles int64
+}
+
+func (q *Queries) StorePuzzleScan(ctx context.Context, arg StorePuzzleScanParams) error {
+	_, err := q.db.ExecContext(ctx, storePuzzleScan, arg.GameID, arg.Puzzles)
+	return err
+}
+
+const suspendMatch = `-- name: SuspendMatch :exec
+INSERT OR REPLACE INTO suspended_matches (id, rated, white_username, black_username, moves, start_time, end_time, computer_level)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type SuspendMatchParams struct {
+	ID            string
+	Rated         bool
+	WhiteUsername string
+	BlackUsername string
+	Moves         string
+	StartTime     time.Time
+	EndTime       time.Time
+	ComputerLevel int64
+}
+
+func (q *Queries) SuspendMatch(ctx context.Context, arg SuspendMatchParams) error {
+	_, err := q.db.ExecContext(ctx, suspendMatch,
+		arg.ID,
+		arg.Rated,
+		arg.WhiteUsername,
+		arg.BlackUsername,
+		arg.Moves,
+		arg.StartTime,
+		arg.EndTime,
+		arg.ComputerLevel,
+	)
+	return err
+}
+
+const teamNameExists = `-- name: TeamNameExists :one
+SELECT EXISTS (SELECT 1 FROM teams WHERE name = ?)
+`
+
+func (q *Queries) TeamNameExists(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, teamNameExists, name)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const updateStudyTree = `-- name: UpdateStudyTree :exec
+UPDATE studies SET tree = ?, updated_at = ?
+WHERE id = ?
+`
+
+type UpdateStudyTreeParams struct {
+	Tree      string
+	UpdatedAt time.Time
+	ID        string
+}
+
+func (q *Queries) UpdateStudyTree(ctx context.Context, arg UpdateStudyTreeParams) error {
+	_, err := q.db.ExecContext(ctx, updateStudyTree, arg.Tree, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const updateTeamDescription = `-- name: UpdateTeamDescription :exec
+UPDATE teams SET description = ?
+WHERE id = ?
+`
+
+type UpdateTeamDescriptionParams struct {
+	Description string
+	ID          string
+}
+
+func (q *Queries) UpdateTeamDescription(ctx context.Context, arg UpdateTeamDescriptionParams) error {
+	_, err := q.db.ExecContext(ctx, updateTeamDescription, arg.Description, arg.ID)
+	return err
+}
+
 const updateUserAPIKey = `-- name: UpdateUserAPIKey :exec
 UPDATE users
 SET api_key = ?1
@@ -290,11 +3319,27 @@ func (q *Queries) UpdateUserAPIKey(ctx context.Context, arg UpdateUserAPIKeyPara
 	return err
 }
 
+const updateUserEmail = `-- name: UpdateUserEmail :exec
+UPDATE users
+SET email = ?
+WHERE uid = ?
+`
+
+type UpdateUserEmailParams struct {
+	Email string
+	Uid   int64
+}
+
+func (q *Queries) UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserEmail, arg.Email, arg.Uid)
+	return err
+}
+
 const updateUserPassword = `-- name: UpdateUserPassword :one
 UPDATE users
 SET password_hash= ?
 WHERE uid = ?
-RETURNING uid, username, password_hash, api_key, created_at
+RETURNING uid, username, password_hash, api_key, is_guest, preferences, email, is_admin, is_bot, banned, created_at, deleted_at
 `
 
 type UpdateUserPasswordParams struct {
@@ -310,7 +3355,87 @@ func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPassword
 		&i.Username,
 		&i.PasswordHash,
 		&i.ApiKey,
+		&i.IsGuest,
+		&i.Preferences,
+		&i.Email,
+		&i.IsAdmin,
+		&i.IsBot,
+		&i.Banned,
+		&i.CreatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const updateUserPreferences = `-- name: UpdateUserPreferences :exec
+UPDATE users
+SET preferences = ?
+WHERE uid = ?
+`
+
+type UpdateUserPreferencesParams struct {
+	Preferences string
+	Uid         int64
+}
+
+func (q *Queries) UpdateUserPreferences(ctx context.Context, arg UpdateUserPreferencesParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPreferences, arg.Preferences, arg.Uid)
+	return err
+}
+
+const upgradeGuestUser = `-- name: UpgradeGuestUser :one
+UPDATE users
+SET username = ?, password_hash = ?, api_key = ?, is_guest = FALSE
+WHERE uid = ? AND is_guest
+RETURNING uid, username, password_hash, api_key, is_guest, preferences, email, is_admin, is_bot, banned, created_at, deleted_at
+`
+
+type UpgradeGuestUserParams struct {
+	Username     string
+	PasswordHash string
+	ApiKey       string
+	Uid          int64
+}
+
+func (q *Queries) UpgradeGuestUser(ctx context.Context, arg UpgradeGuestUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, upgradeGuestUser,
+		arg.Username,
+		arg.PasswordHash,
+		arg.ApiKey,
+		arg.Uid,
+	)
+	var i User
+	err := row.Scan(
+		&i.Uid,
+		&i.Username,
+		&i.PasswordHash,
+		&i.ApiKey,
+		&i.IsGuest,
+		&i.Preferences,
+		&i.Email,
+		&i.IsAdmin,
+		&i.IsBot,
+		&i.Banned,
 		&i.CreatedAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
+
+const withdrawTournamentPlayer = `-- name: WithdrawTournamentPlayer :execrows
+UPDATE tournament_players SET withdrawn = TRUE
+WHERE tournament_id = ? AND uid = ? AND NOT withdrawn
+`
+
+type WithdrawTournamentPlayerParams struct {
+	TournamentID string
+	Uid          int64
+}
+
+func (q *Queries) WithdrawTournamentPlayer(ctx context.Context, arg WithdrawTournamentPlayerParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, withdrawTournamentPlayer, arg.TournamentID, arg.Uid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -18,39 +18,73 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/auth/login": {
-            "post": {
-                "description": "Log into an account using provided username and password. And get an API key.\nUsername can be between 3-20 characters.\nPassword must be at least 3 characters.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/abuse/ips": {
+            "get": {
+                "description": "Lists IPs that several accounts signed up or logged in from, most accounts first.\nClusters of accounts on one IP can be smurfs or spam accounts, but also people on the same network.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
                 ],
-                "summary": "Log into an account and get an API key.",
+                "summary": "List IPs shared by several accounts",
                 "parameters": [
                     {
-                        "description": "Login Account",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/server.UserCredentials"
-                        }
+                        "type": "string",
+                        "description": "Must contain ApiKey of an admin in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "only count activity at or after this time (RFC 3339), default 7 days ago",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "minimum number of accounts, default 2",
+                        "name": "min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "default 50, max 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "default 0",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/server.ApiKeyResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.SharedIP"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
                         }
                     },
                     "401": {
-                        "description": "Invalid username/password",
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an admin",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorReason"
                         }
@@ -64,52 +98,97 @@ const docTemplate = `{
                 }
             }
         },
-        "/matches": {
-            "post": {
-                "description": "**Authorized users** can make a match and receive a game id, which other users can use to join the match.\n### Note:\n### You must be the first one to send a GET to /matches/:id if you want to be the one who picks the colors.\n### duration maxes out at 12 hours",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/audit": {
+            "get": {
+                "description": "Lists security relevant events, newest first.\nActions: login, login.failed, account.locked, account.created, account.upgraded, account.deleted, account.restored, account.purged,\napi_key.issued, signup.blocked, admin.ban, admin.unban, admin.grant, admin.delete_match, admin.adjudicate, admin.resolve_report",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "matches"
+                    "admin"
                 ],
-                "summary": "Create a match, and get a sharable match id.",
+                "summary": "List the audit log",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "description": "Must contain ApiKey of an admin in the format Bearer: apiKey",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
                     },
                     {
-                        "description": "Duration of the match in hours. Max is 12",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/server.CreateMatchRequest"
-                        }
+                        "type": "string",
+                        "description": "only events with this action",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only events performed by this username",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only events performed on this username or match",
+                        "name": "target",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only events at or after this time (RFC 3339)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only events before this time (RFC 3339)",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "default 50, max 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "default 0",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Match Created",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/server.MatchCreatedResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.AuditEvent"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid json body",
+                        "description": "Invalid query",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorReason"
                         }
                     },
                     "403": {
-                        "description": "Invalid Authorization header",
+                        "description": "Not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorReason"
                         }
@@ -117,107 +196,91 @@ const docTemplate = `{
                 }
             }
         },
-        "/matches/{id}": {
+        "/admin/backup": {
             "get": {
-                "description": "Get the board position in FEN format.\nUnauthorized clients can use this.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Responds with a consistent copy of the database, taken without stopping the server.\nAccounts, archived games and the audit log are included, ongoing matches are not.\nStart the server with ` + "`" + `-restore \u003cfile\u003e` + "`" + ` to restore it.",
                 "produces": [
-                    "application/json"
+                    "application/vnd.sqlite3"
                 ],
                 "tags": [
-                    "matches"
+                    "admin"
                 ],
-                "summary": "Get board in FEN format.",
+                "summary": "Download a backup of the database",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Match ID",
-                        "name": "id",
-                        "in": "path",
+                        "description": "Must contain ApiKey of an admin in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "board FEN",
+                        "description": "SQLite database",
                         "schema": {
-                            "type": "string"
+                            "type": "file"
                         }
                     },
-                    "400": {
-                        "description": "Invalid json body / invalid move",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorReason"
                         }
                     },
-                    "404": {
-                        "description": "Match not found",
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorReason"
                         }
                     }
                 }
             },
-            "put": {
-                "description": "You must be in-game to post a move.\nThe move needs to be in UCI format. eg. ` + "`" + `e2e4` + "`" + `\nYou cannot make a move if it's not your turn.",
-                "consumes": [
-                    "application/json"
-                ],
+            "post": {
+                "description": "Writes a consistent copy of the database to the backup directory on the server, without stopping it.\nAccounts, archived games and the audit log are included, ongoing matches are not.\nStart the server with ` + "`" + `-restore \u003cfile\u003e` + "`" + ` to restore it.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "matches"
+                    "admin"
                 ],
-                "summary": "players in-game can make moves when it's their turn.",
+                "summary": "Back up the database to a file",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "description": "Must contain ApiKey of an admin in the format Bearer: apiKey",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
-                    },
-                    {
-                        "description": "move in UCI notation. eg. e2e4",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/server.PutMoveRequest"
-                        }
-                    },
-                    {
-                        "type": "string",
-                        "description": "Match ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "ok",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/server.Backup"
                         }
                     },
-                    "400": {
-                        "description": "Invalid json body / invalid move",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorReason"
                         }
                     },
                     "403": {
-                        "description": "Unauthorized",
+                        "description": "Not an admin",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorReason"
                         }
                     },
-                    "404": {
-                        "description": "Match not found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorReason"
                         }
@@ -225,56 +288,67 @@ const docTemplate = `{
                 }
             }
         },
-        "/matches/{id}/img": {
+        "/admin/fair-play": {
             "get": {
-                "description": "Get the board position in SVG Image format.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Rated games are analyzed with the engine when the server runs with -fair-play. Each player gets a suspicion from 0 to 100 per game,\nfrom how often they found the engine's best move and how regular their move times were.\nPlayers are flagged when their games of the last 30 days are at least 60 suspicious on average, over at least 3 games. Most suspicious first.\nGames at least 80 suspicious are also reported in the ` + "`" + `cheating` + "`" + ` category.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "matches"
+                    "admin"
                 ],
-                "summary": "Get board in SVG format.",
+                "summary": "List players flagged for engine use",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "description": "Must contain ApiKey of an admin in the format Bearer: apiKey",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Match ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "description": "default 50, max 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "default 0",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "SVG image",
+                        "description": "OK",
                         "schema": {
-                            "type": "file"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.FlaggedPlayer"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid json body / invalid move",
+                        "description": "Invalid query",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorReason"
                         }
                     },
-                    "403": {
+                    "401": {
                         "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorReason"
                         }
                     },
-                    "404": {
-                        "description": "Match not found",
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorReason"
                         }
@@ -282,24 +356,20 @@ const docTemplate = `{
                 }
             }
         },
-        "/matches/{id}/play": {
-            "get": {
-                "description": "Authorized users can join a match using the game id.\nThe first person to join choeses their color.\n## On success the server will send ` + "`" + `SSE` + "`" + ` messages whose payloads are JSON.\nEvents don't send this entire object: each event uses only some fields.\nLook [here](https://github.com/BrownNPC/chess-api/blob/master/server/game/game.go#L33) to see **which fields are used by which event.**",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/matches/{id}": {
+            "delete": {
+                "description": "Deletes an ongoing match without storing a result. Players receive an ` + "`" + `aborted` + "`" + ` event.",
                 "produces": [
-                    "application/json",
-                    "text/event-stream"
+                    "application/json"
                 ],
                 "tags": [
-                    "matches"
+                    "admin"
                 ],
-                "summary": "Join a match and receive events from the server.",
+                "summary": "Delete a match",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "description": "Must contain ApiKey of an admin in the format Bearer: apiKey",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
@@ -310,32 +380,23 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "` + "`" + `blackPieces` + "`" + ` is used to pick if you want to play as the black pieces. This is ignored if you are not the first one to join.",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/server.JoinMatchRequest"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "SSE stream — each ` + "`" + `data:` + "`" + ` payload uses some fields of this JSON object (Content-Type: text/event-stream). Events dont sent this whole object.",
+                        "description": "deleted",
                         "schema": {
-                            "$ref": "#/definitions/game.Event"
+                            "type": "string"
                         }
                     },
-                    "400": {
-                        "description": "Invalid json body",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorReason"
                         }
                     },
                     "403": {
-                        "description": "Unauthorized",
+                        "description": "Not an admin",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorReason"
                         }
@@ -349,9 +410,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/users": {
+        "/admin/matches/{id}/adjudicate": {
             "post": {
-                "description": "Username can be between 3-20 characters.\nPassword must be at least 3 characters.",
+                "description": "Ends an ongoing match with the given result. Players receive an ` + "`" + `adjudicated` + "`" + ` event.",
                 "consumes": [
                     "application/json"
                 ],
@@ -359,80 +420,378 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Create an account using provided username and password.",
+                "summary": "Decide the result of a match",
                 "parameters": [
                     {
-                        "description": "Register Account",
+                        "type": "string",
+                        "description": "Must contain ApiKey of an admin in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "winner",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/server.UserCredentials"
+                            "$ref": "#/definitions/server.AdjudicateRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Api Key",
+                    "200": {
+                        "description": "adjudicated",
                         "schema": {
-                            "$ref": "#/definitions/server.ApiKeyResponse"
+                            "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Invalid credentials",
+                        "description": "Invalid json body / invalid result",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorReason"
                         }
                     },
-                    "409": {
-                        "description": "Username already exists",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorReason"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "409": {
+                        "description": "Game already ended",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorReason"
                         }
                     }
                 }
-            },
-            "delete": {
-                "consumes": [
+            }
+        },
+        "/admin/reports": {
+            "get": {
+                "description": "Lists reports made by users, by the word filter and by the fair-play analysis, newest first.",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List reports",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey of an admin in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "include resolved reports",
+                        "name": "resolved",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "default 50, max 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "default 0",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.Report"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reports/{id}/resolve": {
+            "post": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Delete an account",
+                "summary": "Mark a report as resolved",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "description": "Must contain ApiKey of an admin in the format Bearer: apiKey",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Report ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "deleted",
+                        "description": "resolved",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid id",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Report not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/stats": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get server statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey of an admin in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.ServerStats"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users": {
+            "get": {
+                "description": "Lists every user, newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey of an admin in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "default 50, max 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "default 0",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.AdminUser"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{username}/ban": {
+            "post": {
+                "description": "Banned users cannot log in or use their api key.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Ban a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey of an admin in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "banned",
                         "schema": {
                             "type": "string"
                         }
                     },
+                    "400": {
+                        "description": "Cannot ban yourself",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorReason"
                         }
                     },
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -440,112 +799,9827 @@ const docTemplate = `{
                         }
                     }
                 }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Unban a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey of an admin in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "unbanned",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{username}/fair-play": {
+            "get": {
+                "description": "How the player played in each analyzed rated game, newest first. See GET /admin/fair-play.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List the fair-play analyses of a player",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey of an admin in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "default 50, max 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "default 0",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.FairPlayGame"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{username}/related": {
+            "get": {
+                "description": "Lists other accounts that signed up or logged in from an IP the user signed up or logged in from.\nAccounts that share several IPs are listed once per IP.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List accounts related to a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey of an admin in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.RelatedAccount"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/board/game/stream/{id}": {
+            "get": {
+                "description": "Joins the match and streams it as newline delimited JSON (Content-Type: application/x-ndjson), in the shapes of the Lichess board API.\nThe first line is a ` + "`" + `gameFull` + "`" + ` event, followed by ` + "`" + `gameState` + "`" + ` events after every move, your own too, ` + "`" + `chatLine` + "`" + ` events, and ` + "`" + `opponentGone` + "`" + ` events when the opponent's stream ends and when they are back.\nThe stream ends after the ` + "`" + `gameState` + "`" + ` of a finished game.\nUnlike on Lichess, matches must be joined through this stream: closing it resigns unless you open it again within a minute, like GET /matches/{id}/play.\nThe first player to join plays white, unless the match was created from a challenge.\nMatches have no clock, ` + "`" + `wtime` + "`" + ` and ` + "`" + `btime` + "`" + ` are the time until the match is deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lichess"
+                ],
+                "summary": "Stream a game like the Lichess board API",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "NDJSON stream — one event per line, a LichessGameFull, LichessGameState or LichessChatLine",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessGameFull"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "403": {
+                        "description": "Blocked by the opponent / match is full",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many open streams",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "503": {
+                        "description": "Server is restarting",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/board/game/{id}/chat": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lichess"
+                ],
+                "summary": "Get the chat of a game like the Lichess board API",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.LichessChatMessage"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found / player not in-game",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Only the ` + "`" + `player` + "`" + ` room exists. The same limits as POST /matches/{id}/chat apply.",
+                "consumes": [
+                    "application/x-www-form-urlencoded",
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lichess"
+                ],
+                "summary": "Send a chat message like the Lichess board API",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "player"
+                        ],
+                        "type": "string",
+                        "description": "chat room",
+                        "name": "room",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "chat message",
+                        "name": "text",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessOk"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid message",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "403": {
+                        "description": "Blocked",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found / player not in-game",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many messages",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/board/game/{id}/move/{move}": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lichess"
+                ],
+                "summary": "Play a move like the Lichess board API",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "move in UCI notation. eg. e2e4",
+                        "name": "move",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessOk"
+                        }
+                    },
+                    "400": {
+                        "description": "The move is not in UCI notation",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "403": {
+                        "description": "Player not in-game",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "409": {
+                        "description": "Not your turn / the game is over",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "422": {
+                        "description": "The move is not legal in this position",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "503": {
+                        "description": "Server is restarting",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/board/game/{id}/resign": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lichess"
+                ],
+                "summary": "Resign a game like the Lichess board API",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessOk"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found / player not in-game",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "409": {
+                        "description": "The game is over",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "503": {
+                        "description": "Server is restarting",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/bot/game/stream/{id}": {
+            "get": {
+                "description": "Joins the match and streams it as newline delimited JSON (Content-Type: application/x-ndjson), in the shapes of the Lichess board API.\nThe first line is a ` + "`" + `gameFull` + "`" + ` event, followed by ` + "`" + `gameState` + "`" + ` events after every move, your own too, ` + "`" + `chatLine` + "`" + ` events, and ` + "`" + `opponentGone` + "`" + ` events when the opponent's stream ends and when they are back.\nThe stream ends after the ` + "`" + `gameState` + "`" + ` of a finished game.\nUnlike on Lichess, matches must be joined through this stream: closing it resigns unless you open it again within a minute, like GET /matches/{id}/play.\nThe first player to join plays white, unless the match was created from a challenge.\nMatches have no clock, ` + "`" + `wtime` + "`" + ` and ` + "`" + `btime` + "`" + ` are the time until the match is deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lichess"
+                ],
+                "summary": "Stream a game like the Lichess board API",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "NDJSON stream — one event per line, a LichessGameFull, LichessGameState or LichessChatLine",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessGameFull"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "403": {
+                        "description": "Blocked by the opponent / match is full",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many open streams",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "503": {
+                        "description": "Server is restarting",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/bot/game/{id}/chat": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lichess"
+                ],
+                "summary": "Get the chat of a game like the Lichess board API",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.LichessChatMessage"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found / player not in-game",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Only the ` + "`" + `player` + "`" + ` room exists. The same limits as POST /matches/{id}/chat apply.",
+                "consumes": [
+                    "application/x-www-form-urlencoded",
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lichess"
+                ],
+                "summary": "Send a chat message like the Lichess board API",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "player"
+                        ],
+                        "type": "string",
+                        "description": "chat room",
+                        "name": "room",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "chat message",
+                        "name": "text",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessOk"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid message",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "403": {
+                        "description": "Blocked",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found / player not in-game",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many messages",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/bot/game/{id}/move/{move}": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lichess"
+                ],
+                "summary": "Play a move like the Lichess board API",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "move in UCI notation. eg. e2e4",
+                        "name": "move",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessOk"
+                        }
+                    },
+                    "400": {
+                        "description": "The move is not in UCI notation",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "403": {
+                        "description": "Player not in-game",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "409": {
+                        "description": "Not your turn / the game is over",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "422": {
+                        "description": "The move is not legal in this position",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "503": {
+                        "description": "Server is restarting",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/bot/game/{id}/resign": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lichess"
+                ],
+                "summary": "Resign a game like the Lichess board API",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessOk"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found / player not in-game",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "409": {
+                        "description": "The game is over",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    },
+                    "503": {
+                        "description": "Server is restarting",
+                        "schema": {
+                            "$ref": "#/definitions/server.LichessError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/challenge": {
+            "get": {
+                "description": "When enabled, creating an account or a guest requires solving a proof-of-work challenge.\nFind a nonce so that the SHA-256 hash of ` + "`" + `challenge + nonce` + "`" + ` starts with ` + "`" + `difficulty` + "`" + ` zero bits,\nthen send the challenge and the nonce in the ` + "`" + `X-Challenge` + "`" + ` and ` + "`" + `X-Challenge-Nonce` + "`" + ` headers of ` + "`" + `POST /users` + "`" + ` or ` + "`" + `POST /auth/guest` + "`" + `.\nEach challenge can be used once. A difficulty of 0 means no challenge is needed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Get a signup challenge",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.SignupChallengeResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/guest": {
+            "post": {
+                "description": "Creates a temporary guest account and returns its username and API key.\nGuests can only play casual (unrated) matches.\nGuest accounts are deleted after 24 hours, unless they are upgraded to a full account using ` + "`" + `POST /users/upgrade` + "`" + `.\nIf signup challenges are enabled, a solved challenge from ` + "`" + `GET /auth/challenge` + "`" + ` is required.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Play as a guest without creating an account.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "challenge from GET /auth/challenge",
+                        "name": "X-Challenge",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "nonce that solves the challenge",
+                        "name": "X-Challenge-Nonce",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.GuestResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing or unsolved challenge",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many accounts created from this IP",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Log into an account using provided username and password. And get an API key.\nUsername can be between 3-20 characters.\nPassword must be at least 3 characters.\nLogging into an account that is scheduled for deletion restores it.\nAfter 3 failed attempts, each attempt must wait twice as long as the previous one.\nAfter 10 failed attempts the account is locked for 15 minutes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Log into an account and get an API key.",
+                "parameters": [
+                    {
+                        "description": "Login Account",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.UserCredentials"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.ApiKeyResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid username/password",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Account banned",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "423": {
+                        "description": "Account locked",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts, see the Retry-After header",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/bot/game/stream/{id}": {
+            "get": {
+                "description": "Joins the match and streams its state as newline delimited JSON (Content-Type: application/x-ndjson), one event per line.\nThe first line is a ` + "`" + `gameFull` + "`" + ` event, followed by ` + "`" + `gameState` + "`" + ` events after every opponent move and when the game ends, ` + "`" + `chatLine` + "`" + ` events,\nan ` + "`" + `opening` + "`" + ` event once the game leaves the opening book, and ` + "`" + `opponentDisconnected` + "`" + ` and ` + "`" + `opponentConnected` + "`" + ` events when the opponent's stream ends and when they are back.\nMoves are played with PUT /matches/{id}. Empty lines are sent to keep the connection alive.\nLike match event streams, closing the stream resigns unless you open it again within a minute, and ` + "`" + `serverRestarting` + "`" + ` and ` + "`" + `resync` + "`" + ` events end it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bots"
+                ],
+                "summary": "Play a match as a bot",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey of a bot in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "NDJSON stream — one event per line",
+                        "schema": {
+                            "$ref": "#/definitions/server.BotGameEvent"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Not a bot / blocked by the opponent / match is full",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many open streams",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "503": {
+                        "description": "Server is restarting",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/bot/stream/event": {
+            "get": {
+                "description": "## On success the server sends newline delimited JSON (Content-Type: application/x-ndjson), one event per line.\nOpen challenges are sent when the stream opens. Empty lines are sent to keep the connection alive.\nEvent types: ` + "`" + `challenge` + "`" + `, ` + "`" + `challengeCanceled` + "`" + `, ` + "`" + `gameStart` + "`" + `.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bots"
+                ],
+                "summary": "Receive challenges and game starts as they happen",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey of a bot in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "NDJSON stream — one event per line",
+                        "schema": {
+                            "$ref": "#/definitions/server.BotEvent"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Not a bot",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many open streams",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/challenges": {
+            "post": {
+                "description": "Sends a challenge to the event stream of a bot account. The challenge expires after 2 minutes.\nOnce the bot accepts, you get a ` + "`" + `challengeAccepted` + "`" + ` notification with the match id. Join it like any other match.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "challenges"
+                ],
+                "summary": "Challenge a bot to a match",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "the bot and the match to play",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateChallengeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.Challenge"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body / not a bot / cannot challenge yourself",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Blocked / guests cannot play rated matches",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many unfinished matches",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/challenges/{id}": {
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "challenges"
+                ],
+                "summary": "Cancel a challenge you sent",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Challenge ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "cancelled",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Challenge not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/challenges/{id}/accept": {
+            "post": {
+                "description": "Bots accept challenges from their event stream. This creates the match, owned by the challenger,\nand sends a ` + "`" + `gameStart` + "`" + ` event to the bot's event stream. Open the game stream of the match to play.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bots"
+                ],
+                "summary": "Accept a challenge",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey of a bot in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Challenge ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.ChallengeAccepted"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Challenge not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "429": {
+                        "description": "The challenger has too many unfinished matches",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/challenges/{id}/decline": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bots"
+                ],
+                "summary": "Decline a challenge",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey of a bot in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Challenge ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "declined",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Challenge not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/explorer": {
+            "get": {
+                "description": "Lists the moves played in a position of the games finished on this server, with how often each was played and how the games ended.\nThe first 30 half moves of every game are counted. Games count from the time this was added, older games are not in the explorer.\nThe position is ` + "`" + `fen` + "`" + ` after the moves of ` + "`" + `play` + "`" + `, by default the starting position.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "explorer"
+                ],
+                "summary": "Explore the moves played in a position",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "position to start from, the starting position by default",
+                        "name": "fen",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "e2e4,e7e5",
+                        "description": "moves played from fen in UCI notation, separated by commas",
+                        "name": "play",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.Explorer"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid fen or move",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "422": {
+                        "description": "Illegal move",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Always succeeds while the process is running.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/imports/pgn": {
+            "post": {
+                "description": "Stores a game played elsewhere so it can be reviewed with this api.\nThe returned id works like a match id with GET /matches/{id}, /matches/{id}/img and /matches/{id}/gif, which take a ` + "`" + `ply` + "`" + ` to show any position of the game.\nImported games are read-only, moves cannot be played in them. The PGN can be sent as json or as the ` + "`" + `pgn` + "`" + ` field of a form.",
+                "consumes": [
+                    "application/json",
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "imports"
+                ],
+                "summary": "Import a game from PGN",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "PGN of one game",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.ImportPGNRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.ImportedGameResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body / invalid PGN",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/matches": {
+            "post": {
+                "description": "**Authorized users** can make a match and receive a game id, which other users can use to join the match.\n### Note:\n### You must be the first one to send a GET to /matches/:id if you want to be the one who picks the colors.\n### duration maxes out at 12 hours\n### guests can only create casual (unrated) matches",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "matches"
+                ],
+                "summary": "Create a match, and get a sharable match id.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Duration of the match in hours. Max is 12",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateMatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Match Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.MatchCreatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Invalid Authorization header / guests cannot create rated matches",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many unfinished matches",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/matches/computer": {
+            "post": {
+                "description": "The computer joins right away with the other color. Join the match with GET /matches/{id}/play and play like in any other match.\nLevels go from 1 to 8. Matches against the computer are not rated.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "matches"
+                ],
+                "summary": "Create a match against the computer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "level of the computer and your color",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateComputerMatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Match Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.MatchCreatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many unfinished matches",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "503": {
+                        "description": "No engine is configured on this server",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/matches/{id}": {
+            "get": {
+                "description": "Get the board position in FEN format.\nUnauthorized clients can use this.\nSend ` + "`" + `Accept: application/json` + "`" + ` to get a BoardState with the full FEN, the opening (ECO code and name) and the captured pieces instead.\nGames imported with POST /imports/pgn are shown too, at their final position or at the position after ` + "`" + `ply` + "`" + ` half moves.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/plain"
+                ],
+                "tags": [
+                    "matches"
+                ],
+                "summary": "Get board in FEN format.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "half moves played, only for imported games",
+                        "name": "ply",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "board FEN, or a BoardState with Accept: application/json",
+                        "schema": {
+                            "$ref": "#/definitions/server.BoardState"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Identifies the board position"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Board did not change since the ETag in If-None-Match",
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Identifies the board position"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body / invalid move",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "You must be in-game to post a move.\nThe move needs to be in UCI format. eg. ` + "`" + `e2e4` + "`" + `\nYou cannot make a move if it's not your turn.\nIf ` + "`" + `autoQueen` + "`" + ` is enabled in your preferences, pawns reaching the last rank without a promotion piece become queens.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "matches"
+                ],
+                "summary": "players in-game can make moves when it's their turn.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "move in UCI notation. eg. e2e4",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.PutMoveRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body / INVALID_INPUT: the move is missing / INVALID_MOVE_NOTATION: the move is not in UCI notation",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Unauthorized / PLAYER_NOT_IN_MATCH",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "MATCH_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "409": {
+                        "description": "NOT_YOUR_TURN / GAME_OVER",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "422": {
+                        "description": "ILLEGAL_MOVE: the move is not legal in this position",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "503": {
+                        "description": "Server is restarting",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/matches/{id}/chat": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "matches"
+                ],
+                "summary": "Get the chat history of a match",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.ChatMessage"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found / player not in-game",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Your opponent receives a ` + "`" + `chat` + "`" + ` event on their event stream.\nYou can send at most 5 messages every 10 seconds. Messages can be at most 200 characters.\nOffensive words are replaced with asterisks. You cannot message an opponent if either of you blocked the other.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "matches"
+                ],
+                "summary": "Send a chat message to your opponent",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "chat message",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.ChatRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.ChatMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body / invalid message",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Unauthorized / blocked",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found / player not in-game",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many messages",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/matches/{id}/embed": {
+            "get": {
+                "description": "The page needs no api key and no other files. It asks GET /matches/{id} for the board every few seconds, so it stays up to date.\nEmbed it in an iframe to show a match on a blog or in a stream overlay.",
+                "produces": [
+                    "text/html",
+                    "application/json"
+                ],
+                "tags": [
+                    "matches"
+                ],
+                "summary": "Get an HTML page that shows the board of a match",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "white",
+                            "black"
+                        ],
+                        "type": "string",
+                        "description": "color at the bottom of the board",
+                        "name": "orientation",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "brown",
+                            "blue",
+                            "green",
+                            "gray"
+                        ],
+                        "type": "string",
+                        "description": "board theme",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 128,
+                        "minimum": 16,
+                        "type": "integer",
+                        "default": 45,
+                        "description": "width of a square in pixels",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "highlight the squares of the last move",
+                        "name": "lastMove",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "HTML page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameter",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/matches/{id}/events/poll": {
+            "get": {
+                "description": "Long polling alternative to GET /matches/{id}/play, for clients that cannot keep a stream open.\nReturns the events sent to you after the event numbered ` + "`" + `since` + "`" + `, waiting up to ` + "`" + `timeout` + "`" + ` for one if there are none yet. Poll again with ` + "`" + `since` + "`" + ` set to ` + "`" + `next` + "`" + `.\nThe first poll joins the match if you are not playing it yet, ` + "`" + `blackPieces` + "`" + ` works like it does for /play. Moves are played with PUT /matches/{id}.\nPlayers who poll do not resign when they stop polling, polling after your stream ended keeps you from resigning too. A ` + "`" + `resync` + "`" + ` event means events were missed, continue from its position.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "matches"
+                ],
+                "summary": "Poll for the events of a match",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of the last event you got, 0 for all of them",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "how long to wait for an event, like 30s. At most 60s.",
+                        "name": "timeout",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "play as black when joining, ignored if you are not the first one to join",
+                        "name": "blackPieces",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.PollEventsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid since or timeout",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Unauthorized / match is full / guests cannot join rated matches / blocked by the opponent",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "503": {
+                        "description": "Server is restarting",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/matches/{id}/gif": {
+            "get": {
+                "description": "Every position of the game is a frame, from the start to the final position, which is shown longer.\nFinished games can be fetched until the match id is used again. Games imported with POST /imports/pgn can be fetched with their id.\nThe board is drawn using the board theme and piece set in your preferences, from the side of your color if you played.\nResponses have an ` + "`" + `ETag` + "`" + `. Send it in ` + "`" + `If-None-Match` + "`" + ` to get a ` + "`" + `304` + "`" + `.",
+                "produces": [
+                    "image/gif",
+                    "application/json"
+                ],
+                "tags": [
+                    "matches"
+                ],
+                "summary": "Get an animated GIF of a finished game",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 10000,
+                        "minimum": 100,
+                        "type": "integer",
+                        "default": 1000,
+                        "description": "time each position is shown, in milliseconds",
+                        "name": "delay",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "white",
+                            "black"
+                        ],
+                        "type": "string",
+                        "description": "color at the bottom of the board",
+                        "name": "orientation",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "brown",
+                            "blue",
+                            "green",
+                            "gray"
+                        ],
+                        "type": "string",
+                        "description": "board theme",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "cburnett"
+                        ],
+                        "type": "string",
+                        "description": "piece set",
+                        "name": "pieces",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 64,
+                        "minimum": 16,
+                        "type": "integer",
+                        "default": 45,
+                        "description": "width of a square in pixels",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "highlight the squares of the last move",
+                        "name": "lastMove",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "highlight the king in check",
+                        "name": "check",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "GIF image",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "private, no-cache"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Identifies the game and how it is drawn"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "The GIF did not change since the ETag in If-None-Match",
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "private, no-cache"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Identifies the game and how it is drawn"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameter",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "409": {
+                        "description": "The game is not over",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/matches/{id}/img": {
+            "get": {
+                "description": "Get the board position in SVG Image format, or as PNG with ` + "`" + `format=png` + "`" + ` or at /matches/{id}/img.png for apps that don't show SVG.\nThe board is drawn using the board theme and piece set in your preferences, from the side of your color. Query parameters change how it is drawn.\nResponses have an ` + "`" + `ETag` + "`" + `. Send it in ` + "`" + `If-None-Match` + "`" + ` to get a ` + "`" + `304` + "`" + ` while the board has not changed.\nGames imported with POST /imports/pgn are drawn too, at their final position or at the position after ` + "`" + `ply` + "`" + ` half moves.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "image/svg+xml",
+                    "image/png",
+                    "application/json"
+                ],
+                "tags": [
+                    "matches"
+                ],
+                "summary": "Get board in SVG or PNG format.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "svg",
+                            "png"
+                        ],
+                        "type": "string",
+                        "default": "svg",
+                        "description": "image format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "white",
+                            "black"
+                        ],
+                        "type": "string",
+                        "description": "color at the bottom of the board",
+                        "name": "orientation",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "brown",
+                            "blue",
+                            "green",
+                            "gray"
+                        ],
+                        "type": "string",
+                        "description": "board theme",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "cburnett"
+                        ],
+                        "type": "string",
+                        "description": "piece set",
+                        "name": "pieces",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 128,
+                        "minimum": 16,
+                        "type": "integer",
+                        "default": 45,
+                        "description": "width of a square in pixels, the board is 8 squares wide",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "highlight the squares of the last move",
+                        "name": "lastMove",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "highlight the king in check",
+                        "name": "check",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "e2e4,g1f3",
+                        "description": "arrows to draw, moves in UCI notation separated by commas",
+                        "name": "arrows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "e4,d5",
+                        "description": "squares to mark, separated by commas",
+                        "name": "squares",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "half moves played, only for imported games",
+                        "name": "ply",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "SVG or PNG image",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "private, no-cache"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Identifies the board position and how it is drawn"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Board did not change since the ETag in If-None-Match",
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "private, no-cache"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Identifies the board position and how it is drawn"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameter",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/matches/{id}/img.png": {
+            "get": {
+                "description": "Get the board position in SVG Image format, or as PNG with ` + "`" + `format=png` + "`" + ` or at /matches/{id}/img.png for apps that don't show SVG.\nThe board is drawn using the board theme and piece set in your preferences, from the side of your color. Query parameters change how it is drawn.\nResponses have an ` + "`" + `ETag` + "`" + `. Send it in ` + "`" + `If-None-Match` + "`" + ` to get a ` + "`" + `304` + "`" + ` while the board has not changed.\nGames imported with POST /imports/pgn are drawn too, at their final position or at the position after ` + "`" + `ply` + "`" + ` half moves.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "image/svg+xml",
+                    "image/png",
+                    "application/json"
+                ],
+                "tags": [
+                    "matches"
+                ],
+                "summary": "Get board in SVG or PNG format.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "svg",
+                            "png"
+                        ],
+                        "type": "string",
+                        "default": "svg",
+                        "description": "image format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "white",
+                            "black"
+                        ],
+                        "type": "string",
+                        "description": "color at the bottom of the board",
+                        "name": "orientation",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "brown",
+                            "blue",
+                            "green",
+                            "gray"
+                        ],
+                        "type": "string",
+                        "description": "board theme",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "cburnett"
+                        ],
+                        "type": "string",
+                        "description": "piece set",
+                        "name": "pieces",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 128,
+                        "minimum": 16,
+                        "type": "integer",
+                        "default": 45,
+                        "description": "width of a square in pixels, the board is 8 squares wide",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "highlight the squares of the last move",
+                        "name": "lastMove",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "highlight the king in check",
+                        "name": "check",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "e2e4,g1f3",
+                        "description": "arrows to draw, moves in UCI notation separated by commas",
+                        "name": "arrows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "e4,d5",
+                        "description": "squares to mark, separated by commas",
+                        "name": "squares",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "half moves played, only for imported games",
+                        "name": "ply",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "SVG or PNG image",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "private, no-cache"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Identifies the board position and how it is drawn"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Board did not change since the ETag in If-None-Match",
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "private, no-cache"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Identifies the board position and how it is drawn"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameter",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/matches/{id}/play": {
+            "get": {
+                "description": "Authorized users can join a match using the game id.\nThe first person to join choeses their color.\n## On success the server will send ` + "`" + `SSE` + "`" + ` messages whose payloads are JSON.\nEvents don't send this entire object: each event uses only some fields.\nLook [here](https://github.com/BrownNPC/chess-api/blob/master/server/game/game.go#L33) to see **which fields are used by which event.**\n` + "`" + `move` + "`" + ` events have the opponent's move in UCI notation in ` + "`" + `move` + "`" + ` and in SAN in ` + "`" + `san` + "`" + `, the position after it in ` + "`" + `fen` + "`" + `, and the ` + "`" + `check` + "`" + `, ` + "`" + `checkmate` + "`" + `, ` + "`" + `capture` + "`" + `, ` + "`" + `castle` + "`" + ` and ` + "`" + `promotion` + "`" + ` flags.\nWhen the server restarts, players get a ` + "`" + `serverRestarting` + "`" + ` event and the stream ends. The match is not lost, join it again once the server is back.\nOnce the game leaves the opening book, players get an ` + "`" + `opening` + "`" + ` event with the ECO code in ` + "`" + `eco` + "`" + ` and the name of the opening in ` + "`" + `opening` + "`" + `.\nClients that fall too far behind reading events get a ` + "`" + `resync` + "`" + ` event with the current position in ` + "`" + `fen` + "`" + ` instead of the events they missed, and the stream ends. Join again to keep playing.\nWhen your stream ends you have a minute to join again, or you resign.\nWhen the opponent's stream ends, you get an ` + "`" + `opponentDisconnected` + "`" + ` event, and ` + "`" + `opponentConnected` + "`" + ` once they join again.\n` + "`" + `spectatorJoined` + "`" + ` and ` + "`" + `spectatorLeft` + "`" + ` events are sent when someone starts or stops watching the match, with how many people watch it in ` + "`" + `spectators` + "`" + `.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "matches"
+                ],
+                "summary": "Join a match and receive events from the server.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "` + "`" + `blackPieces` + "`" + ` is used to pick if you want to play as the black pieces. This is ignored if you are not the first one to join.",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.JoinMatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "SSE stream — each ` + "`" + `data:` + "`" + ` payload uses some fields of this JSON object (Content-Type: text/event-stream). Events dont sent this whole object.",
+                        "schema": {
+                            "$ref": "#/definitions/game.Event"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Unauthorized / guests cannot join rated matches / blocked by the opponent",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many open streams",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "503": {
+                        "description": "Server is restarting",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/matches/{id}/result": {
+            "get": {
+                "description": "The result is kept after the match is gone, so bots and tournament tools can collect it later.\nMatch ids are reused once a match is gone, the result is the one of the latest game played with the id.\nPlayers who deleted their account are shown as ` + "`" + `anonymous` + "`" + `.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "matches"
+                ],
+                "summary": "Get the result of a match",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.MatchResult"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "409": {
+                        "description": "The game is not over",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications": {
+            "get": {
+                "description": "Lists your most recent notifications, newest first.\nNotification types: ` + "`" + `friendRequest` + "`" + `, ` + "`" + `friendAccepted` + "`" + `, ` + "`" + `yourMove` + "`" + `, ` + "`" + `challengeAccepted` + "`" + `, ` + "`" + `challengeDeclined` + "`" + `, ` + "`" + `tournamentPairing` + "`" + `, ` + "`" + `simulStarted` + "`" + `, ` + "`" + `seekAccepted` + "`" + `.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "List your notifications",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only list unread notifications",
+                        "name": "unread",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max notifications to return, default 50, max 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.Notification"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/read": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Mark notifications as read",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "newest notification id that was read",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.MarkNotificationsReadRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/stream": {
+            "get": {
+                "description": "## On success the server will send ` + "`" + `SSE` + "`" + ` messages whose payloads are JSON notifications.\nHaving this stream open also shows you as online to your friends.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Receive notifications as they happen",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "SSE stream — each ` + "`" + `data:` + "`" + ` payload is a notification (Content-Type: text/event-stream).",
+                        "schema": {
+                            "$ref": "#/definitions/server.Notification"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many open streams",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/puzzles/random": {
+            "get": {
+                "description": "Puzzles are positions of finished games where one move clearly wins, found by the engine in the background.\nThe pool grows as games are played, it is empty when the server runs without an engine.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "puzzles"
+                ],
+                "summary": "Get a random puzzle",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.Puzzle"
+                        }
+                    },
+                    "404": {
+                        "description": "No puzzles yet",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/puzzles/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "puzzles"
+                ],
+                "summary": "Get a puzzle",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Puzzle ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.Puzzle"
+                        }
+                    },
+                    "404": {
+                        "description": "Puzzle not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Succeeds when the database is reachable and the schema is up to date, with the migrations of older databases done.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "ready",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "database unreachable / schema not applied / shutting down",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports": {
+            "post": {
+                "description": "Report a user for abusive chat messages, an offensive username, other abuse or for cheating.\nReports are reviewed by admins. Cheating reports are best made about a match, admins compare them with the fair-play analysis of its game.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Report a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "report",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.ReportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.ReportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body / invalid category",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/seeks": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "seeks"
+                ],
+                "summary": "List the open seeks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "newest first",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.Seek"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Seeks are matches anyone can accept, listed on GET /seeks. A seek expires after 30 minutes.\nOnce someone accepts it, you get a ` + "`" + `seekAccepted` + "`" + ` notification with the match id. Join it like any other match within 5 minutes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "seeks"
+                ],
+                "summary": "Post a seek",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "the match to play",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateSeekRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.Seek"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Guests cannot play rated matches",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many unfinished matches",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/seeks/{id}": {
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "seeks"
+                ],
+                "summary": "Cancel a seek you posted",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Seek ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "cancelled",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Seek not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/seeks/{id}/accept": {
+            "post": {
+                "description": "Creates the match of the seek, owned by the seeker, and sends them a ` + "`" + `seekAccepted` + "`" + ` notification.\nOnly the two players can join the match, each with the color of the seek.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "seeks"
+                ],
+                "summary": "Accept a seek",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Seek ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.SeekAccepted"
+                        }
+                    },
+                    "400": {
+                        "description": "Cannot accept your own seek",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Blocked / guests cannot play rated matches",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Seek not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "429": {
+                        "description": "The seeker has too many unfinished matches",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/simuls": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "simuls"
+                ],
+                "summary": "List the simuls that did not finish",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "newest first",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.Simul"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "In a simultaneous exhibition the host plays white against every challenger at once. Challengers join until the host starts the simul, then every challenger gets their own match.\nThe host goes from board to board in the order challengers joined, and can only move on the board they are at. Once they moved there they go on to the next board whose game is not over.\nChallengers play their match like any other, the host follows every board on GET /simuls/{id}/stream.\nSimuls are kept in memory, a simul is lost when the server restarts but its matches are not. Guests can only open casual simuls.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "simuls"
+                ],
+                "summary": "Open a simul",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "name, how many challengers and how many hours each game can last",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateSimulRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.Simul"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Guests cannot open rated simuls",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/simuls/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "simuls"
+                ],
+                "summary": "Get a simul with its boards",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Simul ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.Simul"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Simul not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/simuls/{id}/join": {
+            "post": {
+                "description": "Simuls can be joined until the host starts them. Joining twice does nothing.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "simuls"
+                ],
+                "summary": "Join a simul as a challenger",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Simul ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "joined",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Guests cannot join rated simuls / the host cannot join / blocked by the host",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Simul not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "409": {
+                        "description": "The simul already started / the simul is full",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Once the simul started, resign your match instead.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "simuls"
+                ],
+                "summary": "Leave a simul before it starts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Simul ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "left",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Simul not found, or you did not join it",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "409": {
+                        "description": "The simul already started",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/simuls/{id}/start": {
+            "post": {
+                "description": "Only the host can start a simul, once at least one challenger joined. A match is created for every challenger, who gets a ` + "`" + `simulStarted` + "`" + ` notification with its id.\nChallengers who don't join their match within 10 minutes lose their board.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "simuls"
+                ],
+                "summary": "Start a simul",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Simul ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.Simul"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "You are not the host",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Simul not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "409": {
+                        "description": "The simul already started / nobody joined",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/simuls/{id}/stream": {
+            "get": {
+                "description": "Joins every board of the simul that is not over and sends the events of all of them on one stream.\n## On success the server will send ` + "`" + `SSE` + "`" + ` messages whose payloads are JSON.\nThe first event is the whole simul. The events of each board come as ` + "`" + `board` + "`" + ` events with the match id, a ` + "`" + `turn` + "`" + ` event says on which board the host plays next.\nMoves are played with PUT /matches/{id} like in any match, on the board of the last ` + "`" + `turn` + "`" + ` event.\nClosing the stream does not resign any game, open it again to continue.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "simuls"
+                ],
+                "summary": "Play every board of a simul as its host",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Simul ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "SSE stream — each ` + "`" + `data:` + "`" + ` payload is a SimulEvent",
+                        "schema": {
+                            "$ref": "#/definitions/server.SimulEvent"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "You are not the host",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Simul not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "409": {
+                        "description": "The simul did not start / it is already streamed",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many open streams",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "503": {
+                        "description": "Server is restarting",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/studies": {
+            "get": {
+                "description": "Studies you own or were invited to, recently changed first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "studies"
+                ],
+                "summary": "List your studies",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.StudySummary"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "A study is an analysis board without turns or players. Everyone the owner invites can play moves for either side, add variations, take moves back and comment positions.\nChanges are sent to everyone on GET /studies/{id}/stream, which makes studies useful for coaching.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "studies"
+                ],
+                "summary": "Create a study",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "name and start position",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateStudyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.Study"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body / invalid FEN",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/studies/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "studies"
+                ],
+                "summary": "Get a study with all its moves",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Study ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.Study"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Study not found, or you are not a member",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "studies"
+                ],
+                "summary": "Delete a study",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Study ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Study not found, or you don't own it",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/studies/{id}/comments": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "studies"
+                ],
+                "summary": "Comment a position of a study",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Study ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "position and comment",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.StudyCommentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "the comment event sent to the stream",
+                        "schema": {
+                            "$ref": "#/definitions/server.StudyEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Study or position not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/studies/{id}/members/{username}": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "studies"
+                ],
+                "summary": "Invite a user to a study",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Study ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user to invite",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "already a member",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "201": {
+                        "description": "added",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Cannot invite yourself",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the owner / blocked",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Study or user not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "409": {
+                        "description": "Too many members",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "The owner can remove anyone, members can remove themselves to leave the study.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "studies"
+                ],
+                "summary": "Remove a member from a study",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Study ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "member to remove",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "removed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the owner",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Study not found / not a member",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/studies/{id}/moves": {
+            "post": {
+                "description": "Plays a move in the position at the end of ` + "`" + `path` + "`" + `, for whichever side is to move.\nThe first move played in a position is its main line, later ones are variations. Playing a move that was played before changes nothing.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "studies"
+                ],
+                "summary": "Play a move in a study",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Study ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "position and move",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.StudyMoveRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "the move event sent to the stream",
+                        "schema": {
+                            "$ref": "#/definitions/server.StudyEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body / invalid move notation",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Study or position not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "409": {
+                        "description": "Too many positions",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "422": {
+                        "description": "Illegal move",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/studies/{id}/stream": {
+            "get": {
+                "description": "## On success the server will send ` + "`" + `SSE` + "`" + ` messages whose payloads are JSON study events.\nThe first event is the whole study, the ones after it are the changes made since.\nThe stream ends after a ` + "`" + `deleted` + "`" + ` event, a ` + "`" + `memberRemoved` + "`" + ` event for you, or a ` + "`" + `resync` + "`" + ` event if you fell behind.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "studies"
+                ],
+                "summary": "Receive the changes of a study as they happen",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Study ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "SSE stream — each ` + "`" + `data:` + "`" + ` payload is a study event (Content-Type: text/event-stream).",
+                        "schema": {
+                            "$ref": "#/definitions/server.StudyEvent"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Study not found, or you are not a member",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many open streams",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/studies/{id}/undo": {
+            "post": {
+                "description": "Removes the move at the end of ` + "`" + `path` + "`" + `, with every move played after it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "studies"
+                ],
+                "summary": "Take back a move in a study",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Study ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "move to take back",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.StudyUndoRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "the undo event sent to the stream",
+                        "schema": {
+                            "$ref": "#/definitions/server.StudyEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Study or move not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/teams": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "teams"
+                ],
+                "summary": "List teams",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "only list teams with this in their name",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max teams to return, default 50, max 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "biggest first",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.Team"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "You become the owner of the team. Everyone can join it, its admins create matches and tournaments only members can join.\nThe team is deleted when its owner deletes it or their account. Guests cannot create teams.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "teams"
+                ],
+                "summary": "Create a team",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "name and description",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateTeamRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.Team"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Guests cannot create teams",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "409": {
+                        "description": "Team name already taken",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/teams/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "teams"
+                ],
+                "summary": "Get the profile of a team",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Team ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.Team"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Team not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Only the owner can delete a team. Nobody can join its tournaments anymore, those that started are played to the end.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "teams"
+                ],
+                "summary": "Delete a team",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Team ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "You don't own the team",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Team not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "teams"
+                ],
+                "summary": "Change the description of a team",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Team ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "new description",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.UpdateTeamRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.Team"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an admin of the team",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Team not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/teams/{id}/join": {
+            "post": {
+                "description": "Joining a team you are a member of does nothing.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "teams"
+                ],
+                "summary": "Join a team",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Team ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "joined",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Team not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "The owner cannot leave their team, they delete it instead.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "teams"
+                ],
+                "summary": "Leave a team",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Team ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "left",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "The owner cannot leave",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Team not found, or you are not a member",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/teams/{id}/matches": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "teams"
+                ],
+                "summary": "List the ongoing matches of a team",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Team ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "newest first",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.TeamMatch"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Team not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a match like POST /matches that only members of the team can join. Any member can create one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "teams"
+                ],
+                "summary": "Create a team match",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Team ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Duration of the match in hours. Max is 12",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateMatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Match Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.MatchCreatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Not a member of the team / guests cannot create rated matches",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Team not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many unfinished matches",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/teams/{id}/members": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "teams"
+                ],
+                "summary": "List the members of a team",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Team ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "the owner first, then admins, then members in the order they joined",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.TeamMember"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Team not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/teams/{id}/members/{username}": {
+            "put": {
+                "description": "Only the owner can make members admins or take it back.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "teams"
+                ],
+                "summary": "Change the role of a team member",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Team ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username of the member",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "admin or member",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.SetTeamRoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "You don't own the team / the owner's role cannot change",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Team or member not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Admins can remove members, only the owner can remove admins.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "teams"
+                ],
+                "summary": "Remove a member from a team",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Team ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username of the member",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "removed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an admin of the team / only the owner can remove admins",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Team or member not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/teams/{id}/tournaments": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "teams"
+                ],
+                "summary": "List the tournaments of a team",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Team ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "open",
+                            "playing",
+                            "finished"
+                        ],
+                        "type": "string",
+                        "description": "only list tournaments with this status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max tournaments to return, default 50, max 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "newest first",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.Tournament"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Team not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a tournament like POST /tournaments that only members of the team can join. Only admins of the team can create one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "teams"
+                ],
+                "summary": "Create a team tournament",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Team ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "name, rounds and hours each game can last",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateTournamentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.Tournament"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an admin of the team / guests cannot create rated tournaments",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Team not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/tournaments": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tournaments"
+                ],
+                "summary": "List tournaments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "open",
+                            "playing",
+                            "finished"
+                        ],
+                        "type": "string",
+                        "description": "only list tournaments with this status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max tournaments to return, default 50, max 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.Tournament"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Players join the tournament until its owner starts it. The server then pairs every round, creates its matches and notifies the players with a ` + "`" + `tournamentPairing` + "`" + ` notification.\nPlayers join their match like any other, with the colors they were paired with. A player who is alone in the match after 10 minutes wins the game, nobody wins it if neither player joined.\nGames still going when their time runs out are drawn. The next round starts once every game of the round ended.\nguests can only create casual (unrated) tournaments",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tournaments"
+                ],
+                "summary": "Create a Swiss tournament",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "name, rounds and hours each game can last",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateTournamentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.Tournament"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Guests cannot create rated tournaments",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/tournaments/{id}": {
+            "get": {
+                "description": "Standings are ranked by score, then by Buchholz, the sum of the scores of each player's opponents. They change as soon as a game ends.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tournaments"
+                ],
+                "summary": "Get a tournament with its standings",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tournament ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.TournamentDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Tournament not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/tournaments/{id}/join": {
+            "post": {
+                "description": "Tournaments can be joined until they start. Joining twice does nothing. Team tournaments can only be joined by members of the team.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tournaments"
+                ],
+                "summary": "Join a tournament",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tournament ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "joined",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Guests cannot join rated tournaments / not a member of the team",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Tournament not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "409": {
+                        "description": "The tournament already started",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Before the tournament starts you are removed from it. Once it started you are withdrawn, your score stays in the standings but you are not paired in the next rounds.\nLeaving does not end the game you are playing.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tournaments"
+                ],
+                "summary": "Leave a tournament",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tournament ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "left",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Tournament not found, or you are not in it",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "409": {
+                        "description": "The tournament is over",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/tournaments/{id}/start": {
+            "post": {
+                "description": "Only the owner can start a tournament, once at least 2 players joined. The first round is paired right away.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tournaments"
+                ],
+                "summary": "Start a tournament",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tournament ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.TournamentDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "You don't own the tournament",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Tournament not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "409": {
+                        "description": "The tournament already started / not enough players",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/tv": {
+            "get": {
+                "description": "## On success the server will send ` + "`" + `SSE` + "`" + ` messages whose payloads are JSON TV events.\nThe stream shows the ongoing match with the most recent move, starting with a ` + "`" + `featured` + "`" + ` event with its players and moves, then every move played.\nWhen its game ends a ` + "`" + `gameOver` + "`" + ` event is sent, and a few seconds later the next match is featured. A ` + "`" + `waiting` + "`" + ` event is sent while no match is being played.\nUnauthorized clients can use this. Everyone watching sees the same match.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "matches"
+                ],
+                "summary": "Watch the featured match",
+                "responses": {
+                    "200": {
+                        "description": "SSE stream — each ` + "`" + `data:` + "`" + ` payload is a TV event (Content-Type: text/event-stream).",
+                        "schema": {
+                            "$ref": "#/definitions/server.TVEvent"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "post": {
+                "description": "Username can be between 3-20 characters.\nPassword must be at least 3 characters.\nIf signup challenges are enabled, a solved challenge from ` + "`" + `GET /auth/challenge` + "`" + ` is required.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create an account using provided username and password.",
+                "parameters": [
+                    {
+                        "description": "Register Account",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.UserCredentials"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "challenge from GET /auth/challenge",
+                        "name": "X-Challenge",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "nonce that solves the challenge",
+                        "name": "X-Challenge-Nonce",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Api Key",
+                        "schema": {
+                            "$ref": "#/definitions/server.ApiKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing or unsolved challenge",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "409": {
+                        "description": "Username already exists",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many accounts created from this IP",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "The account is deactivated immediately and permanently deleted after 30 days.\nLogging in during those 30 days cancels the deletion.\nWhen the account is deleted, your archived games are kept but anonymized.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete an account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/blocks": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blocks"
+                ],
+                "summary": "List blocked users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "usernames",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/blocks/{username}": {
+            "post": {
+                "description": "Blocked users cannot join your matches or send you friend requests, and you cannot join theirs.\nBlocking a friend removes them from your friends.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blocks"
+                ],
+                "summary": "Block a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username of the user to block",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "blocked",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Cannot block yourself",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blocks"
+                ],
+                "summary": "Unblock a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username of the user to unblock",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "unblocked",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found / not blocked",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/bot": {
+            "post": {
+                "description": "Bot accounts are played by engines. They can be challenged, and play through the bot streams.\nThis cannot be undone. Guests cannot become bots.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bots"
+                ],
+                "summary": "Turn your account into a bot account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Guests cannot become bots",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/email": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get your email address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.EmailResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "While you are disconnected from a match, on every server, the server emails you when it becomes your move in it and when your opponent resigns.\nEach kind of email can be turned off with the ` + "`" + `emailYourMove` + "`" + ` and ` + "`" + `emailOpponentResigned` + "`" + ` preferences. Emails are only sent if the server is set up to send them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Set your email address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "your address",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.EmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.EmailResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid email address",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/export": {
+            "get": {
+                "description": "Download a zip archive containing your account information and all your archived games.\n` + "`" + `account.json` + "`" + ` contains the account and game metadata, ` + "`" + `games.pgn` + "`" + ` contains every game in PGN format.",
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Export your account data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "zip archive",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/friends": {
+            "get": {
+                "description": "Lists your friends and whether they are online or playing a match.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "friends"
+                ],
+                "summary": "List your friends",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.Friend"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/friends/requests": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "friends"
+                ],
+                "summary": "List incoming friend requests",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.FriendRequest"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/friends/{username}": {
+            "put": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "friends"
+                ],
+                "summary": "Accept a friend request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username of the user who sent the request",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "accepted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found / no pending friend request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Sends a friend request to a user.\nIf they already sent you a friend request, it is accepted instead.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "friends"
+                ],
+                "summary": "Send a friend request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username of the user to befriend",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "accepted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "201": {
+                        "description": "requested",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Cannot befriend yourself",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "403": {
+                        "description": "Blocked",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "409": {
+                        "description": "Already friends / already requested",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes a friend, or declines / cancels a pending friend request.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "friends"
+                ],
+                "summary": "Remove a friend",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username of the friend",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "removed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found / not friends",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/preferences": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get your preferences",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.Preferences"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Only the fields present in the body are changed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Change your preferences",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "preferences to change",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.PreferencesPatch"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "updated preferences",
+                        "schema": {
+                            "$ref": "#/definitions/server.Preferences"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body / unknown board theme or piece set",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/teams": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "teams"
+                ],
+                "summary": "List the teams you are a member of",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.TeamMembership"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/webhooks": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List your webhooks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.Webhook"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Match events of your matches are POSTed to the URL as JSON: ` + "`" + `gameStart` + "`" + `, ` + "`" + `move` + "`" + ` and ` + "`" + `gameOver` + "`" + `.\nThe ` + "`" + `X-Webhook-Signature` + "`" + ` header is ` + "`" + `sha256=` + "`" + ` followed by the hex HMAC-SHA256 of the body, keyed with the secret in the response. The secret is only shown once.\n` + "`" + `X-Webhook-Event` + "`" + ` is the event and ` + "`" + `X-Webhook-Delivery` + "`" + ` identifies it, it stays the same when a failed delivery is retried.\nResponses other than 2xx are retried up to 5 times with backoff. Deliveries can arrive out of order, ` + "`" + `ply` + "`" + ` orders moves.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Register a webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "URL, and optionally the one match to send events of",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.CreatedWebhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid json body",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many webhooks",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/webhooks/{id}": {
+            "delete": {
+                "description": "Its delivery log is deleted with it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete a webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid webhook id",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/webhooks/{id}/deliveries": {
+            "get": {
+                "description": "Every attempt to deliver an event, newest first. Deliveries are kept for 7 days.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List deliveries of a webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "default 50, max 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "default 0",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.WebhookDelivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid webhook id / query",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/upgrade": {
+            "post": {
+                "description": "Guests can pick a username and password to keep their account and game history.\nUsername can be between 3-20 characters.\nPassword must be at least 3 characters.\nThe old guest API key stops working, use the returned key instead.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Upgrade a guest account into a full account.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey of a guest in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "New account credentials",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.UserCredentials"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Api Key",
+                        "schema": {
+                            "$ref": "#/definitions/server.ApiKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid credentials / not a guest",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "409": {
+                        "description": "Username already exists",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{username}/games/export": {
+            "get": {
+                "description": "Streams every archived game of the user, oldest first, as PGN games one after another or as newline delimited JSON with one game per line.\nGames are sent while they are read, so large archives start right away. The format is picked with ` + "`" + `format` + "`" + `, or with an ` + "`" + `Accept` + "`" + ` header of ` + "`" + `application/x-ndjson` + "`" + `.\nEvery game has an id. If the stream breaks off, continue it with ` + "`" + `after` + "`" + ` set to the id of the last game you got.",
+                "produces": [
+                    "application/x-chess-pgn",
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Export the games of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must contain ApiKey in the format Bearer: apiKey",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "whose games to export",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pgn",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "pgn by default",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only games finished at or after this time (RFC 3339)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only games finished before this time (RFC 3339)",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "white",
+                            "black"
+                        ],
+                        "type": "string",
+                        "description": "only games the user played with this color",
+                        "name": "color",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "win",
+                            "loss",
+                            "draw"
+                        ],
+                        "type": "string",
+                        "description": "only games the user won, lost or drew",
+                        "name": "result",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "only games with a higher id",
+                        "name": "after",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "PGN, or NDJSON with one game per line",
+                        "schema": {
+                            "$ref": "#/definitions/server.ExportedGame"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorReason"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "game.Event": {
+            "type": "object",
+            "properties": {
+                "capture": {
+                    "description": "the move takes a piece, en passant too",
+                    "type": "boolean",
+                    "example": false
+                },
+                "castle": {
+                    "description": "the move castles either side",
+                    "type": "boolean",
+                    "example": false
+                },
+                "check": {
+                    "description": "the move gives check",
+                    "type": "boolean",
+                    "example": false
+                },
+                "checkmate": {
+                    "description": "the move ends the game by checkmate",
+                    "type": "boolean",
+                    "example": false
+                },
+                "eco": {
+                    "description": "ECO code of the opening",
+                    "type": "string",
+                    "example": "C20"
+                },
+                "endTime": {
+                    "description": "when this match will be deleted if the game does not end.",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "fen": {
+                    "description": "position after the move, or the current position on resync",
+                    "type": "string",
+                    "example": "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"
+                },
+                "from": {
+                    "description": "who sent the chat message",
+                    "type": "string",
+                    "example": "JohnDoe"
+                },
+                "message": {
+                    "description": "chat message",
+                    "type": "string",
+                    "example": "good luck!"
+                },
+                "move": {
+                    "description": "Move in UCI notation",
+                    "type": "string",
+                    "example": "e2e4"
+                },
+                "opening": {
+                    "description": "name of the opening",
+                    "type": "string",
+                    "example": "King's Pawn Game"
+                },
+                "oponentUsername": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                },
+                "opponentBlack": {
+                    "description": "is the opponent using the black pieces",
+                    "type": "boolean",
+                    "example": false
+                },
+                "promotion": {
+                    "description": "the move promotes a pawn, san has the new piece",
+                    "type": "boolean",
+                    "example": false
+                },
+                "result": {
+                    "description": "result decided by an admin",
+                    "type": "string",
+                    "example": "1-0"
+                },
+                "san": {
+                    "description": "Move in standard algebraic notation",
+                    "type": "string",
+                    "example": "e4"
+                },
+                "spectators": {
+                    "description": "how many people watch the match",
+                    "type": "integer",
+                    "example": 3
+                },
+                "startTime": {
+                    "description": "when this match was creatd",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "type": {
+                    "$ref": "#/definitions/game.EventType"
+                }
+            }
+        },
+        "game.EventType": {
+            "type": "string",
+            "enum": [
+                "move",
+                "opponent",
+                "resign",
+                "chat",
+                "aborted",
+                "adjudicated",
+                "serverRestarting",
+                "resync",
+                "opening",
+                "opponentDisconnected",
+                "opponentConnected",
+                "spectatorJoined",
+                "spectatorLeft"
+            ],
+            "x-enum-varnames": [
+                "Move",
+                "OpponentInfo",
+                "Resign",
+                "Chat",
+                "Aborted",
+                "Adjudicated",
+                "ServerRestarting",
+                "Resync",
+                "OpeningDetected",
+                "OpponentDisconnected",
+                "OpponentConnected",
+                "SpectatorJoined",
+                "SpectatorLeft"
+            ]
+        },
+        "game.LoggedEvent": {
+            "type": "object",
+            "properties": {
+                "capture": {
+                    "description": "the move takes a piece, en passant too",
+                    "type": "boolean",
+                    "example": false
+                },
+                "castle": {
+                    "description": "the move castles either side",
+                    "type": "boolean",
+                    "example": false
+                },
+                "check": {
+                    "description": "the move gives check",
+                    "type": "boolean",
+                    "example": false
+                },
+                "checkmate": {
+                    "description": "the move ends the game by checkmate",
+                    "type": "boolean",
+                    "example": false
+                },
+                "eco": {
+                    "description": "ECO code of the opening",
+                    "type": "string",
+                    "example": "C20"
+                },
+                "endTime": {
+                    "description": "when this match will be deleted if the game does not end.",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "fen": {
+                    "description": "position after the move, or the current position on resync",
+                    "type": "string",
+                    "example": "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"
+                },
+                "from": {
+                    "description": "who sent the chat message",
+                    "type": "string",
+                    "example": "JohnDoe"
+                },
+                "message": {
+                    "description": "chat message",
+                    "type": "string",
+                    "example": "good luck!"
+                },
+                "move": {
+                    "description": "Move in UCI notation",
+                    "type": "string",
+                    "example": "e2e4"
+                },
+                "opening": {
+                    "description": "name of the opening",
+                    "type": "string",
+                    "example": "King's Pawn Game"
+                },
+                "oponentUsername": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                },
+                "opponentBlack": {
+                    "description": "is the opponent using the black pieces",
+                    "type": "boolean",
+                    "example": false
+                },
+                "promotion": {
+                    "description": "the move promotes a pawn, san has the new piece",
+                    "type": "boolean",
+                    "example": false
+                },
+                "result": {
+                    "description": "result decided by an admin",
+                    "type": "string",
+                    "example": "1-0"
+                },
+                "san": {
+                    "description": "Move in standard algebraic notation",
+                    "type": "string",
+                    "example": "e4"
+                },
+                "seq": {
+                    "description": "1 is the first event of the match. Numbers start over when the server restarts.",
+                    "type": "integer",
+                    "example": 3
+                },
+                "spectators": {
+                    "description": "how many people watch the match",
+                    "type": "integer",
+                    "example": 3
+                },
+                "startTime": {
+                    "description": "when this match was creatd",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "type": {
+                    "$ref": "#/definitions/game.EventType"
+                }
+            }
+        },
+        "game.Material": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "description": "value of white's pieces on the board minus black's, counting pawns 1, knights and bishops 3, rooks 5 and queens 9.\nPromoted pawns count as the piece they became.",
+                    "type": "integer",
+                    "example": 2
+                },
+                "black": {
+                    "description": "white pieces black captured",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "p"
+                    ]
+                },
+                "white": {
+                    "description": "black pieces white captured, in the order they were taken: p, n, b, r or q",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "p",
+                        "n"
+                    ]
+                }
+            }
+        },
+        "game.Opening": {
+            "type": "object",
+            "properties": {
+                "eco": {
+                    "type": "string",
+                    "example": "C20"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "King's Pawn Game"
+                }
+            }
+        },
+        "server.AdjudicateRequest": {
+            "type": "object",
+            "required": [
+                "result"
+            ],
+            "properties": {
+                "result": {
+                    "type": "string",
+                    "enum": [
+                        "white",
+                        "black",
+                        "draw"
+                    ],
+                    "example": "white"
+                }
+            }
+        },
+        "server.AdminUser": {
+            "type": "object",
+            "properties": {
+                "banned": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "createdAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "deletedAt": {
+                    "description": "when the user deleted their account, if they did",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "isAdmin": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "isBot": {
+                    "description": "the account is played by an engine",
+                    "type": "boolean",
+                    "example": false
+                },
+                "isGuest": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "userId": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "username": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                }
+            }
+        },
+        "server.ApiKeyResponse": {
+            "type": "object",
+            "properties": {
+                "apiKey": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.AuditEvent": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "login.failed"
+                },
+                "actor": {
+                    "description": "empty if the actor is unknown, like the server itself",
+                    "type": "string",
+                    "example": "JohnDoe"
+                },
+                "createdAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "details": {
+                    "type": "string",
+                    "example": "wrong password"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "target": {
+                    "description": "username or match id the action was performed on",
+                    "type": "string",
+                    "example": "JohnDoe"
+                }
+            }
+        },
+        "server.Backup": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "name": {
+                    "description": "file name in the backup directory",
+                    "type": "string",
+                    "example": "chess-20261015T120000.000Z.db"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 131072
+                }
+            }
+        },
+        "server.BoardState": {
+            "type": "object",
+            "properties": {
+                "board": {
+                    "description": "board part of the FEN",
+                    "type": "string",
+                    "example": "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR"
+                },
+                "fen": {
+                    "type": "string",
+                    "example": "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"
+                },
+                "material": {
+                    "description": "captured pieces and the material balance",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/game.Material"
+                        }
+                    ]
+                },
+                "opening": {
+                    "description": "empty until a move in the ECO book is played",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/game.Opening"
+                        }
+                    ]
+                },
+                "openingFinal": {
+                    "description": "the game left the book, the opening won't change anymore",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "server.BotEvent": {
+            "type": "object",
+            "properties": {
+                "challenge": {
+                    "$ref": "#/definitions/server.Challenge"
+                },
+                "color": {
+                    "description": "color the bot plays in the match",
+                    "type": "string",
+                    "example": "black"
+                },
+                "matchId": {
+                    "type": "string",
+                    "example": "AB2C21"
+                },
+                "type": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/server.BotEventType"
+                        }
+                    ],
+                    "example": "challenge"
+                }
+            }
+        },
+        "server.BotEventType": {
+            "type": "string",
+            "enum": [
+                "challenge",
+                "challengeCanceled",
+                "gameStart"
+            ],
+            "x-enum-varnames": [
+                "BotChallenge",
+                "BotChallengeCanceled",
+                "BotGameStart"
+            ]
+        },
+        "server.BotGameEvent": {
+            "type": "object",
+            "properties": {
+                "black": {
+                    "type": "string",
+                    "example": "StockBot"
+                },
+                "eco": {
+                    "type": "string",
+                    "example": "C20"
+                },
+                "endTime": {
+                    "description": "when this match will be deleted if the game does not end.",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "fen": {
+                    "type": "string",
+                    "example": "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "AB2C21"
+                },
+                "moves": {
+                    "description": "moves so far in UCI notation, separated by spaces. Left out before the first move.",
+                    "type": "string",
+                    "example": "e2e4 e7e5"
+                },
+                "opening": {
+                    "type": "string",
+                    "example": "King's Pawn Game"
+                },
+                "rated": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "startTime": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "status": {
+                    "description": "started, over or aborted",
+                    "type": "string",
+                    "example": "started"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "good luck!"
+                },
+                "type": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/server.BotGameEventType"
+                        }
+                    ],
+                    "example": "gameState"
+                },
+                "username": {
+                    "description": "who sent the chat message",
+                    "type": "string",
+                    "example": "JohnDoe"
+                },
+                "white": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                },
+                "winner": {
+                    "description": "white or black, empty for draws and running games",
+                    "type": "string",
+                    "example": "white"
+                }
+            }
+        },
+        "server.BotGameEventType": {
+            "type": "string",
+            "enum": [
+                "gameFull",
+                "gameState",
+                "chatLine",
+                "opening"
+            ],
+            "x-enum-varnames": [
+                "BotGameFull",
+                "BotGameState",
+                "BotChatLine",
+                "BotGameOpening"
+            ]
+        },
+        "server.Challenge": {
+            "type": "object",
+            "properties": {
+                "challengerBlack": {
+                    "description": "the challenger plays the black pieces",
+                    "type": "boolean",
+                    "example": false
+                },
+                "duration": {
+                    "description": "duration of the match in hours",
+                    "type": "integer",
+                    "example": 1
+                },
+                "expiresAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "from": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "Q7ZD4F2K"
+                },
+                "rated": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "to": {
+                    "description": "the bot being challenged",
+                    "type": "string",
+                    "example": "StockBot"
+                }
+            }
+        },
+        "server.ChallengeAccepted": {
+            "type": "object",
+            "properties": {
+                "color": {
+                    "description": "color the bot plays",
+                    "type": "string",
+                    "example": "black"
+                },
+                "matchId": {
+                    "type": "string",
+                    "example": "AB2C21"
+                }
+            }
+        },
+        "server.ChatMessage": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "good luck!"
+                },
+                "sentAt": {
+                    "type": "string",
+                    "format": "date-time"
+                }
+            }
+        },
+        "server.ChatRequest": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "good luck!"
+                }
+            }
+        },
+        "server.CreateChallengeRequest": {
+            "type": "object",
+            "required": [
+                "duration",
+                "username"
+            ],
+            "properties": {
+                "blackPieces": {
+                    "description": "whether you play the black pieces",
+                    "type": "boolean",
+                    "example": false
+                },
+                "duration": {
+                    "description": "duration in hours",
+                    "type": "integer",
+                    "maximum": 12,
+                    "minimum": 1,
+                    "example": 1
+                },
+                "rated": {
+                    "description": "rated matches cannot be played by guests",
+                    "type": "boolean",
+                    "example": false
+                },
+                "username": {
+                    "description": "username of the bot to play",
+                    "type": "string",
+                    "example": "StockBot"
+                }
+            }
+        },
+        "server.CreateComputerMatchRequest": {
+            "type": "object",
+            "required": [
+                "duration",
+                "level"
+            ],
+            "properties": {
+                "blackPieces": {
+                    "description": "whether you play the black pieces",
+                    "type": "boolean",
+                    "example": false
+                },
+                "duration": {
+                    "description": "duration in hours",
+                    "type": "integer",
+                    "maximum": 12,
+                    "minimum": 1,
+                    "example": 1
+                },
+                "level": {
+                    "description": "how strong the computer plays, from 1 to 8",
+                    "type": "integer",
+                    "maximum": 8,
+                    "minimum": 1,
+                    "example": 3
+                }
+            }
+        },
+        "server.CreateMatchRequest": {
+            "type": "object",
+            "required": [
+                "duration"
+            ],
+            "properties": {
+                "duration": {
+                    "description": "duration in hours",
+                    "type": "integer",
+                    "maximum": 12,
+                    "minimum": 1,
+                    "example": 12
+                },
+                "rated": {
+                    "description": "rated matches cannot be joined by guests",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "server.CreateSeekRequest": {
+            "type": "object",
+            "required": [
+                "duration"
+            ],
+            "properties": {
+                "blackPieces": {
+                    "description": "whether you play the black pieces",
+                    "type": "boolean",
+                    "example": false
+                },
+                "duration": {
+                    "description": "duration in hours",
+                    "type": "integer",
+                    "maximum": 12,
+                    "minimum": 1,
+                    "example": 1
+                },
+                "rated": {
+                    "description": "rated seeks cannot be accepted by guests",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "server.CreateSimulRequest": {
+            "type": "object",
+            "required": [
+                "boards",
+                "duration"
+            ],
+            "properties": {
+                "boards": {
+                    "description": "challengers the simul can have",
+                    "type": "integer",
+                    "maximum": 30,
+                    "minimum": 1,
+                    "example": 10
+                },
+                "duration": {
+                    "description": "hours each game can last",
+                    "type": "integer",
+                    "maximum": 12,
+                    "minimum": 1,
+                    "example": 2
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Friday simul"
+                },
+                "rated": {
+                    "description": "guests cannot join rated simuls",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "server.CreateStudyRequest": {
+            "type": "object",
+            "properties": {
+                "fen": {
+                    "description": "position the study starts from, the initial position if empty",
+                    "type": "string",
+                    "example": "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 1"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Sicilian lesson"
+                }
+            }
+        },
+        "server.CreateTeamRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "Casual players from Lisbon"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 3,
+                    "example": "Knights of Lisbon"
+                }
+            }
+        },
+        "server.CreateTournamentRequest": {
+            "type": "object",
+            "required": [
+                "duration",
+                "rounds"
+            ],
+            "properties": {
+                "duration": {
+                    "description": "hours each game can last",
+                    "type": "integer",
+                    "maximum": 12,
+                    "minimum": 1,
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Friday blitz"
+                },
+                "rated": {
+                    "description": "guests cannot join rated tournaments",
+                    "type": "boolean",
+                    "example": false
+                },
+                "rounds": {
+                    "type": "integer",
+                    "maximum": 20,
+                    "minimum": 1,
+                    "example": 5
+                }
+            }
+        },
+        "server.CreateWebhookRequest": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "matchId": {
+                    "description": "only send events of this match, empty for every match",
+                    "type": "string",
+                    "example": "AB2C21"
+                },
+                "url": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "https://example.com/chess"
+                }
+            }
+        },
+        "server.CreatedWebhook": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "matchId": {
+                    "description": "only events of this match are sent, empty for every match",
+                    "type": "string",
+                    "example": "AB2C21"
+                },
+                "secret": {
+                    "description": "key of the HMAC-SHA256 in the X-Webhook-Signature header of deliveries",
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/chess"
+                }
+            }
+        },
+        "server.EmailRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "description": "empty removes your address",
+                    "type": "string",
+                    "maxLength": 254,
+                    "example": "john@example.com"
+                }
+            }
+        },
+        "server.EmailResponse": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "description": "empty if you did not give one",
+                    "type": "string",
+                    "example": "john@example.com"
+                }
+            }
+        },
+        "server.ErrorCode": {
+            "type": "string",
+            "enum": [
+                "INTERNAL_ERROR",
+                "INVALID_JSON",
+                "INVALID_INPUT",
+                "NOT_FOUND",
+                "METHOD_NOT_ALLOWED",
+                "SHUTTING_DOWN",
+                "NOT_READY",
+                "RATE_LIMITED",
+                "UNAUTHORIZED",
+                "INVALID_AUTH_HEADER",
+                "API_KEY_EXPIRED",
+                "INVALID_CREDENTIALS",
+                "ACCOUNT_BANNED",
+                "ACCOUNT_DELETED",
+                "ACCOUNT_LOCKED",
+                "LOGIN_THROTTLED",
+                "NOT_ADMIN",
+                "USER_NOT_FOUND",
+                "USERNAME_TAKEN",
+                "USERNAME_NOT_ALLOWED",
+                "NOT_A_GUEST",
+                "CHALLENGE_REQUIRED",
+                "CHALLENGE_FAILED",
+                "TOO_MANY_SIGNUPS",
+                "CANNOT_TARGET_SELF",
+                "BLOCKED",
+                "NOT_BLOCKED",
+                "ALREADY_FRIENDS",
+                "NOT_FRIENDS",
+                "FRIEND_REQUEST_EXISTS",
+                "NO_FRIEND_REQUEST",
+                "REPORT_NOT_FOUND",
+                "WEBHOOK_NOT_FOUND",
+                "TOO_MANY_WEBHOOKS",
+                "NOT_A_BOT",
+                "GUESTS_CANNOT_BE_BOTS",
+                "CHALLENGE_NOT_FOUND",
+                "MATCH_NOT_FOUND",
+                "MATCH_FULL",
+                "GUESTS_CANNOT_PLAY_RATED",
+                "PLAYER_NOT_IN_MATCH",
+                "NOT_YOUR_TURN",
+                "INVALID_MOVE_NOTATION",
+                "ILLEGAL_MOVE",
+                "GAME_OVER",
+                "GAME_NOT_OVER",
+                "TOO_MANY_MATCHES",
+                "TOO_MANY_STREAMS",
+                "NO_COMPUTER",
+                "INVALID_PGN",
+                "STUDY_NOT_FOUND",
+                "NOT_STUDY_OWNER",
+                "STUDY_FULL",
+                "POSITION_NOT_FOUND",
+                "PUZZLE_NOT_FOUND",
+                "TOURNAMENT_NOT_FOUND",
+                "NOT_TOURNAMENT_OWNER",
+                "TOURNAMENT_STARTED",
+                "TOURNAMENT_FINISHED",
+                "NOT_ENOUGH_PLAYERS",
+                "NOT_IN_TOURNAMENT",
+                "TOURNAMENT_GAME",
+                "TEAM_NOT_FOUND",
+                "TEAM_NAME_TAKEN",
+                "NOT_TEAM_MEMBER",
+                "NOT_TEAM_ADMIN",
+                "NOT_TEAM_OWNER",
+                "GUESTS_CANNOT_CREATE_TEAMS",
+                "SIMUL_NOT_FOUND",
+                "NOT_SIMUL_HOST",
+                "SIMUL_STARTED",
+                "SIMUL_FULL",
+                "NOT_IN_SIMUL",
+                "NOT_CURRENT_BOARD",
+                "SIMUL_GAME",
+                "SIMUL_STREAMED",
+                "SEEK_NOT_FOUND",
+                "SEEK_GAME"
+            ],
+            "x-enum-varnames": [
+                "CODE_INTERNAL_ERROR",
+                "CODE_INVALID_JSON",
+                "CODE_INVALID_INPUT",
+                "CODE_NOT_FOUND",
+                "CODE_METHOD_NOT_ALLOWED",
+                "CODE_SHUTTING_DOWN",
+                "CODE_NOT_READY",
+                "CODE_RATE_LIMITED",
+                "CODE_UNAUTHORIZED",
+                "CODE_INVALID_AUTH_HEADER",
+                "CODE_API_KEY_EXPIRED",
+                "CODE_INVALID_CREDENTIALS",
+                "CODE_ACCOUNT_BANNED",
+                "CODE_ACCOUNT_DELETED",
+                "CODE_ACCOUNT_LOCKED",
+                "CODE_LOGIN_THROTTLED",
+                "CODE_NOT_ADMIN",
+                "CODE_USER_NOT_FOUND",
+                "CODE_USERNAME_TAKEN",
+                "CODE_USERNAME_NOT_ALLOWED",
+                "CODE_NOT_A_GUEST",
+                "CODE_CHALLENGE_REQUIRED",
+                "CODE_CHALLENGE_FAILED",
+                "CODE_TOO_MANY_SIGNUPS",
+                "CODE_CANNOT_TARGET_SELF",
+                "CODE_BLOCKED",
+                "CODE_NOT_BLOCKED",
+                "CODE_ALREADY_FRIENDS",
+                "CODE_NOT_FRIENDS",
+                "CODE_FRIEND_REQUEST_EXISTS",
+                "CODE_NO_FRIEND_REQUEST",
+                "CODE_REPORT_NOT_FOUND",
+                "CODE_WEBHOOK_NOT_FOUND",
+                "CODE_TOO_MANY_WEBHOOKS",
+                "CODE_NOT_A_BOT",
+                "CODE_GUESTS_CANNOT_BE_BOTS",
+                "CODE_CHALLENGE_NOT_FOUND",
+                "CODE_MATCH_NOT_FOUND",
+                "CODE_MATCH_FULL",
+                "CODE_GUESTS_CANNOT_RATED",
+                "CODE_PLAYER_NOT_IN_MATCH",
+                "CODE_NOT_YOUR_TURN",
+                "CODE_INVALID_MOVE_NOTATION",
+                "CODE_ILLEGAL_MOVE",
+                "CODE_GAME_OVER",
+                "CODE_GAME_NOT_OVER",
+                "CODE_TOO_MANY_MATCHES",
+                "CODE_TOO_MANY_STREAMS",
+                "CODE_NO_COMPUTER",
+                "CODE_INVALID_PGN",
+                "CODE_STUDY_NOT_FOUND",
+                "CODE_NOT_STUDY_OWNER",
+                "CODE_STUDY_FULL",
+                "CODE_POSITION_NOT_FOUND",
+                "CODE_PUZZLE_NOT_FOUND",
+                "CODE_TOURNAMENT_NOT_FOUND",
+                "CODE_NOT_TOURNAMENT_OWNER",
+                "CODE_TOURNAMENT_STARTED",
+                "CODE_TOURNAMENT_FINISHED",
+                "CODE_NOT_ENOUGH_PLAYERS",
+                "CODE_NOT_IN_TOURNAMENT",
+                "CODE_TOURNAMENT_GAME",
+                "CODE_TEAM_NOT_FOUND",
+                "CODE_TEAM_NAME_TAKEN",
+                "CODE_NOT_TEAM_MEMBER",
+                "CODE_NOT_TEAM_ADMIN",
+                "CODE_NOT_TEAM_OWNER",
+                "CODE_GUESTS_CANNOT_CREATE_TEAMS",
+                "CODE_SIMUL_NOT_FOUND",
+                "CODE_NOT_SIMUL_HOST",
+                "CODE_SIMUL_STARTED",
+                "CODE_SIMUL_FULL",
+                "CODE_NOT_IN_SIMUL",
+                "CODE_NOT_CURRENT_BOARD",
+                "CODE_SIMUL_GAME",
+                "CODE_SIMUL_STREAMED",
+                "CODE_SEEK_NOT_FOUND",
+                "CODE_SEEK_GAME"
+            ]
+        },
+        "server.ErrorReason": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "stable identifier of the error, clients should check this instead of the reason",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/server.ErrorCode"
+                        }
+                    ],
+                    "example": "MATCH_NOT_FOUND"
+                },
+                "fields": {
+                    "description": "the invalid fields, when the request body did not pass validation",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.FieldError"
+                    }
+                },
+                "reason": {
+                    "description": "human readable explanation, it can change at any time",
+                    "type": "string",
+                    "example": "reason"
+                }
+            }
+        },
+        "server.Explorer": {
+            "type": "object",
+            "properties": {
+                "fen": {
+                    "description": "the explored position, after the moves of play",
+                    "type": "string",
+                    "example": "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1"
+                },
+                "moves": {
+                    "description": "most played first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.ExplorerMove"
+                    }
+                },
+                "results": {
+                    "description": "of the games that continued from the position",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/server.ExplorerResults"
+                        }
+                    ]
+                }
+            }
+        },
+        "server.ExplorerMove": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "$ref": "#/definitions/server.ExplorerResults"
+                },
+                "san": {
+                    "type": "string",
+                    "example": "e4"
+                },
+                "uci": {
+                    "type": "string",
+                    "example": "e2e4"
+                }
+            }
+        },
+        "server.ExplorerResults": {
+            "type": "object",
+            "properties": {
+                "black": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "blackPercent": {
+                    "type": "number",
+                    "example": 30
+                },
+                "drawPercent": {
+                    "type": "number",
+                    "example": 20
+                },
+                "draws": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "games": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "white": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "whitePercent": {
+                    "description": "of the games, rounded to one decimal",
+                    "type": "number",
+                    "example": 50
+                }
+            }
+        },
+        "server.ExportedGame": {
+            "type": "object",
+            "properties": {
+                "black": {
+                    "type": "string",
+                    "example": "JaneDoe"
+                },
+                "eco": {
+                    "description": "opening that was played, empty if the first move is not in the ECO book",
+                    "type": "string",
+                    "example": "C20"
+                },
+                "finishedAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "moves": {
+                    "description": "movetext in PGN, only in game exports. account.json leaves it out, the moves are in games.pgn.",
+                    "type": "string",
+                    "example": "1. e4 e5 2. Nf3 *"
+                },
+                "opening": {
+                    "type": "string",
+                    "example": "King's Pawn Game"
+                },
+                "result": {
+                    "type": "string",
+                    "example": "white"
+                },
+                "white": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                }
+            }
+        },
+        "server.FairPlayGame": {
+            "type": "object",
+            "properties": {
+                "averageMoveTime": {
+                    "description": "of the timed moves, in milliseconds",
+                    "type": "integer",
+                    "example": 4200
+                },
+                "engineMatches": {
+                    "type": "integer",
+                    "example": 21
+                },
+                "finishedAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "gameId": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "matchId": {
+                    "type": "string",
+                    "example": "AB2C21"
+                },
+                "moveTimeDeviation": {
+                    "type": "integer",
+                    "example": 600
+                },
+                "moves": {
+                    "description": "positions the engine analyzed, and how many times the player found its best move",
+                    "type": "integer",
+                    "example": 24
+                },
+                "suspicion": {
+                    "description": "0 to 100, how much the game looks like engine play",
+                    "type": "integer",
+                    "example": 81
+                }
+            }
+        },
+        "server.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "description": "json name of the field",
+                    "type": "string",
+                    "example": "duration"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "must be at most 12"
+                }
+            }
+        },
+        "server.FlaggedPlayer": {
+            "type": "object",
+            "properties": {
+                "engineMatches": {
+                    "type": "integer",
+                    "example": 104
+                },
+                "games": {
+                    "description": "rated games analyzed in the last 30 days",
+                    "type": "integer",
+                    "example": 5
+                },
+                "moves": {
+                    "description": "of the analyzed moves, how many were the engine's best move",
+                    "type": "integer",
+                    "example": 120
+                },
+                "openReports": {
+                    "description": "unresolved cheating reports about the player",
+                    "type": "integer",
+                    "example": 2
+                },
+                "suspicion": {
+                    "description": "average of the games, 0 to 100",
+                    "type": "integer",
+                    "example": 72
+                },
+                "username": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                }
+            }
+        },
+        "server.Friend": {
+            "type": "object",
+            "properties": {
+                "presence": {
+                    "$ref": "#/definitions/server.Presence"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                }
+            }
+        },
+        "server.FriendRequest": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                },
+                "sentAt": {
+                    "type": "string",
+                    "format": "date-time"
+                }
+            }
+        },
+        "server.GuestResponse": {
+            "type": "object",
+            "properties": {
+                "apiKey": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "description": "when the guest account will be deleted, unless it is upgraded",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "Guest_4F2KQ7ZD"
+                }
+            }
+        },
+        "server.ImportPGNRequest": {
+            "type": "object",
+            "required": [
+                "pgn"
+            ],
+            "properties": {
+                "pgn": {
+                    "description": "one game, with or without tags",
+                    "type": "string",
+                    "maxLength": 65536,
+                    "example": "[White \"Anderssen\"]\n[Black \"Kieseritzky\"]\n\n1. e4 e5 2. f4 exf4 *"
+                }
+            }
+        },
+        "server.ImportedGameResponse": {
+            "type": "object",
+            "properties": {
+                "black": {
+                    "type": "string",
+                    "example": "Kieseritzky"
+                },
+                "id": {
+                    "description": "use it as the match id with the board, image and GIF endpoints of matches",
+                    "type": "string",
+                    "example": "AB2C21DE3F4G"
+                },
+                "importedAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "opening": {
+                    "$ref": "#/definitions/game.Opening"
+                },
+                "plies": {
+                    "description": "number of half moves",
+                    "type": "integer",
+                    "example": 4
+                },
+                "result": {
+                    "description": "empty if the PGN has no result",
+                    "type": "string",
+                    "enum": [
+                        "white",
+                        "black",
+                        "draw",
+                        ""
+                    ],
+                    "example": "white"
+                },
+                "white": {
+                    "type": "string",
+                    "example": "Anderssen"
+                }
+            }
+        },
+        "server.JoinMatchRequest": {
+            "type": "object",
+            "properties": {
+                "blackPieces": {
+                    "description": "whether to use black pieces instead of white",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "server.LichessChatMessage": {
+            "type": "object",
+            "properties": {
+                "text": {
+                    "type": "string",
+                    "example": "good luck!"
+                },
+                "user": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                }
+            }
+        },
+        "server.LichessError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "Not your turn, or game already over"
+                }
+            }
+        },
+        "server.LichessGameFull": {
+            "type": "object",
+            "properties": {
+                "black": {
+                    "$ref": "#/definitions/server.LichessPlayer"
+                },
+                "createdAt": {
+                    "description": "in milliseconds since 1970",
+                    "type": "integer",
+                    "example": 1523825103562
+                },
+                "id": {
+                    "type": "string",
+                    "example": "AB2C21"
+                },
+                "initialFen": {
+                    "type": "string",
+                    "example": "startpos"
+                },
+                "perf": {
+                    "$ref": "#/definitions/server.LichessPerf"
+                },
+                "rated": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "speed": {
+                    "type": "string",
+                    "example": "correspondence"
+                },
+                "state": {
+                    "$ref": "#/definitions/server.LichessGameState"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "gameFull"
+                },
+                "variant": {
+                    "$ref": "#/definitions/server.LichessVariant"
+                },
+                "white": {
+                    "description": "left out until the player joined",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/server.LichessPlayer"
+                        }
+                    ]
+                }
+            }
+        },
+        "server.LichessGameState": {
+            "type": "object",
+            "properties": {
+                "binc": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "btime": {
+                    "type": "integer",
+                    "example": 43200000
+                },
+                "moves": {
+                    "description": "moves so far in UCI notation, separated by spaces",
+                    "type": "string",
+                    "example": "e2e4 e7e5"
+                },
+                "status": {
+                    "description": "started, mate, resign, stalemate, draw or aborted",
+                    "type": "string",
+                    "example": "started"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "gameState"
+                },
+                "winc": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "winner": {
+                    "description": "white or black, left out for draws and running games",
+                    "type": "string",
+                    "example": "white"
+                },
+                "wtime": {
+                    "description": "milliseconds until the match is deleted if the game does not end, the same for both players",
+                    "type": "integer",
+                    "example": 43200000
+                }
+            }
+        },
+        "server.LichessOk": {
+            "type": "object",
+            "properties": {
+                "ok": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "server.LichessPerf": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Correspondence"
+                }
+            }
+        },
+        "server.LichessPlayer": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "the username in lowercase",
+                    "type": "string",
+                    "example": "johndoe"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                },
+                "title": {
+                    "description": "BOT for bot accounts",
+                    "type": "string",
+                    "example": "BOT"
+                }
+            }
+        },
+        "server.LichessVariant": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "string",
+                    "example": "standard"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Standard"
+                },
+                "short": {
+                    "type": "string",
+                    "example": "Std"
+                }
+            }
+        },
+        "server.MarkNotificationsReadRequest": {
+            "type": "object",
+            "properties": {
+                "upTo": {
+                    "description": "every notification up to and including this id is marked as read",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 12
+                }
+            }
+        },
+        "server.MatchCreatedResponse": {
+            "type": "object",
+            "properties": {
+                "matchId": {
+                    "type": "string",
+                    "example": "AB2C21"
+                }
+            }
+        },
+        "server.MatchResult": {
+            "type": "object",
+            "properties": {
+                "black": {
+                    "type": "string",
+                    "example": "JaneDoe"
+                },
+                "endedAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "fen": {
+                    "description": "position at the end of the game, empty for games archived before it was stored",
+                    "type": "string",
+                    "example": "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3"
+                },
+                "matchId": {
+                    "type": "string",
+                    "example": "AB2C21"
+                },
+                "method": {
+                    "description": "how the game ended. Matches have no clock, games that ran out of time are adjudicated.\nEmpty for games archived before it was stored.",
+                    "type": "string",
+                    "enum": [
+                        "checkmate",
+                        "resignation",
+                        "adjudication",
+                        "stalemate",
+                        "insufficientMaterial",
+                        "threefoldRepetition",
+                        "fivefoldRepetition",
+                        "fiftyMoveRule",
+                        "seventyFiveMoveRule"
+                    ],
+                    "example": "checkmate"
+                },
+                "result": {
+                    "description": "white, black or draw",
+                    "type": "string",
+                    "enum": [
+                        "white",
+                        "black",
+                        "draw"
+                    ],
+                    "example": "white"
+                },
+                "white": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                },
+                "winner": {
+                    "description": "username of the winner, empty for draws",
+                    "type": "string",
+                    "example": "JohnDoe"
+                }
+            }
+        },
+        "server.Notification": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "from": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "matchId": {
+                    "type": "string",
+                    "example": "AB2C21"
+                },
+                "read": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "type": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/server.NotificationType"
+                        }
+                    ],
+                    "example": "friendRequest"
+                }
+            }
+        },
+        "server.NotificationType": {
+            "type": "string",
+            "enum": [
+                "friendRequest",
+                "friendAccepted",
+                "yourMove",
+                "challengeAccepted",
+                "challengeDeclined",
+                "tournamentPairing",
+                "simulStarted",
+                "seekAccepted",
+                "serverRestarting"
+            ],
+            "x-enum-varnames": [
+                "NotifyFriendRequest",
+                "NotifyFriendAccepted",
+                "NotifyYourMove",
+                "NotifyChallengeAccepted",
+                "NotifyChallengeDeclined",
+                "NotifyTournamentPairing",
+                "NotifySimulStarted",
+                "NotifySeekAccepted",
+                "NotifyServerRestarting"
+            ]
+        },
+        "server.PollEventsResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "description": "empty if the timeout ran out first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/game.LoggedEvent"
+                    }
+                },
+                "next": {
+                    "description": "pass it as since to get the events after these",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "server.Preferences": {
+            "type": "object",
+            "properties": {
+                "allowChallengesFromStrangers": {
+                    "description": "whether users who aren't your friends may challenge you",
+                    "type": "boolean",
+                    "example": true
+                },
+                "allowTakebacks": {
+                    "description": "whether opponents may ask to take back a move",
+                    "type": "boolean",
+                    "example": true
+                },
+                "autoQueen": {
+                    "description": "promote to a queen when a pawn move reaches the last rank without a promotion piece, eg. ` + "`" + `e7e8` + "`" + `",
+                    "type": "boolean",
+                    "example": true
+                },
+                "boardTheme": {
+                    "description": "colors of the board image",
+                    "type": "string",
+                    "enum": [
+                        "brown",
+                        "blue",
+                        "green",
+                        "gray"
+                    ],
+                    "example": "brown"
+                },
+                "emailOpponentResigned": {
+                    "description": "email you when your opponent resigns while you are disconnected from the match",
+                    "type": "boolean",
+                    "example": true
+                },
+                "emailYourMove": {
+                    "description": "email you when it becomes your move while you are disconnected from the match, see PUT /users/me/email",
+                    "type": "boolean",
+                    "example": true
+                },
+                "pieceSet": {
+                    "description": "pieces of the board image",
+                    "type": "string",
+                    "enum": [
+                        "cburnett"
+                    ],
+                    "example": "cburnett"
+                }
+            }
+        },
+        "server.PreferencesPatch": {
+            "type": "object",
+            "properties": {
+                "allowChallengesFromStrangers": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "allowTakebacks": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "autoQueen": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "boardTheme": {
+                    "type": "string",
+                    "enum": [
+                        "brown",
+                        "blue",
+                        "green",
+                        "gray"
+                    ],
+                    "example": "blue"
+                },
+                "emailOpponentResigned": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "emailYourMove": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "pieceSet": {
+                    "type": "string",
+                    "enum": [
+                        "cburnett"
+                    ],
+                    "example": "cburnett"
+                }
+            }
+        },
+        "server.Presence": {
+            "type": "object",
+            "properties": {
+                "inGame": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "matchId": {
+                    "description": "match the user is playing, only set when inGame is true",
+                    "type": "string",
+                    "example": "AB2C21"
+                },
+                "online": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "server.PutMoveRequest": {
+            "type": "object",
+            "required": [
+                "move"
+            ],
+            "properties": {
+                "move": {
+                    "type": "string",
+                    "example": "e2e4"
+                }
+            }
+        },
+        "server.Puzzle": {
+            "type": "object",
+            "properties": {
+                "black": {
+                    "type": "string",
+                    "example": "JaneDoe"
+                },
+                "createdAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "fen": {
+                    "description": "the side to move has one winning move",
+                    "type": "string",
+                    "example": "r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4"
+                },
+                "gameId": {
+                    "description": "id of the archived game the position is from",
+                    "type": "integer",
+                    "example": 7
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "matchId": {
+                    "description": "match the game was played in, its id may have been reused since",
+                    "type": "string",
+                    "example": "AB2C21"
+                },
+                "ply": {
+                    "description": "half moves played before the position",
+                    "type": "integer",
+                    "example": 6
+                },
+                "solution": {
+                    "description": "the winning move in UCI notation",
+                    "type": "string",
+                    "example": "h5f7"
+                },
+                "white": {
+                    "description": "players of the game, empty if they deleted their account",
+                    "type": "string",
+                    "example": "JohnDoe"
+                }
+            }
+        },
+        "server.RelatedAccount": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "description": "signups and logins of this account from IP",
+                    "type": "integer",
+                    "example": 4
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "JohnDoe2"
+                }
+            }
+        },
+        "server.Report": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "example": "chat"
+                },
+                "createdAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "details": {
+                    "type": "string",
+                    "example": "insulted me in chat"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "matchId": {
+                    "type": "string",
+                    "example": "AB2C21"
+                },
+                "reported": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                },
+                "reporter": {
+                    "description": "empty when reported automatically",
+                    "type": "string",
+                    "example": "JaneDoe"
+                },
+                "resolved": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "server.ReportRequest": {
+            "type": "object",
+            "required": [
+                "category",
+                "username"
+            ],
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "enum": [
+                        "chat",
+                        "username",
+                        "abuse",
+                        "cheating",
+                        "other"
+                    ],
+                    "example": "chat"
+                },
+                "details": {
+                    "type": "string",
+                    "maxLength": 1000,
+                    "example": "insulted me in chat"
+                },
+                "matchId": {
+                    "description": "match the incident happened in, optional",
+                    "type": "string",
+                    "example": "AB2C21"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                }
+            }
+        },
+        "server.ReportResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "server.Seek": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "duration": {
+                    "description": "duration of the match in hours",
+                    "type": "integer",
+                    "example": 1
+                },
+                "expiresAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "from": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "Z2D4Q7FK"
+                },
+                "rated": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "seekerBlack": {
+                    "description": "the seeker plays the black pieces",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "server.SeekAccepted": {
+            "type": "object",
+            "properties": {
+                "color": {
+                    "description": "color you play",
+                    "type": "string",
+                    "example": "black"
+                },
+                "matchId": {
+                    "type": "string",
+                    "example": "AB2C21"
+                }
+            }
+        },
+        "server.ServerStats": {
+            "type": "object",
+            "properties": {
+                "activeMatches": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "boardImageCacheHits": {
+                    "description": "board images served from the cache, and drawn because they were not in it, since the server started",
+                    "type": "integer",
+                    "example": 240
+                },
+                "boardImageCacheMisses": {
+                    "type": "integer",
+                    "example": 31
+                },
+                "connectedUsers": {
+                    "description": "users with at least one open event stream",
+                    "type": "integer",
+                    "example": 20
+                },
+                "droppedEvents": {
+                    "description": "match events players fell too far behind to get, since the server started",
+                    "type": "integer",
+                    "example": 0
+                },
+                "droppedMoves": {
+                    "description": "moves that were never written to the move log, since the server started",
+                    "type": "integer",
+                    "example": 0
+                },
+                "droppedWebhookEvents": {
+                    "description": "webhook events that did not fit in the queue, since the server started",
+                    "type": "integer",
+                    "example": 0
+                },
+                "queuedMoves": {
+                    "description": "moves waiting to be written to the move log",
+                    "type": "integer",
+                    "example": 3
+                },
+                "resyncs": {
+                    "description": "streams that were ended with a resync event because they fell behind",
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
+        "server.SetTeamRoleRequest": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "admin",
+                        "member"
+                    ],
+                    "example": "admin"
+                }
+            }
+        },
+        "server.SharedIP": {
+            "type": "object",
+            "properties": {
+                "accounts": {
+                    "description": "number of different accounts",
+                    "type": "integer",
+                    "example": 3
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "logins": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "signups": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "usernames": {
+                    "description": "the accounts, in no particular order",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "JohnDoe",
+                        "JohnDoe2",
+                        "JohnDoe3"
+                    ]
+                }
+            }
+        },
+        "server.SignupChallengeResponse": {
+            "type": "object",
+            "properties": {
+                "challenge": {
+                    "type": "string",
+                    "example": "1760000000.QW2F7ZKJ4C5X2LRXJQ6ZJQFZBY.9c1f..."
+                },
+                "difficulty": {
+                    "description": "number of leading zero bits sha256(challenge + nonce) must have. 0 if challenges are turned off.",
+                    "type": "integer",
+                    "example": 20
+                },
+                "expiresAt": {
+                    "type": "string",
+                    "format": "date-time"
+                }
+            }
+        },
+        "server.Simul": {
+            "type": "object",
+            "properties": {
+                "boards": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.SimulBoard"
+                    }
+                },
+                "challengers": {
+                    "description": "challengers waiting for the simul to start",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "createdAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "currentBoard": {
+                    "description": "match id of the board the host plays next, empty unless the simul is playing",
+                    "type": "string",
+                    "example": "AB2C21"
+                },
+                "duration": {
+                    "description": "hours each game can last",
+                    "type": "integer",
+                    "example": 2
+                },
+                "host": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "S4KQ7Z2D"
+                },
+                "maxBoards": {
+                    "description": "challengers the simul can have",
+                    "type": "integer",
+                    "example": 10
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Friday simul"
+                },
+                "rated": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "status": {
+                    "description": "open while challengers can join, then playing, then finished",
+                    "type": "string",
+                    "enum": [
+                        "open",
+                        "playing",
+                        "finished"
+                    ],
+                    "example": "open"
+                }
+            }
+        },
+        "server.SimulBoard": {
+            "type": "object",
+            "properties": {
+                "challenger": {
+                    "type": "string",
+                    "example": "JaneDoe"
+                },
+                "matchId": {
+                    "type": "string",
+                    "example": "AB2C21"
+                },
+                "result": {
+                    "description": "empty while the game is played, aborted if the match expired without a result",
+                    "type": "string",
+                    "enum": [
+                        "white",
+                        "black",
+                        "draw",
+                        "aborted",
+                        ""
+                    ],
+                    "example": "white"
+                }
+            }
+        },
+        "server.SimulEvent": {
+            "type": "object",
+            "properties": {
+                "event": {
+                    "description": "the event of the board, like on GET /matches/{id}/play",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/game.Event"
+                        }
+                    ]
+                },
+                "matchId": {
+                    "type": "string",
+                    "example": "AB2C21"
+                },
+                "result": {
+                    "type": "string",
+                    "example": "draw"
+                },
+                "simul": {
+                    "$ref": "#/definitions/server.Simul"
+                },
+                "type": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/server.SimulEventType"
+                        }
+                    ],
+                    "example": "turn"
+                }
+            }
+        },
+        "server.SimulEventType": {
+            "type": "string",
+            "enum": [
+                "simul",
+                "board",
+                "turn",
+                "boardEnded",
+                "finished",
+                "resync",
+                "serverRestarting"
+            ],
+            "x-enum-varnames": [
+                "SimulState",
+                "SimulBoardEvent",
+                "SimulTurn",
+                "SimulBoardEnded",
+                "SimulFinished",
+                "SimulResync",
+                "SimulServerRestarting"
+            ]
+        },
+        "server.Study": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "K7ZD4F2Q"
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Sicilian lesson"
+                },
+                "owner": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                },
+                "root": {
+                    "description": "the start position, with every move played from it",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/server.StudyNode"
+                        }
+                    ]
+                },
+                "updatedAt": {
+                    "type": "string",
+                    "format": "date-time"
+                }
+            }
+        },
+        "server.StudyCommentRequest": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "description": "empty to remove the comment",
+                    "type": "string",
+                    "maxLength": 1000,
+                    "example": "The Sicilian Defence"
+                },
+                "path": {
+                    "description": "moves from the start position to the position to comment, empty for the start position",
+                    "type": "array",
+                    "maxItems": 1000,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "e2e4",
+                        "c7c5"
+                    ]
+                }
+            }
+        },
+        "server.StudyEvent": {
+            "type": "object",
+            "properties": {
+                "by": {
+                    "description": "username of whoever made the change",
+                    "type": "string",
+                    "example": "JohnDoe"
+                },
+                "member": {
+                    "description": "the member who was added or removed",
+                    "type": "string",
+                    "example": "JaneDoe"
+                },
+                "node": {
+                    "$ref": "#/definitions/server.StudyNode"
+                },
+                "path": {
+                    "description": "moves from the start position to the changed position, empty for the start position",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "e2e4",
+                        "c7c5"
+                    ]
+                },
+                "study": {
+                    "$ref": "#/definitions/server.Study"
+                },
+                "type": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/server.StudyEventType"
+                        }
+                    ],
+                    "example": "move"
+                }
+            }
+        },
+        "server.StudyEventType": {
+            "type": "string",
+            "enum": [
+                "study",
+                "move",
+                "undo",
+                "comment",
+                "memberAdded",
+                "memberRemoved",
+                "deleted",
+                "resync",
+                "serverRestarting"
+            ],
+            "x-enum-varnames": [
+                "StudyState",
+                "StudyMovePlayed",
+                "StudyMoveUndone",
+                "StudyCommented",
+                "StudyMemberAdded",
+                "StudyMemberRemoved",
+                "StudyDeleted",
+                "StudyResync",
+                "StudyServerRestarting"
+            ]
+        },
+        "server.StudyMoveRequest": {
+            "type": "object",
+            "required": [
+                "move"
+            ],
+            "properties": {
+                "move": {
+                    "description": "UCI notation. If it was played in that position before, the existing move is used.",
+                    "type": "string",
+                    "example": "g1f3"
+                },
+                "path": {
+                    "description": "moves from the start position to the position to play in, empty for the start position",
+                    "type": "array",
+                    "maxItems": 1000,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "e2e4",
+                        "c7c5"
+                    ]
+                }
+            }
+        },
+        "server.StudyNode": {
+            "type": "object",
+            "properties": {
+                "children": {
+                    "description": "moves played from this position. The first one is the main line, the others are variations.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.StudyNode"
+                    }
+                },
+                "comment": {
+                    "type": "string",
+                    "example": "The most popular first move"
+                },
+                "fen": {
+                    "type": "string",
+                    "example": "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"
+                },
+                "move": {
+                    "description": "UCI notation, empty at the start position",
+                    "type": "string",
+                    "example": "e2e4"
+                },
+                "san": {
+                    "description": "the move in algebraic notation",
+                    "type": "string",
+                    "example": "e4"
+                }
             }
-        }
-    },
-    "definitions": {
-        "game.Event": {
+        },
+        "server.StudySummary": {
             "type": "object",
             "properties": {
-                "endTime": {
-                    "description": "when this match will be deleted if the game does not end.",
+                "createdAt": {
                     "type": "string",
                     "format": "date-time"
                 },
-                "move": {
-                    "description": "Move in UCI notation",
+                "id": {
                     "type": "string",
-                    "example": "e2e4"
+                    "example": "K7ZD4F2Q"
                 },
-                "oponentUsername": {
+                "name": {
                     "type": "string",
-                    "example": "JohnDoe"
+                    "example": "Sicilian lesson"
                 },
-                "opponentBlack": {
-                    "description": "is the opponent using the black pieces",
-                    "type": "boolean",
-                    "example": false
+                "owner": {
+                    "type": "string",
+                    "example": "JohnDoe"
                 },
-                "startTime": {
-                    "description": "when this match was creatd",
+                "updatedAt": {
                     "type": "string",
                     "format": "date-time"
+                }
+            }
+        },
+        "server.StudyUndoRequest": {
+            "type": "object",
+            "properties": {
+                "path": {
+                    "description": "moves from the start position to the move to take back",
+                    "type": "array",
+                    "maxItems": 1000,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "e2e4",
+                        "c7c5"
+                    ]
+                }
+            }
+        },
+        "server.TVEvent": {
+            "type": "object",
+            "properties": {
+                "black": {
+                    "type": "string",
+                    "example": "JaneDoe"
+                },
+                "fen": {
+                    "description": "position after the last move",
+                    "type": "string",
+                    "example": "rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2"
+                },
+                "matchId": {
+                    "type": "string",
+                    "example": "AB2C21"
+                },
+                "move": {
+                    "description": "the move that was played in UCI notation, and its number. 1 is white's first move.",
+                    "type": "string",
+                    "example": "g1f3"
+                },
+                "moves": {
+                    "description": "moves played so far in UCI notation, only in featured events",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "e2e4",
+                        "e7e5"
+                    ]
+                },
+                "ply": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "result": {
+                    "description": "like 1-0, only in gameOver events",
+                    "type": "string",
+                    "example": "1-0"
                 },
                 "type": {
-                    "$ref": "#/definitions/game.EventType"
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/server.TVEventType"
+                        }
+                    ],
+                    "example": "move"
+                },
+                "white": {
+                    "type": "string",
+                    "example": "JohnDoe"
                 }
             }
         },
-        "game.EventType": {
+        "server.TVEventType": {
             "type": "string",
             "enum": [
+                "featured",
                 "move",
-                "opponent",
-                "resign"
+                "gameOver",
+                "waiting",
+                "serverRestarting"
             ],
             "x-enum-varnames": [
-                "Move",
-                "OpponentInfo",
-                "Resign"
+                "TVFeatured",
+                "TVMove",
+                "TVGameOver",
+                "TVWaiting",
+                "TVServerRestarting"
             ]
         },
-        "server.ApiKeyResponse": {
+        "server.Team": {
             "type": "object",
             "properties": {
-                "apiKey": {
-                    "type": "string"
+                "createdAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Casual players from Lisbon"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "K7ZD4F2Q"
+                },
+                "members": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Knights of Lisbon"
+                },
+                "owner": {
+                    "type": "string",
+                    "example": "JohnDoe"
                 }
             }
         },
-        "server.CreateMatchRequest": {
+        "server.TeamMatch": {
             "type": "object",
             "properties": {
-                "duration": {
-                    "description": "duration in hours",
-                    "type": "integer",
-                    "example": 12
+                "endTime": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "matchId": {
+                    "type": "string",
+                    "example": "AB2C21"
+                },
+                "players": {
+                    "description": "usernames of the players who joined",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "rated": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "startTime": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "state": {
+                    "type": "string",
+                    "enum": [
+                        "waiting",
+                        "playing",
+                        "finished"
+                    ],
+                    "example": "waiting"
                 }
             }
         },
-        "server.ErrorReason": {
+        "server.TeamMember": {
             "type": "object",
             "properties": {
-                "reason": {
+                "joinedAt": {
                     "type": "string",
-                    "example": "reason"
+                    "format": "date-time"
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "owner",
+                        "admin",
+                        "member"
+                    ],
+                    "example": "member"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "JaneDoe"
                 }
             }
         },
-        "server.JoinMatchRequest": {
+        "server.TeamMembership": {
             "type": "object",
             "properties": {
-                "blackPieces": {
-                    "description": "whether to use black pieces instead of white",
+                "id": {
+                    "type": "string",
+                    "example": "K7ZD4F2Q"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Knights of Lisbon"
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "owner",
+                        "admin",
+                        "member"
+                    ],
+                    "example": "member"
+                }
+            }
+        },
+        "server.Tournament": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "currentRound": {
+                    "description": "0 until the tournament starts",
+                    "type": "integer",
+                    "example": 0
+                },
+                "duration": {
+                    "description": "hours each game can last",
+                    "type": "integer",
+                    "example": 1
+                },
+                "id": {
+                    "type": "string",
+                    "example": "T4KQ7Z2D"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Friday blitz"
+                },
+                "owner": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                },
+                "players": {
+                    "type": "integer",
+                    "example": 8
+                },
+                "rated": {
                     "type": "boolean",
                     "example": false
+                },
+                "rounds": {
+                    "description": "rounds the tournament lasts",
+                    "type": "integer",
+                    "example": 5
+                },
+                "status": {
+                    "description": "open while players can join, then playing, then finished",
+                    "type": "string",
+                    "enum": [
+                        "open",
+                        "playing",
+                        "finished"
+                    ],
+                    "example": "open"
+                },
+                "team": {
+                    "description": "id of the team whose members can join, empty if everyone can",
+                    "type": "string",
+                    "example": "K7ZD4F2Q"
                 }
             }
         },
-        "server.MatchCreatedResponse": {
+        "server.TournamentDetails": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "currentRound": {
+                    "description": "0 until the tournament starts",
+                    "type": "integer",
+                    "example": 0
+                },
+                "duration": {
+                    "description": "hours each game can last",
+                    "type": "integer",
+                    "example": 1
+                },
+                "id": {
+                    "type": "string",
+                    "example": "T4KQ7Z2D"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Friday blitz"
+                },
+                "owner": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                },
+                "pairings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.TournamentPairing"
+                    }
+                },
+                "players": {
+                    "type": "integer",
+                    "example": 8
+                },
+                "rated": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "rounds": {
+                    "description": "rounds the tournament lasts",
+                    "type": "integer",
+                    "example": 5
+                },
+                "standings": {
+                    "description": "best first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.TournamentStanding"
+                    }
+                },
+                "status": {
+                    "description": "open while players can join, then playing, then finished",
+                    "type": "string",
+                    "enum": [
+                        "open",
+                        "playing",
+                        "finished"
+                    ],
+                    "example": "open"
+                },
+                "team": {
+                    "description": "id of the team whose members can join, empty if everyone can",
+                    "type": "string",
+                    "example": "K7ZD4F2Q"
+                }
+            }
+        },
+        "server.TournamentPairing": {
             "type": "object",
             "properties": {
+                "black": {
+                    "description": "empty when white has a bye",
+                    "type": "string",
+                    "example": "JaneDoe"
+                },
                 "matchId": {
                     "type": "string",
                     "example": "AB2C21"
+                },
+                "result": {
+                    "description": "empty while the game is played, forfeit if nobody joined it",
+                    "type": "string",
+                    "enum": [
+                        "white",
+                        "black",
+                        "draw",
+                        "forfeit",
+                        ""
+                    ],
+                    "example": "white"
+                },
+                "round": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "white": {
+                    "type": "string",
+                    "example": "JohnDoe"
                 }
             }
         },
-        "server.PutMoveRequest": {
+        "server.TournamentStanding": {
             "type": "object",
             "properties": {
-                "move": {
+                "buchholz": {
+                    "description": "sum of the scores of the opponents, breaks ties",
+                    "type": "number",
+                    "example": 4
+                },
+                "rank": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "score": {
+                    "description": "a win or a bye is 1 point, a draw half a point",
+                    "type": "number",
+                    "example": 2.5
+                },
+                "username": {
                     "type": "string",
-                    "example": "e2e4"
+                    "example": "JohnDoe"
+                },
+                "withdrawn": {
+                    "description": "withdrawn players are not paired anymore",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "server.UpdateTeamRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "Casual players from Lisbon"
+                }
+            }
+        },
+        "server.User": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "isBot": {
+                    "description": "the account is played by an engine",
+                    "type": "boolean",
+                    "example": false
+                },
+                "userId": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "username": {
+                    "type": "string",
+                    "example": "JohnDoe"
                 }
             }
         },
         "server.UserCredentials": {
             "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
             "properties": {
                 "password": {
                     "type": "string",
@@ -555,10 +10629,68 @@ const docTemplate = `{
                 "username": {
                     "type": "string",
                     "maxLength": 20,
-                    "minLength": 4,
+                    "minLength": 3,
                     "example": "JohnDoe"
                 }
             }
+        },
+        "server.Webhook": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "matchId": {
+                    "description": "only events of this match are sent, empty for every match",
+                    "type": "string",
+                    "example": "AB2C21"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/chess"
+                }
+            }
+        },
+        "server.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempt": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "createdAt": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "deliveryId": {
+                    "description": "the same for every attempt to deliver one event, sent in the X-Webhook-Delivery header",
+                    "type": "string",
+                    "example": "7XQ3LJ2ZC5N4K6PWM4RAV2Y3HT"
+                },
+                "durationMs": {
+                    "type": "integer",
+                    "example": 84
+                },
+                "error": {
+                    "description": "empty when the delivery succeeded",
+                    "type": "string",
+                    "example": "webhook responded with 500 Internal Server Error"
+                },
+                "event": {
+                    "type": "string",
+                    "example": "move"
+                },
+                "statusCode": {
+                    "description": "0 when there was no response",
+                    "type": "integer",
+                    "example": 200
+                }
+            }
         }
     }
 }`
@@ -570,7 +10702,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "",
 	Schemes:          []string{},
 	Title:            "Chess API",
-	Description:      "chess api for playing chess online.",
+	Description:      "chess api for playing chess online.\nRequests are rate limited per api key, or per IP address for requests without one.\nLimited responses have `X-RateLimit-Limit`, `X-RateLimit-Remaining` and `X-RateLimit-Reset` headers.\nRequests over the limit get a `429` response with a `Retry-After` header.\nErrors have a `code` that does not change, like `MATCH_NOT_FOUND`, and a human readable `reason`.\nWhen a request body is invalid, `fields` lists every invalid field and why.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
@@ -0,0 +1,59 @@
+// serving the gRPC api next to the HTTP server
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"net"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// grpcCredentials makes the gRPC server use the certificates of the HTTP server, if it serves HTTPS.
+// It must be called after startServer, which sets up autocert.
+func grpcCredentials(e *echo.Echo, config Config) ([]grpc.ServerOption, error) {
+	switch {
+	case config.TLSCertFile != "":
+		creds, err := credentials.NewServerTLSFromFile(config.TLSCertFile, config.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return []grpc.ServerOption{grpc.Creds(creds)}, nil
+	case len(config.AutocertDomains) > 0:
+		return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(e.AutoTLSManager.TLSConfig()))}, nil
+	}
+	return nil, nil
+}
+
+// startGRPCServer serves g on addr in the background
+func startGRPCServer(g *grpc.Server, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	slog.Info("grpc server started", "addr", listener.Addr().String())
+	go func() {
+		if err := g.Serve(listener); err != nil {
+			log.Fatal("gRPC server shutdown", err)
+		}
+	}()
+	return nil
+}
+
+// stopGRPCServer waits for calls to finish until ctx is done, then closes the connections that are left
+func stopGRPCServer(ctx context.Context, g *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		g.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		slog.Warn("grpc calls did not finish in time")
+		g.Stop()
+	}
+}
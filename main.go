@@ -3,16 +3,23 @@ package main
 
 import (
 	"api/server"
+	"api/server/game"
 	"context"
-	"crypto/rand"
-	"database/sql"
 	_ "embed"
+	"errors"
+	"flag"
 	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 
 	_ "api/docs"
 
 	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
 	_ "modernc.org/sqlite"
 
 	echoSwagger "github.com/swaggo/echo-swagger"
@@ -23,22 +30,126 @@ var DATABASE_SCHEMA string
 
 //	@title			Chess API
 //	@description	chess api for playing chess online.
+//	@description	Requests are rate limited per api key, or per IP address for requests without one.
+//	@description	Limited responses have `X-RateLimit-Limit`, `X-RateLimit-Remaining` and `X-RateLimit-Reset` headers.
+//	@description	Requests over the limit get a `429` response with a `Retry-After` header.
+//	@description	Errors have a `code` that does not change, like `MATCH_NOT_FOUND`, and a human readable `reason`.
+//	@description	When a request body is invalid, `fields` lists every invalid field and why.
 
 // @license.name	MIT
 func main() {
+	config, err := loadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	} else if err != nil {
+		log.Fatal("invalid configuration: ", err)
+	}
+	jwtSecret, err := config.jwtSecret()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	ctx := context.Background()
-	dbconn, err := sql.Open("sqlite", "sqlite.db")
+	if config.RestoreFrom != "" {
+		if err := server.RestoreBackup(ctx, config.RestoreFrom, config.Database); err != nil {
+			log.Fatal(err)
+		}
+		slog.Info("restored database from backup", "backup", config.RestoreFrom, "database", config.Database)
+	}
+	dbconn, err := config.openDatabase()
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer dbconn.Close()
 
-	// create tables if not present
-	dbconn.ExecContext(ctx, DATABASE_SCHEMA)
+	// add the new columns to tables of older databases, then create tables and indexes if not present
+	if err := server.Migrate(ctx, dbconn); err != nil {
+		log.Fatal("failed to migrate database: ", err)
+	}
+	if _, err := dbconn.ExecContext(ctx, DATABASE_SCHEMA); err != nil {
+		log.Fatal("failed to apply database schema: ", err)
+	}
+
+	shutdownTracing, err := setupTracing(ctx)
+	if err != nil {
+		log.Fatal("failed to set up tracing: ", err)
+	}
+	defer shutdownTracing(ctx)
 
 	e := echo.New()
+	e.IPExtractor = config.ipExtractor()
+	e.Use(otelecho.Middleware("chess-api", otelecho.WithSkipper(func(c echo.Context) bool {
+		// probes would drown out the interesting traces
+		return c.Path() == "/healthz" || c.Path() == "/readyz"
+	})))
 
-	srv := server.NewServer(dbconn, JWT_SECRET)
+	srv := server.NewServer(dbconn, jwtSecret)
+	if words := config.bannedWords(); words != nil {
+		srv.WordFilter = server.NewWordFilter(words)
+	}
+	srv.Passwords = config.Passwords
+	config.applyRateLimits(srv.RateLimits)
+	srv.SignupChallenges.SetDifficulty(config.SignupChallengeDifficulty)
+	srv.SignupsPerIP = config.SignupsPerIP
+	srv.MaxMatchesPerUser = config.MaxMatchesPerUser
+	srv.MaxStreamsPerUser = config.MaxStreamsPerUser
+	srv.BackupDir = config.BackupDir
+	srv.Webhooks.AllowPrivateAddresses = config.WebhooksAllowPrivate
+	if config.SMTPAddr != "" {
+		srv.Emails.Mailer = server.SMTPMailer{
+			Addr:     config.SMTPAddr,
+			Username: config.SMTPUsername,
+			Password: config.SMTPPassword,
+			From:     config.SMTPFrom,
+		}
+		srv.Emails.BaseURL = config.PublicURL
+	}
+	if len(config.Admins) > 0 {
+		srv.MakeAdmins(ctx, config.Admins)
+	}
+	// stopped after the streams are drained, so leaving players still reach the other replicas
+	replicationCtx, stopReplication := context.WithCancel(ctx)
+	defer stopReplication()
+	if config.RedisURL != "" {
+		backend, err := game.NewRedisBackend(ctx, config.RedisURL)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer func() {
+			stopReplication()
+			backend.Close()
+		}()
+		srv.GameStorage.UseBackend(replicationCtx, backend)
+	}
+	if config.Engine != "" {
+		engines := server.NewEnginePool(config.Engine, config.EngineProcesses)
+		if err := engines.Check(ctx); err != nil {
+			log.Fatal(err)
+		}
+		srv.Computer = server.NewComputer(config.EngineUsername, engines)
+		if err := srv.EnsureComputerUser(ctx); err != nil {
+			log.Fatal(err)
+		}
+		defer srv.Computer.Close()
+		if config.MinePuzzles {
+			// stopped before the engines
+			mining, stopMining := context.WithCancel(ctx)
+			defer stopMining()
+			go srv.MinePuzzles(mining)
+		}
+		if config.FairPlay {
+			// stopped before the engines
+			analyzing, stopAnalyzing := context.WithCancel(ctx)
+			defer stopAnalyzing()
+			go srv.AnalyzeFairPlay(analyzing)
+		}
+	}
+	srv.ResumeMatches(ctx)
+	if config.Seed {
+		if err := srv.Seed(ctx); err != nil {
+			log.Fatal("failed to seed demo data: ", err)
+		}
+	}
 
 	e.GET("/", func(c echo.Context) error {
 		return c.Redirect(302, "/swagger/index.html")
@@ -47,28 +158,35 @@ func main() {
 
 	srv.RegisterRoutes(e)
 
-	err = e.Start(":8080")
-	if err != nil {
-		log.Fatal("Server shutdown", err)
-	}
-}
-
-var JWT_SECRET = make([]byte, 32)
-
-func init() {
-	secret, err := os.ReadFile("JWT_SECRET")
-	if err != nil {
-		// create secret if file doesnt exist
-		f, err := os.Create("JWT_SECRET")
-		defer f.Close()
+	interrupted, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	challengeServer := startServer(e, config)
+	var grpcServer *grpc.Server
+	if config.GRPCAddr != "" {
+		opts, err := grpcCredentials(e, config)
 		if err != nil {
-			log.Panicln("failed to create jwt secret", err)
+			log.Fatal("failed to load grpc certificates: ", err)
 		}
-		_, err = f.Write([]byte(rand.Text()))
-		if err != nil {
-			log.Panicln("failed to write jwt secret", err)
+		grpcServer = srv.NewGRPCServer(append(opts, grpc.StatsHandler(otelgrpc.NewServerHandler()))...)
+		if err := startGRPCServer(grpcServer, config.GRPCAddr); err != nil {
+			log.Fatal(err)
 		}
-	} else {
-		JWT_SECRET = secret
+	}
+	<-interrupted.Done()
+
+	slog.Info("shutting down")
+	drainCtx, cancelDrain := context.WithTimeout(ctx, config.ShutdownTimeout)
+	defer cancelDrain()
+	srv.Shutdown(drainCtx)
+	if err := e.Shutdown(drainCtx); err != nil {
+		slog.Warn("connections did not close in time", "error", err)
+	}
+	if grpcServer != nil {
+		stopGRPCServer(drainCtx, grpcServer)
+	}
+	// after the http server, so moves played while draining are written too
+	srv.MoveLog.Close()
+	if challengeServer != nil {
+		challengeServer.Shutdown(drainCtx)
 	}
 }
@@ -3,6 +3,21 @@ INSERT INTO users (username, password_hash, api_key)
 VALUES (?, ?, ?)
 RETURNING *;
 
+-- name: CreateGuestUser :one
+INSERT INTO users (username, password_hash, api_key, is_guest)
+VALUES (?, '', ?, TRUE)
+RETURNING *;
+
+-- name: UpgradeGuestUser :one
+UPDATE users
+SET username = ?, password_hash = ?, api_key = ?, is_guest = FALSE
+WHERE uid = ? AND is_guest
+RETURNING *;
+
+-- name: DeleteExpiredGuests :execrows
+DELETE FROM users
+WHERE is_guest AND created_at < ?;
+
 -- name: GetUserById :one
 SELECT * FROM users
 WHERE uid = ?;
@@ -27,19 +42,64 @@ UPDATE users
 SET api_key = ?1
 WHERE username = ?2;
 
+-- name: SoftDeleteUser :exec
+UPDATE users
+SET deleted_at = ?
+WHERE uid = ?;
+
+-- name: RestoreUser :exec
+UPDATE users
+SET deleted_at = NULL
+WHERE uid = ?;
+
+-- name: ListUsersDeletedBefore :many
+SELECT * FROM users
+WHERE deleted_at IS NOT NULL AND deleted_at < ?;
+
+-- name: UpdateUserPreferences :exec
+UPDATE users
+SET preferences = ?
+WHERE uid = ?;
+
+-- name: UpdateUserEmail :exec
+UPDATE users
+SET email = ?
+WHERE uid = ?;
+
+-- name: SetUserAdmin :execrows
+UPDATE users
+SET is_admin = ?
+WHERE username = ?;
+
+-- name: SetUserBot :execrows
+UPDATE users
+SET is_bot = TRUE
+WHERE uid = ? AND is_guest = FALSE;
+
+-- name: SetUserBanned :execrows
+UPDATE users
+SET banned = ?
+WHERE uid = ?;
+
 -- name: DeleteUser :exec
 DELETE FROM users
 WHERE uid = ?;
 
 -- name: StoreGame :one
-INSERT INTO games (white_uid, black_uid, result, moves, finished_at)
-VALUES (?, ?, ?, ?, ?)
+INSERT INTO games (white_uid, black_uid, result, moves, finished_at, eco, opening, match_id, method, final_fen)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: GetGameById :one
 SELECT * FROM games
 WHERE Id = ?;
 
+-- name: GetLatestGameByMatchId :one
+SELECT * FROM games
+WHERE match_id = ?
+ORDER BY id DESC
+LIMIT 1;
+
 -- name: ListGames :many
 SELECT * FROM games
 ORDER BY finished_at DESC
@@ -51,7 +111,576 @@ WHERE white_uid = ? OR black_uid = ?
 ORDER BY finished_at DESC
 LIMIT ? OFFSET ?;
 
+-- name: ListAllGamesByPlayer :many
+SELECT * FROM games
+WHERE white_uid = sqlc.arg(uid) OR black_uid = sqlc.arg(uid)
+ORDER BY finished_at ASC;
+
+-- name: ListGamesOfPlayerAfter :many
+-- games of a player in the order they were stored, starting after the game with id after_id.
+-- color is white or black for the games the player played with that color, empty for all of them.
+-- outcome is win, loss or draw from the player's side, empty for all of them.
+SELECT * FROM games
+WHERE (white_uid = sqlc.arg(uid) OR black_uid = sqlc.arg(uid))
+  AND id > sqlc.arg(after_id)
+  AND finished_at >= sqlc.arg(since)
+  AND finished_at < sqlc.arg(until)
+  AND (CAST(sqlc.arg(color) AS TEXT) = ''
+    OR (sqlc.arg(color) = 'white' AND white_uid = sqlc.arg(uid))
+    OR (sqlc.arg(color) = 'black' AND black_uid = sqlc.arg(uid)))
+  AND (CAST(sqlc.arg(outcome) AS TEXT) = ''
+    OR (sqlc.arg(outcome) = 'draw' AND result = 'draw')
+    OR (sqlc.arg(outcome) = 'win' AND ((result = 'white' AND white_uid = sqlc.arg(uid)) OR (result = 'black' AND black_uid = sqlc.arg(uid))))
+    OR (sqlc.arg(outcome) = 'loss' AND ((result = 'white' AND black_uid = sqlc.arg(uid)) OR (result = 'black' AND white_uid = sqlc.arg(uid)))))
+ORDER BY id
+LIMIT sqlc.arg(limit);
+
+-- name: AnonymizeGamesOfPlayer :exec
+UPDATE games
+SET white_uid = CASE WHEN white_uid = sqlc.arg(uid) THEN 0 ELSE white_uid END,
+    black_uid = CASE WHEN black_uid = sqlc.arg(uid) THEN 0 ELSE black_uid END
+WHERE white_uid = sqlc.arg(uid) OR black_uid = sqlc.arg(uid);
+
 -- name: DeleteGame :exec
 DELETE FROM games
 WHERE id = ?;
 
+-- name: CreateFriendRequest :exec
+INSERT INTO friendships (requester_uid, addressee_uid)
+VALUES (?, ?);
+
+-- name: GetFriendship :one
+SELECT * FROM friendships
+WHERE (requester_uid = sqlc.arg(uid) AND addressee_uid = sqlc.arg(other_uid))
+   OR (requester_uid = sqlc.arg(other_uid) AND addressee_uid = sqlc.arg(uid));
+
+-- name: AcceptFriendRequest :execrows
+UPDATE friendships
+SET accepted = TRUE
+WHERE requester_uid = ? AND addressee_uid = ? AND NOT accepted;
+
+-- name: DeleteFriendship :execrows
+DELETE FROM friendships
+WHERE (requester_uid = sqlc.arg(uid) AND addressee_uid = sqlc.arg(other_uid))
+   OR (requester_uid = sqlc.arg(other_uid) AND addressee_uid = sqlc.arg(uid));
+
+-- name: DeleteFriendshipsOfUser :exec
+DELETE FROM friendships
+WHERE requester_uid = sqlc.arg(uid) OR addressee_uid = sqlc.arg(uid);
+
+-- name: ListFriendUsernames :many
+SELECT users.username FROM friendships
+JOIN users ON users.uid = CASE
+    WHEN friendships.requester_uid = sqlc.arg(uid) THEN friendships.addressee_uid
+    ELSE friendships.requester_uid
+END
+WHERE friendships.accepted
+  AND (friendships.requester_uid = sqlc.arg(uid) OR friendships.addressee_uid = sqlc.arg(uid))
+ORDER BY users.username;
+
+-- name: ListIncomingFriendRequests :many
+SELECT users.username, friendships.created_at FROM friendships
+JOIN users ON users.uid = friendships.requester_uid
+WHERE friendships.addressee_uid = ? AND NOT friendships.accepted
+ORDER BY friendships.created_at DESC;
+
+-- name: CreateBlock :exec
+INSERT OR IGNORE INTO blocks (blocker_uid, blocked_uid)
+VALUES (?, ?);
+
+-- name: DeleteBlock :execrows
+DELETE FROM blocks
+WHERE blocker_uid = ? AND blocked_uid = ?;
+
+-- name: DeleteBlocksOfUser :exec
+DELETE FROM blocks
+WHERE blocker_uid = sqlc.arg(uid) OR blocked_uid = sqlc.arg(uid);
+
+-- name: ListBlockedUsernames :many
+SELECT users.username FROM blocks
+JOIN users ON users.uid = blocks.blocked_uid
+WHERE blocks.blocker_uid = ?
+ORDER BY users.username;
+
+-- name: IsBlockedBetween :one
+-- true if either user blocked the other
+SELECT EXISTS (
+    SELECT 1 FROM blocks
+    JOIN users blocker ON blocker.uid = blocks.blocker_uid
+    JOIN users blocked ON blocked.uid = blocks.blocked_uid
+    WHERE (blocker.username = sqlc.arg(username) AND blocked.username = sqlc.arg(other_username))
+       OR (blocker.username = sqlc.arg(other_username) AND blocked.username = sqlc.arg(username))
+);
+
+-- name: CreateChatMessage :one
+INSERT INTO chat_messages (match_id, username, message, sent_at)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListChatMessages :many
+SELECT * FROM chat_messages
+WHERE match_id = ?
+ORDER BY id ASC;
+
+-- name: CreateReport :one
+INSERT INTO reports (reporter_uid, reported_username, match_id, category, details)
+VALUES (?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: CreateNotification :one
+INSERT INTO notifications (uid, type, from_username, match_id, created_at)
+VALUES (?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListNotifications :many
+SELECT * FROM notifications
+WHERE uid = sqlc.arg(uid) AND (CAST(sqlc.arg(include_read) AS BOOLEAN) OR NOT read)
+ORDER BY id DESC
+LIMIT sqlc.arg(limit);
+
+-- name: MarkNotificationsRead :execrows
+UPDATE notifications
+SET read = TRUE
+WHERE uid = ? AND id <= ? AND NOT read;
+
+-- name: DeleteNotificationsOfUser :exec
+DELETE FROM notifications
+WHERE uid = ?;
+
+-- name: ListReports :many
+SELECT * FROM reports
+WHERE (CAST(sqlc.arg(include_resolved) AS BOOLEAN) OR NOT resolved)
+ORDER BY id DESC
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: ResolveReport :execrows
+UPDATE reports
+SET resolved = TRUE
+WHERE id = ?;
+
+-- name: CreateAuditEvent :exec
+INSERT INTO audit_log (action, actor, target, ip, details)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: ListAuditEvents :many
+SELECT * FROM audit_log
+WHERE (CAST(sqlc.arg(action) AS TEXT) = '' OR action = sqlc.arg(action))
+  AND (CAST(sqlc.arg(actor) AS TEXT) = '' OR actor = sqlc.arg(actor))
+  AND (CAST(sqlc.arg(target) AS TEXT) = '' OR target = sqlc.arg(target))
+  AND created_at >= sqlc.arg(since)
+  AND created_at < sqlc.arg(until)
+ORDER BY id DESC
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: CountSignupsFromIP :one
+SELECT COUNT(*) FROM audit_log
+WHERE ip = sqlc.arg(ip) AND action = 'account.created' AND created_at >= sqlc.arg(since);
+
+-- name: ListSharedIPs :many
+-- IPs that several accounts signed up or logged in from
+SELECT ip,
+    COUNT(DISTINCT target) AS accounts,
+    CAST(SUM(action = 'account.created') AS INTEGER) AS signups,
+    CAST(SUM(action = 'login') AS INTEGER) AS logins,
+    CAST(group_concat(DISTINCT target) AS TEXT) AS usernames
+FROM audit_log
+WHERE action IN ('account.created', 'login') AND ip != '' AND created_at >= sqlc.arg(since)
+GROUP BY ip
+HAVING COUNT(DISTINCT target) >= CAST(sqlc.arg(min_accounts) AS INTEGER)
+ORDER BY accounts DESC, ip
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: ListAccountsSharingIPs :many
+-- other accounts that signed up or logged in from an IP the user used
+SELECT other.target AS username, other.ip, COUNT(*) AS events
+FROM audit_log other
+WHERE other.action IN ('account.created', 'login') AND other.target != sqlc.arg(username)
+  AND other.ip IN (
+    SELECT mine.ip FROM audit_log mine
+    WHERE mine.action IN ('account.created', 'login') AND mine.target = sqlc.arg(username) AND mine.ip != ''
+  )
+GROUP BY other.target, other.ip
+ORDER BY other.target, other.ip;
+
+-- name: SuspendMatch :exec
+INSERT OR REPLACE INTO suspended_matches (id, rated, white_username, black_username, moves, start_time, end_time, computer_level)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListSuspendedMatches :many
+SELECT * FROM suspended_matches;
+
+-- name: DeleteSuspendedMatch :exec
+DELETE FROM suspended_matches
+WHERE id = ?;
+
+-- name: StoreMatchMove :exec
+INSERT OR IGNORE INTO match_moves (match_id, ply, move, played_at)
+VALUES (?, ?, ?, ?);
+
+-- name: DeleteOldMatchMoves :execrows
+DELETE FROM match_moves
+WHERE played_at < ?;
+
+-- name: CreateWebhook :one
+INSERT INTO webhooks (uid, url, secret, match_id)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: CountWebhooksOfUser :one
+SELECT COUNT(*) FROM webhooks
+WHERE uid = ?;
+
+-- name: ListWebhooksOfUser :many
+SELECT * FROM webhooks
+WHERE uid = ?
+ORDER BY id;
+
+-- name: GetWebhookOfUser :one
+SELECT * FROM webhooks
+WHERE id = ? AND uid = ?;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE id = ? AND uid = ?;
+
+-- name: ListWebhooksForMatch :many
+SELECT webhooks.* FROM webhooks
+JOIN users ON users.uid = webhooks.uid
+WHERE users.username IN (sqlc.arg(white), sqlc.arg(black))
+  AND webhooks.match_id IN ('', sqlc.arg(match_id))
+  AND users.banned = FALSE AND users.deleted_at IS NULL;
+
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (webhook_id, delivery_id, event, attempt, status_code, error, duration_ms)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE webhook_id = ?
+ORDER BY id DESC
+LIMIT ? OFFSET ?;
+
+-- name: DeleteOldWebhookDeliveries :execrows
+DELETE FROM webhook_deliveries
+WHERE created_at < ?;
+
+-- name: CreateImportedGame :one
+INSERT INTO imported_games (id, uid, white, black, result, pgn, eco, opening)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetImportedGame :one
+SELECT * FROM imported_games
+WHERE id = ?;
+
+-- name: CreateStudy :one
+INSERT INTO studies (id, owner_uid, name, tree)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetStudy :one
+SELECT studies.id, studies.name, studies.tree, studies.owner_uid, users.username AS owner, studies.created_at, studies.updated_at
+FROM studies
+JOIN users ON users.uid = studies.owner_uid
+WHERE studies.id = ?;
+
+-- name: UpdateStudyTree :exec
+UPDATE studies SET tree = ?, updated_at = ?
+WHERE id = ?;
+
+-- name: DeleteStudy :execrows
+DELETE FROM studies
+WHERE id = ? AND owner_uid = ?;
+
+-- name: ListStudiesOfUser :many
+-- studies the user owns or was invited to, recently changed first
+SELECT studies.id, studies.name, users.username AS owner, studies.created_at, studies.updated_at
+FROM studies
+JOIN users ON users.uid = studies.owner_uid
+WHERE studies.owner_uid = sqlc.arg(uid)
+   OR studies.id IN (SELECT study_id FROM study_members WHERE study_members.uid = sqlc.arg(uid))
+ORDER BY studies.updated_at DESC;
+
+-- name: AddStudyMember :execrows
+INSERT OR IGNORE INTO study_members (study_id, uid)
+VALUES (?, ?);
+
+-- name: RemoveStudyMember :execrows
+DELETE FROM study_members
+WHERE study_id = ? AND uid = ?;
+
+-- name: ListStudyMembers :many
+SELECT users.username FROM study_members
+JOIN users ON users.uid = study_members.uid
+WHERE study_members.study_id = ?
+ORDER BY users.username;
+
+-- name: NextGameToScanForPuzzles :one
+-- the first finished game after the ones the puzzle miner analyzed
+SELECT * FROM games
+WHERE id > (SELECT COALESCE(MAX(game_id), 0) FROM puzzle_scans)
+ORDER BY id
+LIMIT 1;
+
+-- name: StorePuzzleScan :exec
+INSERT INTO puzzle_scans (game_id, puzzles)
+VALUES (?, ?);
+
+-- name: CreatePuzzle :exec
+INSERT INTO puzzles (game_id, ply, fen, solution)
+VALUES (?, ?, ?, ?);
+
+-- name: GetPuzzle :one
+-- the players are empty if they deleted their account
+SELECT puzzles.id, puzzles.game_id, puzzles.ply, puzzles.fen, puzzles.solution, puzzles.created_at, games.match_id,
+       COALESCE(white.username, '') AS white, COALESCE(black.username, '') AS black
+FROM puzzles
+JOIN games ON games.id = puzzles.game_id
+LEFT JOIN users AS white ON white.uid = games.white_uid
+LEFT JOIN users AS black ON black.uid = games.black_uid
+WHERE puzzles.id = ?;
+
+-- name: GetRandomPuzzleId :one
+SELECT id FROM puzzles
+ORDER BY RANDOM()
+LIMIT 1;
+
+-- name: CreateTournament :one
+INSERT INTO tournaments (id, owner_uid, name, rounds, duration, rated)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetTournament :one
+-- team is empty unless only the members of a team can join
+SELECT tournaments.*, users.username AS owner, COALESCE(team_tournaments.team_id, '') AS team
+FROM tournaments
+JOIN users ON users.uid = tournaments.owner_uid
+LEFT JOIN team_tournaments ON team_tournaments.tournament_id = tournaments.id
+WHERE tournaments.id = ?;
+
+-- name: ListTournaments :many
+-- newest first. status filters by status and team by team, empty for every tournament.
+SELECT tournaments.*, users.username AS owner, COALESCE(team_tournaments.team_id, '') AS team,
+       (SELECT COUNT(*) FROM tournament_players WHERE tournament_players.tournament_id = tournaments.id) AS players
+FROM tournaments
+JOIN users ON users.uid = tournaments.owner_uid
+LEFT JOIN team_tournaments ON team_tournaments.tournament_id = tournaments.id
+WHERE (CAST(sqlc.arg(status) AS TEXT) = '' OR tournaments.status = sqlc.arg(status))
+  AND (CAST(sqlc.arg(team) AS TEXT) = '' OR team_tournaments.team_id = sqlc.arg(team))
+ORDER BY tournaments.created_at DESC, tournaments.id
+LIMIT sqlc.arg(limit);
+
+-- name: SetTournamentRound :exec
+UPDATE tournaments SET current_round = ?, status = ?
+WHERE id = ?;
+
+-- name: AddTournamentPlayer :execrows
+INSERT OR IGNORE INTO tournament_players (tournament_id, uid)
+VALUES (?, ?);
+
+-- name: RemoveTournamentPlayer :execrows
+DELETE FROM tournament_players
+WHERE tournament_id = ? AND uid = ?;
+
+-- name: WithdrawTournamentPlayer :execrows
+UPDATE tournament_players SET withdrawn = TRUE
+WHERE tournament_id = ? AND uid = ? AND NOT withdrawn;
+
+-- name: ListTournamentPlayers :many
+-- in the order they joined, which is their seed
+SELECT tournament_players.uid, users.username, tournament_players.withdrawn
+FROM tournament_players
+JOIN users ON users.uid = tournament_players.uid
+WHERE tournament_players.tournament_id = ?
+ORDER BY tournament_players.joined_at, tournament_players.rowid;
+
+-- name: CreateTournamentPairing :exec
+INSERT INTO tournament_pairings (tournament_id, round, white_uid, black_uid, match_id, result)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: ListTournamentPairings :many
+-- players are empty if they deleted their account, black is empty for a bye
+SELECT tournament_pairings.*, COALESCE(white.username, '') AS white, COALESCE(black.username, '') AS black
+FROM tournament_pairings
+LEFT JOIN users AS white ON white.uid = tournament_pairings.white_uid
+LEFT JOIN users AS black ON black.uid = tournament_pairings.black_uid
+WHERE tournament_pairings.tournament_id = ?
+ORDER BY tournament_pairings.round, tournament_pairings.rowid;
+
+-- name: ListUnfinishedTournamentPairings :many
+-- games of every tournament that did not end yet
+SELECT tournament_pairings.*, COALESCE(white.username, '') AS white, COALESCE(black.username, '') AS black
+FROM tournament_pairings
+LEFT JOIN users AS white ON white.uid = tournament_pairings.white_uid
+LEFT JOIN users AS black ON black.uid = tournament_pairings.black_uid
+WHERE tournament_pairings.result = '';
+
+-- name: GetUnfinishedTournamentPairingByMatch :one
+SELECT tournament_pairings.*, COALESCE(white.username, '') AS white, COALESCE(black.username, '') AS black
+FROM tournament_pairings
+LEFT JOIN users AS white ON white.uid = tournament_pairings.white_uid
+LEFT JOIN users AS black ON black.uid = tournament_pairings.black_uid
+WHERE tournament_pairings.match_id = ? AND tournament_pairings.result = '';
+
+-- name: SetTournamentPairingResult :execrows
+UPDATE tournament_pairings SET result = ?
+WHERE tournament_id = ? AND round = ? AND white_uid = ? AND result = '';
+
+-- name: CountUnfinishedTournamentPairings :one
+SELECT COUNT(*) FROM tournament_pairings
+WHERE tournament_id = ? AND result = '';
+
+-- name: CreateTeam :one
+INSERT INTO teams (id, owner_uid, name, description)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: TeamNameExists :one
+SELECT EXISTS (SELECT 1 FROM teams WHERE name = ?);
+
+-- name: GetTeam :one
+SELECT teams.*, users.username AS owner,
+       (SELECT COUNT(*) FROM team_members WHERE team_members.team_id = teams.id) AS members
+FROM teams
+JOIN users ON users.uid = teams.owner_uid
+WHERE teams.id = ?;
+
+-- name: ListTeams :many
+-- biggest first. search matches part of the name, empty for every team.
+SELECT teams.*, users.username AS owner,
+       (SELECT COUNT(*) FROM team_members WHERE team_members.team_id = teams.id) AS members
+FROM teams
+JOIN users ON users.uid = teams.owner_uid
+WHERE instr(lower(teams.name), lower(CAST(sqlc.arg(search) AS TEXT))) > 0
+ORDER BY members DESC, teams.created_at, teams.id
+LIMIT sqlc.arg(limit);
+
+-- name: ListTeamsOfUser :many
+SELECT teams.id, teams.name, team_members.role
+FROM team_members
+JOIN teams ON teams.id = team_members.team_id
+WHERE team_members.uid = ?
+ORDER BY team_members.joined_at;
+
+-- name: UpdateTeamDescription :exec
+UPDATE teams SET description = ?
+WHERE id = ?;
+
+-- name: DeleteTeam :execrows
+DELETE FROM teams
+WHERE id = ? AND owner_uid = ?;
+
+-- name: AddTeamMember :execrows
+INSERT OR IGNORE INTO team_members (team_id, uid, role)
+VALUES (?, ?, ?);
+
+-- name: GetTeamMemberRole :one
+SELECT role FROM team_members
+WHERE team_id = ? AND uid = ?;
+
+-- name: ListTeamMembers :many
+-- the owner first, then admins, then members in the order they joined
+SELECT users.username, team_members.role, team_members.joined_at
+FROM team_members
+JOIN users ON users.uid = team_members.uid
+WHERE team_members.team_id = ?
+ORDER BY CASE team_members.role WHEN 'owner' THEN 0 WHEN 'admin' THEN 1 ELSE 2 END, team_members.joined_at, team_members.rowid;
+
+-- name: SetTeamMemberRole :execrows
+-- the owner's role cannot change
+UPDATE team_members SET role = ?
+WHERE team_id = ? AND uid = ? AND role != 'owner';
+
+-- name: RemoveTeamMember :execrows
+-- the owner cannot be removed, they delete the team instead
+DELETE FROM team_members
+WHERE team_id = ? AND uid = ? AND role != 'owner';
+
+-- name: CreateTeamTournament :exec
+INSERT INTO team_tournaments (tournament_id, team_id)
+VALUES (?, ?);
+
+-- name: CreateTeamMatch :exec
+INSERT OR REPLACE INTO team_matches (match_id, team_id)
+VALUES (?, ?);
+
+-- name: GetTeamOfMatch :one
+SELECT team_id FROM team_matches
+WHERE match_id = ?;
+
+-- name: ListTeamMatches :many
+-- newest first
+SELECT match_id FROM team_matches
+WHERE team_id = ?
+ORDER BY created_at DESC, rowid DESC;
+
+-- name: ListAllTeamMatches :many
+SELECT match_id FROM team_matches;
+
+-- name: DeleteTeamMatch :exec
+DELETE FROM team_matches
+WHERE match_id = ?;
+
+-- name: QueueFairPlayGame :exec
+INSERT INTO fair_play_queue (game_id, move_times)
+VALUES (?, ?);
+
+-- name: NextFairPlayGame :one
+-- the oldest rated game waiting for the fair-play analysis
+SELECT fair_play_queue.game_id, fair_play_queue.move_times, games.white_uid, games.black_uid, games.moves, games.match_id
+FROM fair_play_queue
+JOIN games ON games.id = fair_play_queue.game_id
+ORDER BY fair_play_queue.game_id
+LIMIT 1;
+
+-- name: DeleteFairPlayGame :exec
+DELETE FROM fair_play_queue
+WHERE game_id = ?;
+
+-- name: DeleteOldFairPlayGames :execrows
+-- games nobody analyzed, when the server runs without the fair-play analysis
+DELETE FROM fair_play_queue
+WHERE created_at < ?;
+
+-- name: StoreFairPlayAnalysis :exec
+INSERT OR REPLACE INTO fair_play_analyses (game_id, uid, moves, engine_matches, average_move_time, move_time_deviation, suspicion)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListFairPlayAnalysesOfUser :many
+SELECT fair_play_analyses.*, games.match_id, games.finished_at
+FROM fair_play_analyses
+JOIN games ON games.id = fair_play_analyses.game_id
+WHERE fair_play_analyses.uid = ?
+ORDER BY fair_play_analyses.game_id DESC
+LIMIT ? OFFSET ?;
+
+-- name: ListFlaggedPlayers :many
+-- players whose games analyzed after since look like engine play on average, most suspicious first
+SELECT users.username,
+       COUNT(*) AS games,
+       CAST(AVG(fair_play_analyses.suspicion) AS INTEGER) AS suspicion,
+       CAST(SUM(fair_play_analyses.moves) AS INTEGER) AS moves,
+       CAST(SUM(fair_play_analyses.engine_matches) AS INTEGER) AS engine_matches,
+       (SELECT COUNT(*) FROM reports
+        WHERE reports.reported_username = users.username AND reports.category = 'cheating' AND NOT reports.resolved) AS open_reports
+FROM fair_play_analyses
+JOIN users ON users.uid = fair_play_analyses.uid
+WHERE fair_play_analyses.created_at > sqlc.arg(since)
+GROUP BY users.uid
+HAVING COUNT(*) >= CAST(sqlc.arg(min_games) AS INTEGER) AND AVG(fair_play_analyses.suspicion) >= CAST(sqlc.arg(min_suspicion) AS INTEGER)
+ORDER BY suspicion DESC, users.uid
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: AddExplorerMove :exec
+INSERT INTO explorer_moves (position, move, white_wins, draws, black_wins)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (position, move) DO UPDATE
+SET white_wins = white_wins + excluded.white_wins,
+    draws = draws + excluded.draws,
+    black_wins = black_wins + excluded.black_wins;
+
+-- name: ListExplorerMoves :many
+-- most played first
+SELECT move, white_wins, draws, black_wins FROM explorer_moves
+WHERE position = ?
+ORDER BY white_wins + draws + black_wins DESC, move;
@@ -1,20 +1,374 @@
 PRAGMA journal_mode = WAL;
 
-CREATE TABLE users (
+CREATE TABLE IF NOT EXISTS users (
     uid INTEGER PRIMARY KEY AUTOINCREMENT,
     username TEXT UNIQUE NOT NULL,
     password_hash TEXT NOT NULL,
     -- jwt with expiry as the api key
     api_key TEXT UNIQUE NOT NULL,
-    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+    -- guest accounts have no password and are deleted after they expire
+    is_guest BOOLEAN NOT NULL DEFAULT FALSE,
+    -- JSON object, see server.Preferences
+    preferences TEXT NOT NULL DEFAULT '{}',
+    -- where emails about matches are sent, empty if the user did not give one. See server/email.go
+    email TEXT NOT NULL DEFAULT '',
+    is_admin BOOLEAN NOT NULL DEFAULT FALSE,
+    -- accounts played by an engine through the bot api, see server/bots.go
+    is_bot BOOLEAN NOT NULL DEFAULT FALSE,
+    -- banned users cannot log in or use their api key
+    banned BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- set when the user deletes their account. The account is removed after a grace period.
+    deleted_at DATETIME
 );
 
-CREATE TABLE games (
+CREATE TABLE IF NOT EXISTS games (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
+    -- uid is 0 when the player deleted their account
     white_uid INTEGER NOT NULL,
     black_uid INTEGER NOT NULL,
     result TEXT CHECK (Result IN ('white', 'black', 'draw')) NOT NULL,
     -- PGN of moves
     moves TEXT NOT NULL,  
-    finished_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+    finished_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- opening that was played, empty if the first move is not in the ECO book
+    eco TEXT NOT NULL DEFAULT '',
+    opening TEXT NOT NULL DEFAULT '',
+    -- id of the match the game was played in. Ids are reused, the latest game is the one of the match.
+    match_id TEXT NOT NULL DEFAULT '',
+    -- how the game ended, see server/result.go. Empty for games archived before it was stored, like final_fen.
+    method TEXT NOT NULL DEFAULT '',
+    -- position at the end of the game
+    final_fen TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS games_match_id ON games (match_id, id);
+-- games of a player, for exports
+CREATE INDEX IF NOT EXISTS games_white_uid ON games (white_uid, id);
+CREATE INDEX IF NOT EXISTS games_black_uid ON games (black_uid, id);
+
+CREATE TABLE IF NOT EXISTS friendships (
+    requester_uid INTEGER NOT NULL,
+    addressee_uid INTEGER NOT NULL,
+    -- false while the friend request is pending
+    accepted BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (requester_uid, addressee_uid)
+);
+
+CREATE TABLE IF NOT EXISTS blocks (
+    blocker_uid INTEGER NOT NULL,
+    blocked_uid INTEGER NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (blocker_uid, blocked_uid)
+);
+
+CREATE TABLE IF NOT EXISTS chat_messages (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    match_id TEXT NOT NULL,
+    username TEXT NOT NULL,
+    message TEXT NOT NULL,
+    sent_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS chat_messages_match_id ON chat_messages (match_id);
+
+CREATE TABLE IF NOT EXISTS reports (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    -- NULL when the report was made automatically by the word filter
+    reporter_uid INTEGER,
+    reported_username TEXT NOT NULL,
+    -- empty when the report is not about a match
+    match_id TEXT NOT NULL DEFAULT '',
+    category TEXT CHECK (category IN ('chat', 'username', 'abuse', 'cheating', 'other')) NOT NULL,
+    details TEXT NOT NULL,
+    resolved BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    uid INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    -- user who caused the notification, empty if none
+    from_username TEXT NOT NULL DEFAULT '',
+    -- match the notification is about, empty if none
+    match_id TEXT NOT NULL DEFAULT '',
+    read BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS notifications_uid ON notifications (uid, id);
+
+-- security relevant events, rows are never updated or deleted
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    -- e.g. login.failed, see AUDIT_* in server/audit.go
+    action TEXT NOT NULL,
+    -- username of whoever performed the action, empty if unknown
+    actor TEXT NOT NULL DEFAULT '',
+    -- username or match the action was performed on, empty if none
+    target TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    details TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_actor ON audit_log (actor, id);
+-- finding accounts that sign up or log in from the same IP
+CREATE INDEX IF NOT EXISTS audit_log_ip ON audit_log (ip, action, created_at);
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit log is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit log is append-only');
+END;
+
+-- unfinished matches saved during a shutdown, resumed on the next start
+CREATE TABLE IF NOT EXISTS suspended_matches (
+    id TEXT PRIMARY KEY,
+    rated BOOLEAN NOT NULL,
+    -- empty if nobody joined with that color
+    white_username TEXT NOT NULL,
+    black_username TEXT NOT NULL,
+    -- PGN of moves
+    moves TEXT NOT NULL,
+    start_time DATETIME NOT NULL,
+    end_time DATETIME NOT NULL,
+    -- level of the computer playing in the match, 0 if it doesn't
+    computer_level INTEGER NOT NULL DEFAULT 0
+);
+
+-- moves of ongoing matches as they are played, written in batches by server.MoveLog
+CREATE TABLE IF NOT EXISTS match_moves (
+    match_id TEXT NOT NULL,
+    -- 1 for white's first move
+    ply INTEGER NOT NULL,
+    -- UCI notation
+    move TEXT NOT NULL,
+    played_at DATETIME NOT NULL,
+    PRIMARY KEY (match_id, ply)
+);
+
+-- URLs that receive match events of a user, see server/webhooks.go
+CREATE TABLE IF NOT EXISTS webhooks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    uid INTEGER NOT NULL REFERENCES users (uid) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    -- deliveries are signed with it, so receivers can check they came from this server
+    secret TEXT NOT NULL,
+    -- empty for every match of the user
+    match_id TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS webhooks_uid ON webhooks (uid);
+
+-- every attempt to deliver an event to a webhook
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    -- the same for every attempt to deliver one event
+    delivery_id TEXT NOT NULL,
+    event TEXT NOT NULL,
+    attempt INTEGER NOT NULL,
+    -- 0 when there was no response
+    status_code INTEGER NOT NULL,
+    -- empty when the delivery succeeded
+    error TEXT NOT NULL DEFAULT '',
+    duration_ms INTEGER NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, id);
+
+-- games uploaded as PGN to review them with the board endpoints, see server/imports.go
+CREATE TABLE IF NOT EXISTS imported_games (
+    -- longer than match ids, so an id is never both
+    id TEXT PRIMARY KEY,
+    uid INTEGER NOT NULL REFERENCES users (uid) ON DELETE CASCADE,
+    -- names from the PGN tags, ? if missing
+    white TEXT NOT NULL,
+    black TEXT NOT NULL,
+    -- empty if the PGN has no result
+    result TEXT CHECK (result IN ('white', 'black', 'draw', '')) NOT NULL,
+    -- the PGN as it was uploaded
+    pgn TEXT NOT NULL,
+    eco TEXT NOT NULL DEFAULT '',
+    opening TEXT NOT NULL DEFAULT '',
+    imported_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS imported_games_uid ON imported_games (uid);
+
+-- shared analysis boards, see server/studies.go
+CREATE TABLE IF NOT EXISTS studies (
+    id TEXT PRIMARY KEY,
+    owner_uid INTEGER NOT NULL REFERENCES users (uid) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    -- JSON tree of moves, see server.StudyNode
+    tree TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- people invited to a study by its owner
+CREATE TABLE IF NOT EXISTS study_members (
+    study_id TEXT NOT NULL REFERENCES studies (id) ON DELETE CASCADE,
+    uid INTEGER NOT NULL REFERENCES users (uid) ON DELETE CASCADE,
+    PRIMARY KEY (study_id, uid)
+);
+
+CREATE INDEX IF NOT EXISTS study_members_uid ON study_members (uid);
+
+-- positions of finished games where one move clearly wins, found by the puzzle miner, see server/puzzles.go
+CREATE TABLE IF NOT EXISTS puzzles (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    -- the game the position is from
+    game_id INTEGER NOT NULL REFERENCES games (id) ON DELETE CASCADE,
+    -- half moves played before the position
+    ply INTEGER NOT NULL,
+    fen TEXT NOT NULL,
+    -- the winning move, in UCI notation
+    solution TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS puzzles_game_id ON puzzles (game_id);
+
+-- games the puzzle miner analyzed. Games are analyzed in the order of their ids, so the highest id is where it continues.
+CREATE TABLE IF NOT EXISTS puzzle_scans (
+    game_id INTEGER PRIMARY KEY,
+    -- puzzles found in the game
+    puzzles INTEGER NOT NULL,
+    scanned_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Swiss tournaments, see server/tournaments.go
+CREATE TABLE IF NOT EXISTS tournaments (
+    id TEXT PRIMARY KEY,
+    owner_uid INTEGER NOT NULL REFERENCES users (uid) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    rounds INTEGER NOT NULL,
+    -- hours the games of each round last
+    duration INTEGER NOT NULL,
+    -- guests cannot join rated tournaments
+    rated BOOLEAN NOT NULL DEFAULT FALSE,
+    status TEXT CHECK (status IN ('open', 'playing', 'finished')) NOT NULL DEFAULT 'open',
+    -- 0 until the tournament starts
+    current_round INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS tournament_players (
+    tournament_id TEXT NOT NULL REFERENCES tournaments (id) ON DELETE CASCADE,
+    uid INTEGER NOT NULL REFERENCES users (uid) ON DELETE CASCADE,
+    -- withdrawn players are not paired in the next rounds
+    withdrawn BOOLEAN NOT NULL DEFAULT FALSE,
+    joined_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tournament_id, uid)
+);
+
+CREATE INDEX IF NOT EXISTS tournament_players_uid ON tournament_players (uid);
+
+-- the games of each round. Scores are counted from the results.
+CREATE TABLE IF NOT EXISTS tournament_pairings (
+    tournament_id TEXT NOT NULL REFERENCES tournaments (id) ON DELETE CASCADE,
+    round INTEGER NOT NULL,
+    white_uid INTEGER NOT NULL,
+    -- 0 when white has a bye, which counts as a win
+    black_uid INTEGER NOT NULL,
+    -- empty for a bye
+    match_id TEXT NOT NULL,
+    -- empty until the game ends, forfeit when neither player joined
+    result TEXT CHECK (result IN ('white', 'black', 'draw', 'forfeit', '')) NOT NULL DEFAULT '',
+    PRIMARY KEY (tournament_id, round, white_uid)
 );
+
+CREATE INDEX IF NOT EXISTS tournament_pairings_match_id ON tournament_pairings (match_id);
+
+-- teams, see server/teams.go
+CREATE TABLE IF NOT EXISTS teams (
+    id TEXT PRIMARY KEY,
+    -- the team is deleted with its owner's account
+    owner_uid INTEGER NOT NULL REFERENCES users (uid) ON DELETE CASCADE,
+    name TEXT UNIQUE NOT NULL COLLATE NOCASE,
+    description TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS team_members (
+    team_id TEXT NOT NULL REFERENCES teams (id) ON DELETE CASCADE,
+    uid INTEGER NOT NULL REFERENCES users (uid) ON DELETE CASCADE,
+    -- admins manage members and team events, only the owner manages admins
+    role TEXT CHECK (role IN ('owner', 'admin', 'member')) NOT NULL DEFAULT 'member',
+    joined_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (team_id, uid)
+);
+
+CREATE INDEX IF NOT EXISTS team_members_uid ON team_members (uid);
+
+-- tournaments only members of the team can join.
+-- Rows are kept when the team is deleted, so that nobody can join its tournaments anymore.
+CREATE TABLE IF NOT EXISTS team_tournaments (
+    tournament_id TEXT PRIMARY KEY REFERENCES tournaments (id) ON DELETE CASCADE,
+    team_id TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS team_tournaments_team_id ON team_tournaments (team_id);
+
+-- ongoing matches only members of the team can join. Rows are removed once the match ended.
+CREATE TABLE IF NOT EXISTS team_matches (
+    match_id TEXT PRIMARY KEY,
+    team_id TEXT NOT NULL REFERENCES teams (id) ON DELETE CASCADE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS team_matches_team_id ON team_matches (team_id);
+
+-- rated games waiting for the fair-play analysis, see server/fairPlay.go
+CREATE TABLE IF NOT EXISTS fair_play_queue (
+    game_id INTEGER PRIMARY KEY REFERENCES games (id) ON DELETE CASCADE,
+    -- milliseconds each move took, comma separated in the order of the moves. -1 when it is not known, like for the first move.
+    move_times TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- how a player played in a rated game, see server/fairPlay.go
+CREATE TABLE IF NOT EXISTS fair_play_analyses (
+    game_id INTEGER NOT NULL REFERENCES games (id) ON DELETE CASCADE,
+    uid INTEGER NOT NULL REFERENCES users (uid) ON DELETE CASCADE,
+    -- positions the engine analyzed, and how many times the player found its best move in them
+    moves INTEGER NOT NULL,
+    engine_matches INTEGER NOT NULL,
+    -- of the moves that were timed, in milliseconds
+    average_move_time INTEGER NOT NULL,
+    move_time_deviation INTEGER NOT NULL,
+    -- 0 to 100, how much the game looks like engine play
+    suspicion INTEGER NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (game_id, uid)
+);
+
+CREATE INDEX IF NOT EXISTS fair_play_analyses_uid ON fair_play_analyses (uid, game_id);
+
+-- moves played in the opening positions of archived games, for the opening explorer, see server/explorer.go
+CREATE TABLE IF NOT EXISTS explorer_moves (
+    -- FEN without the move counters, so games that transposed share the position
+    position TEXT NOT NULL,
+    -- in UCI notation
+    move TEXT NOT NULL,
+    -- how the games that played the move ended
+    white_wins INTEGER NOT NULL DEFAULT 0,
+    draws INTEGER NOT NULL DEFAULT 0,
+    black_wins INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (position, move)
+) WITHOUT ROWID;
+
+-- bumped whenever the schema changes, /readyz checks it.
+-- Columns added to existing tables also need a migration in server/migrations.go, CREATE TABLE IF NOT EXISTS leaves old tables as they are.
+PRAGMA user_version = 19;
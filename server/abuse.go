@@ -0,0 +1,148 @@
+// detecting many accounts created or used from the same IP
+package server
+
+import (
+	"api/db"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// accounts that can be created from one IP, counted from the audit log so it holds across restarts.
+// It can be changed with the SIGNUPS_PER_IP environment variable.
+var DEFAULT_SIGNUPS_PER_IP = RateLimit{Requests: 10, Window: 24 * time.Hour}
+
+// SignupsPerIPMiddleware refuses signups from IPs that created SignupsPerIP accounts recently.
+// Refused signups are written to the audit log, so bursts show up there.
+func (s Server) SignupsPerIPMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if s.SignupsPerIP.Requests == 0 {
+			return next(c)
+		}
+		count, err := s.DB.CountSignupsFromIP(c.Request().Context(), db.CountSignupsFromIPParams{
+			Ip:    c.RealIP(),
+			Since: time.Now().UTC().Add(-s.SignupsPerIP.Window),
+		})
+		if err != nil {
+			slog.Error("failed to count signups", "ip", c.RealIP(), "error", err)
+			return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+		}
+		if count >= int64(s.SignupsPerIP.Requests) {
+			s.audit(c, AUDIT_SIGNUP_BLOCKED, "", "", fmt.Sprintf("%d accounts in %s", count, s.SignupsPerIP.Window))
+			return c.JSON(http.StatusTooManyRequests, Reason(CODE_TOO_MANY_SIGNUPS, "Too many accounts were created from your network, try again later"))
+		}
+		return next(c)
+	}
+}
+
+// SharedIP is an IP several accounts signed up or logged in from
+type SharedIP struct {
+	IP string `json:"ip" example:"203.0.113.7"`
+	// number of different accounts
+	Accounts int64 `json:"accounts" example:"3"`
+	Signups  int64 `json:"signups" example:"3"`
+	Logins   int64 `json:"logins" example:"12"`
+	// the accounts, in no particular order
+	Usernames []string `json:"usernames" example:"JohnDoe,JohnDoe2,JohnDoe3"`
+}
+
+// @Summary		List IPs shared by several accounts
+// @Description	Lists IPs that several accounts signed up or logged in from, most accounts first.
+// @Description	Clusters of accounts on one IP can be smurfs or spam accounts, but also people on the same network.
+// @Tags			admin
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey of an admin in the format Bearer: apiKey"
+// @Param			since			query		string	false	"only count activity at or after this time (RFC 3339), default 7 days ago"
+// @Param			min				query		int		false	"minimum number of accounts, default 2"
+// @Param			limit			query		int		false	"default 50, max 500"
+// @Param			offset			query		int		false	"default 0"
+// @Success		200				{array}		SharedIP
+// @Failure		400				{object}	ErrorReason	"Invalid query"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Not an admin"
+// @Failure		500				{object}	ErrorReason
+// @Router			/admin/abuse/ips [get]
+func (s Server) AdminListSharedIPs(c echo.Context) error {
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return err
+	}
+	params := db.ListSharedIPsParams{
+		Since:       time.Now().Add(-7 * 24 * time.Hour),
+		MinAccounts: 2,
+		Limit:       limit,
+		Offset:      offset,
+	}
+	if since := c.QueryParam("since"); since != "" {
+		if params.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			return c.JSON(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, "since must be an RFC 3339 time"))
+		}
+	}
+	if m := c.QueryParam("min"); m != "" {
+		if params.MinAccounts, err = strconv.ParseInt(m, 10, 64); err != nil || params.MinAccounts < 1 {
+			return c.JSON(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, "min must be a positive number"))
+		}
+	}
+	// created_at is stored in UTC
+	params.Since = params.Since.UTC()
+
+	rows, err := s.DB.ListSharedIPs(c.Request().Context(), params)
+	if err != nil {
+		slog.Error("failed to list shared IPs", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	result := make([]SharedIP, 0, len(rows))
+	for _, r := range rows {
+		result = append(result, SharedIP{
+			IP:        r.Ip,
+			Accounts:  r.Accounts,
+			Signups:   r.Signups,
+			Logins:    r.Logins,
+			Usernames: strings.Split(r.Usernames, ","),
+		})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// RelatedAccount is an account that used the same IP as another one
+type RelatedAccount struct {
+	Username string `json:"username" example:"JohnDoe2"`
+	IP       string `json:"ip" example:"203.0.113.7"`
+	// signups and logins of this account from IP
+	Events int64 `json:"events" example:"4"`
+}
+
+// @Summary		List accounts related to a user
+// @Description	Lists other accounts that signed up or logged in from an IP the user signed up or logged in from.
+// @Description	Accounts that share several IPs are listed once per IP.
+// @Tags			admin
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey of an admin in the format Bearer: apiKey"
+// @Param			username		path		string	true	"Username"
+// @Success		200				{array}		RelatedAccount
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Not an admin"
+// @Failure		404				{object}	ErrorReason	"User not found"
+// @Failure		500				{object}	ErrorReason
+// @Router			/admin/users/{username}/related [get]
+func (s Server) AdminListRelatedAccounts(c echo.Context) error {
+	user, err := s.userFromParam(c)
+	if err != nil {
+		return err
+	}
+	rows, err := s.DB.ListAccountsSharingIPs(c.Request().Context(), user.Username)
+	if err != nil {
+		slog.Error("failed to list related accounts", "username", user.Username, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	result := make([]RelatedAccount, 0, len(rows))
+	for _, r := range rows {
+		result = append(result, RelatedAccount{Username: r.Username, IP: r.Ip, Events: r.Events})
+	}
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,352 @@
+// routes for administrating the server
+package server
+
+import (
+	"api/db"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notnil/chess"
+)
+
+// AdminMiddleware only lets admins through. It must run after AuthApiKeyMiddleware.
+func (s Server) AdminMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if usernameOf(c) == "" {
+			return c.JSON(http.StatusUnauthorized, REASON_UNAUTHORIZED)
+		}
+		if !isAdmin(c) {
+			return c.JSON(http.StatusForbidden, REASON_NOT_ADMIN)
+		}
+		return next(c)
+	}
+}
+
+// AdminUser is a user account as seen by admins
+type AdminUser struct {
+	User
+	IsAdmin bool `json:"isAdmin" example:"false"`
+	IsGuest bool `json:"isGuest" example:"false"`
+	Banned  bool `json:"banned" example:"false"`
+	// when the user deleted their account, if they did
+	DeletedAt *time.Time `json:"deletedAt,omitempty" format:"date-time"`
+}
+
+func AdminUserFromDbUser(user db.User) AdminUser {
+	u := AdminUser{
+		User:    UserFromDbUser(user),
+		IsAdmin: user.IsAdmin,
+		IsGuest: user.IsGuest,
+		Banned:  user.Banned,
+	}
+	if user.DeletedAt.Valid {
+		u.DeletedAt = &user.DeletedAt.Time
+	}
+	return u
+}
+
+// pagination reads the limit and offset query parameters.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func pagination(c echo.Context) (limit, offset int64, err error) {
+	limit = 50
+	if l := c.QueryParam("limit"); l != "" {
+		limit, err = strconv.ParseInt(l, 10, 64)
+		if err != nil || limit < 1 {
+			return 0, 0, echo.NewHTTPError(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, "limit must be a positive number"))
+		}
+		limit = min(limit, 500)
+	}
+	if o := c.QueryParam("offset"); o != "" {
+		offset, err = strconv.ParseInt(o, 10, 64)
+		if err != nil || offset < 0 {
+			return 0, 0, echo.NewHTTPError(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, "offset must be a positive number"))
+		}
+	}
+	return limit, offset, nil
+}
+
+// @Summary		List users
+// @Description	Lists every user, newest first.
+// @Tags			admin
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey of an admin in the format Bearer: apiKey"
+// @Param			limit			query		int		false	"default 50, max 500"
+// @Param			offset			query		int		false	"default 0"
+// @Success		200				{array}		AdminUser
+// @Failure		400				{object}	ErrorReason	"Invalid query"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Not an admin"
+// @Failure		500				{object}	ErrorReason
+// @Router			/admin/users [get]
+func (s Server) AdminListUsers(c echo.Context) error {
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return err
+	}
+	users, err := s.DB.ListUsers(c.Request().Context(), db.ListUsersParams{Limit: limit, Offset: offset})
+	if err != nil {
+		slog.Error("failed to list users", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	result := make([]AdminUser, 0, len(users))
+	for _, u := range users {
+		result = append(result, AdminUserFromDbUser(u))
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary		Ban a user
+// @Description	Banned users cannot log in or use their api key.
+// @Tags			admin
+// @Produce		json
+// @Param			Authorization	header		string		true	"Must contain ApiKey of an admin in the format Bearer: apiKey"
+// @Param			username		path		string		true	"Username"
+// @Success		200				{object}	string		"banned"
+// @Failure		400				{object}	ErrorReason	"Cannot ban yourself"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Not an admin"
+// @Failure		404				{object}	ErrorReason	"User not found"
+// @Failure		500				{object}	ErrorReason
+// @Router			/admin/users/{username}/ban [post]
+func (s Server) AdminBanUser(c echo.Context) error {
+	user, err := s.userFromParam(c)
+	if err != nil {
+		return err
+	}
+	if user.Username == usernameOf(c) {
+		return c.JSON(http.StatusBadRequest, Reason(CODE_CANNOT_TARGET_SELF, "Cannot ban yourself"))
+	}
+	if _, err := s.DB.SetUserBanned(c.Request().Context(), db.SetUserBannedParams{Banned: true, Uid: user.Uid}); err != nil {
+		slog.Error("failed to ban user", "username", user.Username, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	s.audit(c, AUDIT_ADMIN_BAN, usernameOf(c), user.Username, "")
+	return c.JSON(http.StatusOK, "banned")
+}
+
+// @Summary	Unban a user
+// @Tags		admin
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey of an admin in the format Bearer: apiKey"
+// @Param		username		path		string	true	"Username"
+// @Success	200				{object}	string	"unbanned"
+// @Failure	401				{object}	ErrorReason
+// @Failure	403				{object}	ErrorReason	"Not an admin"
+// @Failure	404				{object}	ErrorReason	"User not found"
+// @Failure	500				{object}	ErrorReason
+// @Router		/admin/users/{username}/ban [delete]
+func (s Server) AdminUnbanUser(c echo.Context) error {
+	user, err := s.userFromParam(c)
+	if err != nil {
+		return err
+	}
+	if _, err := s.DB.SetUserBanned(c.Request().Context(), db.SetUserBannedParams{Banned: false, Uid: user.Uid}); err != nil {
+		slog.Error("failed to unban user", "username", user.Username, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	s.audit(c, AUDIT_ADMIN_UNBAN, usernameOf(c), user.Username, "")
+	return c.JSON(http.StatusOK, "unbanned")
+}
+
+// @Summary		Delete a match
+// @Description	Deletes an ongoing match without storing a result. Players receive an `aborted` event.
+// @Tags			admin
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey of an admin in the format Bearer: apiKey"
+// @Param			id				path		string	true	"Match ID"
+// @Success		200				{object}	string	"deleted"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Not an admin"
+// @Failure		404				{object}	ErrorReason	"Match not found"
+// @Router			/admin/matches/{id} [delete]
+func (s Server) AdminDeleteMatch(c echo.Context) error {
+	if !s.GameStorage.DeleteMatch(c.Param("id")) {
+		return c.JSON(http.StatusNotFound, Reason(CODE_MATCH_NOT_FOUND, "match not found"))
+	}
+	s.audit(c, AUDIT_ADMIN_DELETE, usernameOf(c), c.Param("id"), "")
+	return c.JSON(http.StatusOK, "deleted")
+}
+
+type AdjudicateRequest struct {
+	Result string `json:"result" enums:"white,black,draw" example:"white" validate:"required,oneof=white black draw"`
+}
+
+// @Summary		Decide the result of a match
+// @Description	Ends an ongoing match with the given result. Players receive an `adjudicated` event.
+// @Tags			admin
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header		string				true	"Must contain ApiKey of an admin in the format Bearer: apiKey"
+// @Param			id				path		string				true	"Match ID"
+// @Param			payload			body		AdjudicateRequest	true	"winner"
+// @Success		200				{object}	string				"adjudicated"
+// @Failure		400				{object}	ErrorReason			"Invalid json body / invalid result"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Not an admin"
+// @Failure		404				{object}	ErrorReason	"Match not found"
+// @Failure		409				{object}	ErrorReason	"Game already ended"
+// @Router			/admin/matches/{id}/adjudicate [post]
+func (s Server) AdminAdjudicateMatch(c echo.Context) error {
+	var req AdjudicateRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	var outcome chess.Outcome
+	switch req.Result {
+	case "white":
+		outcome = chess.WhiteWon
+	case "black":
+		outcome = chess.BlackWon
+	case "draw":
+		outcome = chess.Draw
+	}
+	match, ok := s.GameStorage.GetMatch(c.Param("id"))
+	if !ok {
+		return c.JSON(http.StatusNotFound, Reason(CODE_MATCH_NOT_FOUND, "match not found"))
+	}
+	if !match.Adjudicate(outcome) {
+		return c.JSON(http.StatusConflict, Reason(CODE_GAME_OVER, "Game already ended"))
+	}
+	s.audit(c, AUDIT_ADMIN_ADJUDICATE, usernameOf(c), match.ID, req.Result)
+	return c.JSON(http.StatusOK, "adjudicated")
+}
+
+// ServerStats is an overview of the server's load
+type ServerStats struct {
+	ActiveMatches int `json:"activeMatches" example:"12"`
+	// users with at least one open event stream
+	ConnectedUsers int `json:"connectedUsers" example:"20"`
+	// match events players fell too far behind to get, since the server started
+	DroppedEvents int64 `json:"droppedEvents" example:"0"`
+	// streams that were ended with a resync event because they fell behind
+	Resyncs int64 `json:"resyncs" example:"0"`
+	// moves waiting to be written to the move log
+	QueuedMoves int `json:"queuedMoves" example:"3"`
+	// moves that were never written to the move log, since the server started
+	DroppedMoves int64 `json:"droppedMoves" example:"0"`
+	// board images served from the cache, and drawn because they were not in it, since the server started
+	BoardImageCacheHits   int64 `json:"boardImageCacheHits" example:"240"`
+	BoardImageCacheMisses int64 `json:"boardImageCacheMisses" example:"31"`
+	// webhook events that did not fit in the queue, since the server started
+	DroppedWebhookEvents int64 `json:"droppedWebhookEvents" example:"0"`
+}
+
+// @Summary	Get server statistics
+// @Tags		admin
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey of an admin in the format Bearer: apiKey"
+// @Success	200				{object}	ServerStats
+// @Failure	401				{object}	ErrorReason
+// @Failure	403				{object}	ErrorReason	"Not an admin"
+// @Router		/admin/stats [get]
+func (s Server) AdminStats(c echo.Context) error {
+	dropped, resyncs := s.GameStorage.EventStats()
+	queuedMoves, droppedMoves := s.MoveLog.Stats()
+	imageHits, imageMisses := s.BoardImages.Stats()
+	return c.JSON(http.StatusOK, ServerStats{
+		ActiveMatches:  s.GameStorage.Count(),
+		ConnectedUsers: s.Presence.OnlineCount(),
+		DroppedEvents:  dropped,
+		Resyncs:        resyncs,
+		QueuedMoves:    queuedMoves,
+		DroppedMoves:   droppedMoves,
+
+		BoardImageCacheHits:   imageHits,
+		BoardImageCacheMisses: imageMisses,
+		DroppedWebhookEvents:  s.Webhooks.Dropped(),
+	})
+}
+
+// Report is a user report or an incident found by the word filter or the fair-play analysis
+type Report struct {
+	ID int64 `json:"id" example:"1"`
+	// empty when reported automatically
+	Reporter  string    `json:"reporter,omitempty" example:"JaneDoe"`
+	Reported  string    `json:"reported" example:"JohnDoe"`
+	MatchID   string    `json:"matchId,omitempty" example:"AB2C21"`
+	Category  string    `json:"category" example:"chat"`
+	Details   string    `json:"details" example:"insulted me in chat"`
+	Resolved  bool      `json:"resolved" example:"false"`
+	CreatedAt time.Time `json:"createdAt" format:"date-time"`
+}
+
+// @Summary		List reports
+// @Description	Lists reports made by users, by the word filter and by the fair-play analysis, newest first.
+// @Tags			admin
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey of an admin in the format Bearer: apiKey"
+// @Param			resolved		query		bool	false	"include resolved reports"
+// @Param			limit			query		int		false	"default 50, max 500"
+// @Param			offset			query		int		false	"default 0"
+// @Success		200				{array}		Report
+// @Failure		400				{object}	ErrorReason	"Invalid query"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Not an admin"
+// @Failure		500				{object}	ErrorReason
+// @Router			/admin/reports [get]
+func (s Server) AdminListReports(c echo.Context) error {
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	reports, err := s.DB.ListReports(ctx, db.ListReportsParams{
+		IncludeResolved: c.QueryParam("resolved") == "true",
+		Limit:           limit,
+		Offset:          offset,
+	})
+	if err != nil {
+		slog.Error("failed to list reports", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	names := usernameCache{s: s, names: map[int64]string{}}
+	result := make([]Report, 0, len(reports))
+	for _, r := range reports {
+		report := Report{
+			ID:        r.ID,
+			Reported:  r.ReportedUsername,
+			MatchID:   r.MatchID,
+			Category:  r.Category,
+			Details:   r.Details,
+			Resolved:  r.Resolved,
+			CreatedAt: r.CreatedAt,
+		}
+		if r.ReporterUid.Valid {
+			report.Reporter = names.get(ctx, r.ReporterUid.Int64)
+		}
+		result = append(result, report)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary	Mark a report as resolved
+// @Tags		admin
+// @Produce	json
+// @Param		Authorization	header		string		true	"Must contain ApiKey of an admin in the format Bearer: apiKey"
+// @Param		id				path		int			true	"Report ID"
+// @Success	200				{object}	string		"resolved"
+// @Failure	400				{object}	ErrorReason	"Invalid id"
+// @Failure	401				{object}	ErrorReason
+// @Failure	403				{object}	ErrorReason	"Not an admin"
+// @Failure	404				{object}	ErrorReason	"Report not found"
+// @Failure	500				{object}	ErrorReason
+// @Router		/admin/reports/{id}/resolve [post]
+func (s Server) AdminResolveReport(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, "invalid report id"))
+	}
+	resolved, err := s.DB.ResolveReport(c.Request().Context(), id)
+	if err != nil {
+		slog.Error("failed to resolve report", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	if resolved == 0 {
+		return c.JSON(http.StatusNotFound, Reason(CODE_REPORT_NOT_FOUND, "report not found"))
+	}
+	s.audit(c, AUDIT_ADMIN_RESOLVE, usernameOf(c), "", "report "+c.Param("id"))
+	return c.JSON(http.StatusOK, "resolved")
+}
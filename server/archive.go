@@ -0,0 +1,86 @@
+// storing finished matches in the database
+package server
+
+import (
+	"api/db"
+	"api/server/game"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+// gameOver is the game storage's OnGameOver hook
+func (s Server) gameOver(m *game.Match) {
+	s.MoveLog.EndMatch(m)
+	s.Watchers.Publish(m.ID, MatchUpdate{Result: string(m.Outcome())})
+	s.Webhooks.Send(m, WebhookEvent{Event: WebhookGameOver, Result: string(m.Outcome())})
+	s.archiveMatch(m)
+	s.tournamentGameOver(m)
+	s.teamGameOver(m)
+	s.simulGameOver(m)
+}
+
+// archiveMatch stores a finished match in the games table.
+func (s Server) archiveMatch(m *game.Match) {
+	white, ok := m.GetPlayerWithColor(chess.White)
+	if !ok {
+		return
+	}
+	black, ok := m.GetPlayerWithColor(chess.Black)
+	if !ok {
+		// nobody to play against, nothing to archive
+		return
+	}
+	ctx := context.Background()
+	whiteUser, err := s.DB.GetUserByUsername(ctx, white.Username)
+	if err != nil {
+		slog.Warn("cannot archive match, white player not found", "match", m.ID, "error", err)
+		return
+	}
+	blackUser, err := s.DB.GetUserByUsername(ctx, black.Username)
+	if err != nil {
+		slog.Warn("cannot archive match, black player not found", "match", m.ID, "error", err)
+		return
+	}
+
+	result := resultFromOutcome(m.Outcome())
+	// only the moves are stored, player names are added on export
+	// so that deleted accounts do not leave their username behind.
+	moves := m.PGN()
+	opening, _ := m.Opening()
+
+	game, err := s.DB.StoreGame(ctx, db.StoreGameParams{
+		WhiteUid:   whiteUser.Uid,
+		BlackUid:   blackUser.Uid,
+		Result:     result,
+		Moves:      moves,
+		FinishedAt: time.Now().UTC(),
+		Eco:        opening.ECO,
+		Opening:    opening.Name,
+		MatchID:    m.ID,
+		Method:     resultMethod(m),
+		FinalFen:   m.Position().String(),
+	})
+	if err != nil {
+		slog.Error("failed to archive match", "match", m.ID, "error", err)
+		return
+	}
+	s.addToExplorer(ctx, game.ID, m.UCIMoves(), result)
+	if m.Rated {
+		s.queueFairPlay(ctx, game.ID, m.ID)
+	}
+}
+
+// convert an outcome into the games.result column
+func resultFromOutcome(outcome chess.Outcome) string {
+	switch outcome {
+	case chess.WhiteWon:
+		return "white"
+	case chess.BlackWon:
+		return "black"
+	default:
+		return "draw"
+	}
+}
@@ -0,0 +1,133 @@
+// recording security relevant events
+package server
+
+import (
+	"api/db"
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// actions stored in the audit log
+const (
+	AUDIT_LOGIN            = "login"
+	AUDIT_LOGIN_FAILED     = "login.failed"
+	AUDIT_ACCOUNT_LOCKED   = "account.locked"
+	AUDIT_ACCOUNT_CREATED  = "account.created"
+	AUDIT_ACCOUNT_UPGRADED = "account.upgraded"
+	AUDIT_ACCOUNT_BOT      = "account.bot"
+	AUDIT_ACCOUNT_DELETED  = "account.deleted"
+	AUDIT_ACCOUNT_RESTORED = "account.restored"
+	AUDIT_ACCOUNT_PURGED   = "account.purged"
+	AUDIT_API_KEY_ISSUED   = "api_key.issued"
+	AUDIT_SIGNUP_BLOCKED   = "signup.blocked"
+	AUDIT_ADMIN_BAN        = "admin.ban"
+	AUDIT_ADMIN_UNBAN      = "admin.unban"
+	AUDIT_ADMIN_GRANT      = "admin.grant"
+	AUDIT_ADMIN_DELETE     = "admin.delete_match"
+	AUDIT_ADMIN_ADJUDICATE = "admin.adjudicate"
+	AUDIT_ADMIN_RESOLVE    = "admin.resolve_report"
+	AUDIT_ADMIN_BACKUP     = "admin.backup"
+)
+
+// audit records an action performed by actor during the request c.
+// Failing to write the audit log does not fail the request.
+func (s Server) audit(c echo.Context, action, actor, target, details string) {
+	s.auditWithIP(c.Request().Context(), action, actor, target, c.RealIP(), details)
+}
+
+// auditWithIP records an action that did not come from a request, like the janitor purging accounts.
+func (s Server) auditWithIP(ctx context.Context, action, actor, target, ip, details string) {
+	err := s.DB.CreateAuditEvent(ctx, db.CreateAuditEventParams{
+		Action:  action,
+		Actor:   actor,
+		Target:  target,
+		Ip:      ip,
+		Details: details,
+	})
+	if err != nil {
+		slog.Error("failed to write audit log", "action", action, "actor", actor, "target", target, "error", err)
+	}
+}
+
+// AuditEvent is an entry in the audit log
+type AuditEvent struct {
+	ID     int64  `json:"id" example:"1"`
+	Action string `json:"action" example:"login.failed"`
+	// empty if the actor is unknown, like the server itself
+	Actor string `json:"actor,omitempty" example:"JohnDoe"`
+	// username or match id the action was performed on
+	Target    string    `json:"target,omitempty" example:"JohnDoe"`
+	IP        string    `json:"ip,omitempty" example:"203.0.113.7"`
+	Details   string    `json:"details,omitempty" example:"wrong password"`
+	CreatedAt time.Time `json:"createdAt" format:"date-time"`
+}
+
+// @Summary		List the audit log
+// @Description	Lists security relevant events, newest first.
+// @Description	Actions: login, login.failed, account.locked, account.created, account.upgraded, account.deleted, account.restored, account.purged,
+// @Description	api_key.issued, signup.blocked, admin.ban, admin.unban, admin.grant, admin.delete_match, admin.adjudicate, admin.resolve_report
+// @Tags			admin
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey of an admin in the format Bearer: apiKey"
+// @Param			action			query		string	false	"only events with this action"
+// @Param			actor			query		string	false	"only events performed by this username"
+// @Param			target			query		string	false	"only events performed on this username or match"
+// @Param			since			query		string	false	"only events at or after this time (RFC 3339)"
+// @Param			until			query		string	false	"only events before this time (RFC 3339)"
+// @Param			limit			query		int		false	"default 50, max 500"
+// @Param			offset			query		int		false	"default 0"
+// @Success		200				{array}		AuditEvent
+// @Failure		400				{object}	ErrorReason	"Invalid query"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Not an admin"
+// @Failure		500				{object}	ErrorReason
+// @Router			/admin/audit [get]
+func (s Server) AdminListAuditLog(c echo.Context) error {
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return err
+	}
+	params := db.ListAuditEventsParams{
+		Action: c.QueryParam("action"),
+		Actor:  c.QueryParam("actor"),
+		Target: c.QueryParam("target"),
+		Until:  time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC),
+		Limit:  limit,
+		Offset: offset,
+	}
+	if since := c.QueryParam("since"); since != "" {
+		if params.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			return c.JSON(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, "since must be an RFC 3339 time"))
+		}
+	}
+	if until := c.QueryParam("until"); until != "" {
+		if params.Until, err = time.Parse(time.RFC3339, until); err != nil {
+			return c.JSON(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, "until must be an RFC 3339 time"))
+		}
+	}
+	// created_at is stored in UTC
+	params.Since, params.Until = params.Since.UTC(), params.Until.UTC()
+
+	events, err := s.DB.ListAuditEvents(c.Request().Context(), params)
+	if err != nil {
+		slog.Error("failed to list audit log", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	result := make([]AuditEvent, 0, len(events))
+	for _, e := range events {
+		result = append(result, AuditEvent{
+			ID:        e.ID,
+			Action:    e.Action,
+			Actor:     e.Actor,
+			Target:    e.Target,
+			IP:        e.Ip,
+			Details:   e.Details,
+			CreatedAt: e.CreatedAt,
+		})
+	}
+	return c.JSON(http.StatusOK, result)
+}
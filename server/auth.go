@@ -2,18 +2,25 @@ package server
 
 import (
 	"api/db"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // AuthApiKeyMiddleware checks the Authorization header for a Bearer <api key>.
 // It sets the context's username field to the username of whom the key belongs to.
 // Otherwise, username is an empty string.
+// The guest field is set to true if the key belongs to a guest account.
+// The admin field is set to true if the key belongs to an admin.
 func (s Server) AuthApiKeyMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		// extract Authorization header
@@ -21,47 +28,59 @@ func (s Server) AuthApiKeyMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 		if ah == "" {
 			// unauthorized user, set username to empty string
 			c.Set("username", "")
+			c.Set("guest", false)
+			c.Set("admin", false)
 			return next(c)
 		}
-		// seperate "Bearer" from api key
-		bearerJwt := strings.Split(ah, " ")
-		if len(bearerJwt) != 2 {
-			return c.JSON(http.StatusForbidden, REASON_INVALID_AUTH_HEADER)
-		}
-		// Bearer xxxx.yyyy.zzzz
-		// get rid of the "Bearer "
-		encodedToken := bearerJwt[1]
-		// parse encoded token
-		token, err := jwt.Parse(encodedToken, func(t *jwt.Token) (any, error) {
-			return s.JwtSecret, nil
-		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()})) // failed to parse?
+		user, err := s.authenticate(c.Request().Context(), ah)
 		if err != nil {
-			return c.JSON(http.StatusUnauthorized, REASON_INVALID_AUTH_HEADER)
+			return err
 		}
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			// valid token, continue
-			username := claims["jti"].(string)
-			user, err := s.DB.GetUserByUsername(c.Request().Context(), username)
-			if err != nil {
-				return c.JSON(http.StatusForbidden, Reason("user does not exist"))
-			}
-			// check if token has expired
-			if user.ApiKey != encodedToken {
-				return c.JSON(http.StatusForbidden, Reason("Key has expired"))
-			}
-			_, ok := s.verifyApiKey(encodedToken)
-			if !ok {
-				return c.JSON(http.StatusForbidden, Reason("Key has expired"))
-			}
 
-			c.Set("username", username)
-			return next(c)
-		} else {
-			panic("Failed to decode jwt into struct. This means the jwt we are sending is wrong")
-		}
+		c.Set("username", user.Username)
+		c.Set("guest", user.IsGuest)
+		c.Set("admin", user.IsAdmin)
+		return next(c)
 	}
 }
 
+// authenticate gets the user whose api key is in authorization, which is "Bearer <api key>".
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (s Server) authenticate(ctx context.Context, authorization string) (db.User, error) {
+	// seperate "Bearer" from api key
+	bearerJwt := strings.Fields(authorization)
+	if len(bearerJwt) != 2 {
+		return db.User{}, echo.NewHTTPError(http.StatusForbidden, REASON_INVALID_AUTH_HEADER)
+	}
+	// Bearer xxxx.yyyy.zzzz
+	// get rid of the "Bearer "
+	encodedToken := bearerJwt[1]
+	// check signature, issuer, audience and expiry
+	username, ok := s.verifyApiKey(encodedToken)
+	if !ok {
+		return db.User{}, echo.NewHTTPError(http.StatusUnauthorized, REASON_INVALID_AUTH_HEADER)
+	}
+	user, err := s.DB.GetUserByUsername(ctx, username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.User{}, echo.NewHTTPError(http.StatusForbidden, Reason(CODE_USER_NOT_FOUND, "user does not exist"))
+	}
+	if err != nil {
+		slog.Error("failed to get user of api key", "username", username, "error", err)
+		return db.User{}, echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	if user.DeletedAt.Valid {
+		return db.User{}, echo.NewHTTPError(http.StatusForbidden, Reason(CODE_ACCOUNT_DELETED, "Account is scheduled for deletion. Log in to restore it"))
+	}
+	if user.Banned {
+		return db.User{}, echo.NewHTTPError(http.StatusForbidden, REASON_BANNED)
+	}
+	// only the latest key of a user is valid
+	if user.ApiKey != encodedToken {
+		return db.User{}, echo.NewHTTPError(http.StatusForbidden, Reason(CODE_API_KEY_EXPIRED, "Key has expired"))
+	}
+	return user, nil
+}
+
 // GetApiKeyTryRenew accepts username and password, and returns an api key.
 // Accounts can be created from /users
 //
@@ -69,6 +88,9 @@ func (s Server) AuthApiKeyMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 //	@Description	Log into an account using provided username and password. And get an API key.
 //	@Description	Username can be between 3-20 characters.
 //	@Description	Password must be at least 3 characters.
+//	@Description	Logging into an account that is scheduled for deletion restores it.
+//	@Description	After 3 failed attempts, each attempt must wait twice as long as the previous one.
+//	@Description	After 10 failed attempts the account is locked for 15 minutes.
 //
 //	@Tags			auth
 //	@Accept			json
@@ -76,35 +98,59 @@ func (s Server) AuthApiKeyMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 //	@Param			payload	body		UserCredentials	true	"Login Account"
 //	@Success		201		{object}	ApiKeyResponse
 //	@Failure		401		{object}	ErrorReason	"Invalid username/password"
+//	@Failure		403		{object}	ErrorReason	"Account banned"
+//	@Failure		423		{object}	ErrorReason	"Account locked"
+//	@Failure		429		{object}	ErrorReason	"Too many failed attempts, see the Retry-After header"
 //	@Failure		500		{object}	ErrorReason
 //	@Router			/auth/login [post]
 func (s Server) GetApiKeyTryRenew(c echo.Context) error {
 	var req UserCredentials
 
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, REASON_JSON_SYNTAX_ERROR)
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
 	}
 
-	// validate username and password
-	if err := ValidateUsernameAndPassword(req.Username, req.Password); err != nil {
-		return c.JSON(http.StatusBadRequest, Reason(err.Error()))
+	ip := c.RealIP()
+	if wait, locked := s.LoginThrottle.Check(req.Username, ip); locked {
+		c.Response().Header().Set("Retry-After", retryAfterSeconds(wait))
+		return c.JSON(http.StatusLocked, Reason(CODE_ACCOUNT_LOCKED, "Account is locked because of too many failed logins, try again later"))
+	} else if wait > 0 {
+		c.Response().Header().Set("Retry-After", retryAfterSeconds(wait))
+		return c.JSON(http.StatusTooManyRequests, Reason(CODE_LOGIN_THROTTLED, "Too many failed logins, try again later"))
 	}
 
 	// get user
 	user, err := s.DB.GetUserByUsername(c.Request().Context(), req.Username)
 	if err != nil {
+		s.loginFailed(c, req.Username, "unknown user")
 		return c.JSON(http.StatusUnauthorized, REASON_INVALID_CREDENTIALS)
 	}
 	// validate password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+	ok, needsRehash := s.Passwords.Verify(user.PasswordHash, req.Password)
+	if !ok {
+		s.loginFailed(c, req.Username, "wrong password")
 		return c.JSON(http.StatusUnauthorized, REASON_INVALID_CREDENTIALS)
 	}
+	s.LoginThrottle.Succeed(req.Username, ip)
+	if user.Banned {
+		s.audit(c, AUDIT_LOGIN_FAILED, "", user.Username, "banned")
+		return c.JSON(http.StatusForbidden, REASON_BANNED)
+	}
+	if needsRehash {
+		s.rehashPassword(c.Request().Context(), user, req.Password)
+	}
 	username, ok := s.verifyApiKey(user.ApiKey)
-	if username != user.Username {
-		slog.Warn("Username stored in api key does not match user we got from database. This should never happen.")
-		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	if user.DeletedAt.Valid {
+		// logging in during the grace period cancels the deletion
+		if err := s.DB.RestoreUser(c.Request().Context(), user.Uid); err != nil {
+			slog.Warn("could not restore user", "error", err)
+			return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+		}
+		s.audit(c, AUDIT_ACCOUNT_RESTORED, user.Username, user.Username, "")
 	}
-	if !ok { // api key expired
+	s.audit(c, AUDIT_LOGIN, user.Username, user.Username, "")
+	// renew keys that expired, were signed with an old secret or lack the current claims
+	if !ok || username != user.Username {
 		user.ApiKey = s.newApiKey(user.Username)
 		err := s.DB.UpdateUserAPIKey(c.Request().Context(), db.UpdateUserAPIKeyParams{
 			ApiKey:   user.ApiKey,
@@ -114,6 +160,83 @@ func (s Server) GetApiKeyTryRenew(c echo.Context) error {
 			slog.Warn("could not update api key for user", "error", err)
 			return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
 		}
+		s.audit(c, AUDIT_API_KEY_ISSUED, user.Username, user.Username, "renewed on login")
 	}
 	return c.JSON(http.StatusOK, ApiKeyResponse{user.ApiKey})
 }
+
+// GuestResponse is returned when a temporary guest account is created.
+type GuestResponse struct {
+	Username string `json:"username" example:"Guest_4F2KQ7ZD"`
+	ApiKey   string `json:"apiKey"`
+	// when the guest account will be deleted, unless it is upgraded
+	ExpiresAt time.Time `json:"expiresAt" format:"date-time"`
+}
+
+// CreateGuest creates a temporary guest account for unauthenticated users.
+//
+//	@Summary		Play as a guest without creating an account.
+//	@Description	Creates a temporary guest account and returns its username and API key.
+//	@Description	Guests can only play casual (unrated) matches.
+//	@Description	Guest accounts are deleted after 24 hours, unless they are upgraded to a full account using `POST /users/upgrade`.
+//
+//	@Description	If signup challenges are enabled, a solved challenge from `GET /auth/challenge` is required.
+//
+//	@Tags			auth
+//	@Produce		json
+//	@Param			X-Challenge			header		string	false	"challenge from GET /auth/challenge"
+//	@Param			X-Challenge-Nonce	header		string	false	"nonce that solves the challenge"
+//	@Success		201					{object}	GuestResponse
+//	@Failure		403					{object}	ErrorReason	"Missing or unsolved challenge"
+//	@Failure		429					{object}	ErrorReason	"Too many accounts created from this IP"
+//	@Failure		500					{object}	ErrorReason
+//	@Router			/auth/guest [post]
+func (s Server) CreateGuest(c echo.Context) error {
+	// Guest_ + 8 random characters fits in the 20 character username limit
+	username := "Guest_" + rand.Text()[:8]
+	user, err := s.DB.CreateGuestUser(c.Request().Context(), db.CreateGuestUserParams{
+		Username: username,
+		ApiKey:   s.newApiKeyWithExpiry(username, GUEST_LIFETIME),
+	})
+	if err != nil {
+		slog.Error("failed to create guest user", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	s.audit(c, AUDIT_ACCOUNT_CREATED, user.Username, user.Username, "guest")
+	return c.JSON(http.StatusCreated, GuestResponse{
+		Username:  user.Username,
+		ApiKey:    user.ApiKey,
+		ExpiresAt: user.CreatedAt.Add(GUEST_LIFETIME),
+	})
+}
+
+// loginFailed records a failed login attempt
+func (s Server) loginFailed(c echo.Context, username, reason string) {
+	ip := c.RealIP()
+	slog.Warn("failed login", "username", username, "ip", ip)
+	s.audit(c, AUDIT_LOGIN_FAILED, "", username, reason)
+	if s.LoginThrottle.Fail(username, ip) {
+		slog.Warn("account locked after failed logins", "username", username, "ip", ip,
+			"duration", LOGIN_LOCKOUT_DURATION)
+		s.audit(c, AUDIT_ACCOUNT_LOCKED, "", username, "too many failed logins")
+	}
+}
+
+// Retry-After header value, rounded up to whole seconds
+func retryAfterSeconds(wait time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(wait.Seconds())))
+}
+
+// rehashPassword upgrades the stored hash to the configured algorithm and cost.
+// Failing to rehash does not fail the login.
+func (s Server) rehashPassword(ctx context.Context, user db.User, password string) {
+	hash, err := s.Passwords.Hash(password)
+	if err != nil {
+		slog.Warn("failed to rehash password", "username", user.Username, "error", err)
+		return
+	}
+	_, err = s.DB.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{PasswordHash: hash, Uid: user.Uid})
+	if err != nil {
+		slog.Warn("failed to store rehashed password", "username", user.Username, "error", err)
+	}
+}
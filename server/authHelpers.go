@@ -1,18 +1,36 @@
 package server
 
 import (
+	"context"
 	"log"
+	"log/slog"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// how long guest accounts (and their api keys) live before they are deleted
+const GUEST_LIFETIME = time.Hour * 24
+
 func (s Server) newApiKey(username string) string {
 	const expiry = time.Hour * 24 * 30
+	return s.newApiKeyWithExpiry(username, expiry)
+}
+
+// issuer and audience of api keys. Keys minted by other services with the same secret are rejected.
+const (
+	JWT_ISSUER   = "chess-api"
+	JWT_AUDIENCE = "chess-api"
+)
 
+func (s Server) newApiKeyWithExpiry(username string, expiry time.Duration) string {
+	now := time.Now()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		ExpiresAt: &jwt.NumericDate{Time: time.Now().Add(expiry)},
-		ID:        username,
+		Issuer:    JWT_ISSUER,
+		Subject:   username,
+		Audience:  jwt.ClaimStrings{JWT_AUDIENCE},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
 	})
 	signedToken, err := token.SignedString(s.JwtSecret)
 	if err != nil {
@@ -21,21 +39,30 @@ func (s Server) newApiKey(username string) string {
 	return signedToken
 }
 
-// check if api key has expired, and return username of the owner
+// check if api key is valid and has not expired, and return username of the owner
 func (s Server) verifyApiKey(key string) (username string, ok bool) {
-	token, err := jwt.ParseWithClaims(key, &jwt.RegisteredClaims{}, func(t *jwt.Token) (any, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(key, &claims, func(t *jwt.Token) (any, error) {
 		return s.JwtSecret, nil
-	})
-	if err != nil {
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(JWT_ISSUER),
+		jwt.WithAudience(JWT_AUDIENCE),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || claims.Subject == "" {
 		return "", false
 	}
-	claims, ok := token.Claims.(*jwt.RegisteredClaims)
-	if !ok {
-		panic("unable to cast to RegisteredClaims. Signature changed")
-	}
-	// expired?
-	if time.Since(claims.ExpiresAt.Time) > 0 {
-		return "", false
+	return claims.Subject, true
+}
+
+// deletes guest accounts that have outlived GUEST_LIFETIME
+func (s Server) purgeExpiredGuests(ctx context.Context) {
+	deleted, err := s.DB.DeleteExpiredGuests(ctx, time.Now().UTC().Add(-GUEST_LIFETIME))
+	if err != nil {
+		slog.Warn("failed to delete expired guests", "error", err)
+	} else if deleted > 0 {
+		slog.Info("deleted expired guests", "count", deleted)
 	}
-	return claims.ID, true
 }
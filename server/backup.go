@@ -0,0 +1,175 @@
+// consistent copies of the database, taken while the server is running
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// where POST /admin/backup writes backups.
+// It can be changed with the BACKUP_DIR environment variable.
+const DEFAULT_BACKUP_DIR = "backups"
+
+// Backup is a copy of the database stored on the server
+type Backup struct {
+	// file name in the backup directory
+	Name      string    `json:"name" example:"chess-20261015T120000.000Z.db"`
+	Size      int64     `json:"size" example:"131072"`
+	CreatedAt time.Time `json:"createdAt" format:"date-time"`
+}
+
+// backupTo writes a consistent copy of the database to path, which must not exist.
+// Writes made during the backup wait for it to finish, reads don't.
+// Ongoing matches live in memory, so only accounts and finished matches are included.
+func (s Server) backupTo(ctx context.Context, path string) error {
+	_, err := s.SQL.ExecContext(ctx, "VACUUM INTO ?", path)
+	return err
+}
+
+// @Summary		Back up the database to a file
+// @Description	Writes a consistent copy of the database to the backup directory on the server, without stopping it.
+// @Description	Accounts, archived games and the audit log are included, ongoing matches are not.
+// @Description	Start the server with `-restore <file>` to restore it.
+// @Tags			admin
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey of an admin in the format Bearer: apiKey"
+// @Success		201				{object}	Backup
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Not an admin"
+// @Failure		500				{object}	ErrorReason
+// @Router			/admin/backup [post]
+func (s Server) AdminCreateBackup(c echo.Context) error {
+	if err := os.MkdirAll(s.BackupDir, 0o700); err != nil {
+		slog.Error("failed to create backup directory", "dir", s.BackupDir, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	now := time.Now().UTC()
+	name := "chess-" + now.Format("20060102T150405.000Z") + ".db"
+	path := filepath.Join(s.BackupDir, name)
+	if err := s.backupTo(c.Request().Context(), path); err != nil {
+		slog.Error("failed to back up database", "path", path, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		slog.Error("failed to stat backup", "path", path, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	s.audit(c, AUDIT_ADMIN_BACKUP, usernameOf(c), "", name)
+	slog.Info("database backed up", "path", path, "size", info.Size())
+	return c.JSON(http.StatusCreated, Backup{Name: name, Size: info.Size(), CreatedAt: now})
+}
+
+// @Summary		Download a backup of the database
+// @Description	Responds with a consistent copy of the database, taken without stopping the server.
+// @Description	Accounts, archived games and the audit log are included, ongoing matches are not.
+// @Description	Start the server with `-restore <file>` to restore it.
+// @Tags			admin
+// @Produce		application/vnd.sqlite3
+// @Param			Authorization	header		string	true	"Must contain ApiKey of an admin in the format Bearer: apiKey"
+// @Success		200				{file}		string	"SQLite database"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Not an admin"
+// @Failure		500				{object}	ErrorReason
+// @Router			/admin/backup [get]
+func (s Server) AdminDownloadBackup(c echo.Context) error {
+	dir, err := os.MkdirTemp("", "chess-backup")
+	if err != nil {
+		slog.Error("failed to create temporary directory", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	defer os.RemoveAll(dir)
+	name := "chess-" + time.Now().UTC().Format("20060102T150405Z") + ".db"
+	path := filepath.Join(dir, name)
+	if err := s.backupTo(c.Request().Context(), path); err != nil {
+		slog.Error("failed to back up database", "path", path, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	s.audit(c, AUDIT_ADMIN_BACKUP, usernameOf(c), "", "download")
+	c.Response().Header().Set(echo.HeaderContentType, "application/vnd.sqlite3")
+	return c.Attachment(path, name)
+}
+
+// RestoreBackup replaces the database file with backup. Call it before the database is opened.
+// The replaced database is kept next to it with a .before-restore suffix.
+func RestoreBackup(ctx context.Context, backup, database string) error {
+	if err := checkBackup(ctx, backup); err != nil {
+		return fmt.Errorf("backup %s cannot be restored: %w", backup, err)
+	}
+	// copy first, so a failed copy leaves the database alone
+	restoring := database + ".restoring"
+	if err := copyFile(backup, restoring); err != nil {
+		os.Remove(restoring)
+		return fmt.Errorf("failed to copy backup: %w", err)
+	}
+	if err := os.Rename(database, database+".before-restore"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		os.Remove(restoring)
+		return fmt.Errorf("failed to move the old database away: %w", err)
+	}
+	// leftovers of the old database would be applied to the restored one
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		if err := os.Remove(database + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", database+suffix, err)
+		}
+	}
+	return os.Rename(restoring, database)
+}
+
+// checkBackup opens backup and checks that it is an intact database this version can use.
+func checkBackup(ctx context.Context, backup string) error {
+	// opening a missing file would create an empty database
+	if _, err := os.Stat(backup); err != nil {
+		return err
+	}
+	conn, err := sql.Open("sqlite", backup)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var integrity string
+	if err := conn.QueryRowContext(ctx, "PRAGMA integrity_check").Scan(&integrity); err != nil {
+		return err
+	}
+	if integrity != "ok" {
+		return fmt.Errorf("integrity check failed: %s", integrity)
+	}
+	var version int
+	if err := conn.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > SCHEMA_VERSION {
+		return fmt.Errorf("it has schema version %d, this server only knows up to %d", version, SCHEMA_VERSION)
+	}
+	return nil
+}
+
+func copyFile(from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
@@ -0,0 +1,129 @@
+// blocking other users
+package server
+
+import (
+	"api/db"
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// @Summary	List blocked users
+// @Tags		blocks
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Success	200				{array}		string	"usernames"
+// @Failure	401				{object}	ErrorReason
+// @Failure	500				{object}	ErrorReason
+// @Router		/users/me/blocks [get]
+func (s Server) ListBlocks(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	usernames, err := s.DB.ListBlockedUsernames(c.Request().Context(), user.Uid)
+	if err != nil {
+		slog.Error("failed to list blocks", "username", user.Username, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	if usernames == nil {
+		usernames = []string{}
+	}
+	return c.JSON(http.StatusOK, usernames)
+}
+
+// @Summary		Block a user
+// @Description	Blocked users cannot join your matches or send you friend requests, and you cannot join theirs.
+// @Description	Blocking a friend removes them from your friends.
+// @Tags			blocks
+// @Produce		json
+// @Param			Authorization	header		string		true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			username		path		string		true	"Username of the user to block"
+// @Success		200				{object}	string		"blocked"
+// @Failure		400				{object}	ErrorReason	"Cannot block yourself"
+// @Failure		401				{object}	ErrorReason
+// @Failure		404				{object}	ErrorReason	"User not found"
+// @Failure		500				{object}	ErrorReason
+// @Router			/users/me/blocks/{username} [post]
+func (s Server) BlockUser(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	other, err := s.userFromParam(c)
+	if err != nil {
+		return err
+	}
+	if other.Uid == user.Uid {
+		return c.JSON(http.StatusBadRequest, Reason(CODE_CANNOT_TARGET_SELF, "Cannot block yourself"))
+	}
+	ctx := c.Request().Context()
+	tx, err := s.SQL.BeginTx(ctx, nil)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	defer tx.Rollback()
+	q := s.DB.WithTx(tx)
+	err = q.CreateBlock(ctx, db.CreateBlockParams{BlockerUid: user.Uid, BlockedUid: other.Uid})
+	if err != nil {
+		slog.Error("failed to block user", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	_, err = q.DeleteFriendship(ctx, db.DeleteFriendshipParams{Uid: user.Uid, OtherUid: other.Uid})
+	if err != nil {
+		slog.Error("failed to remove friendship of blocked user", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	if err := tx.Commit(); err != nil {
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return c.JSON(http.StatusOK, "blocked")
+}
+
+// @Summary	Unblock a user
+// @Tags		blocks
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param		username		path		string	true	"Username of the user to unblock"
+// @Success	200				{object}	string	"unblocked"
+// @Failure	401				{object}	ErrorReason
+// @Failure	404				{object}	ErrorReason	"User not found / not blocked"
+// @Failure	500				{object}	ErrorReason
+// @Router		/users/me/blocks/{username} [delete]
+func (s Server) UnblockUser(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	other, err := s.userFromParam(c)
+	if err != nil {
+		return err
+	}
+	removed, err := s.DB.DeleteBlock(c.Request().Context(), db.DeleteBlockParams{
+		BlockerUid: user.Uid,
+		BlockedUid: other.Uid,
+	})
+	if err != nil {
+		slog.Error("failed to unblock user", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	if removed == 0 {
+		return c.JSON(http.StatusNotFound, Reason(CODE_NOT_BLOCKED, "User is not blocked"))
+	}
+	return c.JSON(http.StatusOK, "unblocked")
+}
+
+// blockedBetween is true if either user blocked the other.
+func (s Server) blockedBetween(ctx context.Context, username, otherUsername string) (bool, error) {
+	blocked, err := s.DB.IsBlockedBetween(ctx, db.IsBlockedBetweenParams{
+		Username:      username,
+		OtherUsername: otherUsername,
+	})
+	if err != nil {
+		slog.Error("failed to check blocks", "error", err)
+		return false, err
+	}
+	return blocked != 0, nil
+}
@@ -0,0 +1,318 @@
+// rendered board images, cached because the same positions come up again and again
+package server
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"image/color"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/notnil/chess"
+	"github.com/notnil/chess/image"
+)
+
+const (
+	// rendered images kept, about 40 KB each
+	BOARD_IMAGE_CACHE_SIZE = 512
+	// the renderer draws squares this many pixels wide, other sizes scale the image
+	DEFAULT_SQUARE_SIZE = 45
+	MIN_SQUARE_SIZE     = 16
+	MAX_SQUARE_SIZE     = 128
+	// most arrows and annotated squares one image can have
+	MAX_BOARD_ANNOTATIONS = 16
+)
+
+// colors of the highlighted squares, the alpha is their opacity
+var (
+	LAST_MOVE_COLOR  = color.RGBA{155, 199, 0, 105}
+	CHECK_COLOR      = color.RGBA{255, 0, 0, 150}
+	ANNOTATION_COLOR = color.RGBA{235, 97, 80, 204}
+)
+
+// file formats of board images
+const (
+	FORMAT_SVG = "svg"
+	FORMAT_PNG = "png"
+)
+
+// BoardImageOptions is how a board image is drawn
+type BoardImageOptions struct {
+	// FORMAT_SVG or FORMAT_PNG
+	Format   string
+	Theme    string
+	PieceSet string
+	// the color at the bottom of the board
+	Orientation chess.Color
+	// width of a square in pixels
+	SquareSize int
+	// squares highlighted as the last move, empty for none
+	LastMove []chess.Square
+	// square of the king in check, chess.NoSquare for none
+	Check chess.Square
+	// annotations drawn on top of the board
+	Squares []chess.Square
+	Arrows  [][2]chess.Square
+}
+
+// key identifies the options in the cache and in ETags
+func (o BoardImageOptions) key() string {
+	return fmt.Sprint(o.Format, o.Theme, o.PieceSet, o.Orientation, o.SquareSize, o.LastMove, o.Check, o.Squares, o.Arrows)
+}
+
+// BoardImageCache keeps the most recently used board images, keyed by the board and how it is drawn.
+type BoardImageCache struct {
+	mu   sync.Mutex
+	size int
+	// key -> element of order
+	images map[string]*list.Element
+	// *boardImage, most recently used first
+	order        *list.List
+	hits, misses int64
+}
+
+type boardImage struct {
+	key  string
+	data []byte
+}
+
+func NewBoardImageCache(size int) *BoardImageCache {
+	return &BoardImageCache{
+		size:   size,
+		images: map[string]*list.Element{},
+		order:  list.New(),
+	}
+}
+
+// Image draws board with opts, or returns the image drawn last time.
+// The returned slice must not be changed.
+func (c *BoardImageCache) Image(board *chess.Board, opts BoardImageOptions) ([]byte, error) {
+	key := board.String() + " " + opts.key()
+	c.mu.Lock()
+	if el, ok := c.images[key]; ok {
+		c.order.MoveToFront(el)
+		c.hits++
+		c.mu.Unlock()
+		return el.Value.(*boardImage).data, nil
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	// drawn without the lock, requests for the same new position may draw it twice
+	draw := drawSVG
+	if opts.Format == FORMAT_PNG {
+		draw = drawPNG
+	}
+	data, err := draw(board, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.images[key]; !ok {
+		c.images[key] = c.order.PushFront(&boardImage{key: key, data: data})
+		for c.order.Len() > c.size {
+			oldest := c.order.Back()
+			c.order.Remove(oldest)
+			delete(c.images, oldest.Value.(*boardImage).key)
+		}
+	}
+	return data, nil
+}
+
+// Stats are the images served from the cache and the images that had to be drawn, since the server started.
+func (c *BoardImageCache) Stats() (hits, misses int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+func drawSVG(board *chess.Board, opts BoardImageOptions) ([]byte, error) {
+	theme := BOARD_THEMES[opts.Theme]
+	draw := []func(*image.Encoder){
+		image.SquareColors(theme[0], theme[1]),
+		image.Perspective(opts.Orientation),
+		image.MarkSquares(LAST_MOVE_COLOR, opts.LastMove...),
+		image.MarkSquares(ANNOTATION_COLOR, opts.Squares...),
+	}
+	if opts.Check != chess.NoSquare {
+		draw = append(draw, image.MarkSquares(CHECK_COLOR, opts.Check))
+	}
+	for _, a := range opts.Arrows {
+		draw = append(draw, image.MarkArrows(image.Arrow(a[0], a[1])))
+	}
+	var buf bytes.Buffer
+	if err := image.SVG(&buf, board, draw...); err != nil {
+		return nil, err
+	}
+	svg := buf.Bytes()
+	if opts.SquareSize != DEFAULT_SQUARE_SIZE {
+		// the drawing keeps its coordinates, the viewBox scales it to the new size
+		width := 8 * DEFAULT_SQUARE_SIZE
+		svg = bytes.Replace(svg,
+			fmt.Appendf(nil, `<svg width="%d" height="%d"`, width, width),
+			fmt.Appendf(nil, `<svg width="%d" height="%d" viewBox="0 0 %d %d"`, 8*opts.SquareSize, 8*opts.SquareSize, width, width),
+			1)
+	}
+	return svg, nil
+}
+
+// invalidQuery is the error of a query parameter with a wrong value
+func invalidQuery(message string) error {
+	return echo.NewHTTPError(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, message))
+}
+
+// boardStyle is how the boards of a match are drawn, whatever the position
+type boardStyle struct {
+	BoardImageOptions
+	// whether to highlight the last move and the king in check
+	lastMove, check bool
+}
+
+// parseBoardStyle reads the query parameters all board images take: theme, pieces, orientation, size, lastMove and check.
+// The theme and piece set default to prefs.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func parseBoardStyle(c echo.Context, prefs Preferences, orientation chess.Color) (boardStyle, error) {
+	st := boardStyle{BoardImageOptions: BoardImageOptions{
+		Format:      FORMAT_SVG,
+		Theme:       prefs.BoardTheme,
+		PieceSet:    prefs.PieceSet,
+		Orientation: orientation,
+		SquareSize:  DEFAULT_SQUARE_SIZE,
+		Check:       chess.NoSquare,
+	}}
+	q := c.QueryParams()
+	if theme := q.Get("theme"); theme != "" {
+		if _, ok := BOARD_THEMES[theme]; !ok {
+			return st, invalidQuery("unknown theme " + theme)
+		}
+		st.Theme = theme
+	}
+	if pieces := q.Get("pieces"); pieces != "" {
+		if !slices.Contains(PIECE_SETS, pieces) {
+			return st, invalidQuery("unknown piece set " + pieces)
+		}
+		st.PieceSet = pieces
+	}
+	switch q.Get("orientation") {
+	case "":
+	case "white":
+		st.Orientation = chess.White
+	case "black":
+		st.Orientation = chess.Black
+	default:
+		return st, invalidQuery("orientation must be white or black")
+	}
+	if size := q.Get("size"); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n < MIN_SQUARE_SIZE || n > MAX_SQUARE_SIZE {
+			return st, invalidQuery(fmt.Sprintf("size must be a number from %d to %d", MIN_SQUARE_SIZE, MAX_SQUARE_SIZE))
+		}
+		st.SquareSize = n
+	}
+	var err error
+	if st.lastMove, err = queryFlag(c, "lastMove"); err != nil {
+		return st, err
+	}
+	if st.check, err = queryFlag(c, "check"); err != nil {
+		return st, err
+	}
+	return st, nil
+}
+
+// queryFlag reads a true or false query parameter, false if it is missing
+func queryFlag(c echo.Context, name string) (bool, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, invalidQuery(name + " must be true or false")
+	}
+	return b, nil
+}
+
+// options are the options to draw position, after move was played. move is nil before the first move.
+func (st boardStyle) options(position *chess.Position, move *chess.Move) BoardImageOptions {
+	opts := st.BoardImageOptions
+	if st.lastMove && move != nil {
+		opts.LastMove = []chess.Square{move.S1(), move.S2()}
+	}
+	if st.check && move != nil && move.HasTag(chess.Check) {
+		opts.Check = kingSquare(position.Board(), position.Turn())
+	}
+	return opts
+}
+
+// boardImageOptions reads how to draw board from the query of the request.
+// The theme and piece set default to prefs, the orientation to the color of username in the match.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func boardImageOptions(c echo.Context, board matchBoard, username string, prefs Preferences) (BoardImageOptions, error) {
+	orientation := chess.White
+	if board.black != "" && board.black == username {
+		orientation = chess.Black
+	}
+	st, err := parseBoardStyle(c, prefs, orientation)
+	if err != nil {
+		return BoardImageOptions{}, err
+	}
+	q := c.QueryParams()
+	switch format := q.Get("format"); {
+	case strings.HasSuffix(c.Path(), ".png"):
+		st.Format = FORMAT_PNG
+	case format == FORMAT_SVG || format == FORMAT_PNG:
+		st.Format = format
+	case format != "":
+		return st.BoardImageOptions, invalidQuery("format must be svg or png")
+	}
+	if squares := q.Get("squares"); squares != "" {
+		for name := range strings.SplitSeq(squares, ",") {
+			sq, ok := parseSquare(name)
+			if !ok {
+				return st.BoardImageOptions, invalidQuery("squares must be squares separated by commas, eg. e4,d5")
+			}
+			st.Squares = append(st.Squares, sq)
+		}
+	}
+	if arrows := q.Get("arrows"); arrows != "" {
+		for move := range strings.SplitSeq(arrows, ",") {
+			from, okFrom := parseSquare(move[:min(2, len(move))])
+			to, okTo := parseSquare(move[min(2, len(move)):])
+			if !okFrom || !okTo {
+				return st.BoardImageOptions, invalidQuery("arrows must be moves in UCI notation separated by commas, eg. e2e4,g1f3")
+			}
+			st.Arrows = append(st.Arrows, [2]chess.Square{from, to})
+		}
+	}
+	if len(st.Squares)+len(st.Arrows) > MAX_BOARD_ANNOTATIONS {
+		return st.BoardImageOptions, invalidQuery(fmt.Sprintf("at most %d squares and arrows can be drawn", MAX_BOARD_ANNOTATIONS))
+	}
+
+	return st.options(board.position, board.lastMove), nil
+}
+
+// parseSquare reads a square like e4
+func parseSquare(name string) (chess.Square, bool) {
+	if len(name) != 2 || name[0] < 'a' || name[0] > 'h' || name[1] < '1' || name[1] > '8' {
+		return chess.NoSquare, false
+	}
+	return chess.NewSquare(chess.File(name[0]-'a'), chess.Rank(name[1]-'1')), true
+}
+
+func kingSquare(board *chess.Board, color chess.Color) chess.Square {
+	king := chess.NewPiece(chess.King, color)
+	for sq, p := range board.SquareMap() {
+		if p == king {
+			return sq
+		}
+	}
+	return chess.NoSquare
+}
@@ -0,0 +1,254 @@
+// board images rasterized to PNG, for chat apps that don't show SVG
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"regexp"
+	"sync"
+
+	"github.com/notnil/chess"
+	chessimage "github.com/notnil/chess/image"
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// arrows look like the arrows of the SVG images, sizes are fractions of a square
+const (
+	ARROW_WIDTH       = 8.0 / 45
+	ARROW_HEAD_LENGTH = 16.0 / 45
+	ARROW_HEAD_WIDTH  = 24.0 / 45
+	// the arrow starts this far from the center of its first square
+	ARROW_START_OFFSET = 15.0 / 45
+	// coordinates on the edge of the board
+	LABEL_FONT_SIZE = 11.0 / 45
+)
+
+// color of the arrows, like the SVG images draw them
+var ARROW_COLOR = color.NRGBA{247, 181, 75, 191}
+
+// the SVG of a piece inside a board image, see pieceIcons
+var pieceSVGRegex = regexp.MustCompile(`(?s)<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="360" height="360" viewBox="(-?\d+) (-?\d+) 360 360">(.*?)</svg>`)
+
+// pieceSVGs are the pieces of the cburnett set as SVG images 45 pixels wide. They are cut out of an SVG board image,
+// so the PNG images use the same drawings as the SVG images.
+var pieceSVGs = sync.OnceValues(func() (map[chess.Piece][]byte, error) {
+	// every piece on the first or last rank, square x is at -x in the viewBox of its piece
+	at := map[chess.Square]chess.Piece{}
+	for file, t := range []chess.PieceType{chess.King, chess.Queen, chess.Rook, chess.Bishop, chess.Knight, chess.Pawn} {
+		at[chess.NewSquare(chess.File(file), chess.Rank1)] = chess.NewPiece(t, chess.White)
+		at[chess.NewSquare(chess.File(file), chess.Rank8)] = chess.NewPiece(t, chess.Black)
+	}
+	var buf bytes.Buffer
+	if err := chessimage.SVG(&buf, chess.NewBoard(at)); err != nil {
+		return nil, err
+	}
+	svgs := map[chess.Piece][]byte{}
+	for _, m := range pieceSVGRegex.FindAllSubmatch(buf.Bytes(), -1) {
+		var x, y int
+		fmt.Sscan(string(m[1]), &x)
+		fmt.Sscan(string(m[2]), &y)
+		sq := chess.NewSquare(chess.File(-x/DEFAULT_SQUARE_SIZE), chess.Rank(7+y/DEFAULT_SQUARE_SIZE))
+		piece, ok := at[sq]
+		if !ok {
+			return nil, fmt.Errorf("piece at unexpected position %d %d", x, y)
+		}
+		// the black queen has a fill without #, browsers ignore it but oksvg fails
+		body := bytes.ReplaceAll(m[3], []byte("fill:000000"), []byte("fill:#000000"))
+		svgs[piece] = fmt.Appendf(nil, `<svg xmlns="http://www.w3.org/2000/svg" width="45" height="45" viewBox="0 0 45 45">%s</svg>`, body)
+	}
+	if len(svgs) != len(at) {
+		return nil, fmt.Errorf("found %d of %d pieces", len(svgs), len(at))
+	}
+	return svgs, nil
+})
+
+// pieceIcons parses the pieces for one image. Icons can't be shared, drawing changes them.
+func pieceIcons() (map[chess.Piece]*oksvg.SvgIcon, error) {
+	svgs, err := pieceSVGs()
+	if err != nil {
+		return nil, err
+	}
+	icons := make(map[chess.Piece]*oksvg.SvgIcon, len(svgs))
+	for piece, svg := range svgs {
+		icon, err := oksvg.ReadIconStream(bytes.NewReader(svg))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", piece, err)
+		}
+		icons[piece] = icon
+	}
+	return icons, nil
+}
+
+var labelFont = sync.OnceValues(func() (*opentype.Font, error) {
+	return opentype.Parse(goregular.TTF)
+})
+
+// drawPNG rasterizes board like drawSVG draws it, at the same size
+func drawPNG(board *chess.Board, opts BoardImageOptions) ([]byte, error) {
+	icons, err := pieceIcons()
+	if err != nil {
+		return nil, err
+	}
+	img, err := rasterize(board, opts, icons)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// rasterize draws board with icons from pieceIcons. Images drawn one after another can share the icons.
+func rasterize(board *chess.Board, opts BoardImageOptions, icons map[chess.Piece]*oksvg.SvgIcon) (*image.RGBA, error) {
+	f, err := labelFont()
+	if err != nil {
+		return nil, err
+	}
+	size := opts.SquareSize
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: float64(size) * LABEL_FONT_SIZE, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, err
+	}
+	defer face.Close()
+
+	img := image.NewRGBA(image.Rect(0, 0, 8*size, 8*size))
+
+	// later marks are drawn over earlier ones, like in drawSVG
+	marks := map[chess.Square]color.RGBA{}
+	for _, sq := range opts.LastMove {
+		marks[sq] = LAST_MOVE_COLOR
+	}
+	for _, sq := range opts.Squares {
+		marks[sq] = ANNOTATION_COLOR
+	}
+	if opts.Check != chess.NoSquare {
+		marks[opts.Check] = CHECK_COLOR
+	}
+
+	theme := BOARD_THEMES[opts.Theme]
+	pieces := board.SquareMap()
+	for row := range 8 {
+		for col := range 8 {
+			sq := squareAt(col, row, opts.Orientation)
+			x, y := col*size, row*size
+			r := image.Rect(x, y, x+size, y+size)
+			squareColor, textColor := theme[1], theme[0]
+			if (int(sq.File())+int(sq.Rank()))%2 == 1 {
+				squareColor, textColor = theme[0], theme[1]
+			}
+			draw.Draw(img, r, image.NewUniform(squareColor), image.Point{}, draw.Src)
+			if mark, ok := marks[sq]; ok {
+				// the marks are straight colors with an opacity, not premultiplied
+				draw.Draw(img, r, image.NewUniform(color.NRGBA(mark)), image.Point{}, draw.Over)
+			}
+			if p := pieces[sq]; p != chess.NoPiece {
+				// rasterizing only the square is much faster than the whole board for every path
+				icon := icons[p]
+				icon.SetTarget(0, 0, float64(size), float64(size))
+				square := img.SubImage(r).(*image.RGBA)
+				icon.Draw(rasterx.NewDasher(size, size, rasterx.NewScannerGV(size, size, square, r)), 1)
+			}
+			d := font.Drawer{Dst: img, Src: image.NewUniform(textColor), Face: face}
+			if col == 0 {
+				d.Dot = fixed.P(x+size/20, y+size*5/20)
+				d.DrawString(sq.Rank().String())
+			}
+			if row == 7 {
+				label := sq.File().String()
+				d.Dot = fixed.P(x+size*19/20-d.MeasureString(label).Round(), y+size-size/15)
+				d.DrawString(label)
+			}
+		}
+	}
+	if len(opts.Arrows) > 0 {
+		w, h := img.Bounds().Dx(), img.Bounds().Dy()
+		dasher := rasterx.NewDasher(w, h, rasterx.NewScannerGV(w, h, img, img.Bounds()))
+		for _, a := range opts.Arrows {
+			drawArrow(dasher, a[0], a[1], opts.Orientation, float64(size))
+		}
+	}
+	return img, nil
+}
+
+// squareAt is the square in column col and row row of a board with orientation at the bottom
+func squareAt(col, row int, orientation chess.Color) chess.Square {
+	if orientation == chess.Black {
+		return chess.NewSquare(chess.File(7-col), chess.Rank(row))
+	}
+	return chess.NewSquare(chess.File(col), chess.Rank(7-row))
+}
+
+// squareCenter is the center of sq in pixels
+func squareCenter(sq chess.Square, orientation chess.Color, size float64) (x, y float64) {
+	col, row := float64(sq.File()), float64(7-sq.Rank())
+	if orientation == chess.Black {
+		col, row = 7-col, float64(sq.Rank())
+	}
+	return (col + 0.5) * size, (row + 0.5) * size
+}
+
+// drawArrow draws an arrow from the center of from to the center of to.
+// Knight moves get an arrow with a corner, that goes the long way first.
+func drawArrow(dasher *rasterx.Dasher, from, to chess.Square, orientation chess.Color, size float64) {
+	points := []chess.Square{from, to}
+	files, ranks := int(to.File())-int(from.File()), int(to.Rank())-int(from.Rank())
+	if files*files+ranks*ranks == 5 {
+		corner := chess.NewSquare(from.File(), to.Rank())
+		if ranks == 1 || ranks == -1 {
+			corner = chess.NewSquare(to.File(), from.Rank())
+		}
+		points = []chess.Square{from, corner, to}
+	}
+	xs, ys := make([]float64, len(points)), make([]float64, len(points))
+	for i, sq := range points {
+		xs[i], ys[i] = squareCenter(sq, orientation, size)
+	}
+	// the line starts away from the center of the first square, and stops where the head starts
+	last := len(points) - 1
+	dx, dy := unit(xs[1]-xs[0], ys[1]-ys[0])
+	xs[0], ys[0] = xs[0]+dx*ARROW_START_OFFSET*size, ys[0]+dy*ARROW_START_OFFSET*size
+	dx, dy = unit(xs[last]-xs[last-1], ys[last]-ys[last-1])
+	tipX, tipY := xs[last], ys[last]
+	xs[last], ys[last] = tipX-dx*ARROW_HEAD_LENGTH*size, tipY-dy*ARROW_HEAD_LENGTH*size
+
+	dasher.SetColor(ARROW_COLOR)
+	dasher.SetStroke(fixed.Int26_6(ARROW_WIDTH*size*64), 4<<6, rasterx.ButtCap, nil, nil, rasterx.Round, nil, 0)
+	dasher.Start(point(xs[0], ys[0]))
+	for i := 1; i <= last; i++ {
+		dasher.Line(point(xs[i], ys[i]))
+	}
+	dasher.Stop(false)
+	dasher.Draw()
+	dasher.Clear()
+
+	// the head is a triangle, drawn by the filler so it is not stroked
+	half := ARROW_HEAD_WIDTH * size / 2
+	filler := &dasher.Filler
+	filler.Start(point(tipX, tipY))
+	filler.Line(point(xs[last]-dy*half, ys[last]+dx*half))
+	filler.Line(point(xs[last]+dy*half, ys[last]-dx*half))
+	filler.Stop(true)
+	filler.Draw()
+	filler.Clear()
+}
+
+func unit(x, y float64) (float64, float64) {
+	length := math.Hypot(x, y)
+	return x / length, y / length
+}
+
+func point(x, y float64) fixed.Point26_6 {
+	return fixed.Point26_6{X: fixed.Int26_6(x * 64), Y: fixed.Int26_6(y * 64)}
+}
@@ -0,0 +1,289 @@
+// bot accounts and the streams engines play through
+package server
+
+import (
+	"api/server/game"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notnil/chess"
+)
+
+// startNDJSON writes the headers of a newline delimited JSON stream
+func startNDJSON(c echo.Context) {
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	w.Flush()
+}
+
+// writeNDJSON sends v as one line of JSON.
+// The error is only non-nil if the client disconnected.
+func writeNDJSON(w *echo.Response, v any) error {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		slog.Warn("Failed to marshal event", "error", err)
+		return nil
+	}
+	if _, err := w.Write(append(encoded, '\n')); err != nil {
+		return err
+	}
+	w.Flush()
+	return nil
+}
+
+// writeNDJSONKeepAlive sends an empty line so proxies don't close the stream
+func writeNDJSONKeepAlive(w *echo.Response) error {
+	if _, err := w.Write([]byte("\n")); err != nil {
+		return err
+	}
+	w.Flush()
+	return nil
+}
+
+// @Summary		Turn your account into a bot account
+// @Description	Bot accounts are played by engines. They can be challenged, and play through the bot streams.
+// @Description	This cannot be undone. Guests cannot become bots.
+// @Tags			bots
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Success		200				{object}	User
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Guests cannot become bots"
+// @Failure		500				{object}	ErrorReason
+// @Router			/users/me/bot [post]
+func (s Server) BecomeBot(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	if user.IsGuest {
+		return c.JSON(http.StatusForbidden, Reason(CODE_GUESTS_CANNOT_BE_BOTS, "Guests cannot become bots"))
+	}
+	if !user.IsBot {
+		if _, err := s.DB.SetUserBot(c.Request().Context(), user.Uid); err != nil {
+			slog.Error("failed to make user a bot", "username", user.Username, "error", err)
+			return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+		}
+		user.IsBot = true
+		s.audit(c, AUDIT_ACCOUNT_BOT, user.Username, user.Username, "")
+	}
+	return c.JSON(http.StatusOK, UserFromDbUser(user))
+}
+
+// currentBot is the current user, who must be a bot.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (s Server) currentBot(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	if !user.IsBot {
+		return echo.NewHTTPError(http.StatusForbidden, Reason(CODE_NOT_A_BOT, "Only bot accounts can use this endpoint"))
+	}
+	return nil
+}
+
+// @Summary		Receive challenges and game starts as they happen
+// @Description	## On success the server sends newline delimited JSON (Content-Type: application/x-ndjson), one event per line.
+// @Description	Open challenges are sent when the stream opens. Empty lines are sent to keep the connection alive.
+// @Description	Event types: `challenge`, `challengeCanceled`, `gameStart`.
+// @Tags			bots
+// @Produce		json
+// @Param			Authorization	header		string		true	"Must contain ApiKey of a bot in the format Bearer: apiKey"
+// @Success		200				{object}	BotEvent	"NDJSON stream — one event per line"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Not a bot"
+// @Failure		429				{object}	ErrorReason	"Too many open streams"
+// @Router			/bot/stream/event [get]
+func (s Server) StreamBotEvents(c echo.Context) error {
+	if err := s.currentBot(c); err != nil {
+		return err
+	}
+	username := usernameOf(c)
+	disconnect, ok := s.Presence.ConnectLimited(username, "", s.MaxStreamsPerUser)
+	if !ok {
+		return c.JSON(http.StatusTooManyRequests, REASON_TOO_MANY_STREAMS)
+	}
+	defer disconnect()
+	events, unsubscribe := s.Challenges.Subscribe(username)
+	defer unsubscribe()
+	startNDJSON(c)
+
+	w := c.Response()
+	for _, ch := range s.Challenges.Pending(username) {
+		if err := writeNDJSON(w, BotEvent{Type: BotChallenge, Challenge: &ch}); err != nil {
+			return nil
+		}
+	}
+	ticker := time.NewTicker(SSE_KEEP_ALIVE_INTERVAL)
+	defer ticker.Stop()
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := writeNDJSONKeepAlive(w); err != nil {
+				return nil
+			}
+		case e := <-events:
+			if err := writeNDJSON(w, e); err != nil {
+				return nil
+			}
+		case <-s.lifecycle.drained:
+			return nil
+		}
+	}
+}
+
+// BotGameEventType is the type of a line on a bot's game stream
+type BotGameEventType string
+
+const (
+	// everything about the game, sent first and when the opponent joins
+	BotGameFull BotGameEventType = "gameFull"
+	// the moves and status after the opponent moved or the game ended
+	BotGameState BotGameEventType = "gameState"
+	BotChatLine  BotGameEventType = "chatLine"
+	// the game left the opening book, eco and opening name the opening that was played
+	BotGameOpening BotGameEventType = "opening"
+)
+
+// BotGameEvent is sent on a bot's game stream. Each type only uses some of the fields.
+// The stream ends with a `serverRestarting` or `resync` event like match event streams, open it again to continue.
+type BotGameEvent struct {
+	Type      BotGameEventType `json:"type" example:"gameState"`
+	ID        string           `json:"id,omitempty" example:"AB2C21"`
+	White     string           `json:"white,omitempty" example:"JohnDoe"`
+	Black     string           `json:"black,omitempty" example:"StockBot"`
+	Rated     bool             `json:"rated,omitempty" example:"false"`
+	StartTime *time.Time       `json:"startTime,omitempty" format:"date-time"`
+	EndTime   *time.Time       `json:"endTime,omitempty" format:"date-time"` // when this match will be deleted if the game does not end.
+	// moves so far in UCI notation, separated by spaces. Left out before the first move.
+	Moves string `json:"moves,omitempty" example:"e2e4 e7e5"`
+	FEN   string `json:"fen,omitempty" example:"rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2"`
+	// started, over or aborted
+	Status string `json:"status,omitempty" example:"started"`
+	// white or black, empty for draws and running games
+	Winner   string `json:"winner,omitempty" example:"white"`
+	Username string `json:"username,omitempty" example:"JohnDoe"` // who sent the chat message
+	Text     string `json:"text,omitempty" example:"good luck!"`
+	ECO      string `json:"eco,omitempty" example:"C20"`
+	Opening  string `json:"opening,omitempty" example:"King's Pawn Game"`
+}
+
+// @Summary		Play a match as a bot
+// @Description	Joins the match and streams its state as newline delimited JSON (Content-Type: application/x-ndjson), one event per line.
+// @Description	The first line is a `gameFull` event, followed by `gameState` events after every opponent move and when the game ends, `chatLine` events,
+// @Description	an `opening` event once the game leaves the opening book, and `opponentDisconnected` and `opponentConnected` events when the opponent's stream ends and when they are back.
+// @Description	Moves are played with PUT /matches/{id}. Empty lines are sent to keep the connection alive.
+// @Description	Like match event streams, closing the stream resigns unless you open it again within a minute, and `serverRestarting` and `resync` events end it.
+// @Tags			bots
+// @Produce		json
+// @Param			Authorization	header		string			true	"Must contain ApiKey of a bot in the format Bearer: apiKey"
+// @Param			id				path		string			true	"Match ID"
+// @Success		200				{object}	BotGameEvent	"NDJSON stream — one event per line"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Not a bot / blocked by the opponent / match is full"
+// @Failure		404				{object}	ErrorReason	"Match not found"
+// @Failure		429				{object}	ErrorReason	"Too many open streams"
+// @Failure		503				{object}	ErrorReason	"Server is restarting"
+// @Router			/bot/game/stream/{id} [get]
+func (s Server) StreamBotGame(c echo.Context) error {
+	if err := s.currentBot(c); err != nil {
+		return err
+	}
+	match, ok := s.GameStorage.GetMatch(c.Param("id"))
+	if !ok {
+		return c.JSON(http.StatusNotFound, Reason(CODE_MATCH_NOT_FOUND, "Match not found"))
+	}
+	if err := s.checkCanJoin(c.Request().Context(), usernameOf(c), isGuest(c), match); err != nil {
+		return err
+	}
+	color, ok := s.Challenges.SeatColor(match.ID, usernameOf(c))
+	if !ok {
+		color = chess.White
+	}
+	return s.playMatch(c.Request().Context(), usernameOf(c), match, color, &ndjsonMatchStream{c: c})
+}
+
+// ndjsonMatchStream sends the state of a match as newline delimited JSON, see StreamBotGame
+type ndjsonMatchStream struct {
+	c     echo.Context
+	w     *echo.Response
+	match *game.Match
+}
+
+func (st *ndjsonMatchStream) start(match *game.Match, _ game.Player) error {
+	st.w, st.match = st.c.Response(), match
+	startNDJSON(st.c)
+	return writeNDJSON(st.w, st.gameFull())
+}
+
+func (st *ndjsonMatchStream) event(_ context.Context, e game.Event) error {
+	switch e.Type {
+	case game.OpponentInfo:
+		return writeNDJSON(st.w, st.gameFull())
+	case game.Chat:
+		return writeNDJSON(st.w, BotGameEvent{Type: BotChatLine, Username: e.From, Text: e.Message})
+	case game.OpeningDetected:
+		return writeNDJSON(st.w, BotGameEvent{Type: BotGameOpening, ECO: e.ECO, Opening: e.OpeningName})
+	case game.Move, game.Resign, game.Adjudicated:
+		return writeNDJSON(st.w, st.gameState())
+	case game.Aborted:
+		state := st.gameState()
+		state.Status = "aborted"
+		return writeNDJSON(st.w, state)
+	case game.SpectatorJoined, game.SpectatorLeft:
+		return nil
+	default:
+		// serverRestarting and resync end the stream, opponentDisconnected and opponentConnected
+		// tell whether the opponent is still there
+		return writeNDJSON(st.w, BotGameEvent{Type: BotGameEventType(e.Type), FEN: e.FEN})
+	}
+}
+
+func (st *ndjsonMatchStream) keepAlive() error {
+	return writeNDJSONKeepAlive(st.w)
+}
+
+func (st *ndjsonMatchStream) gameFull() BotGameEvent {
+	e := st.gameState()
+	e.Type = BotGameFull
+	e.ID = st.match.ID
+	e.Rated = st.match.Rated
+	e.StartTime, e.EndTime = &st.match.StartTime, &st.match.EndTime
+	if p, ok := st.match.GetPlayerWithColor(chess.White); ok {
+		e.White = p.Username
+	}
+	if p, ok := st.match.GetPlayerWithColor(chess.Black); ok {
+		e.Black = p.Username
+	}
+	return e
+}
+
+func (st *ndjsonMatchStream) gameState() BotGameEvent {
+	e := BotGameEvent{
+		Type:   BotGameState,
+		Moves:  strings.Join(st.match.UCIMoves(), " "),
+		FEN:    st.match.Position().String(),
+		Status: "started",
+	}
+	switch st.match.Outcome() {
+	case chess.NoOutcome:
+	case chess.WhiteWon:
+		e.Status, e.Winner = "over", "white"
+	case chess.BlackWon:
+		e.Status, e.Winner = "over", "black"
+	default:
+		e.Status = "over"
+	}
+	return e
+}
@@ -0,0 +1,54 @@
+// compressing responses and letting clients cache them
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// responses shorter than this are sent uncompressed, the gzip overhead is not worth it
+const GZIP_MIN_LENGTH = 1024
+
+// event streams are flushed one event at a time, compressing them only delays events
+var streamingRoutes = map[string]bool{
+	"/matches/:id/play":     true,
+	"/notifications/stream": true,
+	"/bot/stream/event":     true,
+	"/bot/game/stream/:id":  true,
+}
+
+// CompressionMiddleware gzips responses for clients that accept it, except event streams.
+func CompressionMiddleware() echo.MiddlewareFunc {
+	return middleware.GzipWithConfig(middleware.GzipConfig{
+		Skipper: func(c echo.Context) bool {
+			return streamingRoutes[c.Path()]
+		},
+		MinLength: GZIP_MIN_LENGTH,
+	})
+}
+
+// positionETag is a strong ETag for everything a response is derived from, like the board and the theme.
+func positionETag(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// notModified sets the ETag and Cache-Control headers and reports whether the client already has etag.
+// Responses are private, they can depend on the preferences of the user.
+func notModified(c echo.Context, etag string) bool {
+	h := c.Response().Header()
+	h.Set("ETag", etag)
+	// clients may keep the response, but must check it is still current before using it
+	h.Set("Cache-Control", "private, no-cache")
+	for tag := range strings.SplitSeq(c.Request().Header.Get("If-None-Match"), ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			return true
+		}
+	}
+	return false
+}
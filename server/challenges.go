@@ -0,0 +1,375 @@
+// challenging bots to matches
+package server
+
+import (
+	"api/db"
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notnil/chess"
+)
+
+// how long a challenge waits for the bot to accept it, and how long an accepted seat is kept for the bot
+const CHALLENGE_LIFETIME = 2 * time.Minute
+
+// Challenge is an offer to play a match against a bot
+type Challenge struct {
+	ID   string `json:"id" example:"Q7ZD4F2K"`
+	From string `json:"from" example:"JohnDoe"`
+	// the bot being challenged
+	To string `json:"to" example:"StockBot"`
+	// duration of the match in hours
+	Duration int  `json:"duration" example:"1"`
+	Rated    bool `json:"rated" example:"false"`
+	// the challenger plays the black pieces
+	ChallengerBlack bool      `json:"challengerBlack" example:"false"`
+	ExpiresAt       time.Time `json:"expiresAt" format:"date-time"`
+}
+
+type BotEventType string
+
+const (
+	// someone challenged the bot, accept or decline it
+	BotChallenge BotEventType = "challenge"
+	// the challenger cancelled the challenge
+	BotChallengeCanceled BotEventType = "challengeCanceled"
+	// a match the bot plays in was created, open its game stream
+	BotGameStart BotEventType = "gameStart"
+)
+
+// BotEvent is sent on a bot's event stream. Each type only uses some of the fields.
+type BotEvent struct {
+	Type      BotEventType `json:"type" example:"challenge"`
+	Challenge *Challenge   `json:"challenge,omitempty"`
+	MatchID   string       `json:"matchId,omitempty" example:"AB2C21"`
+	// color the bot plays in the match
+	Color string `json:"color,omitempty" example:"black"`
+}
+
+// ChallengeHub keeps open challenges in memory and delivers them to the event streams of bots.
+// Challenges are short lived, they are lost when the server restarts.
+type ChallengeHub struct {
+	mu sync.Mutex
+	// id -> open challenge
+	challenges map[string]Challenge
+	// match id + username -> seat of an accepted challenge, until the player joins
+	seats map[string]botSeat
+	// bot username -> open event streams
+	streams map[string]map[chan BotEvent]struct{}
+}
+
+type botSeat struct {
+	color     chess.Color
+	expiresAt time.Time
+}
+
+func NewChallengeHub() *ChallengeHub {
+	return &ChallengeHub{
+		challenges: map[string]Challenge{},
+		seats:      map[string]botSeat{},
+		streams:    map[string]map[chan BotEvent]struct{}{},
+	}
+}
+
+// Add stores a challenge and sends it to the challenged bot
+func (h *ChallengeHub) Add(ch Challenge) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.challenges[ch.ID] = ch
+	h.publish(ch.To, BotEvent{Type: BotChallenge, Challenge: &ch})
+}
+
+// Take removes the challenge with id, ok is false if there is none or it expired.
+func (h *ChallengeHub) Take(id string) (ch Challenge, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ch, ok = h.challenges[id]
+	if !ok || time.Now().After(ch.ExpiresAt) {
+		return Challenge{}, false
+	}
+	delete(h.challenges, id)
+	return ch, true
+}
+
+// Get is the challenge with id, ok is false if there is none or it expired.
+func (h *ChallengeHub) Get(id string) (ch Challenge, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ch, ok = h.challenges[id]
+	if !ok || time.Now().After(ch.ExpiresAt) {
+		return Challenge{}, false
+	}
+	return ch, true
+}
+
+// Cancel removes a challenge before the bot answered it and tells the bot
+func (h *ChallengeHub) Cancel(ch Challenge) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.challenges, ch.ID)
+	h.publish(ch.To, BotEvent{Type: BotChallengeCanceled, Challenge: &ch})
+}
+
+// Pending are the open challenges to the bot named username
+func (h *ChallengeHub) Pending(username string) []Challenge {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	var pending []Challenge
+	for _, ch := range h.challenges {
+		if ch.To == username && now.Before(ch.ExpiresAt) {
+			pending = append(pending, ch)
+		}
+	}
+	return pending
+}
+
+// Seat remembers the color username plays in a match created from a challenge,
+// and sends the bot a gameStart event.
+func (h *ChallengeHub) Seat(matchID, username string, color chess.Color) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.seats[matchID+" "+username] = botSeat{color: color, expiresAt: time.Now().Add(CHALLENGE_LIFETIME)}
+	h.publish(username, BotEvent{Type: BotGameStart, MatchID: matchID, Color: colorName(color)})
+}
+
+// SeatColor is the color username plays in the match, ok is false if it was not created from a challenge.
+func (h *ChallengeHub) SeatColor(matchID, username string) (color chess.Color, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	seat, ok := h.seats[matchID+" "+username]
+	return seat.color, ok
+}
+
+// Subscribe opens an event stream for the bot named username. The returned function closes it.
+func (h *ChallengeHub) Subscribe(username string) (events chan BotEvent, unsubscribe func()) {
+	events = make(chan BotEvent, 10)
+	h.mu.Lock()
+	if h.streams[username] == nil {
+		h.streams[username] = map[chan BotEvent]struct{}{}
+	}
+	h.streams[username][events] = struct{}{}
+	h.mu.Unlock()
+	return events, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.streams[username], events)
+		if len(h.streams[username]) == 0 {
+			delete(h.streams, username)
+		}
+	}
+}
+
+// publish sends e to every open stream of username without blocking. The lock must be held.
+func (h *ChallengeHub) publish(username string, e BotEvent) {
+	for stream := range h.streams[username] {
+		select {
+		case stream <- e:
+		default:
+			slog.Warn("bot event stream is full, dropping event", "username", username, "type", e.Type)
+		}
+	}
+}
+
+// cleanup forgets expired challenges and seats
+func (h *ChallengeHub) cleanup() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	for id, ch := range h.challenges {
+		if now.After(ch.ExpiresAt) {
+			delete(h.challenges, id)
+		}
+	}
+	for key, seat := range h.seats {
+		if now.After(seat.expiresAt) {
+			delete(h.seats, key)
+		}
+	}
+}
+
+func colorName(color chess.Color) string {
+	if color == chess.Black {
+		return "black"
+	}
+	return "white"
+}
+
+type CreateChallengeRequest struct {
+	// username of the bot to play
+	Username string `json:"username" example:"StockBot" validate:"required"`
+	Duration int    `json:"duration" minimum:"1" maximum:"12" example:"1" validate:"required,min=1,max=12"` // duration in hours
+	// rated matches cannot be played by guests
+	Rated bool `json:"rated" example:"false"`
+	// whether you play the black pieces
+	BlackPieces bool `json:"blackPieces" example:"false"`
+}
+
+// ChallengeAccepted is the match created for an accepted challenge
+type ChallengeAccepted struct {
+	MatchID string `json:"matchId" example:"AB2C21"`
+	// color the bot plays
+	Color string `json:"color" example:"black"`
+}
+
+// @Summary		Challenge a bot to a match
+// @Description	Sends a challenge to the event stream of a bot account. The challenge expires after 2 minutes.
+// @Description	Once the bot accepts, you get a `challengeAccepted` notification with the match id. Join it like any other match.
+// @Tags			challenges
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header		string					true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			payload			body		CreateChallengeRequest	true	"the bot and the match to play"
+// @Success		201				{object}	Challenge
+// @Failure		400				{object}	ErrorReason	"Invalid json body / not a bot / cannot challenge yourself"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Blocked / guests cannot play rated matches"
+// @Failure		404				{object}	ErrorReason	"User not found"
+// @Failure		429				{object}	ErrorReason	"Too many unfinished matches"
+// @Failure		500				{object}	ErrorReason
+// @Router			/challenges [post]
+func (s Server) CreateChallenge(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	var req CreateChallengeRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	bot, err := s.DB.GetUserByUsername(ctx, req.Username)
+	if err != nil || bot.DeletedAt.Valid {
+		return c.JSON(http.StatusNotFound, Reason(CODE_USER_NOT_FOUND, "User not found"))
+	}
+	if bot.Uid == user.Uid {
+		return c.JSON(http.StatusBadRequest, Reason(CODE_CANNOT_TARGET_SELF, "Cannot challenge yourself"))
+	}
+	if !bot.IsBot {
+		return c.JSON(http.StatusBadRequest, Reason(CODE_NOT_A_BOT, "Only bots can be challenged"))
+	}
+	if req.Rated && user.IsGuest {
+		return c.JSON(http.StatusForbidden, Reason(CODE_GUESTS_CANNOT_RATED, "Guests cannot play rated matches"))
+	}
+	if s.tooManyMatches(user.Username) {
+		return c.JSON(http.StatusTooManyRequests, Reason(CODE_TOO_MANY_MATCHES, fmt.Sprintf("You can have at most %d unfinished matches", s.MaxMatchesPerUser)))
+	}
+	blocked, err := s.blockedBetween(ctx, user.Username, bot.Username)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	if blocked {
+		return c.JSON(http.StatusForbidden, Reason(CODE_BLOCKED, "You cannot challenge this user"))
+	}
+
+	ch := Challenge{
+		ID:              rand.Text()[:8],
+		From:            user.Username,
+		To:              bot.Username,
+		Duration:        req.Duration,
+		Rated:           req.Rated,
+		ChallengerBlack: req.BlackPieces,
+		ExpiresAt:       time.Now().UTC().Add(CHALLENGE_LIFETIME),
+	}
+	s.Challenges.Add(ch)
+	return c.JSON(http.StatusCreated, ch)
+}
+
+// @Summary	Cancel a challenge you sent
+// @Tags		challenges
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param		id				path		string	true	"Challenge ID"
+// @Success	200				{object}	string	"cancelled"
+// @Failure	401				{object}	ErrorReason
+// @Failure	404				{object}	ErrorReason	"Challenge not found"
+// @Router		/challenges/{id} [delete]
+func (s Server) CancelChallenge(c echo.Context) error {
+	username := usernameOf(c)
+	if username == "" {
+		return c.JSON(http.StatusUnauthorized, REASON_UNAUTHORIZED)
+	}
+	ch, ok := s.Challenges.Get(c.Param("id"))
+	if !ok || ch.From != username {
+		return c.JSON(http.StatusNotFound, Reason(CODE_CHALLENGE_NOT_FOUND, "Challenge not found"))
+	}
+	s.Challenges.Cancel(ch)
+	return c.JSON(http.StatusOK, "cancelled")
+}
+
+// challengeTo takes the challenge named by the :id path parameter if it was sent to the current user.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (s Server) challengeTo(c echo.Context) (db.User, Challenge, error) {
+	bot, err := s.currentUser(c)
+	if err != nil {
+		return db.User{}, Challenge{}, err
+	}
+	ch, ok := s.Challenges.Get(c.Param("id"))
+	if !ok || ch.To != bot.Username {
+		return db.User{}, Challenge{}, echo.NewHTTPError(http.StatusNotFound, Reason(CODE_CHALLENGE_NOT_FOUND, "Challenge not found"))
+	}
+	if ch, ok = s.Challenges.Take(ch.ID); !ok {
+		// accepted or declined by another request
+		return db.User{}, Challenge{}, echo.NewHTTPError(http.StatusNotFound, Reason(CODE_CHALLENGE_NOT_FOUND, "Challenge not found"))
+	}
+	return bot, ch, nil
+}
+
+// @Summary		Accept a challenge
+// @Description	Bots accept challenges from their event stream. This creates the match, owned by the challenger,
+// @Description	and sends a `gameStart` event to the bot's event stream. Open the game stream of the match to play.
+// @Tags			bots
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey of a bot in the format Bearer: apiKey"
+// @Param			id				path		string	true	"Challenge ID"
+// @Success		200				{object}	ChallengeAccepted
+// @Failure		401				{object}	ErrorReason
+// @Failure		404				{object}	ErrorReason	"Challenge not found"
+// @Failure		429				{object}	ErrorReason	"The challenger has too many unfinished matches"
+// @Failure		500				{object}	ErrorReason
+// @Router			/challenges/{id}/accept [post]
+func (s Server) AcceptChallenge(c echo.Context) error {
+	bot, ch, err := s.challengeTo(c)
+	if err != nil {
+		return err
+	}
+	if s.tooManyMatches(ch.From) {
+		return c.JSON(http.StatusTooManyRequests, Reason(CODE_TOO_MANY_MATCHES, fmt.Sprintf("%s has too many unfinished matches", ch.From)))
+	}
+	ctx := c.Request().Context()
+	match, err := s.GameStorage.NewMatch(ctx, ch.From, time.Duration(ch.Duration)*time.Hour, ch.Rated)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	challengerColor := chess.White
+	if ch.ChallengerBlack {
+		challengerColor = chess.Black
+	}
+	s.Challenges.Seat(match.ID, ch.From, challengerColor)
+	s.Challenges.Seat(match.ID, bot.Username, challengerColor.Other())
+	s.notifyUsername(ctx, ch.From, NotifyChallengeAccepted, bot.Username, match.ID)
+	return c.JSON(http.StatusOK, ChallengeAccepted{MatchID: match.ID, Color: colorName(challengerColor.Other())})
+}
+
+// @Summary	Decline a challenge
+// @Tags		bots
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey of a bot in the format Bearer: apiKey"
+// @Param		id				path		string	true	"Challenge ID"
+// @Success	200				{object}	string	"declined"
+// @Failure	401				{object}	ErrorReason
+// @Failure	404				{object}	ErrorReason	"Challenge not found"
+// @Router		/challenges/{id}/decline [post]
+func (s Server) DeclineChallenge(c echo.Context) error {
+	bot, ch, err := s.challengeTo(c)
+	if err != nil {
+		return err
+	}
+	s.notifyUsername(c.Request().Context(), ch.From, NotifyChallengeDeclined, bot.Username, "")
+	return c.JSON(http.StatusOK, "declined")
+}
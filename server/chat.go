@@ -0,0 +1,212 @@
+// chatting during a match
+package server
+
+import (
+	"api/db"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// a user can send at most CHAT_RATE_LIMIT messages every CHAT_RATE_WINDOW
+const (
+	CHAT_RATE_LIMIT  = 5
+	CHAT_RATE_WINDOW = 10 * time.Second
+)
+
+type ChatRequest struct {
+	Message string `json:"message" maxLength:"200" example:"good luck!" validate:"notblank,max=200"`
+}
+
+// ChatMessage is a chat message sent during a match
+type ChatMessage struct {
+	From    string    `json:"from" example:"JohnDoe"`
+	Message string    `json:"message" example:"good luck!"`
+	SentAt  time.Time `json:"sentAt" format:"date-time"`
+}
+
+func ChatMessageFromDb(m db.ChatMessage) ChatMessage {
+	return ChatMessage{
+		From:    m.Username,
+		Message: m.Message,
+		SentAt:  m.SentAt,
+	}
+}
+
+// @Summary		Send a chat message to your opponent
+// @Description	Your opponent receives a `chat` event on their event stream.
+// @Description	You can send at most 5 messages every 10 seconds. Messages can be at most 200 characters.
+// @Description	Offensive words are replaced with asterisks. You cannot message an opponent if either of you blocked the other.
+// @Tags			matches
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header		string		true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string		true	"Match ID"
+// @Param			payload			body		ChatRequest	true	"chat message"
+// @Success		200				{object}	ChatMessage
+// @Failure		400				{object}	ErrorReason	"Invalid json body / invalid message"
+// @Failure		403				{object}	ErrorReason	"Unauthorized / blocked"
+// @Failure		404				{object}	ErrorReason	"Match not found / player not in-game"
+// @Failure		429				{object}	ErrorReason	"Too many messages"
+// @Failure		500				{object}	ErrorReason
+// @Router			/matches/{id}/chat [post]
+func (s Server) PostChatMessage(c echo.Context) error {
+	username := usernameOf(c)
+	if username == "" {
+		return c.JSON(http.StatusForbidden, REASON_UNAUTHORIZED)
+	}
+	var req ChatRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	saved, err := s.sendChat(c.Request().Context(), username, c.Param("id"), req.Message)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, ChatMessageFromDb(saved))
+}
+
+// sendChat sends message from username to their opponent in the match with id matchID, and saves it.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (s Server) sendChat(ctx context.Context, username, matchID, message string) (db.ChatMessage, error) {
+	message = strings.TrimSpace(message)
+	match, ok := s.GameStorage.GetMatch(matchID)
+	if !ok {
+		return db.ChatMessage{}, echo.NewHTTPError(http.StatusNotFound, Reason(CODE_MATCH_NOT_FOUND, "match not found"))
+	}
+	player, ok := match.GetPlayerFromUsername(username)
+	if !ok {
+		return db.ChatMessage{}, echo.NewHTTPError(http.StatusNotFound, Reason(CODE_PLAYER_NOT_IN_MATCH, "Player not in-game"))
+	}
+	for _, p := range match.Players() {
+		if p.Username == "" || p.Username == username {
+			continue
+		}
+		blocked, err := s.blockedBetween(ctx, username, p.Username)
+		if err != nil {
+			return db.ChatMessage{}, echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+		}
+		if blocked {
+			return db.ChatMessage{}, echo.NewHTTPError(http.StatusForbidden, Reason(CODE_BLOCKED, "You cannot message this user"))
+		}
+	}
+	if !s.ChatLimiter.Allow(username) {
+		return db.ChatMessage{}, echo.NewHTTPError(http.StatusTooManyRequests, Reason(CODE_RATE_LIMITED, "Too many messages, slow down"))
+	}
+
+	if masked, ok := s.WordFilter.Mask(message); ok {
+		s.reportAutomatically(ctx, username, matchID, REPORT_CHAT, message)
+		message = masked
+	}
+
+	saved, err := s.DB.CreateChatMessage(ctx, db.CreateChatMessageParams{
+		MatchID:  matchID,
+		Username: username,
+		Message:  message,
+		SentAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		slog.Error("failed to save chat message", "match", matchID, "error", err)
+		return db.ChatMessage{}, echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	match.Chat(ctx, player, message)
+	return saved, nil
+}
+
+// @Summary	Get the chat history of a match
+// @Tags		matches
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param		id				path		string	true	"Match ID"
+// @Success	200				{array}		ChatMessage
+// @Failure	403				{object}	ErrorReason	"Unauthorized"
+// @Failure	404				{object}	ErrorReason	"Match not found / player not in-game"
+// @Failure	500				{object}	ErrorReason
+// @Router		/matches/{id}/chat [get]
+func (s Server) GetChatMessages(c echo.Context) error {
+	username := usernameOf(c)
+	if username == "" {
+		return c.JSON(http.StatusForbidden, REASON_UNAUTHORIZED)
+	}
+	saved, err := s.chatHistory(c.Request().Context(), username, c.Param("id"))
+	if err != nil {
+		return err
+	}
+	messages := make([]ChatMessage, 0, len(saved))
+	for _, m := range saved {
+		messages = append(messages, ChatMessageFromDb(m))
+	}
+	return c.JSON(http.StatusOK, messages)
+}
+
+// chatHistory is the chat of the match with id matchID, if username plays in it.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (s Server) chatHistory(ctx context.Context, username, matchID string) ([]db.ChatMessage, error) {
+	match, ok := s.GameStorage.GetMatch(matchID)
+	if !ok {
+		return nil, echo.NewHTTPError(http.StatusNotFound, Reason(CODE_MATCH_NOT_FOUND, "match not found"))
+	}
+	if _, ok := match.GetPlayerFromUsername(username); !ok {
+		return nil, echo.NewHTTPError(http.StatusNotFound, Reason(CODE_PLAYER_NOT_IN_MATCH, "Player not in-game"))
+	}
+	saved, err := s.DB.ListChatMessages(ctx, matchID)
+	if err != nil {
+		slog.Error("failed to list chat messages", "match", matchID, "error", err)
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return saved, nil
+}
+
+// ChatLimiter allows each user to send limit messages every window.
+type ChatLimiter struct {
+	limit  int
+	window time.Duration
+	mu     sync.Mutex
+	// username -> times of the messages sent in the current window
+	sent map[string][]time.Time
+}
+
+func NewChatLimiter(limit int, window time.Duration) *ChatLimiter {
+	return &ChatLimiter{
+		limit:  limit,
+		window: window,
+		sent:   map[string][]time.Time{},
+	}
+}
+
+// Allow records a message from username, returns false if they sent too many.
+func (l *ChatLimiter) Allow(username string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	// drop messages that left the window
+	recent := l.sent[username][:0]
+	for _, t := range l.sent[username] {
+		if now.Sub(t) < l.window {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= l.limit {
+		l.sent[username] = recent
+		return false
+	}
+	l.sent[username] = append(recent, now)
+	return true
+}
+
+// forget users who have not chatted recently
+func (l *ChatLimiter) cleanup() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	for username, times := range l.sent {
+		if len(times) == 0 || now.Sub(times[len(times)-1]) >= l.window {
+			delete(l.sent, username)
+		}
+	}
+}
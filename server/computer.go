@@ -0,0 +1,217 @@
+// matches against the computer, played by a UCI engine
+package server
+
+import (
+	"api/db"
+	"api/server/game"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notnil/chess"
+)
+
+const (
+	// default username of the bot account the computer plays as
+	DEFAULT_COMPUTER_USERNAME = "Stockfish"
+	// default number of engine processes, matches wait for a free one
+	DEFAULT_ENGINE_PROCESSES = 2
+	// how long the computer may think about one move, including waiting for an engine
+	COMPUTER_MOVE_TIMEOUT = 10 * time.Second
+	// how often the computer checks whether its matches were deleted
+	COMPUTER_POLL_INTERVAL = 10 * time.Second
+)
+
+// Computer plays matches through the same pipeline as people: it joins as a player,
+// reads the match's events and plays its moves with MoveAs.
+type Computer struct {
+	// bot account the computer plays as
+	Username string
+	engines  *EnginePool
+
+	mu sync.Mutex
+	// match id -> level, for the matches the computer is playing
+	levels map[string]int
+}
+
+func NewComputer(username string, engines *EnginePool) *Computer {
+	return &Computer{
+		Username: username,
+		engines:  engines,
+		levels:   map[string]int{},
+	}
+}
+
+// EnsureComputerUser creates the bot account the computer plays as, so its games can be archived.
+// It fails if the username belongs to an account that is not a bot.
+func (s Server) EnsureComputerUser(ctx context.Context) error {
+	user, err := s.DB.GetUserByUsername(ctx, s.Computer.Username)
+	if errors.Is(err, sql.ErrNoRows) {
+		// nobody can log in with an empty password hash
+		user, err = s.DB.CreateUser(ctx, db.CreateUserParams{
+			Username: s.Computer.Username,
+			ApiKey:   s.newApiKey(s.Computer.Username),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create computer user %s: %w", s.Computer.Username, err)
+		}
+		if _, err := s.DB.SetUserBot(ctx, user.Uid); err != nil {
+			return err
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !user.IsBot {
+		return fmt.Errorf("computer user %s already exists and is not a bot", s.Computer.Username)
+	}
+	return nil
+}
+
+// Play joins match as color and plays it at level in the background.
+// ok is false if the seat is taken.
+func (c *Computer) Play(match *game.Match, color chess.Color, level int) (ok bool) {
+	player, ok := match.Join(c.Username, color)
+	if !ok {
+		return false
+	}
+	c.mu.Lock()
+	c.levels[match.ID] = level
+	c.mu.Unlock()
+	go c.play(match, player, level)
+	return true
+}
+
+// Level is the level the computer plays match at, 0 if it does not play in it.
+func (c *Computer) Level(matchID string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.levels[matchID]
+}
+
+func (c *Computer) forget(matchID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.levels, matchID)
+}
+
+// computerLevel is the level the computer plays match at, 0 if it does not play in it or there is no computer.
+func (s Server) computerLevel(matchID string) int64 {
+	if s.Computer == nil {
+		return 0
+	}
+	return int64(s.Computer.Level(matchID))
+}
+
+// Close stops the engines
+func (c *Computer) Close() {
+	c.engines.Close()
+}
+
+// play moves whenever it is the computer's turn, until the game ends or the match is gone
+func (c *Computer) play(match *game.Match, player game.Player, level int) {
+	ticker := time.NewTicker(COMPUTER_POLL_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case e := <-player.Events:
+			switch e.Type {
+			case game.OpponentInfo, game.Move, game.Resync:
+				c.move(match, player, level)
+			case game.Resign, game.Aborted, game.Adjudicated:
+				c.forget(match.ID)
+				return
+			case game.ServerRestarting:
+				// the level is saved with the match, see Shutdown
+				return
+			}
+		case <-ticker.C:
+			if st := match.State(); st == game.MatchFinished || st == game.MatchClosed {
+				c.forget(match.ID)
+				return
+			}
+		}
+	}
+}
+
+// move plays a move if it is the computer's turn and its opponent joined
+func (c *Computer) move(match *game.Match, player game.Player, level int) {
+	if match.State() != game.MatchPlaying {
+		return
+	}
+	pos := match.Position()
+	if pos.Turn() != player.Color || match.Outcome() != chess.NoOutcome {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), COMPUTER_MOVE_TIMEOUT)
+	defer cancel()
+	move, err := c.engines.BestMove(ctx, pos.String(), ENGINE_LEVELS[level-1])
+	if err != nil {
+		// a random move keeps the game going, the next move gets another engine
+		slog.Error("engine failed, playing a random move", "match", match.ID, "error", err)
+		move, _ = game.RandomMove(pos, rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())))
+	}
+	if err := match.MoveAs(ctx, player, move); err != nil {
+		slog.Warn("computer could not move", "match", match.ID, "move", move, "error", err)
+	}
+}
+
+type CreateComputerMatchRequest struct {
+	Duration int `json:"duration" minimum:"1" maximum:"12" example:"1" validate:"required,min=1,max=12"` // duration in hours
+	// how strong the computer plays, from 1 to 8
+	Level int `json:"level" minimum:"1" maximum:"8" example:"3" validate:"required,min=1,max=8"`
+	// whether you play the black pieces
+	BlackPieces bool `json:"blackPieces" example:"false"`
+}
+
+// @Summary		Create a match against the computer
+// @Description	The computer joins right away with the other color. Join the match with GET /matches/{id}/play and play like in any other match.
+// @Description	Levels go from 1 to 8. Matches against the computer are not rated.
+// @Tags			matches
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header		string						true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			payload			body		CreateComputerMatchRequest	true	"level of the computer and your color"
+// @Success		200				{object}	MatchCreatedResponse		"Match Created"
+// @Failure		400				{object}	ErrorReason					"Invalid json body"
+// @Failure		401				{object}	ErrorReason
+// @Failure		429				{object}	ErrorReason	"Too many unfinished matches"
+// @Failure		500				{object}	ErrorReason
+// @Failure		503				{object}	ErrorReason	"No engine is configured on this server"
+// @Router			/matches/computer [post]
+func (s Server) CreateComputerMatch(c echo.Context) error {
+	username := usernameOf(c)
+	if username == "" {
+		return c.JSON(http.StatusUnauthorized, REASON_UNAUTHORIZED)
+	}
+	var req CreateComputerMatchRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	if s.Computer == nil {
+		return c.JSON(http.StatusServiceUnavailable, Reason(CODE_NO_COMPUTER, "No engine is configured on this server"))
+	}
+	if s.tooManyMatches(username) {
+		return c.JSON(http.StatusTooManyRequests, Reason(CODE_TOO_MANY_MATCHES, fmt.Sprintf("You can have at most %d unfinished matches", s.MaxMatchesPerUser)))
+	}
+	match, err := s.GameStorage.NewMatch(c.Request().Context(), username, time.Duration(req.Duration)*time.Hour, false)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	computerColor := chess.Black
+	if req.BlackPieces {
+		computerColor = chess.White
+	}
+	if !s.Computer.Play(match, computerColor, req.Level) {
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return c.JSON(http.StatusOK, MatchCreatedResponse{match.ID})
+}
@@ -0,0 +1,16 @@
+// limits on how many matches and streams a user can have at once
+package server
+
+// defaults, they can be changed with the MAX_MATCHES_PER_USER and MAX_STREAMS_PER_USER environment variables.
+// 0 means no limit.
+const (
+	// unfinished matches a user can have created
+	DEFAULT_MAX_MATCHES_PER_USER = 5
+	// open event streams per user, match and notification streams count together
+	DEFAULT_MAX_STREAMS_PER_USER = 3
+)
+
+// tooManyMatches is true if username created MaxMatchesPerUser matches that are still going on
+func (s Server) tooManyMatches(username string) bool {
+	return s.MaxMatchesPerUser > 0 && s.GameStorage.CountOwnedBy(username) >= s.MaxMatchesPerUser
+}
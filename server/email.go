@@ -0,0 +1,199 @@
+// emails to players who are disconnected from their matches when something happens in them
+package server
+
+import (
+	"api/db"
+	"api/server/game"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notnil/chess"
+)
+
+// emails waiting to be sent, more are dropped
+const EMAIL_QUEUE_SIZE = 1000
+
+// Mailer sends an email. SMTPMailer sends them through an SMTP server.
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// SMTPMailer sends emails through an SMTP server, with PLAIN authentication if Username is set
+type SMTPMailer struct {
+	// host:port of the server
+	Addr               string
+	Username, Password string
+	// sender address
+	From string
+}
+
+func (m SMTPMailer) Send(to, subject, body string) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		host, _, err := net.SplitHostPort(m.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+	msg := "From: " + m.From + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Date: " + time.Now().Format(time.RFC1123Z) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" + strings.ReplaceAll(body, "\n", "\r\n")
+	return smtp.SendMail(m.Addr, auth, m.From, []string{to}, []byte(msg))
+}
+
+// EmailSender queues emails and sends them one at a time, so the hooks that queue them don't wait for the mail server.
+type EmailSender struct {
+	// nil turns emails off. Must be set before any emails are sent, like BaseURL.
+	Mailer Mailer
+	// public URL of the server, like https://chess.example.com, for the links in emails
+	BaseURL string
+
+	queue chan emailJob
+}
+
+type emailJob struct {
+	to, subject, body string
+}
+
+func NewEmailSender() *EmailSender {
+	e := &EmailSender{queue: make(chan emailJob, EMAIL_QUEUE_SIZE)}
+	go e.work()
+	return e
+}
+
+// Send queues an email without blocking. Nothing is sent without a Mailer.
+func (e *EmailSender) Send(to, subject, body string) {
+	if e.Mailer == nil {
+		return
+	}
+	select {
+	case e.queue <- emailJob{to: to, subject: subject, body: body}:
+	default:
+		slog.Warn("email queue is full, dropped email", "subject", subject)
+	}
+}
+
+// matchLink is where the match can be seen in a browser
+func (e *EmailSender) matchLink(matchID string) string {
+	return strings.TrimSuffix(e.BaseURL, "/") + "/matches/" + matchID + "/embed"
+}
+
+func (e *EmailSender) work() {
+	for job := range e.queue {
+		if err := e.Mailer.Send(job.to, job.subject, job.body); err != nil {
+			slog.Error("failed to send email", "subject", job.subject, "error", err)
+		}
+	}
+}
+
+// emailOffline emails username about match if they gave an email address, allow is true for their preferences,
+// and they are disconnected from match. Seats are replicated, so players connected to another server are not emailed.
+func (s Server) emailOffline(ctx context.Context, match *game.Match, username string, allow func(Preferences) bool, subject, body string) {
+	if s.Emails.Mailer == nil || !match.Disconnected(username) {
+		return
+	}
+	user, err := s.DB.GetUserByUsername(ctx, username)
+	if err != nil || user.Email == "" || user.Banned || user.DeletedAt.Valid {
+		return
+	}
+	if !allow(PreferencesFromDbUser(user)) {
+		return
+	}
+	body += "\n\nTurn these emails off with PATCH /users/me/preferences.\n"
+	s.Emails.Send(user.Email, subject, body)
+}
+
+// emailYourMove tells username it is their move after opponent moved
+func (s Server) emailYourMove(ctx context.Context, match *game.Match, username, opponent, move string) {
+	s.emailOffline(ctx, match, username, func(p Preferences) bool { return p.EmailYourMove },
+		fmt.Sprintf("Your move against %s", opponent),
+		fmt.Sprintf("%s played %s in match %s, it is your move.\n\n%s", opponent, move, match.ID, s.Emails.matchLink(match.ID)))
+}
+
+// emailOpponentResigned tells the opponent of loser that they won
+func (s Server) emailOpponentResigned(ctx context.Context, match *game.Match, username, loser string) {
+	s.emailOffline(ctx, match, username, func(p Preferences) bool { return p.EmailOpponentResigned },
+		fmt.Sprintf("%s resigned", loser),
+		fmt.Sprintf("%s resigned match %s, you won.\n\n%s", loser, match.ID, s.Emails.matchLink(match.ID)))
+}
+
+// resign resigns the game of player if it is still going, and emails their opponent if they are disconnected from the match
+func (s Server) resign(ctx context.Context, match *game.Match, player game.Player) {
+	if match.Outcome() != chess.NoOutcome {
+		return
+	}
+	match.Resign(player)
+	for _, p := range match.Players() {
+		if p.Username != "" && p.Username != player.Username {
+			s.emailOpponentResigned(ctx, match, p.Username, player.Username)
+		}
+	}
+}
+
+type EmailRequest struct {
+	// empty removes your address
+	Email string `json:"email" maxLength:"254" example:"john@example.com" validate:"omitempty,email,max=254"`
+}
+
+type EmailResponse struct {
+	// empty if you did not give one
+	Email string `json:"email" example:"john@example.com"`
+}
+
+// @Summary	Get your email address
+// @Tags		users
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Success	200				{object}	EmailResponse
+// @Failure	401				{object}	ErrorReason
+// @Failure	500				{object}	ErrorReason
+// @Router		/users/me/email [get]
+func (s Server) GetEmail(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, EmailResponse{Email: user.Email})
+}
+
+// @Summary		Set your email address
+// @Description	While you are disconnected from a match, on every server, the server emails you when it becomes your move in it and when your opponent resigns.
+// @Description	Each kind of email can be turned off with the `emailYourMove` and `emailOpponentResigned` preferences. Emails are only sent if the server is set up to send them.
+// @Tags			users
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header		string			true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			payload			body		EmailRequest	true	"your address"
+// @Success		200				{object}	EmailResponse
+// @Failure		400				{object}	ErrorReason	"Invalid email address"
+// @Failure		401				{object}	ErrorReason
+// @Failure		500				{object}	ErrorReason
+// @Router			/users/me/email [put]
+func (s Server) PutEmail(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	var req EmailRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	err = s.DB.UpdateUserEmail(c.Request().Context(), db.UpdateUserEmailParams{Email: req.Email, Uid: user.Uid})
+	if err != nil {
+		slog.Error("failed to update email", "username", user.Username, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return c.JSON(http.StatusOK, EmailResponse{Email: req.Email})
+}
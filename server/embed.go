@@ -0,0 +1,81 @@
+// a board viewer that can be embedded in other pages
+package server
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"image/color"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notnil/chess"
+)
+
+// how often the viewer asks for the board, the public rate limit must allow it
+const EMBED_POLL_INTERVAL = 3 * time.Second
+
+//go:embed embed.html
+var EMBED_PAGE string
+
+var embedTemplate = template.Must(template.New("embed").Parse(EMBED_PAGE))
+
+type embedPage struct {
+	ID          string
+	Light, Dark template.CSS
+	SquareSize  template.CSS
+	// the viewer draws the board from black's side
+	BlackAtBottom bool
+	// the viewer highlights the squares that changed with the last move
+	LastMove bool
+	// in milliseconds
+	PollInterval int64
+}
+
+// @Summary		Get an HTML page that shows the board of a match
+// @Description	The page needs no api key and no other files. It asks GET /matches/{id} for the board every few seconds, so it stays up to date.
+// @Description	Embed it in an iframe to show a match on a blog or in a stream overlay.
+// @Tags			matches
+// @Produce		html,json
+// @Param			id			path		string		true	"Match ID"
+// @Param			orientation	query		string		false	"color at the bottom of the board"	Enums(white, black)
+// @Param			theme		query		string		false	"board theme"						Enums(brown, blue, green, gray)
+// @Param			size		query		int			false	"width of a square in pixels"		minimum(16)	maximum(128)	default(45)
+// @Param			lastMove	query		bool		false	"highlight the squares of the last move"
+// @Success		200			{string}	string		"HTML page"
+// @Failure		400			{object}	ErrorReason	"Invalid query parameter"
+// @Failure		404			{object}	ErrorReason	"Match not found"
+// @Router			/matches/{id}/embed [get]
+func (s Server) GetMatchEmbed(c echo.Context) error {
+	match, ok := s.GameStorage.GetMatch(c.Param("id"))
+	if !ok {
+		return c.JSON(http.StatusNotFound, Reason(CODE_MATCH_NOT_FOUND, "match not found"))
+	}
+	st, err := parseBoardStyle(c, DEFAULT_PREFERENCES, chess.White)
+	if err != nil {
+		return err
+	}
+	theme := BOARD_THEMES[st.Theme]
+	var page bytes.Buffer
+	err = embedTemplate.Execute(&page, embedPage{
+		ID:            match.ID,
+		Light:         cssColor(theme[0]),
+		Dark:          cssColor(theme[1]),
+		SquareSize:    template.CSS(fmt.Sprintf("%dpx", st.SquareSize)),
+		BlackAtBottom: st.Orientation == chess.Black,
+		LastMove:      st.lastMove,
+		PollInterval:  EMBED_POLL_INTERVAL.Milliseconds(),
+	})
+	if err != nil {
+		return err
+	}
+	// the page may be framed anywhere, but only talks to this server
+	c.Response().Header().Set("Content-Security-Policy", "default-src 'none'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; connect-src 'self'")
+	return c.HTMLBlob(http.StatusOK, page.Bytes())
+}
+
+func cssColor(c color.RGBA) template.CSS {
+	return template.CSS(fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B))
+}
@@ -1,18 +1,180 @@
 package server
 
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ErrorCode identifies an error. Unlike the reason, codes never change, so clients can rely on them.
+type ErrorCode string
+
+const (
+	CODE_INTERNAL_ERROR ErrorCode = "INTERNAL_ERROR"
+	// the body is not valid json, or fields have the wrong type
+	CODE_INVALID_JSON ErrorCode = "INVALID_JSON"
+	// a field or query parameter has an invalid value
+	CODE_INVALID_INPUT ErrorCode = "INVALID_INPUT"
+	CODE_NOT_FOUND     ErrorCode = "NOT_FOUND"
+	// the route exists, but not with this method
+	CODE_METHOD_NOT_ALLOWED ErrorCode = "METHOD_NOT_ALLOWED"
+	CODE_SHUTTING_DOWN      ErrorCode = "SHUTTING_DOWN"
+	CODE_NOT_READY          ErrorCode = "NOT_READY"
+	CODE_RATE_LIMITED       ErrorCode = "RATE_LIMITED"
+
+	// authentication
+	CODE_UNAUTHORIZED        ErrorCode = "UNAUTHORIZED"
+	CODE_INVALID_AUTH_HEADER ErrorCode = "INVALID_AUTH_HEADER"
+	CODE_API_KEY_EXPIRED     ErrorCode = "API_KEY_EXPIRED"
+	CODE_INVALID_CREDENTIALS ErrorCode = "INVALID_CREDENTIALS"
+	CODE_ACCOUNT_BANNED      ErrorCode = "ACCOUNT_BANNED"
+	CODE_ACCOUNT_DELETED     ErrorCode = "ACCOUNT_DELETED"
+	CODE_ACCOUNT_LOCKED      ErrorCode = "ACCOUNT_LOCKED"
+	CODE_LOGIN_THROTTLED     ErrorCode = "LOGIN_THROTTLED"
+	CODE_NOT_ADMIN           ErrorCode = "NOT_ADMIN"
+
+	// accounts
+	CODE_USER_NOT_FOUND        ErrorCode = "USER_NOT_FOUND"
+	CODE_USERNAME_TAKEN        ErrorCode = "USERNAME_TAKEN"
+	CODE_USERNAME_NOT_ALLOWED  ErrorCode = "USERNAME_NOT_ALLOWED"
+	CODE_NOT_A_GUEST           ErrorCode = "NOT_A_GUEST"
+	CODE_CHALLENGE_REQUIRED    ErrorCode = "CHALLENGE_REQUIRED"
+	CODE_CHALLENGE_FAILED      ErrorCode = "CHALLENGE_FAILED"
+	CODE_TOO_MANY_SIGNUPS      ErrorCode = "TOO_MANY_SIGNUPS"
+	CODE_CANNOT_TARGET_SELF    ErrorCode = "CANNOT_TARGET_SELF"
+	CODE_BLOCKED               ErrorCode = "BLOCKED"
+	CODE_NOT_BLOCKED           ErrorCode = "NOT_BLOCKED"
+	CODE_ALREADY_FRIENDS       ErrorCode = "ALREADY_FRIENDS"
+	CODE_NOT_FRIENDS           ErrorCode = "NOT_FRIENDS"
+	CODE_FRIEND_REQUEST_EXISTS ErrorCode = "FRIEND_REQUEST_EXISTS"
+	CODE_NO_FRIEND_REQUEST     ErrorCode = "NO_FRIEND_REQUEST"
+	CODE_REPORT_NOT_FOUND      ErrorCode = "REPORT_NOT_FOUND"
+	CODE_WEBHOOK_NOT_FOUND     ErrorCode = "WEBHOOK_NOT_FOUND"
+	CODE_TOO_MANY_WEBHOOKS     ErrorCode = "TOO_MANY_WEBHOOKS"
+	CODE_NOT_A_BOT             ErrorCode = "NOT_A_BOT"
+	CODE_GUESTS_CANNOT_BE_BOTS ErrorCode = "GUESTS_CANNOT_BE_BOTS"
+	CODE_CHALLENGE_NOT_FOUND   ErrorCode = "CHALLENGE_NOT_FOUND"
+
+	// matches
+	CODE_MATCH_NOT_FOUND       ErrorCode = "MATCH_NOT_FOUND"
+	CODE_MATCH_FULL            ErrorCode = "MATCH_FULL"
+	CODE_GUESTS_CANNOT_RATED   ErrorCode = "GUESTS_CANNOT_PLAY_RATED"
+	CODE_PLAYER_NOT_IN_MATCH   ErrorCode = "PLAYER_NOT_IN_MATCH"
+	CODE_NOT_YOUR_TURN         ErrorCode = "NOT_YOUR_TURN"
+	CODE_INVALID_MOVE_NOTATION ErrorCode = "INVALID_MOVE_NOTATION"
+	CODE_ILLEGAL_MOVE          ErrorCode = "ILLEGAL_MOVE"
+	CODE_GAME_OVER             ErrorCode = "GAME_OVER"
+	CODE_GAME_NOT_OVER         ErrorCode = "GAME_NOT_OVER"
+	CODE_TOO_MANY_MATCHES      ErrorCode = "TOO_MANY_MATCHES"
+	CODE_TOO_MANY_STREAMS      ErrorCode = "TOO_MANY_STREAMS"
+	CODE_NO_COMPUTER           ErrorCode = "NO_COMPUTER"
+	CODE_INVALID_PGN           ErrorCode = "INVALID_PGN"
+
+	// studies
+	CODE_STUDY_NOT_FOUND    ErrorCode = "STUDY_NOT_FOUND"
+	CODE_NOT_STUDY_OWNER    ErrorCode = "NOT_STUDY_OWNER"
+	CODE_STUDY_FULL         ErrorCode = "STUDY_FULL"
+	CODE_POSITION_NOT_FOUND ErrorCode = "POSITION_NOT_FOUND"
+
+	CODE_PUZZLE_NOT_FOUND ErrorCode = "PUZZLE_NOT_FOUND"
+
+	// tournaments
+	CODE_TOURNAMENT_NOT_FOUND ErrorCode = "TOURNAMENT_NOT_FOUND"
+	CODE_NOT_TOURNAMENT_OWNER ErrorCode = "NOT_TOURNAMENT_OWNER"
+	CODE_TOURNAMENT_STARTED   ErrorCode = "TOURNAMENT_STARTED"
+	CODE_TOURNAMENT_FINISHED  ErrorCode = "TOURNAMENT_FINISHED"
+	CODE_NOT_ENOUGH_PLAYERS   ErrorCode = "NOT_ENOUGH_PLAYERS"
+	CODE_NOT_IN_TOURNAMENT    ErrorCode = "NOT_IN_TOURNAMENT"
+	CODE_TOURNAMENT_GAME      ErrorCode = "TOURNAMENT_GAME"
+
+	// teams
+	CODE_TEAM_NOT_FOUND             ErrorCode = "TEAM_NOT_FOUND"
+	CODE_TEAM_NAME_TAKEN            ErrorCode = "TEAM_NAME_TAKEN"
+	CODE_NOT_TEAM_MEMBER            ErrorCode = "NOT_TEAM_MEMBER"
+	CODE_NOT_TEAM_ADMIN             ErrorCode = "NOT_TEAM_ADMIN"
+	CODE_NOT_TEAM_OWNER             ErrorCode = "NOT_TEAM_OWNER"
+	CODE_GUESTS_CANNOT_CREATE_TEAMS ErrorCode = "GUESTS_CANNOT_CREATE_TEAMS"
+
+	// simuls
+	CODE_SIMUL_NOT_FOUND   ErrorCode = "SIMUL_NOT_FOUND"
+	CODE_NOT_SIMUL_HOST    ErrorCode = "NOT_SIMUL_HOST"
+	CODE_SIMUL_STARTED     ErrorCode = "SIMUL_STARTED"
+	CODE_SIMUL_FULL        ErrorCode = "SIMUL_FULL"
+	CODE_NOT_IN_SIMUL      ErrorCode = "NOT_IN_SIMUL"
+	CODE_NOT_CURRENT_BOARD ErrorCode = "NOT_CURRENT_BOARD"
+	CODE_SIMUL_GAME        ErrorCode = "SIMUL_GAME"
+	CODE_SIMUL_STREAMED    ErrorCode = "SIMUL_STREAMED"
+
+	// seeks
+	CODE_SEEK_NOT_FOUND ErrorCode = "SEEK_NOT_FOUND"
+	CODE_SEEK_GAME      ErrorCode = "SEEK_GAME"
+)
+
 var (
-	REASON_JSON_SYNTAX_ERROR   = Reason("json syntax error in body")
-	REASON_INTERNAL_ERROR      = Reason("internal server error")
-	REASON_INVALID_CREDENTIALS = Reason("invalid username/password")
-	REASON_INVALID_AUTH_HEADER = Reason("invalid Authorization header")
-	REASON_UNAUTHORIZED        = Reason("no api key in Authorization header. You must be authorized for this endpoint")
+	REASON_JSON_SYNTAX_ERROR   = Reason(CODE_INVALID_JSON, "json syntax error in body")
+	REASON_INTERNAL_ERROR      = Reason(CODE_INTERNAL_ERROR, "internal server error")
+	REASON_INVALID_CREDENTIALS = Reason(CODE_INVALID_CREDENTIALS, "invalid username/password")
+	REASON_INVALID_AUTH_HEADER = Reason(CODE_INVALID_AUTH_HEADER, "invalid Authorization header")
+	REASON_UNAUTHORIZED        = Reason(CODE_UNAUTHORIZED, "no api key in Authorization header. You must be authorized for this endpoint")
+	REASON_BANNED              = Reason(CODE_ACCOUNT_BANNED, "account is banned")
+	REASON_NOT_ADMIN           = Reason(CODE_NOT_ADMIN, "you must be an admin to use this endpoint")
+	REASON_SHUTTING_DOWN       = Reason(CODE_SHUTTING_DOWN, "server is restarting, try again shortly")
+	REASON_TOO_MANY_STREAMS    = Reason(CODE_TOO_MANY_STREAMS, "too many open streams, close one before opening another")
 )
 
 // Error reason
 type ErrorReason struct {
+	// stable identifier of the error, clients should check this instead of the reason
+	Code ErrorCode `json:"code" example:"MATCH_NOT_FOUND"`
+	// human readable explanation, it can change at any time
 	Reason string `json:"reason" example:"reason"`
+	// the invalid fields, when the request body did not pass validation
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+func Reason(code ErrorCode, reason string) ErrorReason {
+	return ErrorReason{Code: code, Reason: reason}
+}
+
+// codes of errors returned by echo itself, like unknown routes
+var statusCodes = map[int]ErrorCode{
+	http.StatusBadRequest:            CODE_INVALID_INPUT,
+	http.StatusUnauthorized:          CODE_UNAUTHORIZED,
+	http.StatusNotFound:              CODE_NOT_FOUND,
+	http.StatusMethodNotAllowed:      CODE_METHOD_NOT_ALLOWED,
+	http.StatusRequestEntityTooLarge: CODE_INVALID_INPUT,
+	http.StatusTooManyRequests:       CODE_RATE_LIMITED,
+	http.StatusServiceUnavailable:    CODE_NOT_READY,
 }
 
-func Reason(err string) ErrorReason {
-	return ErrorReason{err}
+// ErrorHandler responds to errors returned by handlers and middleware with an ErrorReason.
+func (s Server) ErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+	status, reason := http.StatusInternalServerError, REASON_INTERNAL_ERROR
+	if he, ok := err.(*echo.HTTPError); ok {
+		status = he.Code
+		switch m := he.Message.(type) {
+		case ErrorReason:
+			reason = m
+		case string:
+			code, ok := statusCodes[status]
+			if !ok {
+				code = CODE_INTERNAL_ERROR
+			}
+			reason = Reason(code, m)
+		}
+	} else {
+		slog.Error("unhandled error", "method", c.Request().Method, "path", c.Path(), "error", err)
+	}
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(status)
+	} else {
+		err = c.JSON(status, reason)
+	}
+	if err != nil {
+		slog.Warn("failed to send error response", "error", err)
+	}
 }
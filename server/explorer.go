@@ -0,0 +1,183 @@
+// the opening explorer, the moves played in the positions of archived games
+package server
+
+import (
+	"api/db"
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notnil/chess"
+)
+
+// half moves of each game counted in the explorer, later positions are rarely reached twice
+const EXPLORER_MAX_PLY = 30
+
+// ExplorerResults are how the games that reached a position or played a move ended
+type ExplorerResults struct {
+	Games int64 `json:"games" example:"10"`
+	White int64 `json:"white" example:"5"`
+	Draws int64 `json:"draws" example:"2"`
+	Black int64 `json:"black" example:"3"`
+	// of the games, rounded to one decimal
+	WhitePercent float64 `json:"whitePercent" example:"50"`
+	DrawPercent  float64 `json:"drawPercent" example:"20"`
+	BlackPercent float64 `json:"blackPercent" example:"30"`
+}
+
+// ExplorerMove is a move played in the explored position
+type ExplorerMove struct {
+	UCI     string          `json:"uci" example:"e2e4"`
+	SAN     string          `json:"san" example:"e4"`
+	Results ExplorerResults `json:"results"`
+}
+
+// Explorer is what was played in a position of archived games
+type Explorer struct {
+	// the explored position, after the moves of play
+	FEN string `json:"fen" example:"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1"`
+	// of the games that continued from the position
+	Results ExplorerResults `json:"results"`
+	// most played first
+	Moves []ExplorerMove `json:"moves"`
+}
+
+func explorerResults(white, draws, black int64) ExplorerResults {
+	r := ExplorerResults{Games: white + draws + black, White: white, Draws: draws, Black: black}
+	if r.Games > 0 {
+		percent := func(n int64) float64 { return math.Round(float64(n)*1000/float64(r.Games)) / 10 }
+		r.WhitePercent, r.DrawPercent, r.BlackPercent = percent(white), percent(draws), percent(black)
+	}
+	return r
+}
+
+// explorerPosition is the key of pos in the explorer_moves table: its FEN without the move counters.
+// The en passant square is only kept if the capture can be played, like in the FEN of other sites.
+func explorerPosition(pos *chess.Position) string {
+	fields := strings.Fields(pos.String())[:4]
+	if fields[3] != "-" {
+		fields[3] = "-"
+		for _, move := range pos.ValidMoves() {
+			if move.HasTag(chess.EnPassant) {
+				fields[3] = move.S2().String()
+				break
+			}
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// legalMove is the move in UCI notation if it can be played in pos, with the tags SAN needs, or nil
+func legalMove(pos *chess.Position, uci string) *chess.Move {
+	for _, valid := range pos.ValidMoves() {
+		if valid.String() == uci {
+			return valid
+		}
+	}
+	return nil
+}
+
+// addToExplorer counts the first EXPLORER_MAX_PLY moves of a finished game in the explorer.
+// result is the games.result column. A move played again in the same position of the game is counted once.
+func (s Server) addToExplorer(ctx context.Context, gameID int64, moves []string, result string) {
+	var white, draws, black int64
+	switch result {
+	case "white":
+		white = 1
+	case "black":
+		black = 1
+	default:
+		draws = 1
+	}
+	tx, err := s.SQL.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error("failed to add game to the explorer", "game", gameID, "error", err)
+		return
+	}
+	defer tx.Rollback()
+	q := s.DB.WithTx(tx)
+	g := chess.NewGame(chess.UseNotation(chess.UCINotation{}))
+	seen := map[string]bool{}
+	for _, move := range moves[:min(len(moves), EXPLORER_MAX_PLY)] {
+		position := explorerPosition(g.Position())
+		if err := g.MoveStr(move); err != nil {
+			slog.Error("failed to add game to the explorer", "game", gameID, "error", err)
+			return
+		}
+		if seen[position+" "+move] {
+			continue
+		}
+		seen[position+" "+move] = true
+		err := q.AddExplorerMove(ctx, db.AddExplorerMoveParams{
+			Position:  position,
+			Move:      move,
+			WhiteWins: white,
+			Draws:     draws,
+			BlackWins: black,
+		})
+		if err != nil {
+			slog.Error("failed to add game to the explorer", "game", gameID, "error", err)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		slog.Error("failed to add game to the explorer", "game", gameID, "error", err)
+	}
+}
+
+// @Summary		Explore the moves played in a position
+// @Description	Lists the moves played in a position of the games finished on this server, with how often each was played and how the games ended.
+// @Description	The first 30 half moves of every game are counted. Games count from the time this was added, older games are not in the explorer.
+// @Description	The position is `fen` after the moves of `play`, by default the starting position.
+// @Tags			explorer
+// @Produce		json
+// @Param			fen		query		string	false	"position to start from, the starting position by default"
+// @Param			play	query		string	false	"moves played from fen in UCI notation, separated by commas"	example(e2e4,e7e5)
+// @Success		200		{object}	Explorer
+// @Failure		400		{object}	ErrorReason	"Invalid fen or move"
+// @Failure		422		{object}	ErrorReason	"Illegal move"
+// @Failure		500		{object}	ErrorReason
+// @Router			/explorer [get]
+func (s Server) GetExplorer(c echo.Context) error {
+	position := chess.NewGame().Position()
+	if q := c.QueryParam("fen"); q != "" {
+		fen, err := chess.FEN(q)
+		if err != nil {
+			return invalidQuery("fen must be a position in FEN")
+		}
+		position = chess.NewGame(fen).Position()
+	}
+	if q := c.QueryParam("play"); q != "" {
+		for _, move := range strings.Split(q, ",") {
+			if _, err := (chess.UCINotation{}).Decode(position, move); err != nil {
+				return c.JSON(http.StatusBadRequest, Reason(CODE_INVALID_MOVE_NOTATION, "play must be moves in UCI notation separated by commas, eg. e2e4,e7e5"))
+			}
+			valid := legalMove(position, move)
+			if valid == nil {
+				return c.JSON(http.StatusUnprocessableEntity, Reason(CODE_ILLEGAL_MOVE, "illegal move "+move))
+			}
+			position = position.Update(valid)
+		}
+	}
+
+	rows, err := s.DB.ListExplorerMoves(c.Request().Context(), explorerPosition(position))
+	if err != nil {
+		slog.Error("failed to list explorer moves", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	explorer := Explorer{FEN: position.String(), Moves: make([]ExplorerMove, 0, len(rows))}
+	var white, draws, black int64
+	for _, row := range rows {
+		move := ExplorerMove{UCI: row.Move, Results: explorerResults(row.WhiteWins, row.Draws, row.BlackWins)}
+		if valid := legalMove(position, row.Move); valid != nil {
+			move.SAN = chess.AlgebraicNotation{}.Encode(position, valid)
+		}
+		explorer.Moves = append(explorer.Moves, move)
+		white, draws, black = white+row.WhiteWins, draws+row.Draws, black+row.BlackWins
+	}
+	explorer.Results = explorerResults(white, draws, black)
+	return c.JSON(http.StatusOK, explorer)
+}
@@ -0,0 +1,245 @@
+// exporting and deleting user data
+package server
+
+import (
+	"api/db"
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// how long a deleted account can still be restored by logging in
+const ACCOUNT_DELETION_GRACE_PERIOD = time.Hour * 24 * 30
+
+// username shown for players who deleted their account
+const DELETED_USERNAME = "anonymous"
+
+// ExportedGame is the metadata of an archived game in a data export
+type ExportedGame struct {
+	ID         int64     `json:"id" example:"1"`
+	White      string    `json:"white" example:"JohnDoe"`
+	Black      string    `json:"black" example:"JaneDoe"`
+	Result     string    `json:"result" example:"white"`
+	FinishedAt time.Time `json:"finishedAt" format:"date-time"`
+	// opening that was played, empty if the first move is not in the ECO book
+	ECO     string `json:"eco,omitempty" example:"C20"`
+	Opening string `json:"opening,omitempty" example:"King's Pawn Game"`
+	// movetext in PGN, only in game exports. account.json leaves it out, the moves are in games.pgn.
+	Moves string `json:"moves,omitempty" example:"1. e4 e5 2. Nf3 *"`
+}
+
+// AccountExport is the account.json file in a data export
+type AccountExport struct {
+	User User `json:"user"`
+	// empty if you did not give one
+	Email      string         `json:"email" example:"john@example.com"`
+	ExportedAt time.Time      `json:"exportedAt" format:"date-time"`
+	Games      []ExportedGame `json:"games"`
+}
+
+// exportArchive builds a zip archive with account.json and games.pgn
+func (s Server) exportArchive(ctx context.Context, user db.User, games []db.Game) ([]byte, error) {
+	names := usernameCache{s: s, names: map[int64]string{}}
+	account := AccountExport{
+		User:       UserFromDbUser(user),
+		Email:      user.Email,
+		ExportedAt: time.Now().UTC(),
+		Games:      make([]ExportedGame, 0, len(games)),
+	}
+	var pgn bytes.Buffer
+	for _, g := range games {
+		exported := ExportedGame{
+			ID:         g.ID,
+			White:      names.get(ctx, g.WhiteUid),
+			Black:      names.get(ctx, g.BlackUid),
+			Result:     g.Result,
+			FinishedAt: g.FinishedAt,
+			ECO:        g.Eco,
+			Opening:    g.Opening,
+		}
+		account.Games = append(account.Games, exported)
+		writePGN(&pgn, exported, g.Moves)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, err := zw.Create("account.json")
+	if err != nil {
+		return nil, err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(account); err != nil {
+		return nil, err
+	}
+	f, err = zw.Create("games.pgn")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(pgn.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// games read from the database at a time by streamGames
+const EXPORT_BATCH_SIZE = 100
+
+// export formats of GET /users/{username}/games/export
+const (
+	EXPORT_FORMAT_PGN    = "pgn"
+	EXPORT_FORMAT_NDJSON = "ndjson"
+)
+
+// streamGames writes the games of params.Uid to w in format, EXPORT_BATCH_SIZE at a time starting after params.AfterID.
+// Every batch is flushed, so the client gets games while the rest are read.
+func (s Server) streamGames(ctx context.Context, w *echo.Response, format string, params db.ListGamesOfPlayerAfterParams) error {
+	names := usernameCache{s: s, names: map[int64]string{}}
+	params.Limit = EXPORT_BATCH_SIZE
+	for {
+		games, err := s.DB.ListGamesOfPlayerAfter(ctx, params)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		for _, g := range games {
+			exported := ExportedGame{
+				ID:         g.ID,
+				White:      names.get(ctx, g.WhiteUid),
+				Black:      names.get(ctx, g.BlackUid),
+				Result:     g.Result,
+				FinishedAt: g.FinishedAt,
+				ECO:        g.Eco,
+				Opening:    g.Opening,
+			}
+			if format == EXPORT_FORMAT_PGN {
+				writePGN(&buf, exported, g.Moves)
+				continue
+			}
+			exported.Moves = strings.TrimSpace(g.Moves)
+			line, err := json.Marshal(exported)
+			if err != nil {
+				return err
+			}
+			buf.Write(append(line, '\n'))
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+		w.Flush()
+		if len(games) < EXPORT_BATCH_SIZE {
+			return nil
+		}
+		params.AfterID = games[len(games)-1].ID
+	}
+}
+
+// writePGN writes an archived game with its tag pairs.
+// moves is the movetext stored in the games table.
+func writePGN(w io.Writer, g ExportedGame, moves string) {
+	fmt.Fprintf(w, "[Event \"Chess API game\"]\n")
+	fmt.Fprintf(w, "[Site \"?\"]\n")
+	fmt.Fprintf(w, "[Date \"%s\"]\n", g.FinishedAt.Format("2006.01.02"))
+	fmt.Fprintf(w, "[Round \"-\"]\n")
+	fmt.Fprintf(w, "[White \"%s\"]\n", g.White)
+	fmt.Fprintf(w, "[Black \"%s\"]\n", g.Black)
+	fmt.Fprintf(w, "[Result \"%s\"]\n", pgnResult(g.Result))
+	if g.ECO != "" {
+		fmt.Fprintf(w, "[ECO \"%s\"]\n", g.ECO)
+		fmt.Fprintf(w, "[Opening \"%s\"]\n", g.Opening)
+	}
+	fmt.Fprintf(w, "[GameId \"%d\"]\n\n", g.ID)
+	fmt.Fprintf(w, "%s\n\n", strings.TrimSpace(moves))
+}
+
+// convert the games.result column into a PGN result
+func pgnResult(result string) string {
+	switch result {
+	case "white":
+		return "1-0"
+	case "black":
+		return "0-1"
+	default:
+		return "1/2-1/2"
+	}
+}
+
+// usernameCache avoids looking up the same opponent for every game
+type usernameCache struct {
+	s     Server
+	names map[int64]string
+}
+
+func (c usernameCache) get(ctx context.Context, uid int64) string {
+	if name, ok := c.names[uid]; ok {
+		return name
+	}
+	name := DELETED_USERNAME
+	if user, err := c.s.DB.GetUserById(ctx, uid); err == nil && !user.DeletedAt.Valid {
+		name = user.Username
+	}
+	c.names[uid] = name
+	return name
+}
+
+// purgeDeletedUsers permanently deletes accounts whose grace period has passed,
+// after anonymizing their archived games.
+func (s Server) purgeDeletedUsers(ctx context.Context) {
+	before := sql.NullTime{Time: time.Now().UTC().Add(-ACCOUNT_DELETION_GRACE_PERIOD), Valid: true}
+	users, err := s.DB.ListUsersDeletedBefore(ctx, before)
+	if err != nil {
+		slog.Warn("failed to list deleted users", "error", err)
+		return
+	}
+	for _, user := range users {
+		tx, err := s.SQL.BeginTx(ctx, nil)
+		if err != nil {
+			slog.Warn("failed to begin transaction", "error", err)
+			return
+		}
+		q := s.DB.WithTx(tx)
+		if err := q.AnonymizeGamesOfPlayer(ctx, user.Uid); err != nil {
+			tx.Rollback()
+			slog.Warn("failed to anonymize games", "username", user.Username, "error", err)
+			continue
+		}
+		if err := q.DeleteFriendshipsOfUser(ctx, user.Uid); err != nil {
+			tx.Rollback()
+			slog.Warn("failed to delete friendships", "username", user.Username, "error", err)
+			continue
+		}
+		if err := q.DeleteBlocksOfUser(ctx, user.Uid); err != nil {
+			tx.Rollback()
+			slog.Warn("failed to delete blocks", "username", user.Username, "error", err)
+			continue
+		}
+		if err := q.DeleteNotificationsOfUser(ctx, user.Uid); err != nil {
+			tx.Rollback()
+			slog.Warn("failed to delete notifications", "username", user.Username, "error", err)
+			continue
+		}
+		if err := q.DeleteUser(ctx, user.Uid); err != nil {
+			tx.Rollback()
+			slog.Warn("failed to delete user", "username", user.Username, "error", err)
+			continue
+		}
+		if err := tx.Commit(); err != nil {
+			slog.Warn("failed to commit user deletion", "username", user.Username, "error", err)
+			continue
+		}
+		slog.Info("deleted user", "username", user.Username)
+		s.auditWithIP(ctx, AUDIT_ACCOUNT_PURGED, "", user.Username, "", "deletion grace period ended")
+	}
+}
@@ -0,0 +1,419 @@
+// fair play: finding players who use an engine in rated games
+package server
+
+import (
+	"api/db"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notnil/chess"
+)
+
+const (
+	// how long the fair-play analysis waits once every rated game was analyzed, or after the engine failed
+	FAIR_PLAY_INTERVAL = time.Minute
+	// how deep and how long the engine searches each position
+	FAIR_PLAY_SEARCH_DEPTH = 12
+	FAIR_PLAY_SEARCH_TIME  = 200 * time.Millisecond
+	// positions before this ply are opening theory, everyone plays the engine's moves there
+	FAIR_PLAY_MIN_PLY = 8
+	// positions this many centipawns from equal are decided, many moves win them
+	FAIR_PLAY_DECIDED_SCORE = 500
+	// a side with fewer analyzed moves gets no suspicion, there is too little to tell
+	FAIR_PLAY_MIN_MOVES = 10
+	// how often strong humans find the engine's best move, matching it less often is not suspicious
+	FAIR_PLAY_HUMAN_MATCH_RATE = 0.5
+	// games at least this suspicious are reported to the admins right away
+	FAIR_PLAY_REPORT_SUSPICION = 80
+	// players are flagged when their games of the last FAIR_PLAY_WINDOW are this suspicious on average
+	FAIR_PLAY_FLAG_SUSPICION = 60
+	FAIR_PLAY_FLAG_MIN_GAMES = 3
+	FAIR_PLAY_WINDOW         = 30 * 24 * time.Hour
+	// how long rated games wait for the analysis, when the server runs without it
+	FAIR_PLAY_QUEUE_RETENTION = 7 * 24 * time.Hour
+)
+
+// MoveClock remembers when the moves of ongoing rated matches were played, to time them once the game is archived.
+// Matches resumed after a restart have no times for the moves played before it.
+type MoveClock struct {
+	mu sync.Mutex
+	// match id -> when each ply was played, zero if it is not known
+	played map[string][]time.Time
+}
+
+func NewMoveClock() *MoveClock {
+	return &MoveClock{played: map[string][]time.Time{}}
+}
+
+// Add records that ply was played now. It is called from the game storage's OnMove hook, so it must not block.
+func (mc *MoveClock) Add(matchID string, ply int) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	times := mc.played[matchID]
+	for len(times) < ply {
+		times = append(times, time.Time{})
+	}
+	times[ply-1] = time.Now()
+	mc.played[matchID] = times
+}
+
+// Take forgets the match and returns how long each of its moves took, -1 when it is not known.
+// The first move is never timed, nobody was waiting for it.
+func (mc *MoveClock) Take(matchID string) []time.Duration {
+	mc.mu.Lock()
+	times := mc.played[matchID]
+	delete(mc.played, matchID)
+	mc.mu.Unlock()
+	took := make([]time.Duration, len(times))
+	for i := range times {
+		took[i] = -1
+		if i > 0 && !times[i].IsZero() && !times[i-1].IsZero() {
+			took[i] = times[i].Sub(times[i-1])
+		}
+	}
+	return took
+}
+
+// cleanup forgets the matches that ended without being archived
+func (mc *MoveClock) cleanup(exists func(matchID string) bool) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	for matchID := range mc.played {
+		if !exists(matchID) {
+			delete(mc.played, matchID)
+		}
+	}
+}
+
+// queueFairPlay stores the move times of an archived rated game, for AnalyzeFairPlay
+func (s Server) queueFairPlay(ctx context.Context, gameID int64, matchID string) {
+	took := s.MoveClock.Take(matchID)
+	ms := make([]string, len(took))
+	for i, d := range took {
+		ms[i] = "-1"
+		if d >= 0 {
+			ms[i] = strconv.FormatInt(d.Milliseconds(), 10)
+		}
+	}
+	err := s.DB.QueueFairPlayGame(ctx, db.QueueFairPlayGameParams{GameID: gameID, MoveTimes: strings.Join(ms, ",")})
+	if err != nil {
+		slog.Error("failed to queue game for the fair-play analysis", "game", gameID, "error", err)
+	}
+}
+
+// parseMoveTimes reads the move_times column, see queueFairPlay
+func parseMoveTimes(moveTimes string) []time.Duration {
+	if moveTimes == "" {
+		return nil
+	}
+	fields := strings.Split(moveTimes, ",")
+	took := make([]time.Duration, len(fields))
+	for i, f := range fields {
+		ms, err := strconv.ParseInt(f, 10, 64)
+		if err != nil {
+			ms = -1
+		}
+		took[i] = time.Duration(ms) * time.Millisecond
+	}
+	return took
+}
+
+// sideAnalysis is how one player played in a game
+type sideAnalysis struct {
+	moves, engineMatches int
+	// times of the moves that were timed
+	took []time.Duration
+}
+
+// moveTimes are the average and the standard deviation of the timed moves
+func (a sideAnalysis) moveTimes() (average, deviation time.Duration) {
+	if len(a.took) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, d := range a.took {
+		sum += float64(d)
+	}
+	mean := sum / float64(len(a.took))
+	var squares float64
+	for _, d := range a.took {
+		squares += (float64(d) - mean) * (float64(d) - mean)
+	}
+	return time.Duration(mean), time.Duration(math.Sqrt(squares / float64(len(a.took))))
+}
+
+// suspicion is 0 to 100, how much the moves look like an engine's.
+// Finding the engine's best move more often than humans do gives up to 70,
+// moves that always take about as long give up to 30.
+func (a sideAnalysis) suspicion() int {
+	if a.moves < FAIR_PLAY_MIN_MOVES {
+		return 0
+	}
+	rate := float64(a.engineMatches) / float64(a.moves)
+	score := 70 * (rate - FAIR_PLAY_HUMAN_MATCH_RATE) / (1 - FAIR_PLAY_HUMAN_MATCH_RATE)
+	if average, deviation := a.moveTimes(); len(a.took) >= FAIR_PLAY_MIN_MOVES && average > 0 {
+		// humans take longer on hard moves, a variation of half the average or more is human
+		variation := float64(deviation) / float64(average)
+		score += 30 * max(0, 1-2*variation)
+	}
+	return int(math.Round(min(100, max(0, score))))
+}
+
+// AnalyzeFairPlay analyzes every queued rated game with the computer's engines, oldest first, and stores how each player played.
+// Games at least FAIR_PLAY_REPORT_SUSPICION suspicious are reported. It keeps waiting for new games until ctx is cancelled.
+func (s Server) AnalyzeFairPlay(ctx context.Context) {
+	if s.Computer == nil {
+		return
+	}
+	for ctx.Err() == nil {
+		g, err := s.DB.NextFairPlayGame(ctx)
+		if err == nil {
+			err = s.analyzeFairPlay(ctx, g)
+		}
+		if err == nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.Warn("fair-play analysis failed, trying again later", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(FAIR_PLAY_INTERVAL):
+		}
+	}
+}
+
+// analyzeFairPlay stores the analysis of both players of g and takes it off the queue.
+// Nothing is stored if the engine fails, so the game is analyzed again.
+func (s Server) analyzeFairPlay(ctx context.Context, g db.NextFairPlayGameRow) error {
+	var sides [2]sideAnalysis
+	// a game that cannot be read is not analyzed, it is not retried
+	if played, err := readPGN(g.Moves); err != nil {
+		slog.Warn("fair-play analysis skipped a game it cannot read", "game", g.GameID, "error", err)
+	} else {
+		took := parseMoveTimes(g.MoveTimes)
+		positions := played.Positions()
+		for ply, move := range played.Moves() {
+			side := &sides[ply%2]
+			if ply < len(took) && took[ply] >= 0 {
+				side.took = append(side.took, took[ply])
+			}
+			pos := positions[ply]
+			// a forced move says nothing
+			if ply < FAIR_PLAY_MIN_PLY || len(pos.ValidMoves()) < 2 {
+				continue
+			}
+			lines, err := s.Computer.engines.Analyse(ctx, pos.String(), 1, FAIR_PLAY_SEARCH_DEPTH, FAIR_PLAY_SEARCH_TIME)
+			if err != nil {
+				return err
+			}
+			if len(lines) == 0 || abs(lines[0].score) >= FAIR_PLAY_DECIDED_SCORE {
+				continue
+			}
+			side.moves++
+			if lines[0].move == (chess.UCINotation{}).Encode(pos, move) {
+				side.engineMatches++
+			}
+		}
+	}
+
+	// bots play with an engine, and players who deleted their account are gone
+	var players [2]db.User
+	for i, uid := range []int64{g.WhiteUid, g.BlackUid} {
+		user, err := s.DB.GetUserById(ctx, uid)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !user.IsBot && !user.DeletedAt.Valid {
+			players[i] = user
+		}
+	}
+
+	tx, err := s.SQL.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := s.DB.WithTx(tx)
+	for i, side := range sides {
+		if players[i].Uid == 0 {
+			continue
+		}
+		average, deviation := side.moveTimes()
+		err := q.StoreFairPlayAnalysis(ctx, db.StoreFairPlayAnalysisParams{
+			GameID:            g.GameID,
+			Uid:               players[i].Uid,
+			Moves:             int64(side.moves),
+			EngineMatches:     int64(side.engineMatches),
+			AverageMoveTime:   average.Milliseconds(),
+			MoveTimeDeviation: deviation.Milliseconds(),
+			Suspicion:         int64(side.suspicion()),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := q.DeleteFairPlayGame(ctx, g.GameID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for i, side := range sides {
+		if players[i].Uid != 0 && side.suspicion() >= FAIR_PLAY_REPORT_SUSPICION {
+			s.reportAutomatically(ctx, players[i].Username, g.MatchID, REPORT_CHEATING,
+				fmt.Sprintf("game %d: found the engine's best move %d times in %d moves, suspicion %d", g.GameID, side.engineMatches, side.moves, side.suspicion()))
+		}
+	}
+	return nil
+}
+
+// purgeOldFairPlayGames deletes queued games older than FAIR_PLAY_QUEUE_RETENTION, nobody is analyzing them
+func (s Server) purgeOldFairPlayGames(ctx context.Context) {
+	deleted, err := s.DB.DeleteOldFairPlayGames(ctx, time.Now().UTC().Add(-FAIR_PLAY_QUEUE_RETENTION))
+	if err != nil {
+		slog.Warn("failed to delete old fair-play games", "error", err)
+	} else if deleted > 0 {
+		slog.Info("deleted rated games nobody analyzed", "count", deleted)
+	}
+}
+
+// FlaggedPlayer is a player whose recent rated games look like engine play
+type FlaggedPlayer struct {
+	Username string `json:"username" example:"JohnDoe"`
+	// rated games analyzed in the last 30 days
+	Games int64 `json:"games" example:"5"`
+	// average of the games, 0 to 100
+	Suspicion int64 `json:"suspicion" example:"72"`
+	// of the analyzed moves, how many were the engine's best move
+	Moves         int64 `json:"moves" example:"120"`
+	EngineMatches int64 `json:"engineMatches" example:"104"`
+	// unresolved cheating reports about the player
+	OpenReports int64 `json:"openReports" example:"2"`
+}
+
+// FairPlayGame is how a player played in one rated game
+type FairPlayGame struct {
+	GameID  int64  `json:"gameId" example:"7"`
+	MatchID string `json:"matchId" example:"AB2C21"`
+	// positions the engine analyzed, and how many times the player found its best move
+	Moves         int64 `json:"moves" example:"24"`
+	EngineMatches int64 `json:"engineMatches" example:"21"`
+	// of the timed moves, in milliseconds
+	AverageMoveTime   int64 `json:"averageMoveTime" example:"4200"`
+	MoveTimeDeviation int64 `json:"moveTimeDeviation" example:"600"`
+	// 0 to 100, how much the game looks like engine play
+	Suspicion  int64     `json:"suspicion" example:"81"`
+	FinishedAt time.Time `json:"finishedAt" format:"date-time"`
+}
+
+// @Summary		List players flagged for engine use
+// @Description	Rated games are analyzed with the engine when the server runs with -fair-play. Each player gets a suspicion from 0 to 100 per game,
+// @Description	from how often they found the engine's best move and how regular their move times were.
+// @Description	Players are flagged when their games of the last 30 days are at least 60 suspicious on average, over at least 3 games. Most suspicious first.
+// @Description	Games at least 80 suspicious are also reported in the `cheating` category.
+// @Tags			admin
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey of an admin in the format Bearer: apiKey"
+// @Param			limit			query		int		false	"default 50, max 500"
+// @Param			offset			query		int		false	"default 0"
+// @Success		200				{array}		FlaggedPlayer
+// @Failure		400				{object}	ErrorReason	"Invalid query"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Not an admin"
+// @Failure		500				{object}	ErrorReason
+// @Router			/admin/fair-play [get]
+func (s Server) AdminListFlaggedPlayers(c echo.Context) error {
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return err
+	}
+	flagged, err := s.DB.ListFlaggedPlayers(c.Request().Context(), db.ListFlaggedPlayersParams{
+		Since:        time.Now().UTC().Add(-FAIR_PLAY_WINDOW),
+		MinGames:     FAIR_PLAY_FLAG_MIN_GAMES,
+		MinSuspicion: FAIR_PLAY_FLAG_SUSPICION,
+		Limit:        limit,
+		Offset:       offset,
+	})
+	if err != nil {
+		slog.Error("failed to list flagged players", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	result := make([]FlaggedPlayer, 0, len(flagged))
+	for _, f := range flagged {
+		result = append(result, FlaggedPlayer{
+			Username:      f.Username,
+			Games:         f.Games,
+			Suspicion:     f.Suspicion,
+			Moves:         f.Moves,
+			EngineMatches: f.EngineMatches,
+			OpenReports:   f.OpenReports,
+		})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary		List the fair-play analyses of a player
+// @Description	How the player played in each analyzed rated game, newest first. See GET /admin/fair-play.
+// @Tags			admin
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey of an admin in the format Bearer: apiKey"
+// @Param			username		path		string	true	"Username"
+// @Param			limit			query		int		false	"default 50, max 500"
+// @Param			offset			query		int		false	"default 0"
+// @Success		200				{array}		FairPlayGame
+// @Failure		400				{object}	ErrorReason	"Invalid query"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Not an admin"
+// @Failure		404				{object}	ErrorReason	"User not found"
+// @Failure		500				{object}	ErrorReason
+// @Router			/admin/users/{username}/fair-play [get]
+func (s Server) AdminListFairPlayGames(c echo.Context) error {
+	user, err := s.userFromParam(c)
+	if err != nil {
+		return err
+	}
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return err
+	}
+	analyses, err := s.DB.ListFairPlayAnalysesOfUser(c.Request().Context(), db.ListFairPlayAnalysesOfUserParams{
+		Uid:    user.Uid,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		slog.Error("failed to list fair-play analyses", "username", user.Username, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	result := make([]FairPlayGame, 0, len(analyses))
+	for _, a := range analyses {
+		result = append(result, FairPlayGame{
+			GameID:            a.GameID,
+			MatchID:           a.MatchID,
+			Moves:             a.Moves,
+			EngineMatches:     a.EngineMatches,
+			AverageMoveTime:   a.AverageMoveTime,
+			MoveTimeDeviation: a.MoveTimeDeviation,
+			Suspicion:         a.Suspicion,
+			FinishedAt:        a.FinishedAt,
+		})
+	}
+	return c.JSON(http.StatusOK, result)
+}
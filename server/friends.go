@@ -0,0 +1,213 @@
+// friend requests and friend lists
+package server
+
+import (
+	"api/db"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Friend is a user in your friend list along with their presence
+type Friend struct {
+	Username string   `json:"username" example:"JohnDoe"`
+	Presence Presence `json:"presence"`
+}
+
+// FriendRequest is a pending friend request sent to you
+type FriendRequest struct {
+	From   string    `json:"from" example:"JohnDoe"`
+	SentAt time.Time `json:"sentAt" format:"date-time"`
+}
+
+// @Summary		List your friends
+// @Description	Lists your friends and whether they are online or playing a match.
+// @Tags			friends
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Success		200				{array}		Friend
+// @Failure		401				{object}	ErrorReason
+// @Failure		500				{object}	ErrorReason
+// @Router			/users/me/friends [get]
+func (s Server) ListFriends(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	usernames, err := s.DB.ListFriendUsernames(c.Request().Context(), user.Uid)
+	if err != nil {
+		slog.Error("failed to list friends", "username", user.Username, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	friends := make([]Friend, 0, len(usernames))
+	for _, username := range usernames {
+		friends = append(friends, Friend{
+			Username: username,
+			Presence: s.Presence.Get(username),
+		})
+	}
+	return c.JSON(http.StatusOK, friends)
+}
+
+// @Summary	List incoming friend requests
+// @Tags		friends
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Success	200				{array}		FriendRequest
+// @Failure	401				{object}	ErrorReason
+// @Failure	500				{object}	ErrorReason
+// @Router		/users/me/friends/requests [get]
+func (s Server) ListFriendRequests(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	rows, err := s.DB.ListIncomingFriendRequests(c.Request().Context(), user.Uid)
+	if err != nil {
+		slog.Error("failed to list friend requests", "username", user.Username, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	requests := make([]FriendRequest, 0, len(rows))
+	for _, row := range rows {
+		requests = append(requests, FriendRequest{From: row.Username, SentAt: row.CreatedAt})
+	}
+	return c.JSON(http.StatusOK, requests)
+}
+
+// @Summary		Send a friend request
+// @Description	Sends a friend request to a user.
+// @Description	If they already sent you a friend request, it is accepted instead.
+// @Tags			friends
+// @Produce		json
+// @Param			Authorization	header		string		true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			username		path		string		true	"Username of the user to befriend"
+// @Success		201				{object}	string		"requested"
+// @Success		200				{object}	string		"accepted"
+// @Failure		400				{object}	ErrorReason	"Cannot befriend yourself"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Blocked"
+// @Failure		404				{object}	ErrorReason	"User not found"
+// @Failure		409				{object}	ErrorReason	"Already friends / already requested"
+// @Failure		500				{object}	ErrorReason
+// @Router			/users/me/friends/{username} [post]
+func (s Server) SendFriendRequest(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	other, err := s.userFromParam(c)
+	if err != nil {
+		return err
+	}
+	if other.Uid == user.Uid {
+		return c.JSON(http.StatusBadRequest, Reason(CODE_CANNOT_TARGET_SELF, "Cannot befriend yourself"))
+	}
+	ctx := c.Request().Context()
+	blocked, err := s.blockedBetween(ctx, user.Username, other.Username)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	if blocked {
+		return c.JSON(http.StatusForbidden, Reason(CODE_BLOCKED, "You cannot befriend this user"))
+	}
+	friendship, err := s.DB.GetFriendship(ctx, db.GetFriendshipParams{Uid: user.Uid, OtherUid: other.Uid})
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		err = s.DB.CreateFriendRequest(ctx, db.CreateFriendRequestParams{
+			RequesterUid: user.Uid,
+			AddresseeUid: other.Uid,
+		})
+		if err != nil {
+			slog.Error("failed to create friend request", "error", err)
+			return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+		}
+		s.notify(ctx, other, NotifyFriendRequest, user.Username, "")
+		return c.JSON(http.StatusCreated, "requested")
+	case err != nil:
+		slog.Error("failed to get friendship", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	case friendship.Accepted:
+		return c.JSON(http.StatusConflict, Reason(CODE_ALREADY_FRIENDS, "Already friends"))
+	case friendship.RequesterUid == user.Uid:
+		return c.JSON(http.StatusConflict, Reason(CODE_FRIEND_REQUEST_EXISTS, "Friend request already sent"))
+	default:
+		// they already asked us
+		return s.acceptFriendRequest(c, user, other)
+	}
+}
+
+// @Summary	Accept a friend request
+// @Tags		friends
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param		username		path		string	true	"Username of the user who sent the request"
+// @Success	200				{object}	string	"accepted"
+// @Failure	401				{object}	ErrorReason
+// @Failure	404				{object}	ErrorReason	"User not found / no pending friend request"
+// @Failure	500				{object}	ErrorReason
+// @Router		/users/me/friends/{username} [put]
+func (s Server) AcceptFriendRequest(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	other, err := s.userFromParam(c)
+	if err != nil {
+		return err
+	}
+	return s.acceptFriendRequest(c, user, other)
+}
+
+func (s Server) acceptFriendRequest(c echo.Context, user, requester db.User) error {
+	accepted, err := s.DB.AcceptFriendRequest(c.Request().Context(), db.AcceptFriendRequestParams{
+		RequesterUid: requester.Uid,
+		AddresseeUid: user.Uid,
+	})
+	if err != nil {
+		slog.Error("failed to accept friend request", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	if accepted == 0 {
+		return c.JSON(http.StatusNotFound, Reason(CODE_NO_FRIEND_REQUEST, "No pending friend request from this user"))
+	}
+	s.notify(c.Request().Context(), requester, NotifyFriendAccepted, user.Username, "")
+	return c.JSON(http.StatusOK, "accepted")
+}
+
+// @Summary		Remove a friend
+// @Description	Removes a friend, or declines / cancels a pending friend request.
+// @Tags			friends
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			username		path		string	true	"Username of the friend"
+// @Success		200				{object}	string	"removed"
+// @Failure		401				{object}	ErrorReason
+// @Failure		404				{object}	ErrorReason	"User not found / not friends"
+// @Failure		500				{object}	ErrorReason
+// @Router			/users/me/friends/{username} [delete]
+func (s Server) RemoveFriend(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	other, err := s.userFromParam(c)
+	if err != nil {
+		return err
+	}
+	removed, err := s.DB.DeleteFriendship(c.Request().Context(), db.DeleteFriendshipParams{
+		Uid:      user.Uid,
+		OtherUid: other.Uid,
+	})
+	if err != nil {
+		slog.Error("failed to remove friend", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	if removed == 0 {
+		return c.JSON(http.StatusNotFound, Reason(CODE_NOT_FRIENDS, "Not friends with this user"))
+	}
+	return c.JSON(http.StatusOK, "removed")
+}
@@ -0,0 +1,28 @@
+package game
+
+import (
+	"math/rand/v2"
+
+	"github.com/notnil/chess"
+)
+
+// RandomMove picks one of the legal moves in pos, in UCI notation. ok is false if there are none.
+// It is used by bots, like the load test in cmd/simulate.
+func RandomMove(pos *chess.Position, r *rand.Rand) (move string, ok bool) {
+	moves := pos.ValidMoves()
+	if len(moves) == 0 {
+		return "", false
+	}
+	return chess.UCINotation{}.Encode(pos, moves[r.IntN(len(moves))]), true
+}
+
+// ScriptedMoves are the moves played in g, in UCI notation.
+// Bots replay games read from PGN to play the same games every run.
+func ScriptedMoves(g *chess.Game) []string {
+	moves := make([]string, 0, len(g.Moves()))
+	positions := g.Positions()
+	for i, m := range g.Moves() {
+		moves = append(moves, chess.UCINotation{}.Encode(positions[i], m))
+	}
+	return moves
+}
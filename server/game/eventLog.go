@@ -0,0 +1,51 @@
+package game
+
+import "slices"
+
+// events a match keeps for players who poll for them instead of streaming them
+const EVENT_LOG_SIZE = 256
+
+// LoggedEvent is an event sent to a player, numbered in the order the match sent its events.
+type LoggedEvent struct {
+	// 1 is the first event of the match. Numbers start over when the server restarts.
+	Seq int64 `json:"seq" example:"3"`
+	Event
+}
+
+// an event of the log and who it was sent to
+type logEntry struct {
+	seq   int64
+	to    string
+	event Event
+}
+
+// appendLog records that e was sent to username and wakes the players waiting for events. It runs on the event loop.
+func (m *Match) appendLog(username string, e Event) {
+	m.logSeq++
+	m.eventLog = append(m.eventLog, logEntry{seq: m.logSeq, to: username, event: e})
+	if len(m.eventLog) > EVENT_LOG_SIZE {
+		m.eventLog = slices.Delete(m.eventLog, 0, 1)
+	}
+	close(m.logged)
+	m.logged = make(chan struct{})
+}
+
+// EventsSince are the events sent to username after the event numbered since, and the number of the last event of the match.
+// If the match no longer has all of them, there is a single resync event with the current position instead.
+// changed is closed once the match sends its next event.
+func (m *Match) EventsSince(username string, since int64) (events []LoggedEvent, last int64, changed <-chan struct{}) {
+	m.read(func() {
+		last, changed = m.logSeq, m.logged
+		// events were dropped from the log, or the numbers started over after a restart
+		if since > m.logSeq || (len(m.eventLog) > 0 && since < m.eventLog[0].seq-1) {
+			events = []LoggedEvent{{Seq: m.logSeq, Event: EventResync(m.game.FEN())}}
+			return
+		}
+		for _, entry := range m.eventLog {
+			if entry.seq > since && entry.to == username {
+				events = append(events, LoggedEvent{Seq: entry.seq, Event: entry.event})
+			}
+		}
+	})
+	return events, last, changed
+}
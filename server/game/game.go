@@ -3,35 +3,101 @@ package game
 import (
 	"context"
 	"crypto/rand"
+	"encoding/json"
+	"errors"
 	"log/slog"
-	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/notnil/chess"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("api/server/game")
+
 type EventType string
 
 const (
 	Move         EventType = "move"
 	OpponentInfo EventType = "opponent"
 	Resign       EventType = "resign"
+	Chat         EventType = "chat"
+	// an admin deleted the match
+	Aborted EventType = "aborted"
+	// an admin decided the result of the match
+	Adjudicated EventType = "adjudicated"
+	// the server is shutting down, join the match again once it is back
+	ServerRestarting EventType = "serverRestarting"
+	// the client did not read events fast enough and missed some. The stream ends,
+	// continue from the position in fen and join the match again.
+	Resync EventType = "resync"
+	// the game left the opening book, eco and opening name the opening that was played
+	OpeningDetected EventType = "opening"
+	// the opponent's stream ended without resigning, they can join the match again
+	OpponentDisconnected EventType = "opponentDisconnected"
+	// the opponent joined the match again after they disconnected
+	OpponentConnected EventType = "opponentConnected"
+	// someone started or stopped watching the match, spectators is how many watch it now
+	SpectatorJoined EventType = "spectatorJoined"
+	SpectatorLeft   EventType = "spectatorLeft"
 )
 
 type Event struct {
 	Type            EventType
 	Move            string     `json:"move,omitempty" example:"e2e4"` // Move in UCI notation
 	OponentUsername string     `json:"oponentUsername,omitempty" example:"JohnDoe"`
-	OpponentBlack   bool       `json:"opponentBlack" example:"false"`          // is the opponent using the black pieces
-	StartTime       *time.Time `json:"startTime,omitempty" format:"date-time"` // when this match was creatd
-	EndTime         *time.Time `json:"endTime,omitempty" format:"date-time"`   // when this match will be deleted if the game does not end.
+	OpponentBlack   bool       `json:"opponentBlack" example:"false"`                                                       // is the opponent using the black pieces
+	StartTime       *time.Time `json:"startTime,omitempty" format:"date-time"`                                              // when this match was creatd
+	EndTime         *time.Time `json:"endTime,omitempty" format:"date-time"`                                                // when this match will be deleted if the game does not end.
+	From            string     `json:"from,omitempty" example:"JohnDoe"`                                                    // who sent the chat message
+	Message         string     `json:"message,omitempty" example:"good luck!"`                                              // chat message
+	Result          string     `json:"result,omitempty" example:"1-0"`                                                      // result decided by an admin
+	FEN             string     `json:"fen,omitempty" example:"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"` // position after the move, or the current position on resync
+	SAN             string     `json:"san,omitempty" example:"e4"`                                                          // Move in standard algebraic notation
+	Check           bool       `json:"check,omitempty" example:"false"`                                                     // the move gives check
+	Checkmate       bool       `json:"checkmate,omitempty" example:"false"`                                                 // the move ends the game by checkmate
+	Capture         bool       `json:"capture,omitempty" example:"false"`                                                   // the move takes a piece, en passant too
+	Castle          bool       `json:"castle,omitempty" example:"false"`                                                    // the move castles either side
+	Promotion       bool       `json:"promotion,omitempty" example:"false"`                                                 // the move promotes a pawn, san has the new piece
+	ECO             string     `json:"eco,omitempty" example:"C20"`                                                         // ECO code of the opening
+	OpeningName     string     `json:"opening,omitempty" example:"King's Pawn Game"`                                        // name of the opening
+	Spectators      *int       `json:"spectators,omitempty" example:"3"`                                                    // how many people watch the match
+	// span that caused the event, so delivering it can be traced back to the request
+	Trace trace.SpanContext `json:"-" swaggerignore:"true"`
+	// the event as JSON, for events sent to several players
+	encoded []byte
+}
+
+// encode returns e with its JSON attached, so sending it to several players encodes it once.
+func (e Event) encode() Event {
+	encoded, err := json.Marshal(e)
+	if err != nil {
+		slog.Warn("failed to encode event", "type", e.Type, "error", err)
+		return e
+	}
+	e.encoded = encoded
+	return e
 }
 
-func EventMove(opponentMove string) Event {
+// Encoded is the event as JSON, or nil if it was not encoded in advance.
+func (e Event) Encoded() []byte {
+	return e.encoded
+}
+
+// EventMove is move played in the position before, which led to the position after
+func EventMove(before, after *chess.Position, move *chess.Move) Event {
 	return Event{
-		Type: Move,
-		Move: opponentMove,
+		Type:      Move,
+		Move:      chess.UCINotation{}.Encode(before, move),
+		FEN:       after.String(),
+		SAN:       chess.AlgebraicNotation{}.Encode(before, move),
+		Check:     move.HasTag(chess.Check),
+		Checkmate: after.Status() == chess.Checkmate,
+		Capture:   move.HasTag(chess.Capture) || move.HasTag(chess.EnPassant),
+		Castle:    move.HasTag(chess.KingSideCastle) || move.HasTag(chess.QueenSideCastle),
+		Promotion: move.Promo() != chess.NoPieceType,
 	}
 }
 func EventResigned() Event {
@@ -40,7 +106,72 @@ func EventResigned() Event {
 	}
 }
 
+func EventChat(from, message string) Event {
+	return Event{
+		Type:    Chat,
+		From:    from,
+		Message: message,
+	}
+}
+
+func EventAborted() Event {
+	return Event{
+		Type: Aborted,
+	}
+}
+func EventAdjudicated(outcome chess.Outcome) Event {
+	return Event{
+		Type:   Adjudicated,
+		Result: string(outcome),
+	}
+}
+
+func EventResync(fen string) Event {
+	return Event{
+		Type: Resync,
+		FEN:  fen,
+	}
+}
+
+func EventOpening(o Opening) Event {
+	return Event{
+		Type:        OpeningDetected,
+		ECO:         o.ECO,
+		OpeningName: o.Name,
+	}
+}
+
+func EventOpponentDisconnected() Event {
+	return Event{
+		Type: OpponentDisconnected,
+	}
+}
+
+func EventOpponentConnected() Event {
+	return Event{
+		Type: OpponentConnected,
+	}
+}
+
+func EventSpectators(joined bool, count int) Event {
+	e := Event{
+		Type:       SpectatorLeft,
+		Spectators: &count,
+	}
+	if joined {
+		e.Type = SpectatorJoined
+	}
+	return e
+}
+
+func EventServerRestarting() Event {
+	return Event{
+		Type: ServerRestarting,
+	}
+}
+
 // game started event is fired when the 2nd player joins.
+// It is also sent to players who rejoin a resumed match.
 func EventStarted(opponentUsername string, opponentBlack bool, startTime, endTime time.Time) Event {
 	return Event{
 		Type:            OpponentInfo,
@@ -51,70 +182,151 @@ func EventStarted(opponentUsername string, opponentBlack bool, startTime, endTim
 	}
 }
 
+// Match is an ongoing match. Its fields below ID, Rated and Owner belong to its event loop,
+// they are only read and changed by functions passed to do or read, one at a time. See start.
 type Match struct {
 	// 6 character alphanumeric game id
 
 	StartTime, EndTime time.Time
 
-	ID    string
-	Chess *chess.Game
+	ID string
+	// rated matches cannot be joined by guests
+	Rated bool
+	// username of who created the match, empty for matches resumed after a restart
+	Owner string
+	// how long the match is kept while nobody joined
+	joinGrace time.Duration
 
+	game *chess.Game
 	// should never go above 2
-	numPlayers atomic.Uint32
+	numPlayers int
 	players    [2]Player
-	// delete the game
-	ShutDown func()
-	sync.RWMutex
+	// seats of a resumed match that wait for their player to join again
+	awaiting [2]bool
+	// the OnGameOver hook ran
+	ended bool
+	// the game ended with Adjudicate
+	adjudicated bool
+	// see updateOpening
+	opening      Opening
+	openingFinal bool
+	// called once when the game ends
+	onGameOver func(*Match)
+	// called when the game starts on this server
+	onStart func(*Match)
+	// called after every move played on this server
+	onMove  func(m *Match, ply int, move, fen string)
+	storage *MatchStorage
+
+	// functions to run on the event loop
+	inbox chan func()
+	// closed when the event loop stopped
+	stopped chan struct{}
+	// stops the event loop after the current function
+	closing bool
+
+	// a MatchState, changed with setState. It can be read without the event loop.
+	state atomic.Int32
+	// unix nanoseconds
+	stateChangedAt atomic.Int64
+	// unix nanoseconds, 0 before the first move
+	lastMoveAt atomic.Int64
+
+	// the last EVENT_LOG_SIZE events sent to the players, see EventsSince
+	eventLog []logEntry
+	// number of the last event sent
+	logSeq int64
+	// closed and replaced when an event is logged
+	logged chan struct{}
 }
 
 // duration is clamped between 1 minute and 12 hours.
-func (s *MatchStorage) NewMatch(duration time.Duration) *Match {
+func (s *MatchStorage) NewMatch(ctx context.Context, owner string, duration time.Duration, rated bool) (*Match, error) {
+	return s.NewScheduledMatch(ctx, owner, duration, rated, JOIN_GRACE_PERIOD)
+}
+
+// NewScheduledMatch is NewMatch for players who were told to join, it is kept for joinGrace while nobody joined.
+func (s *MatchStorage) NewScheduledMatch(ctx context.Context, owner string, duration time.Duration, rated bool, joinGrace time.Duration) (*Match, error) {
 	// limit of 12 hours
 	duration = max(time.Minute, duration)
 	duration = min(time.Hour*12, duration)
-	ctx, shutdown := context.WithCancel(context.Background())
-	match := Match{
+	now := time.Now().UTC()
+	r := s.execute(ctx, Command{
+		Type: CommandCreate,
 		// 6 char alpha-num id
-		ID:         rand.Text()[:6],
-		StartTime:  time.Now().UTC(),
-		EndTime:    time.Now().UTC().Add(duration),
-		Chess:      chess.NewGame(),
-		numPlayers: atomic.Uint32{},
-		players:    [2]Player{},
-		ShutDown:   shutdown,
-	}
-
-	s.mu.Lock()
-	s.storage[match.ID] = &match
-	s.mu.Unlock()
-	// clean up inactive match
-	go func() {
-		for {
-			time.Sleep(time.Second * 60)
-			select {
-			case <-ctx.Done():
-				s.mu.Lock()
-				delete(s.storage, match.ID)
-				s.mu.Unlock()
-				return
-			default:
-				if match.numPlayers.Load() == 0 || time.Since(match.EndTime) > 0 {
-					s.mu.Lock()
-					delete(s.storage, match.ID)
-					s.mu.Unlock()
-					return
-				}
+		Match:     rand.Text()[:6],
+		Username:  owner,
+		Rated:     rated,
+		StartTime: now,
+		EndTime:   now.Add(duration),
+		JoinGrace: joinGrace,
+	})
+	if r.match == nil {
+		return nil, errors.New("failed to create match")
+	}
+	return r.match, nil
+}
+
+func (s *MatchStorage) create(c Command) *Match {
+	match := &Match{
+		ID:         c.Match,
+		StartTime:  c.StartTime,
+		EndTime:    c.EndTime,
+		game:       chess.NewGame(),
+		Rated:      c.Rated,
+		Owner:      c.Username,
+		joinGrace:  c.JoinGrace,
+		onGameOver: s.OnGameOver,
+		onStart:    s.OnStart,
+		onMove:     s.OnMove,
+		storage:    s,
+	}
+	// commands of replicas that don't send it yet
+	if match.joinGrace == 0 {
+		match.joinGrace = JOIN_GRACE_PERIOD
+	}
+	match.setState(MatchWaiting)
+	match.start()
+	s.put(match)
+	return match
+}
+
+func (m *Match) GetPlayerCount() (count int) {
+	m.read(func() { count = m.numPlayers })
+	return count
+}
+
+// get the players who joined this match
+func (m *Match) Players() []Player {
+	players := make([]Player, 0, 2)
+	m.read(func() {
+		for _, p := range m.players {
+			if p.Username != "" {
+				players = append(players, p)
+			}
+		}
+	})
+	return players
+}
+
+// get the player using the pieces of color, ok is false if nobody joined as that color yet.
+func (m *Match) GetPlayerWithColor(color chess.Color) (player Player, ok bool) {
+	m.read(func() {
+		for _, p := range m.players {
+			if p.Username != "" && p.Color == color {
+				player, ok = p, true
 			}
 		}
-	}()
-	return &match
+	})
+	return player, ok
 }
-func (m *Match) GetPlayerCount() int {
-	return int(m.numPlayers.Load())
+
+func (m *Match) GetPlayerFromUsername(username string) (player Player, ok bool) {
+	m.read(func() { player, ok = m.playerFromUsername(username) })
+	return player, ok
 }
-func (m *Match) GetPlayerFromUsername(username string) (Player, bool) {
-	m.RLock()
-	defer m.RUnlock()
+
+func (m *Match) playerFromUsername(username string) (Player, bool) {
 	for _, p := range m.players {
 		if p.Username == username {
 			return p, true
@@ -123,30 +335,119 @@ func (m *Match) GetPlayerFromUsername(username string) (Player, bool) {
 	return Player{}, false
 }
 
+// Position is the current position. Positions never change, moves make new ones.
+func (m *Match) Position() (position *chess.Position) {
+	m.read(func() { position = m.game.Position() })
+	return position
+}
+
+// LastMove is the current position and the move that led to it. move is nil before the first move.
+func (m *Match) LastMove() (position *chess.Position, move *chess.Move) {
+	m.read(func() {
+		position = m.game.Position()
+		if moves := m.game.Moves(); len(moves) > 0 {
+			move = moves[len(moves)-1]
+		}
+	})
+	return position, move
+}
+
+// Outcome is chess.NoOutcome while the game is running
+func (m *Match) Outcome() (outcome chess.Outcome) {
+	m.read(func() { outcome = m.game.Outcome() })
+	return outcome
+}
+
+// Method is how the game ended, chess.NoMethod while it is running
+func (m *Match) Method() (method chess.Method) {
+	m.read(func() { method = m.game.Method() })
+	return method
+}
+
+// Adjudicated is true if the game was ended by Adjudicate
+func (m *Match) Adjudicated() (adjudicated bool) {
+	m.read(func() { adjudicated = m.adjudicated })
+	return adjudicated
+}
+
+// Disconnected is true if username has a seat in the match but no event stream, because they left it.
+// Seats are replicated, so this is true only if they are not connected to any server.
+func (m *Match) Disconnected(username string) (disconnected bool) {
+	m.read(func() {
+		for i, p := range m.players {
+			if p.Username == username && m.awaiting[i] {
+				disconnected = true
+			}
+		}
+	})
+	return disconnected
+}
+
+// PGN of the moves so far
+func (m *Match) PGN() (pgn string) {
+	m.read(func() { pgn = m.game.String() })
+	return pgn
+}
+
+// UCIMoves are the moves so far in UCI notation
+func (m *Match) UCIMoves() (moves []string) {
+	m.read(func() { moves = ScriptedMoves(m.game) })
+	return moves
+}
+
 // ok is false when 2 players have joined
 // id is whether you're player 1 or 2
 // asColor gets ignored if you aren't the first one to join.
 func (m *Match) Join(username string, asColor chess.Color) (player Player, ok bool) {
-	m.Lock()
-	defer m.Unlock()
-	if m.GetPlayerCount() < 2 {
-		id := int(m.numPlayers.Add(1))
+	r := m.storage.execute(context.Background(), Command{
+		Type:     CommandJoin,
+		Match:    m.ID,
+		Username: username,
+		Color:    asColor,
+	})
+	return r.player, r.ok
+}
+
+// TakeSeat joins like Join, but without an event stream, so the events sent to the player are only logged.
+// The seat is kept like the seat of a player who left, without telling the opponent they left.
+func (m *Match) TakeSeat(username string, asColor chess.Color) (player Player, ok bool) {
+	r := m.storage.execute(context.Background(), Command{
+		Type:     CommandJoin,
+		Match:    m.ID,
+		Username: username,
+		Color:    asColor,
+		Seat:     true,
+	})
+	return r.player, r.ok
+}
+
+// local is true if the player is connected to this server, only then are events sent to them.
+func (m *Match) join(username string, asColor chess.Color, local bool) (player Player, ok bool) {
+	if player, ok := m.rejoin(username, local); ok {
+		return player, true
+	}
+	if m.numPlayers < 2 {
+		m.numPlayers++
+		id := m.numPlayers
 		if id == 1 {
 			// player 1 gets to pick their color
-			m.players[0] = NewPlayer(username, id, asColor)
+			m.players[0] = NewPlayer(username, id, asColor, local)
 			return m.players[0], true
 		} else {
 			// player 2 gets assined the other color
 			player1 := m.players[0]
-			player2 := NewPlayer(username, id, player1.Color.Other())
+			player2 := NewPlayer(username, id, player1.Color.Other(), local)
 			m.players[1] = player2
+			m.setState(MatchPlaying)
 
 			// broadcast EventStarted
-			player1.Events <- EventStarted(player2.Username, player2.Color == chess.Black,
-				m.StartTime, m.EndTime)
-			// this doesn't block because channels are buffered
-			player2.Events <- EventStarted(player1.Username, player1.Color == chess.Black,
-				m.StartTime, m.EndTime)
+			m.send(player1, EventStarted(player2.Username, player2.Color == chess.Black,
+				m.StartTime, m.EndTime))
+			m.send(player2, EventStarted(player1.Username, player1.Color == chess.Black,
+				m.StartTime, m.EndTime))
+			if m.onStart != nil && local {
+				m.onStart(m)
+			}
 
 			return player2, true
 		}
@@ -154,68 +455,306 @@ func (m *Match) Join(username string, asColor chess.Color) (player Player, ok bo
 	return Player{}, false
 }
 
-// ok is false when it's not your turn
-func (m *Match) MoveAs(player Player, moveStr string) bool {
-	ok := m.doMove(player, moveStr)
+// errors returned by MoveAs
+var (
+	ErrNotInMatch   = errors.New("player is not in this match")
+	ErrNotYourTurn  = errors.New("it is not your turn")
+	ErrMoveNotation = errors.New("move is not in UCI notation")
+	ErrIllegalMove  = errors.New("move is not legal in this position")
+	ErrGameOver     = errors.New("game already ended")
+	// the match was deleted or expired
+	ErrMatchNotFound = errors.New("match not found")
+	// the server is restarting
+	ErrSuspended = errors.New("match is suspended")
+	// the command could not be shared with the other replicas
+	ErrUnavailable = errors.New("match is unavailable")
+)
+
+// MoveAs plays moveStr in UCI notation. The error is one of the Err* values of this package.
+func (m *Match) MoveAs(ctx context.Context, player Player, moveStr string) error {
+	ctx, span := tracer.Start(ctx, "game.MoveAs", trace.WithAttributes(
+		attribute.String("match.id", m.ID),
+		attribute.String("player", player.Username),
+		attribute.String("move", moveStr),
+	))
+	defer span.End()
+
+	err := m.storage.execute(ctx, Command{
+		Type:     CommandMove,
+		Match:    m.ID,
+		Username: player.Username,
+		Move:     moveStr,
+	}).err
+	span.SetAttributes(attribute.Bool("move.ok", err == nil))
+	if err != nil {
+		span.SetAttributes(attribute.String("move.error", err.Error()))
+	}
+	return err
+}
+
+func (m *Match) move(ctx context.Context, username string, moveStr string, local bool) error {
+	player, ok := m.playerFromUsername(username)
 	if !ok {
-		return false
+		return ErrNotInMatch
+	}
+	if err := m.doMove(player, moveStr, local); err != nil {
+		return err
 	}
-	m.RLock()
-	var oppEvents chan Event
+	opponent := m.players[0]
 	if player.Username == m.players[0].Username {
-		oppEvents = m.players[1].Events
-	} else {
-		oppEvents = m.players[0].Events
+		opponent = m.players[1]
 	}
-	m.RUnlock()
 
 	// send event
-	if oppEvents != nil {
+	moves, positions := m.game.Moves(), m.game.Positions()
+	played := EventMove(positions[len(positions)-2], m.game.Position(), moves[len(moves)-1])
+	m.sendTraced(ctx, opponent, played)
+	m.updateOpening()
+	return nil
+}
+
+// Chat sends a chat message from player to their opponent.
+func (m *Match) Chat(ctx context.Context, player Player, message string) {
+	ctx, span := tracer.Start(ctx, "game.Chat", trace.WithAttributes(
+		attribute.String("match.id", m.ID),
+		attribute.String("player", player.Username),
+	))
+	defer span.End()
+
+	m.storage.execute(ctx, Command{
+		Type:     CommandChat,
+		Match:    m.ID,
+		Username: player.Username,
+		Message:  message,
+	})
+}
+
+func (m *Match) chat(ctx context.Context, username string, message string) {
+	opponent := m.players[0]
+	if username == m.players[0].Username {
+		opponent = m.players[1]
+	}
+	m.sendTraced(ctx, opponent, EventChat(username, message))
+}
+
+// sendTraced sends an event that remembers the span in ctx
+func (m *Match) sendTraced(ctx context.Context, p Player, e Event) {
+	_, span := tracer.Start(ctx, "game.send")
+	defer span.End()
+	e.Trace = span.SpanContext()
+	m.send(p, e)
+}
+
+// send an event to p without blocking, and log it for players who poll. Only players connected to this server
+// get it on their Events channel. It runs on the event loop.
+//
+// A client that falls EVENT_BUFFER_SIZE events behind would miss moves and end up with the wrong board.
+// Instead, the events it did not read yet are replaced with a resync event that has the current position.
+// Its stream ends there, and it can join the match again.
+func (m *Match) send(p Player, e Event) {
+	if p.Username != "" {
+		m.appendLog(p.Username, e)
+	}
+	events := p.Events
+	if events == nil {
+		return
+	}
+	select {
+	case events <- e:
+		return
+	default:
+	}
+	dropped := 1
+	for drained := false; !drained; {
 		select {
-		case oppEvents <- EventMove(moveStr):
+		case <-events:
+			dropped++
 		default:
-			// channel full
-			slog.Warn("Channel is full when trying to send event. This could be due to a slow client or something else on our side.")
+			drained = true
 		}
 	}
-	return true
+	select {
+	case events <- EventResync(m.game.FEN()):
+	default:
+	}
+	m.storage.droppedEvents.Add(int64(dropped))
+	m.storage.resyncs.Add(1)
+	slog.Warn("event stream fell behind, sent resync", "match", m.ID, "dropped", dropped)
+}
+
+// IsPromotion is true if moveStr moves a pawn to the last rank.
+// The move does not have to be legal.
+func (m *Match) IsPromotion(moveStr string) bool {
+	if len(moveStr) < 4 {
+		return false
+	}
+	squares := m.Position().Board().SquareMap()
+	from, to := moveStr[0:2], moveStr[2:4]
+	for sq, piece := range squares {
+		if sq.String() == from && piece.Type() == chess.Pawn {
+			return to[1] == '8' || to[1] == '1'
+		}
+	}
+	return false
 }
 
-func (m *Match) doMove(player Player, moveStr string) bool {
-	m.Lock()
-	defer m.Unlock()
+func (m *Match) doMove(player Player, moveStr string, local bool) error {
+	if m.State() == MatchSuspended {
+		return ErrSuspended
+	}
 	// ensure this player is in the match
 	if player.Username != m.players[0].Username && player.Username != m.players[1].Username {
-		return false
+		return ErrNotInMatch
+	}
+	if m.game.Outcome() != chess.NoOutcome {
+		return ErrGameOver
 	}
 	// check correct turn
-	if m.Chess.Position().Turn() != player.Color {
-		return false
+	if m.game.Position().Turn() != player.Color {
+		return ErrNotYourTurn
 	}
 	// attempt move
-	playedMove, err := chess.UCINotation{}.Decode(m.Chess.Position(), moveStr)
+	playedMove, err := chess.UCINotation{}.Decode(m.game.Position(), moveStr)
 	if err != nil {
-		return false
+		return ErrMoveNotation
 	}
-	if err := m.Chess.Move(playedMove); err != nil {
+	if err := m.game.Move(playedMove); err != nil {
+		return ErrIllegalMove
+	}
+	m.lastMoveAt.Store(time.Now().UnixNano())
+	if m.onMove != nil && local {
+		m.onMove(m, len(m.game.Moves()), moveStr, m.game.FEN())
+	}
+	if m.game.Outcome() != chess.NoOutcome {
+		m.endGame(local)
+	}
+	return nil
+}
+
+// endGame runs the storage's OnGameOver hook the first time the game ends.
+// Only the server the game ended on runs it, so the game is archived once.
+// It runs in its own goroutine because it reads the match, and the event loop is busy ending the game.
+func (m *Match) endGame(local bool) {
+	if m.ended {
+		return
+	}
+	m.ended = true
+	m.setState(MatchFinished)
+	if m.onGameOver != nil && local {
+		go m.onGameOver(m)
+	}
+}
+
+func (m *Match) Resign(player Player) {
+	// the request that is resigning may already be cancelled
+	m.storage.execute(context.Background(), Command{
+		Type:     CommandResign,
+		Match:    m.ID,
+		Username: player.Username,
+	})
+}
+
+func (m *Match) resign(username string, local bool) {
+	// players leaving because of a restart don't lose
+	if m.State() == MatchSuspended {
+		return
+	}
+	var player, opponent Player
+	switch username {
+	case m.players[0].Username:
+		player, opponent = m.players[0], m.players[1]
+	case m.players[1].Username:
+		player, opponent = m.players[1], m.players[0]
+	default:
+		return
+	}
+	m.game.Resign(player.Color)
+	m.endGame(local)
+	m.send(opponent, EventResigned())
+}
+
+// Leave gives up the player's seat without resigning, so they can join again.
+// It is used when the server shuts down, and when a player's stream ends.
+func (m *Match) Leave(player Player) {
+	m.storage.execute(context.Background(), Command{
+		Type:     CommandLeave,
+		Match:    m.ID,
+		Username: player.Username,
+	})
+}
+
+func (m *Match) leave(username string) {
+	m.detach(username)
+	if m.game.Outcome() != chess.NoOutcome {
+		return
+	}
+	for _, p := range m.players {
+		if p.Username != "" && p.Username != username {
+			m.send(p, EventOpponentDisconnected())
+		}
+	}
+}
+
+// detach keeps the seat of username without an event stream, so they can join again
+func (m *Match) detach(username string) {
+	for i, p := range m.players {
+		if p.Username == username {
+			m.awaiting[i] = true
+			m.players[i].Events = nil
+		}
+	}
+}
+
+// Spectated tells the players that someone started or stopped watching the match, count is how many watch it now.
+// Spectators are counted by the server they watch on, so this is not replicated.
+func (m *Match) Spectated(joined bool, count int) {
+	m.do(func() {
+		e := EventSpectators(joined, count).encode()
+		for _, p := range m.players {
+			m.send(p, e)
+		}
+	})
+}
+
+// Adjudicate ends the game with outcome, which must not be chess.NoOutcome.
+// ok is false if the game already ended.
+func (m *Match) Adjudicate(outcome chess.Outcome) (ok bool) {
+	return m.storage.execute(context.Background(), Command{
+		Type:    CommandAdjudicate,
+		Match:   m.ID,
+		Outcome: outcome,
+	}).ok
+}
+
+func (m *Match) adjudicate(outcome chess.Outcome, local bool) (ok bool) {
+	if m.game.Outcome() != chess.NoOutcome {
 		return false
 	}
+	switch outcome {
+	case chess.WhiteWon:
+		m.game.Resign(chess.Black)
+	case chess.BlackWon:
+		m.game.Resign(chess.White)
+	default:
+		if err := m.game.Draw(chess.DrawOffer); err != nil {
+			return false
+		}
+	}
+	m.adjudicated = true
+	m.endGame(local)
+	e := EventAdjudicated(outcome).encode()
+	for _, p := range m.players {
+		m.send(p, e)
+	}
 	return true
 }
 
-func (m *Match) Resign(player Player) {
-	m.Lock()
-	defer m.Unlock()
-	m.Chess.Resign(player.Color)
-	// close context to clean up
-	defer m.ShutDown()
-	var opponent Player
-	if player.Id == 1 {
-		opponent = m.players[1]
-	} else {
-		opponent = m.players[0]
+// abort tells the players the match was deleted
+func (m *Match) abort() {
+	e := EventAborted().encode()
+	for _, p := range m.players {
+		m.send(p, e)
 	}
-	opponent.Events <- EventResigned()
 }
 
 // func (m *Match) BoardFen(id int) string {
@@ -0,0 +1,157 @@
+package game
+
+import (
+	"context"
+	"time"
+)
+
+// MatchState is where a match is in its lifecycle.
+//
+//	MatchWaiting -> MatchPlaying -> MatchFinished -> MatchClosed
+//
+// Any state can go to MatchSuspended when the server shuts down, or to MatchClosed when the match is deleted.
+type MatchState int32
+
+const (
+	// fewer than two players joined
+	MatchWaiting MatchState = iota
+	MatchPlaying
+	// the game ended, the match is kept for a while so players can still look at it
+	MatchFinished
+	// the server is shutting down, the match cannot change anymore
+	MatchSuspended
+	// the match was removed from storage
+	MatchClosed
+)
+
+func (st MatchState) String() string {
+	switch st {
+	case MatchWaiting:
+		return "waiting"
+	case MatchPlaying:
+		return "playing"
+	case MatchFinished:
+		return "finished"
+	case MatchSuspended:
+		return "suspended"
+	default:
+		return "closed"
+	}
+}
+
+const (
+	// how often the janitor looks for matches that are over
+	JANITOR_INTERVAL = time.Minute
+	// how long a match nobody joined is kept, unless it was created with another grace period
+	JOIN_GRACE_PERIOD = time.Minute
+	// how long a finished match is kept
+	FINISHED_RETENTION = time.Minute
+)
+
+// start runs the match's event loop. Everything that reads or changes the match runs on it,
+// one thing at a time, so the match needs no locks.
+func (m *Match) start() {
+	m.inbox = make(chan func())
+	m.stopped = make(chan struct{})
+	m.logged = make(chan struct{})
+	go m.run()
+}
+
+func (m *Match) run() {
+	defer close(m.stopped)
+	for !m.closing {
+		(<-m.inbox)()
+	}
+}
+
+// do runs fn on the event loop and waits for it. ok is false if the match was closed, fn did not run then.
+// fn must not call do or read, the loop would wait for itself.
+func (m *Match) do(fn func()) (ok bool) {
+	done := make(chan struct{})
+	var panicked any
+	run := func() {
+		defer func() {
+			// the panic belongs to the caller, not the event loop
+			panicked = recover()
+			close(done)
+		}()
+		fn()
+	}
+	select {
+	case m.inbox <- run:
+	case <-m.stopped:
+		return false
+	}
+	<-done
+	if panicked != nil {
+		panic(panicked)
+	}
+	return true
+}
+
+// read runs fn on the event loop. After the match was closed nothing changes it anymore,
+// so fn runs directly then.
+func (m *Match) read(fn func()) {
+	if !m.do(fn) {
+		fn()
+	}
+}
+
+// close stops the event loop. The match must have been removed from storage.
+func (m *Match) close() {
+	m.do(func() { m.closing = true })
+	m.setState(MatchClosed)
+}
+
+func (m *Match) State() MatchState {
+	return MatchState(m.state.Load())
+}
+
+// setState moves the match to st and remembers when
+func (m *Match) setState(st MatchState) {
+	m.stateChangedAt.Store(time.Now().UnixNano())
+	m.state.Store(int32(st))
+}
+
+// LastActivity is when the last move was played, or when the match changed state if that was later.
+func (m *Match) LastActivity() time.Time {
+	return time.Unix(0, max(m.lastMoveAt.Load(), m.stateChangedAt.Load()))
+}
+
+// expired is true if the match can be removed from storage
+func (m *Match) expired(now time.Time) bool {
+	since := now.Sub(time.Unix(0, m.stateChangedAt.Load()))
+	switch m.State() {
+	case MatchWaiting:
+		if m.GetPlayerCount() == 0 && since > m.joinGrace {
+			return true
+		}
+	case MatchFinished:
+		return since > FINISHED_RETENTION
+	case MatchClosed:
+		return true
+	}
+	return now.After(m.EndTime)
+}
+
+// janitor removes matches that are over every JANITOR_INTERVAL until ctx is cancelled.
+func (s *MatchStorage) janitor(ctx context.Context) {
+	ticker := time.NewTicker(JANITOR_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.sweep(now)
+		}
+	}
+}
+
+func (s *MatchStorage) sweep(now time.Time) {
+	for _, m := range s.all() {
+		if m.expired(now) && s.removeMatch(m) {
+			m.close()
+		}
+	}
+}
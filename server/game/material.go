@@ -0,0 +1,60 @@
+package game
+
+import (
+	"github.com/notnil/chess"
+)
+
+// Material is what each player captured so far, for showing captured pieces next to the board
+type Material struct {
+	// black pieces white captured, in the order they were taken: p, n, b, r or q
+	White []string `json:"white" example:"p,n"`
+	// white pieces black captured
+	Black []string `json:"black" example:"p"`
+	// value of white's pieces on the board minus black's, counting pawns 1, knights and bishops 3, rooks 5 and queens 9.
+	// Promoted pawns count as the piece they became.
+	Balance int `json:"balance" example:"2"`
+}
+
+var pieceValues = map[chess.PieceType]int{
+	chess.Pawn:   1,
+	chess.Knight: 3,
+	chess.Bishop: 3,
+	chess.Rook:   5,
+	chess.Queen:  9,
+}
+
+// MaterialOf is the material after moves, positions[i] being the position moves[i] was played in.
+// positions must have at least one more element than moves, the last one is the position after the moves.
+func MaterialOf(positions []*chess.Position, moves []*chess.Move) Material {
+	material := Material{White: []string{}, Black: []string{}}
+	for i, move := range moves {
+		var taken chess.PieceType
+		switch {
+		case move.HasTag(chess.EnPassant):
+			taken = chess.Pawn
+		case move.HasTag(chess.Capture):
+			taken = positions[i].Board().Piece(move.S2()).Type()
+		default:
+			continue
+		}
+		if positions[i].Turn() == chess.White {
+			material.White = append(material.White, taken.String())
+		} else {
+			material.Black = append(material.Black, taken.String())
+		}
+	}
+	for _, piece := range positions[len(moves)].Board().SquareMap() {
+		if piece.Color() == chess.White {
+			material.Balance += pieceValues[piece.Type()]
+		} else {
+			material.Balance -= pieceValues[piece.Type()]
+		}
+	}
+	return material
+}
+
+// Material is what the players captured so far
+func (m *Match) Material() (material Material) {
+	m.read(func() { material = MaterialOf(m.game.Positions(), m.game.Moves()) })
+	return material
+}
@@ -0,0 +1,86 @@
+package game
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/notnil/chess"
+	"github.com/notnil/chess/opening"
+)
+
+// Opening is a named opening from the Encyclopaedia of Chess Openings.
+// It is empty until the first move of the game that is in the book.
+type Opening struct {
+	ECO  string `json:"eco" example:"C20"`
+	Name string `json:"name" example:"King's Pawn Game"`
+}
+
+type ecoBook struct {
+	book *opening.BookECO
+	// UCI moves separated by spaces -> an opening of the book continues after them
+	continued map[string]bool
+}
+
+// the opening book, parsed once in the background when the storage is created
+var openings = sync.OnceValue(func() ecoBook {
+	b := ecoBook{book: opening.NewBookECO(), continued: map[string]bool{}}
+	for _, o := range b.book.Possible(nil) {
+		moves := strings.Fields(o.PGN())
+		for i := 1; i < len(moves); i++ {
+			b.continued[strings.Join(moves[:i], " ")] = true
+		}
+	}
+	return b
+})
+
+// updateOpening names the opening after a move. It runs on the event loop.
+// The opening is final once the moves left the book, then the players get an opening event.
+func (m *Match) updateOpening() {
+	if m.openingFinal {
+		return
+	}
+	b := openings()
+	moves := m.game.Moves()
+	if o := b.book.Find(moves); o != nil {
+		m.opening = Opening{ECO: o.Code(), Name: o.Title()}
+	}
+	if b.continued[uciLine(moves)] {
+		return
+	}
+	m.openingFinal = true
+	if m.opening.ECO == "" {
+		return
+	}
+	e := EventOpening(m.opening).encode()
+	for _, p := range m.players {
+		m.send(p, e)
+	}
+}
+
+// uciLine is moves in UCI notation separated by spaces, like the moves of the opening book
+func uciLine(moves []*chess.Move) string {
+	var line strings.Builder
+	for i, move := range moves {
+		if i > 0 {
+			line.WriteByte(' ')
+		}
+		line.WriteString(move.String())
+	}
+	return line.String()
+}
+
+// Opening is the opening played so far, empty if the first move is not in the book.
+// final is true once the game left the book and the opening can't change anymore.
+func (m *Match) Opening() (o Opening, final bool) {
+	m.read(func() { o, final = m.opening, m.openingFinal })
+	return o, final
+}
+
+// FindOpening names the opening of moves played elsewhere, like an imported game.
+// It is empty if the first move is not in the book.
+func FindOpening(moves []*chess.Move) Opening {
+	if o := openings().book.Find(moves); o != nil {
+		return Opening{ECO: o.Code(), Name: o.Title()}
+	}
+	return Opening{}
+}
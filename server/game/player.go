@@ -2,6 +2,9 @@ package game
 
 import "github.com/notnil/chess"
 
+// events a player can fall behind before their stream is resynced
+const EVENT_BUFFER_SIZE = 10
+
 type Player struct {
 	Username string
 	Id       int
@@ -9,11 +12,15 @@ type Player struct {
 	Events   chan Event
 }
 
-func NewPlayer(username string, id int, color chess.Color) Player {
-	return Player{
+// Events is only made for players connected to this server, it is nil for players on other replicas.
+func NewPlayer(username string, id int, color chess.Color, local bool) Player {
+	p := Player{
 		Username: username,
 		Id:       id,
 		Color:    color,
-		Events:   make(chan Event, 10),
 	}
+	if local {
+		p.Events = make(chan Event, EVENT_BUFFER_SIZE)
+	}
+	return p
 }
@@ -0,0 +1,86 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// commands older than this are trimmed from the stream, matches last at most 12 hours
+const REDIS_REPLAY_WINDOW = 13 * time.Hour
+
+// RedisBackend shares matches through a Redis stream.
+type RedisBackend struct {
+	client *redis.Client
+	stream string
+}
+
+// NewRedisBackend connects to a redis:// or rediss:// url.
+func NewRedisBackend(ctx context.Context, url string) (*RedisBackend, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	return &RedisBackend{client: client, stream: "chess:matches"}, nil
+}
+
+func (b *RedisBackend) Publish(ctx context.Context, c Command) error {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return b.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: b.stream,
+		MinID:  strconv.FormatInt(time.Now().Add(-REDIS_REPLAY_WINDOW).UnixMilli(), 10),
+		Approx: true,
+		Values: map[string]any{"command": payload},
+	}).Err()
+}
+
+func (b *RedisBackend) Subscribe(ctx context.Context, apply func(Command)) error {
+	// start at the beginning to replay the matches that are still going on
+	last := "0"
+	for {
+		streams, err := b.client.XRead(ctx, &redis.XReadArgs{
+			Streams: []string{b.stream, last},
+			Count:   100,
+			Block:   5 * time.Second,
+		}).Result()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			slog.Warn("failed to read match commands from redis", "error", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		for _, msg := range streams[0].Messages {
+			last = msg.ID
+			payload, _ := msg.Values["command"].(string)
+			var c Command
+			if err := json.Unmarshal([]byte(payload), &c); err != nil {
+				slog.Warn("skipping invalid match command", "id", msg.ID, "error", err)
+				continue
+			}
+			apply(c)
+		}
+	}
+}
+
+func (b *RedisBackend) Close() error {
+	return b.client.Close()
+}
@@ -0,0 +1,158 @@
+package game
+
+import (
+	"context"
+	"crypto/rand"
+	"log/slog"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+// how long to wait for a published command to come back from the backend
+const REPLICATION_TIMEOUT = 5 * time.Second
+
+type CommandType string
+
+const (
+	CommandCreate     CommandType = "create"
+	CommandJoin       CommandType = "join"
+	CommandMove       CommandType = "move"
+	CommandChat       CommandType = "chat"
+	CommandResign     CommandType = "resign"
+	CommandLeave      CommandType = "leave"
+	CommandAdjudicate CommandType = "adjudicate"
+	CommandDelete     CommandType = "delete"
+)
+
+// Command changes a match. Every replica applies the same commands in the same order,
+// so they all end up with the same matches.
+// Each command only uses some fields.
+type Command struct {
+	ID string `json:"id"`
+	// replica that published the command
+	Origin   string        `json:"origin"`
+	Type     CommandType   `json:"type"`
+	Match    string        `json:"match"`
+	Username string        `json:"username,omitempty"`
+	Color    chess.Color   `json:"color,omitempty"`
+	Move     string        `json:"move,omitempty"`
+	Message  string        `json:"message,omitempty"`
+	Outcome  chess.Outcome `json:"outcome,omitempty"`
+	Rated    bool          `json:"rated,omitempty"`
+	// join without an event stream, like long polling
+	Seat bool `json:"seat,omitempty"`
+
+	StartTime time.Time     `json:"startTime,omitzero"`
+	EndTime   time.Time     `json:"endTime,omitzero"`
+	JoinGrace time.Duration `json:"joinGrace,omitempty"`
+}
+
+// Backend delivers commands to every replica, in the same order.
+type Backend interface {
+	Publish(ctx context.Context, c Command) error
+	// Subscribe calls apply for every command, starting with the ones published before
+	// that can still affect ongoing matches. It returns when ctx is done.
+	Subscribe(ctx context.Context, apply func(Command)) error
+}
+
+// what applying a command did
+type result struct {
+	match  *Match
+	player Player
+	ok     bool
+	// why a move failed
+	err error
+}
+
+// execute applies c directly, or publishes it and waits until it was applied here
+// so commands from other replicas are ordered the same way everywhere.
+func (s *MatchStorage) execute(ctx context.Context, c Command) result {
+	if s.backend == nil {
+		return s.apply(ctx, c)
+	}
+	c.ID = rand.Text()
+	c.Origin = s.replica
+	done := make(chan result, 1)
+	s.waitersMu.Lock()
+	s.waiters[c.ID] = done
+	s.waitersMu.Unlock()
+	defer func() {
+		s.waitersMu.Lock()
+		delete(s.waiters, c.ID)
+		s.waitersMu.Unlock()
+	}()
+
+	if err := s.backend.Publish(ctx, c); err != nil {
+		slog.Error("failed to publish match command", "type", c.Type, "match", c.Match, "error", err)
+		return result{err: ErrUnavailable}
+	}
+	select {
+	case r := <-done:
+		return r
+	case <-ctx.Done():
+	case <-time.After(REPLICATION_TIMEOUT):
+		slog.Warn("match command was not applied in time", "type", c.Type, "match", c.Match)
+	}
+	return result{err: ErrUnavailable}
+}
+
+// receive applies a command from the backend and hands the result to the request that published it
+func (s *MatchStorage) receive(c Command) {
+	r := s.apply(context.Background(), c)
+	if c.Origin != s.replica {
+		return
+	}
+	s.waitersMu.Lock()
+	done, ok := s.waiters[c.ID]
+	s.waitersMu.Unlock()
+	if ok {
+		done <- r
+	}
+}
+
+// apply changes the match of c. Players only get events on the server they are connected to,
+// and only the replica a command came from runs OnGameOver.
+func (s *MatchStorage) apply(ctx context.Context, c Command) result {
+	local := s.backend == nil || c.Origin == s.replica
+	if c.Type == CommandCreate {
+		// replayed after the match expired
+		if time.Now().After(c.EndTime) {
+			return result{}
+		}
+		return result{match: s.create(c), ok: true}
+	}
+	if c.Type == CommandDelete {
+		return result{ok: s.deleteMatch(c.Match)}
+	}
+	m, ok := s.GetMatch(c.Match)
+	if !ok {
+		return result{err: ErrMatchNotFound}
+	}
+	r := result{match: m, ok: true}
+	ran := m.do(func() {
+		switch c.Type {
+		case CommandJoin:
+			r.player, r.ok = m.join(c.Username, c.Color, local)
+			if r.ok && c.Seat {
+				m.detach(c.Username)
+			}
+		case CommandMove:
+			r.err = m.move(ctx, c.Username, c.Move, local)
+			r.ok = r.err == nil
+		case CommandChat:
+			m.chat(ctx, c.Username, c.Message)
+		case CommandResign:
+			m.resign(c.Username, local)
+		case CommandLeave:
+			m.leave(c.Username)
+		case CommandAdjudicate:
+			r.ok = m.adjudicate(c.Outcome, local)
+		}
+	})
+	if !ran {
+		// closed after it was looked up
+		return result{err: ErrMatchNotFound}
+	}
+	return r
+}
@@ -1,26 +1,194 @@
 package game
 
 import (
+	"context"
+	"crypto/rand"
+	"hash/fnv"
+	"log/slog"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// map from 6 character alphanumeric game id to an ongoing game
-type MatchStorage struct {
-	storage map[string]*Match
+// matches are spread over this many maps, so requests for different matches rarely wait for the same lock
+const SHARD_COUNT = 32
+
+type shard struct {
 	mu      sync.RWMutex
+	matches map[string]*Match
+}
+
+// MatchStorage maps 6 character alphanumeric match ids to ongoing matches.
+// A single janitor removes matches that are over, see MatchState.
+type MatchStorage struct {
+	shards [SHARD_COUNT]shard
+	// called once for every match that ends by checkmate, draw or resignation.
+	// Must be set before any matches are created.
+	OnGameOver func(*Match)
+	// called when the second player joins a match on this server.
+	// It runs on the match's event loop, so it must not block or call methods of the match.
+	// Must be set before any matches are created.
+	OnStart func(*Match)
+	// called for every move played on this server, with the move's ply (1 for white's first move),
+	// the move in UCI notation and the position after it in FEN.
+	// It runs on the match's event loop, so it must not block or call methods of the match.
+	// Must be set before any matches are created.
+	OnMove func(m *Match, ply int, move, fen string)
+
+	// shares matches with other replicas, nil if matches only live on this server
+	backend Backend
+	// tells this server's commands apart from other replicas'
+	replica string
+	// commands published by this server that wait to be applied
+	waiters   map[string]chan result
+	waitersMu sync.Mutex
+
+	// events replaced by resync events, and how many resync events were sent
+	droppedEvents, resyncs atomic.Int64
 }
 
 func NewGamesStorage() *MatchStorage {
-	return &MatchStorage{
-		storage: map[string]*Match{},
-		mu:      sync.RWMutex{},
+	s := &MatchStorage{
+		replica: rand.Text(),
+		waiters: map[string]chan result{},
+	}
+	for i := range s.shards {
+		s.shards[i].matches = map[string]*Match{}
+	}
+	go s.janitor(context.Background())
+	// parsing the opening book takes a moment, better now than during the first move
+	go openings()
+	return s
+}
+
+func (s *MatchStorage) shard(id string) *shard {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return &s.shards[h.Sum32()%SHARD_COUNT]
+}
+
+func (s *MatchStorage) put(m *Match) {
+	sh := s.shard(m.ID)
+	sh.mu.Lock()
+	sh.matches[m.ID] = m
+	sh.mu.Unlock()
+}
+
+// remove takes a match out of storage, ok is false if it wasn't there
+func (s *MatchStorage) remove(id string) (m *Match, ok bool) {
+	sh := s.shard(id)
+	sh.mu.Lock()
+	m, ok = sh.matches[id]
+	delete(sh.matches, id)
+	sh.mu.Unlock()
+	return m, ok
+}
+
+// removeMatch takes m out of storage, unless another match with its id replaced it
+func (s *MatchStorage) removeMatch(m *Match) (ok bool) {
+	sh := s.shard(m.ID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if sh.matches[m.ID] != m {
+		return false
+	}
+	delete(sh.matches, m.ID)
+	return true
+}
+
+// all matches in storage. Matches can be added or removed while the caller goes through them.
+func (s *MatchStorage) all() []*Match {
+	var matches []*Match
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.RLock()
+		for _, m := range sh.matches {
+			matches = append(matches, m)
+		}
+		sh.mu.RUnlock()
 	}
+	return matches
+}
+
+// UseBackend replicates matches through b until ctx is done.
+// Must be called before any matches are created.
+func (s *MatchStorage) UseBackend(ctx context.Context, b Backend) {
+	s.backend = b
+	go func() {
+		if err := b.Subscribe(ctx, s.receive); err != nil && ctx.Err() == nil {
+			slog.Error("stopped receiving match commands", "error", err)
+		}
+	}()
+}
+
+// Replicated is true if matches are shared with other replicas.
+func (s *MatchStorage) Replicated() bool {
+	return s.backend != nil
 }
 
 // get a match, ok is false if doesnt exist
 func (s *MatchStorage) GetMatch(id string) (match *Match, ok bool) {
-	s.mu.RLock()
-	match, ok = s.storage[id]
-	s.mu.RUnlock()
+	sh := s.shard(id)
+	sh.mu.RLock()
+	match, ok = sh.matches[id]
+	sh.mu.RUnlock()
 	return
 }
+
+// EventStats counts events that players fell too far behind to get, see Match.send.
+func (s *MatchStorage) EventStats() (dropped, resyncs int64) {
+	return s.droppedEvents.Load(), s.resyncs.Load()
+}
+
+// number of matches in storage
+func (s *MatchStorage) Count() int {
+	count := 0
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.RLock()
+		count += len(sh.matches)
+		sh.mu.RUnlock()
+	}
+	return count
+}
+
+// CountOwnedBy is the number of unfinished matches created by username.
+func (s *MatchStorage) CountOwnedBy(username string) int {
+	count := 0
+	for _, m := range s.all() {
+		if m.Owner != username {
+			continue
+		}
+		if st := m.State(); (st == MatchWaiting || st == MatchPlaying) && time.Now().Before(m.EndTime) {
+			count++
+		}
+	}
+	return count
+}
+
+// Playing are the matches whose game is going on.
+func (s *MatchStorage) Playing() []*Match {
+	var playing []*Match
+	for _, m := range s.all() {
+		if m.State() == MatchPlaying && time.Now().Before(m.EndTime) {
+			playing = append(playing, m)
+		}
+	}
+	return playing
+}
+
+// DeleteMatch removes a match without ending the game, and tells its players.
+// ok is false if the match doesn't exist.
+func (s *MatchStorage) DeleteMatch(id string) (ok bool) {
+	return s.execute(context.Background(), Command{Type: CommandDelete, Match: id}).ok
+}
+
+func (s *MatchStorage) deleteMatch(id string) (ok bool) {
+	match, ok := s.remove(id)
+	if !ok {
+		return false
+	}
+	match.do(match.abort)
+	match.close()
+	return true
+}
@@ -0,0 +1,129 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+// SuspendedMatch is the state of an unfinished match, kept while the server restarts.
+type SuspendedMatch struct {
+	ID    string
+	Rated bool
+	// empty if nobody joined with that color
+	White, Black       string
+	PGN                string
+	StartTime, EndTime time.Time
+}
+
+// SuspendAll stops every unfinished match and tells its players that the server is restarting.
+// Matches nobody joined are dropped.
+func (s *MatchStorage) SuspendAll() []SuspendedMatch {
+	matches := s.all()
+	suspended := make([]SuspendedMatch, 0, len(matches))
+	for _, m := range matches {
+		var sm SuspendedMatch
+		ok := false
+		m.do(func() { sm, ok = m.suspend() })
+		if ok {
+			suspended = append(suspended, sm)
+		}
+	}
+	return suspended
+}
+
+// suspend freezes the match. ok is false if the game is over or nobody joined.
+func (m *Match) suspend() (sm SuspendedMatch, ok bool) {
+	m.setState(MatchSuspended)
+	e := EventServerRestarting().encode()
+	for _, p := range m.players {
+		m.send(p, e)
+	}
+	if m.game.Outcome() != chess.NoOutcome || m.players[0].Username == "" {
+		return SuspendedMatch{}, false
+	}
+	sm = SuspendedMatch{
+		ID:        m.ID,
+		Rated:     m.Rated,
+		PGN:       m.game.String(),
+		StartTime: m.StartTime,
+		EndTime:   m.EndTime,
+	}
+	for _, p := range m.players {
+		switch {
+		case p.Username == "":
+		case p.Color == chess.White:
+			sm.White = p.Username
+		default:
+			sm.Black = p.Username
+		}
+	}
+	return sm, true
+}
+
+// Resume recreates a suspended match. Its players can join it again with Join.
+func (s *MatchStorage) Resume(sm SuspendedMatch) (*Match, error) {
+	pgn, err := chess.PGN(strings.NewReader(sm.PGN))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PGN: %w", err)
+	}
+	match := &Match{
+		ID:         sm.ID,
+		StartTime:  sm.StartTime,
+		EndTime:    sm.EndTime,
+		game:       chess.NewGame(pgn),
+		Rated:      sm.Rated,
+		joinGrace:  JOIN_GRACE_PERIOD,
+		onGameOver: s.OnGameOver,
+		onStart:    s.OnStart,
+		onMove:     s.OnMove,
+		storage:    s,
+	}
+	match.updateOpening()
+	// the first player keeps the first seat, like before the restart
+	for _, seat := range []struct {
+		username string
+		color    chess.Color
+	}{{sm.White, chess.White}, {sm.Black, chess.Black}} {
+		if seat.username == "" {
+			continue
+		}
+		match.numPlayers++
+		id := match.numPlayers
+		match.players[id-1] = NewPlayer(seat.username, id, seat.color, false)
+		match.awaiting[id-1] = true
+	}
+
+	if match.numPlayers == 2 {
+		match.setState(MatchPlaying)
+	} else {
+		match.setState(MatchWaiting)
+	}
+	match.start()
+	s.put(match)
+	return match, nil
+}
+
+// rejoin gives a player who left a match their seat back. It runs on the event loop.
+func (m *Match) rejoin(username string, local bool) (player Player, ok bool) {
+	for i, p := range m.players {
+		if !m.awaiting[i] || p.Username != username {
+			continue
+		}
+		m.awaiting[i] = false
+		m.players[i] = NewPlayer(p.Username, p.Id, p.Color, local)
+		p = m.players[i]
+		opponent := m.players[1-i]
+		if opponent.Username != "" {
+			m.send(p, EventStarted(opponent.Username, opponent.Color == chess.Black,
+				m.StartTime, m.EndTime))
+			if opponent.Username != username && m.game.Outcome() == chess.NoOutcome {
+				m.send(opponent, EventOpponentConnected())
+			}
+		}
+		return p, true
+	}
+	return Player{}, false
+}
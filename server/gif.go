@@ -0,0 +1,233 @@
+// animated GIFs of whole games, for sharing them
+package server
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notnil/chess"
+)
+
+const (
+	DEFAULT_GIF_FRAME_DELAY = time.Second
+	MIN_GIF_FRAME_DELAY     = 100 * time.Millisecond
+	MAX_GIF_FRAME_DELAY     = 10 * time.Second
+	// the final position is shown this many frame delays before the GIF loops
+	GIF_LAST_FRAME_DELAYS = 3
+	// every move is a frame, bigger squares take too long to draw for long games
+	MAX_GIF_SQUARE_SIZE = 64
+)
+
+// @Summary		Get an animated GIF of a finished game
+// @Description	Every position of the game is a frame, from the start to the final position, which is shown longer.
+// @Description	Finished games can be fetched until the match id is used again. Games imported with POST /imports/pgn can be fetched with their id.
+// @Description	The board is drawn using the board theme and piece set in your preferences, from the side of your color if you played.
+// @Description	Responses have an `ETag`. Send it in `If-None-Match` to get a `304`.
+// @Tags			matches
+// @Produce		gif,json
+// @Param			Authorization	header	string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path	string	true	"Match ID"
+// @Param			delay			query	int		false	"time each position is shown, in milliseconds"	minimum(100)	maximum(10000)	default(1000)
+// @Param			orientation		query	string	false	"color at the bottom of the board"				Enums(white, black)
+// @Param			theme			query	string	false	"board theme"									Enums(brown, blue, green, gray)
+// @Param			pieces			query	string	false	"piece set"										Enums(cburnett)
+// @Param			size			query	int		false	"width of a square in pixels"					minimum(16)	maximum(64)	default(45)
+// @Param			lastMove		query	bool	false	"highlight the squares of the last move"
+// @Param			check			query	bool	false	"highlight the king in check"
+// @Success		200				{file}	string	"GIF image"
+// @Success		304				"The GIF did not change since the ETag in If-None-Match"
+// @Header			200,304			{string}	ETag			"Identifies the game and how it is drawn"
+// @Header			200,304			{string}	Cache-Control	"private, no-cache"
+// @Failure		400				{object}	ErrorReason		"Invalid query parameter"
+// @Failure		401				{object}	ErrorReason
+// @Failure		404				{object}	ErrorReason	"Match not found"
+// @Failure		409				{object}	ErrorReason	"The game is not over"
+// @Failure		500				{object}	ErrorReason
+// @Router			/matches/{id}/gif [get]
+func (s Server) GetMatchGIF(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	pgn, playedBlack, err := s.finishedGame(ctx, c.Param("id"), user.Uid, user.Username)
+	if err != nil {
+		return err
+	}
+
+	orientation := chess.White
+	if playedBlack {
+		orientation = chess.Black
+	}
+	st, err := parseBoardStyle(c, s.preferencesOf(ctx, user.Username), orientation)
+	if err != nil {
+		return err
+	}
+	if st.SquareSize > MAX_GIF_SQUARE_SIZE {
+		return c.JSON(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, fmt.Sprintf("size must be a number from %d to %d", MIN_SQUARE_SIZE, MAX_GIF_SQUARE_SIZE)))
+	}
+	delay := DEFAULT_GIF_FRAME_DELAY
+	if d := c.QueryParam("delay"); d != "" {
+		ms, err := strconv.Atoi(d)
+		delay = time.Duration(ms) * time.Millisecond
+		if err != nil || delay < MIN_GIF_FRAME_DELAY || delay > MAX_GIF_FRAME_DELAY {
+			return c.JSON(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, fmt.Sprintf("delay must be a number of milliseconds from %d to %d", MIN_GIF_FRAME_DELAY.Milliseconds(), MAX_GIF_FRAME_DELAY.Milliseconds())))
+		}
+	}
+
+	// a finished game never changes
+	if notModified(c, positionETag("gif", pgn, st.key(), strconv.FormatBool(st.lastMove), strconv.FormatBool(st.check), delay.String())) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	pgnOpt, err := chess.PGN(strings.NewReader(pgn))
+	if err != nil {
+		slog.Error("failed to read the moves of a finished game", "match", c.Param("id"), "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	animation, err := drawGIF(ctx, chess.NewGame(pgnOpt), st, delay)
+	if err != nil {
+		return err
+	}
+	return c.Blob(http.StatusOK, "image/gif", animation)
+}
+
+// finishedGame is the PGN of the finished game of a match, and whether the user played black in it.
+// Matches are kept for a while after the game ends, after that the game is read from the archive. Imported games are read from the imports.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (s Server) finishedGame(ctx context.Context, matchID string, uid int64, username string) (pgn string, playedBlack bool, err error) {
+	if match, ok := s.GameStorage.GetMatch(matchID); ok {
+		if match.Outcome() == chess.NoOutcome {
+			return "", false, echo.NewHTTPError(http.StatusConflict, Reason(CODE_GAME_NOT_OVER, "The game is not over"))
+		}
+		black, _ := match.GetPlayerWithColor(chess.Black)
+		return match.PGN(), black.Username == username, nil
+	}
+	if isImportID(matchID) {
+		imported, _, err := s.importedGame(ctx, matchID)
+		return imported.Pgn, false, err
+	}
+	g, err := s.DB.GetLatestGameByMatchId(ctx, matchID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, echo.NewHTTPError(http.StatusNotFound, Reason(CODE_MATCH_NOT_FOUND, "match not found"))
+	}
+	if err != nil {
+		slog.Error("failed to get archived game", "match", matchID, "error", err)
+		return "", false, echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return g.Moves, g.BlackUid == uid, nil
+}
+
+// drawGIF draws every position of g as a frame shown for delay
+func drawGIF(ctx context.Context, g *chess.Game, st boardStyle, delay time.Duration) (animation []byte, err error) {
+	_, span := tracer.Start(ctx, "board.gif")
+	defer func() { endSpan(span, err) }()
+
+	icons, err := pieceIcons()
+	if err != nil {
+		return nil, err
+	}
+	positions, moves := g.Positions(), g.Moves()
+	frames := make([]*image.RGBA, len(positions))
+	for i, position := range positions {
+		var move *chess.Move
+		if i > 0 {
+			move = moves[i-1]
+		}
+		if frames[i], err = rasterize(position.Board(), st.options(position, move), icons); err != nil {
+			return nil, err
+		}
+	}
+
+	// the first and last positions have the most pieces and highlights, their colors are used for all frames
+	palette := gifPalette(frames[0], frames[len(frames)-1])
+	indexes := map[color.RGBA]uint8{}
+	anim := gif.GIF{LoopCount: 0}
+	var previous *image.Paletted
+	for i, frame := range frames {
+		paletted := image.NewPaletted(frame.Bounds(), palette)
+		for j := 0; j < len(frame.Pix); j += 4 {
+			c := color.RGBA{frame.Pix[j], frame.Pix[j+1], frame.Pix[j+2], frame.Pix[j+3]}
+			index, ok := indexes[c]
+			if !ok {
+				index = uint8(palette.Index(c))
+				indexes[c] = index
+			}
+			paletted.Pix[j/4] = index
+		}
+		// frames after the first only redraw the squares that changed
+		var img image.Image = paletted
+		if previous != nil {
+			img = paletted.SubImage(changedBounds(previous, paletted))
+		}
+		previous = paletted
+		frameDelay := delay
+		if i == len(frames)-1 {
+			frameDelay *= GIF_LAST_FRAME_DELAYS
+		}
+		anim.Image = append(anim.Image, img.(*image.Paletted))
+		// in hundredths of a second
+		anim.Delay = append(anim.Delay, int(frameDelay/(10*time.Millisecond)))
+		anim.Disposal = append(anim.Disposal, gif.DisposalNone)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, &anim); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// gifPalette is the 256 most common colors of the images
+func gifPalette(images ...*image.RGBA) color.Palette {
+	counts := map[color.RGBA]int{}
+	for _, img := range images {
+		for i := 0; i < len(img.Pix); i += 4 {
+			counts[color.RGBA{img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3]}]++
+		}
+	}
+	// ties are broken by the color, so the same game always gets the same GIF
+	colors := slices.SortedFunc(maps.Keys(counts), func(a, b color.RGBA) int {
+		return cmp.Or(
+			cmp.Compare(counts[b], counts[a]),
+			cmp.Compare(a.R, b.R), cmp.Compare(a.G, b.G), cmp.Compare(a.B, b.B), cmp.Compare(a.A, b.A),
+		)
+	})
+	palette := make(color.Palette, 0, 256)
+	for _, c := range colors[:min(256, len(colors))] {
+		palette = append(palette, c)
+	}
+	return palette
+}
+
+// changedBounds is the smallest rectangle that has all the pixels that differ between a and b.
+// It is never empty, GIF frames need at least one pixel.
+func changedBounds(a, b *image.Paletted) image.Rectangle {
+	bounds := b.Bounds()
+	minX, minY, maxX, maxY := bounds.Max.X, bounds.Max.Y, bounds.Min.X, bounds.Min.Y
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if a.ColorIndexAt(x, y) != b.ColorIndexAt(x, y) {
+				minX, minY = min(minX, x), min(minY, y)
+				maxX, maxY = max(maxX, x+1), max(maxY, y+1)
+			}
+		}
+	}
+	if minX >= maxX {
+		return image.Rect(0, 0, 1, 1)
+	}
+	return image.Rect(minX, minY, maxX, maxY)
+}
@@ -0,0 +1,388 @@
+// the gRPC api, see proto/chess.proto
+package server
+
+import (
+	"api/chesspb"
+	"api/db"
+	"api/server/game"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notnil/chess"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// domain of the ErrorInfo details of errors
+const GRPC_ERROR_DOMAIN = "chess-api"
+
+// gRPC codes of the HTTP statuses the handlers respond with
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.FailedPrecondition,
+	http.StatusUnprocessableEntity: codes.InvalidArgument,
+	http.StatusTooManyRequests:     codes.ResourceExhausted,
+	http.StatusInternalServerError: codes.Internal,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+}
+
+// ChessService implements the Chess gRPC service with the same rules as the HTTP handlers.
+type ChessService struct {
+	chesspb.UnimplementedChessServer
+	s         Server
+	validator *RequestValidator
+}
+
+// NewGRPCServer serves the Chess service. Calls are authorized and rate limited like HTTP requests,
+// streams count as one request when they are opened.
+func (s Server) NewGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(s.recoverUnary, s.authUnary),
+		grpc.ChainStreamInterceptor(s.recoverStream, s.authStream),
+	)
+	g := grpc.NewServer(opts...)
+	chesspb.RegisterChessServer(g, &ChessService{s: s, validator: NewRequestValidator()})
+	return g
+}
+
+// the user whose api key authorized a call, see authorizeCall
+type grpcUserKey struct{}
+
+// requireUser is the user whose api key authorized the call.
+// The returned error is an *echo.HTTPError, see grpcError.
+func requireUser(ctx context.Context) (db.User, error) {
+	user, ok := ctx.Value(grpcUserKey{}).(db.User)
+	if !ok {
+		return db.User{}, echo.NewHTTPError(http.StatusUnauthorized, REASON_UNAUTHORIZED)
+	}
+	return user, nil
+}
+
+// authorizeCall checks the api key in the authorization metadata like AuthApiKeyMiddleware,
+// and limits calls like RateLimitMiddleware. The returned context has the user, if there is an api key.
+func (s Server) authorizeCall(ctx context.Context, method string) (context.Context, error) {
+	limiter := s.RateLimits.Public
+	key := "ip:"
+	if p, ok := peer.FromContext(ctx); ok {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			host = p.Addr.String()
+		}
+		key += host
+	}
+	if values := metadata.ValueFromIncomingContext(ctx, "authorization"); len(values) > 0 && values[0] != "" {
+		user, err := s.authenticate(ctx, values[0])
+		if err != nil {
+			return nil, grpcError(err)
+		}
+		ctx = context.WithValue(ctx, grpcUserKey{}, user)
+		limiter, key = s.RateLimits.Authenticated, "user:"+user.Username
+	}
+	if method == chesspb.Chess_SubmitMove_FullMethodName {
+		limiter = s.RateLimits.Move
+	}
+	if ok, limit, _, _, retryAfter := limiter.Allow(key); !ok && limit.Requests > 0 {
+		grpc.SetHeader(ctx, metadata.Pairs("retry-after", retryAfterSeconds(retryAfter)))
+		return nil, grpcError(echo.NewHTTPError(http.StatusTooManyRequests, Reason(CODE_RATE_LIMITED, "Too many requests, try again later")))
+	}
+	return ctx, nil
+}
+
+func (s Server) authUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := s.authorizeCall(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s Server) authStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authorizeCall(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authorizedStream{ServerStream: ss, ctx: ctx})
+}
+
+// authorizedStream is a stream whose context has the user that opened it
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (st *authorizedStream) Context() context.Context {
+	return st.ctx
+}
+
+// recoverUnary stops a panic in a call from crashing the server, like RecoverMiddleware
+func (s Server) recoverUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (_ any, err error) {
+	defer recoverCall(info.FullMethod, &err)
+	return handler(ctx, req)
+}
+
+func (s Server) recoverStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer recoverCall(info.FullMethod, &err)
+	return handler(srv, ss)
+}
+
+func recoverCall(method string, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	slog.Error("grpc call panicked", "method", method, "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+	*err = grpcError(echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR))
+}
+
+// grpcError converts an *echo.HTTPError from the helpers shared with the HTTP handlers to a gRPC status.
+// The error code is sent in an ErrorInfo detail, and invalid fields in a BadRequest detail.
+func grpcError(err error) error {
+	reason := REASON_INTERNAL_ERROR
+	code := codes.Internal
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		if c, ok := grpcCodes[he.Code]; ok {
+			code = c
+		} else {
+			code = codes.Unknown
+		}
+		switch m := he.Message.(type) {
+		case ErrorReason:
+			reason = m
+		case string:
+			c, ok := statusCodes[he.Code]
+			if !ok {
+				c = CODE_INTERNAL_ERROR
+			}
+			reason = Reason(c, m)
+		}
+	} else {
+		slog.Error("unhandled grpc error", "error", err)
+	}
+	st := status.New(code, reason.Reason)
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: string(reason.Code), Domain: GRPC_ERROR_DOMAIN}}
+	if len(reason.Fields) > 0 {
+		bad := &errdetails.BadRequest{}
+		for _, f := range reason.Fields {
+			bad.FieldViolations = append(bad.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Reason})
+		}
+		details = append(details, bad)
+	}
+	if detailed, err := st.WithDetails(details...); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+func (cs *ChessService) CreateMatch(ctx context.Context, in *chesspb.CreateMatchRequest) (*chesspb.CreateMatchResponse, error) {
+	user, err := requireUser(ctx)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	req := CreateMatchRequest{Duration: int(in.GetDuration()), Rated: in.GetRated()}
+	if err := cs.validator.Validate(req); err != nil {
+		return nil, grpcError(err)
+	}
+	match, err := cs.s.createMatch(ctx, user.Username, user.IsGuest, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &chesspb.CreateMatchResponse{MatchId: match.ID}, nil
+}
+
+func (cs *ChessService) JoinMatch(in *chesspb.JoinMatchRequest, stream grpc.ServerStreamingServer[chesspb.MatchEvent]) error {
+	ctx := stream.Context()
+	user, err := requireUser(ctx)
+	if err != nil {
+		return grpcError(err)
+	}
+	match, ok := cs.s.GameStorage.GetMatch(in.GetMatchId())
+	if !ok {
+		return grpcError(echo.NewHTTPError(http.StatusNotFound, Reason(CODE_MATCH_NOT_FOUND, "Match not found")))
+	}
+	if err := cs.s.checkCanJoin(ctx, user.Username, user.IsGuest, match); err != nil {
+		return grpcError(err)
+	}
+	asColor := cs.s.joinColor(match.ID, user.Username, in.GetBlackPieces())
+	if err := cs.s.playMatch(ctx, user.Username, match, asColor, &grpcMatchStream{stream: stream}); err != nil {
+		return grpcError(err)
+	}
+	return nil
+}
+
+func (cs *ChessService) SubmitMove(ctx context.Context, in *chesspb.SubmitMoveRequest) (*chesspb.SubmitMoveResponse, error) {
+	user, err := requireUser(ctx)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	req := PutMoveRequest{Move: in.GetMove()}
+	if err := cs.validator.Validate(req); err != nil {
+		return nil, grpcError(err)
+	}
+	if err := cs.s.submitMove(ctx, user.Username, in.GetMatchId(), req.Move); err != nil {
+		return nil, grpcError(err)
+	}
+	return &chesspb.SubmitMoveResponse{}, nil
+}
+
+func (cs *ChessService) WatchMatch(in *chesspb.WatchMatchRequest, stream grpc.ServerStreamingServer[chesspb.WatchEvent]) error {
+	s := cs.s
+	if s.Draining() {
+		return grpcError(echo.NewHTTPError(http.StatusServiceUnavailable, REASON_SHUTTING_DOWN))
+	}
+	match, ok := s.GameStorage.GetMatch(in.GetMatchId())
+	if !ok {
+		return grpcError(echo.NewHTTPError(http.StatusNotFound, Reason(CODE_MATCH_NOT_FOUND, "Match not found")))
+	}
+	// subscribed before reading the state, so no move is missed
+	updates, unwatch := s.watch(match)
+	defer unwatch()
+	state := matchStateToProto(match)
+	if err := stream.Send(&chesspb.WatchEvent{Event: &chesspb.WatchEvent_State{State: state}}); err != nil {
+		return nil
+	}
+	if match.Outcome() != chess.NoOutcome {
+		return stream.Send(&chesspb.WatchEvent{Event: &chesspb.WatchEvent_GameOver{GameOver: &chesspb.GameOver{Result: string(match.Outcome())}}})
+	}
+
+	// deleted matches don't end their game, they are checked for now and then
+	ticker := time.NewTicker(SSE_KEEP_ALIVE_INTERVAL)
+	defer ticker.Stop()
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, ok := s.GameStorage.GetMatch(match.ID); !ok {
+				return nil
+			}
+		case u, ok := <-updates:
+			if !ok {
+				return grpcError(echo.NewHTTPError(http.StatusTooManyRequests, Reason(CODE_RATE_LIMITED, "You did not read the moves fast enough, watch the match again")))
+			}
+			if u.Result != "" {
+				return stream.Send(&chesspb.WatchEvent{Event: &chesspb.WatchEvent_GameOver{GameOver: &chesspb.GameOver{Result: u.Result}}})
+			}
+			// already part of the state
+			if u.Ply <= len(state.Moves) {
+				continue
+			}
+			move := &chesspb.MovePlayed{Move: u.Move, Ply: int32(u.Ply), Fen: u.FEN}
+			if err := stream.Send(&chesspb.WatchEvent{Event: &chesspb.WatchEvent_Move{Move: move}}); err != nil {
+				return nil
+			}
+		case <-s.lifecycle.drained:
+			return grpcError(echo.NewHTTPError(http.StatusServiceUnavailable, REASON_SHUTTING_DOWN))
+		}
+	}
+}
+
+// grpcMatchStream sends match events as MatchEvent messages, see ChessService.JoinMatch
+type grpcMatchStream struct {
+	stream grpc.ServerStreamingServer[chesspb.MatchEvent]
+}
+
+func (st *grpcMatchStream) start(_ *game.Match, _ game.Player) error {
+	// lets the client know it joined before the first event
+	return st.stream.SendHeader(metadata.MD{})
+}
+
+func (st *grpcMatchStream) event(ctx context.Context, e game.Event) error {
+	_, span := tracer.Start(ctx, "grpc.send", trace.WithLinks(trace.Link{SpanContext: e.Trace}),
+		trace.WithAttributes(attribute.String("event.type", string(e.Type))))
+	defer span.End()
+	return st.stream.Send(matchEventToProto(e))
+}
+
+// gRPC keeps connections alive itself
+func (st *grpcMatchStream) keepAlive() error {
+	return nil
+}
+
+func matchEventToProto(e game.Event) *chesspb.MatchEvent {
+	var event chesspb.MatchEvent
+	switch e.Type {
+	case game.OpponentInfo:
+		opponent := &chesspb.OpponentJoined{Username: e.OponentUsername, Black: e.OpponentBlack}
+		if e.StartTime != nil {
+			opponent.StartTime = timestamppb.New(*e.StartTime)
+		}
+		if e.EndTime != nil {
+			opponent.EndTime = timestamppb.New(*e.EndTime)
+		}
+		event.Event = &chesspb.MatchEvent_Opponent{Opponent: opponent}
+	case game.Move:
+		move := &chesspb.MovePlayed{
+			Move:      e.Move,
+			Fen:       e.FEN,
+			San:       e.SAN,
+			Check:     e.Check,
+			Checkmate: e.Checkmate,
+			Capture:   e.Capture,
+			Castle:    e.Castle,
+			Promotion: e.Promotion,
+		}
+		event.Event = &chesspb.MatchEvent_Move{Move: move}
+	case game.Resign:
+		event.Event = &chesspb.MatchEvent_Resign{Resign: &chesspb.Resigned{}}
+	case game.Chat:
+		event.Event = &chesspb.MatchEvent_Chat{Chat: &chesspb.ChatMessage{From: e.From, Message: e.Message}}
+	case game.Aborted:
+		event.Event = &chesspb.MatchEvent_Aborted{Aborted: &chesspb.Aborted{}}
+	case game.Adjudicated:
+		event.Event = &chesspb.MatchEvent_Adjudicated{Adjudicated: &chesspb.Adjudicated{Result: e.Result}}
+	case game.ServerRestarting:
+		event.Event = &chesspb.MatchEvent_ServerRestarting{ServerRestarting: &chesspb.ServerRestarting{}}
+	case game.Resync:
+		event.Event = &chesspb.MatchEvent_Resync{Resync: &chesspb.Resync{Fen: e.FEN}}
+	case game.OpeningDetected:
+		event.Event = &chesspb.MatchEvent_Opening{Opening: &chesspb.OpeningDetected{Eco: e.ECO, Name: e.OpeningName}}
+	case game.OpponentDisconnected, game.OpponentConnected:
+		presence := &chesspb.OpponentPresence{Connected: e.Type == game.OpponentConnected}
+		event.Event = &chesspb.MatchEvent_OpponentPresence{OpponentPresence: presence}
+	case game.SpectatorJoined, game.SpectatorLeft:
+		spectators := &chesspb.Spectators{Joined: e.Type == game.SpectatorJoined}
+		if e.Spectators != nil {
+			spectators.Count = int32(*e.Spectators)
+		}
+		event.Event = &chesspb.MatchEvent_Spectators{Spectators: spectators}
+	}
+	return &event
+}
+
+func matchStateToProto(m *game.Match) *chesspb.MatchState {
+	state := &chesspb.MatchState{
+		MatchId:   m.ID,
+		Rated:     m.Rated,
+		Moves:     m.UCIMoves(),
+		Fen:       m.Position().String(),
+		StartTime: timestamppb.New(m.StartTime),
+		EndTime:   timestamppb.New(m.EndTime),
+	}
+	if p, ok := m.GetPlayerWithColor(chess.White); ok {
+		state.White = p.Username
+	}
+	if p, ok := m.GetPlayerWithColor(chess.Black); ok {
+		state.Black = p.Username
+	}
+	return state
+}
@@ -0,0 +1,52 @@
+// probes for load balancers and orchestrators
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SCHEMA_VERSION is the user_version set at the end of schema.sql, and by the last of the migrations.
+// A lower version means the database was not migrated or the schema was not applied completely.
+const SCHEMA_VERSION = 19
+
+// how long /readyz waits for the database
+const READINESS_TIMEOUT = 2 * time.Second
+
+// @Summary		Liveness probe
+// @Description	Always succeeds while the process is running.
+// @Tags			health
+// @Produce		json
+// @Success		200	{object}	string	"ok"
+// @Router			/healthz [get]
+func (s Server) Healthz(c echo.Context) error {
+	return c.JSON(http.StatusOK, "ok")
+}
+
+// @Summary		Readiness probe
+// @Description	Succeeds when the database is reachable and the schema is up to date, with the migrations of older databases done.
+// @Tags			health
+// @Produce		json
+// @Success		200	{object}	string		"ready"
+// @Failure		503	{object}	ErrorReason	"database unreachable / schema not applied / shutting down"
+// @Router			/readyz [get]
+func (s Server) Readyz(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), READINESS_TIMEOUT)
+	defer cancel()
+	if s.Draining() {
+		return c.JSON(http.StatusServiceUnavailable, REASON_SHUTTING_DOWN)
+	}
+	var version int
+	if err := s.SQL.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		slog.Warn("readiness check failed", "error", err)
+		return c.JSON(http.StatusServiceUnavailable, Reason(CODE_NOT_READY, "database unreachable"))
+	}
+	if version < SCHEMA_VERSION {
+		return c.JSON(http.StatusServiceUnavailable, Reason(CODE_NOT_READY, "database schema not applied"))
+	}
+	return c.JSON(http.StatusOK, "ready")
+}
@@ -0,0 +1,206 @@
+// games played elsewhere, imported from PGN to review them
+package server
+
+import (
+	"api/db"
+	"api/server/game"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notnil/chess"
+)
+
+const (
+	// longest PGN that can be imported, in bytes
+	MAX_IMPORT_PGN_LENGTH = 64 * 1024
+	// match ids are 6 characters, imported games use the same endpoints
+	IMPORT_ID_LENGTH = 12
+)
+
+type ImportPGNRequest struct {
+	// one game, with or without tags
+	PGN string `json:"pgn" form:"pgn" maxLength:"65536" example:"[White \"Anderssen\"]\n[Black \"Kieseritzky\"]\n\n1. e4 e5 2. f4 exf4 *" validate:"required,max=65536"`
+}
+
+// ImportedGameResponse is a game imported from PGN
+type ImportedGameResponse struct {
+	// use it as the match id with the board, image and GIF endpoints of matches
+	ID    string `json:"id" example:"AB2C21DE3F4G"`
+	White string `json:"white" example:"Anderssen"`
+	Black string `json:"black" example:"Kieseritzky"`
+	// empty if the PGN has no result
+	Result string `json:"result" enums:"white,black,draw," example:"white"`
+	// number of half moves
+	Plies      int          `json:"plies" example:"4"`
+	Opening    game.Opening `json:"opening"`
+	ImportedAt time.Time    `json:"importedAt" format:"date-time"`
+}
+
+// @Summary		Import a game from PGN
+// @Description	Stores a game played elsewhere so it can be reviewed with this api.
+// @Description	The returned id works like a match id with GET /matches/{id}, /matches/{id}/img and /matches/{id}/gif, which take a `ply` to show any position of the game.
+// @Description	Imported games are read-only, moves cannot be played in them. The PGN can be sent as json or as the `pgn` field of a form.
+// @Tags			imports
+// @Accept			json,x-www-form-urlencoded
+// @Produce		json
+// @Param			Authorization	header		string				true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			payload			body		ImportPGNRequest	true	"PGN of one game"
+// @Success		201				{object}	ImportedGameResponse
+// @Failure		400				{object}	ErrorReason	"Invalid json body / invalid PGN"
+// @Failure		401				{object}	ErrorReason
+// @Failure		500				{object}	ErrorReason
+// @Router			/imports/pgn [post]
+func (s Server) ImportPGN(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	var req ImportPGNRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	pgn := strings.TrimSpace(req.PGN)
+	g, err := readPGN(pgn)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Reason(CODE_INVALID_PGN, "invalid PGN: "+strings.ReplaceAll(err.Error(), "chess: ", "")))
+	}
+	opening := importedOpening(g, g.Moves())
+	result := ""
+	if g.Outcome() != chess.NoOutcome {
+		result = resultFromOutcome(g.Outcome())
+	}
+	imported, err := s.DB.CreateImportedGame(c.Request().Context(), db.CreateImportedGameParams{
+		ID:      rand.Text()[:IMPORT_ID_LENGTH],
+		Uid:     user.Uid,
+		White:   pgnTag(g, "White"),
+		Black:   pgnTag(g, "Black"),
+		Result:  result,
+		Pgn:     pgn,
+		Eco:     opening.ECO,
+		Opening: opening.Name,
+	})
+	if err != nil {
+		slog.Error("failed to store imported game", "username", user.Username, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return c.JSON(http.StatusCreated, ImportedGameResponse{
+		ID:         imported.ID,
+		White:      imported.White,
+		Black:      imported.Black,
+		Result:     imported.Result,
+		Plies:      len(g.Moves()),
+		Opening:    opening,
+		ImportedAt: imported.ImportedAt,
+	})
+}
+
+// readPGN parses the PGN of one game
+func readPGN(pgn string) (*chess.Game, error) {
+	pgnOpt, err := chess.PGN(strings.NewReader(pgn))
+	if err != nil {
+		return nil, err
+	}
+	return chess.NewGame(pgnOpt), nil
+}
+
+// pgnTag is the value of the tag key of g, ? if it has none
+func pgnTag(g *chess.Game, key string) string {
+	if tag := g.GetTagPair(key); tag != nil && tag.Value != "" {
+		return tag.Value
+	}
+	return "?"
+}
+
+// importedOpening names the opening of moves of g. Games that start from a FEN have none, the book starts from the initial position.
+func importedOpening(g *chess.Game, moves []*chess.Move) game.Opening {
+	if g.GetTagPair("FEN") != nil {
+		return game.Opening{}
+	}
+	return game.FindOpening(moves)
+}
+
+// isImportID is true if id can be the id of an imported game, so match ids don't query the database
+func isImportID(id string) bool {
+	return len(id) == IMPORT_ID_LENGTH
+}
+
+// importedGame is the imported game with id, parsed
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (s Server) importedGame(ctx context.Context, id string) (db.ImportedGame, *chess.Game, error) {
+	imported, err := s.DB.GetImportedGame(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return imported, nil, echo.NewHTTPError(http.StatusNotFound, Reason(CODE_MATCH_NOT_FOUND, "match not found"))
+	}
+	if err != nil {
+		slog.Error("failed to get imported game", "id", id, "error", err)
+		return imported, nil, echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	g, err := readPGN(imported.Pgn)
+	if err != nil {
+		slog.Error("failed to read the moves of an imported game", "id", id, "error", err)
+		return imported, nil, echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return imported, g, nil
+}
+
+// matchBoard is a position of a match or an imported game, for the board endpoints
+type matchBoard struct {
+	position *chess.Position
+	// the move that led to the position, nil before the first move
+	lastMove     *chess.Move
+	opening      game.Opening
+	openingFinal bool
+	material     game.Material
+	// username of the black player, empty for imported games
+	black string
+}
+
+// boardOf is the board of the match with id, or of the imported game with id after the number of half moves in the ply query parameter.
+// Without ply it is the final position of an imported game. Matches only have their current position.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (s Server) boardOf(c echo.Context, id string) (matchBoard, error) {
+	if match, ok := s.GameStorage.GetMatch(id); ok {
+		if c.QueryParam("ply") != "" {
+			return matchBoard{}, invalidQuery("ply can only be used with imported games")
+		}
+		b := matchBoard{}
+		b.position, b.lastMove = match.LastMove()
+		b.opening, b.openingFinal = match.Opening()
+		b.material = match.Material()
+		if black, ok := match.GetPlayerWithColor(chess.Black); ok {
+			b.black = black.Username
+		}
+		return b, nil
+	}
+	if !isImportID(id) {
+		return matchBoard{}, echo.NewHTTPError(http.StatusNotFound, Reason(CODE_MATCH_NOT_FOUND, "match not found"))
+	}
+	_, g, err := s.importedGame(c.Request().Context(), id)
+	if err != nil {
+		return matchBoard{}, err
+	}
+	moves := g.Moves()
+	ply := len(moves)
+	if p := c.QueryParam("ply"); p != "" {
+		ply, err = strconv.Atoi(p)
+		if err != nil || ply < 0 || ply > len(moves) {
+			return matchBoard{}, invalidQuery(fmt.Sprintf("ply must be a number from 0 to %d", len(moves)))
+		}
+	}
+	// an imported game never changes, neither does its opening
+	b := matchBoard{position: g.Positions()[ply], opening: importedOpening(g, moves[:ply]), openingFinal: true}
+	b.material = game.MaterialOf(g.Positions(), moves[:ply])
+	if ply > 0 {
+		b.lastMove = moves[ply-1]
+	}
+	return b, nil
+}
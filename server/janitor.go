@@ -0,0 +1,38 @@
+// periodic clean up of the database
+package server
+
+import (
+	"context"
+	"time"
+)
+
+// janitor runs clean up tasks every hour until ctx is cancelled.
+func (s Server) janitor(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	exists := func(matchID string) bool {
+		_, ok := s.GameStorage.GetMatch(matchID)
+		return ok
+	}
+	for {
+		s.purgeExpiredGuests(ctx)
+		s.purgeDeletedUsers(ctx)
+		s.purgeOldMatchMoves(ctx)
+		s.purgeOldWebhookDeliveries(ctx)
+		s.purgeEndedTeamMatches(ctx)
+		s.purgeOldFairPlayGames(ctx)
+		s.Simuls.cleanup(exists)
+		s.MoveClock.cleanup(exists)
+		s.ChatLimiter.cleanup()
+		s.LoginThrottle.cleanup()
+		s.SignupChallenges.cleanup()
+		s.Challenges.cleanup()
+		s.Seeks.cleanup()
+		s.RateLimits.cleanup()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
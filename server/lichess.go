@@ -0,0 +1,433 @@
+// routes shaped like the Lichess board and bot APIs, so clients written for Lichess can play here
+package server
+
+import (
+	"api/server/game"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notnil/chess"
+)
+
+// LichessError is how the Lichess API reports errors
+type LichessError struct {
+	Error string `json:"error" example:"Not your turn, or game already over"`
+}
+
+// LichessOk is the response of Lichess API calls that have nothing to return
+type LichessOk struct {
+	Ok bool `json:"ok" example:"true"`
+}
+
+// LichessPlayer is a player of a LichessGameFull
+type LichessPlayer struct {
+	// the username in lowercase
+	ID   string `json:"id" example:"johndoe"`
+	Name string `json:"name" example:"JohnDoe"`
+	// BOT for bot accounts
+	Title string `json:"title,omitempty" example:"BOT"`
+}
+
+type LichessVariant struct {
+	Key   string `json:"key" example:"standard"`
+	Name  string `json:"name" example:"Standard"`
+	Short string `json:"short" example:"Std"`
+}
+
+type LichessPerf struct {
+	Name string `json:"name" example:"Correspondence"`
+}
+
+// LichessGameState is a line of a Lichess game stream, sent after every move and when the game ends
+type LichessGameState struct {
+	Type string `json:"type" example:"gameState"`
+	// moves so far in UCI notation, separated by spaces
+	Moves string `json:"moves" example:"e2e4 e7e5"`
+	// milliseconds until the match is deleted if the game does not end, the same for both players
+	Wtime int64 `json:"wtime" example:"43200000"`
+	Btime int64 `json:"btime" example:"43200000"`
+	Winc  int64 `json:"winc" example:"0"`
+	Binc  int64 `json:"binc" example:"0"`
+	// started, mate, resign, stalemate, draw or aborted
+	Status string `json:"status" example:"started"`
+	// white or black, left out for draws and running games
+	Winner string `json:"winner,omitempty" example:"white"`
+}
+
+// LichessGameFull is the first line of a Lichess game stream, and is sent again when the opponent joins
+type LichessGameFull struct {
+	Type      string         `json:"type" example:"gameFull"`
+	ID        string         `json:"id" example:"AB2C21"`
+	Rated     bool           `json:"rated" example:"false"`
+	Variant   LichessVariant `json:"variant"`
+	Speed     string         `json:"speed" example:"correspondence"`
+	Perf      LichessPerf    `json:"perf"`
+	CreatedAt int64          `json:"createdAt" example:"1523825103562"` // in milliseconds since 1970
+	// left out until the player joined
+	White      *LichessPlayer   `json:"white,omitempty"`
+	Black      *LichessPlayer   `json:"black,omitempty"`
+	InitialFen string           `json:"initialFen" example:"startpos"`
+	State      LichessGameState `json:"state"`
+}
+
+// LichessChatLine is a line of a Lichess game stream, sent for chat messages
+type LichessChatLine struct {
+	Type     string `json:"type" example:"chatLine"`
+	Room     string `json:"room" example:"player"`
+	Username string `json:"username" example:"JohnDoe"`
+	Text     string `json:"text" example:"good luck!"`
+}
+
+// LichessOpponentGone is a line of a Lichess game stream, sent when the opponent's stream ends and when they are back
+type LichessOpponentGone struct {
+	Type string `json:"type" example:"opponentGone"`
+	Gone bool   `json:"gone" example:"true"`
+}
+
+type LichessChatRequest struct {
+	// only the player room exists
+	Room string `json:"room" form:"room" example:"player" validate:"oneof=player"`
+	Text string `json:"text" form:"text" maxLength:"200" example:"good luck!" validate:"notblank,max=200"`
+}
+
+// LichessChatMessage is a message of the chat history
+type LichessChatMessage struct {
+	Text string `json:"text" example:"good luck!"`
+	User string `json:"user" example:"JohnDoe"`
+}
+
+// lichessErrors responds to the errors of the Lichess routes like Lichess does, with an `error` field.
+// It must run before AuthApiKeyMiddleware, to convert its errors too.
+func (s Server) lichessErrors(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		err := next(c)
+		var he *echo.HTTPError
+		if err == nil || c.Response().Committed || !errors.As(err, &he) {
+			return err
+		}
+		message := fmt.Sprint(he.Message)
+		if reason, ok := he.Message.(ErrorReason); ok {
+			message = reason.Reason
+			for _, f := range reason.Fields {
+				message += fmt.Sprintf(", %s %s", f.Field, f.Reason)
+			}
+		}
+		return c.JSON(he.Code, LichessError{Error: message})
+	}
+}
+
+// lichessUsername is the user of the api key, which Lichess calls a token.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func lichessUsername(c echo.Context) (string, error) {
+	username := usernameOf(c)
+	if username == "" {
+		return "", echo.NewHTTPError(http.StatusUnauthorized, REASON_UNAUTHORIZED)
+	}
+	return username, nil
+}
+
+// @Summary		Stream a game like the Lichess board API
+// @Description	Joins the match and streams it as newline delimited JSON (Content-Type: application/x-ndjson), in the shapes of the Lichess board API.
+// @Description	The first line is a `gameFull` event, followed by `gameState` events after every move, your own too, `chatLine` events, and `opponentGone` events when the opponent's stream ends and when they are back.
+// @Description	The stream ends after the `gameState` of a finished game.
+// @Description	Unlike on Lichess, matches must be joined through this stream: closing it resigns unless you open it again within a minute, like GET /matches/{id}/play.
+// @Description	The first player to join plays white, unless the match was created from a challenge.
+// @Description	Matches have no clock, `wtime` and `btime` are the time until the match is deleted.
+// @Tags			lichess
+// @Produce		json
+// @Param			Authorization	header		string			true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string			true	"Match ID"
+// @Success		200				{object}	LichessGameFull	"NDJSON stream — one event per line, a LichessGameFull, LichessGameState or LichessChatLine"
+// @Failure		401				{object}	LichessError
+// @Failure		403				{object}	LichessError	"Blocked by the opponent / match is full"
+// @Failure		404				{object}	LichessError	"Match not found"
+// @Failure		429				{object}	LichessError	"Too many open streams"
+// @Failure		503				{object}	LichessError	"Server is restarting"
+// @Router			/api/board/game/stream/{id} [get]
+// @Router			/api/bot/game/stream/{id} [get]
+func (s Server) LichessStreamGame(c echo.Context) error {
+	username, err := lichessUsername(c)
+	if err != nil {
+		return err
+	}
+	match, ok := s.GameStorage.GetMatch(c.Param("id"))
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, Reason(CODE_MATCH_NOT_FOUND, "Match not found"))
+	}
+	ctx := c.Request().Context()
+	if err := s.checkCanJoin(ctx, username, isGuest(c), match); err != nil {
+		return err
+	}
+	color := s.joinColor(match.ID, username, false)
+	return s.playMatch(ctx, username, match, color, &lichessGameStream{s: s, c: c})
+}
+
+// @Summary	Play a move like the Lichess board API
+// @Tags		lichess
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param		id				path		string	true	"Match ID"
+// @Param		move			path		string	true	"move in UCI notation. eg. e2e4"
+// @Success	200				{object}	LichessOk
+// @Failure	400				{object}	LichessError	"The move is not in UCI notation"
+// @Failure	401				{object}	LichessError
+// @Failure	403				{object}	LichessError	"Player not in-game"
+// @Failure	404				{object}	LichessError	"Match not found"
+// @Failure	409				{object}	LichessError	"Not your turn / the game is over"
+// @Failure	422				{object}	LichessError	"The move is not legal in this position"
+// @Failure	503				{object}	LichessError	"Server is restarting"
+// @Router		/api/board/game/{id}/move/{move} [post]
+// @Router		/api/bot/game/{id}/move/{move} [post]
+func (s Server) LichessMove(c echo.Context) error {
+	username, err := lichessUsername(c)
+	if err != nil {
+		return err
+	}
+	if err := s.submitMove(c.Request().Context(), username, c.Param("id"), c.Param("move")); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, LichessOk{Ok: true})
+}
+
+// @Summary		Send a chat message like the Lichess board API
+// @Description	Only the `player` room exists. The same limits as POST /matches/{id}/chat apply.
+// @Tags			lichess
+// @Accept			x-www-form-urlencoded,json
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string	true	"Match ID"
+// @Param			room			formData	string	true	"chat room"	Enums(player)
+// @Param			text			formData	string	true	"chat message"
+// @Success		200				{object}	LichessOk
+// @Failure		400				{object}	LichessError	"Invalid message"
+// @Failure		401				{object}	LichessError
+// @Failure		403				{object}	LichessError	"Blocked"
+// @Failure		404				{object}	LichessError	"Match not found / player not in-game"
+// @Failure		429				{object}	LichessError	"Too many messages"
+// @Failure		500				{object}	LichessError
+// @Router			/api/board/game/{id}/chat [post]
+// @Router			/api/bot/game/{id}/chat [post]
+func (s Server) LichessPostChat(c echo.Context) error {
+	username, err := lichessUsername(c)
+	if err != nil {
+		return err
+	}
+	var req LichessChatRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	if _, err := s.sendChat(c.Request().Context(), username, c.Param("id"), req.Text); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, LichessOk{Ok: true})
+}
+
+// @Summary	Get the chat of a game like the Lichess board API
+// @Tags		lichess
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param		id				path		string	true	"Match ID"
+// @Success	200				{array}		LichessChatMessage
+// @Failure	401				{object}	LichessError
+// @Failure	404				{object}	LichessError	"Match not found / player not in-game"
+// @Failure	500				{object}	LichessError
+// @Router		/api/board/game/{id}/chat [get]
+// @Router		/api/bot/game/{id}/chat [get]
+func (s Server) LichessGetChat(c echo.Context) error {
+	username, err := lichessUsername(c)
+	if err != nil {
+		return err
+	}
+	saved, err := s.chatHistory(c.Request().Context(), username, c.Param("id"))
+	if err != nil {
+		return err
+	}
+	lines := make([]LichessChatMessage, 0, len(saved))
+	for _, m := range saved {
+		lines = append(lines, LichessChatMessage{Text: m.Message, User: m.Username})
+	}
+	return c.JSON(http.StatusOK, lines)
+}
+
+// @Summary	Resign a game like the Lichess board API
+// @Tags		lichess
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param		id				path		string	true	"Match ID"
+// @Success	200				{object}	LichessOk
+// @Failure	401				{object}	LichessError
+// @Failure	404				{object}	LichessError	"Match not found / player not in-game"
+// @Failure	409				{object}	LichessError	"The game is over"
+// @Failure	503				{object}	LichessError	"Server is restarting"
+// @Router		/api/board/game/{id}/resign [post]
+// @Router		/api/bot/game/{id}/resign [post]
+func (s Server) LichessResign(c echo.Context) error {
+	username, err := lichessUsername(c)
+	if err != nil {
+		return err
+	}
+	if s.Draining() {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, REASON_SHUTTING_DOWN)
+	}
+	match, ok := s.GameStorage.GetMatch(c.Param("id"))
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, Reason(CODE_MATCH_NOT_FOUND, "match not found"))
+	}
+	player, ok := match.GetPlayerFromUsername(username)
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, Reason(CODE_PLAYER_NOT_IN_MATCH, "Player not in-game"))
+	}
+	if match.Outcome() != chess.NoOutcome {
+		return echo.NewHTTPError(http.StatusConflict, Reason(CODE_GAME_OVER, "the game is over"))
+	}
+	s.resign(c.Request().Context(), match, player)
+	return c.JSON(http.StatusOK, LichessOk{Ok: true})
+}
+
+// lichessGameStream sends a match like a Lichess game stream, see LichessStreamGame
+type lichessGameStream struct {
+	s     Server
+	c     echo.Context
+	w     *echo.Response
+	match *game.Match
+	// moves sent so far
+	plies int
+	// the state of the finished game was sent
+	over bool
+}
+
+func (st *lichessGameStream) start(match *game.Match, _ game.Player) error {
+	st.w, st.match = st.c.Response(), match
+	startNDJSON(st.c)
+	return writeNDJSON(st.w, st.gameFull(st.c.Request().Context()))
+}
+
+func (st *lichessGameStream) event(ctx context.Context, e game.Event) error {
+	switch e.Type {
+	case game.OpponentInfo:
+		return writeNDJSON(st.w, st.gameFull(ctx))
+	case game.Chat:
+		return writeNDJSON(st.w, LichessChatLine{Type: "chatLine", Room: "player", Username: e.From, Text: e.Message})
+	case game.Move, game.OpeningDetected, game.SpectatorJoined, game.SpectatorLeft:
+		// moves are sent by move, Lichess has no opening or spectator events
+		return nil
+	case game.OpponentDisconnected, game.OpponentConnected:
+		return writeNDJSON(st.w, LichessOpponentGone{Type: "opponentGone", Gone: e.Type == game.OpponentDisconnected})
+	case game.Resign, game.Adjudicated:
+		return st.sendState()
+	case game.Aborted:
+		state := st.gameState()
+		state.Status = "aborted"
+		return writeNDJSON(st.w, state)
+	default:
+		// serverRestarting and resync end the stream, like on bot game streams
+		return writeNDJSON(st.w, BotGameEvent{Type: BotGameEventType(e.Type), FEN: e.FEN})
+	}
+}
+
+func (st *lichessGameStream) move(u MatchUpdate) error {
+	// moves played before the stream started are in gameFull
+	if u.Result == "" && u.Ply <= st.plies {
+		return nil
+	}
+	if err := st.sendState(); err != nil {
+		return err
+	}
+	if st.over {
+		return errors.New("game over")
+	}
+	return nil
+}
+
+func (st *lichessGameStream) keepAlive() error {
+	return writeNDJSONKeepAlive(st.w)
+}
+
+// sendState sends the gameState, once the game is over only the first time
+func (st *lichessGameStream) sendState() error {
+	if st.over {
+		return nil
+	}
+	state := st.gameState()
+	st.over = state.Status != "started"
+	return writeNDJSON(st.w, state)
+}
+
+func (st *lichessGameStream) gameFull(ctx context.Context) LichessGameFull {
+	state := st.gameState()
+	st.over = state.Status != "started"
+	e := LichessGameFull{
+		Type:       "gameFull",
+		ID:         st.match.ID,
+		Rated:      st.match.Rated,
+		Variant:    LichessVariant{Key: "standard", Name: "Standard", Short: "Std"},
+		Speed:      "correspondence",
+		Perf:       LichessPerf{Name: "Correspondence"},
+		CreatedAt:  st.match.StartTime.UnixMilli(),
+		InitialFen: "startpos",
+		State:      state,
+	}
+	if p, ok := st.match.GetPlayerWithColor(chess.White); ok {
+		e.White = st.s.lichessPlayer(ctx, p.Username)
+	}
+	if p, ok := st.match.GetPlayerWithColor(chess.Black); ok {
+		e.Black = st.s.lichessPlayer(ctx, p.Username)
+	}
+	return e
+}
+
+func (st *lichessGameStream) gameState() LichessGameState {
+	moves := st.match.UCIMoves()
+	st.plies = len(moves)
+	left := max(time.Until(st.match.EndTime), 0).Milliseconds()
+	state := LichessGameState{
+		Type:   "gameState",
+		Moves:  strings.Join(moves, " "),
+		Wtime:  left,
+		Btime:  left,
+		Status: lichessStatus(st.match.Outcome(), st.match.Method()),
+	}
+	switch st.match.Outcome() {
+	case chess.WhiteWon:
+		state.Winner = "white"
+	case chess.BlackWon:
+		state.Winner = "black"
+	}
+	return state
+}
+
+// lichessStatus is the status Lichess gives a game that ended with outcome by method
+func lichessStatus(outcome chess.Outcome, method chess.Method) string {
+	if outcome == chess.NoOutcome {
+		return "started"
+	}
+	switch method {
+	case chess.Checkmate:
+		return "mate"
+	case chess.Resignation:
+		return "resign"
+	case chess.Stalemate:
+		return "stalemate"
+	default:
+		return "draw"
+	}
+}
+
+func (s Server) lichessPlayer(ctx context.Context, username string) *LichessPlayer {
+	p := &LichessPlayer{ID: strings.ToLower(username), Name: username}
+	user, err := s.DB.GetUserByUsername(ctx, username)
+	if err != nil {
+		slog.Warn("failed to get player of a lichess game stream", "username", username, "error", err)
+		return p
+	}
+	if user.IsBot {
+		p.Title = "BOT"
+	}
+	return p
+}
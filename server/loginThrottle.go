@@ -0,0 +1,121 @@
+// slowing down password guessing on /auth/login
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// failures allowed before attempts are slowed down
+	LOGIN_FREE_ATTEMPTS = 3
+	// longest wait between attempts
+	LOGIN_MAX_BACKOFF = 15 * time.Minute
+	// consecutive failures on an account before it is locked
+	LOGIN_LOCKOUT_THRESHOLD = 10
+	// how long a locked account stays locked
+	LOGIN_LOCKOUT_DURATION = 15 * time.Minute
+	// failures older than this are forgotten
+	LOGIN_FAILURE_MEMORY = time.Hour
+)
+
+type loginFailures struct {
+	count       int
+	last        time.Time
+	lockedUntil time.Time
+}
+
+// LoginThrottle tracks failed logins per account and per IP.
+// After LOGIN_FREE_ATTEMPTS failures, each attempt must wait twice as long as the previous one.
+// Accounts are locked after LOGIN_LOCKOUT_THRESHOLD failures.
+type LoginThrottle struct {
+	mu       sync.Mutex
+	accounts map[string]*loginFailures
+	ips      map[string]*loginFailures
+}
+
+func NewLoginThrottle() *LoginThrottle {
+	return &LoginThrottle{
+		accounts: map[string]*loginFailures{},
+		ips:      map[string]*loginFailures{},
+	}
+}
+
+// backoff is how long to wait after count failures
+func backoff(count int) time.Duration {
+	if count < LOGIN_FREE_ATTEMPTS {
+		return 0
+	}
+	// 2^20 seconds is far past LOGIN_MAX_BACKOFF, larger exponents would overflow
+	wait := time.Second << min(count-LOGIN_FREE_ATTEMPTS, 20)
+	return min(wait, LOGIN_MAX_BACKOFF)
+}
+
+// Check whether a login attempt may be made right now.
+// locked is true when the account is locked, retryAfter is how long to wait.
+func (t *LoginThrottle) Check(username, ip string) (retryAfter time.Duration, locked bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if f, ok := t.accounts[username]; ok {
+		if now.Before(f.lockedUntil) {
+			return f.lockedUntil.Sub(now), true
+		}
+		retryAfter = max(retryAfter, f.last.Add(backoff(f.count)).Sub(now))
+	}
+	if f, ok := t.ips[ip]; ok {
+		retryAfter = max(retryAfter, f.last.Add(backoff(f.count)).Sub(now))
+	}
+	return max(retryAfter, 0), false
+}
+
+// Fail records a failed login. lockedNow is true if this failure locked the account.
+func (t *LoginThrottle) Fail(username, ip string) (lockedNow bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	account := t.failuresOf(t.accounts, username, now)
+	account.count++
+	account.last = now
+	if account.count >= LOGIN_LOCKOUT_THRESHOLD {
+		account.lockedUntil = now.Add(LOGIN_LOCKOUT_DURATION)
+		// start counting again after the lock ends
+		account.count = 0
+		lockedNow = true
+	}
+	address := t.failuresOf(t.ips, ip, now)
+	address.count++
+	address.last = now
+	return lockedNow
+}
+
+// Succeed forgets the failures of the account and IP.
+func (t *LoginThrottle) Succeed(username, ip string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.accounts, username)
+	delete(t.ips, ip)
+}
+
+func (t *LoginThrottle) failuresOf(m map[string]*loginFailures, key string, now time.Time) *loginFailures {
+	f, ok := m[key]
+	if !ok || (now.Sub(f.last) > LOGIN_FAILURE_MEMORY && now.After(f.lockedUntil)) {
+		f = &loginFailures{}
+		m[key] = f
+	}
+	return f
+}
+
+// forget failures that are too old to matter
+func (t *LoginThrottle) cleanup() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	for _, m := range []map[string]*loginFailures{t.accounts, t.ips} {
+		for key, f := range m {
+			if now.Sub(f.last) > LOGIN_FAILURE_MEMORY && now.After(f.lockedUntil) {
+				delete(m, key)
+			}
+		}
+	}
+}
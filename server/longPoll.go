@@ -0,0 +1,115 @@
+// long polling for match events, for clients that cannot keep a stream open
+package server
+
+import (
+	"api/server/game"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// how long a poll waits for events when no timeout is given
+	DEFAULT_POLL_TIMEOUT = 30 * time.Second
+	MAX_POLL_TIMEOUT     = 60 * time.Second
+)
+
+type PollEventsResponse struct {
+	// empty if the timeout ran out first
+	Events []game.LoggedEvent `json:"events"`
+	// pass it as since to get the events after these
+	Next int64 `json:"next" example:"3"`
+}
+
+// @Summary		Poll for the events of a match
+// @Description	Long polling alternative to GET /matches/{id}/play, for clients that cannot keep a stream open.
+// @Description	Returns the events sent to you after the event numbered `since`, waiting up to `timeout` for one if there are none yet. Poll again with `since` set to `next`.
+// @Description	The first poll joins the match if you are not playing it yet, `blackPieces` works like it does for /play. Moves are played with PUT /matches/{id}.
+// @Description	Players who poll do not resign when they stop polling, polling after your stream ended keeps you from resigning too. A `resync` event means events were missed, continue from its position.
+// @Tags			matches
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string	true	"Match ID"
+// @Param			since			query		int		false	"number of the last event you got, 0 for all of them"
+// @Param			timeout			query		string	false	"how long to wait for an event, like 30s. At most 60s."
+// @Param			blackPieces		query		bool	false	"play as black when joining, ignored if you are not the first one to join"
+// @Success		200				{object}	PollEventsResponse
+// @Failure		400				{object}	ErrorReason	"Invalid since or timeout"
+// @Failure		403				{object}	ErrorReason	"Unauthorized / match is full / guests cannot join rated matches / blocked by the opponent"
+// @Failure		404				{object}	ErrorReason	"Match not found"
+// @Failure		503				{object}	ErrorReason	"Server is restarting"
+// @Router			/matches/{id}/events/poll [get]
+func (s Server) PollMatchEvents(c echo.Context) error {
+	username := usernameOf(c)
+	if username == "" {
+		return c.JSON(http.StatusForbidden, REASON_UNAUTHORIZED)
+	}
+	match, ok := s.GameStorage.GetMatch(c.Param("id"))
+	if !ok {
+		return c.JSON(http.StatusNotFound, Reason(CODE_MATCH_NOT_FOUND, "Match not found"))
+	}
+	var since int64
+	if q := c.QueryParam("since"); q != "" {
+		n, err := strconv.ParseInt(q, 10, 64)
+		if err != nil || n < 0 {
+			return invalidQuery("since must be a number of at least 0")
+		}
+		since = n
+	}
+	timeout := DEFAULT_POLL_TIMEOUT
+	if q := c.QueryParam("timeout"); q != "" {
+		d, err := time.ParseDuration(q)
+		if err != nil || d < 0 || d > MAX_POLL_TIMEOUT {
+			return invalidQuery("timeout must be a duration like 30s, at most 60s")
+		}
+		timeout = d
+	}
+
+	if _, ok := match.GetPlayerFromUsername(username); !ok {
+		if err := s.pollJoin(c, username, match); err != nil {
+			return err
+		}
+	}
+	// polling players are back, like players who join again
+	s.Reconnects.cancel(match.ID, username)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	events, last, changed := match.EventsSince(username, since)
+	// events sent to the opponent wake the poll too
+	for waiting := timeout > 0; len(events) == 0 && waiting; {
+		select {
+		case <-changed:
+			events, last, changed = match.EventsSince(username, since)
+		case <-timer.C:
+			waiting = false
+		case <-c.Request().Context().Done():
+			return nil
+		case <-s.lifecycle.drained:
+			waiting = false
+		}
+	}
+	if events == nil {
+		events = []game.LoggedEvent{}
+	}
+	return c.JSON(http.StatusOK, PollEventsResponse{Events: events, Next: last})
+}
+
+// pollJoin takes a seat in match as username without an event stream, so the events sent to them are only logged
+// and they don't resign when they stop polling.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (s Server) pollJoin(c echo.Context, username string, match *game.Match) error {
+	if s.Draining() {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, REASON_SHUTTING_DOWN)
+	}
+	if err := s.checkCanJoin(c.Request().Context(), username, isGuest(c), match); err != nil {
+		return err
+	}
+	blackPieces, _ := strconv.ParseBool(c.QueryParam("blackPieces"))
+	if _, ok := match.TakeSeat(username, s.joinColor(match.ID, username, blackPieces)); !ok {
+		return echo.NewHTTPError(http.StatusForbidden, Reason(CODE_MATCH_FULL, "Match is full"))
+	}
+	return nil
+}
@@ -3,15 +3,15 @@ package server
 
 import (
 	"api/server/game"
-	"encoding/json"
-	"log/slog"
+	"context"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/notnil/chess"
-	"github.com/notnil/chess/image"
 )
 
 // MatchCreatedResponse is the information needed to join a match as the owner or as the opponent
@@ -26,33 +26,54 @@ type MatchCreatedResponse struct {
 //	@Description	### Note:
 //	@Description	### You must be the first one to send a GET to /matches/:id if you want to be the one who picks the colors.
 //	@Description	### duration maxes out at 12 hours
+//	@Description	### guests can only create casual (unrated) matches
 //	@Tags			matches
 //	@Param			Authorization	header	string				true	"Must contain ApiKey in the format Bearer: apiKey"
 //	@Param			payload			body	CreateMatchRequest	true	"Duration of the match in hours. Max is 12"
 //	@Accept			json
 //	@Produce		json
 //	@Success		200	{object}	MatchCreatedResponse	"Match Created"
-//	@Failure		403	{object}	ErrorReason				"Invalid Authorization header"
+//	@Failure		403	{object}	ErrorReason				"Invalid Authorization header / guests cannot create rated matches"
 //	@Failure		400	{object}	ErrorReason				"Invalid json body"
+//	@Failure		429	{object}	ErrorReason				"Too many unfinished matches"
+//	@Failure		500	{object}	ErrorReason
 //	@Router			/matches [post]
 func (s Server) CreateMatch(c echo.Context) error {
-	username := c.Get("username").(string)
+	username := usernameOf(c)
 	if username == "" {
-		return c.JSON(http.StatusForbidden, Reason("You need to be authorized to make a match"))
+		return c.JSON(http.StatusForbidden, Reason(CODE_UNAUTHORIZED, "You need to be authorized to make a match"))
 	}
 	var req CreateMatchRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, REASON_JSON_SYNTAX_ERROR)
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
 	}
-	if req.Duration == 0 {
-		return c.JSON(http.StatusBadRequest, Reason("Duration not provided"))
+	Match, err := s.createMatch(c.Request().Context(), username, isGuest(c), req)
+	if err != nil {
+		return err
 	}
-	Match := s.GameStorage.NewMatch(time.Duration(req.Duration) * time.Hour)
 	return c.JSON(200, MatchCreatedResponse{Match.ID})
 }
 
+// createMatch creates a match for username, who is a guest if guest is true.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (s Server) createMatch(ctx context.Context, username string, guest bool, req CreateMatchRequest) (*game.Match, error) {
+	if req.Rated && guest {
+		return nil, echo.NewHTTPError(http.StatusForbidden, Reason(CODE_GUESTS_CANNOT_RATED, "Guests cannot play rated matches"))
+	}
+	if s.tooManyMatches(username) {
+		return nil, echo.NewHTTPError(http.StatusTooManyRequests, Reason(CODE_TOO_MANY_MATCHES, fmt.Sprintf("You can have at most %d unfinished matches", s.MaxMatchesPerUser)))
+	}
+	match, err := s.GameStorage.NewMatch(ctx, username, time.Duration(req.Duration)*time.Hour, req.Rated)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return match, nil
+}
+
 type CreateMatchRequest struct {
-	Duration int `json:"duration" example:"12"` // duration in hours
+	Duration int `json:"duration" minimum:"1" maximum:"12" example:"12" validate:"required,min=1,max=12"` // duration in hours
+	// rated matches cannot be joined by guests
+	Rated bool `json:"rated" example:"false"`
 }
 
 type JoinMatchRequest struct {
@@ -68,6 +89,13 @@ type JoinMatchRequest struct {
 //	@Description	## On success the server will send `SSE` messages whose payloads are JSON.
 //	@Description	Events don't send this entire object: each event uses only some fields.
 //	@Description	Look [here](https://github.com/BrownNPC/chess-api/blob/master/server/game/game.go#L33) to see **which fields are used by which event.**
+//	@Description	`move` events have the opponent's move in UCI notation in `move` and in SAN in `san`, the position after it in `fen`, and the `check`, `checkmate`, `capture`, `castle` and `promotion` flags.
+//	@Description	When the server restarts, players get a `serverRestarting` event and the stream ends. The match is not lost, join it again once the server is back.
+//	@Description	Once the game leaves the opening book, players get an `opening` event with the ECO code in `eco` and the name of the opening in `opening`.
+//	@Description	Clients that fall too far behind reading events get a `resync` event with the current position in `fen` instead of the events they missed, and the stream ends. Join again to keep playing.
+//	@Description	When your stream ends you have a minute to join again, or you resign.
+//	@Description	When the opponent's stream ends, you get an `opponentDisconnected` event, and `opponentConnected` once they join again.
+//	@Description	`spectatorJoined` and `spectatorLeft` events are sent when someone starts or stops watching the match, with how many people watch it in `spectators`.
 //	@Tags			matches
 //	@Accept			json
 //	@Produce		json
@@ -76,12 +104,14 @@ type JoinMatchRequest struct {
 //	@Param			id				path		string				true	"Match ID"
 //	@Param			payload			body		JoinMatchRequest	true	"`blackPieces` is used to pick if you want to play as the black pieces. This is ignored if you are not the first one to join."
 //	@Success		200				{object}	game.Event			"SSE stream — each `data:` payload uses some fields of this JSON object (Content-Type: text/event-stream). Events dont sent this whole object."
-//	@Failure		403				{object}	ErrorReason			"Unauthorized"
+//	@Failure		403				{object}	ErrorReason			"Unauthorized / guests cannot join rated matches / blocked by the opponent"
 //	@Failure		404				{object}	ErrorReason			"Match not found"
 //	@Failure		400				{object}	ErrorReason			"Invalid json body"
+//	@Failure		429				{object}	ErrorReason			"Too many open streams"
+//	@Failure		503				{object}	ErrorReason			"Server is restarting"
 //	@Router			/matches/{id}/play [get]
 func (s Server) JoinMatch(c echo.Context) error {
-	username := c.Get("username").(string)
+	username := usernameOf(c)
 	if username == "" {
 		return c.JSON(http.StatusForbidden, REASON_UNAUTHORIZED)
 	}
@@ -89,45 +119,141 @@ func (s Server) JoinMatch(c echo.Context) error {
 	matchID := c.Param("id")
 	match, ok := s.GameStorage.GetMatch(matchID)
 	if !ok {
-		return c.JSON(http.StatusNotFound, Reason("Match not found"))
+		return c.JSON(http.StatusNotFound, Reason(CODE_MATCH_NOT_FOUND, "Match not found"))
+	}
+	if err := s.checkCanJoin(c.Request().Context(), username, isGuest(c), match); err != nil {
+		return err
 	}
 
 	var req JoinMatchRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, REASON_JSON_SYNTAX_ERROR)
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
 	}
 
-	// SSE headers
-	w := c.Response()
-	w.Header().Set(echo.HeaderContentType, "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.WriteHeader(http.StatusOK)
-	w.Flush()
+	asColor := s.joinColor(matchID, username, req.BlackPieces)
+	return s.playMatch(c.Request().Context(), username, match, asColor, &sseMatchStream{s: s, c: c})
+}
 
-	var asColor chess.Color
-	if req.BlackPieces {
-		asColor = chess.Black
-	} else {
-		asColor = chess.White
+// joinColor is the color username asked for, unless the match was created from a challenge or a tournament.
+func (s Server) joinColor(matchID, username string, blackPieces bool) chess.Color {
+	// matches created from a challenge keep the colors that were agreed on
+	if color, ok := s.Challenges.SeatColor(matchID, username); ok {
+		return color
+	}
+	if color, ok := s.Tournaments.SeatColor(matchID, username); ok {
+		return color
+	}
+	if color, ok := s.Simuls.SeatColor(matchID, username); ok {
+		return color
 	}
+	if color, ok := s.Seeks.SeatColor(matchID, username); ok {
+		return color
+	}
+	if blackPieces {
+		return chess.Black
+	}
+	return chess.White
+}
 
+// checkCanJoin checks that guests stay out of rated matches, that only the paired players join tournament games,
+// that only members join team matches and that nobody in match blocked username.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (s Server) checkCanJoin(ctx context.Context, username string, guest bool, match *game.Match) error {
+	if match.Rated && guest {
+		return echo.NewHTTPError(http.StatusForbidden, Reason(CODE_GUESTS_CANNOT_RATED, "Guests cannot play rated matches"))
+	}
+	if s.Tournaments.Reserved(match.ID, username) {
+		return echo.NewHTTPError(http.StatusForbidden, Reason(CODE_TOURNAMENT_GAME, "This is a tournament game of other players"))
+	}
+	if s.Simuls.Reserved(match.ID, username) {
+		return echo.NewHTTPError(http.StatusForbidden, Reason(CODE_SIMUL_GAME, "This is a simul board of other players"))
+	}
+	if s.Seeks.Reserved(match.ID, username) {
+		return echo.NewHTTPError(http.StatusForbidden, Reason(CODE_SEEK_GAME, "This match was created from a seek of other players"))
+	}
+	if err := s.checkTeamMatch(ctx, username, match); err != nil {
+		return err
+	}
+	for _, p := range match.Players() {
+		blocked, err := s.blockedBetween(ctx, username, p.Username)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+		}
+		if blocked {
+			return echo.NewHTTPError(http.StatusForbidden, Reason(CODE_BLOCKED, "You cannot join this match"))
+		}
+	}
+	return nil
+}
+
+// matchStream writes the events of a match to a player's connection, see playMatch
+type matchStream interface {
+	// start is called once the player joined, before any events are written
+	start(match *game.Match, player game.Player) error
+	// the error is only non-nil if the client disconnected
+	event(ctx context.Context, e game.Event) error
+	keepAlive() error
+}
+
+// moveStream is a matchStream that sends every move of the match, the player's own moves too.
+// Its move events are not needed, the moves come from the match's watchers instead.
+type moveStream interface {
+	// the error is only non-nil if the stream should end
+	move(u MatchUpdate) error
+}
+
+// playMatch joins match as username and writes its events to out until the game ends for them
+// or they disconnect, which cancels ctx. Players who disconnect resign if they don't join again within RECONNECT_GRACE_PERIOD,
+// unless the server is restarting or they fell behind.
+// The returned error is an *echo.HTTPError that can be returned from the handler, it is nil once out was started.
+func (s Server) playMatch(ctx context.Context, username string, match *game.Match, asColor chess.Color, out matchStream) error {
+	if s.Draining() {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, REASON_SHUTTING_DOWN)
+	}
+	disconnect, ok := s.Presence.ConnectLimited(username, match.ID, s.MaxStreamsPerUser)
+	if !ok {
+		return echo.NewHTTPError(http.StatusTooManyRequests, REASON_TOO_MANY_STREAMS)
+	}
+	defer disconnect()
+	// subscribed before joining, so no move is missed once the stream starts
+	var updates chan MatchUpdate
+	moves, sendsMoves := out.(moveStream)
+	if sendsMoves {
+		var unsubscribe func()
+		updates, unsubscribe = s.Watchers.Subscribe(match.ID)
+		defer unsubscribe()
+	}
 	player, ok := match.Join(username, asColor)
 	if !ok {
-		return c.JSON(http.StatusForbidden, Reason("Match is full"))
+		return echo.NewHTTPError(http.StatusForbidden, Reason(CODE_MATCH_FULL, "Match is full"))
 	}
+	s.Reconnects.cancel(match.ID, username)
 
+	// the client fell behind and will join again
+	resync := false
 	// Ensure the player is removed when this handler returns (disconnect, error, etc.)
-	defer match.Resign(player)
+	defer func() {
+		// the opponent is told the player left
+		match.Leave(player)
+		// players keep their seat while the server restarts or they resync, and can't lose a game that is over
+		if s.Draining() || resync || match.Outcome() != chess.NoOutcome {
+			return
+		}
+		s.Reconnects.wait(match.ID, username, func() {
+			// they may have joined again on another server
+			if match.Disconnected(username) && !s.Draining() {
+				s.resign(context.Background(), match, player)
+			}
+		})
+	}()
+	if err := out.start(match, player); err != nil {
+		return nil
+	}
 
 	// ticker for keep-alive
-	ticker := time.NewTicker(10 * time.Second)
+	ticker := time.NewTicker(SSE_KEEP_ALIVE_INTERVAL)
 	defer ticker.Stop()
 
-	ctx := c.Request().Context()
-
-	var b strings.Builder
-
 	for {
 		select {
 		case <-ctx.Done():
@@ -135,57 +261,86 @@ func (s Server) JoinMatch(c echo.Context) error {
 			return nil
 
 		case <-ticker.C:
-			// send a comment keep-alive line (SSE comment)
-			if _, err := w.Write([]byte(": keep-alive\n\n")); err != nil {
+			if err := out.keepAlive(); err != nil {
 				return nil
 			}
-			w.Flush()
 
 		case e := <-player.Events:
-			msg, err := json.Marshal(e)
-			if err != nil {
-				// don't break loop — log and continue
-				slog.Warn("Failed to marshal match.Event", "error", err)
-				continue
+			if err := out.event(ctx, e); err != nil {
+				return nil
 			}
-
-			b.WriteString("data: ")
-			b.Write(msg)
-			b.WriteString("\n\n")
-
-			if _, err := w.Write([]byte(b.String())); err != nil {
+			switch e.Type {
+			case game.Resync:
+				resync = true
+				return nil
+			case game.Resign, game.Aborted, game.Adjudicated, game.ServerRestarting:
+				return nil
+			}
+		case u, ok := <-updates:
+			if !ok {
+				// fell behind on moves, like on events
+				out.event(ctx, game.EventResync(match.Position().String()))
+				resync = true
 				return nil
 			}
-			w.Flush()
-			b.Reset()
-			if e.Type == game.Resign {
+			if err := moves.move(u); err != nil {
 				return nil
 			}
+		case <-s.lifecycle.drained:
+			// in case the serverRestarting event did not fit in the channel
+			out.event(ctx, game.EventServerRestarting())
+			return nil
 		}
 	}
 }
 
+// sseMatchStream sends match events as server sent events, see JoinMatch
+type sseMatchStream struct {
+	s Server
+	c echo.Context
+	w *echo.Response
+}
+
+func (st *sseMatchStream) start(_ *game.Match, _ game.Player) error {
+	st.w = st.c.Response()
+	startSSE(st.c)
+	return nil
+}
+
+func (st *sseMatchStream) event(ctx context.Context, e game.Event) error {
+	return st.s.writeTracedSSE(ctx, st.w, e)
+}
+
+func (st *sseMatchStream) keepAlive() error {
+	// send a comment keep-alive line (SSE comment)
+	return writeSSEKeepAlive(st.w)
+}
+
 type PutMoveRequest struct {
-	Move string `json:"move" example:"e2e4"`
+	Move string `json:"move" example:"e2e4" validate:"required,uci"`
 }
 
 // @Summary		players in-game can make moves when it's their turn.
 // @Description	You must be in-game to post a move.
 // @Description	The move needs to be in UCI format. eg. `e2e4`
 // @Description	You cannot make a move if it's not your turn.
+// @Description	If `autoQueen` is enabled in your preferences, pawns reaching the last rank without a promotion piece become queens.
 // @Param			Authorization	header	string			true	"Must contain ApiKey in the format Bearer: apiKey"
 // @Param			payload			body	PutMoveRequest	true	"move in UCI notation. eg. e2e4"
 // @Param			id				path	string			true	"Match ID"
 // @Tags			matches
 // @Accept			json
 // @Produce		json
-// @Failure		403	{object}	ErrorReason	"Unauthorized"
-// @Failure		404	{object}	ErrorReason	"Match not found"
-// @Failure		400	{object}	ErrorReason	"Invalid json body / invalid move"
+// @Failure		403	{object}	ErrorReason	"Unauthorized / PLAYER_NOT_IN_MATCH"
+// @Failure		404	{object}	ErrorReason	"MATCH_NOT_FOUND"
+// @Failure		400	{object}	ErrorReason	"Invalid json body / INVALID_INPUT: the move is missing / INVALID_MOVE_NOTATION: the move is not in UCI notation"
+// @Failure		409	{object}	ErrorReason	"NOT_YOUR_TURN / GAME_OVER"
+// @Failure		422	{object}	ErrorReason	"ILLEGAL_MOVE: the move is not legal in this position"
+// @Failure		503	{object}	ErrorReason	"Server is restarting"
 // @Success		200	{object}	string		"ok"
 // @Router			/matches/{id}  [put]
 func (s Server) PutMove(c echo.Context) error {
-	username := c.Get("username").(string)
+	username := usernameOf(c)
 	matchId := c.Param("id")
 
 	if username == "" {
@@ -193,86 +348,190 @@ func (s Server) PutMove(c echo.Context) error {
 	}
 
 	var req PutMoveRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, REASON_JSON_SYNTAX_ERROR)
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	if err := s.submitMove(c.Request().Context(), username, matchId, req.Move); err != nil {
+		return err
 	}
+	return c.JSON(http.StatusOK, "ok")
+}
 
-	Match, ok := s.GameStorage.GetMatch(matchId)
+// submitMove plays move, in UCI notation, as username in the match with id matchID.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (s Server) submitMove(ctx context.Context, username, matchID, move string) error {
+	if s.Draining() {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, REASON_SHUTTING_DOWN)
+	}
+	Match, ok := s.GameStorage.GetMatch(matchID)
 	if !ok {
-		return c.JSON(http.StatusNotFound, Reason("match not found"))
+		return echo.NewHTTPError(http.StatusNotFound, Reason(CODE_MATCH_NOT_FOUND, "match not found"))
 	}
 
 	plr, ok := Match.GetPlayerFromUsername(username)
 	if !ok {
-		return c.JSON(http.StatusNotFound, Reason("Player not in-game"))
+		status, code := moveErrorStatus(game.ErrNotInMatch)
+		return echo.NewHTTPError(status, Reason(code, "Player not in-game"))
 	}
 
-	ok = Match.MoveAs(plr, req.Move)
-	if !ok {
-		return c.JSON(http.StatusBadRequest, Reason("Invalid move"))
+	if err := s.Simuls.CheckTurn(matchID, username); err != nil {
+		return err
 	}
-	return c.JSON(http.StatusOK, "ok")
+
+	if len(move) == 4 && Match.IsPromotion(move) &&
+		s.preferencesOf(ctx, username).AutoQueen {
+		move += "q"
+	}
+
+	if err := Match.MoveAs(ctx, plr, move); err != nil {
+		status, code := moveErrorStatus(err)
+		return echo.NewHTTPError(status, Reason(code, err.Error()))
+	}
+	// let the opponent know if they aren't watching the match, on any server
+	for _, p := range Match.Players() {
+		if p.Username != username && Match.Disconnected(p.Username) {
+			s.notifyUsername(ctx, p.Username, NotifyYourMove, username, matchID)
+			s.emailYourMove(ctx, Match, p.Username, username, move)
+		}
+	}
+	return nil
+}
+
+// moveErrorStatus maps an error from game.MoveAs to a status and error code
+func moveErrorStatus(err error) (int, ErrorCode) {
+	switch err {
+	case game.ErrNotInMatch:
+		return http.StatusForbidden, CODE_PLAYER_NOT_IN_MATCH
+	case game.ErrNotYourTurn:
+		return http.StatusConflict, CODE_NOT_YOUR_TURN
+	case game.ErrMoveNotation:
+		return http.StatusBadRequest, CODE_INVALID_MOVE_NOTATION
+	case game.ErrIllegalMove:
+		return http.StatusUnprocessableEntity, CODE_ILLEGAL_MOVE
+	case game.ErrGameOver:
+		return http.StatusConflict, CODE_GAME_OVER
+	case game.ErrMatchNotFound:
+		return http.StatusNotFound, CODE_MATCH_NOT_FOUND
+	case game.ErrSuspended:
+		return http.StatusServiceUnavailable, CODE_SHUTTING_DOWN
+	default:
+		return http.StatusServiceUnavailable, CODE_NOT_READY
+	}
+}
+
+// BoardState is the board of a match along with the opening played so far
+type BoardState struct {
+	// board part of the FEN
+	Board string `json:"board" example:"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR"`
+	FEN   string `json:"fen" example:"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"`
+	// empty until a move in the ECO book is played
+	Opening game.Opening `json:"opening"`
+	// the game left the book, the opening won't change anymore
+	OpeningFinal bool `json:"openingFinal" example:"false"`
+	// captured pieces and the material balance
+	Material game.Material `json:"material"`
 }
 
 // @Summary		Get board in FEN format.
 // @Description	Get the board position in FEN format.
 // @Description	Unauthorized clients can use this.
+// @Description	Send `Accept: application/json` to get a BoardState with the full FEN, the opening (ECO code and name) and the captured pieces instead.
+// @Description	Games imported with POST /imports/pgn are shown too, at their final position or at the position after `ply` half moves.
 // @Tags			matches
 // @Accept			json
 // @Produce		json
-// @Failure		404	{object}	ErrorReason	"Match not found"
-// @Failure		400	{object}	ErrorReason	"Invalid json body / invalid move"
-// @Success		200	{object}	string		"board FEN"
-// @Param			id	path		string		true	"Match ID"
+// @Produce		plain
+// @Failure		404		{object}	ErrorReason	"Match not found"
+// @Failure		400		{object}	ErrorReason	"Invalid json body / invalid move"
+// @Success		200		{object}	BoardState	"board FEN, or a BoardState with Accept: application/json"
+// @Success		304		"Board did not change since the ETag in If-None-Match"
+// @Header			200,304	{string}	ETag	"Identifies the board position"
+// @Param			id		path		string	true	"Match ID"
+// @Param			ply		query		int		false	"half moves played, only for imported games"
 // @Router			/matches/{id}  [get]
 func (s Server) GetBoardFEN(c echo.Context) error {
-	matchId := c.Param("id")
-
-	Match, ok := s.GameStorage.GetMatch(matchId)
-	if !ok {
-		return c.JSON(http.StatusNotFound, Reason("match not found"))
+	board, err := s.boardOf(c, c.Param("id"))
+	if err != nil {
+		return err
 	}
 
-	Match.RLock()
-	defer Match.RUnlock()
-	var position string = Match.Chess.Position().Board().String()
-	return c.String(http.StatusOK, position)
+	position := board.position
+	if !strings.Contains(c.Request().Header.Get(echo.HeaderAccept), echo.MIMEApplicationJSON) {
+		if notModified(c, positionETag(position.Board().String())) {
+			return c.NoContent(http.StatusNotModified)
+		}
+		return c.String(http.StatusOK, position.Board().String())
+	}
+	// viewers like GET /matches/{id}/embed poll this, most of the time nothing changed
+	if notModified(c, positionETag(position.String(), board.opening.ECO, board.opening.Name, strconv.FormatBool(board.openingFinal))) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.JSON(http.StatusOK, BoardState{
+		Board:        position.Board().String(),
+		FEN:          position.String(),
+		Opening:      board.opening,
+		OpeningFinal: board.openingFinal,
+		Material:     board.material,
+	})
 }
 
-// @Summary		Get board in SVG format.
-// @Description	Get the board position in SVG Image format.
+// @Summary		Get board in SVG or PNG format.
+// @Description	Get the board position in SVG Image format, or as PNG with `format=png` or at /matches/{id}/img.png for apps that don't show SVG.
+// @Description	The board is drawn using the board theme and piece set in your preferences, from the side of your color. Query parameters change how it is drawn.
+// @Description	Responses have an `ETag`. Send it in `If-None-Match` to get a `304` while the board has not changed.
+// @Description	Games imported with POST /imports/pgn are drawn too, at their final position or at the position after `ply` half moves.
 // @Tags			matches
 // @Accept			json
-// @Produce		json
+// @Produce		image/svg+xml,png,json
 // @Param			Authorization	header		string		true	"Must contain ApiKey in the format Bearer: apiKey"
 // @Param			id				path		string		true	"Match ID"
+// @Param			format			query		string		false	"image format"												Enums(svg, png)	default(svg)
+// @Param			orientation		query		string		false	"color at the bottom of the board"							Enums(white, black)
+// @Param			theme			query		string		false	"board theme"												Enums(brown, blue, green, gray)
+// @Param			pieces			query		string		false	"piece set"													Enums(cburnett)
+// @Param			size			query		int			false	"width of a square in pixels, the board is 8 squares wide"	minimum(16)	maximum(128)	default(45)
+// @Param			lastMove		query		bool		false	"highlight the squares of the last move"
+// @Param			check			query		bool		false	"highlight the king in check"
+// @Param			arrows			query		string		false	"arrows to draw, moves in UCI notation separated by commas"	example(e2e4,g1f3)
+// @Param			squares			query		string		false	"squares to mark, separated by commas"						example(e4,d5)
+// @Param			ply				query		int			false	"half moves played, only for imported games"
 // @Failure		403				{object}	ErrorReason	"Unauthorized"
 // @Failure		404				{object}	ErrorReason	"Match not found"
-// @Failure		400				{object}	ErrorReason	"Invalid json body / invalid move"
-// @Success		200				{file}		string		"SVG image"
+// @Failure		400				{object}	ErrorReason	"Invalid query parameter"
+// @Success		200				{file}		string		"SVG or PNG image"
+// @Success		304				"Board did not change since the ETag in If-None-Match"
+// @Header			200,304			{string}	ETag			"Identifies the board position and how it is drawn"
+// @Header			200,304			{string}	Cache-Control	"private, no-cache"
 // @Router			/matches/{id}/img  [get]
+// @Router			/matches/{id}/img.png  [get]
 func (s Server) GetBoardImage(c echo.Context) error {
-	username := c.Get("username").(string)
+	username := usernameOf(c)
 	if username == "" {
 		return c.JSON(http.StatusForbidden, REASON_UNAUTHORIZED)
 	}
-	matchId := c.Param("id")
-
-	Match, ok := s.GameStorage.GetMatch(matchId)
-	if !ok {
-		return c.JSON(http.StatusNotFound, Reason("match not found"))
+	shown, err := s.boardOf(c, c.Param("id"))
+	if err != nil {
+		return err
 	}
 
-	Match.RLock()
-	defer Match.RUnlock()
-	var position = Match.Chess.Position().Board()
-
-	c.Response().Header().Set(echo.HeaderContentType, "image/svg+xml")
-	c.Response().WriteHeader(http.StatusOK)
+	prefs := s.preferencesOf(c.Request().Context(), username)
+	opts, err := boardImageOptions(c, shown, username, prefs)
+	if err != nil {
+		return err
+	}
+	board := shown.position.Board()
 
-	// pass the response writer to your function
-	if err := image.SVG(c.Response().Writer, position); err != nil {
+	// the image only changes when a move is made or it is drawn differently
+	if notModified(c, positionETag(board.String(), opts.key())) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	img, err := s.BoardImages.Image(board, opts)
+	if err != nil {
 		return err
 	}
-	return nil
+	if opts.Format == FORMAT_PNG {
+		return c.Blob(http.StatusOK, "image/png", img)
+	}
+	return c.Blob(http.StatusOK, "image/svg+xml", img)
 }
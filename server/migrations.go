@@ -0,0 +1,140 @@
+// upgrading databases created by older versions, before schema.sql creates what is missing
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// migration brings the tables of an older database up to user_version version.
+// Tables the database does not have yet are left to schema.sql, which creates them with all their columns.
+type migration struct {
+	version int
+	migrate func(ctx context.Context, tx *sql.Tx) error
+}
+
+// migrations in the order of their versions. Only changes to existing tables need one, new tables and indexes are created by schema.sql.
+// Databases from before user_version was set have version 0 and can already have some of the columns of version 1,
+// so columns are only added when they are missing.
+var migrations = []migration{
+	{version: 1, migrate: addColumns(
+		column{"users", "is_guest", "BOOLEAN NOT NULL DEFAULT FALSE"},
+		column{"users", "deleted_at", "DATETIME"},
+		column{"users", "preferences", "TEXT NOT NULL DEFAULT '{}'"},
+		column{"users", "is_admin", "BOOLEAN NOT NULL DEFAULT FALSE"},
+		column{"users", "banned", "BOOLEAN NOT NULL DEFAULT FALSE"},
+	)},
+	{version: 6, migrate: addColumns(
+		column{"users", "is_bot", "BOOLEAN NOT NULL DEFAULT FALSE"},
+	)},
+	{version: 7, migrate: addColumns(
+		column{"suspended_matches", "computer_level", "INTEGER NOT NULL DEFAULT 0"},
+	)},
+	{version: 8, migrate: addColumns(
+		column{"games", "eco", "TEXT NOT NULL DEFAULT ''"},
+		column{"games", "opening", "TEXT NOT NULL DEFAULT ''"},
+	)},
+	{version: 9, migrate: addColumns(
+		column{"games", "match_id", "TEXT NOT NULL DEFAULT ''"},
+	)},
+	{version: 16, migrate: addCheatingReports},
+	{version: 18, migrate: addColumns(
+		column{"users", "email", "TEXT NOT NULL DEFAULT ''"},
+	)},
+	{version: 19, migrate: addColumns(
+		column{"games", "method", "TEXT NOT NULL DEFAULT ''"},
+		column{"games", "final_fen", "TEXT NOT NULL DEFAULT ''"},
+	)},
+}
+
+// column is added to table by a migration, with the definition it has in schema.sql
+type column struct {
+	table, name, definition string
+}
+
+// addColumns is a migration adding the columns that are missing from tables the database has
+func addColumns(columns ...column) func(ctx context.Context, tx *sql.Tx) error {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		for _, c := range columns {
+			var columns, found int
+			err := tx.QueryRowContext(ctx, "SELECT count(*), count(CASE WHEN name = ? THEN 1 END) FROM pragma_table_info(?)", c.name, c.table).
+				Scan(&columns, &found)
+			if err != nil {
+				return err
+			}
+			// no columns means no table
+			if columns == 0 || found > 0 {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.name, c.definition)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Migrate runs the migrations newer than the user_version of the database in one transaction, setting user_version after each.
+// It must run before schema.sql, whose indexes can need the new columns. New databases are left to schema.sql.
+func Migrate(ctx context.Context, conn *sql.DB) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var tables int
+	if err := tx.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'users'").Scan(&tables); err != nil {
+		return err
+	}
+	if tables == 0 {
+		return nil
+	}
+	var version int
+	if err := tx.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		if err := m.migrate(ctx, tx); err != nil {
+			return fmt.Errorf("migration to version %d failed: %w", m.version, err)
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", m.version)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// addCheatingReports allows the cheating category of reports.
+// sqlite cannot change a CHECK constraint, so the table is copied into one with the constraint of schema.sql.
+func addCheatingReports(ctx context.Context, tx *sql.Tx) error {
+	var definition string
+	err := tx.QueryRowContext(ctx, "SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'reports'").Scan(&definition)
+	if errors.Is(err, sql.ErrNoRows) || strings.Contains(definition, "'cheating'") {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		CREATE TABLE reports_migrated (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			reporter_uid INTEGER,
+			reported_username TEXT NOT NULL,
+			match_id TEXT NOT NULL DEFAULT '',
+			category TEXT CHECK (category IN ('chat', 'username', 'abuse', 'cheating', 'other')) NOT NULL,
+			details TEXT NOT NULL,
+			resolved BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+		INSERT INTO reports_migrated (id, reporter_uid, reported_username, match_id, category, details, resolved, created_at)
+			SELECT id, reporter_uid, reported_username, match_id, category, details, resolved, created_at FROM reports;
+		DROP TABLE reports;
+		ALTER TABLE reports_migrated RENAME TO reports;`)
+	return err
+}
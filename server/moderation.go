@@ -0,0 +1,152 @@
+// filtering abusive words and reporting users
+package server
+
+import (
+	"api/db"
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// words rejected in usernames and masked in chat messages, unless replaced by the BANNED_WORDS file
+var DEFAULT_BANNED_WORDS = []string{
+	"fuck", "shit", "cunt", "bitch", "nigger", "nigga", "faggot", "retard", "whore", "slut",
+}
+
+// WordFilter finds banned words in usernames and chat messages.
+// Matching is case-insensitive and also matches words inside other words.
+type WordFilter struct {
+	words []string
+}
+
+// NewWordFilter creates a filter for words. Empty words are ignored.
+func NewWordFilter(words []string) *WordFilter {
+	f := &WordFilter{}
+	for _, w := range words {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if w != "" && !slices.Contains(f.words, w) {
+			f.words = append(f.words, w)
+		}
+	}
+	return f
+}
+
+// Contains is true if text contains a banned word
+func (f *WordFilter) Contains(text string) bool {
+	lower := strings.ToLower(text)
+	for _, w := range f.words {
+		if strings.Contains(lower, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// Mask replaces every banned word in text with asterisks.
+// masked is false if text did not contain banned words.
+func (f *WordFilter) Mask(text string) (result string, masked bool) {
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	if len(lower) != len(runes) {
+		// lowercasing changed the length, fall back to masking the whole message
+		if f.Contains(text) {
+			return strings.Repeat("*", len(runes)), true
+		}
+		return text, false
+	}
+	for _, w := range f.words {
+		word := []rune(w)
+		for i := 0; i+len(word) <= len(lower); i++ {
+			if slices.Equal(lower[i:i+len(word)], word) {
+				for j := i; j < i+len(word); j++ {
+					runes[j] = '*'
+				}
+				masked = true
+			}
+		}
+	}
+	return string(runes), masked
+}
+
+// report categories
+const (
+	REPORT_CHAT     = "chat"
+	REPORT_USERNAME = "username"
+	REPORT_ABUSE    = "abuse"
+	REPORT_CHEATING = "cheating"
+	REPORT_OTHER    = "other"
+)
+
+type ReportRequest struct {
+	Username string `json:"username" example:"JohnDoe" validate:"required"`
+	// match the incident happened in, optional
+	MatchID  string `json:"matchId" example:"AB2C21" validate:"omitempty,len=6"`
+	Category string `json:"category" enums:"chat,username,abuse,cheating,other" example:"chat" validate:"required,oneof=chat username abuse cheating other"`
+	Details  string `json:"details" maxLength:"1000" example:"insulted me in chat" validate:"max=1000"`
+}
+
+// ReportResponse is a recorded report
+type ReportResponse struct {
+	ID        int64     `json:"id" example:"1"`
+	CreatedAt time.Time `json:"createdAt" format:"date-time"`
+}
+
+// @Summary		Report a user
+// @Description	Report a user for abusive chat messages, an offensive username, other abuse or for cheating.
+// @Description	Reports are reviewed by admins. Cheating reports are best made about a match, admins compare them with the fair-play analysis of its game.
+// @Tags			reports
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header		string			true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			payload			body		ReportRequest	true	"report"
+// @Success		201				{object}	ReportResponse
+// @Failure		400				{object}	ErrorReason	"Invalid json body / invalid category"
+// @Failure		401				{object}	ErrorReason
+// @Failure		404				{object}	ErrorReason	"User not found"
+// @Failure		500				{object}	ErrorReason
+// @Router			/reports [post]
+func (s Server) CreateReport(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	var req ReportRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	reported, err := s.DB.GetUserByUsername(c.Request().Context(), req.Username)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, Reason(CODE_USER_NOT_FOUND, "User not found"))
+	}
+	report, err := s.DB.CreateReport(c.Request().Context(), db.CreateReportParams{
+		ReporterUid:      sql.NullInt64{Int64: user.Uid, Valid: true},
+		ReportedUsername: reported.Username,
+		MatchID:          req.MatchID,
+		Category:         req.Category,
+		Details:          req.Details,
+	})
+	if err != nil {
+		slog.Error("failed to create report", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return c.JSON(http.StatusCreated, ReportResponse{ID: report.ID, CreatedAt: report.CreatedAt})
+}
+
+// reportAutomatically records an incident found by the word filter or the fair-play analysis
+func (s Server) reportAutomatically(ctx context.Context, username, matchID, category, details string) {
+	_, err := s.DB.CreateReport(ctx, db.CreateReportParams{
+		ReportedUsername: username,
+		MatchID:          matchID,
+		Category:         category,
+		Details:          details,
+	})
+	if err != nil {
+		slog.Error("failed to create automatic report", "error", err)
+	}
+}
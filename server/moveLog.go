@@ -0,0 +1,199 @@
+// moves of ongoing matches, written to the database in batches
+package server
+
+import (
+	"api/db"
+	"api/server/game"
+	"context"
+	"database/sql"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// how often queued moves are written
+	MOVE_LOG_FLUSH_INTERVAL = time.Second
+	// queued moves that are written right away, without waiting for the interval
+	MOVE_LOG_BATCH_SIZE = 256
+	// moves kept in memory while the database is failing, more are dropped
+	MOVE_LOG_MAX_QUEUED = 10_000
+	// how long the last write may take when the server shuts down
+	MOVE_LOG_CLOSE_TIMEOUT = 5 * time.Second
+	// how long logged moves are kept, finished games are archived with their PGN anyway
+	MOVE_LOG_RETENTION = 7 * 24 * time.Hour
+)
+
+// MoveLog writes the moves of ongoing matches to the match_moves table in the background,
+// so playing a move doesn't wait for the database.
+// Moves are queued per match and written in one transaction every MOVE_LOG_FLUSH_INTERVAL,
+// once MOVE_LOG_BATCH_SIZE moves are queued, or when a game ends.
+//
+// A crash loses at most the moves queued since the last write. Close writes the rest when the server shuts down.
+// While writes fail, moves stay queued up to MOVE_LOG_MAX_QUEUED, further moves are dropped and counted.
+type MoveLog struct {
+	sql *sql.DB
+
+	mu sync.Mutex
+	// match id -> moves in the order they were played
+	queued  map[string][]db.StoreMatchMoveParams
+	count   int
+	dropped int64
+	closed  bool
+
+	// asks the writer to write now, buffered so asking never blocks
+	flushNow chan struct{}
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+func NewMoveLog(conn *sql.DB) *MoveLog {
+	l := &MoveLog{
+		sql:      conn,
+		queued:   map[string][]db.StoreMatchMoveParams{},
+		flushNow: make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+// Add queues a move. It is called from the game storage's OnMove hook, so it must not block.
+func (l *MoveLog) Add(m *game.Match, ply int, move string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed || l.count >= MOVE_LOG_MAX_QUEUED {
+		l.dropped++
+		return
+	}
+	l.queued[m.ID] = append(l.queued[m.ID], db.StoreMatchMoveParams{
+		MatchID:  m.ID,
+		Ply:      int64(ply),
+		Move:     move,
+		PlayedAt: time.Now().UTC(),
+	})
+	l.count++
+	if l.count >= MOVE_LOG_BATCH_SIZE {
+		l.signal()
+	}
+}
+
+// EndMatch writes the queued moves soon, the match's game is over.
+func (l *MoveLog) EndMatch(m *game.Match) {
+	l.signal()
+}
+
+func (l *MoveLog) signal() {
+	select {
+	case l.flushNow <- struct{}{}:
+	default:
+	}
+}
+
+// Stats are the moves waiting to be written, and the moves dropped since the server started.
+func (l *MoveLog) Stats() (queued int, dropped int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.count, l.dropped
+}
+
+func (l *MoveLog) run() {
+	defer close(l.stopped)
+	ticker := time.NewTicker(MOVE_LOG_FLUSH_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		case <-l.flushNow:
+		}
+		if err := l.flush(context.Background()); err != nil {
+			slog.Error("failed to write move log, will retry", "error", err)
+		}
+	}
+}
+
+// Close stops the writer and writes the queued moves, waiting at most MOVE_LOG_CLOSE_TIMEOUT.
+// Moves added afterwards are dropped.
+func (l *MoveLog) Close() {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return
+	}
+	l.closed = true
+	l.mu.Unlock()
+	close(l.stop)
+	<-l.stopped
+
+	ctx, cancel := context.WithTimeout(context.Background(), MOVE_LOG_CLOSE_TIMEOUT)
+	defer cancel()
+	if err := l.flush(ctx); err != nil {
+		queued, _ := l.Stats()
+		slog.Error("failed to write move log, moves are lost", "moves", queued, "error", err)
+	}
+}
+
+// flush writes every queued move. Moves that could not be written are queued again.
+// Only one flush runs at a time: the writer's, or Close's after the writer stopped.
+func (l *MoveLog) flush(ctx context.Context) error {
+	l.mu.Lock()
+	batch, count := l.queued, l.count
+	l.queued, l.count = map[string][]db.StoreMatchMoveParams{}, 0
+	l.mu.Unlock()
+	if count == 0 {
+		return nil
+	}
+
+	err := l.write(ctx, batch, count)
+	if err == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.count+count > MOVE_LOG_MAX_QUEUED {
+		l.dropped += int64(count)
+		return err
+	}
+	for id, moves := range batch {
+		// older moves go first
+		l.queued[id] = append(moves, l.queued[id]...)
+	}
+	l.count += count
+	return err
+}
+
+func (l *MoveLog) write(ctx context.Context, batch map[string][]db.StoreMatchMoveParams, count int) (err error) {
+	ctx, span := tracer.Start(ctx, "moveLog.write")
+	span.SetAttributes(attribute.Int("moves", count), attribute.Int("matches", len(batch)))
+	defer func() { endSpan(span, err) }()
+
+	tx, err := l.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := db.New(tx)
+	for _, moves := range batch {
+		for _, move := range moves {
+			if err := q.StoreMatchMove(ctx, move); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// purgeOldMatchMoves deletes logged moves older than MOVE_LOG_RETENTION
+func (s Server) purgeOldMatchMoves(ctx context.Context) {
+	deleted, err := s.DB.DeleteOldMatchMoves(ctx, time.Now().UTC().Add(-MOVE_LOG_RETENTION))
+	if err != nil {
+		slog.Warn("failed to delete old match moves", "error", err)
+	} else if deleted > 0 {
+		slog.Info("deleted old match moves", "count", deleted)
+	}
+}
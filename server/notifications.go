@@ -0,0 +1,253 @@
+// per-user notifications
+package server
+
+import (
+	"api/db"
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type NotificationType string
+
+const (
+	// someone sent you a friend request
+	NotifyFriendRequest NotificationType = "friendRequest"
+	// someone accepted your friend request
+	NotifyFriendAccepted NotificationType = "friendAccepted"
+	// your opponent moved while you were not connected to the match
+	NotifyYourMove NotificationType = "yourMove"
+	// a bot accepted your challenge, the match is ready to join
+	NotifyChallengeAccepted NotificationType = "challengeAccepted"
+	// a bot declined your challenge
+	NotifyChallengeDeclined NotificationType = "challengeDeclined"
+	// a tournament round started, from is your opponent and the match is ready to join
+	NotifyTournamentPairing NotificationType = "tournamentPairing"
+	// a simul you joined started, from is the host and the match is your board
+	NotifySimulStarted NotificationType = "simulStarted"
+	// someone accepted your seek, from is your opponent and the match is ready to join
+	NotifySeekAccepted NotificationType = "seekAccepted"
+)
+
+// Notification is sent to a user's inbox and notification stream.
+// Each type only uses some of the fields.
+type Notification struct {
+	ID        int64            `json:"id" example:"1"`
+	Type      NotificationType `json:"type" example:"friendRequest"`
+	From      string           `json:"from,omitempty" example:"JohnDoe"`
+	MatchID   string           `json:"matchId,omitempty" example:"AB2C21"`
+	Read      bool             `json:"read" example:"false"`
+	CreatedAt time.Time        `json:"createdAt" format:"date-time"`
+}
+
+func NotificationFromDb(n db.Notification) Notification {
+	return Notification{
+		ID:        n.ID,
+		Type:      NotificationType(n.Type),
+		From:      n.FromUsername,
+		MatchID:   n.MatchID,
+		Read:      n.Read,
+		CreatedAt: n.CreatedAt,
+	}
+}
+
+// NotificationHub delivers notifications to the open notification streams of users.
+type NotificationHub struct {
+	mu sync.Mutex
+	// username -> open streams
+	streams map[string]map[chan Notification]struct{}
+}
+
+func NewNotificationHub() *NotificationHub {
+	return &NotificationHub{
+		streams: map[string]map[chan Notification]struct{}{},
+	}
+}
+
+// Subscribe opens a stream for username. The returned function closes it.
+func (h *NotificationHub) Subscribe(username string) (notifications chan Notification, unsubscribe func()) {
+	notifications = make(chan Notification, 10)
+	h.mu.Lock()
+	if h.streams[username] == nil {
+		h.streams[username] = map[chan Notification]struct{}{}
+	}
+	h.streams[username][notifications] = struct{}{}
+	h.mu.Unlock()
+	return notifications, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.streams[username], notifications)
+		if len(h.streams[username]) == 0 {
+			delete(h.streams, username)
+		}
+	}
+}
+
+// Publish sends n to every open stream of username without blocking.
+func (h *NotificationHub) Publish(username string, n Notification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for stream := range h.streams[username] {
+		select {
+		case stream <- n:
+		default:
+			slog.Warn("notification stream is full, dropping notification", "username", username)
+		}
+	}
+}
+
+// notify stores a notification for user and publishes it to their open streams.
+func (s Server) notify(ctx context.Context, user db.User, t NotificationType, from, matchID string) {
+	saved, err := s.DB.CreateNotification(ctx, db.CreateNotificationParams{
+		Uid:          user.Uid,
+		Type:         string(t),
+		FromUsername: from,
+		MatchID:      matchID,
+		CreatedAt:    time.Now().UTC(),
+	})
+	if err != nil {
+		slog.Error("failed to store notification", "username", user.Username, "error", err)
+		return
+	}
+	s.Notifications.Publish(user.Username, NotificationFromDb(saved))
+}
+
+// notifyUsername is notify for when only the username is known
+func (s Server) notifyUsername(ctx context.Context, username string, t NotificationType, from, matchID string) {
+	user, err := s.DB.GetUserByUsername(ctx, username)
+	if err != nil {
+		return
+	}
+	s.notify(ctx, user, t, from, matchID)
+}
+
+// @Summary		List your notifications
+// @Description	Lists your most recent notifications, newest first.
+// @Description	Notification types: `friendRequest`, `friendAccepted`, `yourMove`, `challengeAccepted`, `challengeDeclined`, `tournamentPairing`, `simulStarted`, `seekAccepted`.
+// @Tags			notifications
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			unread			query		bool	false	"only list unread notifications"
+// @Param			limit			query		int		false	"max notifications to return, default 50, max 100"
+// @Success		200				{array}		Notification
+// @Failure		400				{object}	ErrorReason	"Invalid query"
+// @Failure		401				{object}	ErrorReason
+// @Failure		500				{object}	ErrorReason
+// @Router			/notifications [get]
+func (s Server) ListNotifications(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	unread := c.QueryParam("unread") == "true"
+	limit := 50
+	if l := c.QueryParam("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 {
+			return c.JSON(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, "limit must be a positive number"))
+		}
+		limit = min(limit, 100)
+	}
+	saved, err := s.DB.ListNotifications(c.Request().Context(), db.ListNotificationsParams{
+		Uid:         user.Uid,
+		IncludeRead: !unread,
+		Limit:       int64(limit),
+	})
+	if err != nil {
+		slog.Error("failed to list notifications", "username", user.Username, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	notifications := make([]Notification, 0, len(saved))
+	for _, n := range saved {
+		notifications = append(notifications, NotificationFromDb(n))
+	}
+	return c.JSON(http.StatusOK, notifications)
+}
+
+type MarkNotificationsReadRequest struct {
+	// every notification up to and including this id is marked as read
+	UpTo int64 `json:"upTo" minimum:"0" example:"12" validate:"min=0"`
+}
+
+// @Summary	Mark notifications as read
+// @Tags		notifications
+// @Accept		json
+// @Produce	json
+// @Param		Authorization	header		string							true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param		payload			body		MarkNotificationsReadRequest	true	"newest notification id that was read"
+// @Success	200				{object}	string							"ok"
+// @Failure	400				{object}	ErrorReason						"Invalid json body"
+// @Failure	401				{object}	ErrorReason
+// @Failure	500				{object}	ErrorReason
+// @Router		/notifications/read [post]
+func (s Server) MarkNotificationsRead(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	var req MarkNotificationsReadRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	_, err = s.DB.MarkNotificationsRead(c.Request().Context(), db.MarkNotificationsReadParams{
+		Uid: user.Uid,
+		ID:  req.UpTo,
+	})
+	if err != nil {
+		slog.Error("failed to mark notifications read", "username", user.Username, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return c.JSON(http.StatusOK, "ok")
+}
+
+// @Summary		Receive notifications as they happen
+// @Description	## On success the server will send `SSE` messages whose payloads are JSON notifications.
+// @Description	Having this stream open also shows you as online to your friends.
+// @Tags			notifications
+// @Produce		event-stream
+// @Param			Authorization	header		string			true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Success		200				{object}	Notification	"SSE stream — each `data:` payload is a notification (Content-Type: text/event-stream)."
+// @Failure		401				{object}	ErrorReason
+// @Failure		429				{object}	ErrorReason	"Too many open streams"
+// @Router			/notifications/stream [get]
+func (s Server) StreamNotifications(c echo.Context) error {
+	username := usernameOf(c)
+	if username == "" {
+		return c.JSON(http.StatusUnauthorized, REASON_UNAUTHORIZED)
+	}
+	disconnect, ok := s.Presence.ConnectLimited(username, "", s.MaxStreamsPerUser)
+	if !ok {
+		return c.JSON(http.StatusTooManyRequests, REASON_TOO_MANY_STREAMS)
+	}
+	defer disconnect()
+	notifications, unsubscribe := s.Notifications.Subscribe(username)
+	defer unsubscribe()
+	startSSE(c)
+
+	ticker := time.NewTicker(SSE_KEEP_ALIVE_INTERVAL)
+	defer ticker.Stop()
+	ctx := c.Request().Context()
+	w := c.Response()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := writeSSEKeepAlive(w); err != nil {
+				return nil
+			}
+		case n := <-notifications:
+			if err := writeSSE(w, n); err != nil {
+				return nil
+			}
+		case <-s.lifecycle.drained:
+			writeSSE(w, Notification{Type: NotifyServerRestarting, CreatedAt: time.Now().UTC()})
+			return nil
+		}
+	}
+}
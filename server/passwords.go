@@ -0,0 +1,125 @@
+// hashing and verifying passwords
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// password hashing algorithms
+const (
+	HASH_BCRYPT   = "bcrypt"
+	HASH_ARGON2ID = "argon2id"
+)
+
+// Argon2Params are the cost parameters of argon2id
+type Argon2Params struct {
+	// memory in KiB
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+}
+
+// PasswordHasher hashes new passwords with Algorithm.
+// It can verify hashes made by either algorithm, so existing hashes keep working
+// when the algorithm or its cost changes, and are rehashed on the next login.
+type PasswordHasher struct {
+	Algorithm  string
+	BcryptCost int
+	Argon2     Argon2Params
+}
+
+var DEFAULT_PASSWORD_HASHER = PasswordHasher{
+	Algorithm:  HASH_BCRYPT,
+	BcryptCost: bcrypt.DefaultCost,
+	// the OWASP recommended minimum
+	Argon2: Argon2Params{Memory: 19 * 1024, Time: 2, Threads: 1},
+}
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// Validate checks the algorithm and cost parameters
+func (h PasswordHasher) Validate() error {
+	switch h.Algorithm {
+	case HASH_BCRYPT:
+		if h.BcryptCost < bcrypt.MinCost || h.BcryptCost > bcrypt.MaxCost {
+			return fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case HASH_ARGON2ID:
+		if h.Argon2.Memory < 8*uint32(h.Argon2.Threads) || h.Argon2.Time < 1 || h.Argon2.Threads < 1 {
+			return errors.New("argon2 memory, time and threads must be positive, memory must be at least 8 KiB per thread")
+		}
+	default:
+		return fmt.Errorf("unknown password hash algorithm %q", h.Algorithm)
+	}
+	return nil
+}
+
+// Hash a password using the configured algorithm
+func (h PasswordHasher) Hash(password string) (string, error) {
+	if h.Algorithm == HASH_ARGON2ID {
+		salt := make([]byte, argon2SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		p := h.Argon2
+		key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, argon2KeyLength)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.Memory, p.Time, p.Threads,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.BcryptCost)
+	return string(hash), err
+}
+
+// Verify a password against a hash made by either algorithm.
+// needsRehash is true if the hash was made with a different algorithm or cost than the configured one.
+func (h PasswordHasher) Verify(hash, password string) (ok bool, needsRehash bool) {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		params, salt, key, err := decodeArgon2Hash(hash)
+		if err != nil {
+			return false, false
+		}
+		got := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(got, key) != 1 {
+			return false, false
+		}
+		return true, h.Algorithm != HASH_ARGON2ID || params != h.Argon2
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return false, false
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	return true, h.Algorithm != HASH_BCRYPT || err != nil || cost != h.BcryptCost
+}
+
+// decode $argon2id$v=19$m=65536,t=1,p=4$salt$key
+func decodeArgon2Hash(hash string) (params Argon2Params, salt, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, errors.New("invalid argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errors.New("unsupported argon2 version")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return params, nil, nil, err
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, nil, nil, err
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return params, nil, nil, err
+	}
+	return params, salt, key, nil
+}
@@ -0,0 +1,158 @@
+// per-user settings
+package server
+
+import (
+	"api/db"
+	"context"
+	"encoding/json"
+	"image/color"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// light and dark square colors of each board theme
+var BOARD_THEMES = map[string][2]color.RGBA{
+	"brown": {{235, 209, 166, 255}, {165, 117, 81, 255}},
+	"blue":  {{222, 227, 230, 255}, {140, 162, 173, 255}},
+	"green": {{238, 238, 210, 255}, {118, 150, 86, 255}},
+	"gray":  {{220, 220, 220, 255}, {150, 150, 150, 255}},
+}
+
+// piece sets the board image can be drawn with
+var PIECE_SETS = []string{"cburnett"}
+
+// Preferences are the settings of a user
+type Preferences struct {
+	// colors of the board image
+	BoardTheme string `json:"boardTheme" enums:"brown,blue,green,gray" example:"brown"`
+	// pieces of the board image
+	PieceSet string `json:"pieceSet" enums:"cburnett" example:"cburnett"`
+	// promote to a queen when a pawn move reaches the last rank without a promotion piece, eg. `e7e8`
+	AutoQueen bool `json:"autoQueen" example:"true"`
+	// whether opponents may ask to take back a move
+	AllowTakebacks bool `json:"allowTakebacks" example:"true"`
+	// whether users who aren't your friends may challenge you
+	AllowChallengesFromStrangers bool `json:"allowChallengesFromStrangers" example:"true"`
+	// email you when it becomes your move while you are disconnected from the match, see PUT /users/me/email
+	EmailYourMove bool `json:"emailYourMove" example:"true"`
+	// email you when your opponent resigns while you are disconnected from the match
+	EmailOpponentResigned bool `json:"emailOpponentResigned" example:"true"`
+}
+
+// preferences of users who never changed them
+var DEFAULT_PREFERENCES = Preferences{
+	BoardTheme:                   "brown",
+	PieceSet:                     "cburnett",
+	AutoQueen:                    false,
+	AllowTakebacks:               true,
+	AllowChallengesFromStrangers: true,
+	EmailYourMove:                true,
+	EmailOpponentResigned:        true,
+}
+
+// PreferencesPatch changes only the preferences that are present
+type PreferencesPatch struct {
+	BoardTheme                   *string `json:"boardTheme,omitempty" enums:"brown,blue,green,gray" example:"blue" validate:"omitempty,boardtheme"`
+	PieceSet                     *string `json:"pieceSet,omitempty" enums:"cburnett" example:"cburnett" validate:"omitempty,pieceset"`
+	AutoQueen                    *bool   `json:"autoQueen,omitempty" example:"true"`
+	AllowTakebacks               *bool   `json:"allowTakebacks,omitempty" example:"false"`
+	AllowChallengesFromStrangers *bool   `json:"allowChallengesFromStrangers,omitempty" example:"false"`
+	EmailYourMove                *bool   `json:"emailYourMove,omitempty" example:"false"`
+	EmailOpponentResigned        *bool   `json:"emailOpponentResigned,omitempty" example:"false"`
+}
+
+// PreferencesFromDbUser decodes the preferences column. Missing fields use the defaults.
+func PreferencesFromDbUser(user db.User) Preferences {
+	prefs := DEFAULT_PREFERENCES
+	if err := json.Unmarshal([]byte(user.Preferences), &prefs); err != nil {
+		slog.Warn("invalid preferences in database, using defaults", "username", user.Username, "error", err)
+		return DEFAULT_PREFERENCES
+	}
+	return prefs
+}
+
+// preferencesOf gets the preferences of username, or the defaults if they can't be loaded.
+func (s Server) preferencesOf(ctx context.Context, username string) Preferences {
+	user, err := s.DB.GetUserByUsername(ctx, username)
+	if err != nil {
+		return DEFAULT_PREFERENCES
+	}
+	return PreferencesFromDbUser(user)
+}
+
+// @Summary	Get your preferences
+// @Tags		users
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Success	200				{object}	Preferences
+// @Failure	401				{object}	ErrorReason
+// @Failure	500				{object}	ErrorReason
+// @Router		/users/me/preferences [get]
+func (s Server) GetPreferences(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, PreferencesFromDbUser(user))
+}
+
+// @Summary		Change your preferences
+// @Description	Only the fields present in the body are changed.
+// @Tags			users
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header		string				true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			payload			body		PreferencesPatch	true	"preferences to change"
+// @Success		200				{object}	Preferences			"updated preferences"
+// @Failure		400				{object}	ErrorReason			"Invalid json body / unknown board theme or piece set"
+// @Failure		401				{object}	ErrorReason
+// @Failure		500				{object}	ErrorReason
+// @Router			/users/me/preferences [patch]
+func (s Server) PatchPreferences(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	var req PreferencesPatch
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	prefs := PreferencesFromDbUser(user)
+	if req.BoardTheme != nil {
+		prefs.BoardTheme = *req.BoardTheme
+	}
+	if req.PieceSet != nil {
+		prefs.PieceSet = *req.PieceSet
+	}
+	if req.AutoQueen != nil {
+		prefs.AutoQueen = *req.AutoQueen
+	}
+	if req.AllowTakebacks != nil {
+		prefs.AllowTakebacks = *req.AllowTakebacks
+	}
+	if req.AllowChallengesFromStrangers != nil {
+		prefs.AllowChallengesFromStrangers = *req.AllowChallengesFromStrangers
+	}
+	if req.EmailYourMove != nil {
+		prefs.EmailYourMove = *req.EmailYourMove
+	}
+	if req.EmailOpponentResigned != nil {
+		prefs.EmailOpponentResigned = *req.EmailOpponentResigned
+	}
+
+	encoded, err := json.Marshal(prefs)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	err = s.DB.UpdateUserPreferences(c.Request().Context(), db.UpdateUserPreferencesParams{
+		Preferences: string(encoded),
+		Uid:         user.Uid,
+	})
+	if err != nil {
+		slog.Error("failed to update preferences", "username", user.Username, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return c.JSON(http.StatusOK, prefs)
+}
@@ -0,0 +1,105 @@
+// tracking which users are online
+package server
+
+import (
+	"sync"
+)
+
+// Presence of a user, derived from their open event streams
+type Presence struct {
+	Online bool `json:"online" example:"true"`
+	InGame bool `json:"inGame" example:"true"`
+	// match the user is playing, only set when inGame is true
+	MatchID string `json:"matchId,omitempty" example:"AB2C21"`
+}
+
+// PresenceTracker counts the open event streams of every user.
+// A user with at least one open stream is online.
+type PresenceTracker struct {
+	mu sync.Mutex
+	// username -> number of open streams
+	streams map[string]int
+	// username -> match id -> number of open streams for that match
+	matches map[string]map[string]int
+}
+
+func NewPresenceTracker() *PresenceTracker {
+	return &PresenceTracker{
+		streams: map[string]int{},
+		matches: map[string]map[string]int{},
+	}
+}
+
+// Connect records an open stream for username.
+// matchID is the match being played on this stream, or empty.
+// The returned function must be called when the stream is closed.
+func (p *PresenceTracker) Connect(username, matchID string) (disconnect func()) {
+	disconnect, _ = p.ConnectLimited(username, matchID, 0)
+	return disconnect
+}
+
+// ConnectLimited is like Connect, but ok is false if username already has maxStreams open streams.
+// 0 means no limit. disconnect is nil when ok is false.
+func (p *PresenceTracker) ConnectLimited(username, matchID string, maxStreams int) (disconnect func(), ok bool) {
+	p.mu.Lock()
+	if maxStreams > 0 && p.streams[username] >= maxStreams {
+		p.mu.Unlock()
+		return nil, false
+	}
+	p.streams[username]++
+	if matchID != "" {
+		if p.matches[username] == nil {
+			p.matches[username] = map[string]int{}
+		}
+		p.matches[username][matchID]++
+	}
+	p.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.streams[username]--
+			if p.streams[username] <= 0 {
+				delete(p.streams, username)
+			}
+			if matchID != "" {
+				p.matches[username][matchID]--
+				if p.matches[username][matchID] <= 0 {
+					delete(p.matches[username], matchID)
+				}
+				if len(p.matches[username]) == 0 {
+					delete(p.matches, username)
+				}
+			}
+		})
+	}, true
+}
+
+// Get the presence of a user
+func (p *PresenceTracker) Get(username string) Presence {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	presence := Presence{Online: p.streams[username] > 0}
+	for matchID := range p.matches[username] {
+		presence.InGame = true
+		presence.MatchID = matchID
+		break
+	}
+	return presence
+}
+
+// InMatch is true if username has a stream open for matchID
+func (p *PresenceTracker) InMatch(username, matchID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.matches[username][matchID] > 0
+}
+
+// number of users with at least one open stream
+func (p *PresenceTracker) OnlineCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.streams)
+}
@@ -0,0 +1,188 @@
+// puzzles found in finished games by the engine
+package server
+
+import (
+	"api/db"
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// how long the puzzle miner waits once every finished game was analyzed, or after the engine failed
+	PUZZLE_MINER_INTERVAL = time.Minute
+	// how deep and how long the engine searches each position
+	PUZZLE_SEARCH_DEPTH = 14
+	PUZZLE_SEARCH_TIME  = 300 * time.Millisecond
+	// the best move must be worth this many centipawns to the side to move
+	PUZZLE_WINNING_SCORE = 300
+	// and the second best move at most this many, so only one move wins
+	PUZZLE_SECOND_BEST_SCORE = 50
+	// positions before this ply are opening theory, not puzzles
+	PUZZLE_MIN_PLY = 8
+	// the miner moves on to the next game after finding this many puzzles in one
+	MAX_PUZZLES_PER_GAME = 3
+)
+
+// Puzzle is a position of a finished game where one move clearly wins
+type Puzzle struct {
+	ID int64 `json:"id" example:"42"`
+	// the side to move has one winning move
+	FEN string `json:"fen" example:"r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4"`
+	// the winning move in UCI notation
+	Solution string `json:"solution" example:"h5f7"`
+	// id of the archived game the position is from
+	GameID int64 `json:"gameId" example:"7"`
+	// match the game was played in, its id may have been reused since
+	MatchID string `json:"matchId" example:"AB2C21"`
+	// half moves played before the position
+	Ply int64 `json:"ply" example:"6"`
+	// players of the game, empty if they deleted their account
+	White     string    `json:"white" example:"JohnDoe"`
+	Black     string    `json:"black" example:"JaneDoe"`
+	CreatedAt time.Time `json:"createdAt" format:"date-time"`
+}
+
+// MinePuzzles analyzes every finished game with the computer's engines, oldest first, and stores the puzzles it finds.
+// It keeps waiting for new games until ctx is cancelled. Games are analyzed once, restarts continue after the last one.
+func (s Server) MinePuzzles(ctx context.Context) {
+	if s.Computer == nil {
+		return
+	}
+	for ctx.Err() == nil {
+		g, err := s.DB.NextGameToScanForPuzzles(ctx)
+		if err == nil {
+			err = s.scanForPuzzles(ctx, g)
+		}
+		if err == nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.Warn("puzzle miner failed, trying again later", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(PUZZLE_MINER_INTERVAL):
+		}
+	}
+}
+
+// scanForPuzzles stores the puzzles of g, and that g was analyzed.
+// Nothing is stored if the engine fails, so the game is analyzed again.
+func (s Server) scanForPuzzles(ctx context.Context, g db.Game) error {
+	var found []db.CreatePuzzleParams
+	// a game that cannot be read has no puzzles, it is not retried
+	if played, err := readPGN(g.Moves); err != nil {
+		slog.Warn("puzzle miner skipped a game it cannot read", "game", g.ID, "error", err)
+	} else {
+		positions := played.Positions()
+		// the last position has no move to find
+		for ply := PUZZLE_MIN_PLY; ply < len(positions)-1 && len(found) < MAX_PUZZLES_PER_GAME; ply++ {
+			pos := positions[ply]
+			// a forced move is no puzzle
+			if len(pos.ValidMoves()) < 2 {
+				continue
+			}
+			lines, err := s.Computer.engines.Analyse(ctx, pos.String(), 2, PUZZLE_SEARCH_DEPTH, PUZZLE_SEARCH_TIME)
+			if err != nil {
+				return err
+			}
+			if len(lines) < 2 || lines[0].score < PUZZLE_WINNING_SCORE || lines[1].score > PUZZLE_SECOND_BEST_SCORE {
+				continue
+			}
+			found = append(found, db.CreatePuzzleParams{GameID: g.ID, Ply: int64(ply), Fen: pos.String(), Solution: lines[0].move})
+			// the next position of the same side usually continues the same combination
+			ply += 2
+		}
+	}
+
+	tx, err := s.SQL.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := s.DB.WithTx(tx)
+	for _, p := range found {
+		if err := q.CreatePuzzle(ctx, p); err != nil {
+			return err
+		}
+	}
+	if err := q.StorePuzzleScan(ctx, db.StorePuzzleScanParams{GameID: g.ID, Puzzles: int64(len(found))}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if len(found) > 0 {
+		slog.Info("found puzzles", "game", g.ID, "puzzles", len(found))
+	}
+	return nil
+}
+
+// @Summary		Get a random puzzle
+// @Description	Puzzles are positions of finished games where one move clearly wins, found by the engine in the background.
+// @Description	The pool grows as games are played, it is empty when the server runs without an engine.
+// @Tags			puzzles
+// @Produce		json
+// @Success		200	{object}	Puzzle
+// @Failure		404	{object}	ErrorReason	"No puzzles yet"
+// @Failure		500	{object}	ErrorReason
+// @Router			/puzzles/random [get]
+func (s Server) GetRandomPuzzle(c echo.Context) error {
+	id, err := s.DB.GetRandomPuzzleId(c.Request().Context())
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(http.StatusNotFound, Reason(CODE_PUZZLE_NOT_FOUND, "No puzzles yet"))
+	}
+	if err != nil {
+		slog.Error("failed to pick a puzzle", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return s.writePuzzle(c, id)
+}
+
+// @Summary	Get a puzzle
+// @Tags		puzzles
+// @Produce	json
+// @Param		id	path		int	true	"Puzzle ID"
+// @Success	200	{object}	Puzzle
+// @Failure	404	{object}	ErrorReason	"Puzzle not found"
+// @Failure	500	{object}	ErrorReason
+// @Router		/puzzles/{id} [get]
+func (s Server) GetPuzzle(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, Reason(CODE_PUZZLE_NOT_FOUND, "Puzzle not found"))
+	}
+	return s.writePuzzle(c, id)
+}
+
+func (s Server) writePuzzle(c echo.Context, id int64) error {
+	p, err := s.DB.GetPuzzle(c.Request().Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(http.StatusNotFound, Reason(CODE_PUZZLE_NOT_FOUND, "Puzzle not found"))
+	}
+	if err != nil {
+		slog.Error("failed to get puzzle", "id", id, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return c.JSON(http.StatusOK, Puzzle{
+		ID:        p.ID,
+		FEN:       p.Fen,
+		Solution:  p.Solution,
+		GameID:    p.GameID,
+		MatchID:   p.MatchID,
+		Ply:       p.Ply,
+		White:     p.White,
+		Black:     p.Black,
+		CreatedAt: p.CreatedAt,
+	})
+}
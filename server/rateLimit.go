@@ -0,0 +1,184 @@
+// limiting how many requests a client can make
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RateLimit allows Requests every Window, in bursts of up to Requests.
+// A zero RateLimit allows everything.
+type RateLimit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// default limits, they can be changed with the RATE_LIMIT_* environment variables
+var (
+	// every route that accepts an api key, per key
+	DEFAULT_AUTHENTICATED_RATE_LIMIT = RateLimit{Requests: 120, Window: time.Minute}
+	// routes without an api key, per IP
+	DEFAULT_PUBLIC_RATE_LIMIT = RateLimit{Requests: 60, Window: time.Minute}
+	// POST /auth/login, per IP
+	DEFAULT_LOGIN_RATE_LIMIT = RateLimit{Requests: 10, Window: time.Minute}
+	// POST /users and POST /auth/guest, per IP
+	DEFAULT_SIGNUP_RATE_LIMIT = RateLimit{Requests: 5, Window: time.Hour}
+	// PUT /matches/:id, per key
+	DEFAULT_MOVE_RATE_LIMIT = RateLimit{Requests: 60, Window: time.Minute}
+)
+
+// ParseRateLimit parses limits like "120/1m". "off" disables the limit.
+func ParseRateLimit(s string) (RateLimit, error) {
+	if s == "off" {
+		return RateLimit{}, nil
+	}
+	requests, window, ok := strings.Cut(s, "/")
+	if !ok {
+		return RateLimit{}, errors.New(`rate limit must look like "120/1m" or "off"`)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n < 1 {
+		return RateLimit{}, fmt.Errorf("invalid number of requests %q", requests)
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid window %q", window)
+	}
+	return RateLimit{Requests: n, Window: d}, nil
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter is a token bucket per key. Each bucket holds up to limit.Requests tokens
+// and is refilled at limit.Requests tokens every limit.Window.
+type RateLimiter struct {
+	mu      sync.Mutex
+	limit   RateLimit
+	buckets map[string]*tokenBucket
+}
+
+func NewRateLimiter(limit RateLimit) *RateLimiter {
+	return &RateLimiter{
+		limit:   limit,
+		buckets: map[string]*tokenBucket{},
+	}
+}
+
+// SetLimit changes the limit and forgets all buckets.
+func (l *RateLimiter) SetLimit(limit RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	l.buckets = map[string]*tokenBucket{}
+}
+
+// Allow takes a token from the bucket of key.
+// remaining is the number of tokens left, reset is how long until the bucket is full again
+// and retryAfter is how long to wait for the next token when the request is not allowed.
+func (l *RateLimiter) Allow(key string) (ok bool, limit RateLimit, remaining int, reset, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limit.Requests == 0 {
+		return true, l.limit, 0, 0, 0
+	}
+	now := time.Now()
+	b := l.refill(key, now)
+	perToken := l.limit.Window / time.Duration(l.limit.Requests)
+	if b.tokens < 1 {
+		retryAfter = time.Duration((1 - b.tokens) * float64(perToken))
+	} else {
+		b.tokens--
+		ok = true
+	}
+	reset = time.Duration((float64(l.limit.Requests) - b.tokens) * float64(perToken))
+	return ok, l.limit, int(b.tokens), reset, retryAfter
+}
+
+// refill adds the tokens earned since the last request to the bucket of key
+func (l *RateLimiter) refill(key string, now time.Time) *tokenBucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(l.limit.Requests), last: now}
+		l.buckets[key] = b
+		return b
+	}
+	earned := now.Sub(b.last).Seconds() / l.limit.Window.Seconds() * float64(l.limit.Requests)
+	b.tokens = min(b.tokens+earned, float64(l.limit.Requests))
+	b.last = now
+	return b
+}
+
+// forget buckets that are full again, they behave the same as new ones
+func (l *RateLimiter) cleanup() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= l.limit.Window {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RateLimiters are the limiters used by the routes
+type RateLimiters struct {
+	Authenticated *RateLimiter
+	Public        *RateLimiter
+	Login         *RateLimiter
+	Signup        *RateLimiter
+	Move          *RateLimiter
+}
+
+func NewRateLimiters() RateLimiters {
+	return RateLimiters{
+		Authenticated: NewRateLimiter(DEFAULT_AUTHENTICATED_RATE_LIMIT),
+		Public:        NewRateLimiter(DEFAULT_PUBLIC_RATE_LIMIT),
+		Login:         NewRateLimiter(DEFAULT_LOGIN_RATE_LIMIT),
+		Signup:        NewRateLimiter(DEFAULT_SIGNUP_RATE_LIMIT),
+		Move:          NewRateLimiter(DEFAULT_MOVE_RATE_LIMIT),
+	}
+}
+
+func (r RateLimiters) cleanup() {
+	for _, l := range []*RateLimiter{r.Authenticated, r.Public, r.Login, r.Signup, r.Move} {
+		l.cleanup()
+	}
+}
+
+// RateLimitMiddleware limits requests per api key, or per IP for requests without one.
+// When used together with AuthApiKeyMiddleware, it must run after it.
+func (s Server) RateLimitMiddleware(l *RateLimiter) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			// only the latest key of a user is valid, so limiting the user limits the key
+			key := "ip:" + c.RealIP()
+			if username := usernameOf(c); username != "" {
+				key = "user:" + username
+			}
+			ok, limit, remaining, reset, retryAfter := l.Allow(key)
+			if limit.Requests == 0 {
+				return next(c)
+			}
+			h := c.Response().Header()
+			h.Set("X-RateLimit-Limit", strconv.Itoa(limit.Requests))
+			h.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+			h.Set("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(reset.Seconds()))))
+			if !ok {
+				h.Set("Retry-After", retryAfterSeconds(retryAfter))
+				return c.JSON(http.StatusTooManyRequests, Reason(CODE_RATE_LIMITED, "Too many requests, try again later"))
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// giving players whose match stream ended time to come back before they resign
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// how long a player whose stream ended can join their match again before they resign
+const RECONNECT_GRACE_PERIOD = time.Minute
+
+// Reconnects waits for players whose match stream ended, see playMatch
+type Reconnects struct {
+	mu sync.Mutex
+	// match id + " " + username -> resigns the player
+	timers map[string]*time.Timer
+}
+
+func NewReconnects() *Reconnects {
+	return &Reconnects{timers: map[string]*time.Timer{}}
+}
+
+func reconnectKey(matchID, username string) string {
+	return matchID + " " + username
+}
+
+// wait calls forfeit after RECONNECT_GRACE_PERIOD, unless cancel is called for the player before.
+// Waiting again for the same player replaces the earlier wait.
+func (r *Reconnects) wait(matchID, username string, forfeit func()) {
+	key := reconnectKey(matchID, username)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t, ok := r.timers[key]; ok {
+		t.Stop()
+	}
+	var t *time.Timer
+	t = time.AfterFunc(RECONNECT_GRACE_PERIOD, func() {
+		r.mu.Lock()
+		current := r.timers[key] == t
+		if current {
+			delete(r.timers, key)
+		}
+		r.mu.Unlock()
+		if current {
+			forfeit()
+		}
+	})
+	r.timers[key] = t
+}
+
+// cancel stops waiting for the player, they are back
+func (r *Reconnects) cancel(matchID, username string) {
+	key := reconnectKey(matchID, username)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t, ok := r.timers[key]; ok {
+		t.Stop()
+		delete(r.timers, key)
+	}
+}
@@ -0,0 +1,36 @@
+// turning panics in handlers into 500 responses
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RecoverMiddleware stops a panic in a handler from crashing the server.
+// The stack trace is logged and the client gets an internal error, unless the response was already started.
+func (s Server) RecoverMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) (err error) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			// let the http server abort the connection
+			if r == http.ErrAbortHandler {
+				panic(r)
+			}
+			slog.Error("handler panicked", "method", c.Request().Method, "path", c.Path(),
+				"panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+			if c.Response().Committed {
+				err = nil
+				return
+			}
+			err = c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+		}()
+		return next(c)
+	}
+}
@@ -0,0 +1,131 @@
+// results of finished matches, from memory or from the game archive
+package server
+
+import (
+	"api/server/game"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notnil/chess"
+)
+
+// MatchResult is how the game of a match ended
+type MatchResult struct {
+	MatchID string `json:"matchId" example:"AB2C21"`
+	// white, black or draw
+	Result string `json:"result" enums:"white,black,draw" example:"white"`
+	// username of the winner, empty for draws
+	Winner string `json:"winner" example:"JohnDoe"`
+	White  string `json:"white" example:"JohnDoe"`
+	Black  string `json:"black" example:"JaneDoe"`
+	// how the game ended. Matches have no clock, games that ran out of time are adjudicated.
+	// Empty for games archived before it was stored.
+	Method  string    `json:"method" enums:"checkmate,resignation,adjudication,stalemate,insufficientMaterial,threefoldRepetition,fivefoldRepetition,fiftyMoveRule,seventyFiveMoveRule" example:"checkmate"`
+	EndedAt time.Time `json:"endedAt" format:"date-time"`
+	// position at the end of the game, empty for games archived before it was stored
+	FEN string `json:"fen" example:"rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3"`
+}
+
+// resultMethod is how the finished game of m ended, for the games.method column
+func resultMethod(m *game.Match) string {
+	if m.Adjudicated() {
+		return "adjudication"
+	}
+	switch m.Method() {
+	case chess.Checkmate:
+		return "checkmate"
+	case chess.Resignation:
+		return "resignation"
+	case chess.Stalemate:
+		return "stalemate"
+	case chess.InsufficientMaterial:
+		return "insufficientMaterial"
+	case chess.ThreefoldRepetition:
+		return "threefoldRepetition"
+	case chess.FivefoldRepetition:
+		return "fivefoldRepetition"
+	case chess.FiftyMoveRule:
+		return "fiftyMoveRule"
+	case chess.SeventyFiveMoveRule:
+		return "seventyFiveMoveRule"
+	case chess.DrawOffer:
+		// players can't offer draws, only adjudicated games are drawn this way
+		return "adjudication"
+	}
+	return ""
+}
+
+// winnerOf is the username of the player who won, empty for draws
+func winnerOf(result, white, black string) string {
+	switch result {
+	case "white":
+		return white
+	case "black":
+		return black
+	}
+	return ""
+}
+
+// @Summary		Get the result of a match
+// @Description	The result is kept after the match is gone, so bots and tournament tools can collect it later.
+// @Description	Match ids are reused once a match is gone, the result is the one of the latest game played with the id.
+// @Description	Players who deleted their account are shown as `anonymous`.
+// @Tags			matches
+// @Produce		json
+// @Param			id	path		string	true	"Match ID"
+// @Success		200	{object}	MatchResult
+// @Failure		404	{object}	ErrorReason	"Match not found"
+// @Failure		409	{object}	ErrorReason	"The game is not over"
+// @Failure		500	{object}	ErrorReason
+// @Router			/matches/{id}/result [get]
+func (s Server) GetMatchResult(c echo.Context) error {
+	id := c.Param("id")
+	if match, ok := s.GameStorage.GetMatch(id); ok {
+		outcome := match.Outcome()
+		if outcome == chess.NoOutcome {
+			return c.JSON(http.StatusConflict, Reason(CODE_GAME_NOT_OVER, "The game is not over"))
+		}
+		result := MatchResult{
+			MatchID: match.ID,
+			Result:  resultFromOutcome(outcome),
+			Method:  resultMethod(match),
+			// nothing happens to a finished match until it is removed
+			EndedAt: match.LastActivity().UTC(),
+			FEN:     match.Position().String(),
+		}
+		if p, ok := match.GetPlayerWithColor(chess.White); ok {
+			result.White = p.Username
+		}
+		if p, ok := match.GetPlayerWithColor(chess.Black); ok {
+			result.Black = p.Username
+		}
+		result.Winner = winnerOf(result.Result, result.White, result.Black)
+		return c.JSON(http.StatusOK, result)
+	}
+
+	ctx := c.Request().Context()
+	g, err := s.DB.GetLatestGameByMatchId(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(http.StatusNotFound, Reason(CODE_MATCH_NOT_FOUND, "Match not found"))
+	}
+	if err != nil {
+		slog.Error("failed to get the game of a match", "match", id, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	names := usernameCache{s: s, names: map[int64]string{}}
+	result := MatchResult{
+		MatchID: g.MatchID,
+		Result:  g.Result,
+		White:   names.get(ctx, g.WhiteUid),
+		Black:   names.get(ctx, g.BlackUid),
+		Method:  g.Method,
+		EndedAt: g.FinishedAt,
+		FEN:     g.FinalFen,
+	}
+	result.Winner = winnerOf(result.Result, result.White, result.Black)
+	return c.JSON(http.StatusOK, result)
+}
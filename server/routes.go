@@ -8,15 +8,155 @@ import (
 // RegisterRoutes registers all the routes for this api server.
 
 func (s *Server) RegisterRoutes(e *echo.Echo) {
+	e.HTTPErrorHandler = s.ErrorHandler
+	e.Validator = NewRequestValidator()
+	e.Use(s.RecoverMiddleware)
+	e.Use(CompressionMiddleware())
 
-	e.POST("/users", s.RegisterUserAccount)
-	e.DELETE("/users", s.DeleteUserAccount, s.AuthApiKeyMiddleware)
+	// routes that accept an api key are limited per key, or per IP without one
+	authed := []echo.MiddlewareFunc{s.AuthApiKeyMiddleware, s.RateLimitMiddleware(s.RateLimits.Authenticated)}
+	public := s.RateLimitMiddleware(s.RateLimits.Public)
+	signup := s.RateLimitMiddleware(s.RateLimits.Signup)
 
-	e.POST("/matches", s.CreateMatch, s.AuthApiKeyMiddleware)
-	e.GET("/matches/:id/play", s.JoinMatch, s.AuthApiKeyMiddleware)
-	e.PUT("/matches/:id", s.PutMove, s.AuthApiKeyMiddleware)
-	e.GET("/matches/:id", s.GetBoardFEN)
-	e.GET("/matches/:id/img", s.GetBoardImage, s.AuthApiKeyMiddleware)
+	e.POST("/users", s.RegisterUserAccount, public, signup, s.SignupsPerIPMiddleware, s.SignupChallengeMiddleware)
+	e.DELETE("/users", s.DeleteUserAccount, authed...)
+	e.POST("/users/upgrade", s.UpgradeGuestAccount, authed...)
+	e.GET("/users/me/export", s.ExportUserData, authed...)
+	e.GET("/users/:username/games/export", s.ExportUserGames, authed...)
+	e.GET("/users/me/preferences", s.GetPreferences, authed...)
+	e.PATCH("/users/me/preferences", s.PatchPreferences, authed...)
+	e.GET("/users/me/email", s.GetEmail, authed...)
+	e.PUT("/users/me/email", s.PutEmail, authed...)
 
-	e.POST("/auth/login", s.GetApiKeyTryRenew)
+	e.GET("/users/me/friends", s.ListFriends, authed...)
+	e.GET("/users/me/friends/requests", s.ListFriendRequests, authed...)
+	e.POST("/users/me/friends/:username", s.SendFriendRequest, authed...)
+	e.PUT("/users/me/friends/:username", s.AcceptFriendRequest, authed...)
+	e.DELETE("/users/me/friends/:username", s.RemoveFriend, authed...)
+
+	e.GET("/users/me/blocks", s.ListBlocks, authed...)
+	e.POST("/users/me/blocks/:username", s.BlockUser, authed...)
+	e.DELETE("/users/me/blocks/:username", s.UnblockUser, authed...)
+
+	e.GET("/users/me/webhooks", s.ListWebhooks, authed...)
+	e.POST("/users/me/webhooks", s.CreateWebhook, authed...)
+	e.DELETE("/users/me/webhooks/:id", s.DeleteWebhook, authed...)
+	e.GET("/users/me/webhooks/:id/deliveries", s.ListWebhookDeliveries, authed...)
+
+	e.POST("/matches", s.CreateMatch, authed...)
+	e.POST("/matches/computer", s.CreateComputerMatch, authed...)
+	e.GET("/matches/:id/play", s.JoinMatch, authed...)
+	e.GET("/matches/:id/events/poll", s.PollMatchEvents, authed...)
+	e.PUT("/matches/:id", s.PutMove, s.AuthApiKeyMiddleware, s.RateLimitMiddleware(s.RateLimits.Move))
+	e.GET("/matches/:id", s.GetBoardFEN, public)
+	e.GET("/matches/:id/result", s.GetMatchResult, public)
+	e.GET("/matches/:id/img", s.GetBoardImage, authed...)
+	e.GET("/matches/:id/img.png", s.GetBoardImage, authed...)
+	e.GET("/matches/:id/gif", s.GetMatchGIF, authed...)
+	e.GET("/matches/:id/embed", s.GetMatchEmbed, public)
+	e.GET("/tv", s.WatchTV, public)
+	e.GET("/puzzles/random", s.GetRandomPuzzle, public)
+	e.GET("/puzzles/:id", s.GetPuzzle, public)
+	e.GET("/explorer", s.GetExplorer, public)
+	e.POST("/matches/:id/chat", s.PostChatMessage, authed...)
+	e.GET("/matches/:id/chat", s.GetChatMessages, authed...)
+
+	e.GET("/notifications", s.ListNotifications, authed...)
+	e.POST("/notifications/read", s.MarkNotificationsRead, authed...)
+	e.GET("/notifications/stream", s.StreamNotifications, authed...)
+
+	e.POST("/reports", s.CreateReport, authed...)
+
+	e.POST("/imports/pgn", s.ImportPGN, authed...)
+
+	e.POST("/studies", s.CreateStudy, authed...)
+	e.GET("/studies", s.ListStudies, authed...)
+	e.GET("/studies/:id", s.GetStudy, authed...)
+	e.DELETE("/studies/:id", s.DeleteStudy, authed...)
+	e.POST("/studies/:id/members/:username", s.AddStudyMember, authed...)
+	e.DELETE("/studies/:id/members/:username", s.RemoveStudyMember, authed...)
+	e.POST("/studies/:id/moves", s.PlayStudyMove, s.AuthApiKeyMiddleware, s.RateLimitMiddleware(s.RateLimits.Move))
+	e.POST("/studies/:id/undo", s.UndoStudyMove, s.AuthApiKeyMiddleware, s.RateLimitMiddleware(s.RateLimits.Move))
+	e.PUT("/studies/:id/comments", s.CommentStudyPosition, authed...)
+	e.GET("/studies/:id/stream", s.StreamStudy, authed...)
+
+	e.POST("/tournaments", s.CreateTournament, authed...)
+	e.GET("/tournaments", s.ListTournaments, authed...)
+	e.GET("/tournaments/:id", s.GetTournament, authed...)
+	e.POST("/tournaments/:id/join", s.JoinTournament, authed...)
+	e.DELETE("/tournaments/:id/join", s.LeaveTournament, authed...)
+	e.POST("/tournaments/:id/start", s.StartTournament, authed...)
+
+	e.GET("/users/me/teams", s.ListMyTeams, authed...)
+	teams := e.Group("/teams", authed...)
+	teams.POST("", s.CreateTeam)
+	teams.GET("", s.ListTeams)
+	teams.GET("/:id", s.GetTeam)
+	teams.PATCH("/:id", s.UpdateTeam)
+	teams.DELETE("/:id", s.DeleteTeam)
+	teams.POST("/:id/join", s.JoinTeam)
+	teams.DELETE("/:id/join", s.LeaveTeam)
+	teams.GET("/:id/members", s.ListTeamMembers)
+	teams.PUT("/:id/members/:username", s.SetTeamMemberRole)
+	teams.DELETE("/:id/members/:username", s.RemoveTeamMember)
+	teams.POST("/:id/matches", s.CreateTeamMatch)
+	teams.GET("/:id/matches", s.ListTeamMatches)
+	teams.POST("/:id/tournaments", s.CreateTeamTournament)
+	teams.GET("/:id/tournaments", s.ListTeamTournaments)
+
+	e.POST("/simuls", s.CreateSimul, authed...)
+	e.GET("/simuls", s.ListSimuls, authed...)
+	e.GET("/simuls/:id", s.GetSimul, authed...)
+	e.POST("/simuls/:id/join", s.JoinSimul, authed...)
+	e.DELETE("/simuls/:id/join", s.LeaveSimul, authed...)
+	e.POST("/simuls/:id/start", s.StartSimul, authed...)
+	e.GET("/simuls/:id/stream", s.StreamSimul, authed...)
+
+	e.POST("/seeks", s.CreateSeek, authed...)
+	e.GET("/seeks", s.ListSeeks, authed...)
+	e.DELETE("/seeks/:id", s.CancelSeek, authed...)
+	e.POST("/seeks/:id/accept", s.AcceptSeek, authed...)
+
+	e.POST("/users/me/bot", s.BecomeBot, authed...)
+	e.POST("/challenges", s.CreateChallenge, authed...)
+	e.DELETE("/challenges/:id", s.CancelChallenge, authed...)
+	e.POST("/challenges/:id/accept", s.AcceptChallenge, authed...)
+	e.POST("/challenges/:id/decline", s.DeclineChallenge, authed...)
+	e.GET("/bot/stream/event", s.StreamBotEvents, authed...)
+	e.GET("/bot/game/stream/:id", s.StreamBotGame, authed...)
+
+	// the Lichess board and bot APIs have the same game routes
+	for _, prefix := range []string{"/api/board", "/api/bot"} {
+		lichess := e.Group(prefix, s.lichessErrors, s.AuthApiKeyMiddleware)
+		lichess.GET("/game/stream/:id", s.LichessStreamGame, s.RateLimitMiddleware(s.RateLimits.Authenticated))
+		lichess.POST("/game/:id/move/:move", s.LichessMove, s.RateLimitMiddleware(s.RateLimits.Move))
+		lichess.POST("/game/:id/chat", s.LichessPostChat, s.RateLimitMiddleware(s.RateLimits.Authenticated))
+		lichess.GET("/game/:id/chat", s.LichessGetChat, s.RateLimitMiddleware(s.RateLimits.Authenticated))
+		lichess.POST("/game/:id/resign", s.LichessResign, s.RateLimitMiddleware(s.RateLimits.Authenticated))
+	}
+
+	admin := e.Group("/admin", s.AuthApiKeyMiddleware, s.RateLimitMiddleware(s.RateLimits.Authenticated), s.AdminMiddleware)
+	admin.GET("/users", s.AdminListUsers)
+	admin.POST("/users/:username/ban", s.AdminBanUser)
+	admin.DELETE("/users/:username/ban", s.AdminUnbanUser)
+	admin.GET("/users/:username/related", s.AdminListRelatedAccounts)
+	admin.GET("/users/:username/fair-play", s.AdminListFairPlayGames)
+	admin.DELETE("/matches/:id", s.AdminDeleteMatch)
+	admin.POST("/matches/:id/adjudicate", s.AdminAdjudicateMatch)
+	admin.GET("/stats", s.AdminStats)
+	admin.GET("/reports", s.AdminListReports)
+	admin.POST("/reports/:id/resolve", s.AdminResolveReport)
+	admin.GET("/fair-play", s.AdminListFlaggedPlayers)
+	admin.GET("/audit", s.AdminListAuditLog)
+	admin.GET("/abuse/ips", s.AdminListSharedIPs)
+	admin.GET("/backup", s.AdminDownloadBackup)
+	admin.POST("/backup", s.AdminCreateBackup)
+
+	e.POST("/auth/login", s.GetApiKeyTryRenew, public, s.RateLimitMiddleware(s.RateLimits.Login))
+	e.POST("/auth/guest", s.CreateGuest, public, signup, s.SignupsPerIPMiddleware, s.SignupChallengeMiddleware)
+	e.GET("/auth/challenge", s.GetSignupChallenge, public)
+
+	// probes are not rate limited
+	e.GET("/healthz", s.Healthz)
+	e.GET("/readyz", s.Readyz)
 }
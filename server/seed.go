@@ -0,0 +1,135 @@
+// demo data for developing against a local server
+package server
+
+import (
+	"api/db"
+	"api/server/game"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+// password of every demo user
+const SEED_PASSWORD = "password"
+
+// demo users, the first one is made an admin
+var SEED_USERS = []string{"alice", "bob", "carol", "dave"}
+
+// a scripted match between demo users
+type seedMatch struct {
+	white, black string
+	// in UCI notation
+	moves []string
+	// how the game ends after the moves, if it didn't end on the board. Empty keeps it running.
+	outcome chess.Outcome
+}
+
+var seedFinishedMatches = []seedMatch{
+	// scholar's mate
+	{white: "alice", black: "bob", moves: []string{"e2e4", "e7e5", "f1c4", "b8c6", "d1h5", "g8f6", "h5f7"}},
+	// fool's mate
+	{white: "dave", black: "carol", moves: []string{"f2f3", "e7e5", "g2g4", "d8h4"}},
+	// queen's gambit declined, white resigns
+	{white: "bob", black: "carol", moves: []string{"d2d4", "d7d5", "c2c4", "e7e6", "b1c3", "g8f6"}, outcome: chess.BlackWon},
+	// sicilian, drawn
+	{white: "alice", black: "dave", moves: []string{"e2e4", "c7c5", "g1f3", "d7d6", "d2d4", "c5d4", "f3d4"}, outcome: chess.Draw},
+}
+
+var seedLiveMatches = []seedMatch{
+	// ruy lopez, alice to move
+	{white: "alice", black: "carol", moves: []string{"e2e4", "e7e5", "g1f3", "b8c6", "f1b5", "a7a6"}},
+	// indian defence, bob to move
+	{white: "dave", black: "bob", moves: []string{"d2d4", "g8f6", "c2c4"}},
+	// waiting for an opponent
+	{white: "carol"},
+}
+
+// Seed fills the database with demo users and finished games, and starts a few matches.
+// Users and games are only created once, matches are in memory so they are started every time.
+// The demo users can join their matches with GET /matches/{id}/play.
+func (s Server) Seed(ctx context.Context) error {
+	_, err := s.DB.GetUserByUsername(ctx, SEED_USERS[0])
+	seeded := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if !seeded {
+		passwordHash, err := s.Passwords.Hash(SEED_PASSWORD)
+		if err != nil {
+			return err
+		}
+		for _, username := range SEED_USERS {
+			_, err := s.DB.CreateUser(ctx, db.CreateUserParams{
+				Username:     username,
+				PasswordHash: passwordHash,
+				ApiKey:       s.newApiKey(username),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create demo user %s: %w", username, err)
+			}
+		}
+		if _, err := s.DB.SetUserAdmin(ctx, db.SetUserAdminParams{IsAdmin: true, Username: SEED_USERS[0]}); err != nil {
+			return err
+		}
+		// finished matches are archived by the OnGameOver hook
+		for _, sm := range seedFinishedMatches {
+			if _, err := s.playSeedMatch(ctx, sm); err != nil {
+				return err
+			}
+		}
+	}
+	for _, sm := range seedLiveMatches {
+		m, err := s.playSeedMatch(ctx, sm)
+		if err != nil {
+			return err
+		}
+		slog.Info("started demo match", "match", m.ID, "white", sm.white, "black", sm.black)
+	}
+	slog.Info("seeded demo data", "users", SEED_USERS, "password", SEED_PASSWORD, "admin", SEED_USERS[0])
+	return nil
+}
+
+// playSeedMatch creates a match, plays the scripted moves and ends it with the scripted outcome.
+// Players of running matches leave their seats, so they can join them later.
+func (s Server) playSeedMatch(ctx context.Context, sm seedMatch) (*game.Match, error) {
+	m, err := s.GameStorage.NewMatch(ctx, sm.white, 12*time.Hour, false)
+	if err != nil {
+		return nil, err
+	}
+	var players []game.Player
+	for _, seat := range []struct {
+		username string
+		color    chess.Color
+	}{{sm.white, chess.White}, {sm.black, chess.Black}} {
+		if seat.username == "" {
+			continue
+		}
+		p, ok := m.Join(seat.username, seat.color)
+		if !ok {
+			return nil, fmt.Errorf("demo user %s could not join match %s", seat.username, m.ID)
+		}
+		players = append(players, p)
+	}
+	for i, move := range sm.moves {
+		if err := m.MoveAs(ctx, players[i%2], move); err != nil {
+			return nil, fmt.Errorf("demo move %s in match %s: %w", move, m.ID, err)
+		}
+	}
+	switch {
+	case m.Outcome() != chess.NoOutcome:
+	case sm.outcome == "":
+		for _, p := range players {
+			m.Leave(p)
+		}
+	case sm.outcome == chess.BlackWon:
+		m.Resign(players[0])
+	default:
+		m.Adjudicate(sm.outcome)
+	}
+	return m, nil
+}
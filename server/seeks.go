@@ -0,0 +1,302 @@
+// the seek board, where players post the match they want and anyone can accept it
+package server
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notnil/chess"
+)
+
+const (
+	// how long a seek waits for someone to accept it
+	SEEK_LIFETIME = 30 * time.Minute
+	// how long the players of an accepted seek have to join its match, their seats are kept for them until then
+	SEEK_JOIN_GRACE = 5 * time.Minute
+)
+
+// Seek is a match someone wants to play against anyone
+type Seek struct {
+	ID   string `json:"id" example:"Z2D4Q7FK"`
+	From string `json:"from" example:"JohnDoe"`
+	// duration of the match in hours
+	Duration int  `json:"duration" example:"1"`
+	Rated    bool `json:"rated" example:"false"`
+	// the seeker plays the black pieces
+	SeekerBlack bool      `json:"seekerBlack" example:"false"`
+	CreatedAt   time.Time `json:"createdAt" format:"date-time"`
+	ExpiresAt   time.Time `json:"expiresAt" format:"date-time"`
+}
+
+type CreateSeekRequest struct {
+	Duration int `json:"duration" minimum:"1" maximum:"12" example:"1" validate:"required,min=1,max=12"` // duration in hours
+	// rated seeks cannot be accepted by guests
+	Rated bool `json:"rated" example:"false"`
+	// whether you play the black pieces
+	BlackPieces bool `json:"blackPieces" example:"false"`
+}
+
+// SeekAccepted is the match created for an accepted seek
+type SeekAccepted struct {
+	MatchID string `json:"matchId" example:"AB2C21"`
+	// color you play
+	Color string `json:"color" example:"black"`
+}
+
+// SeekHub keeps open seeks in memory, and who plays in the matches of accepted seeks.
+// Seeks are short lived, they are lost when the server restarts.
+type SeekHub struct {
+	mu sync.Mutex
+	// id -> open seek
+	seeks map[string]Seek
+	// match id -> players of an accepted seek, until they joined
+	seats map[string]seekSeat
+}
+
+type seekSeat struct {
+	white, black string
+	expiresAt    time.Time
+}
+
+func NewSeekHub() *SeekHub {
+	return &SeekHub{
+		seeks: map[string]Seek{},
+		seats: map[string]seekSeat{},
+	}
+}
+
+// Add posts a seek on the board
+func (h *SeekHub) Add(seek Seek) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.seeks[seek.ID] = seek
+}
+
+// Get is the seek with id, ok is false if there is none or it expired.
+func (h *SeekHub) Get(id string) (seek Seek, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	seek, ok = h.seeks[id]
+	if !ok || time.Now().After(seek.ExpiresAt) {
+		return Seek{}, false
+	}
+	return seek, true
+}
+
+// Take removes the seek with id, ok is false if there is none or it expired.
+func (h *SeekHub) Take(id string) (seek Seek, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	seek, ok = h.seeks[id]
+	if !ok || time.Now().After(seek.ExpiresAt) {
+		return Seek{}, false
+	}
+	delete(h.seeks, id)
+	return seek, true
+}
+
+// Open are the seeks that did not expire, newest first
+func (h *SeekHub) Open() []Seek {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	seeks := []Seek{}
+	for _, seek := range h.seeks {
+		if now.Before(seek.ExpiresAt) {
+			seeks = append(seeks, seek)
+		}
+	}
+	slices.SortFunc(seeks, func(a, b Seek) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return seeks
+}
+
+// Seat remembers who plays white and black in a match created from a seek
+func (h *SeekHub) Seat(matchID, white, black string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.seats[matchID] = seekSeat{white: white, black: black, expiresAt: time.Now().Add(SEEK_JOIN_GRACE)}
+}
+
+// SeatColor is the color username plays in the match, ok is false if it was not created from a seek they play in.
+func (h *SeekHub) SeatColor(matchID, username string) (color chess.Color, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	seat, ok := h.seats[matchID]
+	switch {
+	case !ok:
+		return chess.NoColor, false
+	case seat.white == username:
+		return chess.White, true
+	case seat.black == username:
+		return chess.Black, true
+	}
+	return chess.NoColor, false
+}
+
+// Reserved is true if the match was created from a seek of other players
+func (h *SeekHub) Reserved(matchID, username string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	seat, ok := h.seats[matchID]
+	return ok && seat.white != username && seat.black != username
+}
+
+// cleanup forgets expired seeks and seats
+func (h *SeekHub) cleanup() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	for id, seek := range h.seeks {
+		if now.After(seek.ExpiresAt) {
+			delete(h.seeks, id)
+		}
+	}
+	for matchID, seat := range h.seats {
+		if now.After(seat.expiresAt) {
+			delete(h.seats, matchID)
+		}
+	}
+}
+
+// @Summary		Post a seek
+// @Description	Seeks are matches anyone can accept, listed on GET /seeks. A seek expires after 30 minutes.
+// @Description	Once someone accepts it, you get a `seekAccepted` notification with the match id. Join it like any other match within 5 minutes.
+// @Tags			seeks
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header		string				true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			payload			body		CreateSeekRequest	true	"the match to play"
+// @Success		201				{object}	Seek
+// @Failure		400				{object}	ErrorReason	"Invalid json body"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Guests cannot play rated matches"
+// @Failure		429				{object}	ErrorReason	"Too many unfinished matches"
+// @Router			/seeks [post]
+func (s Server) CreateSeek(c echo.Context) error {
+	username := usernameOf(c)
+	if username == "" {
+		return c.JSON(http.StatusUnauthorized, REASON_UNAUTHORIZED)
+	}
+	var req CreateSeekRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	if req.Rated && isGuest(c) {
+		return c.JSON(http.StatusForbidden, Reason(CODE_GUESTS_CANNOT_RATED, "Guests cannot play rated matches"))
+	}
+	if s.tooManyMatches(username) {
+		return c.JSON(http.StatusTooManyRequests, Reason(CODE_TOO_MANY_MATCHES, fmt.Sprintf("You can have at most %d unfinished matches", s.MaxMatchesPerUser)))
+	}
+	now := time.Now().UTC()
+	seek := Seek{
+		ID:          rand.Text()[:8],
+		From:        username,
+		Duration:    req.Duration,
+		Rated:       req.Rated,
+		SeekerBlack: req.BlackPieces,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(SEEK_LIFETIME),
+	}
+	s.Seeks.Add(seek)
+	return c.JSON(http.StatusCreated, seek)
+}
+
+// @Summary	List the open seeks
+// @Tags		seeks
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Success	200				{array}		Seek	"newest first"
+// @Failure	401				{object}	ErrorReason
+// @Router		/seeks [get]
+func (s Server) ListSeeks(c echo.Context) error {
+	return c.JSON(http.StatusOK, s.Seeks.Open())
+}
+
+// @Summary	Cancel a seek you posted
+// @Tags		seeks
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param		id				path		string	true	"Seek ID"
+// @Success	200				{object}	string	"cancelled"
+// @Failure	401				{object}	ErrorReason
+// @Failure	404				{object}	ErrorReason	"Seek not found"
+// @Router		/seeks/{id} [delete]
+func (s Server) CancelSeek(c echo.Context) error {
+	username := usernameOf(c)
+	if username == "" {
+		return c.JSON(http.StatusUnauthorized, REASON_UNAUTHORIZED)
+	}
+	seek, ok := s.Seeks.Get(c.Param("id"))
+	if !ok || seek.From != username {
+		return c.JSON(http.StatusNotFound, Reason(CODE_SEEK_NOT_FOUND, "Seek not found"))
+	}
+	s.Seeks.Take(seek.ID)
+	return c.JSON(http.StatusOK, "cancelled")
+}
+
+// @Summary		Accept a seek
+// @Description	Creates the match of the seek, owned by the seeker, and sends them a `seekAccepted` notification.
+// @Description	Only the two players can join the match, each with the color of the seek.
+// @Tags			seeks
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string	true	"Seek ID"
+// @Success		200				{object}	SeekAccepted
+// @Failure		400				{object}	ErrorReason	"Cannot accept your own seek"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Blocked / guests cannot play rated matches"
+// @Failure		404				{object}	ErrorReason	"Seek not found"
+// @Failure		429				{object}	ErrorReason	"The seeker has too many unfinished matches"
+// @Failure		500				{object}	ErrorReason
+// @Router			/seeks/{id}/accept [post]
+func (s Server) AcceptSeek(c echo.Context) error {
+	username := usernameOf(c)
+	if username == "" {
+		return c.JSON(http.StatusUnauthorized, REASON_UNAUTHORIZED)
+	}
+	seek, ok := s.Seeks.Get(c.Param("id"))
+	if !ok {
+		return c.JSON(http.StatusNotFound, Reason(CODE_SEEK_NOT_FOUND, "Seek not found"))
+	}
+	if seek.From == username {
+		return c.JSON(http.StatusBadRequest, Reason(CODE_CANNOT_TARGET_SELF, "Cannot accept your own seek"))
+	}
+	if seek.Rated && isGuest(c) {
+		return c.JSON(http.StatusForbidden, Reason(CODE_GUESTS_CANNOT_RATED, "Guests cannot play rated matches"))
+	}
+	ctx := c.Request().Context()
+	blocked, err := s.blockedBetween(ctx, username, seek.From)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	if blocked {
+		return c.JSON(http.StatusForbidden, Reason(CODE_BLOCKED, "You cannot accept this seek"))
+	}
+	if s.tooManyMatches(seek.From) {
+		return c.JSON(http.StatusTooManyRequests, Reason(CODE_TOO_MANY_MATCHES, fmt.Sprintf("%s has too many unfinished matches", seek.From)))
+	}
+	if seek, ok = s.Seeks.Take(seek.ID); !ok {
+		// accepted or cancelled by another request
+		return c.JSON(http.StatusNotFound, Reason(CODE_SEEK_NOT_FOUND, "Seek not found"))
+	}
+	match, err := s.GameStorage.NewScheduledMatch(ctx, seek.From, time.Duration(seek.Duration)*time.Hour, seek.Rated, SEEK_JOIN_GRACE)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	white, black := seek.From, username
+	if seek.SeekerBlack {
+		white, black = black, white
+	}
+	s.Seeks.Seat(match.ID, white, black)
+	s.notifyUsername(ctx, seek.From, NotifySeekAccepted, username, match.ID)
+	color := chess.Black
+	if seek.SeekerBlack {
+		color = chess.White
+	}
+	return c.JSON(http.StatusOK, SeekAccepted{MatchID: match.ID, Color: colorName(color)})
+}
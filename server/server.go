@@ -3,7 +3,9 @@ package server
 import (
 	"api/db"
 	"api/server/game"
+	"context"
 	"database/sql"
+	"log/slog"
 )
 
 type Server struct {
@@ -11,13 +13,104 @@ type Server struct {
 	SQL         *sql.DB
 	JwtSecret   []byte
 	GameStorage *game.MatchStorage
+	// moves of ongoing matches, written in the background
+	MoveLog *MoveLog
+	// when the moves of ongoing rated matches were played, for the fair-play analysis
+	MoveClock *MoveClock
+	Webhooks  *WebhookDispatcher
+	// emails players who are offline about their matches, off without a Mailer
+	Emails *EmailSender
+	// plays matches against people, nil if no engine is configured
+	Computer *Computer
+	Presence *PresenceTracker
+	// players whose match stream ended, who resign unless they come back
+	Reconnects  *Reconnects
+	BoardImages *BoardImageCache
+	ChatLimiter *ChatLimiter
+	WordFilter  *WordFilter
+	// open notification streams
+	Notifications *NotificationHub
+	// open challenges to bots and the event streams of bots
+	Challenges *ChallengeHub
+	// people watching matches without playing
+	Watchers *MatchWatchers
+	// open streams of studies
+	Studies *StudyHub
+	// pairs tournament rounds, and who plays in their matches
+	Tournaments *Tournaments
+	// simuls and which board their hosts play next
+	Simuls *SimulHub
+	// open seeks, and who plays in the matches of accepted ones
+	Seeks *SeekHub
+	// picks the match shown on GET /tv
+	TV            *TV
+	LoginThrottle *LoginThrottle
+	// proof-of-work required to sign up, off by default
+	SignupChallenges *SignupChallenges
+	// accounts that can be created per IP, counted from the audit log
+	SignupsPerIP RateLimit
+	// unfinished matches a user can have created, 0 for no limit
+	MaxMatchesPerUser int
+	// open event streams per user, 0 for no limit
+	MaxStreamsPerUser int
+	// directory backups are written to
+	BackupDir  string
+	Passwords  PasswordHasher
+	RateLimits RateLimiters
+	lifecycle  *lifecycle
 }
 
 func NewServer(dbConnection *sql.DB, jwtSecret []byte) Server {
-	return Server{
-		DB:          db.New(dbConnection),
+	s := Server{
+		DB:          db.New(tracedDB{dbConnection}),
 		SQL:         dbConnection,
 		JwtSecret:   jwtSecret,
 		GameStorage: game.NewGamesStorage(),
+		MoveLog:     NewMoveLog(dbConnection),
+		MoveClock:   NewMoveClock(),
+		Presence:    NewPresenceTracker(),
+		Reconnects:  NewReconnects(),
+		BoardImages: NewBoardImageCache(BOARD_IMAGE_CACHE_SIZE),
+		ChatLimiter: NewChatLimiter(CHAT_RATE_LIMIT, CHAT_RATE_WINDOW),
+		WordFilter:  NewWordFilter(DEFAULT_BANNED_WORDS),
+
+		Notifications:    NewNotificationHub(),
+		Challenges:       NewChallengeHub(),
+		Watchers:         NewMatchWatchers(),
+		Studies:          NewStudyHub(),
+		Tournaments:      NewTournaments(),
+		Simuls:           NewSimulHub(),
+		Seeks:            NewSeekHub(),
+		TV:               NewTV(),
+		LoginThrottle:    NewLoginThrottle(),
+		SignupChallenges: NewSignupChallenges(0),
+		SignupsPerIP:     DEFAULT_SIGNUPS_PER_IP,
+
+		MaxMatchesPerUser: DEFAULT_MAX_MATCHES_PER_USER,
+		MaxStreamsPerUser: DEFAULT_MAX_STREAMS_PER_USER,
+		BackupDir:         DEFAULT_BACKUP_DIR,
+		Passwords:         DEFAULT_PASSWORD_HASHER,
+		RateLimits:        NewRateLimiters(),
+		lifecycle:         newLifecycle(),
+	}
+	s.Webhooks = NewWebhookDispatcher(s.DB)
+	s.Emails = NewEmailSender()
+	s.GameStorage.OnGameOver = s.gameOver
+	s.GameStorage.OnStart = s.gameStarted
+	s.GameStorage.OnMove = s.moved
+	go s.janitor(context.Background())
+	go s.tournamentReferee(context.Background())
+	return s
+}
+
+// MakeAdmins gives the users admin rights. Users that don't exist are skipped with a warning.
+func (s Server) MakeAdmins(ctx context.Context, usernames []string) {
+	for _, username := range usernames {
+		updated, err := s.DB.SetUserAdmin(ctx, db.SetUserAdminParams{IsAdmin: true, Username: username})
+		if err != nil || updated == 0 {
+			slog.Warn("could not make user an admin", "username", username, "error", err)
+			continue
+		}
+		s.auditWithIP(ctx, AUDIT_ADMIN_GRANT, "", username, "", "ADMINS environment variable")
 	}
 }
@@ -0,0 +1,106 @@
+// draining connections and saving matches before the server stops
+package server
+
+import (
+	"api/db"
+	"api/server/game"
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+// default for how long Shutdown and the http server get to drain connections, in total
+const SHUTDOWN_TIMEOUT = 15 * time.Second
+
+// sent on notification streams when the server shuts down
+const NotifyServerRestarting NotificationType = "serverRestarting"
+
+type lifecycle struct {
+	draining atomic.Bool
+	// closed once event streams should end
+	drained   chan struct{}
+	closeOnce sync.Once
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{drained: make(chan struct{})}
+}
+
+// Draining is true once Shutdown was called.
+func (s Server) Draining() bool {
+	return s.lifecycle.draining.Load()
+}
+
+// Shutdown stops accepting moves and new streams, saves unfinished matches so they can be resumed
+// after a restart, and ends every event stream with a serverRestarting event.
+// Replicated matches are not saved, the other replicas keep them going.
+// The http server must be shut down afterwards to wait for the streams to close.
+func (s Server) Shutdown(ctx context.Context) {
+	if s.lifecycle.draining.Swap(true) {
+		return
+	}
+	if s.GameStorage.Replicated() {
+		s.lifecycle.closeOnce.Do(func() { close(s.lifecycle.drained) })
+		return
+	}
+	matches := s.GameStorage.SuspendAll()
+	for _, m := range matches {
+		err := s.DB.SuspendMatch(ctx, db.SuspendMatchParams{
+			ID:            m.ID,
+			Rated:         m.Rated,
+			WhiteUsername: m.White,
+			BlackUsername: m.Black,
+			Moves:         m.PGN,
+			StartTime:     m.StartTime,
+			EndTime:       m.EndTime,
+			ComputerLevel: s.computerLevel(m.ID),
+		})
+		if err != nil {
+			slog.Error("failed to save match", "match", m.ID, "error", err)
+		}
+	}
+	slog.Info("saved unfinished matches", "count", len(matches))
+	s.lifecycle.closeOnce.Do(func() { close(s.lifecycle.drained) })
+}
+
+// ResumeMatches restores the matches saved by Shutdown. Matches that expired in the meantime are dropped.
+// It does nothing for replicated matches, they are replayed from the backend instead.
+func (s Server) ResumeMatches(ctx context.Context) {
+	if s.GameStorage.Replicated() {
+		return
+	}
+	matches, err := s.DB.ListSuspendedMatches(ctx)
+	if err != nil {
+		slog.Error("failed to list saved matches", "error", err)
+		return
+	}
+	for _, m := range matches {
+		if time.Now().Before(m.EndTime) {
+			match, err := s.GameStorage.Resume(game.SuspendedMatch{
+				ID:        m.ID,
+				Rated:     m.Rated,
+				White:     m.WhiteUsername,
+				Black:     m.BlackUsername,
+				PGN:       m.Moves,
+				StartTime: m.StartTime,
+				EndTime:   m.EndTime,
+			})
+			if err != nil {
+				slog.Warn("failed to resume match", "match", m.ID, "error", err)
+			} else if m.ComputerLevel > 0 && s.Computer != nil {
+				// the computer takes its seat back, the color is ignored
+				s.Computer.Play(match, chess.White, int(m.ComputerLevel))
+			}
+		}
+		if err := s.DB.DeleteSuspendedMatch(ctx, m.ID); err != nil {
+			slog.Warn("failed to delete saved match", "match", m.ID, "error", err)
+		}
+	}
+	if len(matches) > 0 {
+		slog.Info("resumed saved matches", "count", len(matches))
+	}
+}
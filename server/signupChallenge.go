@@ -0,0 +1,173 @@
+// proof-of-work challenges that make scripted signups expensive
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/bits"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// how long a challenge can be solved for
+	SIGNUP_CHALLENGE_LIFETIME = 10 * time.Minute
+	// above this, solving takes too long for a browser
+	MAX_SIGNUP_CHALLENGE_DIFFICULTY = 32
+)
+
+// SignupChallenges hands out hashcash style challenges. To sign up, clients must find a nonce
+// so that sha256(challenge + nonce) starts with Difficulty zero bits.
+// Challenges are signed, so only solved ones have to be remembered, to stop them from being reused.
+type SignupChallenges struct {
+	mu sync.Mutex
+	// leading zero bits, 0 turns challenges off
+	difficulty int
+	// solved challenges and when they expire
+	solved map[string]time.Time
+}
+
+func NewSignupChallenges(difficulty int) *SignupChallenges {
+	return &SignupChallenges{
+		difficulty: difficulty,
+		solved:     map[string]time.Time{},
+	}
+}
+
+// SetDifficulty changes the number of leading zero bits solutions need. 0 turns challenges off.
+func (sc *SignupChallenges) SetDifficulty(difficulty int) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.difficulty = difficulty
+}
+
+func (sc *SignupChallenges) Difficulty() int {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.difficulty
+}
+
+// solve marks challenge as used. ok is false if it was used before.
+func (sc *SignupChallenges) solve(challenge string, expiresAt time.Time) (ok bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if _, used := sc.solved[challenge]; used {
+		return false
+	}
+	sc.solved[challenge] = expiresAt
+	return true
+}
+
+// forget solved challenges that expired, they are rejected anyway
+func (sc *SignupChallenges) cleanup() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	now := time.Now()
+	for challenge, expiresAt := range sc.solved {
+		if now.After(expiresAt) {
+			delete(sc.solved, challenge)
+		}
+	}
+}
+
+// leadingZeroBits of sha256(challenge + nonce)
+func leadingZeroBits(challenge, nonce string) int {
+	sum := sha256.Sum256([]byte(challenge + nonce))
+	n := 0
+	for _, b := range sum {
+		n += bits.LeadingZeros8(b)
+		if b != 0 {
+			break
+		}
+	}
+	return n
+}
+
+// signChallenge makes a challenge in the format expiry.random.signature
+func (s Server) signChallenge(expiresAt time.Time) string {
+	payload := strconv.FormatInt(expiresAt.Unix(), 10) + "." + rand.Text()
+	return payload + "." + s.challengeSignature(payload)
+}
+
+func (s Server) challengeSignature(payload string) string {
+	mac := hmac.New(sha256.New, s.JwtSecret)
+	mac.Write([]byte("signup challenge:" + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyChallenge checks that challenge was issued by this server and solved by nonce.
+func (s Server) verifyChallenge(challenge, nonce string) error {
+	i := strings.LastIndex(challenge, ".")
+	if i < 0 || !hmac.Equal([]byte(challenge[i+1:]), []byte(s.challengeSignature(challenge[:i]))) {
+		return errors.New("invalid challenge, get one from GET /auth/challenge")
+	}
+	expiry, _, _ := strings.Cut(challenge, ".")
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil {
+		return errors.New("invalid challenge, get one from GET /auth/challenge")
+	}
+	expiresAt := time.Unix(unix, 0)
+	if time.Now().After(expiresAt) {
+		return errors.New("challenge expired, get a new one from GET /auth/challenge")
+	}
+	if leadingZeroBits(challenge, nonce) < s.SignupChallenges.Difficulty() {
+		return errors.New("nonce does not solve the challenge")
+	}
+	if !s.SignupChallenges.solve(challenge, expiresAt) {
+		return errors.New("challenge was already used, get a new one from GET /auth/challenge")
+	}
+	return nil
+}
+
+// SignupChallengeMiddleware requires a solved challenge in the X-Challenge and X-Challenge-Nonce headers,
+// unless challenges are turned off.
+func (s Server) SignupChallengeMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if s.SignupChallenges.Difficulty() == 0 {
+			return next(c)
+		}
+		h := c.Request().Header
+		challenge, nonce := h.Get("X-Challenge"), h.Get("X-Challenge-Nonce")
+		if challenge == "" || nonce == "" {
+			return c.JSON(http.StatusForbidden, Reason(CODE_CHALLENGE_REQUIRED, "Solve a challenge from GET /auth/challenge and send it in the X-Challenge and X-Challenge-Nonce headers"))
+		}
+		if err := s.verifyChallenge(challenge, nonce); err != nil {
+			return c.JSON(http.StatusForbidden, Reason(CODE_CHALLENGE_FAILED, err.Error()))
+		}
+		return next(c)
+	}
+}
+
+// SignupChallengeResponse is a proof-of-work challenge
+type SignupChallengeResponse struct {
+	Challenge string `json:"challenge" example:"1760000000.QW2F7ZKJ4C5X2LRXJQ6ZJQFZBY.9c1f..."`
+	// number of leading zero bits sha256(challenge + nonce) must have. 0 if challenges are turned off.
+	Difficulty int       `json:"difficulty" example:"20"`
+	ExpiresAt  time.Time `json:"expiresAt" format:"date-time"`
+}
+
+// @Summary		Get a signup challenge
+// @Description	When enabled, creating an account or a guest requires solving a proof-of-work challenge.
+// @Description	Find a nonce so that the SHA-256 hash of `challenge + nonce` starts with `difficulty` zero bits,
+// @Description	then send the challenge and the nonce in the `X-Challenge` and `X-Challenge-Nonce` headers of `POST /users` or `POST /auth/guest`.
+// @Description	Each challenge can be used once. A difficulty of 0 means no challenge is needed.
+// @Tags			auth
+// @Produce		json
+// @Success		200	{object}	SignupChallengeResponse
+// @Router			/auth/challenge [get]
+func (s Server) GetSignupChallenge(c echo.Context) error {
+	expiresAt := time.Now().Add(SIGNUP_CHALLENGE_LIFETIME).Truncate(time.Second)
+	return c.JSON(http.StatusOK, SignupChallengeResponse{
+		Challenge:  s.signChallenge(expiresAt),
+		Difficulty: s.SignupChallenges.Difficulty(),
+		ExpiresAt:  expiresAt.UTC(),
+	})
+}
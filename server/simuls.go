@@ -0,0 +1,676 @@
+// simultaneous exhibitions, where a host plays many challengers at once
+package server
+
+import (
+	"api/server/game"
+	"context"
+	"crypto/rand"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notnil/chess"
+)
+
+const (
+	// how long challengers have to join their board once the simul started
+	SIMUL_JOIN_GRACE = 10 * time.Minute
+	// how long a simul nobody started is kept, and how long a finished simul is kept
+	SIMUL_LIFETIME = 2 * time.Hour
+	// events a host stream can fall behind by before it is closed
+	SIMUL_BUFFER_SIZE = 64
+)
+
+// Simul is a simultaneous exhibition. The host plays white against every challenger, going from board to board.
+type Simul struct {
+	ID   string `json:"id" example:"S4KQ7Z2D"`
+	Name string `json:"name" example:"Friday simul"`
+	Host string `json:"host" example:"JohnDoe"`
+	// hours each game can last
+	Duration int  `json:"duration" example:"2"`
+	Rated    bool `json:"rated" example:"false"`
+	// challengers the simul can have
+	MaxBoards int `json:"maxBoards" example:"10"`
+	// open while challengers can join, then playing, then finished
+	Status string `json:"status" example:"open" enums:"open,playing,finished"`
+	// challengers waiting for the simul to start
+	Challengers []string     `json:"challengers"`
+	Boards      []SimulBoard `json:"boards"`
+	// match id of the board the host plays next, empty unless the simul is playing
+	CurrentBoard string    `json:"currentBoard,omitempty" example:"AB2C21"`
+	CreatedAt    time.Time `json:"createdAt" format:"date-time"`
+}
+
+// SimulBoard is the game of a challenger against the host
+type SimulBoard struct {
+	Challenger string `json:"challenger" example:"JaneDoe"`
+	MatchID    string `json:"matchId" example:"AB2C21"`
+	// empty while the game is played, aborted if the match expired without a result
+	Result string `json:"result" example:"white" enums:"white,black,draw,aborted,"`
+}
+
+type CreateSimulRequest struct {
+	Name string `json:"name" maxLength:"100" example:"Friday simul" validate:"notblank,max=100"`
+	// challengers the simul can have
+	Boards int `json:"boards" minimum:"1" maximum:"30" example:"10" validate:"required,min=1,max=30"`
+	// hours each game can last
+	Duration int `json:"duration" minimum:"1" maximum:"12" example:"2" validate:"required,min=1,max=12"`
+	// guests cannot join rated simuls
+	Rated bool `json:"rated" example:"false"`
+}
+
+type SimulEventType string
+
+const (
+	// the whole simul, the first event of the stream
+	SimulState SimulEventType = "simul"
+	// an event of one of the boards, in Event
+	SimulBoardEvent SimulEventType = "board"
+	// the host plays next on the board of MatchID
+	SimulTurn SimulEventType = "turn"
+	// the game of MatchID ended with Result
+	SimulBoardEnded SimulEventType = "boardEnded"
+	// every game ended, the stream ends
+	SimulFinished SimulEventType = "finished"
+	// the stream fell behind and ends, open it again to continue
+	SimulResync SimulEventType = "resync"
+	// the server is shutting down, open the stream again in a moment
+	SimulServerRestarting SimulEventType = "serverRestarting"
+)
+
+// SimulEvent is sent on the host's stream. Each type only uses some of the fields.
+type SimulEvent struct {
+	Type    SimulEventType `json:"type" example:"turn"`
+	MatchID string         `json:"matchId,omitempty" example:"AB2C21"`
+	// the event of the board, like on GET /matches/{id}/play
+	Event  *game.Event `json:"event,omitempty"`
+	Result string      `json:"result,omitempty" example:"draw"`
+	Simul  *Simul      `json:"simul,omitempty"`
+}
+
+// SimulHub keeps simuls in memory, decides which board the host plays next and delivers that to the host's stream.
+// Simuls are lost when the server restarts, their matches are not.
+type SimulHub struct {
+	mu     sync.Mutex
+	simuls map[string]*Simul
+	// match id -> simul id
+	boards map[string]string
+	// simul id -> the host's stream
+	streams map[string]chan SimulEvent
+}
+
+func NewSimulHub() *SimulHub {
+	return &SimulHub{
+		simuls:  map[string]*Simul{},
+		boards:  map[string]string{},
+		streams: map[string]chan SimulEvent{},
+	}
+}
+
+// copy is a snapshot of sim that can be sent without holding h.mu
+func (sim *Simul) copy() Simul {
+	c := *sim
+	c.Challengers = slices.Clone(sim.Challengers)
+	c.Boards = slices.Clone(sim.Boards)
+	return c
+}
+
+// Get is a snapshot of the simul with id
+func (h *SimulHub) Get(id string) (Simul, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sim, ok := h.simuls[id]
+	if !ok {
+		return Simul{}, false
+	}
+	return sim.copy(), true
+}
+
+// List are the simuls that did not finish, newest first
+func (h *SimulHub) List() []Simul {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	simuls := []Simul{}
+	for _, sim := range h.simuls {
+		if sim.Status != "finished" {
+			simuls = append(simuls, sim.copy())
+		}
+	}
+	slices.SortFunc(simuls, func(a, b Simul) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return simuls
+}
+
+func (h *SimulHub) add(sim *Simul) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.simuls[sim.ID] = sim
+}
+
+// change runs fn on the simul with id, the returned error is fn's
+func (h *SimulHub) change(id string, fn func(sim *Simul) error) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sim, ok := h.simuls[id]
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, Reason(CODE_SIMUL_NOT_FOUND, "Simul not found"))
+	}
+	return fn(sim)
+}
+
+// SeatColor is the color username plays on the board of matchID, ok is false if it is not their board.
+func (h *SimulHub) SeatColor(matchID, username string) (color chess.Color, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sim, board := h.board(matchID)
+	switch {
+	case board == nil:
+		return chess.NoColor, false
+	case sim.Host == username:
+		return chess.White, true
+	case board.Challenger == username:
+		return chess.Black, true
+	}
+	return chess.NoColor, false
+}
+
+// Reserved is true if matchID is a simul board that username does not play on
+func (h *SimulHub) Reserved(matchID, username string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sim, board := h.board(matchID)
+	return board != nil && sim.Host != username && board.Challenger != username
+}
+
+// CheckTurn checks that username may move on matchID. The host only moves on the board they are at.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (h *SimulHub) CheckTurn(matchID, username string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sim, board := h.board(matchID)
+	if board == nil || sim.Host != username || sim.CurrentBoard == matchID {
+		return nil
+	}
+	return echo.NewHTTPError(http.StatusConflict, Reason(CODE_NOT_CURRENT_BOARD, "You play on board "+sim.CurrentBoard+" next"))
+}
+
+// board is the simul and board of matchID, nil if it is not a simul board. h.mu must be held.
+func (h *SimulHub) board(matchID string) (*Simul, *SimulBoard) {
+	id, ok := h.boards[matchID]
+	if !ok {
+		return nil, nil
+	}
+	sim := h.simuls[id]
+	for i := range sim.Boards {
+		if sim.Boards[i].MatchID == matchID {
+			return sim, &sim.Boards[i]
+		}
+	}
+	return nil, nil
+}
+
+// start seats the challengers on their boards, the host starts on the first one
+func (h *SimulHub) start(sim *Simul, boards []SimulBoard) {
+	sim.Status = "playing"
+	sim.Challengers = []string{}
+	sim.Boards = boards
+	sim.CurrentBoard = boards[0].MatchID
+	for _, b := range boards {
+		h.boards[b.MatchID] = sim.ID
+	}
+	h.publish(sim.ID, SimulEvent{Type: SimulTurn, MatchID: sim.CurrentBoard})
+}
+
+// Moved moves the host on to the next board once they played on the current one
+func (h *SimulHub) Moved(matchID string, byHost bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sim, _ := h.board(matchID)
+	if sim == nil || !byHost || sim.CurrentBoard != matchID {
+		return
+	}
+	h.advance(sim)
+}
+
+// Ended records the result of a board, the host moves on if it was the board they were at
+func (h *SimulHub) Ended(matchID, result string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sim, board := h.board(matchID)
+	if board == nil || board.Result != "" {
+		return
+	}
+	board.Result = result
+	delete(h.boards, matchID)
+	h.publish(sim.ID, SimulEvent{Type: SimulBoardEnded, MatchID: matchID, Result: result})
+	if sim.CurrentBoard == matchID {
+		h.advance(sim)
+	}
+}
+
+// advance moves the host to the next unfinished board after the current one, or finishes the simul. h.mu must be held.
+func (h *SimulHub) advance(sim *Simul) {
+	current := slices.IndexFunc(sim.Boards, func(b SimulBoard) bool { return b.MatchID == sim.CurrentBoard })
+	for i := 1; i <= len(sim.Boards); i++ {
+		next := sim.Boards[(current+i)%len(sim.Boards)]
+		if next.Result == "" {
+			sim.CurrentBoard = next.MatchID
+			h.publish(sim.ID, SimulEvent{Type: SimulTurn, MatchID: next.MatchID})
+			return
+		}
+	}
+	sim.Status = "finished"
+	sim.CurrentBoard = ""
+	h.publish(sim.ID, SimulEvent{Type: SimulFinished})
+}
+
+// Subscribe opens the host's stream of the simul with id. The returned function closes it.
+// ok is false if the stream is already open, a host joining their boards twice would play themselves.
+// The stream's channel is closed if it falls too far behind.
+func (h *SimulHub) Subscribe(id string) (events chan SimulEvent, unsubscribe func(), ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, open := h.streams[id]; open {
+		return nil, nil, false
+	}
+	events = make(chan SimulEvent, SIMUL_BUFFER_SIZE)
+	h.streams[id] = events
+	return events, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if h.streams[id] == events {
+			delete(h.streams, id)
+		}
+	}, true
+}
+
+// publish sends e to the host's stream without blocking, the stream is closed if it is full. h.mu must be held.
+func (h *SimulHub) publish(id string, e SimulEvent) {
+	stream, ok := h.streams[id]
+	if !ok {
+		return
+	}
+	select {
+	case stream <- e:
+	default:
+		delete(h.streams, id)
+		close(stream)
+	}
+}
+
+// cleanup ends the boards whose match expired without a result and forgets old simuls
+func (h *SimulHub) cleanup(exists func(matchID string) bool) {
+	h.mu.Lock()
+	var expired []string
+	for matchID := range h.boards {
+		if !exists(matchID) {
+			expired = append(expired, matchID)
+		}
+	}
+	h.mu.Unlock()
+	for _, matchID := range expired {
+		h.Ended(matchID, "aborted")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for id, sim := range h.simuls {
+		if sim.Status != "playing" && time.Since(sim.CreatedAt) > SIMUL_LIFETIME {
+			delete(h.simuls, id)
+		}
+	}
+}
+
+// @Summary		Open a simul
+// @Description	In a simultaneous exhibition the host plays white against every challenger at once. Challengers join until the host starts the simul, then every challenger gets their own match.
+// @Description	The host goes from board to board in the order challengers joined, and can only move on the board they are at. Once they moved there they go on to the next board whose game is not over.
+// @Description	Challengers play their match like any other, the host follows every board on GET /simuls/{id}/stream.
+// @Description	Simuls are kept in memory, a simul is lost when the server restarts but its matches are not. Guests can only open casual simuls.
+// @Tags			simuls
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header		string				true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			payload			body		CreateSimulRequest	true	"name, how many challengers and how many hours each game can last"
+// @Success		201				{object}	Simul
+// @Failure		400				{object}	ErrorReason	"Invalid json body"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Guests cannot open rated simuls"
+// @Failure		500				{object}	ErrorReason
+// @Router			/simuls [post]
+func (s Server) CreateSimul(c echo.Context) error {
+	username := usernameOf(c)
+	if username == "" {
+		return c.JSON(http.StatusUnauthorized, REASON_UNAUTHORIZED)
+	}
+	var req CreateSimulRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	if req.Rated && isGuest(c) {
+		return c.JSON(http.StatusForbidden, Reason(CODE_GUESTS_CANNOT_RATED, "Guests cannot play rated matches"))
+	}
+	sim := &Simul{
+		ID:          rand.Text()[:8],
+		Name:        req.Name,
+		Host:        username,
+		Duration:    req.Duration,
+		Rated:       req.Rated,
+		MaxBoards:   req.Boards,
+		Status:      "open",
+		Challengers: []string{},
+		Boards:      []SimulBoard{},
+		CreatedAt:   time.Now().UTC(),
+	}
+	s.Simuls.add(sim)
+	return c.JSON(http.StatusCreated, sim.copy())
+}
+
+// @Summary	List the simuls that did not finish
+// @Tags		simuls
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Success	200				{array}		Simul	"newest first"
+// @Failure	401				{object}	ErrorReason
+// @Router		/simuls [get]
+func (s Server) ListSimuls(c echo.Context) error {
+	return c.JSON(http.StatusOK, s.Simuls.List())
+}
+
+// @Summary	Get a simul with its boards
+// @Tags		simuls
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param		id				path		string	true	"Simul ID"
+// @Success	200				{object}	Simul
+// @Failure	401				{object}	ErrorReason
+// @Failure	404				{object}	ErrorReason	"Simul not found"
+// @Router		/simuls/{id} [get]
+func (s Server) GetSimul(c echo.Context) error {
+	sim, ok := s.Simuls.Get(c.Param("id"))
+	if !ok {
+		return c.JSON(http.StatusNotFound, Reason(CODE_SIMUL_NOT_FOUND, "Simul not found"))
+	}
+	return c.JSON(http.StatusOK, sim)
+}
+
+// @Summary		Join a simul as a challenger
+// @Description	Simuls can be joined until the host starts them. Joining twice does nothing.
+// @Tags			simuls
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string	true	"Simul ID"
+// @Success		200				{object}	string	"joined"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Guests cannot join rated simuls / the host cannot join / blocked by the host"
+// @Failure		404				{object}	ErrorReason	"Simul not found"
+// @Failure		409				{object}	ErrorReason	"The simul already started / the simul is full"
+// @Failure		500				{object}	ErrorReason
+// @Router			/simuls/{id}/join [post]
+func (s Server) JoinSimul(c echo.Context) error {
+	username := usernameOf(c)
+	if username == "" {
+		return c.JSON(http.StatusUnauthorized, REASON_UNAUTHORIZED)
+	}
+	sim, ok := s.Simuls.Get(c.Param("id"))
+	if !ok {
+		return c.JSON(http.StatusNotFound, Reason(CODE_SIMUL_NOT_FOUND, "Simul not found"))
+	}
+	blocked, err := s.blockedBetween(c.Request().Context(), username, sim.Host)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	if blocked {
+		return c.JSON(http.StatusForbidden, Reason(CODE_BLOCKED, "You cannot join this simul"))
+	}
+	err = s.Simuls.change(sim.ID, func(sim *Simul) error {
+		switch {
+		case sim.Host == username:
+			return echo.NewHTTPError(http.StatusForbidden, Reason(CODE_INVALID_INPUT, "The host cannot be a challenger"))
+		case sim.Rated && isGuest(c):
+			return echo.NewHTTPError(http.StatusForbidden, Reason(CODE_GUESTS_CANNOT_RATED, "Guests cannot play rated matches"))
+		case sim.Status != "open":
+			return echo.NewHTTPError(http.StatusConflict, Reason(CODE_SIMUL_STARTED, "The simul already started"))
+		case slices.Contains(sim.Challengers, username):
+			return nil
+		case len(sim.Challengers) >= sim.MaxBoards:
+			return echo.NewHTTPError(http.StatusConflict, Reason(CODE_SIMUL_FULL, "The simul is full"))
+		}
+		sim.Challengers = append(sim.Challengers, username)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, "joined")
+}
+
+// @Summary		Leave a simul before it starts
+// @Description	Once the simul started, resign your match instead.
+// @Tags			simuls
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string	true	"Simul ID"
+// @Success		200				{object}	string	"left"
+// @Failure		401				{object}	ErrorReason
+// @Failure		404				{object}	ErrorReason	"Simul not found, or you did not join it"
+// @Failure		409				{object}	ErrorReason	"The simul already started"
+// @Router			/simuls/{id}/join [delete]
+func (s Server) LeaveSimul(c echo.Context) error {
+	username := usernameOf(c)
+	if username == "" {
+		return c.JSON(http.StatusUnauthorized, REASON_UNAUTHORIZED)
+	}
+	err := s.Simuls.change(c.Param("id"), func(sim *Simul) error {
+		if sim.Status != "open" {
+			return echo.NewHTTPError(http.StatusConflict, Reason(CODE_SIMUL_STARTED, "The simul already started"))
+		}
+		i := slices.Index(sim.Challengers, username)
+		if i < 0 {
+			return echo.NewHTTPError(http.StatusNotFound, Reason(CODE_NOT_IN_SIMUL, "You did not join this simul"))
+		}
+		sim.Challengers = slices.Delete(sim.Challengers, i, i+1)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, "left")
+}
+
+// @Summary		Start a simul
+// @Description	Only the host can start a simul, once at least one challenger joined. A match is created for every challenger, who gets a `simulStarted` notification with its id.
+// @Description	Challengers who don't join their match within 10 minutes lose their board.
+// @Tags			simuls
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string	true	"Simul ID"
+// @Success		200				{object}	Simul
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"You are not the host"
+// @Failure		404				{object}	ErrorReason	"Simul not found"
+// @Failure		409				{object}	ErrorReason	"The simul already started / nobody joined"
+// @Failure		500				{object}	ErrorReason
+// @Router			/simuls/{id}/start [post]
+func (s Server) StartSimul(c echo.Context) error {
+	username := usernameOf(c)
+	if username == "" {
+		return c.JSON(http.StatusUnauthorized, REASON_UNAUTHORIZED)
+	}
+	ctx := c.Request().Context()
+	var started Simul
+	err := s.Simuls.change(c.Param("id"), func(sim *Simul) error {
+		switch {
+		case sim.Host != username:
+			return echo.NewHTTPError(http.StatusForbidden, Reason(CODE_NOT_SIMUL_HOST, "Only the host can start the simul"))
+		case sim.Status != "open":
+			return echo.NewHTTPError(http.StatusConflict, Reason(CODE_SIMUL_STARTED, "The simul already started"))
+		case len(sim.Challengers) == 0:
+			return echo.NewHTTPError(http.StatusConflict, Reason(CODE_NOT_ENOUGH_PLAYERS, "Nobody joined the simul"))
+		}
+		boards := make([]SimulBoard, 0, len(sim.Challengers))
+		for _, challenger := range sim.Challengers {
+			match, err := s.GameStorage.NewScheduledMatch(ctx, "", time.Duration(sim.Duration)*time.Hour, sim.Rated, SIMUL_JOIN_GRACE)
+			if err != nil {
+				for _, b := range boards {
+					s.GameStorage.DeleteMatch(b.MatchID)
+				}
+				slog.Error("failed to create simul board", "simul", sim.ID, "error", err)
+				return echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+			}
+			boards = append(boards, SimulBoard{Challenger: challenger, MatchID: match.ID})
+		}
+		s.Simuls.start(sim, boards)
+		started = sim.copy()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, b := range started.Boards {
+		s.notifyUsername(ctx, b.Challenger, NotifySimulStarted, started.Host, b.MatchID)
+	}
+	return c.JSON(http.StatusOK, started)
+}
+
+// @Summary		Play every board of a simul as its host
+// @Description	Joins every board of the simul that is not over and sends the events of all of them on one stream.
+// @Description	## On success the server will send `SSE` messages whose payloads are JSON.
+// @Description	The first event is the whole simul. The events of each board come as `board` events with the match id, a `turn` event says on which board the host plays next.
+// @Description	Moves are played with PUT /matches/{id} like in any match, on the board of the last `turn` event.
+// @Description	Closing the stream does not resign any game, open it again to continue.
+// @Tags			simuls
+// @Produce		event-stream
+// @Param			Authorization	header		string		true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string		true	"Simul ID"
+// @Success		200				{object}	SimulEvent	"SSE stream — each `data:` payload is a SimulEvent"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"You are not the host"
+// @Failure		404				{object}	ErrorReason	"Simul not found"
+// @Failure		409				{object}	ErrorReason	"The simul did not start / it is already streamed"
+// @Failure		429				{object}	ErrorReason	"Too many open streams"
+// @Failure		503				{object}	ErrorReason	"Server is restarting"
+// @Router			/simuls/{id}/stream [get]
+func (s Server) StreamSimul(c echo.Context) error {
+	username := usernameOf(c)
+	if username == "" {
+		return c.JSON(http.StatusUnauthorized, REASON_UNAUTHORIZED)
+	}
+	if s.Draining() {
+		return c.JSON(http.StatusServiceUnavailable, REASON_SHUTTING_DOWN)
+	}
+	id := c.Param("id")
+	sim, ok := s.Simuls.Get(id)
+	switch {
+	case !ok:
+		return c.JSON(http.StatusNotFound, Reason(CODE_SIMUL_NOT_FOUND, "Simul not found"))
+	case sim.Host != username:
+		return c.JSON(http.StatusForbidden, Reason(CODE_NOT_SIMUL_HOST, "Only the host can follow every board"))
+	case sim.Status == "open":
+		return c.JSON(http.StatusConflict, Reason(CODE_SIMUL_STARTED, "The simul did not start yet"))
+	}
+	disconnect, ok := s.Presence.ConnectLimited(username, "", s.MaxStreamsPerUser)
+	if !ok {
+		return c.JSON(http.StatusTooManyRequests, REASON_TOO_MANY_STREAMS)
+	}
+	defer disconnect()
+	simulEvents, unsubscribe, ok := s.Simuls.Subscribe(id)
+	if !ok {
+		return c.JSON(http.StatusConflict, Reason(CODE_SIMUL_STREAMED, "The simul is already streamed, close the other stream first"))
+	}
+	defer unsubscribe()
+
+	ctx, cancel := context.WithCancel(c.Request().Context())
+	boardEvents := make(chan SimulEvent, SIMUL_BUFFER_SIZE)
+	// the boards are left before the stream is closed
+	var following sync.WaitGroup
+	defer following.Wait()
+	defer cancel()
+	for _, b := range sim.Boards {
+		match, ok := s.GameStorage.GetMatch(b.MatchID)
+		if b.Result != "" || !ok {
+			continue
+		}
+		following.Add(1)
+		go func() {
+			defer following.Done()
+			s.followSimulBoard(ctx, username, match, boardEvents)
+		}()
+	}
+	// the simul may have changed while the boards were joined
+	sim, _ = s.Simuls.Get(id)
+
+	startSSE(c)
+	w := c.Response()
+	if err := writeSSE(w, SimulEvent{Type: SimulState, Simul: &sim}); err != nil || sim.Status == "finished" {
+		return nil
+	}
+	ticker := time.NewTicker(SSE_KEEP_ALIVE_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := writeSSEKeepAlive(w); err != nil {
+				return nil
+			}
+		case e := <-boardEvents:
+			if err := writeSSE(w, e); err != nil {
+				return nil
+			}
+		case e, ok := <-simulEvents:
+			if !ok {
+				writeSSE(w, SimulEvent{Type: SimulResync})
+				return nil
+			}
+			if err := writeSSE(w, e); err != nil || e.Type == SimulFinished {
+				return nil
+			}
+		case <-s.lifecycle.drained:
+			writeSSE(w, SimulEvent{Type: SimulServerRestarting})
+			return nil
+		}
+	}
+}
+
+// followSimulBoard joins match as the host and sends its events to out until ctx is cancelled or the game ends.
+// The host keeps their seat when it returns, like a player who left.
+func (s Server) followSimulBoard(ctx context.Context, host string, match *game.Match, out chan<- SimulEvent) {
+	disconnect := s.Presence.Connect(host, match.ID)
+	defer disconnect()
+	player, ok := match.Join(host, chess.White)
+	if !ok {
+		return
+	}
+	defer match.Leave(player)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-player.Events:
+			select {
+			case out <- SimulEvent{Type: SimulBoardEvent, MatchID: match.ID, Event: &e}:
+			case <-ctx.Done():
+				return
+			}
+			// a resync is forwarded with the board's position, the board's events continue after it
+			switch e.Type {
+			case game.Resign, game.Aborted, game.Adjudicated, game.ServerRestarting:
+				return
+			}
+		}
+	}
+}
+
+// simulMoved moves the host of a simul on to their next board, see moved.
+// The host plays white, so they played the moves of odd plies.
+func (s Server) simulMoved(m *game.Match, ply int) {
+	s.Simuls.Moved(m.ID, ply%2 == 1)
+}
+
+// simulGameOver records the result of m if it is a simul board, see gameOver
+func (s Server) simulGameOver(m *game.Match) {
+	s.Simuls.Ended(m.ID, resultFromOutcome(m.Outcome()))
+}
@@ -0,0 +1,81 @@
+// helpers for server sent events
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// how often a keep-alive comment is sent on event streams
+const SSE_KEEP_ALIVE_INTERVAL = 10 * time.Second
+
+// startSSE writes the headers of an event stream
+func startSSE(c echo.Context) {
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	w.Flush()
+}
+
+// buffers events are encoded into, reused between events and streams
+var sseBuffers = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// buffers that grew larger than this are not reused, so one huge event doesn't stay in memory
+const MAX_POOLED_SSE_BUFFER = 64 << 10
+
+// writeSSE sends v as the JSON payload of an event.
+// The error is only non-nil if the client disconnected.
+func writeSSE(w *echo.Response, v any) error {
+	buf := sseBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= MAX_POOLED_SSE_BUFFER {
+			sseBuffers.Put(buf)
+		}
+	}()
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		// don't break the stream — log and continue
+		slog.Warn("Failed to marshal event", "error", err)
+		return nil
+	}
+	// Encode ends with a newline, the frame adds its own
+	return writeSSEData(w, bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
+
+var (
+	sseDataPrefix = []byte("data: ")
+	sseFrameEnd   = []byte("\n\n")
+)
+
+// writeSSEData sends an event whose JSON payload is already encoded.
+// The frame is written straight to the response, without copying the payload.
+func writeSSEData(w *echo.Response, payload []byte) error {
+	if _, err := w.Write(sseDataPrefix); err != nil {
+		return err
+	}
+	if _, err := w.Write(payload); err != nil {
+		return err
+	}
+	if _, err := w.Write(sseFrameEnd); err != nil {
+		return err
+	}
+	w.Flush()
+	return nil
+}
+
+// writeSSEKeepAlive sends a comment so proxies don't close the stream.
+func writeSSEKeepAlive(w *echo.Response) error {
+	if _, err := w.Write([]byte(": keep-alive\n\n")); err != nil {
+		return err
+	}
+	w.Flush()
+	return nil
+}
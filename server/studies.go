@@ -0,0 +1,729 @@
+// shared analysis boards, where members explore moves, variations and comments together
+package server
+
+import (
+	"api/db"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notnil/chess"
+)
+
+const (
+	// people a study can be shared with, besides its owner
+	MAX_STUDY_MEMBERS = 30
+	// positions a study can have, counting every variation
+	MAX_STUDY_POSITIONS = 2000
+	// events a stream can fall behind by before it is closed
+	STUDY_BUFFER_SIZE = 32
+)
+
+// StudyNode is a position of a study, reached by playing Move in the position before it
+type StudyNode struct {
+	// UCI notation, empty at the start position
+	Move string `json:"move,omitempty" example:"e2e4"`
+	// the move in algebraic notation
+	SAN     string `json:"san,omitempty" example:"e4"`
+	FEN     string `json:"fen" example:"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"`
+	Comment string `json:"comment,omitempty" example:"The most popular first move"`
+	// moves played from this position. The first one is the main line, the others are variations.
+	Children []*StudyNode `json:"children,omitempty"`
+}
+
+// Study is an analysis board shared by its owner with the members
+type Study struct {
+	ID      string   `json:"id" example:"K7ZD4F2Q"`
+	Name    string   `json:"name" example:"Sicilian lesson"`
+	Owner   string   `json:"owner" example:"JohnDoe"`
+	Members []string `json:"members"`
+	// the start position, with every move played from it
+	Root      *StudyNode `json:"root"`
+	CreatedAt time.Time  `json:"createdAt" format:"date-time"`
+	UpdatedAt time.Time  `json:"updatedAt" format:"date-time"`
+}
+
+// StudySummary is a study without its moves
+type StudySummary struct {
+	ID        string    `json:"id" example:"K7ZD4F2Q"`
+	Name      string    `json:"name" example:"Sicilian lesson"`
+	Owner     string    `json:"owner" example:"JohnDoe"`
+	CreatedAt time.Time `json:"createdAt" format:"date-time"`
+	UpdatedAt time.Time `json:"updatedAt" format:"date-time"`
+}
+
+type CreateStudyRequest struct {
+	Name string `json:"name" maxLength:"100" example:"Sicilian lesson" validate:"notblank,max=100"`
+	// position the study starts from, the initial position if empty
+	FEN string `json:"fen" example:"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 1"`
+}
+
+type StudyMoveRequest struct {
+	// moves from the start position to the position to play in, empty for the start position
+	Path []string `json:"path" example:"e2e4,c7c5" validate:"max=1000"`
+	// UCI notation. If it was played in that position before, the existing move is used.
+	Move string `json:"move" example:"g1f3" validate:"required"`
+}
+
+type StudyUndoRequest struct {
+	// moves from the start position to the move to take back
+	Path []string `json:"path" example:"e2e4,c7c5" validate:"min=1,max=1000"`
+}
+
+type StudyCommentRequest struct {
+	// moves from the start position to the position to comment, empty for the start position
+	Path []string `json:"path" example:"e2e4,c7c5" validate:"max=1000"`
+	// empty to remove the comment
+	Comment string `json:"comment" maxLength:"1000" example:"The Sicilian Defence" validate:"max=1000"`
+}
+
+type StudyEventType string
+
+const (
+	// the whole study, the first event of a stream
+	StudyState StudyEventType = "study"
+	// a move was played, Node is the position after it
+	StudyMovePlayed StudyEventType = "move"
+	// the move at the end of Path was taken back, along with every move after it
+	StudyMoveUndone StudyEventType = "undo"
+	// the comment of the position at the end of Path changed, Node is the position
+	StudyCommented StudyEventType = "comment"
+	// the owner invited Member
+	StudyMemberAdded StudyEventType = "memberAdded"
+	// Member was removed or left, their stream ends
+	StudyMemberRemoved StudyEventType = "memberRemoved"
+	// the owner deleted the study, the stream ends
+	StudyDeleted StudyEventType = "deleted"
+	// the stream fell behind and ends, open it again to get the study
+	StudyResync StudyEventType = "resync"
+	// the server is shutting down, open the stream again in a moment
+	StudyServerRestarting StudyEventType = "serverRestarting"
+)
+
+// StudyEvent is a change to a study, sent on its stream. Each type only uses some of the fields.
+type StudyEvent struct {
+	Type StudyEventType `json:"type" example:"move"`
+	// username of whoever made the change
+	By string `json:"by,omitempty" example:"JohnDoe"`
+	// moves from the start position to the changed position, empty for the start position
+	Path []string   `json:"path,omitempty" example:"e2e4,c7c5"`
+	Node *StudyNode `json:"node,omitempty"`
+	// the member who was added or removed
+	Member string `json:"member,omitempty" example:"JaneDoe"`
+	Study  *Study `json:"study,omitempty"`
+}
+
+// StudyHub delivers the changes of studies to their open streams.
+// Changes are made one at a time, so every stream sees them in the same order.
+type StudyHub struct {
+	// held while a study is changed and the change is published
+	editing sync.Mutex
+
+	mu sync.Mutex
+	// study id -> open streams
+	streams map[string]map[chan StudyEvent]struct{}
+}
+
+func NewStudyHub() *StudyHub {
+	return &StudyHub{
+		streams: map[string]map[chan StudyEvent]struct{}{},
+	}
+}
+
+// Subscribe opens a stream of the study with id. The returned function closes it.
+// The stream's channel is closed if it falls too far behind.
+func (h *StudyHub) Subscribe(id string) (events chan StudyEvent, unsubscribe func()) {
+	events = make(chan StudyEvent, STUDY_BUFFER_SIZE)
+	h.mu.Lock()
+	if h.streams[id] == nil {
+		h.streams[id] = map[chan StudyEvent]struct{}{}
+	}
+	h.streams[id][events] = struct{}{}
+	h.mu.Unlock()
+	return events, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(id, events)
+	}
+}
+
+// Publish sends e to every stream of the study without blocking. Streams that are full are closed.
+func (h *StudyHub) Publish(id string, e StudyEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for stream := range h.streams[id] {
+		select {
+		case stream <- e:
+		default:
+			h.remove(id, stream)
+			close(stream)
+		}
+	}
+}
+
+// remove forgets stream, h.mu must be held
+func (h *StudyHub) remove(id string, stream chan StudyEvent) {
+	delete(h.streams[id], stream)
+	if len(h.streams[id]) == 0 {
+		delete(h.streams, id)
+	}
+}
+
+// the path of a request goes through moves that were not played
+var errPositionNotFound = echo.NewHTTPError(http.StatusNotFound, Reason(CODE_POSITION_NOT_FOUND, "the moves of path were not played in the study"))
+
+// find is the position at the end of path, nil if the moves were not played
+func (n *StudyNode) find(path []string) *StudyNode {
+	for _, move := range path {
+		i := slices.IndexFunc(n.Children, func(c *StudyNode) bool { return c.Move == move })
+		if i < 0 {
+			return nil
+		}
+		n = n.Children[i]
+	}
+	return n
+}
+
+// count is the number of positions from n on
+func (n *StudyNode) count() int {
+	total := 1
+	for _, c := range n.Children {
+		total += c.count()
+	}
+	return total
+}
+
+// play adds move, in UCI notation, after n. If it was played before the existing position is returned and added is false.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (n *StudyNode) play(move string) (child *StudyNode, added bool, err error) {
+	if i := slices.IndexFunc(n.Children, func(c *StudyNode) bool { return c.Move == move }); i >= 0 {
+		return n.Children[i], false, nil
+	}
+	fen, err := chess.FEN(n.FEN)
+	if err != nil {
+		return nil, false, err
+	}
+	position := chess.NewGame(fen).Position()
+	for _, valid := range position.ValidMoves() {
+		if valid.String() != move {
+			continue
+		}
+		child = &StudyNode{
+			Move: move,
+			SAN:  chess.AlgebraicNotation{}.Encode(position, valid),
+			FEN:  position.Update(valid).String(),
+		}
+		n.Children = append(n.Children, child)
+		return child, true, nil
+	}
+	if _, err := (chess.UCINotation{}).Decode(position, move); err != nil {
+		return nil, false, echo.NewHTTPError(http.StatusBadRequest, Reason(CODE_INVALID_MOVE_NOTATION, "move must be in UCI notation, eg. e2e4"))
+	}
+	return nil, false, echo.NewHTTPError(http.StatusUnprocessableEntity, Reason(CODE_ILLEGAL_MOVE, "illegal move"))
+}
+
+func studyFromDb(row db.GetStudyRow, members []string) (Study, error) {
+	st := Study{
+		ID:        row.ID,
+		Name:      row.Name,
+		Owner:     row.Owner,
+		Members:   members,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	}
+	return st, json.Unmarshal([]byte(row.Tree), &st.Root)
+}
+
+// studyOf is the study with id, if username owns it or is a member.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (s Server) studyOf(ctx context.Context, id, username string) (Study, error) {
+	notFound := echo.NewHTTPError(http.StatusNotFound, Reason(CODE_STUDY_NOT_FOUND, "study not found"))
+	row, err := s.DB.GetStudy(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Study{}, notFound
+	}
+	if err != nil {
+		slog.Error("failed to get study", "study", id, "error", err)
+		return Study{}, echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	members, err := s.DB.ListStudyMembers(ctx, id)
+	if err != nil {
+		slog.Error("failed to list study members", "study", id, "error", err)
+		return Study{}, echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	if row.Owner != username && !slices.Contains(members, username) {
+		return Study{}, notFound
+	}
+	st, err := studyFromDb(row, members)
+	if err != nil {
+		slog.Error("failed to read the moves of a study", "study", id, "error", err)
+		return Study{}, echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return st, nil
+}
+
+// editStudy changes the moves of the study with id with change, saves them and publishes the event change returns.
+// Nothing is saved or published if change reports that nothing changed. username must own the study or be a member.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (s Server) editStudy(ctx context.Context, id, username string, change func(root *StudyNode) (e StudyEvent, changed bool, err error)) (StudyEvent, error) {
+	s.Studies.editing.Lock()
+	defer s.Studies.editing.Unlock()
+	st, err := s.studyOf(ctx, id, username)
+	if err != nil {
+		return StudyEvent{}, err
+	}
+	e, changed, err := change(st.Root)
+	e.By = username
+	if err != nil || !changed {
+		return e, err
+	}
+	tree, err := json.Marshal(st.Root)
+	if err != nil {
+		return StudyEvent{}, err
+	}
+	err = s.DB.UpdateStudyTree(ctx, db.UpdateStudyTreeParams{Tree: string(tree), UpdatedAt: time.Now().UTC(), ID: id})
+	if err != nil {
+		slog.Error("failed to save study", "study", id, "error", err)
+		return StudyEvent{}, echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	s.Studies.Publish(id, e)
+	return e, nil
+}
+
+// @Summary		Create a study
+// @Description	A study is an analysis board without turns or players. Everyone the owner invites can play moves for either side, add variations, take moves back and comment positions.
+// @Description	Changes are sent to everyone on GET /studies/{id}/stream, which makes studies useful for coaching.
+// @Tags			studies
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header		string				true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			payload			body		CreateStudyRequest	true	"name and start position"
+// @Success		201				{object}	Study
+// @Failure		400				{object}	ErrorReason	"Invalid json body / invalid FEN"
+// @Failure		401				{object}	ErrorReason
+// @Failure		500				{object}	ErrorReason
+// @Router			/studies [post]
+func (s Server) CreateStudy(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	var req CreateStudyRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	root := &StudyNode{FEN: chess.StartingPosition().String()}
+	if req.FEN != "" {
+		fen, err := chess.FEN(req.FEN)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, "fen is not a valid FEN"))
+		}
+		root.FEN = chess.NewGame(fen).Position().String()
+	}
+	tree, err := json.Marshal(root)
+	if err != nil {
+		return err
+	}
+	created, err := s.DB.CreateStudy(c.Request().Context(), db.CreateStudyParams{
+		ID:       rand.Text()[:8],
+		OwnerUid: user.Uid,
+		Name:     req.Name,
+		Tree:     string(tree),
+	})
+	if err != nil {
+		slog.Error("failed to create study", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return c.JSON(http.StatusCreated, Study{
+		ID:        created.ID,
+		Name:      created.Name,
+		Owner:     user.Username,
+		Members:   []string{},
+		Root:      root,
+		CreatedAt: created.CreatedAt,
+		UpdatedAt: created.UpdatedAt,
+	})
+}
+
+// @Summary		List your studies
+// @Description	Studies you own or were invited to, recently changed first.
+// @Tags			studies
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Success		200				{array}		StudySummary
+// @Failure		401				{object}	ErrorReason
+// @Failure		500				{object}	ErrorReason
+// @Router			/studies [get]
+func (s Server) ListStudies(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	rows, err := s.DB.ListStudiesOfUser(c.Request().Context(), user.Uid)
+	if err != nil {
+		slog.Error("failed to list studies", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	studies := make([]StudySummary, len(rows))
+	for i, r := range rows {
+		studies[i] = StudySummary{ID: r.ID, Name: r.Name, Owner: r.Owner, CreatedAt: r.CreatedAt, UpdatedAt: r.UpdatedAt}
+	}
+	return c.JSON(http.StatusOK, studies)
+}
+
+// @Summary	Get a study with all its moves
+// @Tags		studies
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param		id				path		string	true	"Study ID"
+// @Success	200				{object}	Study
+// @Failure	401				{object}	ErrorReason
+// @Failure	404				{object}	ErrorReason	"Study not found, or you are not a member"
+// @Failure	500				{object}	ErrorReason
+// @Router		/studies/{id} [get]
+func (s Server) GetStudy(c echo.Context) error {
+	username := usernameOf(c)
+	if username == "" {
+		return c.JSON(http.StatusUnauthorized, REASON_UNAUTHORIZED)
+	}
+	st, err := s.studyOf(c.Request().Context(), c.Param("id"), username)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, st)
+}
+
+// @Summary	Delete a study
+// @Tags		studies
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param		id				path		string	true	"Study ID"
+// @Success	200				{object}	string	"deleted"
+// @Failure	401				{object}	ErrorReason
+// @Failure	404				{object}	ErrorReason	"Study not found, or you don't own it"
+// @Failure	500				{object}	ErrorReason
+// @Router		/studies/{id} [delete]
+func (s Server) DeleteStudy(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	id := c.Param("id")
+	s.Studies.editing.Lock()
+	defer s.Studies.editing.Unlock()
+	deleted, err := s.DB.DeleteStudy(c.Request().Context(), db.DeleteStudyParams{ID: id, OwnerUid: user.Uid})
+	if err != nil {
+		slog.Error("failed to delete study", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	if deleted == 0 {
+		return c.JSON(http.StatusNotFound, Reason(CODE_STUDY_NOT_FOUND, "study not found"))
+	}
+	s.Studies.Publish(id, StudyEvent{Type: StudyDeleted, By: user.Username})
+	return c.JSON(http.StatusOK, "deleted")
+}
+
+// @Summary	Invite a user to a study
+// @Tags		studies
+// @Produce	json
+// @Param		Authorization	header		string		true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param		id				path		string		true	"Study ID"
+// @Param		username		path		string		true	"user to invite"
+// @Success	201				{object}	string		"added"
+// @Success	200				{object}	string		"already a member"
+// @Failure	400				{object}	ErrorReason	"Cannot invite yourself"
+// @Failure	401				{object}	ErrorReason
+// @Failure	403				{object}	ErrorReason	"Not the owner / blocked"
+// @Failure	404				{object}	ErrorReason	"Study or user not found"
+// @Failure	409				{object}	ErrorReason	"Too many members"
+// @Failure	500				{object}	ErrorReason
+// @Router		/studies/{id}/members/{username} [post]
+func (s Server) AddStudyMember(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	other, err := s.userFromParam(c)
+	if err != nil {
+		return err
+	}
+	if other.Uid == user.Uid {
+		return c.JSON(http.StatusBadRequest, Reason(CODE_CANNOT_TARGET_SELF, "You own the study"))
+	}
+	ctx := c.Request().Context()
+	s.Studies.editing.Lock()
+	defer s.Studies.editing.Unlock()
+	st, err := s.studyOf(ctx, c.Param("id"), user.Username)
+	if err != nil {
+		return err
+	}
+	if st.Owner != user.Username {
+		return c.JSON(http.StatusForbidden, Reason(CODE_NOT_STUDY_OWNER, "Only the owner can invite people"))
+	}
+	if slices.Contains(st.Members, other.Username) {
+		return c.JSON(http.StatusOK, "already a member")
+	}
+	if len(st.Members) >= MAX_STUDY_MEMBERS {
+		return c.JSON(http.StatusConflict, Reason(CODE_STUDY_FULL, fmt.Sprintf("A study can have at most %d members", MAX_STUDY_MEMBERS)))
+	}
+	blocked, err := s.blockedBetween(ctx, user.Username, other.Username)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	if blocked {
+		return c.JSON(http.StatusForbidden, Reason(CODE_BLOCKED, "You cannot invite this user"))
+	}
+	if _, err := s.DB.AddStudyMember(ctx, db.AddStudyMemberParams{StudyID: st.ID, Uid: other.Uid}); err != nil {
+		slog.Error("failed to add study member", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	s.Studies.Publish(st.ID, StudyEvent{Type: StudyMemberAdded, By: user.Username, Member: other.Username})
+	return c.JSON(http.StatusCreated, "added")
+}
+
+// @Summary		Remove a member from a study
+// @Description	The owner can remove anyone, members can remove themselves to leave the study.
+// @Tags			studies
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string	true	"Study ID"
+// @Param			username		path		string	true	"member to remove"
+// @Success		200				{object}	string	"removed"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Not the owner"
+// @Failure		404				{object}	ErrorReason	"Study not found / not a member"
+// @Failure		500				{object}	ErrorReason
+// @Router			/studies/{id}/members/{username} [delete]
+func (s Server) RemoveStudyMember(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	member := c.Param("username")
+	ctx := c.Request().Context()
+	s.Studies.editing.Lock()
+	defer s.Studies.editing.Unlock()
+	st, err := s.studyOf(ctx, c.Param("id"), user.Username)
+	if err != nil {
+		return err
+	}
+	if st.Owner != user.Username && member != user.Username {
+		return c.JSON(http.StatusForbidden, Reason(CODE_NOT_STUDY_OWNER, "Only the owner can remove other members"))
+	}
+	if !slices.Contains(st.Members, member) {
+		return c.JSON(http.StatusNotFound, Reason(CODE_USER_NOT_FOUND, "Not a member of the study"))
+	}
+	other, err := s.DB.GetUserByUsername(ctx, member)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	if _, err := s.DB.RemoveStudyMember(ctx, db.RemoveStudyMemberParams{StudyID: st.ID, Uid: other.Uid}); err != nil {
+		slog.Error("failed to remove study member", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	s.Studies.Publish(st.ID, StudyEvent{Type: StudyMemberRemoved, By: user.Username, Member: member})
+	return c.JSON(http.StatusOK, "removed")
+}
+
+// @Summary		Play a move in a study
+// @Description	Plays a move in the position at the end of `path`, for whichever side is to move.
+// @Description	The first move played in a position is its main line, later ones are variations. Playing a move that was played before changes nothing.
+// @Tags			studies
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header		string				true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string				true	"Study ID"
+// @Param			payload			body		StudyMoveRequest	true	"position and move"
+// @Success		200				{object}	StudyEvent			"the move event sent to the stream"
+// @Failure		400				{object}	ErrorReason			"Invalid json body / invalid move notation"
+// @Failure		401				{object}	ErrorReason
+// @Failure		404				{object}	ErrorReason	"Study or position not found"
+// @Failure		409				{object}	ErrorReason	"Too many positions"
+// @Failure		422				{object}	ErrorReason	"Illegal move"
+// @Failure		500				{object}	ErrorReason
+// @Router			/studies/{id}/moves [post]
+func (s Server) PlayStudyMove(c echo.Context) error {
+	username := usernameOf(c)
+	if username == "" {
+		return c.JSON(http.StatusUnauthorized, REASON_UNAUTHORIZED)
+	}
+	var req StudyMoveRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	e, err := s.editStudy(c.Request().Context(), c.Param("id"), username, func(root *StudyNode) (StudyEvent, bool, error) {
+		position := root.find(req.Path)
+		if position == nil {
+			return StudyEvent{}, false, errPositionNotFound
+		}
+		if position.find([]string{req.Move}) == nil && root.count() >= MAX_STUDY_POSITIONS {
+			return StudyEvent{}, false, echo.NewHTTPError(http.StatusConflict, Reason(CODE_STUDY_FULL, fmt.Sprintf("A study can have at most %d positions", MAX_STUDY_POSITIONS)))
+		}
+		node, added, err := position.play(req.Move)
+		if err != nil {
+			return StudyEvent{}, false, err
+		}
+		return StudyEvent{Type: StudyMovePlayed, Path: append(slices.Clip(req.Path), req.Move), Node: node}, added, nil
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, e)
+}
+
+// @Summary		Take back a move in a study
+// @Description	Removes the move at the end of `path`, with every move played after it.
+// @Tags			studies
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header		string				true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string				true	"Study ID"
+// @Param			payload			body		StudyUndoRequest	true	"move to take back"
+// @Success		200				{object}	StudyEvent			"the undo event sent to the stream"
+// @Failure		400				{object}	ErrorReason			"Invalid json body"
+// @Failure		401				{object}	ErrorReason
+// @Failure		404				{object}	ErrorReason	"Study or move not found"
+// @Failure		500				{object}	ErrorReason
+// @Router			/studies/{id}/undo [post]
+func (s Server) UndoStudyMove(c echo.Context) error {
+	username := usernameOf(c)
+	if username == "" {
+		return c.JSON(http.StatusUnauthorized, REASON_UNAUTHORIZED)
+	}
+	var req StudyUndoRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	e, err := s.editStudy(c.Request().Context(), c.Param("id"), username, func(root *StudyNode) (StudyEvent, bool, error) {
+		parent := root.find(req.Path[:len(req.Path)-1])
+		if parent == nil || parent.find(req.Path[len(req.Path)-1:]) == nil {
+			return StudyEvent{}, false, errPositionNotFound
+		}
+		parent.Children = slices.DeleteFunc(parent.Children, func(n *StudyNode) bool { return n.Move == req.Path[len(req.Path)-1] })
+		return StudyEvent{Type: StudyMoveUndone, Path: req.Path}, true, nil
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, e)
+}
+
+// @Summary	Comment a position of a study
+// @Tags		studies
+// @Accept		json
+// @Produce	json
+// @Param		Authorization	header		string				true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param		id				path		string				true	"Study ID"
+// @Param		payload			body		StudyCommentRequest	true	"position and comment"
+// @Success	200				{object}	StudyEvent			"the comment event sent to the stream"
+// @Failure	400				{object}	ErrorReason			"Invalid json body"
+// @Failure	401				{object}	ErrorReason
+// @Failure	404				{object}	ErrorReason	"Study or position not found"
+// @Failure	500				{object}	ErrorReason
+// @Router		/studies/{id}/comments [put]
+func (s Server) CommentStudyPosition(c echo.Context) error {
+	username := usernameOf(c)
+	if username == "" {
+		return c.JSON(http.StatusUnauthorized, REASON_UNAUTHORIZED)
+	}
+	var req StudyCommentRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	comment, _ := s.WordFilter.Mask(req.Comment)
+	e, err := s.editStudy(c.Request().Context(), c.Param("id"), username, func(root *StudyNode) (StudyEvent, bool, error) {
+		position := root.find(req.Path)
+		if position == nil {
+			return StudyEvent{}, false, errPositionNotFound
+		}
+		position.Comment = comment
+		// the event carries the position without the moves after it
+		commented := *position
+		commented.Children = nil
+		return StudyEvent{Type: StudyCommented, Path: req.Path, Node: &commented}, true, nil
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, e)
+}
+
+// @Summary		Receive the changes of a study as they happen
+// @Description	## On success the server will send `SSE` messages whose payloads are JSON study events.
+// @Description	The first event is the whole study, the ones after it are the changes made since.
+// @Description	The stream ends after a `deleted` event, a `memberRemoved` event for you, or a `resync` event if you fell behind.
+// @Tags			studies
+// @Produce		event-stream
+// @Param			Authorization	header		string		true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string		true	"Study ID"
+// @Success		200				{object}	StudyEvent	"SSE stream — each `data:` payload is a study event (Content-Type: text/event-stream)."
+// @Failure		401				{object}	ErrorReason
+// @Failure		404				{object}	ErrorReason	"Study not found, or you are not a member"
+// @Failure		429				{object}	ErrorReason	"Too many open streams"
+// @Router			/studies/{id}/stream [get]
+func (s Server) StreamStudy(c echo.Context) error {
+	username := usernameOf(c)
+	if username == "" {
+		return c.JSON(http.StatusUnauthorized, REASON_UNAUTHORIZED)
+	}
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	// no change can be made between reading the study and subscribing, so none is missed or sent twice
+	s.Studies.editing.Lock()
+	st, err := s.studyOf(ctx, id, username)
+	if err != nil {
+		s.Studies.editing.Unlock()
+		return err
+	}
+	events, unsubscribe := s.Studies.Subscribe(id)
+	s.Studies.editing.Unlock()
+	defer unsubscribe()
+
+	disconnect, ok := s.Presence.ConnectLimited(username, "", s.MaxStreamsPerUser)
+	if !ok {
+		return c.JSON(http.StatusTooManyRequests, REASON_TOO_MANY_STREAMS)
+	}
+	defer disconnect()
+	startSSE(c)
+	w := c.Response()
+	if err := writeSSE(w, StudyEvent{Type: StudyState, Study: &st}); err != nil {
+		return nil
+	}
+
+	ticker := time.NewTicker(SSE_KEEP_ALIVE_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := writeSSEKeepAlive(w); err != nil {
+				return nil
+			}
+		case e, ok := <-events:
+			if !ok {
+				writeSSE(w, StudyEvent{Type: StudyResync})
+				return nil
+			}
+			if err := writeSSE(w, e); err != nil {
+				return nil
+			}
+			if e.Type == StudyDeleted || (e.Type == StudyMemberRemoved && e.Member == username) {
+				return nil
+			}
+		case <-s.lifecycle.drained:
+			writeSSE(w, StudyEvent{Type: StudyServerRestarting})
+			return nil
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Swiss pairing of tournament rounds
+package server
+
+import (
+	"api/db"
+	"slices"
+)
+
+// how many pairings the search for a round without rematches tries before it allows them
+const SWISS_PAIRING_BUDGET = 100_000
+
+// swissPlayer is a player of a tournament with the scores of the rounds played so far
+type swissPlayer struct {
+	uid       int64
+	username  string
+	withdrawn bool
+	// in half points, a win or a bye is 2 and a draw is 1
+	points int
+	// sum of the points of the opponents, breaks ties
+	buchholz int
+	// uids of the players they played
+	opponents map[int64]bool
+	// games with white minus games with black
+	colorBalance int
+	hadBye       bool
+	// order they joined in, breaks the remaining ties
+	seed int
+}
+
+// swissPairing is a game of a round, black is nil for a bye
+type swissPairing struct {
+	white, black *swissPlayer
+}
+
+// standings scores players from the pairings of the rounds played so far and ranks them,
+// by points, then by buchholz, then by seed.
+func standings(players []db.ListTournamentPlayersRow, pairings []db.ListTournamentPairingsRow) []*swissPlayer {
+	byUid := make(map[int64]*swissPlayer, len(players))
+	ranked := make([]*swissPlayer, len(players))
+	for i, p := range players {
+		ranked[i] = &swissPlayer{uid: p.Uid, username: p.Username, withdrawn: p.Withdrawn, opponents: map[int64]bool{}, seed: i}
+		byUid[p.Uid] = ranked[i]
+	}
+	for _, p := range pairings {
+		white, black := byUid[p.WhiteUid], byUid[p.BlackUid]
+		if p.BlackUid == 0 {
+			if white != nil {
+				white.hadBye = true
+				white.points += 2
+			}
+			continue
+		}
+		// players who deleted their account are not in the tournament anymore
+		if white != nil {
+			white.colorBalance++
+			white.opponents[p.BlackUid] = true
+		}
+		if black != nil {
+			black.colorBalance--
+			black.opponents[p.WhiteUid] = true
+		}
+		switch {
+		case p.Result == "white" && white != nil:
+			white.points += 2
+		case p.Result == "black" && black != nil:
+			black.points += 2
+		case p.Result == "draw" && white != nil && black != nil:
+			white.points++
+			black.points++
+		}
+	}
+	for _, p := range ranked {
+		for uid := range p.opponents {
+			if o := byUid[uid]; o != nil {
+				p.buchholz += o.points
+			}
+		}
+	}
+	slices.SortStableFunc(ranked, func(a, b *swissPlayer) int {
+		if a.points != b.points {
+			return b.points - a.points
+		}
+		if a.buchholz != b.buchholz {
+			return b.buchholz - a.buchholz
+		}
+		return a.seed - b.seed
+	})
+	return ranked
+}
+
+// pairRound pairs the players who did not withdraw, ranked as standings returns them.
+// Players meet the highest ranked player they did not play yet. If that is impossible rematches are allowed.
+// With an odd number of players the lowest ranked one without a bye gets it.
+func pairRound(ranked []*swissPlayer) []swissPairing {
+	var active []*swissPlayer
+	for _, p := range ranked {
+		if !p.withdrawn {
+			active = append(active, p)
+		}
+	}
+	var pairings []swissPairing
+	if len(active)%2 == 1 {
+		bye := len(active) - 1
+		for i := len(active) - 1; i >= 0; i-- {
+			if !active[i].hadBye {
+				bye = i
+				break
+			}
+		}
+		pairings = append(pairings, swissPairing{white: active[bye]})
+		active = slices.Delete(slices.Clone(active), bye, bye+1)
+	}
+
+	budget := SWISS_PAIRING_BUDGET
+	games, ok := pairWithoutRematches(active, &budget)
+	if !ok {
+		// everyone played everyone, or the search took too long
+		games = nil
+		for i := 0; i+1 < len(active); i += 2 {
+			games = append(games, [2]*swissPlayer{active[i], active[i+1]})
+		}
+	}
+	for _, g := range games {
+		pairings = append(pairings, colors(g[0], g[1]))
+	}
+	return pairings
+}
+
+// pairWithoutRematches pairs the first player with the highest ranked one they did not play,
+// backtracking when the rest cannot be paired. It gives up once budget runs out.
+func pairWithoutRematches(players []*swissPlayer, budget *int) ([][2]*swissPlayer, bool) {
+	if len(players) == 0 {
+		return nil, true
+	}
+	first := players[0]
+	for i := 1; i < len(players); i++ {
+		if *budget <= 0 {
+			return nil, false
+		}
+		*budget--
+		if first.opponents[players[i].uid] {
+			continue
+		}
+		rest := make([]*swissPlayer, 0, len(players)-2)
+		rest = append(rest, players[1:i]...)
+		rest = append(rest, players[i+1:]...)
+		if games, ok := pairWithoutRematches(rest, budget); ok {
+			return append([][2]*swissPlayer{{first, players[i]}}, games...), true
+		}
+	}
+	return nil, false
+}
+
+// colors gives white to whoever played black more often, to the higher ranked player a if they played both equally
+func colors(a, b *swissPlayer) swissPairing {
+	if b.colorBalance < a.colorBalance {
+		return swissPairing{white: b, black: a}
+	}
+	return swissPairing{white: a, black: b}
+}
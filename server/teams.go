@@ -0,0 +1,703 @@
+// teams of players, with members only matches and tournaments
+package server
+
+import (
+	"api/db"
+	"api/server/game"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// roles of team members
+const (
+	TEAM_OWNER  = "owner"
+	TEAM_ADMIN  = "admin"
+	TEAM_MEMBER = "member"
+)
+
+// Team is a group of players. Its admins create matches and tournaments only its members can join.
+type Team struct {
+	ID          string    `json:"id" example:"K7ZD4F2Q"`
+	Name        string    `json:"name" example:"Knights of Lisbon"`
+	Description string    `json:"description" example:"Casual players from Lisbon"`
+	Owner       string    `json:"owner" example:"JohnDoe"`
+	Members     int       `json:"members" example:"12"`
+	CreatedAt   time.Time `json:"createdAt" format:"date-time"`
+}
+
+type TeamMember struct {
+	Username string    `json:"username" example:"JaneDoe"`
+	Role     string    `json:"role" example:"member" enums:"owner,admin,member"`
+	JoinedAt time.Time `json:"joinedAt" format:"date-time"`
+}
+
+// TeamMembership is a team a user is a member of
+type TeamMembership struct {
+	ID   string `json:"id" example:"K7ZD4F2Q"`
+	Name string `json:"name" example:"Knights of Lisbon"`
+	Role string `json:"role" example:"member" enums:"owner,admin,member"`
+}
+
+// TeamMatch is an ongoing match only members of the team can join
+type TeamMatch struct {
+	MatchID string `json:"matchId" example:"AB2C21"`
+	Rated   bool   `json:"rated" example:"false"`
+	State   string `json:"state" example:"waiting" enums:"waiting,playing,finished"`
+	// usernames of the players who joined
+	Players   []string  `json:"players"`
+	StartTime time.Time `json:"startTime" format:"date-time"`
+	EndTime   time.Time `json:"endTime" format:"date-time"`
+}
+
+type CreateTeamRequest struct {
+	Name        string `json:"name" minLength:"3" maxLength:"50" example:"Knights of Lisbon" validate:"notblank,min=3,max=50"`
+	Description string `json:"description" maxLength:"500" example:"Casual players from Lisbon" validate:"max=500"`
+}
+
+type UpdateTeamRequest struct {
+	Description string `json:"description" maxLength:"500" example:"Casual players from Lisbon" validate:"max=500"`
+}
+
+type SetTeamRoleRequest struct {
+	Role string `json:"role" example:"admin" enums:"admin,member" validate:"required,oneof=admin member"`
+}
+
+func teamFromDb(t db.GetTeamRow) Team {
+	return Team{
+		ID:          t.ID,
+		Name:        t.Name,
+		Description: t.Description,
+		Owner:       t.Owner,
+		Members:     int(t.Members),
+		CreatedAt:   t.CreatedAt,
+	}
+}
+
+// teamOf is the team with the :id path parameter.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (s Server) teamOf(c echo.Context) (db.GetTeamRow, error) {
+	t, err := s.DB.GetTeam(c.Request().Context(), c.Param("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return t, echo.NewHTTPError(http.StatusNotFound, Reason(CODE_TEAM_NOT_FOUND, "Team not found"))
+	}
+	if err != nil {
+		slog.Error("failed to get team", "team", c.Param("id"), "error", err)
+		return t, echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return t, nil
+}
+
+// teamRole is the role of the user with uid in the team, an error if they are not a member.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (s Server) teamRole(ctx context.Context, teamID string, uid int64) (string, error) {
+	role, err := s.DB.GetTeamMemberRole(ctx, db.GetTeamMemberRoleParams{TeamID: teamID, Uid: uid})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", echo.NewHTTPError(http.StatusForbidden, Reason(CODE_NOT_TEAM_MEMBER, "You are not a member of this team"))
+	}
+	if err != nil {
+		slog.Error("failed to get team role", "team", teamID, "error", err)
+		return "", echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return role, nil
+}
+
+// teamAdmin checks that the user with uid is an admin or the owner of the team.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (s Server) teamAdmin(ctx context.Context, teamID string, uid int64) (role string, err error) {
+	role, err = s.teamRole(ctx, teamID, uid)
+	if err != nil {
+		return "", err
+	}
+	if role == TEAM_MEMBER {
+		return "", echo.NewHTTPError(http.StatusForbidden, Reason(CODE_NOT_TEAM_ADMIN, "Only admins of the team can do this"))
+	}
+	return role, nil
+}
+
+// @Summary		Create a team
+// @Description	You become the owner of the team. Everyone can join it, its admins create matches and tournaments only members can join.
+// @Description	The team is deleted when its owner deletes it or their account. Guests cannot create teams.
+// @Tags			teams
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header		string				true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			payload			body		CreateTeamRequest	true	"name and description"
+// @Success		201				{object}	Team
+// @Failure		400				{object}	ErrorReason	"Invalid json body"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Guests cannot create teams"
+// @Failure		409				{object}	ErrorReason	"Team name already taken"
+// @Failure		500				{object}	ErrorReason
+// @Router			/teams [post]
+func (s Server) CreateTeam(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	if user.IsGuest {
+		return c.JSON(http.StatusForbidden, Reason(CODE_GUESTS_CANNOT_CREATE_TEAMS, "Guests cannot create teams"))
+	}
+	var req CreateTeamRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	taken, err := s.DB.TeamNameExists(ctx, req.Name)
+	if err != nil {
+		slog.Error("failed to check team name", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	if taken == 1 {
+		return c.JSON(http.StatusConflict, Reason(CODE_TEAM_NAME_TAKEN, "Team name already taken"))
+	}
+
+	tx, err := s.SQL.BeginTx(ctx, nil)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	defer tx.Rollback()
+	q := s.DB.WithTx(tx)
+	created, err := q.CreateTeam(ctx, db.CreateTeamParams{
+		ID:          rand.Text()[:8],
+		OwnerUid:    user.Uid,
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err == nil {
+		_, err = q.AddTeamMember(ctx, db.AddTeamMemberParams{TeamID: created.ID, Uid: user.Uid, Role: TEAM_OWNER})
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		slog.Error("failed to create team", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return c.JSON(http.StatusCreated, Team{
+		ID:          created.ID,
+		Name:        created.Name,
+		Description: created.Description,
+		Owner:       user.Username,
+		Members:     1,
+		CreatedAt:   created.CreatedAt,
+	})
+}
+
+// @Summary	List teams
+// @Tags		teams
+// @Produce	json
+// @Param		Authorization	header		string		true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param		search			query		string		false	"only list teams with this in their name"
+// @Param		limit			query		int			false	"max teams to return, default 50, max 100"
+// @Success	200				{array}		Team		"biggest first"
+// @Failure	400				{object}	ErrorReason	"Invalid query"
+// @Failure	401				{object}	ErrorReason
+// @Failure	500				{object}	ErrorReason
+// @Router		/teams [get]
+func (s Server) ListTeams(c echo.Context) error {
+	limit := 50
+	if l := c.QueryParam("limit"); l != "" {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 {
+			return c.JSON(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, "limit must be a positive number"))
+		}
+		limit = min(limit, 100)
+	}
+	rows, err := s.DB.ListTeams(c.Request().Context(), db.ListTeamsParams{Search: c.QueryParam("search"), Limit: int64(limit)})
+	if err != nil {
+		slog.Error("failed to list teams", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	teams := make([]Team, len(rows))
+	for i, r := range rows {
+		teams[i] = teamFromDb(db.GetTeamRow(r))
+	}
+	return c.JSON(http.StatusOK, teams)
+}
+
+// @Summary	List the teams you are a member of
+// @Tags		teams
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Success	200				{array}		TeamMembership
+// @Failure	401				{object}	ErrorReason
+// @Failure	500				{object}	ErrorReason
+// @Router		/users/me/teams [get]
+func (s Server) ListMyTeams(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	rows, err := s.DB.ListTeamsOfUser(c.Request().Context(), user.Uid)
+	if err != nil {
+		slog.Error("failed to list teams of user", "username", user.Username, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	teams := make([]TeamMembership, len(rows))
+	for i, r := range rows {
+		teams[i] = TeamMembership(r)
+	}
+	return c.JSON(http.StatusOK, teams)
+}
+
+// @Summary	Get the profile of a team
+// @Tags		teams
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param		id				path		string	true	"Team ID"
+// @Success	200				{object}	Team
+// @Failure	401				{object}	ErrorReason
+// @Failure	404				{object}	ErrorReason	"Team not found"
+// @Failure	500				{object}	ErrorReason
+// @Router		/teams/{id} [get]
+func (s Server) GetTeam(c echo.Context) error {
+	t, err := s.teamOf(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, teamFromDb(t))
+}
+
+// @Summary	Change the description of a team
+// @Tags		teams
+// @Accept		json
+// @Produce	json
+// @Param		Authorization	header		string				true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param		id				path		string				true	"Team ID"
+// @Param		payload			body		UpdateTeamRequest	true	"new description"
+// @Success	200				{object}	Team
+// @Failure	400				{object}	ErrorReason	"Invalid json body"
+// @Failure	401				{object}	ErrorReason
+// @Failure	403				{object}	ErrorReason	"Not an admin of the team"
+// @Failure	404				{object}	ErrorReason	"Team not found"
+// @Failure	500				{object}	ErrorReason
+// @Router		/teams/{id} [patch]
+func (s Server) UpdateTeam(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	var req UpdateTeamRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	t, err := s.teamOf(c)
+	if err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	if _, err := s.teamAdmin(ctx, t.ID, user.Uid); err != nil {
+		return err
+	}
+	if err := s.DB.UpdateTeamDescription(ctx, db.UpdateTeamDescriptionParams{Description: req.Description, ID: t.ID}); err != nil {
+		slog.Error("failed to update team", "team", t.ID, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	t.Description = req.Description
+	return c.JSON(http.StatusOK, teamFromDb(t))
+}
+
+// @Summary		Delete a team
+// @Description	Only the owner can delete a team. Nobody can join its tournaments anymore, those that started are played to the end.
+// @Tags			teams
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string	true	"Team ID"
+// @Success		200				{object}	string	"deleted"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"You don't own the team"
+// @Failure		404				{object}	ErrorReason	"Team not found"
+// @Failure		500				{object}	ErrorReason
+// @Router			/teams/{id} [delete]
+func (s Server) DeleteTeam(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	t, err := s.teamOf(c)
+	if err != nil {
+		return err
+	}
+	if t.OwnerUid != user.Uid {
+		return c.JSON(http.StatusForbidden, Reason(CODE_NOT_TEAM_OWNER, "Only the owner can delete the team"))
+	}
+	if _, err := s.DB.DeleteTeam(c.Request().Context(), db.DeleteTeamParams{ID: t.ID, OwnerUid: user.Uid}); err != nil {
+		slog.Error("failed to delete team", "team", t.ID, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return c.JSON(http.StatusOK, "deleted")
+}
+
+// @Summary	List the members of a team
+// @Tags		teams
+// @Produce	json
+// @Param		Authorization	header		string		true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param		id				path		string		true	"Team ID"
+// @Success	200				{array}		TeamMember	"the owner first, then admins, then members in the order they joined"
+// @Failure	401				{object}	ErrorReason
+// @Failure	404				{object}	ErrorReason	"Team not found"
+// @Failure	500				{object}	ErrorReason
+// @Router		/teams/{id}/members [get]
+func (s Server) ListTeamMembers(c echo.Context) error {
+	t, err := s.teamOf(c)
+	if err != nil {
+		return err
+	}
+	rows, err := s.DB.ListTeamMembers(c.Request().Context(), t.ID)
+	if err != nil {
+		slog.Error("failed to list team members", "team", t.ID, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	members := make([]TeamMember, len(rows))
+	for i, r := range rows {
+		members[i] = TeamMember(r)
+	}
+	return c.JSON(http.StatusOK, members)
+}
+
+// @Summary		Join a team
+// @Description	Joining a team you are a member of does nothing.
+// @Tags			teams
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string	true	"Team ID"
+// @Success		200				{object}	string	"joined"
+// @Failure		401				{object}	ErrorReason
+// @Failure		404				{object}	ErrorReason	"Team not found"
+// @Failure		500				{object}	ErrorReason
+// @Router			/teams/{id}/join [post]
+func (s Server) JoinTeam(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	t, err := s.teamOf(c)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.AddTeamMember(c.Request().Context(), db.AddTeamMemberParams{TeamID: t.ID, Uid: user.Uid, Role: TEAM_MEMBER})
+	if err != nil {
+		slog.Error("failed to join team", "team", t.ID, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return c.JSON(http.StatusOK, "joined")
+}
+
+// @Summary		Leave a team
+// @Description	The owner cannot leave their team, they delete it instead.
+// @Tags			teams
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string	true	"Team ID"
+// @Success		200				{object}	string	"left"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"The owner cannot leave"
+// @Failure		404				{object}	ErrorReason	"Team not found, or you are not a member"
+// @Failure		500				{object}	ErrorReason
+// @Router			/teams/{id}/join [delete]
+func (s Server) LeaveTeam(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	t, err := s.teamOf(c)
+	if err != nil {
+		return err
+	}
+	if t.OwnerUid == user.Uid {
+		return c.JSON(http.StatusForbidden, Reason(CODE_NOT_TEAM_MEMBER, "The owner cannot leave the team, delete it instead"))
+	}
+	left, err := s.DB.RemoveTeamMember(c.Request().Context(), db.RemoveTeamMemberParams{TeamID: t.ID, Uid: user.Uid})
+	if err != nil {
+		slog.Error("failed to leave team", "team", t.ID, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	if left == 0 {
+		return c.JSON(http.StatusNotFound, Reason(CODE_NOT_TEAM_MEMBER, "You are not a member of this team"))
+	}
+	return c.JSON(http.StatusOK, "left")
+}
+
+// @Summary		Change the role of a team member
+// @Description	Only the owner can make members admins or take it back.
+// @Tags			teams
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header		string				true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string				true	"Team ID"
+// @Param			username		path		string				true	"Username of the member"
+// @Param			payload			body		SetTeamRoleRequest	true	"admin or member"
+// @Success		200				{object}	string				"ok"
+// @Failure		400				{object}	ErrorReason			"Invalid json body"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"You don't own the team / the owner's role cannot change"
+// @Failure		404				{object}	ErrorReason	"Team or member not found"
+// @Failure		500				{object}	ErrorReason
+// @Router			/teams/{id}/members/{username} [put]
+func (s Server) SetTeamMemberRole(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	var req SetTeamRoleRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	t, err := s.teamOf(c)
+	if err != nil {
+		return err
+	}
+	if t.OwnerUid != user.Uid {
+		return c.JSON(http.StatusForbidden, Reason(CODE_NOT_TEAM_OWNER, "Only the owner can change roles"))
+	}
+	member, err := s.userFromParam(c)
+	if err != nil {
+		return err
+	}
+	if member.Uid == t.OwnerUid {
+		return c.JSON(http.StatusForbidden, Reason(CODE_NOT_TEAM_OWNER, "The owner's role cannot change"))
+	}
+	changed, err := s.DB.SetTeamMemberRole(c.Request().Context(), db.SetTeamMemberRoleParams{Role: req.Role, TeamID: t.ID, Uid: member.Uid})
+	if err != nil {
+		slog.Error("failed to change team role", "team", t.ID, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	if changed == 0 {
+		return c.JSON(http.StatusNotFound, Reason(CODE_NOT_TEAM_MEMBER, "User is not a member of this team"))
+	}
+	return c.JSON(http.StatusOK, "ok")
+}
+
+// @Summary		Remove a member from a team
+// @Description	Admins can remove members, only the owner can remove admins.
+// @Tags			teams
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string	true	"Team ID"
+// @Param			username		path		string	true	"Username of the member"
+// @Success		200				{object}	string	"removed"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Not an admin of the team / only the owner can remove admins"
+// @Failure		404				{object}	ErrorReason	"Team or member not found"
+// @Failure		500				{object}	ErrorReason
+// @Router			/teams/{id}/members/{username} [delete]
+func (s Server) RemoveTeamMember(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	t, err := s.teamOf(c)
+	if err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	role, err := s.teamAdmin(ctx, t.ID, user.Uid)
+	if err != nil {
+		return err
+	}
+	member, err := s.userFromParam(c)
+	if err != nil {
+		return err
+	}
+	memberRole, err := s.teamRole(ctx, t.ID, member.Uid)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, Reason(CODE_NOT_TEAM_MEMBER, "User is not a member of this team"))
+	}
+	if memberRole == TEAM_OWNER || memberRole == TEAM_ADMIN && role != TEAM_OWNER {
+		return c.JSON(http.StatusForbidden, Reason(CODE_NOT_TEAM_OWNER, "Only the owner can remove admins"))
+	}
+	if _, err := s.DB.RemoveTeamMember(ctx, db.RemoveTeamMemberParams{TeamID: t.ID, Uid: member.Uid}); err != nil {
+		slog.Error("failed to remove team member", "team", t.ID, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return c.JSON(http.StatusOK, "removed")
+}
+
+// @Summary		Create a team match
+// @Description	Creates a match like POST /matches that only members of the team can join. Any member can create one.
+// @Tags			teams
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header		string					true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string					true	"Team ID"
+// @Param			payload			body		CreateMatchRequest		true	"Duration of the match in hours. Max is 12"
+// @Success		200				{object}	MatchCreatedResponse	"Match Created"
+// @Failure		400				{object}	ErrorReason				"Invalid json body"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Not a member of the team / guests cannot create rated matches"
+// @Failure		404				{object}	ErrorReason	"Team not found"
+// @Failure		429				{object}	ErrorReason	"Too many unfinished matches"
+// @Failure		500				{object}	ErrorReason
+// @Router			/teams/{id}/matches [post]
+func (s Server) CreateTeamMatch(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	var req CreateMatchRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	t, err := s.teamOf(c)
+	if err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	if _, err := s.teamRole(ctx, t.ID, user.Uid); err != nil {
+		return err
+	}
+	match, err := s.createMatch(ctx, user.Username, user.IsGuest, req)
+	if err != nil {
+		return err
+	}
+	if err := s.DB.CreateTeamMatch(ctx, db.CreateTeamMatchParams{MatchID: match.ID, TeamID: t.ID}); err != nil {
+		slog.Error("failed to store team match", "team", t.ID, "error", err)
+		s.GameStorage.DeleteMatch(match.ID)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return c.JSON(http.StatusOK, MatchCreatedResponse{match.ID})
+}
+
+// @Summary	List the ongoing matches of a team
+// @Tags		teams
+// @Produce	json
+// @Param		Authorization	header		string		true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param		id				path		string		true	"Team ID"
+// @Success	200				{array}		TeamMatch	"newest first"
+// @Failure	401				{object}	ErrorReason
+// @Failure	404				{object}	ErrorReason	"Team not found"
+// @Failure	500				{object}	ErrorReason
+// @Router		/teams/{id}/matches [get]
+func (s Server) ListTeamMatches(c echo.Context) error {
+	t, err := s.teamOf(c)
+	if err != nil {
+		return err
+	}
+	ids, err := s.DB.ListTeamMatches(c.Request().Context(), t.ID)
+	if err != nil {
+		slog.Error("failed to list team matches", "team", t.ID, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	matches := []TeamMatch{}
+	for _, id := range ids {
+		m, ok := s.GameStorage.GetMatch(id)
+		if !ok {
+			continue
+		}
+		tm := TeamMatch{MatchID: m.ID, Rated: m.Rated, State: m.State().String(), Players: []string{}, StartTime: m.StartTime, EndTime: m.EndTime}
+		for _, p := range m.Players() {
+			tm.Players = append(tm.Players, p.Username)
+		}
+		matches = append(matches, tm)
+	}
+	return c.JSON(http.StatusOK, matches)
+}
+
+// @Summary		Create a team tournament
+// @Description	Creates a tournament like POST /tournaments that only members of the team can join. Only admins of the team can create one.
+// @Tags			teams
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header		string					true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string					true	"Team ID"
+// @Param			payload			body		CreateTournamentRequest	true	"name, rounds and hours each game can last"
+// @Success		201				{object}	Tournament
+// @Failure		400				{object}	ErrorReason	"Invalid json body"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Not an admin of the team / guests cannot create rated tournaments"
+// @Failure		404				{object}	ErrorReason	"Team not found"
+// @Failure		500				{object}	ErrorReason
+// @Router			/teams/{id}/tournaments [post]
+func (s Server) CreateTeamTournament(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	var req CreateTournamentRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	t, err := s.teamOf(c)
+	if err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	if _, err := s.teamAdmin(ctx, t.ID, user.Uid); err != nil {
+		return err
+	}
+	created, err := s.createTournament(ctx, user, req, t.ID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, created)
+}
+
+// @Summary	List the tournaments of a team
+// @Tags		teams
+// @Produce	json
+// @Param		Authorization	header		string		true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param		id				path		string		true	"Team ID"
+// @Param		status			query		string		false	"only list tournaments with this status"	Enums(open, playing, finished)
+// @Param		limit			query		int			false	"max tournaments to return, default 50, max 100"
+// @Success	200				{array}		Tournament	"newest first"
+// @Failure	400				{object}	ErrorReason	"Invalid query"
+// @Failure	401				{object}	ErrorReason
+// @Failure	404				{object}	ErrorReason	"Team not found"
+// @Failure	500				{object}	ErrorReason
+// @Router		/teams/{id}/tournaments [get]
+func (s Server) ListTeamTournaments(c echo.Context) error {
+	t, err := s.teamOf(c)
+	if err != nil {
+		return err
+	}
+	return s.listTournaments(c, t.ID)
+}
+
+// checkTeamMatch checks that username is a member of the team if match is a team match.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (s Server) checkTeamMatch(ctx context.Context, username string, match *game.Match) error {
+	team, err := s.DB.GetTeamOfMatch(ctx, match.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		slog.Error("failed to get team of match", "match", match.ID, "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	user, err := s.DB.GetUserByUsername(ctx, username)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	_, err = s.teamRole(ctx, team, user.Uid)
+	return err
+}
+
+// teamGameOver forgets that m was a team match, its id can be used by another match. See gameOver.
+func (s Server) teamGameOver(m *game.Match) {
+	if err := s.DB.DeleteTeamMatch(context.Background(), m.ID); err != nil {
+		slog.Warn("failed to delete team match", "match", m.ID, "error", err)
+	}
+}
+
+// purgeEndedTeamMatches forgets team matches that expired without a result
+func (s Server) purgeEndedTeamMatches(ctx context.Context) {
+	ids, err := s.DB.ListAllTeamMatches(ctx)
+	if err != nil {
+		slog.Error("failed to list team matches", "error", err)
+		return
+	}
+	for _, id := range ids {
+		if _, ok := s.GameStorage.GetMatch(id); !ok {
+			s.DB.DeleteTeamMatch(ctx, id)
+		}
+	}
+}
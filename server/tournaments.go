@@ -0,0 +1,661 @@
+// Swiss tournaments, where the server pairs every round and creates its matches
+package server
+
+import (
+	"api/db"
+	"api/server/game"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notnil/chess"
+)
+
+const (
+	// how long the players of a round have to join their match. A player who joined alone wins once it passes.
+	TOURNAMENT_NO_SHOW_TIMEOUT = 10 * time.Minute
+	// how often the referee looks for tournament games that nobody finished
+	TOURNAMENT_REFEREE_INTERVAL = 15 * time.Second
+)
+
+// Tournament is a Swiss tournament. Every round players are paired with someone on the same score they did not play yet.
+type Tournament struct {
+	ID    string `json:"id" example:"T4KQ7Z2D"`
+	Name  string `json:"name" example:"Friday blitz"`
+	Owner string `json:"owner" example:"JohnDoe"`
+	// rounds the tournament lasts
+	Rounds int `json:"rounds" example:"5"`
+	// hours each game can last
+	Duration int  `json:"duration" example:"1"`
+	Rated    bool `json:"rated" example:"false"`
+	// open while players can join, then playing, then finished
+	Status string `json:"status" example:"open" enums:"open,playing,finished"`
+	// 0 until the tournament starts
+	CurrentRound int `json:"currentRound" example:"0"`
+	// id of the team whose members can join, empty if everyone can
+	Team      string    `json:"team,omitempty" example:"K7ZD4F2Q"`
+	Players   int       `json:"players" example:"8"`
+	CreatedAt time.Time `json:"createdAt" format:"date-time"`
+}
+
+// TournamentDetails is a tournament with its live standings and the games of every round
+type TournamentDetails struct {
+	Tournament
+	// best first
+	Standings []TournamentStanding `json:"standings"`
+	Pairings  []TournamentPairing  `json:"pairings"`
+}
+
+type TournamentStanding struct {
+	Rank     int    `json:"rank" example:"1"`
+	Username string `json:"username" example:"JohnDoe"`
+	// a win or a bye is 1 point, a draw half a point
+	Score float64 `json:"score" example:"2.5"`
+	// sum of the scores of the opponents, breaks ties
+	Buchholz float64 `json:"buchholz" example:"4"`
+	// withdrawn players are not paired anymore
+	Withdrawn bool `json:"withdrawn" example:"false"`
+}
+
+// TournamentPairing is a game of a round
+type TournamentPairing struct {
+	Round int    `json:"round" example:"1"`
+	White string `json:"white" example:"JohnDoe"`
+	// empty when white has a bye
+	Black   string `json:"black" example:"JaneDoe"`
+	MatchID string `json:"matchId" example:"AB2C21"`
+	// empty while the game is played, forfeit if nobody joined it
+	Result string `json:"result" example:"white" enums:"white,black,draw,forfeit,"`
+}
+
+type CreateTournamentRequest struct {
+	Name   string `json:"name" maxLength:"100" example:"Friday blitz" validate:"notblank,max=100"`
+	Rounds int    `json:"rounds" minimum:"1" maximum:"20" example:"5" validate:"required,min=1,max=20"`
+	// hours each game can last
+	Duration int `json:"duration" minimum:"1" maximum:"12" example:"1" validate:"required,min=1,max=12"`
+	// guests cannot join rated tournaments
+	Rated bool `json:"rated" example:"false"`
+}
+
+// Tournaments pairs the rounds of tournaments one at a time, and remembers who plays in their matches.
+type Tournaments struct {
+	// held while a round is paired or a result is recorded
+	pairing sync.Mutex
+
+	mu sync.Mutex
+	// match id -> players of an unfinished tournament game
+	seats map[string]tournamentSeats
+}
+
+type tournamentSeats struct {
+	white, black string
+}
+
+func NewTournaments() *Tournaments {
+	return &Tournaments{seats: map[string]tournamentSeats{}}
+}
+
+// SeatColor is the color username was paired with in the match, ok is false if it is not their tournament game.
+func (t *Tournaments) SeatColor(matchID, username string) (color chess.Color, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	seats, ok := t.seats[matchID]
+	switch {
+	case !ok:
+		return chess.NoColor, false
+	case seats.white == username:
+		return chess.White, true
+	case seats.black == username:
+		return chess.Black, true
+	}
+	return chess.NoColor, false
+}
+
+// Reserved is true if the match is a tournament game of other players than username
+func (t *Tournaments) Reserved(matchID, username string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	seats, ok := t.seats[matchID]
+	return ok && seats.white != username && seats.black != username
+}
+
+func (t *Tournaments) seat(matchID, white, black string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.seats[matchID] = tournamentSeats{white: white, black: black}
+}
+
+func (t *Tournaments) unseat(matchID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.seats, matchID)
+}
+
+// reseat replaces every seat with the players of the unfinished games
+func (t *Tournaments) reseat(unfinished []db.ListUnfinishedTournamentPairingsRow) {
+	seats := make(map[string]tournamentSeats, len(unfinished))
+	for _, p := range unfinished {
+		if p.MatchID != "" {
+			seats[p.MatchID] = tournamentSeats{white: p.White, black: p.Black}
+		}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.seats = seats
+}
+
+func tournamentFromDb(t db.Tournament, owner, team string, players int) Tournament {
+	return Tournament{
+		ID:           t.ID,
+		Name:         t.Name,
+		Owner:        owner,
+		Rounds:       int(t.Rounds),
+		Duration:     int(t.Duration),
+		Rated:        t.Rated,
+		Status:       t.Status,
+		CurrentRound: int(t.CurrentRound),
+		Team:         team,
+		Players:      players,
+		CreatedAt:    t.CreatedAt,
+	}
+}
+
+// tournamentOf is the tournament with the :id path parameter.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (s Server) tournamentOf(c echo.Context) (db.GetTournamentRow, error) {
+	t, err := s.DB.GetTournament(c.Request().Context(), c.Param("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return t, echo.NewHTTPError(http.StatusNotFound, Reason(CODE_TOURNAMENT_NOT_FOUND, "Tournament not found"))
+	}
+	if err != nil {
+		slog.Error("failed to get tournament", "tournament", c.Param("id"), "error", err)
+		return t, echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return t, nil
+}
+
+// @Summary		Create a Swiss tournament
+// @Description	Players join the tournament until its owner starts it. The server then pairs every round, creates its matches and notifies the players with a `tournamentPairing` notification.
+// @Description	Players join their match like any other, with the colors they were paired with. A player who is alone in the match after 10 minutes wins the game, nobody wins it if neither player joined.
+// @Description	Games still going when their time runs out are drawn. The next round starts once every game of the round ended.
+// @Description	guests can only create casual (unrated) tournaments
+// @Tags			tournaments
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header		string					true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			payload			body		CreateTournamentRequest	true	"name, rounds and hours each game can last"
+// @Success		201				{object}	Tournament
+// @Failure		400				{object}	ErrorReason	"Invalid json body"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Guests cannot create rated tournaments"
+// @Failure		500				{object}	ErrorReason
+// @Router			/tournaments [post]
+func (s Server) CreateTournament(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	var req CreateTournamentRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	t, err := s.createTournament(c.Request().Context(), user, req, "")
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, t)
+}
+
+// createTournament creates a tournament owned by user, only the members of team can join it unless team is empty.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (s Server) createTournament(ctx context.Context, user db.User, req CreateTournamentRequest, team string) (Tournament, error) {
+	if req.Rated && user.IsGuest {
+		return Tournament{}, echo.NewHTTPError(http.StatusForbidden, Reason(CODE_GUESTS_CANNOT_RATED, "Guests cannot play rated matches"))
+	}
+	created, err := func() (db.Tournament, error) {
+		tx, err := s.SQL.BeginTx(ctx, nil)
+		if err != nil {
+			return db.Tournament{}, err
+		}
+		defer tx.Rollback()
+		q := s.DB.WithTx(tx)
+		created, err := q.CreateTournament(ctx, db.CreateTournamentParams{
+			ID:       rand.Text()[:8],
+			OwnerUid: user.Uid,
+			Name:     req.Name,
+			Rounds:   int64(req.Rounds),
+			Duration: int64(req.Duration),
+			Rated:    req.Rated,
+		})
+		if err != nil {
+			return created, err
+		}
+		if team != "" {
+			if err := q.CreateTeamTournament(ctx, db.CreateTeamTournamentParams{TournamentID: created.ID, TeamID: team}); err != nil {
+				return created, err
+			}
+		}
+		return created, tx.Commit()
+	}()
+	if err != nil {
+		slog.Error("failed to create tournament", "error", err)
+		return Tournament{}, echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return tournamentFromDb(created, user.Username, team, 0), nil
+}
+
+// @Summary	List tournaments
+// @Tags		tournaments
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param		status			query		string	false	"only list tournaments with this status"	Enums(open, playing, finished)
+// @Param		limit			query		int		false	"max tournaments to return, default 50, max 100"
+// @Success	200				{array}		Tournament
+// @Failure	400				{object}	ErrorReason	"Invalid query"
+// @Failure	401				{object}	ErrorReason
+// @Failure	500				{object}	ErrorReason
+// @Router		/tournaments [get]
+func (s Server) ListTournaments(c echo.Context) error {
+	return s.listTournaments(c, "")
+}
+
+// listTournaments writes the tournaments of team, or of everyone if team is empty, filtered by the query parameters
+func (s Server) listTournaments(c echo.Context, team string) error {
+	status := c.QueryParam("status")
+	if status != "" && status != "open" && status != "playing" && status != "finished" {
+		return c.JSON(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, "status must be open, playing or finished"))
+	}
+	limit := 50
+	if l := c.QueryParam("limit"); l != "" {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 {
+			return c.JSON(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, "limit must be a positive number"))
+		}
+		limit = min(limit, 100)
+	}
+	rows, err := s.DB.ListTournaments(c.Request().Context(), db.ListTournamentsParams{Status: status, Team: team, Limit: int64(limit)})
+	if err != nil {
+		slog.Error("failed to list tournaments", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	tournaments := make([]Tournament, len(rows))
+	for i, r := range rows {
+		tournaments[i] = tournamentFromDb(db.Tournament{
+			ID:           r.ID,
+			Name:         r.Name,
+			Rounds:       r.Rounds,
+			Duration:     r.Duration,
+			Rated:        r.Rated,
+			Status:       r.Status,
+			CurrentRound: r.CurrentRound,
+			CreatedAt:    r.CreatedAt,
+		}, r.Owner, r.Team, int(r.Players))
+	}
+	return c.JSON(http.StatusOK, tournaments)
+}
+
+// @Summary		Get a tournament with its standings
+// @Description	Standings are ranked by score, then by Buchholz, the sum of the scores of each player's opponents. They change as soon as a game ends.
+// @Tags			tournaments
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string	true	"Tournament ID"
+// @Success		200				{object}	TournamentDetails
+// @Failure		401				{object}	ErrorReason
+// @Failure		404				{object}	ErrorReason	"Tournament not found"
+// @Failure		500				{object}	ErrorReason
+// @Router			/tournaments/{id} [get]
+func (s Server) GetTournament(c echo.Context) error {
+	t, err := s.tournamentOf(c)
+	if err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	players, err := s.DB.ListTournamentPlayers(ctx, t.ID)
+	if err != nil {
+		slog.Error("failed to list tournament players", "tournament", t.ID, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	pairings, err := s.DB.ListTournamentPairings(ctx, t.ID)
+	if err != nil {
+		slog.Error("failed to list tournament pairings", "tournament", t.ID, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	details := TournamentDetails{
+		Tournament: tournamentFromDb(db.Tournament{
+			ID:           t.ID,
+			Name:         t.Name,
+			Rounds:       t.Rounds,
+			Duration:     t.Duration,
+			Rated:        t.Rated,
+			Status:       t.Status,
+			CurrentRound: t.CurrentRound,
+			CreatedAt:    t.CreatedAt,
+		}, t.Owner, t.Team, len(players)),
+		Standings: make([]TournamentStanding, 0, len(players)),
+		Pairings:  make([]TournamentPairing, len(pairings)),
+	}
+	for i, p := range standings(players, pairings) {
+		details.Standings = append(details.Standings, TournamentStanding{
+			Rank:      i + 1,
+			Username:  p.username,
+			Score:     float64(p.points) / 2,
+			Buchholz:  float64(p.buchholz) / 2,
+			Withdrawn: p.withdrawn,
+		})
+	}
+	for i, p := range pairings {
+		details.Pairings[i] = TournamentPairing{Round: int(p.Round), White: p.White, Black: p.Black, MatchID: p.MatchID, Result: p.Result}
+	}
+	return c.JSON(http.StatusOK, details)
+}
+
+// @Summary		Join a tournament
+// @Description	Tournaments can be joined until they start. Joining twice does nothing. Team tournaments can only be joined by members of the team.
+// @Tags			tournaments
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string	true	"Tournament ID"
+// @Success		200				{object}	string	"joined"
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"Guests cannot join rated tournaments / not a member of the team"
+// @Failure		404				{object}	ErrorReason	"Tournament not found"
+// @Failure		409				{object}	ErrorReason	"The tournament already started"
+// @Failure		500				{object}	ErrorReason
+// @Router			/tournaments/{id}/join [post]
+func (s Server) JoinTournament(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	// the status cannot change while the player is added
+	s.Tournaments.pairing.Lock()
+	defer s.Tournaments.pairing.Unlock()
+	t, err := s.tournamentOf(c)
+	if err != nil {
+		return err
+	}
+	if t.Status != "open" {
+		return c.JSON(http.StatusConflict, Reason(CODE_TOURNAMENT_STARTED, "The tournament already started"))
+	}
+	if t.Rated && user.IsGuest {
+		return c.JSON(http.StatusForbidden, Reason(CODE_GUESTS_CANNOT_RATED, "Guests cannot play rated matches"))
+	}
+	if t.Team != "" {
+		if _, err := s.teamRole(c.Request().Context(), t.Team, user.Uid); err != nil {
+			return err
+		}
+	}
+	_, err = s.DB.AddTournamentPlayer(c.Request().Context(), db.AddTournamentPlayerParams{TournamentID: t.ID, Uid: user.Uid})
+	if err != nil {
+		slog.Error("failed to join tournament", "tournament", t.ID, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return c.JSON(http.StatusOK, "joined")
+}
+
+// @Summary		Leave a tournament
+// @Description	Before the tournament starts you are removed from it. Once it started you are withdrawn, your score stays in the standings but you are not paired in the next rounds.
+// @Description	Leaving does not end the game you are playing.
+// @Tags			tournaments
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string	true	"Tournament ID"
+// @Success		200				{object}	string	"left"
+// @Failure		401				{object}	ErrorReason
+// @Failure		404				{object}	ErrorReason	"Tournament not found, or you are not in it"
+// @Failure		409				{object}	ErrorReason	"The tournament is over"
+// @Failure		500				{object}	ErrorReason
+// @Router			/tournaments/{id}/join [delete]
+func (s Server) LeaveTournament(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	s.Tournaments.pairing.Lock()
+	defer s.Tournaments.pairing.Unlock()
+	t, err := s.tournamentOf(c)
+	if err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	var left int64
+	switch t.Status {
+	case "open":
+		left, err = s.DB.RemoveTournamentPlayer(ctx, db.RemoveTournamentPlayerParams{TournamentID: t.ID, Uid: user.Uid})
+	case "playing":
+		left, err = s.DB.WithdrawTournamentPlayer(ctx, db.WithdrawTournamentPlayerParams{TournamentID: t.ID, Uid: user.Uid})
+	default:
+		return c.JSON(http.StatusConflict, Reason(CODE_TOURNAMENT_FINISHED, "The tournament is over"))
+	}
+	if err != nil {
+		slog.Error("failed to leave tournament", "tournament", t.ID, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	if left == 0 {
+		return c.JSON(http.StatusNotFound, Reason(CODE_NOT_IN_TOURNAMENT, "You are not in this tournament"))
+	}
+	return c.JSON(http.StatusOK, "left")
+}
+
+// @Summary		Start a tournament
+// @Description	Only the owner can start a tournament, once at least 2 players joined. The first round is paired right away.
+// @Tags			tournaments
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		string	true	"Tournament ID"
+// @Success		200				{object}	TournamentDetails
+// @Failure		401				{object}	ErrorReason
+// @Failure		403				{object}	ErrorReason	"You don't own the tournament"
+// @Failure		404				{object}	ErrorReason	"Tournament not found"
+// @Failure		409				{object}	ErrorReason	"The tournament already started / not enough players"
+// @Failure		500				{object}	ErrorReason
+// @Router			/tournaments/{id}/start [post]
+func (s Server) StartTournament(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	err = func() error {
+		s.Tournaments.pairing.Lock()
+		defer s.Tournaments.pairing.Unlock()
+		t, err := s.tournamentOf(c)
+		if err != nil {
+			return err
+		}
+		if t.OwnerUid != user.Uid {
+			return echo.NewHTTPError(http.StatusForbidden, Reason(CODE_NOT_TOURNAMENT_OWNER, "Only the owner can start the tournament"))
+		}
+		if t.Status != "open" {
+			return echo.NewHTTPError(http.StatusConflict, Reason(CODE_TOURNAMENT_STARTED, "The tournament already started"))
+		}
+		ctx := c.Request().Context()
+		players, err := s.DB.ListTournamentPlayers(ctx, t.ID)
+		if err != nil {
+			slog.Error("failed to list tournament players", "tournament", t.ID, "error", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+		}
+		if len(players) < 2 {
+			return echo.NewHTTPError(http.StatusConflict, Reason(CODE_NOT_ENOUGH_PLAYERS, "A tournament needs at least 2 players"))
+		}
+		if err := s.nextRound(ctx, t); err != nil {
+			slog.Error("failed to start tournament", "tournament", t.ID, "error", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+		}
+		return nil
+	}()
+	if err != nil {
+		return err
+	}
+	return s.GetTournament(c)
+}
+
+// nextRound pairs the round after the current one and creates its matches,
+// or finishes the tournament after its last round or once fewer than 2 players are left.
+// s.Tournaments.pairing must be held.
+func (s Server) nextRound(ctx context.Context, t db.GetTournamentRow) error {
+	round := t.CurrentRound + 1
+	players, err := s.DB.ListTournamentPlayers(ctx, t.ID)
+	if err != nil {
+		return err
+	}
+	pairings, err := s.DB.ListTournamentPairings(ctx, t.ID)
+	if err != nil {
+		return err
+	}
+	games := pairRound(standings(players, pairings))
+	if round > t.Rounds || !slices.ContainsFunc(games, func(g swissPairing) bool { return g.black != nil }) {
+		slog.Info("tournament finished", "tournament", t.ID, "rounds", t.CurrentRound)
+		return s.DB.SetTournamentRound(ctx, db.SetTournamentRoundParams{CurrentRound: t.CurrentRound, Status: "finished", ID: t.ID})
+	}
+
+	matches := make([]*game.Match, len(games))
+	for i, g := range games {
+		if g.black == nil {
+			continue
+		}
+		matches[i], err = s.GameStorage.NewScheduledMatch(ctx, "", time.Duration(t.Duration)*time.Hour, t.Rated, TOURNAMENT_NO_SHOW_TIMEOUT)
+		if err != nil {
+			return err
+		}
+		s.Tournaments.seat(matches[i].ID, g.white.username, g.black.username)
+	}
+	tx, err := s.SQL.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := s.DB.WithTx(tx)
+	for i, g := range games {
+		p := db.CreateTournamentPairingParams{TournamentID: t.ID, Round: round, WhiteUid: g.white.uid}
+		if g.black == nil {
+			// a bye is won without playing
+			p.Result = "white"
+		} else {
+			p.BlackUid, p.MatchID = g.black.uid, matches[i].ID
+		}
+		if err := q.CreateTournamentPairing(ctx, p); err != nil {
+			return err
+		}
+	}
+	if err := q.SetTournamentRound(ctx, db.SetTournamentRoundParams{CurrentRound: round, Status: "playing", ID: t.ID}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for i, g := range games {
+		if g.black != nil {
+			s.notifyUsername(ctx, g.white.username, NotifyTournamentPairing, g.black.username, matches[i].ID)
+			s.notifyUsername(ctx, g.black.username, NotifyTournamentPairing, g.white.username, matches[i].ID)
+		}
+	}
+	slog.Info("tournament round started", "tournament", t.ID, "round", round, "games", len(games))
+	return nil
+}
+
+// recordResult stores the result of a tournament game and starts the next round once every game of the round ended.
+// Results of games that already have one are ignored.
+func (s Server) recordResult(ctx context.Context, p db.GetUnfinishedTournamentPairingByMatchRow, result string) {
+	s.Tournaments.pairing.Lock()
+	defer s.Tournaments.pairing.Unlock()
+	s.Tournaments.unseat(p.MatchID)
+	recorded, err := s.DB.SetTournamentPairingResult(ctx, db.SetTournamentPairingResultParams{
+		Result:       result,
+		TournamentID: p.TournamentID,
+		Round:        p.Round,
+		WhiteUid:     p.WhiteUid,
+	})
+	if err != nil {
+		slog.Error("failed to record tournament result", "tournament", p.TournamentID, "match", p.MatchID, "error", err)
+		return
+	}
+	if recorded == 0 {
+		return
+	}
+	unfinished, err := s.DB.CountUnfinishedTournamentPairings(ctx, p.TournamentID)
+	if err != nil || unfinished > 0 {
+		return
+	}
+	t, err := s.DB.GetTournament(ctx, p.TournamentID)
+	if err != nil {
+		slog.Error("failed to get tournament", "tournament", p.TournamentID, "error", err)
+		return
+	}
+	if t.Status != "playing" || t.CurrentRound != p.Round {
+		return
+	}
+	if err := s.nextRound(ctx, t); err != nil {
+		slog.Error("failed to start tournament round", "tournament", t.ID, "error", err)
+	}
+}
+
+// tournamentGameOver records the result of m if it is a tournament game, see gameOver
+func (s Server) tournamentGameOver(m *game.Match) {
+	ctx := context.Background()
+	p, err := s.DB.GetUnfinishedTournamentPairingByMatch(ctx, m.ID)
+	if err != nil {
+		return
+	}
+	s.recordResult(ctx, p, resultFromOutcome(m.Outcome()))
+}
+
+// tournamentReferee ends the tournament games nobody finished, every TOURNAMENT_REFEREE_INTERVAL until ctx is cancelled.
+// A game nobody joined is forfeited, a player who is alone after TOURNAMENT_NO_SHOW_TIMEOUT wins,
+// and a game still going when its time is about to run out is drawn.
+func (s Server) tournamentReferee(ctx context.Context) {
+	ticker := time.NewTicker(TOURNAMENT_REFEREE_INTERVAL)
+	defer ticker.Stop()
+	for {
+		s.refereeTournamentGames(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s Server) refereeTournamentGames(ctx context.Context) {
+	unfinished, err := s.DB.ListUnfinishedTournamentPairings(ctx)
+	if err != nil {
+		slog.Error("failed to list tournament games", "error", err)
+		return
+	}
+	// pairings made by other replicas, or before a restart
+	s.Tournaments.reseat(unfinished)
+	now := time.Now()
+	for _, p := range unfinished {
+		match, ok := s.GameStorage.GetMatch(p.MatchID)
+		if !ok {
+			// expired before anyone joined, or lost in a restart
+			s.recordResult(ctx, db.GetUnfinishedTournamentPairingByMatchRow(p), "forfeit")
+			continue
+		}
+		switch match.State() {
+		case game.MatchWaiting:
+			players := match.Players()
+			if len(players) == 1 && now.Sub(match.StartTime) > TOURNAMENT_NO_SHOW_TIMEOUT {
+				outcome := chess.WhiteWon
+				if players[0].Color == chess.Black {
+					outcome = chess.BlackWon
+				}
+				match.Adjudicate(outcome)
+			}
+		case game.MatchPlaying:
+			// before the match expires without a result
+			if now.Add(2 * TOURNAMENT_REFEREE_INTERVAL).After(match.EndTime) {
+				match.Adjudicate(chess.Draw)
+			}
+		}
+	}
+}
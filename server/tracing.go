@@ -0,0 +1,84 @@
+// OpenTelemetry spans for database queries and event delivery
+package server
+
+import (
+	"api/server/game"
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("api/server")
+
+// tracedDB starts a span for every query made through sqlc.
+type tracedDB struct {
+	*sql.DB
+}
+
+// queryName is the sqlc name of a query, taken from its "-- name: GetUserByUsername :one" comment
+func queryName(query string) string {
+	if name, ok := strings.CutPrefix(query, "-- name: "); ok {
+		if name, _, ok = strings.Cut(name, " "); ok {
+			return name
+		}
+	}
+	return "query"
+}
+
+func (d tracedDB) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "db."+queryName(query), trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system", "sqlite")))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil && err != sql.ErrNoRows {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (d tracedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := d.start(ctx, query)
+	result, err := d.DB.ExecContext(ctx, query, args...)
+	endSpan(span, err)
+	return result, err
+}
+
+func (d tracedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := d.start(ctx, query)
+	stmt, err := d.DB.PrepareContext(ctx, query)
+	endSpan(span, err)
+	return stmt, err
+}
+
+func (d tracedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := d.start(ctx, query)
+	rows, err := d.DB.QueryContext(ctx, query, args...)
+	endSpan(span, err)
+	return rows, err
+}
+
+func (d tracedDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := d.start(ctx, query)
+	row := d.DB.QueryRowContext(ctx, query, args...)
+	endSpan(span, row.Err())
+	return row
+}
+
+// writeTracedSSE writes a match event, linking the span to the request that caused the event.
+func (s Server) writeTracedSSE(ctx context.Context, w *echo.Response, e game.Event) error {
+	_, span := tracer.Start(ctx, "sse.write", trace.WithLinks(trace.Link{SpanContext: e.Trace}),
+		trace.WithAttributes(attribute.String("event.type", string(e.Type))))
+	defer span.End()
+	if payload := e.Encoded(); payload != nil {
+		return writeSSEData(w, payload)
+	}
+	return writeSSE(w, e)
+}
@@ -0,0 +1,200 @@
+// a stream of a featured match that moves on to another match when the game ends
+package server
+
+import (
+	"api/server/game"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notnil/chess"
+)
+
+const (
+	// how often TV looks for a match while none is being played
+	TV_IDLE_INTERVAL = 5 * time.Second
+	// how long the end of a game stays on TV before the next match
+	TV_SWITCH_DELAY = 3 * time.Second
+)
+
+type TVEventType string
+
+const (
+	// a match is on TV now. The event has its players, moves and position.
+	TVFeatured TVEventType = "featured"
+	// a move was played in the featured match
+	TVMove TVEventType = "move"
+	// the featured game ended, the next match follows shortly
+	TVGameOver TVEventType = "gameOver"
+	// no match is being played, a featured event follows once one starts
+	TVWaiting TVEventType = "waiting"
+	// the server is shutting down, open the stream again in a moment
+	TVServerRestarting TVEventType = "serverRestarting"
+)
+
+// TVEvent is sent on GET /tv. Each type only uses some of the fields.
+type TVEvent struct {
+	Type    TVEventType `json:"type" example:"move"`
+	MatchID string      `json:"matchId,omitempty" example:"AB2C21"`
+	White   string      `json:"white,omitempty" example:"JohnDoe"`
+	Black   string      `json:"black,omitempty" example:"JaneDoe"`
+	// moves played so far in UCI notation, only in featured events
+	Moves []string `json:"moves,omitempty" example:"e2e4,e7e5"`
+	// the move that was played in UCI notation, and its number. 1 is white's first move.
+	Move string `json:"move,omitempty" example:"g1f3"`
+	Ply  int    `json:"ply,omitempty" example:"3"`
+	// position after the last move
+	FEN string `json:"fen,omitempty" example:"rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2"`
+	// like 1-0, only in gameOver events
+	Result string `json:"result,omitempty" example:"1-0"`
+}
+
+// TV picks the match shown on GET /tv. Every viewer sees the same match until its game ends.
+type TV struct {
+	mu sync.Mutex
+	// id of the match on TV, empty if none
+	featured string
+}
+
+func NewTV() *TV {
+	return &TV{}
+}
+
+// Featured is the match to show, nil if no game is being played.
+// The featured match stays on while its game goes on, then the match with the most recent move is picked.
+// Ratings would be a better measure of an interesting game, but players have none.
+func (tv *TV) Featured(storage *game.MatchStorage) *game.Match {
+	tv.mu.Lock()
+	defer tv.mu.Unlock()
+	if m, ok := storage.GetMatch(tv.featured); ok && m.State() == game.MatchPlaying && time.Now().Before(m.EndTime) {
+		return m
+	}
+	var best *game.Match
+	for _, m := range storage.Playing() {
+		if best == nil || m.LastActivity().After(best.LastActivity()) {
+			best = m
+		}
+	}
+	tv.featured = ""
+	if best != nil {
+		tv.featured = best.ID
+	}
+	return best
+}
+
+// @Summary		Watch the featured match
+// @Description	## On success the server will send `SSE` messages whose payloads are JSON TV events.
+// @Description	The stream shows the ongoing match with the most recent move, starting with a `featured` event with its players and moves, then every move played.
+// @Description	When its game ends a `gameOver` event is sent, and a few seconds later the next match is featured. A `waiting` event is sent while no match is being played.
+// @Description	Unauthorized clients can use this. Everyone watching sees the same match.
+// @Tags			matches
+// @Produce		event-stream
+// @Success		200	{object}	TVEvent	"SSE stream — each `data:` payload is a TV event (Content-Type: text/event-stream)."
+// @Router			/tv [get]
+func (s Server) WatchTV(c echo.Context) error {
+	startSSE(c)
+	ctx := c.Request().Context()
+	w := c.Response()
+	waiting := false
+	for {
+		m := s.TV.Featured(s.GameStorage)
+		if m == nil {
+			if !waiting {
+				if err := writeSSE(w, TVEvent{Type: TVWaiting}); err != nil {
+					return nil
+				}
+				waiting = true
+			}
+			if !s.tvPause(ctx, w, TV_IDLE_INTERVAL) {
+				return nil
+			}
+			continue
+		}
+		waiting = false
+		if !s.tvShow(ctx, w, m) {
+			return nil
+		}
+	}
+}
+
+// tvShow streams the match until its game ends. ok is false if the stream is over.
+func (s Server) tvShow(ctx context.Context, w *echo.Response, m *game.Match) (ok bool) {
+	// subscribed before reading the moves, so no move is missed
+	updates, unwatch := s.watch(m)
+	defer unwatch()
+	featured := TVEvent{Type: TVFeatured, MatchID: m.ID, Moves: m.UCIMoves(), FEN: m.Position().String()}
+	if white, ok := m.GetPlayerWithColor(chess.White); ok {
+		featured.White = white.Username
+	}
+	if black, ok := m.GetPlayerWithColor(chess.Black); ok {
+		featured.Black = black.Username
+	}
+	if err := writeSSE(w, featured); err != nil {
+		return false
+	}
+	if outcome := m.Outcome(); outcome != chess.NoOutcome {
+		return s.tvGameOver(ctx, w, m.ID, string(outcome))
+	}
+
+	// deleted matches don't end their game, they are checked for now and then
+	ticker := time.NewTicker(SSE_KEEP_ALIVE_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			if err := writeSSEKeepAlive(w); err != nil {
+				return false
+			}
+			if outcome := m.Outcome(); outcome != chess.NoOutcome {
+				return s.tvGameOver(ctx, w, m.ID, string(outcome))
+			}
+			if m.State() != game.MatchPlaying {
+				return true
+			}
+		case u, ok := <-updates:
+			if !ok {
+				// fell behind, featuring the match again sends its moves
+				return true
+			}
+			if u.Result != "" {
+				return s.tvGameOver(ctx, w, m.ID, u.Result)
+			}
+			// already part of the featured event
+			if u.Ply <= len(featured.Moves) {
+				continue
+			}
+			if err := writeSSE(w, TVEvent{Type: TVMove, MatchID: m.ID, Move: u.Move, Ply: u.Ply, FEN: u.FEN}); err != nil {
+				return false
+			}
+		case <-s.lifecycle.drained:
+			writeSSE(w, TVEvent{Type: TVServerRestarting})
+			return false
+		}
+	}
+}
+
+// tvGameOver sends the result of the featured match and leaves it on for TV_SWITCH_DELAY. ok is false if the stream is over.
+func (s Server) tvGameOver(ctx context.Context, w *echo.Response, matchID, result string) (ok bool) {
+	if err := writeSSE(w, TVEvent{Type: TVGameOver, MatchID: matchID, Result: result}); err != nil {
+		return false
+	}
+	return s.tvPause(ctx, w, TV_SWITCH_DELAY)
+}
+
+// tvPause waits for d. ok is false if the stream is over.
+func (s Server) tvPause(ctx context.Context, w *echo.Response, d time.Duration) (ok bool) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	case <-s.lifecycle.drained:
+		writeSSE(w, TVEvent{Type: TVServerRestarting})
+		return false
+	}
+}
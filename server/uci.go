@@ -0,0 +1,348 @@
+// running UCI chess engines like Stockfish
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// how long an engine gets to start and answer the handshake
+const ENGINE_START_TIMEOUT = 5 * time.Second
+
+// engineLevel is how hard the computer tries
+type engineLevel struct {
+	// the Skill Level option of Stockfish, 0 to 20. Other engines ignore it.
+	skill int
+	// the search stops at this depth or after moveTime, whichever comes first
+	depth    int
+	moveTime time.Duration
+}
+
+// difficulty levels of the computer, level 1 is ENGINE_LEVELS[0]
+var ENGINE_LEVELS = []engineLevel{
+	{skill: 0, depth: 1, moveTime: 50 * time.Millisecond},
+	{skill: 3, depth: 2, moveTime: 100 * time.Millisecond},
+	{skill: 6, depth: 3, moveTime: 150 * time.Millisecond},
+	{skill: 9, depth: 5, moveTime: 200 * time.Millisecond},
+	{skill: 11, depth: 8, moveTime: 300 * time.Millisecond},
+	{skill: 14, depth: 12, moveTime: 400 * time.Millisecond},
+	{skill: 17, depth: 16, moveTime: 600 * time.Millisecond},
+	{skill: 20, depth: 22, moveTime: time.Second},
+}
+
+// uciEngine is a running engine process that is spoken to over stdin and stdout.
+// It runs one search at a time.
+type uciEngine struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	// lines the engine printed, closed when it exits
+	lines chan string
+}
+
+// startUCIEngine runs the engine at path and waits until it is ready for a search
+func startUCIEngine(ctx context.Context, path string) (*uciEngine, error) {
+	cmd := exec.Command(path)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start engine %s: %w", path, err)
+	}
+	e := &uciEngine{cmd: cmd, stdin: stdin, lines: make(chan string, 64)}
+	go func() {
+		defer close(e.lines)
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			e.lines <- scanner.Text()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, ENGINE_START_TIMEOUT)
+	defer cancel()
+	if err := e.send("uci"); err == nil {
+		_, err = e.waitFor(ctx, "uciok")
+	}
+	if err == nil {
+		err = e.send("isready")
+	}
+	if err == nil {
+		_, err = e.waitFor(ctx, "readyok")
+	}
+	if err != nil {
+		e.close()
+		return nil, fmt.Errorf("engine %s did not start: %w", path, err)
+	}
+	return e, nil
+}
+
+func (e *uciEngine) send(command string) error {
+	_, err := io.WriteString(e.stdin, command+"\n")
+	return err
+}
+
+// waitFor reads lines until one starts with prefix, and returns it
+func (e *uciEngine) waitFor(ctx context.Context, prefix string) (string, error) {
+	for {
+		select {
+		case line, ok := <-e.lines:
+			if !ok {
+				return "", errors.New("engine exited")
+			}
+			if strings.HasPrefix(line, prefix) {
+				return line, nil
+			}
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// bestMove searches the position in fen and returns the move in UCI notation
+func (e *uciEngine) bestMove(ctx context.Context, fen string, level engineLevel) (string, error) {
+	for _, command := range []string{
+		"setoption name MultiPV value 1",
+		fmt.Sprintf("setoption name Skill Level value %d", level.skill),
+		"position fen " + fen,
+		fmt.Sprintf("go depth %d movetime %d", level.depth, level.moveTime.Milliseconds()),
+	} {
+		if err := e.send(command); err != nil {
+			return "", err
+		}
+	}
+	line, err := e.waitFor(ctx, "bestmove")
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 2 || fields[1] == "(none)" {
+		return "", fmt.Errorf("engine found no move: %q", line)
+	}
+	return fields[1], nil
+}
+
+// scores of mates, minus the moves to mate so faster mates score higher
+const MATE_SCORE = 100_000
+
+// engineLine is one of the best moves the engine found in a position
+type engineLine struct {
+	// in UCI notation
+	move string
+	// centipawns for the side to move, see MATE_SCORE
+	score int
+}
+
+// analyse searches the position in fen at full strength and returns its best lines, best first.
+// There are fewer than lines of them if the position has fewer legal moves.
+func (e *uciEngine) analyse(ctx context.Context, fen string, lines int, depth int, moveTime time.Duration) ([]engineLine, error) {
+	for _, command := range []string{
+		fmt.Sprintf("setoption name MultiPV value %d", lines),
+		"setoption name Skill Level value 20",
+		"position fen " + fen,
+		fmt.Sprintf("go depth %d movetime %d", depth, moveTime.Milliseconds()),
+	} {
+		if err := e.send(command); err != nil {
+			return nil, err
+		}
+	}
+	// the last info of each line is from the deepest search
+	found := make([]engineLine, lines)
+	for {
+		line, err := e.waitFor(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(line, "bestmove") {
+			break
+		}
+		if l, n, ok := parseInfoLine(line); ok && n >= 1 && n <= lines {
+			found[n-1] = l
+		}
+	}
+	best := make([]engineLine, 0, lines)
+	for _, l := range found {
+		if l.move == "" {
+			break
+		}
+		best = append(best, l)
+	}
+	return best, nil
+}
+
+// parseInfoLine reads the first move and score of an info line, and the number of its line with MultiPV.
+// ok is false for info lines without a move and a score.
+func parseInfoLine(line string) (l engineLine, multiPV int, ok bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != "info" {
+		return l, 0, false
+	}
+	multiPV = 1
+	scored := false
+	for i := 1; i < len(fields)-1; i++ {
+		switch fields[i] {
+		case "multipv":
+			multiPV, _ = strconv.Atoi(fields[i+1])
+		case "score":
+			if i+2 >= len(fields) {
+				return l, 0, false
+			}
+			n, err := strconv.Atoi(fields[i+2])
+			if err != nil {
+				return l, 0, false
+			}
+			switch fields[i+1] {
+			case "cp":
+				l.score, scored = n, true
+			case "mate":
+				l.score, scored = MATE_SCORE-abs(n), true
+				if n < 0 {
+					l.score = -l.score
+				}
+			}
+		case "pv":
+			l.move = fields[i+1]
+		}
+	}
+	return l, multiPV, scored && l.move != ""
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// close stops the engine process
+func (e *uciEngine) close() {
+	e.stdin.Close()
+	e.cmd.Process.Kill()
+	e.cmd.Wait()
+}
+
+// EnginePool starts engine processes as searches need them, up to a limit, and reuses them.
+// An engine that fails a search is stopped, the next search starts a new one.
+type EnginePool struct {
+	path string
+	// engines waiting for a search
+	idle chan *uciEngine
+	// one token per running engine
+	slots  chan struct{}
+	closed atomic.Bool
+}
+
+// NewEnginePool runs at most size engines from the executable at path
+func NewEnginePool(path string, size int) *EnginePool {
+	return &EnginePool{
+		path:  path,
+		idle:  make(chan *uciEngine, size),
+		slots: make(chan struct{}, size),
+	}
+}
+
+// Check starts an engine to make sure the executable works, and keeps it for the first search.
+func (p *EnginePool) Check(ctx context.Context) error {
+	e, err := p.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	p.release(e)
+	return nil
+}
+
+// BestMove searches the position in fen at level, waiting for an engine if all of them are busy.
+func (p *EnginePool) BestMove(ctx context.Context, fen string, level engineLevel) (move string, err error) {
+	ctx, span := tracer.Start(ctx, "engine.bestMove")
+	defer func() { endSpan(span, err) }()
+
+	err = p.search(ctx, func(e *uciEngine) (err error) {
+		move, err = e.bestMove(ctx, fen, level)
+		return err
+	})
+	return move, err
+}
+
+// Analyse searches the position in fen at full strength for its best lines, see uciEngine.analyse.
+// It waits for an engine if all of them are busy.
+func (p *EnginePool) Analyse(ctx context.Context, fen string, lines, depth int, moveTime time.Duration) (best []engineLine, err error) {
+	ctx, span := tracer.Start(ctx, "engine.analyse")
+	defer func() { endSpan(span, err) }()
+
+	err = p.search(ctx, func(e *uciEngine) (err error) {
+		best, err = e.analyse(ctx, fen, lines, depth, moveTime)
+		return err
+	})
+	return best, err
+}
+
+// search runs fn with an engine of the pool
+func (p *EnginePool) search(ctx context.Context, fn func(e *uciEngine) error) error {
+	e, err := p.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	if err := fn(e); err != nil {
+		// it may still be searching, or print the rest of its answer into the next search
+		e.close()
+		<-p.slots
+		return err
+	}
+	p.release(e)
+	return nil
+}
+
+func (p *EnginePool) acquire(ctx context.Context) (*uciEngine, error) {
+	select {
+	case e := <-p.idle:
+		return e, nil
+	default:
+	}
+	select {
+	case e := <-p.idle:
+		return e, nil
+	case p.slots <- struct{}{}:
+		e, err := startUCIEngine(ctx, p.path)
+		if err != nil {
+			<-p.slots
+			return nil, err
+		}
+		return e, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *EnginePool) release(e *uciEngine) {
+	if p.closed.Load() {
+		e.close()
+		<-p.slots
+		return
+	}
+	p.idle <- e
+}
+
+// Close stops the idle engines. Busy engines stop once their search is done.
+func (p *EnginePool) Close() {
+	p.closed.Store(true)
+	for {
+		select {
+		case e := <-p.idle:
+			e.close()
+			<-p.slots
+		default:
+			return
+		}
+	}
+}
@@ -3,12 +3,18 @@ package server
 
 import (
 	"api/db"
+	"database/sql"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
-	"golang.org/x/crypto/bcrypt"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
 )
 
 // User is the representation of a user account that will be returned by the api
@@ -16,12 +22,14 @@ type User struct {
 	UserID    int64     `json:"userId" example:"12"`
 	Username  string    `json:"username" example:"JohnDoe"`
 	CreatedAt time.Time `json:"createdAt" format:"date-time"`
+	// the account is played by an engine
+	IsBot bool `json:"isBot" example:"false"`
 }
 
 // UserCredentials are the required credentials to make a an account and log in.
 type UserCredentials struct {
-	Username string `json:"username" minLength:"4" maxLength:"20" example:"JohnDoe"`
-	Password string `json:"password" minLength:"3" example:"Password123"`
+	Username string `json:"username" minLength:"3" maxLength:"20" example:"JohnDoe" validate:"required,min=3,max=20,username"`
+	Password string `json:"password" minLength:"3" example:"Password123" validate:"required,min=3"`
 }
 type ApiKeyResponse struct {
 	ApiKey string `json:"apiKey"`
@@ -32,33 +40,37 @@ type ApiKeyResponse struct {
 //	@Summary		Create an account using provided username and password.
 //	@Description	Username can be between 3-20 characters.
 //	@Description	Password must be at least 3 characters.
+//	@Description	If signup challenges are enabled, a solved challenge from `GET /auth/challenge` is required.
 //
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
-//	@Param			payload	body		UserCredentials	true	"Register Account"
-//	@Success		201		{object}	ApiKeyResponse	"Api Key"
-//	@Failure		400		{object}	ErrorReason		"Invalid credentials"
-//	@Failure		409		{object}	ErrorReason		"Username already exists"
-//	@Failure		500		{object}	ErrorReason
+//	@Param			payload				body		UserCredentials	true	"Register Account"
+//	@Param			X-Challenge			header		string			false	"challenge from GET /auth/challenge"
+//	@Param			X-Challenge-Nonce	header		string			false	"nonce that solves the challenge"
+//	@Success		201					{object}	ApiKeyResponse	"Api Key"
+//	@Failure		400					{object}	ErrorReason		"Invalid credentials"
+//	@Failure		403					{object}	ErrorReason		"Missing or unsolved challenge"
+//	@Failure		429					{object}	ErrorReason		"Too many accounts created from this IP"
+//	@Failure		409					{object}	ErrorReason		"Username already exists"
+//	@Failure		500					{object}	ErrorReason
 //	@Router			/users [post]
 func (s Server) RegisterUserAccount(c echo.Context) error {
 	var req UserCredentials
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, REASON_JSON_SYNTAX_ERROR)
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
 	}
-	// validate username and password
-	if err := ValidateUsernameAndPassword(req.Username, req.Password); err != nil {
-		return c.JSON(http.StatusBadRequest, Reason(err.Error()))
+	if s.WordFilter.Contains(req.Username) {
+		return c.JSON(http.StatusBadRequest, Reason(CODE_USERNAME_NOT_ALLOWED, USERNAME_NOT_ALLOWED_ERROR))
 	}
 
 	// check if username already exists
 	user, _ := s.DB.GetUserByUsername(c.Request().Context(), req.Username)
 	if user.Username != "" {
-		return c.JSON(http.StatusConflict, Reason("Username already exists"))
+		return c.JSON(http.StatusConflict, Reason(CODE_USERNAME_TAKEN, "Username already exists"))
 	}
 	// generate password hash
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	passwordHash, err := s.Passwords.Hash(req.Password)
 	if err != nil {
 		slog.Error("Failed to hash password", "password", req.Password, "error", err)
 		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
@@ -66,7 +78,7 @@ func (s Server) RegisterUserAccount(c echo.Context) error {
 	// create user in the database
 	user, err = s.DB.CreateUser(c.Request().Context(), db.CreateUserParams{
 		Username:     req.Username,
-		PasswordHash: string(passwordHash),
+		PasswordHash: passwordHash,
 		ApiKey:       s.newApiKey(req.Username),
 	})
 
@@ -74,22 +86,26 @@ func (s Server) RegisterUserAccount(c echo.Context) error {
 		slog.Error("failed to create user, guard statements should stop this", "error", err)
 		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
 	}
+	s.audit(c, AUDIT_ACCOUNT_CREATED, user.Username, user.Username, "")
 
 	return c.JSON(http.StatusCreated, ApiKeyResponse{user.ApiKey})
 }
 
-// @Summary	Delete an account
+// @Summary		Delete an account
+// @Description	The account is deactivated immediately and permanently deleted after 30 days.
+// @Description	Logging in during those 30 days cancels the deletion.
+// @Description	When the account is deleted, your archived games are kept but anonymized.
 //
-// @Tags		users
-// @Accept		json
-// @Produce	json
-// @Param		Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
-// @Success	200				{object}	string	"deleted"
-// @Failure	401				{object}	ErrorReason
-// @Failure	500				{object}	ErrorReason
-// @Router		/users [delete]
+// @Tags			users
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Success		200				{object}	string	"deleted"
+// @Failure		401				{object}	ErrorReason
+// @Failure		500				{object}	ErrorReason
+// @Router			/users [delete]
 func (s Server) DeleteUserAccount(c echo.Context) error {
-	username := c.Get("username").(string)
+	username := usernameOf(c)
 	if username == "" {
 		return c.JSON(http.StatusUnauthorized, REASON_UNAUTHORIZED)
 	}
@@ -97,11 +113,212 @@ func (s Server) DeleteUserAccount(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
 	}
-	err = s.DB.DeleteUser(c.Request().Context(), user.Uid)
+	err = s.DB.SoftDeleteUser(c.Request().Context(), db.SoftDeleteUserParams{
+		DeletedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		Uid:       user.Uid,
+	})
 	if err != nil {
 		slog.Warn("user exists in DB but we cannot delete it", "username", username)
 		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
 	}
+	s.audit(c, AUDIT_ACCOUNT_DELETED, username, username, "")
 
 	return c.JSON(http.StatusOK, "deleted")
 }
+
+// @Summary		Export your account data
+// @Description	Download a zip archive containing your account information and all your archived games.
+// @Description	`account.json` contains the account and game metadata, `games.pgn` contains every game in PGN format.
+//
+// @Tags			users
+// @Produce		application/zip
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Success		200				{file}		string	"zip archive"
+// @Failure		401				{object}	ErrorReason
+// @Failure		500				{object}	ErrorReason
+// @Router			/users/me/export [get]
+func (s Server) ExportUserData(c echo.Context) error {
+	username := usernameOf(c)
+	if username == "" {
+		return c.JSON(http.StatusUnauthorized, REASON_UNAUTHORIZED)
+	}
+	ctx := c.Request().Context()
+	user, err := s.DB.GetUserByUsername(ctx, username)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	games, err := s.DB.ListAllGamesByPlayer(ctx, user.Uid)
+	if err != nil {
+		slog.Error("failed to list games for export", "username", username, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	archive, err := s.exportArchive(ctx, user, games)
+	if err != nil {
+		slog.Error("failed to build export archive", "username", username, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf(`attachment; filename="%s-export.zip"`, user.Username))
+	return c.Blob(http.StatusOK, "application/zip", archive)
+}
+
+// @Summary		Export the games of a user
+// @Description	Streams every archived game of the user, oldest first, as PGN games one after another or as newline delimited JSON with one game per line.
+// @Description	Games are sent while they are read, so large archives start right away. The format is picked with `format`, or with an `Accept` header of `application/x-ndjson`.
+// @Description	Every game has an id. If the stream breaks off, continue it with `after` set to the id of the last game you got.
+// @Tags			users
+// @Produce		application/x-chess-pgn,json
+// @Param			Authorization	header		string			true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			username		path		string			true	"whose games to export"
+// @Param			format			query		string			false	"pgn by default"	Enums(pgn, ndjson)
+// @Param			since			query		string			false	"only games finished at or after this time (RFC 3339)"
+// @Param			until			query		string			false	"only games finished before this time (RFC 3339)"
+// @Param			color			query		string			false	"only games the user played with this color"	Enums(white, black)
+// @Param			result			query		string			false	"only games the user won, lost or drew"			Enums(win, loss, draw)
+// @Param			after			query		int				false	"only games with a higher id"
+// @Success		200				{object}	ExportedGame	"PGN, or NDJSON with one game per line"
+// @Failure		400				{object}	ErrorReason		"Invalid query"
+// @Failure		401				{object}	ErrorReason
+// @Failure		404				{object}	ErrorReason	"User not found"
+// @Failure		500				{object}	ErrorReason
+// @Router			/users/{username}/games/export [get]
+func (s Server) ExportUserGames(c echo.Context) error {
+	if usernameOf(c) == "" {
+		return c.JSON(http.StatusUnauthorized, REASON_UNAUTHORIZED)
+	}
+	user, err := s.userFromParam(c)
+	if err != nil {
+		return err
+	}
+	format := c.QueryParam("format")
+	if format == "" {
+		format = EXPORT_FORMAT_PGN
+		if strings.Contains(c.Request().Header.Get(echo.HeaderAccept), "application/x-ndjson") {
+			format = EXPORT_FORMAT_NDJSON
+		}
+	}
+	if format != EXPORT_FORMAT_PGN && format != EXPORT_FORMAT_NDJSON {
+		return c.JSON(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, "format must be pgn or ndjson"))
+	}
+	params := db.ListGamesOfPlayerAfterParams{
+		Uid:     user.Uid,
+		Until:   time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC),
+		Color:   c.QueryParam("color"),
+		Outcome: c.QueryParam("result"),
+	}
+	if params.Color != "" && params.Color != "white" && params.Color != "black" {
+		return c.JSON(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, "color must be white or black"))
+	}
+	if params.Outcome != "" && params.Outcome != "win" && params.Outcome != "loss" && params.Outcome != "draw" {
+		return c.JSON(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, "result must be win, loss or draw"))
+	}
+	if since := c.QueryParam("since"); since != "" {
+		if params.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			return c.JSON(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, "since must be an RFC 3339 time"))
+		}
+	}
+	if until := c.QueryParam("until"); until != "" {
+		if params.Until, err = time.Parse(time.RFC3339, until); err != nil {
+			return c.JSON(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, "until must be an RFC 3339 time"))
+		}
+	}
+	// finished_at is stored in UTC
+	params.Since, params.Until = params.Since.UTC(), params.Until.UTC()
+	if after := c.QueryParam("after"); after != "" {
+		if params.AfterID, err = strconv.ParseInt(after, 10, 64); err != nil || params.AfterID < 0 {
+			return c.JSON(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, "after must be a game id"))
+		}
+	}
+
+	contentType := "application/x-chess-pgn"
+	if format == EXPORT_FORMAT_NDJSON {
+		contentType = "application/x-ndjson"
+	}
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, contentType)
+	w.WriteHeader(http.StatusOK)
+	if err := s.streamGames(c.Request().Context(), w, format, params); err != nil {
+		// the status was sent already, the client notices the missing games by the last id
+		slog.Warn("game export ended early", "username", user.Username, "error", err)
+	}
+	return nil
+}
+
+// Upgrade a guest account into a full account, keeping its game history.
+//
+//	@Summary		Upgrade a guest account into a full account.
+//	@Description	Guests can pick a username and password to keep their account and game history.
+//	@Description	Username can be between 3-20 characters.
+//	@Description	Password must be at least 3 characters.
+//	@Description	The old guest API key stops working, use the returned key instead.
+//
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string			true	"Must contain ApiKey of a guest in the format Bearer: apiKey"
+//	@Param			payload			body		UserCredentials	true	"New account credentials"
+//	@Success		200				{object}	ApiKeyResponse	"Api Key"
+//	@Failure		400				{object}	ErrorReason		"Invalid credentials / not a guest"
+//	@Failure		401				{object}	ErrorReason
+//	@Failure		409				{object}	ErrorReason	"Username already exists"
+//	@Failure		500				{object}	ErrorReason
+//	@Router			/users/upgrade [post]
+func (s Server) UpgradeGuestAccount(c echo.Context) error {
+	username := usernameOf(c)
+	if username == "" {
+		return c.JSON(http.StatusUnauthorized, REASON_UNAUTHORIZED)
+	}
+	if !isGuest(c) {
+		return c.JSON(http.StatusBadRequest, Reason(CODE_NOT_A_GUEST, "Only guest accounts can be upgraded"))
+	}
+	var req UserCredentials
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	if s.WordFilter.Contains(req.Username) {
+		return c.JSON(http.StatusBadRequest, Reason(CODE_USERNAME_NOT_ALLOWED, USERNAME_NOT_ALLOWED_ERROR))
+	}
+	// guests may keep their generated username
+	if req.Username != username {
+		_, err := s.DB.GetUserByUsername(c.Request().Context(), req.Username)
+		if err == nil {
+			return c.JSON(http.StatusConflict, Reason(CODE_USERNAME_TAKEN, "Username already exists"))
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("failed to check username", "username", req.Username, "error", err)
+			return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+		}
+	}
+	guest, err := s.DB.GetUserByUsername(c.Request().Context(), username)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	passwordHash, err := s.Passwords.Hash(req.Password)
+	if err != nil {
+		slog.Error("Failed to hash password", "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	// uid stays the same, so games played as a guest are kept.
+	user, err := s.DB.UpgradeGuestUser(c.Request().Context(), db.UpgradeGuestUserParams{
+		Username:     req.Username,
+		PasswordHash: passwordHash,
+		ApiKey:       s.newApiKey(req.Username),
+		Uid:          guest.Uid,
+	})
+	// someone registered the username since it was checked
+	if isUniqueViolation(err) {
+		return c.JSON(http.StatusConflict, Reason(CODE_USERNAME_TAKEN, "Username already exists"))
+	}
+	if err != nil {
+		slog.Error("failed to upgrade guest user", "username", username, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	s.audit(c, AUDIT_ACCOUNT_UPGRADED, user.Username, username, "")
+	return c.JSON(http.StatusOK, ApiKeyResponse{user.ApiKey})
+}
+
+// isUniqueViolation is true if err is from a UNIQUE constraint failing
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
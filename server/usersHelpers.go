@@ -2,52 +2,65 @@ package server
 
 import (
 	"api/db"
-	"errors"
-	"fmt"
+	"net/http"
 	"regexp"
+
+	"github.com/labstack/echo/v4"
 )
 
 var usernameRegex = regexp.MustCompile(`^[a-zA-Z0-9_]*$`)
 
-func ValidatePassword(password string) error {
-	if len([]rune(password)) < 3 {
-		return fmt.Errorf("password must be at least 3 characters")
+const INVALID_USERNAME_ERROR = "username can only contain letters, numbers, and underscores"
+const USERNAME_NOT_ALLOWED_ERROR = "username is not allowed"
+
+func UserFromDbUser(user db.User) User {
+	return User{
+		UserID:    user.Uid,
+		Username:  user.Username,
+		CreatedAt: user.CreatedAt,
+		IsBot:     user.IsBot,
 	}
-	return nil
 }
 
-const INVALID_USERNAME_ERROR = "username can only contain letters, numbers, and underscores"
+// usernameOf is the username set by AuthApiKeyMiddleware, empty if the request has no api key
+// or the middleware did not run.
+func usernameOf(c echo.Context) string {
+	username, _ := c.Get("username").(string)
+	return username
+}
 
-func ValidateUsername(username string) error {
-	length := len([]rune(username))
-	if length < 3 {
-		return errors.New("username must be at least 3 characters long")
-	}
-	if length > 20 {
-		return errors.New("username cannot be longer than 20 characters")
-	}
-	if !usernameRegex.MatchString(username) {
-		return errors.New(INVALID_USERNAME_ERROR)
-	}
-	return nil
+// isGuest is true if AuthApiKeyMiddleware found a guest's api key
+func isGuest(c echo.Context) bool {
+	guest, _ := c.Get("guest").(bool)
+	return guest
 }
 
-func ValidateUsernameAndPassword(username, password string) error {
+// isAdmin is true if AuthApiKeyMiddleware found an admin's api key
+func isAdmin(c echo.Context) bool {
+	admin, _ := c.Get("admin").(bool)
+	return admin
+}
 
-	// validate username
-	if err := ValidateUsername(username); err != nil {
-		return err
+// currentUser gets the authorized user from the database.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (s Server) currentUser(c echo.Context) (db.User, error) {
+	username := usernameOf(c)
+	if username == "" {
+		return db.User{}, echo.NewHTTPError(http.StatusUnauthorized, REASON_UNAUTHORIZED)
 	}
-	// validate password
-	if err := ValidatePassword(password); err != nil {
-		return err
+	user, err := s.DB.GetUserByUsername(c.Request().Context(), username)
+	if err != nil {
+		return db.User{}, echo.NewHTTPError(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
 	}
-	return nil
+	return user, nil
 }
-func UserFromDbUser(user db.User) User {
-	return User{
-		UserID:    user.Uid,
-		Username:  user.Username,
-		CreatedAt: user.CreatedAt,
+
+// userFromParam gets the user named by the :username path parameter.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func (s Server) userFromParam(c echo.Context) (db.User, error) {
+	user, err := s.DB.GetUserByUsername(c.Request().Context(), c.Param("username"))
+	if err != nil || user.DeletedAt.Valid {
+		return db.User{}, echo.NewHTTPError(http.StatusNotFound, Reason(CODE_USER_NOT_FOUND, "User not found"))
 	}
+	return user, nil
 }
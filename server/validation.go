@@ -0,0 +1,128 @@
+// validating request bodies with struct tags
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+// a move in UCI notation, like e2e4 or e7e8q
+var uciRegex = regexp.MustCompile(`^[a-h][1-8][a-h][1-8][qrbn]?$`)
+
+// FieldError explains why a field of the request body is invalid
+type FieldError struct {
+	// json name of the field
+	Field  string `json:"field" example:"duration"`
+	Reason string `json:"reason" example:"must be at most 12"`
+}
+
+// RequestValidator checks the `validate` struct tags of request bodies.
+// Besides the built in validations it knows username, uci, notblank, boardtheme and pieceset.
+type RequestValidator struct {
+	validate *validator.Validate
+}
+
+func NewRequestValidator() *RequestValidator {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	// report fields by the name clients use
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	v.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+		return usernameRegex.MatchString(fl.Field().String())
+	})
+	v.RegisterValidation("uci", func(fl validator.FieldLevel) bool {
+		return uciRegex.MatchString(fl.Field().String())
+	})
+	v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+	v.RegisterValidation("boardtheme", func(fl validator.FieldLevel) bool {
+		_, ok := BOARD_THEMES[fl.Field().String()]
+		return ok
+	})
+	v.RegisterValidation("pieceset", func(fl validator.FieldLevel) bool {
+		return slices.Contains(PIECE_SETS, fl.Field().String())
+	})
+	return &RequestValidator{validate: v}
+}
+
+// Validate returns an *echo.HTTPError listing every invalid field.
+// Its code is INVALID_INPUT, or INVALID_MOVE_NOTATION when a move is not in UCI notation.
+func (rv *RequestValidator) Validate(i any) error {
+	err := rv.validate.Struct(i)
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return err
+	}
+	reason := Reason(CODE_INVALID_INPUT, "invalid fields in body")
+	for _, fe := range invalid {
+		// the same code as playing a move in the wrong notation, so clients handle both alike
+		if fe.Tag() == "uci" {
+			reason.Code = CODE_INVALID_MOVE_NOTATION
+		}
+		reason.Fields = append(reason.Fields, FieldError{Field: fe.Field(), Reason: fieldErrorReason(fe)})
+	}
+	return echo.NewHTTPError(http.StatusBadRequest, reason)
+}
+
+func fieldErrorReason(fe validator.FieldError) string {
+	// strings are measured in characters, numbers by value
+	unit := ""
+	if fe.Kind() == reflect.String {
+		unit = " characters"
+	}
+	switch fe.Tag() {
+	case "required", "notblank":
+		return "is required"
+	case "min":
+		if unit != "" {
+			return fmt.Sprintf("must be at least %s%s long", fe.Param(), unit)
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if unit != "" {
+			return fmt.Sprintf("cannot be longer than %s%s", fe.Param(), unit)
+		}
+		return "must be at most " + fe.Param()
+	case "len":
+		return fmt.Sprintf("must be exactly %s%s long", fe.Param(), unit)
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "username":
+		return INVALID_USERNAME_ERROR
+	case "http_url":
+		return "must be an http or https URL"
+	case "alphanum":
+		return "can only contain letters and digits"
+	case "uci":
+		return "must be a move in UCI notation, like e2e4 or e7e8q"
+	case "boardtheme":
+		return "unknown board theme"
+	case "pieceset":
+		return "unknown piece set"
+	default:
+		return "is invalid"
+	}
+}
+
+// bindAndValidate binds the request body to req and validates it.
+// The returned error is an *echo.HTTPError that can be returned from the handler.
+func bindAndValidate(c echo.Context, req any) error {
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, REASON_JSON_SYNTAX_ERROR)
+	}
+	return c.Validate(req)
+}
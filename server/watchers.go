@@ -0,0 +1,98 @@
+// streams of the moves of matches, for people watching them and for streams that send every move
+package server
+
+import (
+	"api/server/game"
+	"sync"
+)
+
+// updates a watcher can fall behind by before their stream is closed
+const WATCH_BUFFER_SIZE = 32
+
+// MatchUpdate is sent to the people watching a match. Moves set Ply, Move and FEN, the end of the game sets Result.
+type MatchUpdate struct {
+	// 1 for white's first move
+	Ply       int
+	Move, FEN string
+	// like 1-0, empty until the game is over
+	Result string
+}
+
+// MatchWatchers delivers the moves of matches played on this server to the people watching them.
+type MatchWatchers struct {
+	mu sync.Mutex
+	// match id -> open streams
+	streams map[string]map[chan MatchUpdate]struct{}
+	// match id -> streams opened by spectators, the players' own streams are not counted
+	spectators map[string]int
+}
+
+func NewMatchWatchers() *MatchWatchers {
+	return &MatchWatchers{
+		streams:    map[string]map[chan MatchUpdate]struct{}{},
+		spectators: map[string]int{},
+	}
+}
+
+// Subscribe opens a stream of the match with id matchID. The returned function closes it.
+// The stream's channel is closed if the watcher falls too far behind, they missed updates and have to watch again.
+func (h *MatchWatchers) Subscribe(matchID string) (updates chan MatchUpdate, unsubscribe func()) {
+	updates = make(chan MatchUpdate, WATCH_BUFFER_SIZE)
+	h.mu.Lock()
+	if h.streams[matchID] == nil {
+		h.streams[matchID] = map[chan MatchUpdate]struct{}{}
+	}
+	h.streams[matchID][updates] = struct{}{}
+	h.mu.Unlock()
+	return updates, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(matchID, updates)
+	}
+}
+
+// spectate adds delta to the spectators of the match and returns how many there are now
+func (h *MatchWatchers) spectate(matchID string, delta int) (count int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	count = h.spectators[matchID] + delta
+	if count <= 0 {
+		delete(h.spectators, matchID)
+		return 0
+	}
+	h.spectators[matchID] = count
+	return count
+}
+
+// Publish sends u to every stream of the match without blocking. Streams that are full are closed.
+func (h *MatchWatchers) Publish(matchID string, u MatchUpdate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for stream := range h.streams[matchID] {
+		select {
+		case stream <- u:
+		default:
+			h.remove(matchID, stream)
+			close(stream)
+		}
+	}
+}
+
+// remove forgets stream, h.mu must be held
+func (h *MatchWatchers) remove(matchID string, stream chan MatchUpdate) {
+	delete(h.streams[matchID], stream)
+	if len(h.streams[matchID]) == 0 {
+		delete(h.streams, matchID)
+	}
+}
+
+// watch subscribes a spectator to the match and tells its players, who see how many people watch it.
+// The returned function stops watching.
+func (s Server) watch(m *game.Match) (updates chan MatchUpdate, unwatch func()) {
+	updates, unsubscribe := s.Watchers.Subscribe(m.ID)
+	m.Spectated(true, s.Watchers.spectate(m.ID, 1))
+	return updates, func() {
+		unsubscribe()
+		m.Spectated(false, s.Watchers.spectate(m.ID, -1))
+	}
+}
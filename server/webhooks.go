@@ -0,0 +1,496 @@
+// sending match events to URLs registered by users
+package server
+
+import (
+	"api/db"
+	"api/server/game"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/notnil/chess"
+)
+
+const (
+	// webhooks a user can register
+	MAX_WEBHOOKS_PER_USER = 10
+	// how long a webhook gets to respond
+	WEBHOOK_TIMEOUT = 5 * time.Second
+	// attempts to deliver an event before giving up
+	WEBHOOK_MAX_ATTEMPTS = 5
+	// wait before the second attempt, doubled after every failed attempt
+	WEBHOOK_RETRY_BACKOFF = 2 * time.Second
+	// events waiting to be sent, more are dropped
+	WEBHOOK_QUEUE_SIZE = 1000
+	// goroutines looking up the webhooks of queued events
+	WEBHOOK_WORKERS = 4
+	// requests to webhooks at the same time
+	WEBHOOK_CONCURRENCY = 16
+	// how long delivery logs are kept
+	WEBHOOK_DELIVERY_RETENTION = 7 * 24 * time.Hour
+)
+
+// WebhookEventType is sent in the X-Webhook-Event header and the event field of deliveries
+type WebhookEventType string
+
+const (
+	// the second player joined
+	WebhookGameStart WebhookEventType = "gameStart"
+	WebhookMove      WebhookEventType = "move"
+	// checkmate, draw, resignation or a result decided by an admin
+	WebhookGameOver WebhookEventType = "gameOver"
+)
+
+// WebhookEvent is the JSON body POSTed to webhooks
+type WebhookEvent struct {
+	Event   WebhookEventType `json:"event" example:"move"`
+	MatchID string           `json:"matchId" example:"AB2C21"`
+	White   string           `json:"white" example:"JohnDoe"`
+	Black   string           `json:"black" example:"JaneDoe"`
+	// move events only, 1 for white's first move
+	Ply int `json:"ply,omitempty" example:"1"`
+	// move events only, in UCI notation
+	Move string `json:"move,omitempty" example:"e2e4"`
+	// position after the move, move events only
+	FEN string `json:"fen,omitempty" example:"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"`
+	// gameOver events only
+	Result    string    `json:"result,omitempty" example:"1-0"`
+	Timestamp time.Time `json:"timestamp" format:"date-time"`
+}
+
+type webhookJob struct {
+	match *game.Match
+	event WebhookEvent
+}
+
+// WebhookDispatcher POSTs match events to the webhooks of the players.
+// Events are queued, so the match doesn't wait for the database or the webhooks.
+// Failed deliveries are retried with backoff, every attempt is logged in webhook_deliveries.
+// Deliveries can arrive out of order, the ply of move events orders them.
+// Events that are still queued or being retried when the server stops are lost.
+type WebhookDispatcher struct {
+	// webhooks may use loopback and private network addresses, for local development.
+	// Must be set before any events are sent.
+	AllowPrivateAddresses bool
+
+	db     *db.Queries
+	client *http.Client
+	queue  chan webhookJob
+	// limits requests to webhooks
+	sending chan struct{}
+	// events that did not fit in the queue
+	dropped atomic.Int64
+}
+
+func NewWebhookDispatcher(queries *db.Queries) *WebhookDispatcher {
+	d := &WebhookDispatcher{
+		db:      queries,
+		queue:   make(chan webhookJob, WEBHOOK_QUEUE_SIZE),
+		sending: make(chan struct{}, WEBHOOK_CONCURRENCY),
+	}
+	dialer := &net.Dialer{Timeout: WEBHOOK_TIMEOUT, Control: d.checkAddress}
+	d.client = &http.Client{
+		Timeout: WEBHOOK_TIMEOUT,
+		// no proxy, it would be the one dialed and checked
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+		// a redirect could lead anywhere, it counts as a failed delivery
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	for range WEBHOOK_WORKERS {
+		go d.work()
+	}
+	return d
+}
+
+// checkAddress keeps webhooks from reaching the server's own network, unless AllowPrivateAddresses is set.
+// It runs after DNS resolution, so a public name pointing at a private address is refused too.
+func (d *WebhookDispatcher) checkAddress(network, address string, _ syscall.RawConn) error {
+	if d.AllowPrivateAddresses {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("webhooks cannot be sent to %s", host)
+	}
+	return nil
+}
+
+// Send queues an event of m without blocking. The players are filled in later, so Send can be
+// called from the game storage's hooks.
+func (d *WebhookDispatcher) Send(m *game.Match, e WebhookEvent) {
+	e.MatchID = m.ID
+	e.Timestamp = time.Now().UTC()
+	select {
+	case d.queue <- webhookJob{match: m, event: e}:
+	default:
+		d.dropped.Add(1)
+		slog.Warn("webhook queue is full, dropped event", "match", m.ID, "event", e.Event)
+	}
+}
+
+// Dropped is the number of events that did not fit in the queue, since the server started.
+func (d *WebhookDispatcher) Dropped() int64 {
+	return d.dropped.Load()
+}
+
+func (d *WebhookDispatcher) work() {
+	for job := range d.queue {
+		d.dispatch(job)
+	}
+}
+
+// dispatch starts delivering an event to every webhook interested in it
+func (d *WebhookDispatcher) dispatch(job webhookJob) {
+	e := job.event
+	for _, p := range job.match.Players() {
+		if p.Color == chess.White {
+			e.White = p.Username
+		} else {
+			e.Black = p.Username
+		}
+	}
+	ctx := context.Background()
+	hooks, err := d.db.ListWebhooksForMatch(ctx, db.ListWebhooksForMatchParams{
+		White:   e.White,
+		Black:   e.Black,
+		MatchID: e.MatchID,
+	})
+	if err != nil {
+		slog.Error("failed to list webhooks", "match", e.MatchID, "error", err)
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		slog.Error("failed to encode webhook event", "error", err)
+		return
+	}
+	for _, hook := range hooks {
+		go d.deliver(ctx, hook, e.Event, body)
+	}
+}
+
+// deliver POSTs body to hook until it succeeds or WEBHOOK_MAX_ATTEMPTS attempts failed
+func (d *WebhookDispatcher) deliver(ctx context.Context, hook db.Webhook, event WebhookEventType, body []byte) {
+	// receivers can tell retries of one event apart from new events
+	deliveryID := rand.Text()
+	backoff := WEBHOOK_RETRY_BACKOFF
+	for attempt := 1; attempt <= WEBHOOK_MAX_ATTEMPTS; attempt++ {
+		d.sending <- struct{}{}
+		start := time.Now()
+		status, err := d.post(ctx, hook, event, deliveryID, body)
+		took := time.Since(start)
+		<-d.sending
+
+		errText := ""
+		if err != nil {
+			errText = err.Error()
+		}
+		logErr := d.db.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+			WebhookID:  hook.ID,
+			DeliveryID: deliveryID,
+			Event:      string(event),
+			Attempt:    int64(attempt),
+			StatusCode: int64(status),
+			Error:      errText,
+			DurationMs: took.Milliseconds(),
+		})
+		if logErr != nil {
+			slog.Warn("failed to log webhook delivery", "webhook", hook.ID, "error", logErr)
+		}
+		if err == nil {
+			return
+		}
+		if attempt < WEBHOOK_MAX_ATTEMPTS {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	slog.Info("gave up delivering webhook", "webhook", hook.ID, "event", event, "delivery", deliveryID)
+}
+
+// post sends one attempt. status is 0 if there was no response.
+func (d *WebhookDispatcher) post(ctx context.Context, hook db.Webhook, event WebhookEventType, deliveryID string, body []byte) (status int, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "chess-api-webhooks")
+	req.Header.Set("X-Webhook-Event", string(event))
+	req.Header.Set("X-Webhook-Delivery", deliveryID)
+	req.Header.Set("X-Webhook-Signature", webhookSignature(hook.Secret, body))
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// webhookSignature is the hex HMAC-SHA256 of body with the webhook's secret, prefixed with "sha256="
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// gameStarted is the game storage's OnStart hook
+func (s Server) gameStarted(m *game.Match) {
+	s.Webhooks.Send(m, WebhookEvent{Event: WebhookGameStart})
+}
+
+// moved is the game storage's OnMove hook
+func (s Server) moved(m *game.Match, ply int, move, fen string) {
+	s.MoveLog.Add(m, ply, move)
+	s.Watchers.Publish(m.ID, MatchUpdate{Ply: ply, Move: move, FEN: fen})
+	s.Webhooks.Send(m, WebhookEvent{Event: WebhookMove, Ply: ply, Move: move, FEN: fen})
+	s.simulMoved(m, ply)
+	if m.Rated {
+		s.MoveClock.Add(m.ID, ply)
+	}
+}
+
+// Webhook is a URL that receives match events
+type Webhook struct {
+	ID  int64  `json:"id" example:"1"`
+	URL string `json:"url" example:"https://example.com/chess"`
+	// only events of this match are sent, empty for every match
+	MatchID   string    `json:"matchId,omitempty" example:"AB2C21"`
+	CreatedAt time.Time `json:"createdAt" format:"date-time"`
+}
+
+func webhookFromDb(hook db.Webhook) Webhook {
+	return Webhook{ID: hook.ID, URL: hook.Url, MatchID: hook.MatchID, CreatedAt: hook.CreatedAt}
+}
+
+// CreatedWebhook is a new webhook with its secret, which is only shown once
+type CreatedWebhook struct {
+	Webhook
+	// key of the HMAC-SHA256 in the X-Webhook-Signature header of deliveries
+	Secret string `json:"secret" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+type CreateWebhookRequest struct {
+	URL string `json:"url" example:"https://example.com/chess" validate:"required,http_url,max=500"`
+	// only send events of this match, empty for every match
+	MatchID string `json:"matchId" example:"AB2C21" validate:"omitempty,len=6,alphanum"`
+}
+
+// WebhookDelivery is one attempt to deliver an event to a webhook
+type WebhookDelivery struct {
+	// the same for every attempt to deliver one event, sent in the X-Webhook-Delivery header
+	DeliveryID string `json:"deliveryId" example:"7XQ3LJ2ZC5N4K6PWM4RAV2Y3HT"`
+	Event      string `json:"event" example:"move"`
+	Attempt    int64  `json:"attempt" example:"1"`
+	// 0 when there was no response
+	StatusCode int64 `json:"statusCode" example:"200"`
+	// empty when the delivery succeeded
+	Error      string    `json:"error,omitempty" example:"webhook responded with 500 Internal Server Error"`
+	DurationMs int64     `json:"durationMs" example:"84"`
+	CreatedAt  time.Time `json:"createdAt" format:"date-time"`
+}
+
+// @Summary		Register a webhook
+// @Description	Match events of your matches are POSTed to the URL as JSON: `gameStart`, `move` and `gameOver`.
+// @Description	The `X-Webhook-Signature` header is `sha256=` followed by the hex HMAC-SHA256 of the body, keyed with the secret in the response. The secret is only shown once.
+// @Description	`X-Webhook-Event` is the event and `X-Webhook-Delivery` identifies it, it stays the same when a failed delivery is retried.
+// @Description	Responses other than 2xx are retried up to 5 times with backoff. Deliveries can arrive out of order, `ply` orders moves.
+// @Tags			webhooks
+// @Accept			json
+// @Produce		json
+// @Param			Authorization	header		string					true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			payload			body		CreateWebhookRequest	true	"URL, and optionally the one match to send events of"
+// @Success		201				{object}	CreatedWebhook
+// @Failure		400				{object}	ErrorReason	"Invalid json body"
+// @Failure		401				{object}	ErrorReason
+// @Failure		429				{object}	ErrorReason	"Too many webhooks"
+// @Failure		500				{object}	ErrorReason
+// @Router			/users/me/webhooks [post]
+func (s Server) CreateWebhook(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	var req CreateWebhookRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	count, err := s.DB.CountWebhooksOfUser(ctx, user.Uid)
+	if err != nil {
+		slog.Error("failed to count webhooks", "username", user.Username, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	if count >= MAX_WEBHOOKS_PER_USER {
+		return c.JSON(http.StatusTooManyRequests, Reason(CODE_TOO_MANY_WEBHOOKS, fmt.Sprintf("You can have at most %d webhooks", MAX_WEBHOOKS_PER_USER)))
+	}
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	hook, err := s.DB.CreateWebhook(ctx, db.CreateWebhookParams{
+		Uid:     user.Uid,
+		Url:     req.URL,
+		Secret:  hex.EncodeToString(secret),
+		MatchID: req.MatchID,
+	})
+	if err != nil {
+		slog.Error("failed to create webhook", "username", user.Username, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	return c.JSON(http.StatusCreated, CreatedWebhook{Webhook: webhookFromDb(hook), Secret: hook.Secret})
+}
+
+// @Summary	List your webhooks
+// @Tags		webhooks
+// @Produce	json
+// @Param		Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Success	200				{array}		Webhook
+// @Failure	401				{object}	ErrorReason
+// @Failure	500				{object}	ErrorReason
+// @Router		/users/me/webhooks [get]
+func (s Server) ListWebhooks(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	hooks, err := s.DB.ListWebhooksOfUser(c.Request().Context(), user.Uid)
+	if err != nil {
+		slog.Error("failed to list webhooks", "username", user.Username, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	webhooks := make([]Webhook, 0, len(hooks))
+	for _, hook := range hooks {
+		webhooks = append(webhooks, webhookFromDb(hook))
+	}
+	return c.JSON(http.StatusOK, webhooks)
+}
+
+// @Summary		Delete a webhook
+// @Description	Its delivery log is deleted with it.
+// @Tags			webhooks
+// @Produce		json
+// @Param			Authorization	header		string		true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		int			true	"Webhook ID"
+// @Success		200				{object}	string		"deleted"
+// @Failure		400				{object}	ErrorReason	"Invalid webhook id"
+// @Failure		401				{object}	ErrorReason
+// @Failure		404				{object}	ErrorReason	"Webhook not found"
+// @Failure		500				{object}	ErrorReason
+// @Router			/users/me/webhooks/{id} [delete]
+func (s Server) DeleteWebhook(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, "invalid webhook id"))
+	}
+	deleted, err := s.DB.DeleteWebhook(c.Request().Context(), db.DeleteWebhookParams{ID: id, Uid: user.Uid})
+	if err != nil {
+		slog.Error("failed to delete webhook", "username", user.Username, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	if deleted == 0 {
+		return c.JSON(http.StatusNotFound, Reason(CODE_WEBHOOK_NOT_FOUND, "webhook not found"))
+	}
+	return c.JSON(http.StatusOK, "deleted")
+}
+
+// @Summary		List deliveries of a webhook
+// @Description	Every attempt to deliver an event, newest first. Deliveries are kept for 7 days.
+// @Tags			webhooks
+// @Produce		json
+// @Param			Authorization	header		string	true	"Must contain ApiKey in the format Bearer: apiKey"
+// @Param			id				path		int		true	"Webhook ID"
+// @Param			limit			query		int		false	"default 50, max 500"
+// @Param			offset			query		int		false	"default 0"
+// @Success		200				{array}		WebhookDelivery
+// @Failure		400				{object}	ErrorReason	"Invalid webhook id / query"
+// @Failure		401				{object}	ErrorReason
+// @Failure		404				{object}	ErrorReason	"Webhook not found"
+// @Failure		500				{object}	ErrorReason
+// @Router			/users/me/webhooks/{id}/deliveries [get]
+func (s Server) ListWebhookDeliveries(c echo.Context) error {
+	user, err := s.currentUser(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Reason(CODE_INVALID_INPUT, "invalid webhook id"))
+	}
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	hook, err := s.DB.GetWebhookOfUser(ctx, db.GetWebhookOfUserParams{ID: id, Uid: user.Uid})
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(http.StatusNotFound, Reason(CODE_WEBHOOK_NOT_FOUND, "webhook not found"))
+	} else if err != nil {
+		slog.Error("failed to get webhook", "username", user.Username, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	rows, err := s.DB.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{
+		WebhookID: hook.ID,
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		slog.Error("failed to list webhook deliveries", "webhook", hook.ID, "error", err)
+		return c.JSON(http.StatusInternalServerError, REASON_INTERNAL_ERROR)
+	}
+	deliveries := make([]WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		deliveries = append(deliveries, WebhookDelivery{
+			DeliveryID: row.DeliveryID,
+			Event:      row.Event,
+			Attempt:    row.Attempt,
+			StatusCode: row.StatusCode,
+			Error:      row.Error,
+			DurationMs: row.DurationMs,
+			CreatedAt:  row.CreatedAt,
+		})
+	}
+	return c.JSON(http.StatusOK, deliveries)
+}
+
+// purgeOldWebhookDeliveries deletes delivery logs older than WEBHOOK_DELIVERY_RETENTION
+func (s Server) purgeOldWebhookDeliveries(ctx context.Context) {
+	deleted, err := s.DB.DeleteOldWebhookDeliveries(ctx, time.Now().UTC().Add(-WEBHOOK_DELIVERY_RETENTION))
+	if err != nil {
+		slog.Warn("failed to delete old webhook deliveries", "error", err)
+	} else if deleted > 0 {
+		slog.Info("deleted old webhook deliveries", "count", deleted)
+	}
+}
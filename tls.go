@@ -0,0 +1,45 @@
+// serving HTTP or HTTPS
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// startServer serves e in the background, over HTTPS if a certificate or autocert domains are configured.
+// With autocert, it also starts an HTTP server for ACME challenges, which is returned so it can be shut down.
+func startServer(e *echo.Echo, config Config) (challengeServer *http.Server) {
+	serve := func() error { return e.Start(config.Addr) }
+	switch {
+	case config.TLSCertFile != "":
+		serve = func() error { return e.StartTLS(config.Addr, config.TLSCertFile, config.TLSKeyFile) }
+	case len(config.AutocertDomains) > 0:
+		e.AutoTLSManager = autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(config.AutocertDomains...),
+			Cache:      autocert.DirCache(config.AutocertCacheDir),
+			Email:      config.AutocertEmail,
+		}
+		// answers http-01 challenges and redirects everything else to HTTPS
+		challengeServer = &http.Server{
+			Addr:    config.AutocertHTTPAddr,
+			Handler: e.AutoTLSManager.HTTPHandler(nil),
+		}
+		go func() {
+			if err := challengeServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatal("ACME challenge server shutdown", err)
+			}
+		}()
+		serve = func() error { return e.StartAutoTLS(config.Addr) }
+	}
+	go func() {
+		if err := serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Server shutdown", err)
+		}
+	}()
+	return challengeServer
+}